@@ -2,71 +2,337 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"google.golang.org/grpc"
 
 	"github.com/turboline-ai/turbostream/go-backend/internal/config"
 	"github.com/turboline-ai/turbostream/go-backend/internal/db"
 	transport "github.com/turboline-ai/turbostream/go-backend/internal/http"
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+	"github.com/turboline-ai/turbostream/go-backend/internal/tracing"
+	pb "github.com/turboline-ai/turbostream/go-backend/pkg/proto"
 )
 
 func main() {
-	cfg := config.Load()
+	checkConfig := flag.Bool("check-config", false, "print the effective configuration with secrets masked, validate it and exit")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if *checkConfig {
+		_ = config.WriteReport(os.Stdout, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\n✗ invalid configuration:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("\n✓ configuration is valid")
+		return
+	}
+	// slog.SetDefault also routes the standard log package through the handler
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat))
+	if err != nil {
+		fatal("invalid configuration (run with --check-config to see every setting)", err)
+	}
+	if cfg.UsesDefaultJWTSecret() {
+		slog.Warn("using the default JWT_SECRET, which is insecure for production")
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+	if cfg.OTelEndpoint != "" {
+		slog.Info("tracing enabled", "endpoint", cfg.OTelEndpoint, "sample_ratio", cfg.OTelSampleRatio)
+	}
+
+	// MongoDB is required with every STORAGE_DRIVER: SQL storage only takes
+	// over users, feeds, subscriptions, sessions and the usage ledger, and
+	// everything else (login activity, email tokens, security keys, history,
+	// alerts, the audit log and so on) has no SQL implementation
 	mongoClient := db.New(cfg.MongoURI, cfg.MongoDatabase)
 	if err := mongoClient.Connect(ctx); err != nil {
-		log.Fatalf("failed to connect to MongoDB: %v", err)
+		fatal(mongoRequired(cfg, "failed to connect to MongoDB"), err)
 	}
 	if err := mongoClient.Raw.Ping(ctx, readpref.Primary()); err != nil {
-		log.Fatalf("failed to ping MongoDB: %v", err)
+		fatal(mongoRequired(cfg, "failed to ping MongoDB"), err)
+	}
+	slog.Info("MongoDB connected", "database", cfg.MongoDatabase)
+
+	// Index builds on large collections can outlast the startup timeout
+	migrateCtx, migrateCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	if applied, err := db.Migrate(migrateCtx, mongoClient.Db, db.Migrations); err != nil {
+		slog.Warn("failed to apply database migrations", "applied", applied, "error", err)
+	} else if applied > 0 {
+		slog.Info("database migrations applied", "count", applied)
+	}
+	migrateCancel()
+
+	// Users, feeds, subscriptions, sessions and the usage ledger can live in
+	// a SQL database instead; everything else stays in MongoDB
+	store := services.NewMongoStore(mongoClient.Db)
+	var sqlDB *sql.DB
+	if cfg.StorageDriver != "mongodb" {
+		if sqlDB, err = db.OpenSQL(ctx, cfg.StorageDriver, cfg.StorageDSN); err != nil {
+			fatal("failed to connect to SQL storage", err)
+		}
+		migrateCtx, migrateCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		applied, err := db.MigrateSQL(migrateCtx, sqlDB, db.SQLMigrations)
+		migrateCancel()
+		if err != nil {
+			fatal("failed to apply SQL storage migrations", err)
+		}
+		store = services.NewSQLStore(sqlDB, cfg.StorageDriver)
+		slog.Info("SQL storage enabled", "driver", cfg.StorageDriver, "migrations_applied", applied)
 	}
-	log.Println("✓ MongoDB connected")
 
 	authService := services.NewAuthService(cfg, mongoClient.Raw, mongoClient.Db)
-	marketplaceService := services.NewMarketplaceService(mongoClient.Db)
+	authService.SetStore(store)
+	if providers := authService.OAuthProviders(); len(providers) > 0 {
+		slog.Info("OAuth sign-in enabled", "providers", providers)
+	}
+	emailService := services.NewEmailService(cfg)
+	if emailService.Enabled() {
+		slog.Info("email delivery enabled", "provider", emailService.Provider())
+	}
+	authService.SetEmailService(emailService)
+	if err := authService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create email token and security key indexes", "error", err)
+	}
+	marketplaceService := services.NewMarketplaceServiceWithRepos(store.Feeds, store.Subscriptions)
+	marketplaceService.SetFeedSecrets(services.NewFeedSecrets(cfg.EncryptionKey))
+	if n, err := marketplaceService.SealStoredSecrets(ctx); err != nil {
+		slog.Warn("failed to encrypt stored feed secrets", "error", err)
+	} else if n > 0 {
+		slog.Info("encrypted stored feed secrets", "feeds", n)
+	}
+	if cfg.DemoFeeds {
+		if n, err := marketplaceService.EnsureDemoFeeds(ctx); err != nil {
+			slog.Warn("failed to seed demo feeds", "error", err)
+		} else if n > 0 {
+			slog.Info("seeded demo feeds", "feeds", n)
+		}
+	}
 	settingsService := services.NewSettingsService(mongoClient.Db)
 	azureService := services.NewAzureOpenAI(cfg)
 
 	// Initialize LLM service with LangChain Go
 	llmService, err := services.NewLLMService(cfg)
 	if err != nil {
-		log.Printf("⚠️  failed to initialize LLM service: %v", err)
+		slog.Warn("failed to initialize LLM service", "error", err)
 	} else if llmService.Enabled() {
-		log.Printf("✓ LLM service initialized with providers: %v", llmService.GetAvailableProviders())
+		slog.Info("LLM service initialized", "providers", llmService.GetAvailableProviders())
 	} else {
-		log.Printf("⚠️  No LLM providers configured - AI features disabled")
+		slog.Warn("no LLM providers configured, AI features disabled")
+	}
+
+	// Restore persisted feed contexts so AI analysis has data straight after a restart
+	var contextStore *services.FeedContextStore
+	if llmService != nil && cfg.ContextSnapshotInterval > 0 {
+		contextStore = services.NewFeedContextStore(mongoClient.Db)
+		if restored, err := contextStore.Restore(ctx, llmService, cfg.ContextSnapshotMaxAge); err != nil {
+			slog.Warn("failed to restore feed contexts", "error", err)
+		} else if restored > 0 {
+			slog.Info("restored feed contexts", "feeds", restored)
+		}
+	}
+
+	if n, err := authService.EnsureAdmins(ctx); err != nil {
+		slog.Warn("failed to grant admin roles", "error", err)
+	} else if n > 0 {
+		slog.Info("granted admin role from ADMIN_EMAILS", "users", n)
 	}
 
 	if err := settingsService.EnsureDefaultCategories(ctx); err != nil {
-		log.Printf("⚠️  failed to seed settings categories: %v", err)
+		slog.Warn("failed to seed settings categories", "error", err)
+	}
+
+	// Users' own provider keys, sealed with ENCRYPTION_KEY
+	llmKeyService := services.NewLLMKeyService(cfg, mongoClient.Db)
+	if err := llmKeyService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create LLM key indexes", "error", err)
+	}
+	if llmService != nil {
+		llmService.SetUserKeys(llmKeyService)
 	}
 
 	socketManager := socket.NewManager(authService, azureService, marketplaceService, []string{cfg.CORSOrigin})
 	socketManager.SetLLMService(llmService)
 
+	historyService := services.NewFeedHistoryService(mongoClient.Db, cfg.FeedHistoryRetention)
+	if err := historyService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create feed history indexes", "error", err)
+	}
+	socketManager.SetHistoryService(historyService)
+	if llmService != nil {
+		llmService.SetFeedHistory(historyService)
+	}
+
+	alertService := services.NewAlertService(mongoClient.Db, authService, emailService)
+	alertService.SetStore(store)
+	if err := alertService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create alert rule indexes", "error", err)
+	}
+	socketManager.SetAlertService(alertService)
+
+	webhookService := services.NewWebhookService(mongoClient.Db)
+	if err := webhookService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create webhook indexes", "error", err)
+	}
+	socketManager.SetWebhookService(webhookService)
+
+	feedAccessService := services.NewFeedAccessService(mongoClient.Db)
+	feedAccessService.SetStore(store)
+	if err := feedAccessService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create feed access indexes", "error", err)
+	}
+	socketManager.SetFeedAccessService(feedAccessService)
+
+	orgService := services.NewOrganizationService(mongoClient.Db)
+	orgService.SetStore(store)
+	if err := orgService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create organization indexes", "error", err)
+	}
+	marketplaceService.SetOrgRoles(orgService)
+	feedAccessService.SetOrgRoles(orgService)
+
+	auditService := services.NewAuditService(mongoClient.Db, cfg.AuditRetention)
+	if err := auditService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create audit log indexes", "error", err)
+	}
+
+	// Account export and deletion (GDPR)
+	accountService := services.NewAccountService(mongoClient.Db, marketplaceService)
+	accountService.SetStore(store)
+
+	llmUsageService := services.NewLLMUsageService(mongoClient.Db)
+	llmUsageService.SetStore(store)
+	if err := llmUsageService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create LLM usage indexes", "error", err)
+	}
+	socketManager.SetLLMUsageService(llmUsageService)
+
+	analysisService := services.NewAnalysisService(mongoClient.Db)
+	if err := analysisService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create analysis schedule indexes", "error", err)
+	}
+	socketManager.SetAnalysisService(analysisService)
+
+	conversationService := services.NewConversationService(mongoClient.Db, cfg.LLMConversationTokens)
+	if err := conversationService.EnsureIndexes(ctx); err != nil {
+		slog.Warn("failed to create conversation indexes", "error", err)
+	}
+	socketManager.SetConversationService(conversationService)
+	socketManager.SetReplayBufferSize(cfg.FeedReplayBufferSize)
+	socketManager.SetCompression(cfg.WSCompression)
+	socketManager.SetOpenPublicFeeds(cfg.WSOpenPublicFeeds)
+	socketManager.SetHeartbeat(cfg.WSPingInterval, cfg.WSPingTimeout)
+	socketManager.SetBandwidthQuotas(socket.BandwidthQuotas{
+		SubscriptionBytesPerDay: int64(cfg.BandwidthSubscriptionMBPerDay) << 20,
+		UserMessagesPerSecond:   cfg.BandwidthUserMessagesPerSec,
+	})
+
+	// Shared by the REST middleware and websocket message handling
+	rateLimits := ratelimit.Limits{
+		IP:     ratelimit.New(cfg.RateLimitIPPerMinute, cfg.RateLimitIPBurst),
+		User:   ratelimit.New(cfg.RateLimitUserPerMinute, cfg.RateLimitUserBurst),
+		LLM:    ratelimit.New(cfg.RateLimitLLMPerMinute, cfg.RateLimitLLMBurst),
+		Ingest: ratelimit.New(cfg.RateLimitIngestPerMinute, cfg.RateLimitIngestBurst),
+	}
+	socketManager.SetRateLimits(rateLimits)
+
+	// Share broadcasts and feed ownership with other instances
+	if cfg.RedisURL != "" {
+		cluster, err := socket.NewRedisCluster(ctx, cfg.RedisURL)
+		if err != nil {
+			fatal("failed to connect to Redis", err)
+		}
+		defer cluster.Close()
+		socketManager.SetCluster(cluster)
+	}
+
+	if cfg.WarmPopularFeeds > 0 || len(cfg.WarmFeedIDs) > 0 {
+		go func() {
+			warmCtx, warmCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer warmCancel()
+			socketManager.WarmUpFeeds(warmCtx, cfg.WarmPopularFeeds, cfg.WarmFeedIDs, cfg.WarmFeedGrace)
+		}()
+	}
+
+	// Cancelled on SIGINT/SIGTERM to shut the server down gracefully
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go socketManager.RunSubscriptionExpiry(context.Background(), cfg.SubscriptionExpiryInterval)
+	go socketManager.RunAggregations(runCtx)
+	go socketManager.RunAlerts(runCtx)
+	go socketManager.RunAnalyses(runCtx)
+	go webhookService.Run(runCtx)
+	go marketplaceService.RunFeedPurge(runCtx, services.FeedPurgeInterval)
+
+	// Broadcasts reach clients on other instances; stopped with the server
+	go func() {
+		if err := socketManager.RunCluster(runCtx); err != nil {
+			slog.Error("cluster broadcast stopped", "error", err)
+		}
+	}()
+
+	snapshotsDone := make(chan struct{})
+	go func() {
+		defer close(snapshotsDone)
+		if contextStore != nil {
+			contextStore.Run(runCtx, llmService, cfg.ContextSnapshotInterval)
+		}
+	}()
+
+	historyDone := make(chan struct{})
+	go func() {
+		defer close(historyDone)
+		historyService.Run(runCtx)
+	}()
+
 	gin.SetMode(gin.ReleaseMode)
 
 	router := transport.BuildEngine(transport.RouterDeps{
-		Config:      cfg,
-		AuthService: authService,
-		Marketplace: marketplaceService,
-		Settings:    settingsService,
-		LLM:         llmService,
-		Sockets:     socketManager,
+		Config:        cfg,
+		DB:            mongoClient.Db,
+		AuthService:   authService,
+		Marketplace:   marketplaceService,
+		Settings:      settingsService,
+		LLM:           llmService,
+		History:       historyService,
+		Alerts:        alertService,
+		Webhooks:      webhookService,
+		FeedAccess:    feedAccessService,
+		Organizations: orgService,
+		Audit:         auditService,
+		Account:       accountService,
+		LLMUsage:      llmUsageService,
+		LLMKeys:       llmKeyService,
+		Analyses:      analysisService,
+		Conversations: conversationService,
+		Sockets:       socketManager,
+		RateLimits:    rateLimits,
 	})
 
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	log.Printf("🚀 Go backend listening on %s (CORS: %s)", addr, cfg.CORSOrigin)
+	slog.Info("Go backend listening", "addr", addr, "cors_origin", cfg.CORSOrigin)
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/ws" {
 			socketManager.Handle(w, r)
@@ -85,7 +351,69 @@ func main() {
 		ReadHeaderTimeout: 5 * time.Second,  // Max time to read request headers
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatalf("server error: %v", err)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal("server error", err)
+		}
+	}()
+
+	// gRPC streaming API for services and bots that want typed streams
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != 0 {
+		grpcAddr := fmt.Sprintf("%s:%d", cfg.Host, cfg.GRPCPort)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			fatal("gRPC listen error", err)
+		}
+		grpcServer = grpc.NewServer()
+		pb.RegisterTurboStreamServer(grpcServer, socket.NewGRPCServer(socketManager))
+		slog.Info("gRPC API listening", "addr", grpcAddr)
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				fatal("gRPC server error", err)
+			}
+		}()
+	}
+
+	<-runCtx.Done()
+	slog.Info("shutting down")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("server shutdown", "error", err)
 	}
+	// Websocket connections are hijacked, so srv.Shutdown does not wait for them
+	if err := socketManager.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("websocket shutdown", "error", err)
+	}
+	// The websocket shutdown has ended the feed streams, so this only waits
+	// for calls in flight
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	// Wait for the final context snapshot and queued history writes
+	<-snapshotsDone
+	<-historyDone
+	if sqlDB != nil {
+		sqlDB.Close()
+	}
+	// Flush the last spans, including those of the shutdown itself
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("tracing shutdown", "error", err)
+	}
+}
+
+// mongoRequired explains a MongoDB failure to deployments that chose SQL
+// storage and may expect to run without MongoDB
+func mongoRequired(cfg config.Config, msg string) string {
+	if cfg.StorageDriver == "mongodb" {
+		return msg
+	}
+	return msg + " (still required with STORAGE_DRIVER=" + cfg.StorageDriver + ", which moves only users, feeds, subscriptions, sessions and the usage ledger)"
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -1,9 +1,8 @@
 package config
 
 import (
-	"log"
-	"os"
-	"strconv"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +13,7 @@ type Config struct {
 	Env            string
 	Host           string
 	Port           int
+	GRPCPort       int // serves the gRPC streaming API on Host; 0 disables it
 	CORSOrigin     string
 	JWTSecret      string
 	MongoURI       string
@@ -60,96 +60,357 @@ type Config struct {
 	LLMMaxTokens    int
 	LLMTemperature  float64
 	LLMContextLimit int // Max number of feed entries to include in context
+	// Context windows in tokens by model name, overriding the built-in ones;
+	// queries send only the newest entries that fit in the window
+	LLMModelContextWindows map[string]int
+	// Decimal places for float values in the CSV context (-1 keeps the shortest exact value)
+	LLMNumberPrecision int
+	// How long answers to repeated questions are reused while a feed's data is unchanged (0 disables)
+	LLMCacheTTL time.Duration
+	// Provider failover: each provider gets LLMProviderTimeout, a query tries
+	// at most LLMMaxProviderAttempts providers (1 disables failover) and gives
+	// up once LLMFallbackBudget has passed
+	LLMProviderTimeout     time.Duration
+	LLMMaxProviderAttempts int
+	LLMFallbackBudget      time.Duration
+	// Tokens of earlier turns kept in a conversation; older turns are dropped
+	LLMConversationTokens int
+	// Tool calls a query that enables tools may make; 0 disables tools
+	LLMToolMaxCalls int
+	// Retrieval: once a feed's context holds LLMRetrievalMinEntries entries
+	// (0 disables), queries send only the LLMRetrievalTopK entries most
+	// similar to the question, scored by LLMRetrievalEmbedder ("local",
+	// "openai" or "ollama"), dropping those below LLMRetrievalMinSimilarity
+	LLMRetrievalMinEntries    int
+	LLMRetrievalTopK          int
+	LLMRetrievalMinSimilarity float64
+	LLMRetrievalEmbedder      string
+	OpenAIEmbeddingModel      string
+	OllamaEmbeddingModel      string
+
+	// Feed warm-up: pre-connect feeds at startup so first subscribers see data immediately
+	WarmPopularFeeds int           // Number of top popular public feeds to pre-connect (0 disables)
+	WarmFeedIDs      []string      // Explicit feed IDs to pre-connect
+	WarmFeedGrace    time.Duration // Disconnect warmed feeds with no subscribers after this window
+
+	// Seed public system feeds of simulated prices and news at startup
+	DemoFeeds bool
+
+	// Subscription expiry: how often expired subscriptions are swept (0 disables);
+	// live clients keep streaming for up to one interval after expiry
+	SubscriptionExpiryInterval time.Duration
+
+	// Feed context persistence: how often LLM feed contexts are snapshotted to
+	// MongoDB (0 disables), and how old restored entries may be (0 keeps all)
+	ContextSnapshotInterval time.Duration
+	ContextSnapshotMaxAge   time.Duration
+
+	// Feed history: how long broadcast feed messages are kept in the feed_data
+	// collection unless a feed sets its own retention (0 records only feeds that opt in)
+	FeedHistoryRetention time.Duration
+
+	// Audit log: how long security-relevant events are kept (0 keeps them forever)
+	AuditRetention time.Duration
+
+	// Recent feed-data messages kept per feed for replay on subscribe (0 disables)
+	FeedReplayBufferSize int
+
+	// Offer permessage-deflate to websocket clients that ask for it
+	WSCompression bool
+
+	// Let clients subscribe to public feeds over websockets without
+	// authenticating or subscribing first
+	WSOpenPublicFeeds bool
+
+	// Websocket heartbeat: how often clients are pinged (0 disables) and how
+	// long they have to answer before they are dropped as stale
+	WSPingInterval time.Duration
+	WSPingTimeout  time.Duration
+
+	// Websocket egress quotas (0 disables): feed-data megabytes a user may
+	// receive from one feed per UTC day, and feed-data messages per second a
+	// user may receive from all feeds
+	BandwidthSubscriptionMBPerDay int
+	BandwidthUserMessagesPerSec   int
+
+	// Access tokens are short-lived JWTs; refresh tokens are stored per session
+	// and rotated on every refresh
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// OAuth sign-in; a provider is enabled when its client ID and secret are
+	// set. Providers redirect back to OAuthRedirectBaseURL +
+	// /api/auth/oauth/:provider/callback. When OAuthFrontendURL is set the
+	// callback redirects there with the tokens in the URL fragment instead of
+	// answering with JSON.
+	GoogleOAuthClientID     string
+	GoogleOAuthClientSecret string
+	GitHubOAuthClientID     string
+	GitHubOAuthClientSecret string
+	OAuthRedirectBaseURL    string
+	OAuthFrontendURL        string
+
+	// WebAuthn second factor: security keys and passkeys are registered for
+	// WebAuthnRPID (the site's domain) and accepted from pages served at one
+	// of WebAuthnOrigins; an empty RP ID disables them
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+
+	// Users with these emails are given the admin role at startup and on sign-up
+	AdminEmails []string
+
+	// Redis URL for running several backend instances; broadcasts are shared
+	// over pub/sub and each upstream feed is held by one instance (empty disables)
+	RedisURL string
+
+	// StorageDriver keeps users, feeds, subscriptions, sessions and the LLM
+	// usage ledger in mongodb (default), postgres or sqlite; StorageDSN is the
+	// SQL connection string or SQLite file. Everything else stays in MongoDB,
+	// which is required with every driver.
+	StorageDriver string
+	StorageDSN    string
+
+	// Rate limits (token bucket, requests per minute with a burst; 0 disables).
+	// IP and user limits cover REST requests and websocket messages; the LLM
+	// limit additionally applies to AI queries per user, and the ingest limit
+	// to pushes per push feed ingestion token.
+	RateLimitIPPerMinute     int
+	RateLimitIPBurst         int
+	RateLimitUserPerMinute   int
+	RateLimitUserBurst       int
+	RateLimitLLMPerMinute    int
+	RateLimitLLMBurst        int
+	RateLimitIngestPerMinute int
+	RateLimitIngestBurst     int
+
+	// Email delivery for verification, password reset and alert emails.
+	// EmailProvider is smtp or sendgrid (smtp by default, or sendgrid when only
+	// SendGridAPIKey is set); a provider without its settings sends nothing
+	EmailProvider  string
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	SendGridAPIKey string
+	EmailFrom      string
+	// EmailLinkBaseURL prefixes the links in verification and reset emails
+	// (<url>/verify-email?token=...); when empty they carry only the token
+	EmailLinkBaseURL string
+
+	// How long shutdown waits for in-flight LLM queries and client queues to drain
+	ShutdownTimeout time.Duration
+	// ReadyRequireLLM fails /readyz while no LLM provider is configured
+	ReadyRequireLLM bool
+
+	// LogLevel is debug, info, warn or error; LogFormat is text, or json for
+	// log collectors
+	LogLevel  string
+	LogFormat string
+
+	// OpenTelemetry tracing: spans go over OTLP/HTTP to OTelEndpoint, the
+	// collector's base URL (empty disables tracing); OTelSampleRatio is the
+	// share of new traces kept
+	OTelEndpoint    string
+	OTelServiceName string
+	OTelSampleRatio float64
+
+	// Settings lists every variable Load read with its effective value; see
+	// WriteReport
+	Settings []Setting
 }
 
-// Load reads configuration from .env.local (for parity with the Node app) and environment variables.
-func Load() Config {
+// UsesDefaultJWTSecret reports whether JWT_SECRET was left at the built-in
+// value, which Validate only accepts outside production
+func (c Config) UsesDefaultJWTSecret() bool {
+	return c.JWTSecret == defaultJWTSecret
+}
+
+// Load reads configuration from .env.local (for parity with the Node app),
+// environment variables and the YAML or TOML file named by TURBOSTREAM_CONFIG;
+// variables override the file. Values that do not parse and settings that fail
+// Validate are returned together as the error; the Config is filled in with
+// defaults for them so it can still be reported.
+func Load() (Config, error) {
 	_ = godotenv.Load(".env.local")
+	l := &loader{}
+	l.loadFile()
 
-	port := parseInt(getEnv("BACKEND_PORT", "7210"))
-	timeoutMS := parseInt(getEnv("REQUEST_TIMEOUT_MS", "15000"))
-	tokenQuota := parseInt64(getEnv("TOKEN_QUOTA_PER_MONTH", "1000000"))
-	llmMaxTokens := parseInt(getEnv("LLM_MAX_TOKENS", "1024"))
-	llmContextLimit := parseInt(getEnv("LLM_CONTEXT_LIMIT", "50"))
-	llmTemp := parseFloat(getEnv("LLM_TEMPERATURE", "0.7"))
+	port := l.int("BACKEND_PORT", 7210)
+	timeoutMS := l.int("REQUEST_TIMEOUT_MS", 15000)
+	tokenQuota := l.int64("TOKEN_QUOTA_PER_MONTH", 1000000)
+	llmMaxTokens := l.int("LLM_MAX_TOKENS", 1024)
+	llmContextLimit := l.int("LLM_CONTEXT_LIMIT", 50)
+	llmTemp := l.float("LLM_TEMPERATURE", 0.7)
+	llmPrecision := l.int("LLM_NUMBER_PRECISION", -1)
+	llmCacheSec := l.int("LLM_CACHE_TTL_SECONDS", 60)
+	llmProviderTimeoutSec := l.int("LLM_PROVIDER_TIMEOUT_SECONDS", 30)
+	llmMaxAttempts := l.int("LLM_MAX_PROVIDER_ATTEMPTS", 3)
+	llmFallbackBudgetSec := l.int("LLM_FALLBACK_BUDGET_SECONDS", 55)
+	llmConversationTokens := l.int("LLM_CONVERSATION_MAX_TOKENS", 4000)
+	llmToolMaxCalls := l.int("LLM_TOOL_MAX_CALLS", 4)
+	llmRetrievalMinEntries := l.int("LLM_RETRIEVAL_MIN_ENTRIES", 200)
+	llmRetrievalTopK := l.int("LLM_RETRIEVAL_TOP_K", 30)
+	llmRetrievalMinSimilarity := l.float("LLM_RETRIEVAL_MIN_SIMILARITY", 0.1)
+	warmPopular := l.int("WARM_POPULAR_FEEDS", 0)
+	warmGraceSec := l.int("WARM_FEED_GRACE_SECONDS", 300)
+	subExpirySec := l.int("SUBSCRIPTION_EXPIRY_INTERVAL_SECONDS", 60)
+	snapshotSec := l.int("CONTEXT_SNAPSHOT_INTERVAL_SECONDS", 0)
+	snapshotMaxAgeSec := l.int("CONTEXT_SNAPSHOT_MAX_AGE_SECONDS", 3600)
+	historyRetentionHours := l.int("FEED_HISTORY_RETENTION_HOURS", 0)
+	auditRetentionDays := l.int("AUDIT_RETENTION_DAYS", 365)
+	replayBufferSize := l.int("FEED_REPLAY_BUFFER_SIZE", 50)
+	pingIntervalSec := l.int("WS_PING_INTERVAL_SECONDS", 30)
+	pingTimeoutSec := l.int("WS_PING_TIMEOUT_SECONDS", 10)
+	accessTTLMin := l.int("ACCESS_TOKEN_TTL_MINUTES", 15)
+	refreshTTLHours := l.int("REFRESH_TOKEN_TTL_HOURS", 720)
+	shutdownSec := l.int("SHUTDOWN_TIMEOUT_SECONDS", 30)
+	ipRate := l.int("RATE_LIMIT_IP_PER_MINUTE", 600)
+	ipBurst := l.int("RATE_LIMIT_IP_BURST", 100)
+	userRate := l.int("RATE_LIMIT_USER_PER_MINUTE", 300)
+	userBurst := l.int("RATE_LIMIT_USER_BURST", 60)
+	llmRate := l.int("RATE_LIMIT_LLM_PER_MINUTE", 20)
+	llmBurst := l.int("RATE_LIMIT_LLM_BURST", 5)
+	ingestRate := l.int("RATE_LIMIT_INGEST_PER_MINUTE", 600)
+	ingestBurst := l.int("RATE_LIMIT_INGEST_BURST", 100)
+	smtpPort := l.int("SMTP_PORT", 587)
 
-	jwtSecret := getEnv("JWT_SECRET", "change-me")
-	if jwtSecret == "change-me" {
-		log.Println("⚠️  WARNING: Using default JWT_SECRET. This is insecure for production.")
-	}
+	corsOrigin := l.str("CORS_ORIGIN", "http://localhost:7200")
+	smtpFrom := l.str("SMTP_FROM", "noreply@turbostream.local") // read when EMAIL_FROM is unset
+	jwtSecret := l.str("JWT_SECRET", defaultJWTSecret)
 
-	return Config{
-		Env:                getEnv("NODE_ENV", "development"),
-		Host:               getEnv("BACKEND_HOST", "0.0.0.0"),
+	cfg := Config{
+		Env:                l.str("NODE_ENV", "development"),
+		Host:               l.str("BACKEND_HOST", "0.0.0.0"),
 		Port:               port,
-		CORSOrigin:         getEnv("CORS_ORIGIN", "http://localhost:7200"),
+		GRPCPort:           l.int("GRPC_PORT", 0),
+		CORSOrigin:         corsOrigin,
 		JWTSecret:          jwtSecret,
-		MongoURI:           getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-		MongoDatabase:      getEnv("MONGODB_DB_NAME", "realtime_crypto"),
-		EncryptionKey:      getEnv("ENCRYPTION_KEY", "default-encryption-key-change-in-production"),
+		MongoURI:           l.str("MONGODB_URI", "mongodb://localhost:27017"),
+		MongoDatabase:      l.str("MONGODB_DB_NAME", "realtime_crypto"),
+		EncryptionKey:      l.str("ENCRYPTION_KEY", defaultEncryptionKey),
 		DefaultTimeout:     time.Duration(timeoutMS) * time.Millisecond,
-		AzureEndpoint:      getEnv("AZURE_OPENAI_ENDPOINT", ""),
-		AzureAPIKey:        getEnv("AZURE_OPENAI_API_KEY", ""),
-		AzureAPIVersion:    getEnv("AZURE_OPENAI_API_VERSION", "2024-02-15-preview"),
-		AzureDeployment:    getEnv("AZURE_OPENAI_DEPLOYMENT_NAME", "gpt-4o"),
-		StripeSecretKey:    getEnv("STRIPE_SECRET_KEY", ""),
-		StripePublishable:  getEnv("STRIPE_PUBLISHABLE_KEY", ""),
-		StripeWebhook:      getEnv("STRIPE_WEBHOOK_SECRET", ""),
-		DefaultAIProvider:  getEnv("DEFAULT_AI_PROVIDER", "azure-openai"),
+		AzureEndpoint:      l.str("AZURE_OPENAI_ENDPOINT", ""),
+		AzureAPIKey:        l.str("AZURE_OPENAI_API_KEY", ""),
+		AzureAPIVersion:    l.str("AZURE_OPENAI_API_VERSION", "2024-02-15-preview"),
+		AzureDeployment:    l.str("AZURE_OPENAI_DEPLOYMENT_NAME", "gpt-4o"),
+		StripeSecretKey:    l.str("STRIPE_SECRET_KEY", ""),
+		StripePublishable:  l.str("STRIPE_PUBLISHABLE_KEY", ""),
+		StripeWebhook:      l.str("STRIPE_WEBHOOK_SECRET", ""),
+		DefaultAIProvider:  l.str("DEFAULT_AI_PROVIDER", "azure-openai"),
 		TokenQuotaPerMonth: tokenQuota,
 
 		// LLM Providers
-		OpenAIAPIKey:    getEnv("OPENAI_API_KEY", ""),
-		OpenAIModel:     getEnv("OPENAI_MODEL", "gpt-4o"),
-		AnthropicAPIKey: getEnv("ANTHROPIC_API_KEY", ""),
-		AnthropicModel:  getEnv("ANTHROPIC_MODEL", "claude-3-5-sonnet-20241022"),
-		GoogleAPIKey:    getEnv("GOOGLE_API_KEY", ""),
-		GoogleModel:     getEnv("GOOGLE_MODEL", "gemini-1.5-flash"),
-		MistralAPIKey:   getEnv("MISTRAL_API_KEY", ""),
-		MistralModel:    getEnv("MISTRAL_MODEL", "mistral-large-latest"),
-		XAIAPIKey:       getEnv("XAI_API_KEY", ""),
-		XAIModel:        getEnv("XAI_MODEL", "grok-beta"),
-		OllamaBaseURL:   getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
-		OllamaModel:     getEnv("OLLAMA_MODEL", "llama3.2"),
+		OpenAIAPIKey:    l.str("OPENAI_API_KEY", ""),
+		OpenAIModel:     l.str("OPENAI_MODEL", "gpt-4o"),
+		AnthropicAPIKey: l.str("ANTHROPIC_API_KEY", ""),
+		AnthropicModel:  l.str("ANTHROPIC_MODEL", "claude-3-5-sonnet-20241022"),
+		GoogleAPIKey:    l.str("GOOGLE_API_KEY", ""),
+		GoogleModel:     l.str("GOOGLE_MODEL", "gemini-1.5-flash"),
+		MistralAPIKey:   l.str("MISTRAL_API_KEY", ""),
+		MistralModel:    l.str("MISTRAL_MODEL", "mistral-large-latest"),
+		XAIAPIKey:       l.str("XAI_API_KEY", ""),
+		XAIModel:        l.str("XAI_MODEL", "grok-beta"),
+		OllamaBaseURL:   l.str("OLLAMA_BASE_URL", "http://localhost:11434"),
+		OllamaModel:     l.str("OLLAMA_MODEL", "llama3.2"),
 
 		// LLM Settings
-		LLMMaxTokens:    llmMaxTokens,
-		LLMTemperature:  llmTemp,
-		LLMContextLimit: llmContextLimit,
-	}
-}
+		LLMMaxTokens:       llmMaxTokens,
+		LLMTemperature:     llmTemp,
+		LLMContextLimit:    llmContextLimit,
+		LLMNumberPrecision: llmPrecision,
+		LLMCacheTTL:        time.Duration(llmCacheSec) * time.Second,
 
-func getEnv(key, fallback string) string {
-	val := os.Getenv(key)
-	if val == "" {
-		return fallback
-	}
-	return val
-}
+		LLMModelContextWindows: l.intMap("LLM_MODEL_CONTEXT_WINDOWS"),
+		LLMProviderTimeout:     time.Duration(llmProviderTimeoutSec) * time.Second,
+		LLMMaxProviderAttempts: llmMaxAttempts,
+		LLMFallbackBudget:      time.Duration(llmFallbackBudgetSec) * time.Second,
+		LLMConversationTokens:  llmConversationTokens,
+		LLMToolMaxCalls:        llmToolMaxCalls,
 
-func parseInt(val string) int {
-	n, err := strconv.Atoi(val)
-	if err != nil {
-		log.Printf("⚠️  invalid int for %s: %v (using 0)", val, err)
-		return 0
-	}
-	return n
-}
+		// Retrieval
+		LLMRetrievalMinEntries:    llmRetrievalMinEntries,
+		LLMRetrievalTopK:          llmRetrievalTopK,
+		LLMRetrievalMinSimilarity: llmRetrievalMinSimilarity,
+		LLMRetrievalEmbedder:      l.str("LLM_RETRIEVAL_EMBEDDER", "local"),
+		OpenAIEmbeddingModel:      l.str("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small"),
+		OllamaEmbeddingModel:      l.str("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text"),
 
-func parseInt64(val string) int64 {
-	n, err := strconv.ParseInt(val, 10, 64)
-	if err != nil {
-		log.Printf("⚠️  invalid int64 for %s: %v (using 0)", val, err)
-		return 0
-	}
-	return n
-}
+		// Feed warm-up
+		WarmPopularFeeds: warmPopular,
+		WarmFeedIDs:      l.list("WARM_FEED_IDS", ""),
+		WarmFeedGrace:    time.Duration(warmGraceSec) * time.Second,
+		DemoFeeds:        l.bool("DEMO_FEEDS", false),
+
+		SubscriptionExpiryInterval: time.Duration(subExpirySec) * time.Second,
+
+		ContextSnapshotInterval: time.Duration(snapshotSec) * time.Second,
+		ContextSnapshotMaxAge:   time.Duration(snapshotMaxAgeSec) * time.Second,
+
+		FeedHistoryRetention: time.Duration(historyRetentionHours) * time.Hour,
+		FeedReplayBufferSize: replayBufferSize,
+
+		AuditRetention: time.Duration(auditRetentionDays) * 24 * time.Hour,
+
+		WSCompression:     l.bool("WS_COMPRESSION", false),
+		WSOpenPublicFeeds: l.bool("WS_OPEN_PUBLIC_FEEDS", false),
+		WSPingInterval:    time.Duration(pingIntervalSec) * time.Second,
+		WSPingTimeout:     time.Duration(pingTimeoutSec) * time.Second,
+
+		BandwidthSubscriptionMBPerDay: l.int("BANDWIDTH_SUBSCRIPTION_MB_PER_DAY", 0),
+		BandwidthUserMessagesPerSec:   l.int("BANDWIDTH_USER_MESSAGES_PER_SECOND", 0),
+
+		AccessTokenTTL:  time.Duration(accessTTLMin) * time.Minute,
+		RefreshTokenTTL: time.Duration(refreshTTLHours) * time.Hour,
+
+		GoogleOAuthClientID:     l.str("GOOGLE_OAUTH_CLIENT_ID", ""),
+		GoogleOAuthClientSecret: l.str("GOOGLE_OAUTH_CLIENT_SECRET", ""),
+		GitHubOAuthClientID:     l.str("GITHUB_OAUTH_CLIENT_ID", ""),
+		GitHubOAuthClientSecret: l.str("GITHUB_OAUTH_CLIENT_SECRET", ""),
+		OAuthRedirectBaseURL:    strings.TrimRight(l.str("OAUTH_REDIRECT_BASE_URL", "http://localhost:7210"), "/"),
+		OAuthFrontendURL:        l.str("OAUTH_FRONTEND_URL", ""),
+
+		WebAuthnRPID:    l.str("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName:  l.str("WEBAUTHN_RP_NAME", "TurboStream"),
+		WebAuthnOrigins: l.list("WEBAUTHN_ORIGINS", corsOrigin),
+
+		AdminEmails: parseList(strings.ToLower(l.str("ADMIN_EMAILS", ""))),
+
+		RedisURL: l.str("REDIS_URL", ""),
+
+		StorageDriver: strings.ToLower(l.str("STORAGE_DRIVER", "mongodb")),
+		StorageDSN:    l.str("STORAGE_DSN", ""),
+
+		RateLimitIPPerMinute:     ipRate,
+		RateLimitIPBurst:         ipBurst,
+		RateLimitUserPerMinute:   userRate,
+		RateLimitUserBurst:       userBurst,
+		RateLimitLLMPerMinute:    llmRate,
+		RateLimitLLMBurst:        llmBurst,
+		RateLimitIngestPerMinute: ingestRate,
+		RateLimitIngestBurst:     ingestBurst,
+
+		EmailProvider:    strings.ToLower(l.str("EMAIL_PROVIDER", "")),
+		SMTPHost:         l.str("SMTP_HOST", ""),
+		SMTPPort:         smtpPort,
+		SMTPUsername:     l.str("SMTP_USERNAME", ""),
+		SMTPPassword:     l.str("SMTP_PASSWORD", ""),
+		SendGridAPIKey:   l.str("SENDGRID_API_KEY", ""),
+		EmailFrom:        l.str("EMAIL_FROM", smtpFrom),
+		EmailLinkBaseURL: strings.TrimRight(l.str("EMAIL_LINK_BASE_URL", ""), "/"),
+
+		ShutdownTimeout: time.Duration(shutdownSec) * time.Second,
+		ReadyRequireLLM: l.bool("READY_REQUIRE_LLM", false),
+
+		LogLevel:  strings.ToLower(l.str("LOG_LEVEL", "info")),
+		LogFormat: strings.ToLower(l.str("LOG_FORMAT", "text")),
+
+		OTelEndpoint:    l.str("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTelServiceName: l.str("OTEL_SERVICE_NAME", "turbostream-backend"),
+		OTelSampleRatio: l.float("OTEL_TRACES_SAMPLER_ARG", 1),
 
-func parseFloat(val string) float64 {
-	n, err := strconv.ParseFloat(val, 64)
-	if err != nil {
-		log.Printf("⚠️  invalid float for %s: %v (using 0)", val, err)
-		return 0
+		Settings: l.settings,
 	}
-	return n
+	l.unknownFileSettings()
+	return cfg, errors.Join(append(l.errs, cfg.Validate())...)
 }
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 7210, cfg.Port)
+	assert.Equal(t, []string{"http://localhost:7200"}, cfg.WebAuthnOrigins)
+	assert.NotEmpty(t, cfg.Settings)
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	t.Setenv("BACKEND_PORT", "http")
+	t.Setenv("WS_COMPRESSION", "sometimes")
+	t.Setenv("LLM_MODEL_CONTEXT_WINDOWS", "gpt-4o=128000,broken")
+	t.Setenv("MONGODB_URI", "localhost:27017")
+
+	cfg, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `BACKEND_PORT: "http" is not an integer`)
+	assert.Contains(t, err.Error(), `WS_COMPRESSION: "sometimes" is not true or false`)
+	assert.Contains(t, err.Error(), `LLM_MODEL_CONTEXT_WINDOWS: "broken" is not a name=integer pair`)
+	assert.Contains(t, err.Error(), `MONGODB_URI: "localhost:27017" is not a URL`)
+	// Values that do not parse keep their defaults
+	assert.Equal(t, 7210, cfg.Port)
+	assert.Equal(t, map[string]int{"gpt-4o": 128000}, cfg.LLMModelContextWindows)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid, err := Load()
+	require.NoError(t, err)
+	valid.JWTSecret = "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"short JWT secret", func(c *Config) { c.JWTSecret = "secret" }, "JWT_SECRET: must be at least 32 characters"},
+		{"missing JWT secret", func(c *Config) { c.JWTSecret = "" }, "JWT_SECRET: is required"},
+		{"default JWT secret in production", func(c *Config) { c.Env = "production"; c.JWTSecret = defaultJWTSecret }, "JWT_SECRET: must be changed"},
+		{"default encryption key in production", func(c *Config) { c.Env = "production" }, "ENCRYPTION_KEY: must be changed"},
+		{"Ollama URL", func(c *Config) { c.OllamaBaseURL = "localhost:11434" }, "OLLAMA_BASE_URL"},
+		{"Azure endpoint", func(c *Config) { c.AzureEndpoint = "ftp://azure.example.com" }, "AZURE_OPENAI_ENDPOINT: scheme must be http or https"},
+		{"port", func(c *Config) { c.Port = 70000 }, "BACKEND_PORT"},
+		{"gRPC port", func(c *Config) { c.GRPCPort = -1 }, "GRPC_PORT"},
+		{"email provider", func(c *Config) { c.EmailProvider = "postmark" }, "EMAIL_PROVIDER"},
+		{"storage driver", func(c *Config) { c.StorageDriver = "mysql" }, "STORAGE_DRIVER"},
+		{"storage DSN", func(c *Config) { c.StorageDriver = "postgres" }, "STORAGE_DSN: is required"},
+		{"temperature", func(c *Config) { c.LLMTemperature = 3 }, "LLM_TEMPERATURE"},
+		{"negative duration", func(c *Config) { c.AuditRetention = -1 }, "AUDIT_RETENTION_DAYS"},
+		{"log level", func(c *Config) { c.LogLevel = "verbose" }, "LOG_LEVEL"},
+		{"log format", func(c *Config) { c.LogFormat = "logfmt" }, "LOG_FORMAT"},
+		{"OTLP endpoint", func(c *Config) { c.OTelEndpoint = "collector:4318" }, "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		{"trace sample ratio", func(c *Config) { c.OTelSampleRatio = 1.5 }, "OTEL_TRACES_SAMPLER_ARG"},
+		{"WebAuthn RP ID", func(c *Config) { c.WebAuthnRPID = "https://example.com" }, "WEBAUTHN_RP_ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+	assert.NoError(t, valid.Validate())
+}
+
+func TestWriteReport_MasksSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+	t.Setenv("SMTP_PASSWORD", "hunter2")
+	t.Setenv("REDIS_URL", "redis://:s3cret@redis.internal:6379/0")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, WriteReport(&out, cfg))
+	report := out.String()
+	assert.NotContains(t, report, "0123456789abcdef0123")
+	assert.Contains(t, report, "********cdef")
+	assert.NotContains(t, report, "hunter2")
+	assert.NotContains(t, report, "s3cret")
+	assert.Contains(t, report, "redis://:xxxxx@redis.internal:6379/0")
+	assert.Regexp(t, `BACKEND_PORT\s+7210\s+default`, report)
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "turbostream.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+backend:
+  port: 8080
+jwt_secret: file-secret-file-secret-file-secret
+admin_emails: [Admin@example.com, ops@example.com]
+llm:
+  max_tokens: 2048
+  model_context_windows:
+    custom-model: 32000
+  providers:
+    openai:
+      api_key: sk-file
+      model: gpt-4o-mini
+      models:
+        gpt-4o-mini:
+          context_window: 128000
+    ollama:
+      base_url: http://ollama.internal:11434
+`), 0o600))
+	t.Setenv("TURBOSTREAM_CONFIG", path)
+	t.Setenv("OPENAI_MODEL", "gpt-4o")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, "file-secret-file-secret-file-secret", cfg.JWTSecret)
+	assert.Equal(t, []string{"admin@example.com", "ops@example.com"}, cfg.AdminEmails)
+	assert.Equal(t, 2048, cfg.LLMMaxTokens)
+	assert.Equal(t, "sk-file", cfg.OpenAIAPIKey)
+	// Variables override the file
+	assert.Equal(t, "gpt-4o", cfg.OpenAIModel)
+	assert.Equal(t, "http://ollama.internal:11434", cfg.OllamaBaseURL)
+	assert.Equal(t, map[string]int{"custom-model": 32000, "gpt-4o-mini": 128000}, cfg.LLMModelContextWindows)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteReport(&out, cfg))
+	assert.Regexp(t, `BACKEND_PORT\s+8080\s+file`, out.String())
+	assert.Regexp(t, `OPENAI_MODEL\s+gpt-4o\s+env`, out.String())
+}
+
+func TestLoad_ConfigFileTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "turbostream.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[smtp]
+host = "smtp.example.com"
+port = 2525
+
+[llm.providers.anthropic]
+api_key = "sk-ant"
+model = "claude-3-5-haiku-latest"
+`), 0o600))
+	t.Setenv("TURBOSTREAM_CONFIG", path)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com", cfg.SMTPHost)
+	assert.Equal(t, 2525, cfg.SMTPPort)
+	assert.Equal(t, "sk-ant", cfg.AnthropicAPIKey)
+	assert.Equal(t, "claude-3-5-haiku-latest", cfg.AnthropicModel)
+}
+
+func TestLoad_ConfigFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "turbostream.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+backend:
+  prot: 8080
+llm:
+  providers:
+    cohere:
+      api_key: x
+`), 0o600))
+	t.Setenv("TURBOSTREAM_CONFIG", path)
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "llm.providers.cohere: unknown LLM provider")
+
+	require.NoError(t, os.WriteFile(path, []byte("backend:\n  prot: 8080\n"), 0o600))
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TURBOSTREAM_CONFIG: unknown setting backend.prot")
+
+	t.Setenv("TURBOSTREAM_CONFIG", filepath.Join(dir, "turbostream.json"))
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be .yaml, .yml or .toml")
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileEnv names the optional YAML or TOML config file
+const configFileEnv = "TURBOSTREAM_CONFIG"
+
+// fileValue is a setting read from the config file
+type fileValue struct {
+	value string
+	path  string // where it was set, such as llm.providers.openai.api_key
+}
+
+// providerPrefixes maps the blocks under llm.providers to the variables
+// their settings stand for, so llm.providers.openai.model is OPENAI_MODEL
+var providerPrefixes = map[string]string{
+	"openai":       "OPENAI",
+	"anthropic":    "ANTHROPIC",
+	"google":       "GOOGLE",
+	"gemini":       "GOOGLE",
+	"mistral":      "MISTRAL",
+	"xai":          "XAI",
+	"grok":         "XAI",
+	"ollama":       "OLLAMA",
+	"azure":        "AZURE_OPENAI",
+	"azure-openai": "AZURE_OPENAI",
+}
+
+// contextWindowsKey is the variable that model context windows from the
+// file are gathered into
+const contextWindowsKey = "LLM_MODEL_CONTEXT_WINDOWS"
+
+// readConfigFile parses a YAML (.yaml, .yml) or TOML (.toml) config file into
+// settings keyed by the environment variable each one stands for. Nested
+// keys are joined with underscores, so smtp: {host: ...} sets SMTP_HOST, and
+// lists become comma-separated values.
+func readConfigFile(path string) (map[string]fileValue, error) {
+	unmarshal := yaml.Unmarshal
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+	case ".toml":
+		unmarshal = toml.Unmarshal
+	default:
+		return nil, fmt.Errorf("%s: config file must be .yaml, .yml or .toml, not %q", path, ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	f := &fileFlattener{out: map[string]fileValue{}}
+	if err := f.block("", "", doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(f.windows) > 0 {
+		sort.Strings(f.windows)
+		f.out[contextWindowsKey] = fileValue{value: strings.Join(f.windows, ","), path: "llm.model_context_windows"}
+	}
+	return f.out, nil
+}
+
+// fileFlattener turns the nested config document into variables
+type fileFlattener struct {
+	out map[string]fileValue
+	// windows are name=tokens pairs from llm.model_context_windows and the
+	// models of each provider
+	windows []string
+}
+
+func joinKey(prefix, key string) string {
+	key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// block flattens a table whose settings start with prefix
+func (f *fileFlattener) block(prefix, path string, table map[string]interface{}) error {
+	for name, v := range table {
+		key, at := joinKey(prefix, name), joinPath(path, name)
+		switch {
+		case key == "LLM_PROVIDERS":
+			providers, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s must be a table of providers", at)
+			}
+			if err := f.providers(at, providers); err != nil {
+				return err
+			}
+		case key == contextWindowsKey:
+			windows, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s must map model names to token counts", at)
+			}
+			for model, tokens := range windows {
+				f.windows = append(f.windows, fmt.Sprintf("%s=%v", model, tokens))
+			}
+		default:
+			if err := f.value(key, at, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// providers flattens llm.providers: each provider's settings take the
+// provider's variable prefix, and its models block sets per-model options
+func (f *fileFlattener) providers(path string, providers map[string]interface{}) error {
+	for name, v := range providers {
+		at := joinPath(path, name)
+		prefix, ok := providerPrefixes[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("%s: unknown LLM provider", at)
+		}
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be a table", at)
+		}
+		for option, value := range block {
+			if option != "models" {
+				if err := f.value(joinKey(prefix, option), joinPath(at, option), value); err != nil {
+					return err
+				}
+				continue
+			}
+			models, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.models must be a table of models", at)
+			}
+			for model, opts := range models {
+				if err := f.model(joinPath(at, "models."+model), model, opts); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// model reads the options of one model; context_window is the only one
+func (f *fileFlattener) model(path, model string, opts interface{}) error {
+	table, ok := opts.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s must be a table", path)
+	}
+	for option, value := range table {
+		if option != "context_window" {
+			return fmt.Errorf("%s.%s: unknown model option", path, option)
+		}
+		f.windows = append(f.windows, fmt.Sprintf("%s=%v", model, value))
+	}
+	return nil
+}
+
+// value stores a scalar or list, or descends into a nested table
+func (f *fileFlattener) value(key, path string, v interface{}) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return f.block(key, path, val)
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, fmt.Sprint(item))
+		}
+		f.out[key] = fileValue{value: strings.Join(items, ","), path: path}
+	case nil:
+	default:
+		f.out[key] = fileValue{value: fmt.Sprint(val), path: path}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Setting is one variable as Load resolved it, for the --check-config report
+type Setting struct {
+	Key    string
+	Value  string // secrets are masked
+	Source string // "env", "file" or "default"
+}
+
+// loader reads typed variables, recording each setting and every value that
+// does not parse instead of silently using the default
+type loader struct {
+	// file holds the config file's settings, which variables override
+	file     map[string]fileValue
+	read     map[string]bool
+	settings []Setting
+	errs     []error
+}
+
+// lookup returns the variable, else the config file's value, else fallback
+func (l *loader) lookup(key, fallback string) string {
+	if l.read == nil {
+		l.read = map[string]bool{}
+	}
+	l.read[key] = true
+	val, source := os.Getenv(key), "env"
+	if val == "" {
+		if fv, ok := l.file[key]; ok {
+			val, source = fv.value, "file"
+		} else {
+			val, source = fallback, "default"
+		}
+	}
+	l.settings = append(l.settings, Setting{Key: key, Value: maskSetting(key, val), Source: source})
+	return val
+}
+
+// loadFile reads the config file named by TURBOSTREAM_CONFIG, if any
+func (l *loader) loadFile() {
+	path := os.Getenv(configFileEnv)
+	if path == "" {
+		return
+	}
+	file, err := readConfigFile(path)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", configFileEnv, err))
+		return
+	}
+	l.file = file
+}
+
+// unknownFileSettings reports config file settings Load never read, which
+// are most likely typos
+func (l *loader) unknownFileSettings() {
+	var unknown []string
+	for key, fv := range l.file {
+		if !l.read[key] {
+			unknown = append(unknown, fv.path)
+		}
+	}
+	sort.Strings(unknown)
+	for _, path := range unknown {
+		l.errs = append(l.errs, fmt.Errorf("%s: unknown setting %s", configFileEnv, path))
+	}
+}
+
+// invalid records a value that does not parse
+func (l *loader) invalid(key, raw, want string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: %q is not %s", key, raw, want))
+}
+
+func (l *loader) str(key, fallback string) string {
+	return strings.TrimSpace(l.lookup(key, fallback))
+}
+
+func (l *loader) int(key string, fallback int) int {
+	raw := l.str(key, strconv.Itoa(fallback))
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		l.invalid(key, raw, "an integer")
+		return fallback
+	}
+	return n
+}
+
+func (l *loader) int64(key string, fallback int64) int64 {
+	raw := l.str(key, strconv.FormatInt(fallback, 10))
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		l.invalid(key, raw, "an integer")
+		return fallback
+	}
+	return n
+}
+
+func (l *loader) float(key string, fallback float64) float64 {
+	raw := l.str(key, strconv.FormatFloat(fallback, 'f', -1, 64))
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		l.invalid(key, raw, "a number")
+		return fallback
+	}
+	return n
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	raw := l.str(key, strconv.FormatBool(fallback))
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.invalid(key, raw, "true or false")
+		return fallback
+	}
+	return b
+}
+
+func (l *loader) list(key, fallback string) []string {
+	return parseList(l.lookup(key, fallback))
+}
+
+// intMap reads "key=n,key=n" pairs
+func (l *loader) intMap(key string) map[string]int {
+	out := make(map[string]int)
+	for _, part := range l.list(key, "") {
+		name, raw, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || err != nil {
+			l.invalid(key, part, "a name=integer pair")
+			continue
+		}
+		out[strings.TrimSpace(name)] = n
+	}
+	return out
+}
+
+func parseList(val string) []string {
+	var out []string
+	for _, part := range strings.Split(val, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// secretSuffixes mark the variables whose values are never printed
+var secretSuffixes = []string{"_SECRET", "_KEY", "_PASSWORD"}
+
+// maskSetting hides secrets and the passwords in connection URLs
+func maskSetting(key, val string) string {
+	if val == "" {
+		return ""
+	}
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return maskSecret(val)
+		}
+	}
+	if u, err := url.Parse(val); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return val
+}
+
+// maskSecret shows only the last four characters of long secrets
+func maskSecret(val string) string {
+	if len(val) < 16 {
+		return "********"
+	}
+	return "********" + val[len(val)-4:]
+}
+
+// WriteReport prints the effective configuration as a table sorted by
+// variable, secrets masked
+func WriteReport(w io.Writer, cfg Config) error {
+	settings := append([]Setting(nil), cfg.Settings...)
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIABLE\tVALUE\tSOURCE")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	return tw.Flush()
+}
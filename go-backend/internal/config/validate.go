@@ -0,0 +1,229 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultJWTSecret     = "change-me"
+	defaultEncryptionKey = "default-encryption-key-change-in-production"
+	// minJWTSecretLength is the shortest JWT_SECRET accepted, 256 bits for HS256
+	minJWTSecretLength = 32
+)
+
+// Validate reports every setting that would make the server misbehave, one
+// error per variable. The built-in JWT and encryption secrets are accepted
+// outside production so a fresh checkout starts.
+func (c Config) Validate() error {
+	v := &validator{}
+
+	switch {
+	case c.JWTSecret == "":
+		v.fail("JWT_SECRET", "is required")
+	case c.JWTSecret == defaultJWTSecret:
+		if c.Env == "production" {
+			v.fail("JWT_SECRET", "must be changed from the default in production")
+		}
+	case len(c.JWTSecret) < minJWTSecretLength:
+		v.fail("JWT_SECRET", "must be at least %d characters", minJWTSecretLength)
+	}
+	if c.Env == "production" && c.EncryptionKey == defaultEncryptionKey {
+		v.fail("ENCRYPTION_KEY", "must be changed from the default in production")
+	}
+
+	v.port("BACKEND_PORT", c.Port)
+	if c.GRPCPort != 0 {
+		v.port("GRPC_PORT", c.GRPCPort)
+	}
+	v.url("MONGODB_URI", c.MongoURI, true, "mongodb", "mongodb+srv")
+	if c.MongoDatabase == "" {
+		v.fail("MONGODB_DB_NAME", "is required")
+	}
+	if c.CORSOrigin != "*" {
+		v.url("CORS_ORIGIN", c.CORSOrigin, true, "http", "https")
+	}
+	v.url("OLLAMA_BASE_URL", c.OllamaBaseURL, false, "http", "https")
+	v.url("AZURE_OPENAI_ENDPOINT", c.AzureEndpoint, false, "http", "https")
+	v.url("OAUTH_REDIRECT_BASE_URL", c.OAuthRedirectBaseURL, false, "http", "https")
+	v.url("OAUTH_FRONTEND_URL", c.OAuthFrontendURL, false, "http", "https")
+	v.url("EMAIL_LINK_BASE_URL", c.EmailLinkBaseURL, false, "http", "https")
+	v.url("REDIS_URL", c.RedisURL, false, "redis", "rediss")
+	v.url("OTEL_EXPORTER_OTLP_ENDPOINT", c.OTelEndpoint, false, "http", "https")
+	if c.OTelEndpoint != "" && c.OTelServiceName == "" {
+		v.fail("OTEL_SERVICE_NAME", "is required with OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	for _, origin := range c.WebAuthnOrigins {
+		v.url("WEBAUTHN_ORIGINS", origin, true, "http", "https")
+	}
+	if strings.ContainsAny(c.WebAuthnRPID, ":/") {
+		v.fail("WEBAUTHN_RP_ID", "must be a domain without scheme or port")
+	}
+
+	switch c.StorageDriver {
+	case "mongodb":
+	case "postgres", "sqlite":
+		if c.StorageDSN == "" {
+			v.fail("STORAGE_DSN", "is required with STORAGE_DRIVER=%s", c.StorageDriver)
+		}
+	default:
+		v.fail("STORAGE_DRIVER", "must be mongodb, postgres or sqlite")
+	}
+
+	switch c.EmailProvider {
+	case "", "smtp", "sendgrid":
+	default:
+		v.fail("EMAIL_PROVIDER", "must be smtp or sendgrid")
+	}
+	if c.SMTPHost != "" {
+		v.port("SMTP_PORT", c.SMTPPort)
+	}
+	switch c.LLMRetrievalEmbedder {
+	case "local", "openai", "ollama":
+	default:
+		v.fail("LLM_RETRIEVAL_EMBEDDER", "must be local, openai or ollama")
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		v.fail("LOG_LEVEL", "must be debug, info, warn or error")
+	}
+	switch c.LogFormat {
+	case "text", "json":
+	default:
+		v.fail("LOG_FORMAT", "must be text or json")
+	}
+
+	if c.LLMTemperature < 0 || c.LLMTemperature > 2 {
+		v.fail("LLM_TEMPERATURE", "must be between 0 and 2")
+	}
+	if c.LLMRetrievalMinSimilarity < 0 || c.LLMRetrievalMinSimilarity > 1 {
+		v.fail("LLM_RETRIEVAL_MIN_SIMILARITY", "must be between 0 and 1")
+	}
+	if c.OTelSampleRatio < 0 || c.OTelSampleRatio > 1 {
+		v.fail("OTEL_TRACES_SAMPLER_ARG", "must be between 0 and 1")
+	}
+	if c.LLMNumberPrecision < -1 {
+		v.fail("LLM_NUMBER_PRECISION", "must be -1 or more")
+	}
+	v.min("LLM_MAX_TOKENS", c.LLMMaxTokens, 1)
+	v.min("LLM_MAX_PROVIDER_ATTEMPTS", c.LLMMaxProviderAttempts, 1)
+	for name, window := range c.LLMModelContextWindows {
+		if window < 1 {
+			v.fail("LLM_MODEL_CONTEXT_WINDOWS", "%s must have a positive window", name)
+		}
+	}
+	for key, n := range map[string]int{
+		"LLM_CONTEXT_LIMIT":            c.LLMContextLimit,
+		"LLM_CONVERSATION_MAX_TOKENS":  c.LLMConversationTokens,
+		"LLM_TOOL_MAX_CALLS":           c.LLMToolMaxCalls,
+		"LLM_RETRIEVAL_MIN_ENTRIES":    c.LLMRetrievalMinEntries,
+		"LLM_RETRIEVAL_TOP_K":          c.LLMRetrievalTopK,
+		"WARM_POPULAR_FEEDS":           c.WarmPopularFeeds,
+		"FEED_REPLAY_BUFFER_SIZE":      c.FeedReplayBufferSize,
+		"RATE_LIMIT_IP_PER_MINUTE":     c.RateLimitIPPerMinute,
+		"RATE_LIMIT_IP_BURST":          c.RateLimitIPBurst,
+		"RATE_LIMIT_USER_PER_MINUTE":   c.RateLimitUserPerMinute,
+		"RATE_LIMIT_USER_BURST":        c.RateLimitUserBurst,
+		"RATE_LIMIT_LLM_PER_MINUTE":    c.RateLimitLLMPerMinute,
+		"RATE_LIMIT_LLM_BURST":         c.RateLimitLLMBurst,
+		"RATE_LIMIT_INGEST_PER_MINUTE": c.RateLimitIngestPerMinute,
+		"RATE_LIMIT_INGEST_BURST":      c.RateLimitIngestBurst,
+	} {
+		v.min(key, n, 0)
+	}
+	if c.TokenQuotaPerMonth < 0 {
+		v.fail("TOKEN_QUOTA_PER_MONTH", "must not be negative")
+	}
+
+	for key, d := range map[string]time.Duration{
+		"REQUEST_TIMEOUT_MS":           c.DefaultTimeout,
+		"LLM_PROVIDER_TIMEOUT_SECONDS": c.LLMProviderTimeout,
+		"LLM_FALLBACK_BUDGET_SECONDS":  c.LLMFallbackBudget,
+		"ACCESS_TOKEN_TTL_MINUTES":     c.AccessTokenTTL,
+		"REFRESH_TOKEN_TTL_HOURS":      c.RefreshTokenTTL,
+		"SHUTDOWN_TIMEOUT_SECONDS":     c.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			v.fail(key, "must be positive")
+		}
+	}
+	for key, d := range map[string]time.Duration{
+		"LLM_CACHE_TTL_SECONDS":                c.LLMCacheTTL,
+		"WARM_FEED_GRACE_SECONDS":              c.WarmFeedGrace,
+		"SUBSCRIPTION_EXPIRY_INTERVAL_SECONDS": c.SubscriptionExpiryInterval,
+		"CONTEXT_SNAPSHOT_INTERVAL_SECONDS":    c.ContextSnapshotInterval,
+		"CONTEXT_SNAPSHOT_MAX_AGE_SECONDS":     c.ContextSnapshotMaxAge,
+		"FEED_HISTORY_RETENTION_HOURS":         c.FeedHistoryRetention,
+		"AUDIT_RETENTION_DAYS":                 c.AuditRetention,
+		"WS_PING_INTERVAL_SECONDS":             c.WSPingInterval,
+		"WS_PING_TIMEOUT_SECONDS":              c.WSPingTimeout,
+	} {
+		if d < 0 {
+			v.fail(key, "must not be negative")
+		}
+	}
+	return v.err()
+}
+
+// validator collects the problems found by Validate
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(key, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// err joins the problems sorted by variable, so the report is stable
+func (v *validator) err() error {
+	sort.Slice(v.errs, func(i, j int) bool { return v.errs[i].Error() < v.errs[j].Error() })
+	return errors.Join(v.errs...)
+}
+
+func (v *validator) min(key string, n, min int) {
+	if n < min {
+		v.fail(key, "must be at least %d", min)
+	}
+}
+
+func (v *validator) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		v.fail(key, "must be a port between 1 and 65535")
+	}
+}
+
+// url checks raw is an absolute URL with one of schemes and a host; optional
+// URLs may be empty
+func (v *validator) url(key, raw string, required bool, schemes ...string) {
+	if raw == "" {
+		if required {
+			v.fail(key, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		v.fail(key, "%q is not a URL", redactURL(raw))
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return
+		}
+	}
+	v.fail(key, "scheme must be %s", strings.Join(schemes, " or "))
+}
+
+// redactURL hides the password of a URL that is echoed in an error
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Redacted()
+	}
+	return "(unparseable)"
+}
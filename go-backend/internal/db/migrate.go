@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrationsCollection records the migrations applied to a database
+const migrationsCollection = "migrations"
+
+// Migration is one versioned change to the database. Up must be safe to run
+// again, as an instance may stop between applying and recording it, or two
+// instances may start together.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// migrationRecord is a migrations document; _id is the version
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Migrate applies the migrations not yet recorded in the database, oldest
+// first, recording each once it succeeds. It stops at the first failure so
+// later migrations never run on a database missing an earlier one, and
+// returns how many it applied.
+func Migrate(ctx context.Context, db *mongo.Database, migrations []Migration) (int, error) {
+	cur, err := db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	var records []migrationRecord
+	if err := cur.All(ctx, &records); err != nil {
+		return 0, err
+	}
+	applied := make(map[int]bool, len(records))
+	for _, r := range records {
+		applied[r.Version] = true
+	}
+
+	todo, err := pendingMigrations(migrations, applied)
+	if err != nil {
+		return 0, err
+	}
+	for i, m := range todo {
+		if err := m.Up(ctx, db); err != nil {
+			return i, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		_, err := db.Collection(migrationsCollection).InsertOne(ctx, migrationRecord{Version: m.Version, Description: m.Description, AppliedAt: time.Now().UTC()})
+		// Another instance may have recorded it first
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return i, fmt.Errorf("recording migration %d: %w", m.Version, err)
+		}
+		slog.Info("applied migration", "version", m.Version, "description", m.Description)
+	}
+	return len(todo), nil
+}
+
+// pendingMigrations returns the migrations not in applied, sorted by version
+func pendingMigrations(migrations []Migration, applied map[int]bool) ([]Migration, error) {
+	return pending(migrations, func(m Migration) int { return m.Version }, applied)
+}
+
+// pending returns the migrations whose version is not in applied, sorted by
+// version, checking that versions are positive and unique
+func pending[M any](migrations []M, version func(M) int, applied map[int]bool) ([]M, error) {
+	seen := make(map[int]bool, len(migrations))
+	var todo []M
+	for _, m := range migrations {
+		v := version(m)
+		if v <= 0 || seen[v] {
+			return nil, fmt.Errorf("migration version %d is not positive and unique", v)
+		}
+		seen[v] = true
+		if !applied[v] {
+			todo = append(todo, m)
+		}
+	}
+	sort.Slice(todo, func(i, j int) bool { return version(todo[i]) < version(todo[j]) })
+	return todo, nil
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 3}, {Version: 1}, {Version: 2}}
+
+	todo, err := pendingMigrations(migrations, map[int]bool{1: true})
+	require.NoError(t, err)
+	require.Len(t, todo, 2)
+	assert.Equal(t, 2, todo[0].Version)
+	assert.Equal(t, 3, todo[1].Version)
+
+	todo, err = pendingMigrations(migrations, map[int]bool{1: true, 2: true, 3: true})
+	require.NoError(t, err)
+	assert.Empty(t, todo)
+
+	_, err = pendingMigrations([]Migration{{Version: 1}, {Version: 1}}, nil)
+	assert.Error(t, err)
+	_, err = pendingMigrations([]Migration{{Version: 0}}, nil)
+	assert.Error(t, err)
+}
+
+func TestMigrations_Versions(t *testing.T) {
+	todo, err := pendingMigrations(Migrations, nil)
+	require.NoError(t, err)
+	for i, m := range todo {
+		assert.Equal(t, i+1, m.Version, "versions run from 1 without gaps")
+		assert.NotEmpty(t, m.Description)
+		assert.NotNil(t, m.Up)
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations are the server's schema changes, applied at startup. Append new
+// ones with the next version; never edit or renumber one that has shipped.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "feed listing, owner and text search indexes",
+		Up: createIndexes("websocket_feeds",
+			mongo.IndexModel{Keys: bson.D{{Key: "category", Value: 1}, {Key: "createdAt", Value: -1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "isPublic", Value: 1}, {Key: "createdAt", Value: -1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "isPublic", Value: 1}, {Key: "subscriberCount", Value: -1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}, {Key: "tags", Value: "text"}},
+				Options: options.Index().SetName("feed_search").
+					SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "tags", Value: 5}, {Key: "description", Value: 1}}),
+			},
+		),
+	},
+	{
+		Version:     2,
+		Description: "subscription lookup and expiry indexes",
+		Up: createIndexes("user_subscriptions",
+			mongo.IndexModel{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "feedId", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "feedId", Value: 1}, {Key: "isActive", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "isActive", Value: 1}, {Key: "expiresAt", Value: 1}}},
+		),
+	},
+	{
+		Version:     3,
+		Description: "session, API key and login lookup indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			steps := []func(context.Context, *mongo.Database) error{
+				createIndexes("sessions",
+					mongo.IndexModel{Keys: bson.D{{Key: "refreshTokenHash", Value: 1}}},
+					mongo.IndexModel{Keys: bson.D{{Key: "prevRefreshTokenHash", Value: 1}}, Options: options.Index().SetSparse(true)},
+					mongo.IndexModel{Keys: bson.D{{Key: "userId", Value: 1}}},
+				),
+				createIndexes("api_keys",
+					mongo.IndexModel{Keys: bson.D{{Key: "keyHash", Value: 1}}},
+					mongo.IndexModel{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+				),
+				createIndexes("users",
+					mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}},
+					mongo.IndexModel{Keys: bson.D{{Key: "oauthAccounts.provider", Value: 1}, {Key: "oauthAccounts.subject", Value: 1}}},
+				),
+				createIndexes("login_activity",
+					mongo.IndexModel{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "timestamp", Value: -1}}},
+				),
+			}
+			for _, step := range steps {
+				if err := step(ctx, db); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "organization feed index",
+		Up: createIndexes("websocket_feeds",
+			mongo.IndexModel{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetSparse(true)},
+		),
+	},
+	{
+		Version:     5,
+		Description: "deleted feed index",
+		Up: createIndexes("websocket_feeds",
+			mongo.IndexModel{Keys: bson.D{{Key: "deletedAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+		),
+	},
+}
+
+// createIndexes returns a migration step creating indexes on a collection;
+// creating an index that already exists is a no-op
+func createIndexes(collection string, indexes ...mongo.IndexModel) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
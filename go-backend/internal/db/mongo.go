@@ -5,6 +5,9 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/tracing"
 )
 
 // Client wraps a Mongo client and database handle.
@@ -25,7 +28,7 @@ func New(uri, dbName string) *Client {
 
 // Connect establishes the Mongo connection.
 func (c *Client) Connect(ctx context.Context) error {
-	opts := options.Client().ApplyURI(c.uri)
+	opts := options.Client().ApplyURI(c.uri).SetMonitor(tracing.MongoMonitor(metrics.MongoMonitor()))
 	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		return err
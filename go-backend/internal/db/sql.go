@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	// database/sql drivers for STORAGE_DRIVER=postgres and sqlite
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// sqlDrivers maps STORAGE_DRIVER values to database/sql driver names
+var sqlDrivers = map[string]string{
+	"postgres": "pgx",
+	"sqlite":   "sqlite",
+}
+
+// OpenSQL connects to a Postgres or SQLite database and checks it responds
+func OpenSQL(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	name, ok := sqlDrivers[driver]
+	if !ok {
+		return nil, fmt.Errorf("unknown SQL driver %q", driver)
+	}
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		// SQLite allows one writer; a single connection queues writes here
+		// instead of failing them with SQLITE_BUSY
+		db.SetMaxOpenConns(1)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// SQLMigration is one versioned change to a SQL database. Its statements run
+// in one transaction with the record of the migration, so either both land
+// or neither does.
+type SQLMigration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// MigrateSQL applies the migrations not yet recorded in schema_migrations,
+// oldest first, and returns how many it applied. Like Migrate it stops at
+// the first failure.
+func MigrateSQL(ctx context.Context, db *sql.DB, migrations []SQLMigration) (int, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return 0, err
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return 0, err
+	}
+	applied := map[int]bool{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	todo, err := pending(migrations, func(m SQLMigration) int { return m.Version }, applied)
+	if err != nil {
+		return 0, err
+	}
+	for i, m := range todo {
+		if err := applySQLMigration(ctx, db, m); err != nil {
+			return i, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		slog.Info("applied SQL migration", "version", m.Version, "description", m.Description)
+	}
+	return len(todo), nil
+}
+
+// applySQLMigration runs a migration and records it in one transaction
+func applySQLMigration(ctx context.Context, db *sql.DB, m SQLMigration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range m.Statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, description, applied_at) VALUES ($1, $2, $3)`,
+		m.Version, m.Description, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SQLMigrations create the tables of the SQL storage backend. Each table
+// keeps the full record as extended JSON in doc, next to the columns that
+// queries filter and sort on. Times are Unix milliseconds. Append new
+// migrations with the next version; never edit one that has shipped.
+var SQLMigrations = []SQLMigration{
+	{
+		Version:     1,
+		Description: "users, feeds, subscriptions, sessions and LLM usage",
+		Statements: []string{
+			`CREATE TABLE users (
+				id TEXT PRIMARY KEY,
+				email TEXT NOT NULL,
+				name TEXT NOT NULL,
+				role TEXT NOT NULL,
+				created_at BIGINT NOT NULL,
+				doc TEXT NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX users_email ON users (email)`,
+			`CREATE INDEX users_created_at ON users (created_at)`,
+			`CREATE TABLE user_oauth_accounts (
+				provider TEXT NOT NULL,
+				subject TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				PRIMARY KEY (provider, subject)
+			)`,
+			`CREATE INDEX user_oauth_accounts_user ON user_oauth_accounts (user_id)`,
+			`CREATE TABLE websocket_feeds (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				description TEXT NOT NULL,
+				tags TEXT NOT NULL,
+				category TEXT NOT NULL,
+				is_public BOOLEAN NOT NULL,
+				is_verified BOOLEAN NOT NULL,
+				owner_id TEXT NOT NULL,
+				subscriber_count BIGINT NOT NULL,
+				created_at BIGINT NOT NULL,
+				doc TEXT NOT NULL
+			)`,
+			`CREATE INDEX websocket_feeds_category ON websocket_feeds (category, created_at)`,
+			`CREATE INDEX websocket_feeds_public ON websocket_feeds (is_public, created_at)`,
+			`CREATE INDEX websocket_feeds_owner ON websocket_feeds (owner_id)`,
+			`CREATE TABLE user_subscriptions (
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				feed_id TEXT NOT NULL,
+				is_active BOOLEAN NOT NULL,
+				expires_at BIGINT,
+				doc TEXT NOT NULL,
+				UNIQUE (user_id, feed_id)
+			)`,
+			`CREATE INDEX user_subscriptions_feed ON user_subscriptions (feed_id, is_active)`,
+			`CREATE INDEX user_subscriptions_expiry ON user_subscriptions (is_active, expires_at)`,
+			`CREATE TABLE sessions (
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				refresh_token_hash TEXT NOT NULL,
+				prev_refresh_token_hash TEXT NOT NULL,
+				is_active BOOLEAN NOT NULL,
+				expires_at BIGINT NOT NULL,
+				doc TEXT NOT NULL
+			)`,
+			`CREATE INDEX sessions_refresh_token ON sessions (refresh_token_hash)`,
+			`CREATE INDEX sessions_prev_refresh_token ON sessions (prev_refresh_token_hash)`,
+			`CREATE INDEX sessions_user ON sessions (user_id)`,
+			`CREATE TABLE llm_usage (
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				created_at BIGINT NOT NULL,
+				doc TEXT NOT NULL
+			)`,
+			`CREATE INDEX llm_usage_user ON llm_usage (user_id, created_at)`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "organization-owned feeds",
+		Statements: []string{
+			`ALTER TABLE websocket_feeds ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX websocket_feeds_org ON websocket_feeds (org_id, created_at)`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "soft-deleted feeds",
+		Statements: []string{
+			`ALTER TABLE websocket_feeds ADD COLUMN deleted_at BIGINT`,
+			`CREATE INDEX websocket_feeds_deleted ON websocket_feeds (deleted_at)`,
+		},
+	},
+}
@@ -1,38 +1,160 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 )
 
-// AuthMiddleware verifies the JWT and injects userId/email/username into the context.
+// APIKeyHeader carries an API key in place of a bearer token
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyRoute is the scope an API key needs on the feed named by param
+type apiKeyRoute struct {
+	scope string
+	param string
+}
+
+// apiKeyRoutes lists the endpoints API keys may call; everything else needs a user token
+var apiKeyRoutes = map[string]apiKeyRoute{
+	"POST /api/marketplace/feeds/:id/data":   {models.APIKeyScopePublish, "id"},
+	"GET /api/marketplace/feeds/:id/sample":  {models.APIKeyScopeRead, "id"},
+	"GET /api/marketplace/feeds/:id/history": {models.APIKeyScopeRead, "id"},
+	"GET /api/marketplace/feeds/:id/health":  {models.APIKeyScopeRead, "id"},
+	"GET /api/marketplace/feeds/:id/schema":  {models.APIKeyScopeRead, "id"},
+}
+
+// AuthMiddleware verifies the access token and its session, then injects
+// userId/sessionId/email/username into the context. Requests with an
+// X-API-Key header act as the key's owner on the routes API keys may call.
 func AuthMiddleware(auth *services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if secret := c.GetHeader(APIKeyHeader); secret != "" {
+			authenticateAPIKey(c, auth, secret)
+			return
+		}
 		header := c.GetHeader("Authorization")
 		if header == "" || !strings.HasPrefix(strings.ToLower(header), "bearer ") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "missing token"})
 			return
 		}
 		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer"))
-		claims, err := auth.ParseToken(token)
-		if err != nil {
+		claims, err := auth.Authenticate(c.Request.Context(), token)
+		if errors.Is(err, services.ErrInvalidToken) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "invalid token"})
 			return
 		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"success": false, "message": "failed to verify session"})
+			return
+		}
 		userIDStr, _ := claims["userId"].(string)
+		sessionIDStr, _ := claims["sessionId"].(string)
 		userOID, err := primitive.ObjectIDFromHex(userIDStr)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "invalid token"})
 			return
 		}
+		sessionOID, _ := primitive.ObjectIDFromHex(sessionIDStr)
 		c.Set("userId", userOID)
+		c.Set("sessionId", sessionOID)
 		c.Set("userEmail", claims["email"])
 		c.Set("username", claims["username"])
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware sets userId, userEmail and username like
+// AuthMiddleware when the request carries a valid bearer token and lets it
+// through anonymously otherwise, for public routes whose answer depends on
+// who is asking
+func OptionalAuthMiddleware(auth *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(strings.ToLower(header), "bearer ") {
+			c.Next()
+			return
+		}
+		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer"))
+		claims, err := auth.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.Next()
+			return
+		}
+		userIDStr, _ := claims["userId"].(string)
+		if userOID, err := primitive.ObjectIDFromHex(userIDStr); err == nil {
+			c.Set("userId", userOID)
+			c.Set("userEmail", claims["email"])
+			c.Set("username", claims["username"])
+		}
+		c.Next()
+	}
+}
+
+// UserRoles looks up a user's role; *services.AuthService implements it
+type UserRoles interface {
+	GetUserRole(ctx context.Context, userID primitive.ObjectID) (string, error)
+}
+
+// RequireRole lets the request through only when the authenticated user has
+// one of roles, and stores the role as "role" in the context. It must run
+// after AuthMiddleware. The role is read on every request so role changes
+// apply without waiting for tokens to expire.
+func RequireRole(users UserRoles, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userId")
+		oid, isOID := userID.(primitive.ObjectID)
+		if !ok || !isOID {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "missing token"})
+			return
+		}
+		role, err := users.GetUserRole(c.Request.Context(), oid)
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "invalid token"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"success": false, "message": "failed to load user role"})
+			return
+		}
+		if !(models.User{Role: role}).HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "message": "insufficient role", "code": "insufficient_role"})
+			return
+		}
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey verifies the key and that it grants the scope this route needs
+func authenticateAPIKey(c *gin.Context, auth *services.AuthService, secret string) {
+	key, err := auth.AuthenticateAPIKey(c.Request.Context(), secret)
+	if errors.Is(err, services.ErrInvalidAPIKey) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "invalid API key"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"success": false, "message": "failed to verify API key"})
+		return
+	}
+	if !apiKeyAllowed(c, key) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "message": "API key is not allowed to access this endpoint"})
+		return
+	}
+	c.Set("userId", key.UserID)
+	c.Set("apiKey", key)
+	c.Next()
+}
+
+// apiKeyAllowed reports whether the route accepts API keys and the key grants its scope on the route's feed
+func apiKeyAllowed(c *gin.Context, key models.APIKey) bool {
+	route, ok := apiKeyRoutes[c.Request.Method+" "+c.FullPath()]
+	return ok && key.Allows(c.Param(route.param), route.scope)
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestAPIKeyAllowed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feedID := primitive.NewObjectID().Hex()
+	otherFeedID := primitive.NewObjectID().Hex()
+	key := models.APIKey{Grants: []models.APIKeyGrant{
+		{FeedID: feedID, Scopes: []string{models.APIKeyScopePublish}},
+		{FeedID: otherFeedID, Scopes: []string{models.APIKeyScopeRead}},
+	}}
+
+	router := gin.New()
+	check := func(c *gin.Context) {
+		if apiKeyAllowed(c, key) {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Status(http.StatusForbidden)
+	}
+	router.POST("/api/marketplace/feeds/:id/data", check)
+	router.GET("/api/marketplace/feeds/:id/history", check)
+	router.DELETE("/api/marketplace/feeds/:id", check)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"publish with publish scope", http.MethodPost, "/api/marketplace/feeds/" + feedID + "/data", http.StatusOK},
+		{"publish with read scope only", http.MethodPost, "/api/marketplace/feeds/" + otherFeedID + "/data", http.StatusForbidden},
+		{"read with read scope", http.MethodGet, "/api/marketplace/feeds/" + otherFeedID + "/history", http.StatusOK},
+		{"read with publish scope only", http.MethodGet, "/api/marketplace/feeds/" + feedID + "/history", http.StatusForbidden},
+		{"feed not granted", http.MethodPost, "/api/marketplace/feeds/" + primitive.NewObjectID().Hex() + "/data", http.StatusForbidden},
+		{"route not open to API keys", http.MethodDelete, "/api/marketplace/feeds/" + feedID, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+// fakeRoles serves roles from a map for RequireRole tests
+type fakeRoles map[primitive.ObjectID]string
+
+func (f fakeRoles) GetUserRole(_ context.Context, userID primitive.ObjectID) (string, error) {
+	role, ok := f[userID]
+	if !ok {
+		return "", services.ErrUserNotFound
+	}
+	return role, nil
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admin := primitive.NewObjectID()
+	curator := primitive.NewObjectID()
+	legacy := primitive.NewObjectID()
+	roles := fakeRoles{admin: models.RoleAdmin, curator: models.RoleCurator, legacy: ""}
+
+	tests := []struct {
+		name    string
+		userID  interface{}
+		allowed []string
+		want    int
+	}{
+		{"admin on admin route", admin, []string{models.RoleAdmin}, http.StatusOK},
+		{"curator on admin route", curator, []string{models.RoleAdmin}, http.StatusForbidden},
+		{"curator on curator route", curator, []string{models.RoleCurator, models.RoleAdmin}, http.StatusOK},
+		{"user without stored role counts as user", legacy, []string{models.RoleUser}, http.StatusOK},
+		{"user without stored role on curator route", legacy, []string{models.RoleCurator}, http.StatusForbidden},
+		{"unknown user", primitive.NewObjectID(), []string{models.RoleAdmin}, http.StatusUnauthorized},
+		{"not authenticated", nil, []string{models.RoleAdmin}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				if tt.userID != nil {
+					c.Set("userId", tt.userID)
+				}
+			}, RequireRole(roles, tt.allowed...), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// accountConfirmation re-confirms the caller's identity before an account
+// export or deletion
+type accountConfirmation struct {
+	Password  string `json:"password"`
+	TotpToken string `json:"totpToken"`
+	// WebAuthn answers the security key challenge of an earlier attempt
+	WebAuthn *services.WebAuthnAssertion `json:"webauthn"`
+	// Email confirms accounts that have no password
+	Email string `json:"email"`
+}
+
+// confirmAccount checks the caller's password and second factor, read from the
+// JSON body or, for requests without one, the X-Confirm-Password,
+// X-Confirm-TOTP and X-Confirm-Email headers. It answers the request and
+// returns false when they do not match.
+func (h *AuthHandler) confirmAccount(c *gin.Context, action string) (models.User, bool) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	body := accountConfirmation{
+		Password:  c.GetHeader("X-Confirm-Password"),
+		TotpToken: c.GetHeader("X-Confirm-TOTP"),
+		Email:     c.GetHeader("X-Confirm-Email"),
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+			return models.User{}, false
+		}
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	user, err := h.Service.ConfirmIdentity(ctx, userID, body.Password, body.Email, body.TotpToken, body.WebAuthn)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorRequired) {
+			h.twoFactorChallenge(c, user, err, models.WebAuthnChallengeConfirm)
+			return models.User{}, false
+		}
+		recordAudit(c, h.Audit, auditFailure(auditEvent(c, action, services.AuditTargetUser, userID.Hex()), err))
+		status, code := errorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{"success": false, "message": err.Error(), "code": code, "requiresTwoFactor": user.TwoFactor})
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// exportAccount returns every record kept about the caller as a JSON download
+func (h *AuthHandler) exportAccount(c *gin.Context) {
+	if h.Account == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "account export is disabled"})
+		return
+	}
+	user, ok := h.confirmAccount(c, services.AuditAccountExport)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	export, err := h.Account.Export(ctx, user)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditAccountExport, services.AuditTargetUser, user.ID.Hex()))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="turbostream-export-%s.json"`, user.ID.Hex()))
+	c.JSON(http.StatusOK, export)
+}
+
+// deleteAccount removes the caller's account and everything they own, then
+// disconnects their feeds and websocket clients
+func (h *AuthHandler) deleteAccount(c *gin.Context) {
+	if h.Account == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "account deletion is disabled"})
+		return
+	}
+	user, ok := h.confirmAccount(c, services.AuditAccountDelete)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	deletion, err := h.Account.Delete(ctx, user)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditAccountDelete, services.AuditTargetUser, user.ID.Hex()), map[string]interface{}{"email": user.Email, "feeds": deletion.FeedIDs}, nil))
+	if h.Sockets != nil {
+		for _, feedID := range deletion.FeedIDs {
+			h.Sockets.StopFeed(feedID)
+		}
+		h.Sockets.DisconnectUser(user.ID.Hex())
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Account deleted", "data": deletion})
+}
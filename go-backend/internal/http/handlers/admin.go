@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+)
+
+// maxAdminListLimit caps how many users or feeds one admin listing returns
+const maxAdminListLimit = 200
+
+// AdminHandler handles user management and feed moderation for admins
+type AdminHandler struct {
+	Auth        *services.AuthService
+	Marketplace *services.MarketplaceService
+	Sockets     *socket.Manager
+	// Audit records admin actions and serves the audit log; nil disables both
+	Audit *services.AuditService
+}
+
+// NewAdminHandler creates a new admin handler instance
+func NewAdminHandler(auth *services.AuthService, marketplace *services.MarketplaceService, sockets *socket.Manager) *AdminHandler {
+	return &AdminHandler{Auth: auth, Marketplace: marketplace, Sockets: sockets}
+}
+
+// RegisterRoutes attaches admin endpoints; the group must require the admin role
+func (h *AdminHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/users", h.users)
+	r.PUT("/users/:id/role", h.setRole)
+	r.PUT("/users/:id/token-quota", h.setTokenQuota)
+	r.GET("/feeds", h.feeds)
+	r.PUT("/feeds/:id/moderation", h.moderateFeed)
+	r.POST("/feeds/:id/verify", h.verifyFeed)
+	r.GET("/audit", h.auditLog)
+}
+
+// users lists users, optionally filtered by ?search= on email or name
+func (h *AdminHandler) users(c *gin.Context) {
+	limit, skip := adminPage(c)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	users, err := h.Auth.ListUsers(ctx, c.Query("search"), limit, skip)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": users, "count": len(users)})
+}
+
+// setRole changes a user's role to admin, curator or user
+func (h *AdminHandler) setRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid user id"})
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	before, err := h.Auth.GetUser(ctx, userID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	user, err := h.Auth.SetRole(ctx, userID, body.Role)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditAdminSetRole, services.AuditTargetUser, userID.Hex()), before, user))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": user})
+}
+
+// setTokenQuota overrides a user's monthly token quota; a null or missing
+// limit returns the user to the configured quota
+func (h *AdminHandler) setTokenQuota(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid user id"})
+		return
+	}
+	var body struct {
+		Limit *int64 `json:"limit"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	before, err := h.Auth.GetUser(ctx, userID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	user, err := h.Auth.SetTokenQuota(ctx, userID, body.Limit)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditAdminSetQuota, services.AuditTargetUser, userID.Hex()), before, user))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": user})
+}
+
+// feeds lists every feed for moderation, optionally filtered by
+// ?verified=true|false; ?verified=false is the verification review queue
+func (h *AdminHandler) feeds(c *gin.Context) {
+	verified, err := parseOptionalBool(c.Query("verified"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "verified must be true or false"})
+		return
+	}
+	limit, skip := adminPage(c)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feeds, err := h.Marketplace.GetAllFeeds(ctx, verified, limit, skip)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feeds, "count": len(feeds)})
+}
+
+// moderateFeed revokes verification or (de)activates any feed. Deactivating
+// disconnects the upstream and keeps it from reconnecting until the feed is
+// reactivated. Verifying goes through verifyFeed so the checks always run.
+func (h *AdminHandler) moderateFeed(c *gin.Context) {
+	id := c.Param("id")
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid id"})
+		return
+	}
+	var body struct {
+		IsVerified *bool `json:"isVerified"`
+		IsActive   *bool `json:"isActive"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	updates := bson.M{}
+	if body.IsVerified != nil {
+		if *body.IsVerified {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "use POST /api/admin/feeds/:id/verify to verify a feed"})
+			return
+		}
+		updates["isVerified"] = false
+	}
+	if body.IsActive != nil {
+		updates["isActive"] = *body.IsActive
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "isVerified or isActive required"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	before, err := h.Marketplace.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	feed, err := h.Marketplace.UpdateFeed(ctx, oid, updates)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditAdminModerate, services.AuditTargetFeed, id), before, feed))
+	if h.Sockets != nil && before.IsActive != feed.IsActive {
+		if !feed.IsActive {
+			h.Sockets.PauseFeed(id)
+		} else if err := h.Sockets.ResumeFeed(*feed); err != nil {
+			slog.Warn("failed to reconnect reactivated feed", "feed_id", id, "error", err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feed})
+}
+
+// verifyFeed runs the connectivity and schema checks on a feed and marks it
+// verified when they pass. {"force": true} verifies despite failed checks,
+// e.g. for connection types that cannot be sampled. The check results are
+// stored on the feed either way.
+func (h *AdminHandler) verifyFeed(c *gin.Context) {
+	adminID := c.MustGet("userId").(primitive.ObjectID)
+	id := c.Param("id")
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid id"})
+		return
+	}
+	var body struct {
+		Force bool `json:"force"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+			return
+		}
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Marketplace.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	verification := h.Sockets.CheckFeed(ctx, *feed)
+	verified := verification.Passed || body.Force
+	if verified {
+		now := time.Now().UTC()
+		verification.Forced = !verification.Passed
+		verification.VerifiedBy = adminID.Hex()
+		verification.VerifiedAt = &now
+	}
+	updated, err := h.Marketplace.UpdateFeed(ctx, oid, bson.M{"isVerified": verified, "verification": verification})
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	event := auditChange(auditEvent(c, services.AuditAdminVerify, services.AuditTargetFeed, id), feed, updated)
+	if !verified {
+		event.Outcome = models.AuditFailure
+		event.Reason = "feed failed verification checks"
+	}
+	recordAudit(c, h.Audit, event)
+	if !verified {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "message": "feed failed verification checks", "code": "verification_failed", "data": updated})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
+}
+
+// adminPage reads ?limit= (default 50, capped) and ?skip= for admin listings
+func adminPage(c *gin.Context) (limit, skip int64) {
+	l := parseLimit(c.Query("limit"), 50)
+	if l > maxAdminListLimit {
+		l = maxAdminListLimit
+	}
+	return int64(l), int64(parseLimit(c.Query("skip"), 0))
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+)
+
+// alertRulePayload is the body of alert rule create and update requests;
+// fields left out keep their current value
+type alertRulePayload struct {
+	Name            *string `json:"name"`
+	Condition       *string `json:"condition"`
+	CooldownSeconds *int    `json:"cooldownSeconds"`
+	Enabled         *bool   `json:"enabled"`
+	NotifyEmail     *bool   `json:"notifyEmail"`
+	WebhookURL      *string `json:"webhookUrl"`
+}
+
+// apply copies the fields present in the payload onto rule
+func (p alertRulePayload) apply(rule *models.AlertRule) {
+	if p.Name != nil {
+		rule.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.Condition != nil {
+		rule.Condition = strings.TrimSpace(*p.Condition)
+	}
+	if p.CooldownSeconds != nil {
+		rule.CooldownSeconds = *p.CooldownSeconds
+	}
+	if p.Enabled != nil {
+		rule.Enabled = *p.Enabled
+	}
+	if p.NotifyEmail != nil {
+		rule.NotifyEmail = *p.NotifyEmail
+	}
+	if p.WebhookURL != nil {
+		rule.WebhookURL = strings.TrimSpace(*p.WebhookURL)
+	}
+}
+
+// alertsEnabled answers 503 when alert rules are not available
+func (h *MarketplaceHandler) alertsEnabled(c *gin.Context) bool {
+	if h.Alerts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "alerts are disabled"})
+		return false
+	}
+	return true
+}
+
+// listAlerts returns the user's alert rules for a subscribed feed
+func (h *MarketplaceHandler) listAlerts(c *gin.Context) {
+	if !h.alertsEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	rules, err := h.Alerts.ListAlertRules(ctx, userID.Hex(), c.Param("feedId"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": rules, "count": len(rules)})
+}
+
+// createAlert adds an alert rule to the user's active subscription to a feed
+func (h *MarketplaceHandler) createAlert(c *gin.Context) {
+	if !h.alertsEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	feedID := c.Param("feedId")
+	var body alertRulePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	rule := models.AlertRule{UserID: userID.Hex(), FeedID: feedID, Enabled: true}
+	body.apply(&rule)
+	if err := socket.ValidateAlertCondition(rule.Condition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if _, err := h.Service.GetSubscription(ctx, userID.Hex(), feedID); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	created, err := h.Alerts.CreateAlertRule(ctx, rule)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	h.reloadAlerts(feedID)
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": created})
+}
+
+// updateAlert edits one of the user's alert rules for a feed
+func (h *MarketplaceHandler) updateAlert(c *gin.Context) {
+	if !h.alertsEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	feedID := c.Param("feedId")
+	ruleID, err := primitive.ObjectIDFromHex(c.Param("alertId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid alert id"})
+		return
+	}
+	var body alertRulePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	rule, err := h.Alerts.GetAlertRule(ctx, userID.Hex(), feedID, ruleID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	body.apply(rule)
+	if err := socket.ValidateAlertCondition(rule.Condition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	updated, err := h.Alerts.ReplaceAlertRule(ctx, *rule)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	h.reloadAlerts(feedID)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
+}
+
+// deleteAlert removes one of the user's alert rules for a feed
+func (h *MarketplaceHandler) deleteAlert(c *gin.Context) {
+	if !h.alertsEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	feedID := c.Param("feedId")
+	ruleID, err := primitive.ObjectIDFromHex(c.Param("alertId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid alert id"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Alerts.DeleteAlertRule(ctx, userID.Hex(), feedID, ruleID); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	h.reloadAlerts(feedID)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Alert deleted"})
+}
+
+// reloadAlerts applies rule changes to the feed if it is connected on this instance
+func (h *MarketplaceHandler) reloadAlerts(feedID string) {
+	if h.Sockets != nil {
+		h.Sockets.ReloadAlertRules(feedID)
+	}
+}
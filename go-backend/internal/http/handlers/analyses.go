@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// schedulePayload is the body of analysis schedule create and update
+// requests; fields left out keep their current value
+type schedulePayload struct {
+	FeedID          *string `json:"feedId"`
+	Name            *string `json:"name"`
+	Prompt          *string `json:"prompt"`
+	Provider        *string `json:"provider"`
+	IntervalSeconds *int    `json:"intervalSeconds"`
+	Enabled         *bool   `json:"enabled"`
+}
+
+// apply copies the fields present in the payload onto schedule
+func (p schedulePayload) apply(schedule *models.AnalysisSchedule) {
+	if p.FeedID != nil {
+		schedule.FeedID = strings.TrimSpace(*p.FeedID)
+	}
+	if p.Name != nil {
+		schedule.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.Prompt != nil {
+		schedule.Prompt = strings.TrimSpace(*p.Prompt)
+	}
+	if p.Provider != nil {
+		schedule.Provider = strings.ToLower(strings.TrimSpace(*p.Provider))
+	}
+	if p.IntervalSeconds != nil {
+		schedule.IntervalSeconds = *p.IntervalSeconds
+	}
+	if p.Enabled != nil {
+		schedule.Enabled = *p.Enabled
+	}
+}
+
+// schedulesEnabled answers 503 when scheduled analyses are not available
+func (h *LLMHandler) schedulesEnabled(c *gin.Context) bool {
+	if h.Analyses == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scheduled analyses are disabled"})
+		return false
+	}
+	return true
+}
+
+// scheduleID parses the :id path parameter, answering 400 when it is not an ID
+func scheduleID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// respondLLMError answers with the status and code of a service error
+func respondLLMError(c *gin.Context, err error) {
+	status, code := errorStatus(err, http.StatusInternalServerError)
+	c.JSON(status, gin.H{"error": err.Error(), "code": code})
+}
+
+// ListSchedules returns the user's analysis schedules
+// GET /api/llm/schedules
+func (h *LLMHandler) ListSchedules(c *gin.Context) {
+	if !h.schedulesEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	schedules, err := h.Analyses.ListSchedules(ctx, userID.Hex())
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules, "count": len(schedules)})
+}
+
+// CreateSchedule adds an analysis schedule; it first runs within a check
+// interval once the feed has data
+// POST /api/llm/schedules
+func (h *LLMHandler) CreateSchedule(c *gin.Context) {
+	if !h.schedulesEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body schedulePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	schedule := models.AnalysisSchedule{UserID: userID.Hex(), Enabled: true}
+	body.apply(&schedule)
+	if schedule.FeedID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "feedId is required"})
+		return
+	}
+	if !h.canQueryFeed(c, schedule.FeedID) {
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	created, err := h.Analyses.CreateSchedule(ctx, schedule)
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetSchedule returns one of the user's analysis schedules
+// GET /api/llm/schedules/:id
+func (h *LLMHandler) GetSchedule(c *gin.Context) {
+	if !h.schedulesEnabled(c) {
+		return
+	}
+	id, ok := scheduleID(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	schedule, err := h.Analyses.GetSchedule(ctx, userID.Hex(), id)
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule edits one of the user's analysis schedules
+// PUT /api/llm/schedules/:id
+func (h *LLMHandler) UpdateSchedule(c *gin.Context) {
+	if !h.schedulesEnabled(c) {
+		return
+	}
+	id, ok := scheduleID(c)
+	if !ok {
+		return
+	}
+	var body schedulePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	schedule, err := h.Analyses.GetSchedule(ctx, userID.Hex(), id)
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	body.apply(schedule)
+	if schedule.FeedID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "feedId is required"})
+		return
+	}
+	if !h.canQueryFeed(c, schedule.FeedID) {
+		return
+	}
+	updated, err := h.Analyses.ReplaceSchedule(ctx, *schedule)
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteSchedule removes one of the user's analysis schedules and its results
+// DELETE /api/llm/schedules/:id
+func (h *LLMHandler) DeleteSchedule(c *gin.Context) {
+	if !h.schedulesEnabled(c) {
+		return
+	}
+	id, ok := scheduleID(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Analyses.DeleteSchedule(ctx, userID.Hex(), id); err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "schedule deleted"})
+}
+
+// ListAnalyses returns the latest results of one of the user's schedules,
+// newest first; limit defaults to 20 and is capped at 100
+// GET /api/llm/schedules/:id/analyses
+func (h *LLMHandler) ListAnalyses(c *gin.Context) {
+	if !h.schedulesEnabled(c) {
+		return
+	}
+	id, ok := scheduleID(c)
+	if !ok {
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	analyses, err := h.Analyses.ListAnalyses(ctx, userID.Hex(), id, limit)
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"analyses": analyses, "count": len(analyses)})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// auditTimeout bounds writing one audit event
+const auditTimeout = 5 * time.Second
+
+// auditEvent starts an audit event for the request, with the caller as actor
+// when the request is authenticated
+func auditEvent(c *gin.Context, action, targetType, targetID string) models.AuditEvent {
+	event := models.AuditEvent{
+		Action:     action,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if userID, ok := c.Get("userId"); ok {
+		if oid, ok := userID.(primitive.ObjectID); ok {
+			event.ActorID = oid.Hex()
+		}
+	}
+	if email, ok := c.Get("userEmail"); ok {
+		event.ActorEmail, _ = email.(string)
+	}
+	return event
+}
+
+// recordAudit stores an audit event without failing the request; it still
+// runs when the client has gone away. A nil service records nothing.
+func recordAudit(c *gin.Context, audit *services.AuditService, event models.AuditEvent) {
+	if audit == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditTimeout)
+	defer cancel()
+	if err := audit.Record(ctx, event); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// auditFailure marks an audit event as a failed attempt
+func auditFailure(event models.AuditEvent, err error) models.AuditEvent {
+	event.Outcome = models.AuditFailure
+	event.Reason = err.Error()
+	return event
+}
+
+// auditChange adds the fields that changed between before and after
+func auditChange(event models.AuditEvent, before, after interface{}) models.AuditEvent {
+	event.Before, event.After = services.AuditDiff(before, after)
+	return event
+}
+
+// auditLog returns audit events, newest first. Query parameters: action,
+// actorId, targetType, targetId, outcome (success|failure), from and to
+// (RFC3339), limit (default 50, max 500) and offset.
+func (h *AdminHandler) auditLog(c *gin.Context) {
+	if h.Audit == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "audit log is disabled"})
+		return
+	}
+	from, to, err := parseTimeRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	outcome := c.Query("outcome")
+	if outcome != "" && outcome != models.AuditSuccess && outcome != models.AuditFailure {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "outcome must be success or failure"})
+		return
+	}
+	filter := services.AuditFilter{
+		Action:     c.Query("action"),
+		ActorID:    c.Query("actorId"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("targetId"),
+		Outcome:    outcome,
+		From:       from,
+		To:         to,
+		Limit:      int64(parseLimit(c.Query("limit"), 0)),
+		Offset:     int64(parseLimit(c.Query("offset"), 0)),
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	events, total, err := h.Audit.List(ctx, filter)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": events, "count": len(events), "total": total})
+}
@@ -2,21 +2,42 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
 )
 
 // AuthHandler handles HTTP requests for authentication and user management
 type AuthHandler struct {
 	Service *services.AuthService
+	// Audit records sign-ins and account security changes; nil records nothing
+	Audit *services.AuditService
+	// Account exports and deletes accounts; nil disables both
+	Account *services.AccountService
+	// Sockets disconnects the feeds and clients of deleted accounts
+	Sockets *socket.Manager
+	// OAuthFrontendURL receives tokens in its URL fragment after OAuth sign-in;
+	// when empty the callback answers with JSON
+	OAuthFrontendURL string
 }
 
+// oauthStateCookie binds an OAuth sign-in to the browser that started it
+const oauthStateCookie = "oauth_state"
+
+// emailTimeout bounds sending one email after the request has been answered
+const emailTimeout = 30 * time.Second
+
 // NewAuthHandler creates a new authentication handler instance
 func NewAuthHandler(service *services.AuthService) *AuthHandler {
 	return &AuthHandler{Service: service}
@@ -26,6 +47,14 @@ func NewAuthHandler(service *services.AuthService) *AuthHandler {
 func (h *AuthHandler) RegisterPublic(r *gin.RouterGroup) {
 	r.POST("/register", h.register)
 	r.POST("/login", h.login)
+	r.POST("/refresh", h.refresh)
+	r.POST("/verify-email", h.verifyEmail)
+	r.POST("/forgot-password", h.forgotPassword)
+	r.POST("/reset-password", h.resetPassword)
+	r.GET("/oauth/providers", h.oauthProviders)
+	r.GET("/oauth/:provider", h.oauthStart)
+	r.GET("/oauth/:provider/callback", h.oauthCallback)
+	r.POST("/oauth/complete", h.oauthComplete)
 }
 
 // RegisterProtected attaches endpoints that require a valid JWT.
@@ -33,18 +62,28 @@ func (h *AuthHandler) RegisterProtected(r *gin.RouterGroup) {
 	r.GET("/me", h.me)
 	r.POST("/logout", h.logout)
 	r.POST("/change-password", h.changePassword)
+	r.POST("/resend-verification", h.resendVerification)
+	r.GET("/export", h.exportAccount)
+	r.DELETE("/account", h.deleteAccount)
 	r.POST("/2fa/setup", h.twoFactorSetup)
 	r.POST("/2fa/enable", h.enableTwoFactor)
 	r.POST("/2fa/disable", h.disableTwoFactor)
+	r.POST("/webauthn/register/begin", h.webAuthnRegisterBegin)
+	r.POST("/webauthn/register/finish", h.webAuthnRegisterFinish)
+	r.GET("/webauthn/credentials", h.webAuthnCredentials)
+	r.DELETE("/webauthn/credentials/:id", h.deleteWebAuthnCredential)
 	r.GET("/2fa/backup-codes/status", h.backupCodeStatus)
 	r.POST("/2fa/backup-codes/regenerate", h.regenerateBackupCodes)
 	r.GET("/sessions", h.sessions)
 	r.DELETE("/sessions/:id", h.terminateSession)
 	r.POST("/sessions/terminate-others", h.terminateOthers)
 	r.GET("/login-activity", h.loginActivity)
+	r.GET("/api-keys", h.apiKeys)
+	r.POST("/api-keys", h.createAPIKey)
+	r.DELETE("/api-keys/:id", h.revokeAPIKey)
 }
 
-// register handles user registration and returns JWT token
+// register handles user registration and returns the new session's tokens
 func (h *AuthHandler) register(c *gin.Context) {
 	var body struct {
 		Email    string `json:"email"`
@@ -58,20 +97,27 @@ func (h *AuthHandler) register(c *gin.Context) {
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
 
-	token, user, err := h.Service.Register(ctx, body.Email, body.Password, body.Name)
+	tokens, user, err := h.Service.Register(ctx, body.Email, body.Password, body.Name)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "User registered successfully", "token": token, "user": user})
+	h.auditSignIn(c, services.AuditRegister, user)
+	if h.Service.EmailEnabled() {
+		go h.sendVerificationEmail(user)
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "User registered successfully", "token": tokens.AccessToken, "refreshToken": tokens.RefreshToken, "expiresIn": tokens.ExpiresIn, "user": user})
 }
 
-// login authenticates user with email/password and optional 2FA
+// login authenticates user with email/password and optional 2FA: a TOTP or
+// backup code, or a security key assertion answering the options returned
+// when the second factor was asked for
 func (h *AuthHandler) login(c *gin.Context) {
 	var body struct {
-		Email     string `json:"email"`
-		Password  string `json:"password"`
-		TotpToken string `json:"totpToken"`
+		Email     string                      `json:"email"`
+		Password  string                      `json:"password"`
+		TotpToken string                      `json:"totpToken"`
+		WebAuthn  *services.WebAuthnAssertion `json:"webauthn"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
@@ -80,12 +126,166 @@ func (h *AuthHandler) login(c *gin.Context) {
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
 
-	token, user, err := h.Service.Login(ctx, body.Email, body.Password, body.TotpToken, c.ClientIP(), c.Request.UserAgent())
+	var (
+		tokens services.AuthTokens
+		user   models.User
+		err    error
+	)
+	if body.WebAuthn != nil {
+		tokens, user, err = h.Service.LoginWebAuthn(ctx, body.Email, body.Password, *body.WebAuthn, c.ClientIP(), c.Request.UserAgent())
+	} else {
+		tokens, user, err = h.Service.Login(ctx, body.Email, body.Password, body.TotpToken, c.ClientIP(), c.Request.UserAgent())
+	}
+	if err != nil {
+		// Asking for the 2FA code is a step of signing in, not a failed attempt
+		if errors.Is(err, services.ErrTwoFactorRequired) {
+			h.twoFactorChallenge(c, user, err, models.WebAuthnChallengeLogin)
+			return
+		}
+		event := auditEvent(c, services.AuditLogin, services.AuditTargetUser, "")
+		event.ActorEmail = strings.ToLower(strings.TrimSpace(body.Email))
+		recordAudit(c, h.Audit, auditFailure(event, err))
+		status, code := errorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{"success": false, "message": err.Error(), "code": code, "requiresTwoFactor": user.TwoFactor})
+		return
+	}
+	h.auditSignIn(c, services.AuditLogin, user)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login successful", "token": tokens.AccessToken, "refreshToken": tokens.RefreshToken, "expiresIn": tokens.ExpiresIn, "user": user})
+}
+
+// auditSignIn records a successful sign-in, which happens before the request
+// carries the user's identity
+func (h *AuthHandler) auditSignIn(c *gin.Context, action string, user models.User) {
+	event := auditEvent(c, action, services.AuditTargetUser, user.ID.Hex())
+	event.ActorID = user.ID.Hex()
+	event.ActorEmail = user.Email
+	recordAudit(c, h.Audit, event)
+}
+
+// refresh exchanges a refresh token for a new access token and rotated refresh token
+func (h *AuthHandler) refresh(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+
+	tokens, user, err := h.Service.Refresh(ctx, body.RefreshToken)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	user.Password = ""
+	c.JSON(http.StatusOK, gin.H{"success": true, "token": tokens.AccessToken, "refreshToken": tokens.RefreshToken, "expiresIn": tokens.ExpiresIn, "user": user})
+}
+
+// oauthProviders lists the OAuth providers users can sign in with
+func (h *AuthHandler) oauthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": h.Service.OAuthProviders()})
+}
+
+// oauthStart redirects the browser to the provider's sign-in page
+func (h *AuthHandler) oauthStart(c *gin.Context) {
+	authURL, state, err := h.Service.OAuthAuthURL(c.Param("provider"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, int((10 * time.Minute).Seconds()), "/api/auth/oauth", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// oauthCallback completes OAuth sign-in and hands the new session's tokens to
+// the frontend, or returns them like login when no frontend is configured
+func (h *AuthHandler) oauthCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "sign-in cancelled: " + providerErr, "code": "oauth_denied"})
+		return
+	}
+	state := c.Query("state")
+	if cookie, err := c.Cookie(oauthStateCookie); err != nil || cookie != state {
+		respondError(c, services.ErrInvalidOAuthState, http.StatusBadRequest)
+		return
+	}
+	c.SetCookie(oauthStateCookie, "", -1, "/api/auth/oauth", "", c.Request.TLS != nil, true)
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	tokens, user, pending, err := h.Service.OAuthLogin(ctx, c.Param("provider"), c.Query("code"), state, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, services.ErrTwoFactorRequired) {
+		h.oauthTwoFactor(c, user, pending, err)
+		return
+	}
+	if err != nil {
+		event := auditEvent(c, services.AuditOAuthLogin, services.AuditTargetUser, "")
+		recordAudit(c, h.Audit, auditFailure(event, err))
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	h.auditSignIn(c, services.AuditOAuthLogin, user)
+	if h.OAuthFrontendURL != "" {
+		fragment := url.Values{
+			"token":        {tokens.AccessToken},
+			"refreshToken": {tokens.RefreshToken},
+			"expiresIn":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+		}
+		c.Redirect(http.StatusFound, h.OAuthFrontendURL+"#"+fragment.Encode())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login successful", "token": tokens.AccessToken, "refreshToken": tokens.RefreshToken, "expiresIn": tokens.ExpiresIn, "user": user})
+}
+
+// oauthTwoFactor asks a user who signed in at the provider for their second
+// factor, which they send to oauthComplete with the pending token
+func (h *AuthHandler) oauthTwoFactor(c *gin.Context, user models.User, pending string, err error) {
+	if h.OAuthFrontendURL != "" {
+		ctx, cancel := contextWithTimeout(c)
+		defer cancel()
+		fragment := url.Values{
+			"twoFactorToken":   {pending},
+			"twoFactorMethods": {strings.Join(h.Service.TwoFactorMethods(ctx, user), ",")},
+		}
+		c.Redirect(http.StatusFound, h.OAuthFrontendURL+"#"+fragment.Encode())
+		return
+	}
+	status, resp := h.twoFactorResponse(c, user, err, models.WebAuthnChallengeLogin)
+	resp["twoFactorToken"] = pending
+	c.JSON(status, resp)
+}
+
+// oauthComplete finishes an OAuth sign-in that needed the second factor: a
+// TOTP or backup code, or a security key assertion
+func (h *AuthHandler) oauthComplete(c *gin.Context) {
+	var body struct {
+		TwoFactorToken string                      `json:"twoFactorToken"`
+		TotpToken      string                      `json:"totpToken"`
+		WebAuthn       *services.WebAuthnAssertion `json:"webauthn"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	tokens, user, err := h.Service.CompleteOAuthLogin(ctx, body.TwoFactorToken, body.TotpToken, body.WebAuthn, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, services.ErrTwoFactorRequired) {
+		h.twoFactorChallenge(c, user, err, models.WebAuthnChallengeLogin)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error(), "requiresTwoFactor": user.TwoFactor})
+		event := auditEvent(c, services.AuditOAuthLogin, services.AuditTargetUser, "")
+		event.ActorEmail = user.Email
+		recordAudit(c, h.Audit, auditFailure(event, err))
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login successful", "token": token, "user": user})
+	h.auditSignIn(c, services.AuditOAuthLogin, user)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login successful", "token": tokens.AccessToken, "refreshToken": tokens.RefreshToken, "expiresIn": tokens.ExpiresIn, "user": user})
 }
 
 // me retrieves the authenticated user's profile information
@@ -95,7 +295,7 @@ func (h *AuthHandler) me(c *gin.Context) {
 	defer cancel()
 	user, err := h.Service.GetUser(ctx, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User not found"})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "user": user})
@@ -108,14 +308,24 @@ func (h *AuthHandler) GetTokenUsage(c *gin.Context) {
 	defer cancel()
 	user, err := h.Service.GetUser(ctx, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User not found"})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "tokenUsage": user.TokenUsage})
 }
 
-// logout handles user logout (JWT is stateless, so this is primarily for client-side cleanup)
+// logout terminates the current session, revoking its access and refresh tokens
 func (h *AuthHandler) logout(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	if sessionID, ok := c.Get("sessionId"); ok {
+		ctx, cancel := contextWithTimeout(c)
+		defer cancel()
+		if err := h.Service.TerminateSession(ctx, userID, sessionID.(primitive.ObjectID)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditLogout, services.AuditTargetUser, userID.Hex()))
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logout successful"})
 }
 
@@ -132,13 +342,128 @@ func (h *AuthHandler) changePassword(c *gin.Context) {
 	userID := c.MustGet("userId").(primitive.ObjectID)
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
+	event := auditEvent(c, services.AuditPasswordChange, services.AuditTargetUser, userID.Hex())
 	if err := h.Service.ChangePassword(ctx, userID, body.CurrentPassword, body.NewPassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		recordAudit(c, h.Audit, auditFailure(event, err))
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
+	recordAudit(c, h.Audit, event)
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// sendVerificationEmail emails a new user their verification token without
+// holding up registration
+func (h *AuthHandler) sendVerificationEmail(user models.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+	defer cancel()
+	if err := h.Service.SendVerificationEmail(ctx, user); err != nil {
+		slog.Warn("failed to send verification email", "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+// verifyEmail marks the user's email as verified with the token they were emailed
+func (h *AuthHandler) verifyEmail(c *gin.Context) {
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "token is required"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	user, err := h.Service.VerifyEmail(ctx, body.Token)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	event := auditEvent(c, services.AuditEmailVerify, services.AuditTargetUser, user.ID.Hex())
+	event.ActorID, event.ActorEmail = user.ID.Hex(), user.Email
+	recordAudit(c, h.Audit, event)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Email verified", "user": user})
+}
+
+// resendVerification sends the signed-in user a new verification email
+func (h *AuthHandler) resendVerification(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Service.ResendVerificationEmail(ctx, userID); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Verification email sent"})
+}
+
+// forgotPassword emails a password reset token. The answer is the same
+// whether or not the email has an account, and the email is sent after it.
+func (h *AuthHandler) forgotPassword(c *gin.Context) {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "email is required"})
+		return
+	}
+	if !h.Service.EmailEnabled() {
+		respondError(c, services.ErrEmailDisabled, http.StatusServiceUnavailable)
+		return
+	}
+	event := auditEvent(c, services.AuditPasswordForgot, services.AuditTargetUser, "")
+	event.ActorEmail = strings.ToLower(strings.TrimSpace(body.Email))
+	recordAudit(c, h.Audit, event)
+	go func() {
+		// Outlives the request but keeps its request ID for the log
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), emailTimeout)
+		defer cancel()
+		if err := h.Service.RequestPasswordReset(ctx, body.Email); err != nil {
+			slog.WarnContext(ctx, "failed to send password reset email", "error", err)
+		}
+	}()
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "If an account uses this email, a password reset token has been sent to it"})
+}
+
+// resetPassword sets a new password with an emailed reset token and signs the
+// user out everywhere. Accounts with 2FA or a security key also send
+// totpToken or a webauthn assertion; without either the answer carries
+// requiresTwoFactor and, for security keys, the challenge to answer.
+func (h *AuthHandler) resetPassword(c *gin.Context) {
+	var body struct {
+		Token     string                      `json:"token"`
+		Password  string                      `json:"password"`
+		TotpToken string                      `json:"totpToken"`
+		WebAuthn  *services.WebAuthnAssertion `json:"webauthn"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	user, err := h.Service.ResetPassword(ctx, body.Token, body.Password, body.TotpToken, body.WebAuthn)
+	if errors.Is(err, services.ErrTwoFactorRequired) {
+		h.twoFactorChallenge(c, user, err, models.WebAuthnChallengeReset)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEmailToken) || errors.Is(err, services.ErrInvalidVerificationCode) {
+			event := auditEvent(c, services.AuditPasswordReset, services.AuditTargetUser, "")
+			if !user.ID.IsZero() {
+				event.TargetID, event.ActorEmail = user.ID.Hex(), user.Email
+			}
+			recordAudit(c, h.Audit, auditFailure(event, err))
+		}
+		status, code := errorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{"success": false, "message": err.Error(), "code": code, "requiresTwoFactor": user.TwoFactor})
+		return
+	}
+	event := auditEvent(c, services.AuditPasswordReset, services.AuditTargetUser, user.ID.Hex())
+	event.ActorID, event.ActorEmail = user.ID.Hex(), user.Email
+	recordAudit(c, h.Audit, event)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password reset; sign in with the new password"})
+}
+
 // twoFactorSetup generates TOTP secret and QR code for 2FA enrollment
 func (h *AuthHandler) twoFactorSetup(c *gin.Context) {
 	email := c.GetString("userEmail")
@@ -165,9 +490,10 @@ func (h *AuthHandler) enableTwoFactor(c *gin.Context) {
 	defer cancel()
 	codes, err := h.Service.EnableTwoFactor(ctx, userID, body.Secret, body.Token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditTwoFactorEnable, services.AuditTargetUser, userID.Hex()))
 	c.JSON(http.StatusOK, gin.H{"success": true, "backupCodes": codes})
 }
 
@@ -180,6 +506,7 @@ func (h *AuthHandler) disableTwoFactor(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditTwoFactorDisable, services.AuditTargetUser, userID.Hex()))
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
@@ -196,10 +523,13 @@ func (h *AuthHandler) backupCodeStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "unusedCount": count})
 }
 
-// regenerateBackupCodes creates a new set of backup codes after TOTP verification
+// regenerateBackupCodes creates a new set of backup codes once the user's
+// second factor is confirmed with a TOTP or backup code (token) or a security
+// key assertion
 func (h *AuthHandler) regenerateBackupCodes(c *gin.Context) {
 	var body struct {
-		Token string `json:"token"`
+		Token    string                      `json:"token"`
+		WebAuthn *services.WebAuthnAssertion `json:"webauthn"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
@@ -208,9 +538,15 @@ func (h *AuthHandler) regenerateBackupCodes(c *gin.Context) {
 	userID := c.MustGet("userId").(primitive.ObjectID)
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	codes, err := h.Service.RegenerateBackupCodes(ctx, userID, body.Token)
+	codes, err := h.Service.RegenerateBackupCodes(ctx, userID, body.Token, body.WebAuthn)
+	if errors.Is(err, services.ErrTwoFactorRequired) {
+		if user, uerr := h.Service.GetUser(ctx, userID); uerr == nil {
+			h.twoFactorChallenge(c, *user, err, models.WebAuthnChallengeConfirm)
+			return
+		}
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "backupCodes": codes})
@@ -251,7 +587,9 @@ func (h *AuthHandler) terminateOthers(c *gin.Context) {
 	userID := c.MustGet("userId").(primitive.ObjectID)
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	count, err := h.Service.TerminateOtherSessions(ctx, userID, primitive.NilObjectID)
+	current, _ := c.Get("sessionId")
+	currentID, _ := current.(primitive.ObjectID)
+	count, err := h.Service.TerminateOtherSessions(ctx, userID, currentID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
@@ -278,6 +616,60 @@ func (h *AuthHandler) loginActivity(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "activities": activities})
 }
 
+// apiKeys lists the user's API keys; the keys themselves are never returned
+func (h *AuthHandler) apiKeys(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	keys, err := h.Service.ListAPIKeys(ctx, userID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": keys, "count": len(keys)})
+}
+
+// createAPIKey creates an API key scoped to read and/or publish on specific
+// feeds. The key is only included in this response.
+func (h *AuthHandler) createAPIKey(c *gin.Context) {
+	var body struct {
+		Name  string               `json:"name"`
+		Feeds []models.APIKeyGrant `json:"feeds"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	secret, key, err := h.Service.CreateAPIKey(ctx, userID, body.Name, body.Feeds)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditAPIKeyCreate, services.AuditTargetAPIKey, key.ID.Hex()), nil, key))
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Store this key now, it will not be shown again", "key": secret, "data": key})
+}
+
+// revokeAPIKey revokes one of the user's API keys
+func (h *AuthHandler) revokeAPIKey(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	keyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid API key id"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Service.RevokeAPIKey(ctx, userID, keyID); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditAPIKeyRevoke, services.AuditTargetAPIKey, keyID.Hex()))
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
 // contextWithTimeout aligns request-scoped timeouts with the config default.
 func contextWithTimeout(c *gin.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(c.Request.Context(), 15*time.Second)
@@ -179,6 +179,7 @@ func TestAuthHandler_Login(t *testing.T) {
 			checkResponse: func(t *testing.T, resp map[string]interface{}) {
 				assert.True(t, resp["success"].(bool))
 				assert.NotEmpty(t, resp["token"])
+				assert.NotEmpty(t, resp["refreshToken"])
 				user := resp["user"].(map[string]interface{})
 				assert.Equal(t, email, user["email"])
 			},
@@ -243,6 +244,47 @@ func TestAuthHandler_Login(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_Refresh(t *testing.T) {
+	handler, authService, cleanup := setupAuthHandler(t)
+	if handler == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	router := setupTestRouter()
+	public := router.Group("/api/auth")
+	handler.RegisterPublic(public)
+
+	ctx := context.Background()
+	tokens, _, err := authService.Register(ctx, "refresh@example.com", "password", "Refresh Test")
+	require.NoError(t, err)
+
+	refresh := func(token string) (int, map[string]interface{}) {
+		payload, err := json.Marshal(map[string]string{"refreshToken": token})
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	status, resp := refresh(tokens.RefreshToken)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, resp["token"])
+	assert.NotEmpty(t, resp["refreshToken"])
+	assert.NotEqual(t, tokens.RefreshToken, resp["refreshToken"])
+
+	status, resp = refresh(tokens.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "invalid_token", resp["code"])
+
+	status, _ = refresh("")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
 func TestAuthHandler_Me(t *testing.T) {
 	handler, authService, cleanup := setupAuthHandler(t)
 	if handler == nil {
@@ -444,3 +486,48 @@ func TestAuthHandler_Logout(t *testing.T) {
 	assert.True(t, response["success"].(bool))
 	assert.Contains(t, response["message"].(string), "Logout successful")
 }
+
+func TestOAuthHandlers(t *testing.T) {
+	service := services.NewAuthService(config.Config{
+		JWTSecret:               "test-secret-key-for-testing-only",
+		GitHubOAuthClientID:     "client",
+		GitHubOAuthClientSecret: "secret",
+		OAuthRedirectBaseURL:    "http://localhost:7210",
+	}, nil, nil)
+	handler := NewAuthHandler(service)
+	router := setupTestRouter()
+	handler.RegisterPublic(router.Group("/api/auth"))
+
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/auth/oauth/providers")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"github"`)
+
+	w = get("/api/auth/oauth/github")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "https://github.com/login/oauth/authorize?")
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+
+	w = get("/api/auth/oauth/google")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The state must come back from the browser that started sign-in
+	w = get("/api/auth/oauth/github/callback?code=abc&state=" + cookies[0].Value)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_oauth_state")
+
+	w = get("/api/auth/oauth/github/callback?error=access_denied", cookies[0])
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "oauth_denied")
+}
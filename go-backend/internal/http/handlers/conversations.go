@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// conversationsEnabled answers 503 when conversations are not available
+func (h *LLMHandler) conversationsEnabled(c *gin.Context) bool {
+	if h.Conversations == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "conversations are disabled"})
+		return false
+	}
+	return true
+}
+
+// conversationID parses the :id path parameter, answering 400 when it is not an ID
+func conversationID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid conversation id"})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// ListConversations returns the user's conversations without their messages,
+// most recent first; feedId limits them to one feed
+// GET /api/llm/conversations
+func (h *LLMHandler) ListConversations(c *gin.Context) {
+	if !h.conversationsEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	conversations, err := h.Conversations.ListConversations(ctx, userID.Hex(), c.Query("feedId"))
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations, "count": len(conversations)})
+}
+
+// GetConversation returns one of the user's conversations with its messages
+// GET /api/llm/conversations/:id
+func (h *LLMHandler) GetConversation(c *gin.Context) {
+	if !h.conversationsEnabled(c) {
+		return
+	}
+	id, ok := conversationID(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	conv, err := h.Conversations.GetConversation(ctx, userID.Hex(), id)
+	if err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+// DeleteConversation removes one of the user's conversations
+// DELETE /api/llm/conversations/:id
+func (h *LLMHandler) DeleteConversation(c *gin.Context) {
+	if !h.conversationsEnabled(c) {
+		return
+	}
+	id, ok := conversationID(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Conversations.DeleteConversation(ctx, userID.Hex(), id); err != nil {
+		respondLLMError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "conversation deleted"})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// errorMapping ties a service sentinel error to an HTTP status and a stable error code
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+var errorMappings = []errorMapping{
+	{services.ErrMissingCredentials, http.StatusBadRequest, "missing_credentials"},
+	{services.ErrUserExists, http.StatusBadRequest, "user_exists"},
+	{services.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{services.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{services.ErrTwoFactorRequired, http.StatusUnauthorized, "two_factor_required"},
+	{services.ErrVerificationCodeRequired, http.StatusBadRequest, "verification_code_required"},
+	{services.ErrInvalidVerificationCode, http.StatusBadRequest, "invalid_verification_code"},
+	{services.ErrIncorrectPassword, http.StatusBadRequest, "incorrect_password"},
+	{services.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{services.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+	{services.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found"},
+	{services.ErrAPIKeyNameRequired, http.StatusBadRequest, "api_key_name_required"},
+	{services.ErrInvalidAPIKeyScope, http.StatusBadRequest, "invalid_api_key_scope"},
+	{services.ErrEmailDisabled, http.StatusServiceUnavailable, "email_disabled"},
+	{services.ErrInvalidEmailToken, http.StatusBadRequest, "invalid_email_token"},
+	{services.ErrEmailAlreadyVerified, http.StatusConflict, "email_already_verified"},
+	{services.ErrPasswordRequired, http.StatusBadRequest, "password_required"},
+	{services.ErrWebAuthnDisabled, http.StatusServiceUnavailable, "webauthn_disabled"},
+	{services.ErrInvalidWebAuthnResponse, http.StatusBadRequest, "invalid_webauthn_response"},
+	{services.ErrWebAuthnChallenge, http.StatusBadRequest, "invalid_webauthn_challenge"},
+	{services.ErrWebAuthnCredentialNotFound, http.StatusNotFound, "webauthn_credential_not_found"},
+	{services.ErrWebAuthnCredentialExists, http.StatusConflict, "webauthn_credential_exists"},
+	{services.ErrUnsupportedWebAuthnKey, http.StatusBadRequest, "unsupported_webauthn_key"},
+	{services.ErrWebAuthnSignCount, http.StatusUnauthorized, "webauthn_sign_count"},
+	{services.ErrEmailConfirmation, http.StatusBadRequest, "email_confirmation_required"},
+	{services.ErrInvalidRole, http.StatusBadRequest, "invalid_role"},
+	{services.ErrInvalidTokenQuota, http.StatusBadRequest, "invalid_token_quota"},
+	{services.ErrOAuthProviderNotConfigured, http.StatusNotFound, "oauth_provider_not_configured"},
+	{services.ErrInvalidOAuthState, http.StatusBadRequest, "invalid_oauth_state"},
+	{services.ErrOAuthEmailUnverified, http.StatusForbidden, "oauth_email_unverified"},
+	{services.ErrOAuthAccountUnverified, http.StatusConflict, "oauth_account_unverified"},
+	{services.ErrOAuthExchangeFailed, http.StatusBadGateway, "oauth_failed"},
+	{services.ErrFeedNotFound, http.StatusNotFound, "feed_not_found"},
+	{services.ErrNotAuthorized, http.StatusForbidden, "not_authorized"},
+	{services.ErrQueryRequired, http.StatusBadRequest, "query_required"},
+	{services.ErrInvalidSort, http.StatusBadRequest, "invalid_sort"},
+	{services.ErrSubscriptionNotFound, http.StatusNotFound, "subscription_not_found"},
+	{services.ErrAlertRuleNotFound, http.StatusNotFound, "alert_rule_not_found"},
+	{services.ErrAlertConditionRequired, http.StatusBadRequest, "alert_condition_required"},
+	{services.ErrInvalidAlertCooldown, http.StatusBadRequest, "invalid_alert_cooldown"},
+	{services.ErrInvalidWebhookURL, http.StatusBadRequest, "invalid_webhook_url"},
+	{services.ErrTooManyAlertRules, http.StatusBadRequest, "too_many_alert_rules"},
+	{services.ErrWebhookNotFound, http.StatusNotFound, "webhook_not_found"},
+	{services.ErrWebhookURLNotHTTPS, http.StatusBadRequest, "invalid_webhook_url"},
+	{services.ErrInvalidWebhookEvent, http.StatusBadRequest, "invalid_webhook_event"},
+	{services.ErrTooManyWebhooks, http.StatusBadRequest, "too_many_webhooks"},
+	{services.ErrInvalidGrantEmail, http.StatusBadRequest, "invalid_email"},
+	{services.ErrAccessGrantNotFound, http.StatusNotFound, "access_grant_not_found"},
+	{services.ErrInviteNotFound, http.StatusNotFound, "invite_not_found"},
+	{services.ErrInvalidInvite, http.StatusBadRequest, "invalid_invite"},
+	{services.ErrOrgNotFound, http.StatusNotFound, "org_not_found"},
+	{services.ErrInvalidOrgName, http.StatusBadRequest, "invalid_org_name"},
+	{services.ErrInvalidOrgRole, http.StatusBadRequest, "invalid_org_role"},
+	{services.ErrOrgMemberExists, http.StatusConflict, "org_member_exists"},
+	{services.ErrOrgMemberNotFound, http.StatusNotFound, "org_member_not_found"},
+	{services.ErrLastOrgOwner, http.StatusConflict, "last_org_owner"},
+	{services.ErrFeedOwnedByOrg, http.StatusConflict, "feed_owned_by_org"},
+	{services.ErrFeedNotDeleted, http.StatusConflict, "feed_not_deleted"},
+	{services.ErrFeedRestoreExpired, http.StatusGone, "feed_restore_expired"},
+	{services.ErrFeedPaused, http.StatusConflict, "feed_paused"},
+	{services.ErrNotPushFeed, http.StatusBadRequest, "not_push_feed"},
+	{services.ErrInvalidIngestSignature, http.StatusUnauthorized, "invalid_signature"},
+	{services.ErrInvalidFeedUpdate, http.StatusBadRequest, "invalid_feed_update"},
+	{services.ErrCategoryKeyRequired, http.StatusBadRequest, "category_key_required"},
+	{services.ErrInvalidCategoryKey, http.StatusBadRequest, "invalid_category_key"},
+	{services.ErrCategoryExists, http.StatusConflict, "category_exists"},
+	{services.ErrCategoryNotFound, http.StatusNotFound, "category_not_found"},
+	{services.ErrUnknownCategory, http.StatusBadRequest, "unknown_category"},
+	{services.ErrProviderNotConfigured, http.StatusBadRequest, "provider_not_configured"},
+	{services.ErrNoProviders, http.StatusServiceUnavailable, "no_providers"},
+	{services.ErrInvalidChatOptions, http.StatusBadRequest, "invalid_options"},
+	{services.ErrInvalidUsageGroup, http.StatusBadRequest, "invalid_group_by"},
+	{services.ErrInvalidLLMKeyProvider, http.StatusBadRequest, "invalid_provider"},
+	{services.ErrLLMKeyRequired, http.StatusBadRequest, "api_key_required"},
+	{services.ErrLLMKeyNotFound, http.StatusNotFound, "llm_key_not_found"},
+	{services.ErrInvalidResponseFormat, http.StatusBadRequest, "invalid_response_format"},
+	{services.ErrInvalidStructuredOutput, http.StatusBadGateway, "invalid_structured_output"},
+	{services.ErrConversationNotFound, http.StatusNotFound, "conversation_not_found"},
+	{services.ErrConversationFeed, http.StatusBadRequest, "conversation_feed_mismatch"},
+	{services.ErrLLMToolsDisabled, http.StatusBadRequest, "llm_tools_disabled"},
+	{services.ErrLLMToolsStreaming, http.StatusBadRequest, "llm_tools_streaming"},
+	{services.ErrTooManyToolCalls, http.StatusBadGateway, "too_many_tool_calls"},
+	{services.ErrAnalysisScheduleNotFound, http.StatusNotFound, "schedule_not_found"},
+	{services.ErrInvalidAnalysisInterval, http.StatusBadRequest, "invalid_interval"},
+	{services.ErrAnalysisPromptTooLong, http.StatusBadRequest, "prompt_too_long"},
+	{services.ErrUnknownLLMProvider, http.StatusBadRequest, "unknown_provider"},
+	{services.ErrTooManyAnalysisSchedules, http.StatusBadRequest, "too_many_schedules"},
+}
+
+// errorStatus maps a service error to an HTTP status and error code, using
+// fallback for errors without a sentinel.
+func errorStatus(err error, fallback int) (int, string) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status, m.code
+		}
+	}
+	if fallback < http.StatusInternalServerError {
+		return fallback, "bad_request"
+	}
+	return fallback, "internal_error"
+}
+
+// respondError writes a service error using the standard response envelope
+func respondError(c *gin.Context, err error, fallback int) {
+	status, code := errorStatus(err, fallback)
+	c.JSON(status, gin.H{"success": false, "message": err.Error(), "code": code})
+}
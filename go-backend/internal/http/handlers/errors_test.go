@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		fallback       int
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"feed not found", services.ErrFeedNotFound, http.StatusInternalServerError, http.StatusNotFound, "feed_not_found"},
+		{"not authorized", services.ErrNotAuthorized, http.StatusInternalServerError, http.StatusForbidden, "not_authorized"},
+		{"invalid credentials", services.ErrInvalidCredentials, http.StatusInternalServerError, http.StatusUnauthorized, "invalid_credentials"},
+		{"user exists", services.ErrUserExists, http.StatusInternalServerError, http.StatusBadRequest, "user_exists"},
+		{"wrapped sentinel", fmt.Errorf("openai: %w", services.ErrProviderNotConfigured), http.StatusInternalServerError, http.StatusBadRequest, "provider_not_configured"},
+		{"unknown error uses fallback", errors.New("boom"), http.StatusInternalServerError, http.StatusInternalServerError, "internal_error"},
+		{"unknown client error", errors.New("bad"), http.StatusBadRequest, http.StatusBadRequest, "bad_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := errorStatus(tt.err, tt.fallback)
+			assert.Equal(t, tt.expectedStatus, status)
+			assert.Equal(t, tt.expectedCode, code)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// createInvitePayload is the body of invite creation requests
+type createInvitePayload struct {
+	// MaxUses of 0 lets the invite be redeemed any number of times
+	MaxUses int `json:"maxUses"`
+	// TTLSeconds of 0 keeps the invite for the longest allowed time (30 days)
+	TTLSeconds int `json:"ttlSeconds"`
+}
+
+// accessEnabled answers 503 when private feed sharing is not available
+func (h *MarketplaceHandler) accessEnabled(c *gin.Context) bool {
+	if h.Access == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "feed sharing is disabled"})
+		return false
+	}
+	return true
+}
+
+// canReadFeed checks userID may read the feed, answering the request and
+// returning false when they may not. Private feeds are readable by their
+// owner and by users granted access.
+func (h *MarketplaceHandler) canReadFeed(ctx context.Context, c *gin.Context, feed *models.WebSocketFeed, userID string) bool {
+	if feed.IsPublic || feed.OwnerID == userID {
+		return true
+	}
+	if h.Access != nil {
+		ok, err := h.Access.CanAccess(ctx, feed, userID)
+		if err != nil {
+			respondError(c, err, http.StatusInternalServerError)
+			return false
+		}
+		if ok {
+			return true
+		}
+	}
+	respondError(c, services.ErrNotAuthorized, http.StatusForbidden)
+	return false
+}
+
+// hasGrant reports whether userID was granted access to a private feed; a
+// failed lookup counts as no grant
+func (h *MarketplaceHandler) hasGrant(ctx context.Context, feed *models.WebSocketFeed, userID string) bool {
+	if h.Access == nil || userID == "" {
+		return false
+	}
+	ok, err := h.Access.CanAccess(ctx, feed, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check feed access", "feed_id", feed.ID.Hex(), "user_id", userID, "error", err)
+		return false
+	}
+	return ok
+}
+
+// ownFeed checks the caller owns the feed in the :id parameter, answering the
+// request and returning false when they do not
+func (h *MarketplaceHandler) ownFeed(ctx context.Context, c *gin.Context, userID primitive.ObjectID) bool {
+	if _, err := h.Service.GetOwnedFeed(ctx, c.Param("id"), userID.Hex()); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// listAccess returns who has been granted access to one of the caller's feeds
+func (h *MarketplaceHandler) listAccess(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.ownFeed(ctx, c, userID) {
+		return
+	}
+	grants, err := h.Access.ListGrants(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": grants, "count": len(grants)})
+}
+
+// grantAccess lets an email read one of the caller's feeds; the user does not
+// need an account yet
+func (h *MarketplaceHandler) grantAccess(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "email is required"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.ownFeed(ctx, c, userID) {
+		return
+	}
+	grant, err := h.Access.GrantAccess(ctx, c.Param("id"), body.Email, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedAccessGrant, services.AuditTargetGrant, grant.ID.Hex()), nil, grant))
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": grant})
+}
+
+// revokeAccess removes an access grant from one of the caller's feeds. The
+// user's subscription is ended and their connected clients are dropped from
+// the feed.
+func (h *MarketplaceHandler) revokeAccess(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	grantID, err := primitive.ObjectIDFromHex(c.Param("grantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid grant id"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.ownFeed(ctx, c, userID) {
+		return
+	}
+	feedID := c.Param("id")
+	grant, err := h.Access.RevokeGrant(ctx, feedID, grantID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedAccessRevoke, services.AuditTargetGrant, grantID.Hex()), grant, nil))
+	grantee, err := h.Access.UserIDByEmail(ctx, grant.Email)
+	if err == nil {
+		if err := h.Service.Unsubscribe(ctx, grantee, feedID); err != nil {
+			slog.WarnContext(ctx, "failed to unsubscribe after revoking access", "user_id", grantee, "feed_id", feedID, "error", err)
+		}
+		h.Sockets.RevokeFeedAccess(grantee, feedID)
+	} else if !errors.Is(err, services.ErrUserNotFound) {
+		slog.WarnContext(ctx, "failed to look up grantee after revoking access", "email", grant.Email, "feed_id", feedID, "error", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Access revoked"})
+}
+
+// listInvites returns the invites to one of the caller's feeds
+func (h *MarketplaceHandler) listInvites(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.ownFeed(ctx, c, userID) {
+		return
+	}
+	invites, err := h.Access.ListInvites(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": invites, "count": len(invites)})
+}
+
+// createInvite creates an invite token for one of the caller's feeds. The
+// token is only returned here.
+func (h *MarketplaceHandler) createInvite(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body createInvitePayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+			return
+		}
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.ownFeed(ctx, c, userID) {
+		return
+	}
+	token, invite, err := h.Access.CreateInvite(ctx, c.Param("id"), userID.Hex(), body.MaxUses, time.Duration(body.TTLSeconds)*time.Second)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedInviteCreate, services.AuditTargetInvite, invite.ID.Hex()), nil, invite))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Share this token now; it is not shown again",
+		"data":    gin.H{"invite": invite, "token": token},
+	})
+}
+
+// revokeInvite stops an invite to one of the caller's feeds from being redeemed
+func (h *MarketplaceHandler) revokeInvite(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	inviteID, err := primitive.ObjectIDFromHex(c.Param("inviteId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid invite id"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.ownFeed(ctx, c, userID) {
+		return
+	}
+	if err := h.Access.RevokeInvite(ctx, c.Param("id"), inviteID); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditFeedInviteRevoke, services.AuditTargetInvite, inviteID.Hex()))
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Invite revoked"})
+}
+
+// acceptInvite redeems an invite token, granting the caller access to its feed
+func (h *MarketplaceHandler) acceptInvite(c *gin.Context) {
+	if !h.accessEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	grant, err := h.Access.RedeemInvite(ctx, c.Param("token"), userID.Hex())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInvite) {
+			recordAudit(c, h.Audit, auditFailure(auditEvent(c, services.AuditFeedInviteAccept, services.AuditTargetInvite, ""), err))
+		}
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	targetID := ""
+	if grant.InviteID != nil {
+		targetID = grant.InviteID.Hex()
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedInviteAccept, services.AuditTargetInvite, targetID), nil, grant))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": grant})
+}
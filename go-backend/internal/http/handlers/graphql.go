@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+)
+
+//go:embed graphql_schema.graphql
+var graphQLSchema string
+
+// graphQLMaxDepth bounds how deeply queries may nest
+const graphQLMaxDepth = 8
+
+// errAuthRequired answers fields that need an authenticated caller
+var errAuthRequired = errors.New("authentication required")
+
+// GraphQLHandler serves the GraphQL API. Its resolvers use the marketplace
+// handler's services, so GraphQL callers pass the same access checks as REST
+// callers, and subscriptions join the same rooms as websocket clients.
+type GraphQLHandler struct {
+	Marketplace *MarketplaceHandler
+	// Auth verifies the token subscriptions send in connection_init; nil
+	// leaves every subscription anonymous
+	Auth *services.AuthService
+	// OriginPatterns lists the origins allowed to open subscription
+	// websockets; empty allows any
+	OriginPatterns []string
+	schema         *graphql.Schema
+}
+
+// NewGraphQLHandler creates the GraphQL handler over a marketplace handler
+func NewGraphQLHandler(marketplace *MarketplaceHandler, auth *services.AuthService) *GraphQLHandler {
+	return &GraphQLHandler{
+		Marketplace: marketplace,
+		Auth:        auth,
+		schema: graphql.MustParseSchema(graphQLSchema, &graphQLResolver{h: marketplace},
+			graphql.UseFieldResolvers(), graphql.MaxDepth(graphQLMaxDepth)),
+	}
+}
+
+// RegisterRoutes attaches the endpoint: POST runs queries and mutations, GET
+// upgrades to a graphql-transport-ws websocket for subscriptions
+func (h *GraphQLHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.POST("", h.query)
+	group.GET("", h.subscriptions)
+}
+
+// graphQLRequestBody is a GraphQL operation sent over HTTP or as the payload
+// of a graphql-transport-ws subscribe message
+type graphQLRequestBody struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// query runs a query or mutation. Errors are reported in the response's
+// errors list, so the status is 200 for any request that could be read.
+func (h *GraphQLHandler) query(c *gin.Context) {
+	var body graphQLRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "request must be JSON with a query"}}})
+		return
+	}
+	resp := h.schema.Exec(withGraphQLRequest(c.Request.Context(), c), body.Query, body.OperationName, body.Variables)
+	c.JSON(http.StatusOK, resp)
+}
+
+// graphQLRequestKey carries the HTTP request an operation arrived on
+type graphQLRequestKey struct{}
+
+func withGraphQLRequest(ctx context.Context, c *gin.Context) context.Context {
+	return context.WithValue(ctx, graphQLRequestKey{}, c)
+}
+
+// graphQLRequest returns the request an operation arrived on; for
+// subscriptions it is the websocket upgrade, with the caller set by
+// connection_init
+func graphQLRequest(ctx context.Context) *gin.Context {
+	c, _ := ctx.Value(graphQLRequestKey{}).(*gin.Context)
+	return c
+}
+
+// graphQLCaller returns the authenticated caller's user ID, or "" for anonymous callers
+func graphQLCaller(c *gin.Context) string {
+	if oid, ok := c.Get("userId"); ok {
+		if userID, ok := oid.(primitive.ObjectID); ok {
+			return userID.Hex()
+		}
+	}
+	return ""
+}
+
+// graphQLError is a resolver error with the REST error code in extensions.code
+type graphQLError struct {
+	err  error
+	code string
+}
+
+func (e *graphQLError) Error() string {
+	return e.err.Error()
+}
+
+func (e *graphQLError) Unwrap() error {
+	return e.err
+}
+
+// Extensions adds the error code to the error in the response
+func (e *graphQLError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// graphQLErr converts a service error the way respondError does, using
+// fallback for errors without a sentinel
+func graphQLErr(err error, fallback int) error {
+	if errors.Is(err, errAuthRequired) {
+		return &graphQLError{err: err, code: "unauthenticated"}
+	}
+	_, code := errorStatus(err, fallback)
+	return &graphQLError{err: err, code: code}
+}
+
+// graphQLResolver resolves the root Query, Mutation and Subscription fields
+type graphQLResolver struct {
+	h *MarketplaceHandler
+}
+
+// authenticated returns the request and its caller, or errAuthRequired
+func (r *graphQLResolver) authenticated(ctx context.Context) (*gin.Context, string, error) {
+	c := graphQLRequest(ctx)
+	userID := graphQLCaller(c)
+	if userID == "" {
+		return nil, "", graphQLErr(errAuthRequired, http.StatusUnauthorized)
+	}
+	return c, userID, nil
+}
+
+// mayRead reports whether userID may read the feed: private feeds are
+// readable by their owner and by users granted access
+func (r *graphQLResolver) mayRead(ctx context.Context, feed *models.WebSocketFeed, userID string) bool {
+	return feed.IsPublic || (userID != "" && feed.OwnerID == userID) || r.h.hasGrant(ctx, feed, userID)
+}
+
+func (r *graphQLResolver) Feeds(ctx context.Context, args struct {
+	Category *string
+	Verified *bool
+	Sort     *string
+	Limit    int32
+	Offset   int32
+}) (*graphQLFeedPage, error) {
+	if args.Limit < 0 || args.Offset < 0 {
+		return nil, graphQLErr(errors.New("limit and offset must not be negative"), http.StatusBadRequest)
+	}
+	opts := services.FeedListOptions{
+		Category: deref(args.Category),
+		Verified: args.Verified,
+		Sort:     deref(args.Sort),
+		Limit:    int64(min(args.Limit, maxFeedPageSize)),
+		Offset:   int64(args.Offset),
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultFeedPageSize
+	}
+	ctx, cancel := contextWithTimeout(graphQLRequest(ctx))
+	defer cancel()
+	feeds, total, err := r.h.Service.GetPublicFeeds(ctx, opts)
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	return &graphQLFeedPage{
+		Feeds:   newGraphQLFeeds(feeds),
+		Total:   int32(total),
+		HasMore: opts.Offset+int64(len(feeds)) < total,
+	}, nil
+}
+
+// Feed returns null for missing feeds and for private feeds the caller may
+// not read, as the REST lookup answers 404 for both
+func (r *graphQLResolver) Feed(ctx context.Context, args struct{ ID graphql.ID }) (*graphQLFeed, error) {
+	c := graphQLRequest(ctx)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := r.h.Service.GetFeedByID(ctx, string(args.ID))
+	if errors.Is(err, services.ErrFeedNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	if !r.mayRead(ctx, feed, graphQLCaller(c)) {
+		return nil, nil
+	}
+	return newGraphQLFeed(*feed), nil
+}
+
+func (r *graphQLResolver) SearchFeeds(ctx context.Context, args struct {
+	Query    string
+	Category *string
+}) ([]*graphQLFeed, error) {
+	ctx, cancel := contextWithTimeout(graphQLRequest(ctx))
+	defer cancel()
+	feeds, err := r.h.Service.SearchFeeds(ctx, args.Query, deref(args.Category))
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	return newGraphQLFeeds(feeds), nil
+}
+
+func (r *graphQLResolver) PopularFeeds(ctx context.Context, args struct{ Limit int32 }) ([]*graphQLFeed, error) {
+	if args.Limit <= 0 {
+		args.Limit = 10
+	}
+	ctx, cancel := contextWithTimeout(graphQLRequest(ctx))
+	defer cancel()
+	feeds, err := r.h.Service.GetPopularFeeds(ctx, int64(min(args.Limit, maxFeedPageSize)))
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	return newGraphQLFeeds(feeds), nil
+}
+
+func (r *graphQLResolver) MyFeeds(ctx context.Context) ([]*graphQLFeed, error) {
+	c, userID, err := r.authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feeds, err := r.h.Service.GetUserFeeds(ctx, userID)
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	return newGraphQLFeeds(feeds), nil
+}
+
+func (r *graphQLResolver) MySubscriptions(ctx context.Context) ([]*graphQLSubscription, error) {
+	c, userID, err := r.authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	subs, err := r.h.Service.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	out := make([]*graphQLSubscription, len(subs))
+	for i := range subs {
+		out[i] = newGraphQLSubscription(subs[i])
+	}
+	return out, nil
+}
+
+// createFeedInput is the CreateFeedInput GraphQL input
+type createFeedInput struct {
+	Name            string
+	Description     *string
+	URL             string
+	Category        *string
+	Icon            *string
+	ConnectionType  string
+	EventName       *string
+	DataFormat      *string
+	IsPublic        *bool
+	Tags            *[]string
+	Website         *string
+	Documentation   *string
+	SystemPrompt    *string
+	DefaultAIPrompt *string
+}
+
+// connectionTypesNeedingConfig are the connection types whose settings the
+// GraphQL input does not cover, or whose credentials it cannot return
+var connectionTypesNeedingConfig = map[string]string{
+	"mqtt":                      "mqttConfig",
+	"kafka":                     "kafkaConfig",
+	"http-polling":              "httpConfig",
+	services.PushConnectionType: "ingestion credentials",
+}
+
+// CreateFeed creates a feed the way POST /api/marketplace/feeds does and
+// subscribes the caller to it
+func (r *graphQLResolver) CreateFeed(ctx context.Context, args struct{ Input createFeedInput }) (*graphQLFeed, error) {
+	c, userID, err := r.authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in := args.Input
+	if field, ok := connectionTypesNeedingConfig[in.ConnectionType]; ok {
+		return nil, graphQLErr(fmt.Errorf("%s feeds need %s; create them through the REST API", in.ConnectionType, field), http.StatusBadRequest)
+	}
+	feed := models.WebSocketFeed{
+		Name:                in.Name,
+		Description:         deref(in.Description),
+		SystemPrompt:        deref(in.SystemPrompt),
+		URL:                 in.URL,
+		Category:            deref(in.Category),
+		Icon:                deref(in.Icon),
+		IsActive:            true,
+		IsPublic:            in.IsPublic != nil && *in.IsPublic,
+		FeedType:            "user",
+		OwnerID:             userID,
+		OwnerName:           c.GetString("username"),
+		ConnectionType:      in.ConnectionType,
+		EventName:           deref(in.EventName),
+		DataFormat:          deref(in.DataFormat),
+		ReconnectionEnabled: true,
+		Website:             deref(in.Website),
+		Documentation:       deref(in.Documentation),
+		DefaultAIPrompt:     deref(in.DefaultAIPrompt),
+	}
+	if in.Tags != nil {
+		feed.Tags = *in.Tags
+	}
+	if errs := socket.ValidateFeed(feed); len(errs) > 0 {
+		return nil, &graphQLError{err: fmt.Errorf("%s: %s", errs[0].Field, errs[0].Message), code: "invalid_feed"}
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if r.h.Categories != nil {
+		category, err := r.h.Categories.ResolveCategory(ctx, feed.Category)
+		if err != nil {
+			return nil, graphQLErr(err, http.StatusInternalServerError)
+		}
+		feed.Category = category
+	}
+	created, err := r.h.Service.CreateFeed(ctx, feed)
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusBadRequest)
+	}
+
+	recordAudit(c, r.h.Audit, auditChange(auditEvent(c, services.AuditFeedCreate, services.AuditTargetFeed, created.ID.Hex()), nil, created))
+
+	// Auto-subscribe creator to their own feed, as REST does
+	_, _ = r.h.Service.Subscribe(ctx, userID, created.ID.Hex(), "")
+	return newGraphQLFeed(*created), nil
+}
+
+// Subscribe subscribes the caller to a feed they may read and connects the
+// feed if it is active
+func (r *graphQLResolver) Subscribe(ctx context.Context, args struct {
+	FeedID     graphql.ID
+	TTLSeconds *int32
+}) (*graphQLSubscription, error) {
+	c, userID, err := r.authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ttl time.Duration
+	if args.TTLSeconds != nil {
+		if *args.TTLSeconds < 0 {
+			return nil, graphQLErr(errors.New("ttlSeconds must not be negative"), http.StatusBadRequest)
+		}
+		ttl = time.Duration(*args.TTLSeconds) * time.Second
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := r.h.Service.GetFeedByID(ctx, string(args.FeedID))
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	if !r.mayRead(ctx, feed, userID) {
+		return nil, graphQLErr(services.ErrNotAuthorized, http.StatusForbidden)
+	}
+	sub, err := r.h.Service.SubscribeWithTTL(ctx, userID, feed.ID.Hex(), "", ttl)
+	if err != nil {
+		return nil, graphQLErr(err, http.StatusInternalServerError)
+	}
+	if feed.IsActive {
+		_ = r.h.Sockets.ConnectFeed(*feed)
+	}
+	return newGraphQLSubscription(*sub), nil
+}
+
+func (r *graphQLResolver) Unsubscribe(ctx context.Context, args struct{ FeedID graphql.ID }) (bool, error) {
+	c, userID, err := r.authenticated(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := r.h.Service.Unsubscribe(ctx, userID, string(args.FeedID)); err != nil {
+		return false, graphQLErr(err, http.StatusInternalServerError)
+	}
+	return true, nil
+}
+
+// FeedData streams a feed through the socket manager, so the subscription
+// joins the feed's data room like a websocket subscriber. The channel closes
+// when the operation ends, the server shuts down or the subscriber falls too
+// far behind.
+func (r *graphQLResolver) FeedData(ctx context.Context, args struct {
+	FeedID      graphql.ID
+	Filters     *[]string
+	ReplayCount int32
+}) (<-chan *graphQLFeedEvent, error) {
+	c := graphQLRequest(ctx)
+	var filters []string
+	if args.Filters != nil {
+		filters = *args.Filters
+		if err := socket.ValidateFilters(filters); err != nil {
+			return nil, graphQLErr(err, http.StatusBadRequest)
+		}
+	}
+	sub := socket.Subscriber{UserID: graphQLCaller(c), IP: c.ClientIP()}
+	stream, err := r.h.Sockets.OpenFeedStream(ctx, sub, string(args.FeedID), filters, int(args.ReplayCount))
+	var denied *socket.SubscriptionDeniedError
+	switch {
+	case errors.As(err, &denied) && denied.NotFound():
+		return nil, &graphQLError{err: err, code: "feed_not_found"}
+	case errors.As(err, &denied):
+		return nil, &graphQLError{err: err, code: "not_authorized"}
+	case err != nil:
+		return nil, graphQLErr(err, http.StatusServiceUnavailable)
+	}
+
+	events := make(chan *graphQLFeedEvent)
+	go func() {
+		defer close(events)
+		defer stream.Close()
+		for {
+			event, err := stream.Next()
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.InfoContext(ctx, "graphql feed stream ended", "feed_id", args.FeedID, "reason", err)
+				}
+				return
+			}
+			select {
+			case events <- newGraphQLFeedEvent(event):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+type graphQLFeedPage struct {
+	Feeds   []*graphQLFeed
+	Total   int32
+	HasMore bool
+}
+
+// graphQLFeed is the Feed type: the listing fields of a feed, without its
+// connection settings
+type graphQLFeed struct {
+	ID              graphql.ID
+	Name            string
+	Description     string
+	URL             string
+	Category        string
+	Icon            string
+	IsActive        bool
+	PausedAt        *graphql.Time
+	IsVerified      bool
+	IsPublic        bool
+	FeedType        string
+	OwnerID         string
+	OwnerName       string
+	OrgID           string
+	ConnectionType  string
+	EventName       string
+	DataFormat      string
+	SubscriberCount int32
+	Tags            []string
+	Website         string
+	Documentation   string
+	CreatedAt       graphql.Time
+	UpdatedAt       graphql.Time
+}
+
+func newGraphQLFeed(feed models.WebSocketFeed) *graphQLFeed {
+	out := &graphQLFeed{
+		ID:              graphql.ID(feed.ID.Hex()),
+		Name:            feed.Name,
+		Description:     feed.Description,
+		URL:             feed.URL,
+		Category:        feed.Category,
+		Icon:            feed.Icon,
+		IsActive:        feed.IsActive,
+		PausedAt:        graphQLTime(feed.PausedAt),
+		IsVerified:      feed.IsVerified,
+		IsPublic:        feed.IsPublic,
+		FeedType:        feed.FeedType,
+		OwnerID:         feed.OwnerID,
+		OwnerName:       feed.OwnerName,
+		OrgID:           feed.OrgID,
+		ConnectionType:  feed.ConnectionType,
+		EventName:       feed.EventName,
+		DataFormat:      feed.DataFormat,
+		SubscriberCount: int32(feed.SubscriberCount),
+		Tags:            feed.Tags,
+		Website:         feed.Website,
+		Documentation:   feed.Documentation,
+		CreatedAt:       graphql.Time{Time: feed.CreatedAt},
+		UpdatedAt:       graphql.Time{Time: feed.UpdatedAt},
+	}
+	if out.Tags == nil {
+		out.Tags = []string{}
+	}
+	return out
+}
+
+func newGraphQLFeeds(feeds []models.WebSocketFeed) []*graphQLFeed {
+	out := make([]*graphQLFeed, len(feeds))
+	for i := range feeds {
+		out[i] = newGraphQLFeed(feeds[i])
+	}
+	return out
+}
+
+type graphQLSubscription struct {
+	ID           graphql.ID
+	FeedID       graphql.ID
+	SubscribedAt graphql.Time
+	IsActive     bool
+	Filters      []string
+	ExpiresAt    *graphql.Time
+}
+
+func newGraphQLSubscription(sub models.UserSubscription) *graphQLSubscription {
+	out := &graphQLSubscription{
+		ID:           graphql.ID(sub.ID.Hex()),
+		FeedID:       graphql.ID(sub.FeedID),
+		SubscribedAt: graphql.Time{Time: sub.Subscribed},
+		IsActive:     sub.IsActive,
+		Filters:      []string{},
+		ExpiresAt:    graphQLTime(sub.ExpiresAt),
+	}
+	if sub.Settings != nil && sub.Settings.Filters != nil {
+		out.Filters = sub.Settings.Filters
+	}
+	return out
+}
+
+type graphQLFeedEvent struct {
+	Data   *graphQLFeedMessage
+	Status *graphQLFeedStatus
+}
+
+type graphQLFeedMessage struct {
+	FeedID    graphql.ID
+	FeedName  string
+	EventName string
+	Data      *graphQLJSON
+	Timestamp graphql.Time
+}
+
+type graphQLFeedStatus struct {
+	FeedID  graphql.ID
+	Status  string
+	Message string
+}
+
+func newGraphQLFeedEvent(event socket.StreamEvent) *graphQLFeedEvent {
+	if st := event.Status; st != nil {
+		return &graphQLFeedEvent{Status: &graphQLFeedStatus{
+			FeedID:  graphql.ID(st.FeedID),
+			Status:  st.Status,
+			Message: st.Message,
+		}}
+	}
+	msg := event.Data
+	return &graphQLFeedEvent{Data: &graphQLFeedMessage{
+		FeedID:    graphql.ID(msg.FeedID),
+		FeedName:  msg.FeedName,
+		EventName: msg.EventName,
+		Data:      &graphQLJSON{Value: msg.Data},
+		Timestamp: graphql.Time{Time: msg.Timestamp},
+	}}
+}
+
+func graphQLTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+// graphQLJSON is the JSON scalar: any JSON value, passed through as is
+type graphQLJSON struct {
+	Value interface{}
+}
+
+// ImplementsGraphQLType maps the type to the JSON scalar
+func (graphQLJSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+// UnmarshalGraphQL accepts any input value
+func (j *graphQLJSON) UnmarshalGraphQL(input interface{}) error {
+	j.Value = input
+	return nil
+}
+
+func (j graphQLJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Value)
+}
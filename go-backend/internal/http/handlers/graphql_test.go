@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+)
+
+// setupGraphQL serves the GraphQL API over in-memory repositories, acting as
+// userID when it is not zero
+func setupGraphQL(t *testing.T, userID primitive.ObjectID) (*gin.Engine, *GraphQLHandler, *services.MarketplaceService) {
+	t.Helper()
+	marketplaceService := services.NewMarketplaceServiceWithRepos(services.NewMemoryFeedRepo(), services.NewMemorySubscriptionRepo())
+	// The socket manager has no marketplace, so streams skip access checks and feeds are never dialled
+	marketplace := NewMarketplaceHandler(marketplaceService, socket.NewManager(nil, nil, nil, nil))
+	handler := NewGraphQLHandler(marketplace, nil)
+
+	router := setupTestRouter()
+	group := router.Group("/api/graphql", func(c *gin.Context) {
+		if !userID.IsZero() {
+			c.Set("userId", userID)
+			c.Set("username", "alice")
+		}
+	})
+	handler.RegisterRoutes(group)
+	return router, handler, marketplaceService
+}
+
+type graphQLTestResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, router *gin.Engine, query string, variables map[string]interface{}) graphQLTestResponse {
+	t.Helper()
+	body, err := json.Marshal(graphQLRequestBody{Query: query, Variables: variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp graphQLTestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestGraphQLHandler_Queries(t *testing.T) {
+	owner := primitive.NewObjectID()
+	router, _, marketplaceService := setupGraphQL(t, primitive.NilObjectID)
+	ctx := context.Background()
+	public, err := marketplaceService.CreateFeed(ctx, models.WebSocketFeed{Name: "Prices", URL: "wss://example.com", Category: "crypto", IsPublic: true, Tags: []string{"btc"}})
+	require.NoError(t, err)
+	private, err := marketplaceService.CreateFeed(ctx, models.WebSocketFeed{Name: "Secret", URL: "wss://example.com", IsPublic: false, OwnerID: owner.Hex()})
+	require.NoError(t, err)
+
+	resp := postGraphQL(t, router, `{ feeds(limit: 10) { total hasMore feeds { id name category tags isPublic } } }`, nil)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"total":1,"hasMore":false,"feeds":[{"id":"`+public.ID.Hex()+`","name":"Prices","category":"crypto","tags":["btc"],"isPublic":true}]}`,
+		string(resp.Data["feeds"]))
+
+	resp = postGraphQL(t, router, `query($id: ID!) { feed(id: $id) { name } }`, map[string]interface{}{"id": private.ID.Hex()})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `null`, string(resp.Data["feed"]), "private feeds look missing")
+
+	resp = postGraphQL(t, router, `query($id: ID!) { feed(id: $id) { name } }`, map[string]interface{}{"id": primitive.NewObjectID().Hex()})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `null`, string(resp.Data["feed"]))
+
+	resp = postGraphQL(t, router, `{ myFeeds { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "unauthenticated", resp.Errors[0].Extensions["code"])
+
+	resp = postGraphQL(t, router, `{ feeds { nope } }`, nil)
+	assert.NotEmpty(t, resp.Errors, "queries are validated against the schema")
+}
+
+func TestGraphQLHandler_Mutations(t *testing.T) {
+	userID := primitive.NewObjectID()
+	router, _, marketplaceService := setupGraphQL(t, userID)
+
+	resp := postGraphQL(t, router, `mutation($input: CreateFeedInput!) { createFeed(input: $input) { id name ownerId ownerName isActive feedType } }`,
+		map[string]interface{}{"input": map[string]interface{}{"name": "Ticks", "url": "wss://example.com/ticks", "connectionType": "websocket", "isPublic": true}})
+	require.Empty(t, resp.Errors)
+	var created struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		OwnerID   string `json:"ownerId"`
+		OwnerName string `json:"ownerName"`
+		IsActive  bool   `json:"isActive"`
+		FeedType  string `json:"feedType"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["createFeed"], &created))
+	assert.Equal(t, "Ticks", created.Name)
+	assert.Equal(t, userID.Hex(), created.OwnerID)
+	assert.Equal(t, "alice", created.OwnerName)
+	assert.True(t, created.IsActive)
+	assert.Equal(t, "user", created.FeedType)
+
+	resp = postGraphQL(t, router, `{ mySubscriptions { feedId isActive } }`, nil)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[{"feedId":"`+created.ID+`","isActive":true}]`, string(resp.Data["mySubscriptions"]), "the creator is subscribed")
+
+	resp = postGraphQL(t, router, `mutation { createFeed(input: {name: "", url: "ftp://x", connectionType: "websocket"}) { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "invalid_feed", resp.Errors[0].Extensions["code"])
+
+	resp = postGraphQL(t, router, `mutation { createFeed(input: {name: "Q", url: "mqtt://x", connectionType: "mqtt"}) { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "REST API")
+
+	// Inactive, so subscribing does not dial the feed
+	other, err := marketplaceService.CreateFeed(context.Background(), models.WebSocketFeed{Name: "Other", URL: "wss://example.com", IsPublic: true})
+	require.NoError(t, err)
+	resp = postGraphQL(t, router, `mutation($id: ID!) { subscribe(feedId: $id, ttlSeconds: 60) { feedId expiresAt } }`, map[string]interface{}{"id": other.ID.Hex()})
+	require.Empty(t, resp.Errors)
+	var sub struct {
+		FeedID    string     `json:"feedId"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["subscribe"], &sub))
+	assert.Equal(t, other.ID.Hex(), sub.FeedID)
+	require.NotNil(t, sub.ExpiresAt)
+
+	resp = postGraphQL(t, router, `mutation($id: ID!) { unsubscribe(feedId: $id) }`, map[string]interface{}{"id": other.ID.Hex()})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `true`, string(resp.Data["unsubscribe"]))
+
+	private, err := marketplaceService.CreateFeed(context.Background(), models.WebSocketFeed{Name: "Private", URL: "wss://example.com", OwnerID: primitive.NewObjectID().Hex()})
+	require.NoError(t, err)
+	resp = postGraphQL(t, router, `mutation($id: ID!) { subscribe(feedId: $id) { feedId } }`, map[string]interface{}{"id": private.ID.Hex()})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "not_authorized", resp.Errors[0].Extensions["code"])
+}
+
+// dialGraphQL opens a subscription websocket to the test server
+func dialGraphQL(t *testing.T, srv *httptest.Server, subprotocols ...string) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: subprotocols, HandshakeTimeout: 5 * time.Second}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/graphql", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	return conn
+}
+
+// closeCode reads until the server closes the connection and returns its close code
+func closeCode(t *testing.T, conn *websocket.Conn) int {
+	t.Helper()
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		return closeErr.Code
+	}
+}
+
+func TestGraphQLHandler_Subscriptions(t *testing.T) {
+	router, handler, _ := setupGraphQL(t, primitive.NilObjectID)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	sockets := handler.Marketplace.Sockets
+	conn := dialGraphQL(t, srv, graphQLWSProtocol)
+
+	read := func() graphQLWSMessage {
+		t.Helper()
+		var msg graphQLWSMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{Type: "connection_init"}))
+	assert.Equal(t, "connection_ack", read().Type)
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{Type: "ping"}))
+	assert.Equal(t, "pong", read().Type)
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Prices", IsActive: true}
+	feedID := feed.ID.Hex()
+	payload, err := json.Marshal(graphQLRequestBody{
+		Query:     `subscription($id: ID!) { feedData(feedId: $id, filters: ["data.price > 10"]) { data { feedId eventName data } status { status } } }`,
+		Variables: map[string]interface{}{"id": feedID},
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{ID: "1", Type: "subscribe", Payload: payload}))
+	require.Eventually(t, func() bool { return sockets.LiveSubscribers(feedID).Connections == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	sockets.BroadcastFeedData(feed, map[string]interface{}{"price": 5.0}, "tick")
+	sockets.BroadcastFeedData(feed, map[string]interface{}{"price": 12.5}, "tick")
+	msg := read()
+	assert.Equal(t, "next", msg.Type)
+	assert.Equal(t, "1", msg.ID)
+	assert.JSONEq(t, `{"data":{"feedData":{"data":{"feedId":"`+feedID+`","eventName":"tick","data":{"price":12.5}},"status":null}}}`, string(msg.Payload),
+		"the filter drops the cheap message")
+
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{ID: "1", Type: "complete"}))
+	require.Eventually(t, func() bool { return sockets.LiveSubscribers(feedID).Connections == 0 }, 2*time.Second, 10*time.Millisecond,
+		"completing the operation leaves the feed's room")
+
+	bad, err := json.Marshal(graphQLRequestBody{Query: `subscription { feedData(feedId: "f1", filters: ["data.price"]) { data { feedId } } }`})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{ID: "2", Type: "subscribe", Payload: bad}))
+	msg = read()
+	assert.Equal(t, "2", msg.ID)
+	assert.Equal(t, "error", msg.Type)
+	assert.Contains(t, string(msg.Payload), "invalid filter")
+
+	invalid, err := json.Marshal(graphQLRequestBody{Query: `subscription { nope }`})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{ID: "3", Type: "subscribe", Payload: invalid}))
+	msg = read()
+	assert.Equal(t, "3", msg.ID)
+	assert.Equal(t, "error", msg.Type, "operations that do not validate get an error message")
+
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{Type: "connection_init"}))
+	assert.Equal(t, graphQLCloseTooManyInitReqs, closeCode(t, conn))
+}
+
+func TestGraphQLHandler_SubscriptionsNeedInit(t *testing.T) {
+	router, _, _ := setupGraphQL(t, primitive.NilObjectID)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	conn := dialGraphQL(t, srv, graphQLWSProtocol)
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"{ feeds { total } }"}`)}))
+	assert.Equal(t, graphQLCloseUnauthorized, closeCode(t, conn))
+
+	conn = dialGraphQL(t, srv, graphQLWSProtocol)
+	require.NoError(t, conn.WriteJSON(graphQLWSMessage{Type: "connection_init", Payload: json.RawMessage(`{"authorization":"Bearer token"}`)}))
+	assert.Equal(t, graphQLCloseForbidden, closeCode(t, conn), "tokens cannot be checked without an auth service")
+
+	conn = dialGraphQL(t, srv)
+	assert.Equal(t, graphQLCloseBadProtocol, closeCode(t, conn))
+}
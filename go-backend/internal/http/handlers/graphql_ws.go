@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// graphQLWSProtocol is the websocket subprotocol subscriptions are served on
+const graphQLWSProtocol = "graphql-transport-ws"
+
+// Subscription websocket timeouts
+const (
+	// graphQLInitTimeout is how long a client may wait before connection_init
+	graphQLInitTimeout = 10 * time.Second
+	graphQLWriteWait   = 10 * time.Second
+)
+
+// Close codes defined by the graphql-transport-ws protocol
+const (
+	graphQLCloseInvalidMessage  = 4400
+	graphQLCloseUnauthorized    = 4401
+	graphQLCloseForbidden       = 4403
+	graphQLCloseBadProtocol     = 4406
+	graphQLCloseInitTimeout     = 4408
+	graphQLCloseDuplicateID     = 4409
+	graphQLCloseTooManyInitReqs = 4429
+)
+
+// graphQLWSMessage is a graphql-transport-ws protocol message
+type graphQLWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// graphQLWSConn is one subscription websocket and its running operations
+type graphQLWSConn struct {
+	h    *GraphQLHandler
+	c    *gin.Context
+	conn *websocket.Conn
+	ctx  context.Context
+
+	writeMu sync.Mutex
+	opsMu   sync.Mutex
+	ops     map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// subscriptions upgrades to a graphql-transport-ws websocket. The client
+// authenticates in connection_init with an "authorization" ("Bearer <token>")
+// or "token" payload entry; without one its operations are anonymous.
+func (h *GraphQLHandler) subscriptions(c *gin.Context) {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{graphQLWSProtocol},
+		CheckOrigin:  h.allowOrigin,
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "graphql websocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	ws := &graphQLWSConn{h: h, c: c, conn: conn, ctx: ctx, ops: make(map[string]context.CancelFunc)}
+	if conn.Subprotocol() != graphQLWSProtocol {
+		ws.close(graphQLCloseBadProtocol, "Subprotocol not acceptable")
+		cancel()
+		return
+	}
+	code, reason := ws.run()
+	cancel()
+	ws.wg.Wait()
+	ws.close(code, reason)
+}
+
+// allowOrigin accepts requests without an Origin header and, when
+// OriginPatterns is set, browser origins matching one of them
+func (h *GraphQLHandler) allowOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(h.OriginPatterns) == 0 {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range h.OriginPatterns {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, u.Host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// run reads messages until the connection ends, returning how to close it
+func (ws *graphQLWSConn) run() (int, string) {
+	_ = ws.conn.SetReadDeadline(time.Now().Add(graphQLInitTimeout))
+	acked := false
+	for {
+		var msg graphQLWSMessage
+		if err := ws.conn.ReadJSON(&msg); err != nil {
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() && !acked {
+				return graphQLCloseInitTimeout, "Connection initialisation timeout"
+			}
+			if _, ok := err.(*websocket.CloseError); ok {
+				return websocket.CloseNormalClosure, ""
+			}
+			return graphQLCloseInvalidMessage, "Invalid message"
+		}
+		switch msg.Type {
+		case "connection_init":
+			if acked {
+				return graphQLCloseTooManyInitReqs, "Too many initialisation requests"
+			}
+			if !ws.authenticate(msg.Payload) {
+				return graphQLCloseForbidden, "Forbidden"
+			}
+			acked = true
+			_ = ws.conn.SetReadDeadline(time.Time{})
+			ws.write(graphQLWSMessage{Type: "connection_ack"})
+		case "ping":
+			ws.write(graphQLWSMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !acked {
+				return graphQLCloseUnauthorized, "Unauthorized"
+			}
+			var body graphQLRequestBody
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &body) != nil {
+				return graphQLCloseInvalidMessage, "Invalid subscribe message"
+			}
+			if !ws.start(msg.ID, body) {
+				return graphQLCloseDuplicateID, fmt.Sprintf("Subscriber for %s already exists", msg.ID)
+			}
+		case "complete":
+			ws.stop(msg.ID)
+		default:
+			return graphQLCloseInvalidMessage, "Invalid message type"
+		}
+	}
+}
+
+// authenticate sets the caller on the upgrade request from a connection_init
+// payload, reporting false for credentials that cannot be verified
+func (ws *graphQLWSConn) authenticate(payload json.RawMessage) bool {
+	var init struct {
+		Authorization string `json:"authorization"`
+		Token         string `json:"token"`
+	}
+	if len(payload) > 0 && string(payload) != "null" {
+		if err := json.Unmarshal(payload, &init); err != nil {
+			return false
+		}
+	}
+	token := init.Token
+	if token == "" {
+		token = strings.TrimSpace(strings.TrimPrefix(init.Authorization, "Bearer"))
+	}
+	if token == "" {
+		return true
+	}
+	if ws.h.Auth == nil {
+		return false
+	}
+	claims, err := ws.h.Auth.Authenticate(ws.ctx, token)
+	if err != nil {
+		return false
+	}
+	userIDStr, _ := claims["userId"].(string)
+	userOID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return false
+	}
+	ws.c.Set("userId", userOID)
+	ws.c.Set("userEmail", claims["email"])
+	ws.c.Set("username", claims["username"])
+	return true
+}
+
+// start runs an operation, reporting false when id is already running
+func (ws *graphQLWSConn) start(id string, body graphQLRequestBody) bool {
+	ws.opsMu.Lock()
+	defer ws.opsMu.Unlock()
+	if _, ok := ws.ops[id]; ok {
+		return false
+	}
+	ctx, cancel := context.WithCancel(withGraphQLRequest(ws.ctx, ws.c))
+	ws.ops[id] = cancel
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+		ws.execute(ctx, id, body)
+		// An operation the client completed needs no complete message
+		ws.opsMu.Lock()
+		_, running := ws.ops[id]
+		delete(ws.ops, id)
+		ws.opsMu.Unlock()
+		cancel()
+		if running && ws.ctx.Err() == nil {
+			ws.write(graphQLWSMessage{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// execute sends an operation's results as next messages. A request that fails
+// before it runs, such as one that does not validate, gets an error message.
+func (ws *graphQLWSConn) execute(ctx context.Context, id string, body graphQLRequestBody) {
+	responses, err := ws.h.schema.Subscribe(ctx, body.Query, body.OperationName, body.Variables)
+	if err != nil {
+		ws.writePayload(id, "error", []map[string]string{{"message": err.Error()}})
+		ws.stop(id)
+		return
+	}
+	first := true
+	for r := range responses {
+		resp, ok := r.(*graphql.Response)
+		if !ok {
+			continue
+		}
+		if first && len(resp.Data) == 0 && len(resp.Errors) > 0 {
+			ws.writePayload(id, "error", resp.Errors)
+			// An error message ends the operation without a complete
+			ws.stop(id)
+			return
+		}
+		first = false
+		ws.writePayload(id, "next", resp)
+	}
+}
+
+// stop cancels a running operation
+func (ws *graphQLWSConn) stop(id string) {
+	ws.opsMu.Lock()
+	cancel, ok := ws.ops[id]
+	delete(ws.ops, id)
+	ws.opsMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (ws *graphQLWSConn) writePayload(id, msgType string, payload interface{}) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		slog.WarnContext(ws.ctx, "failed to encode graphql message", "type", msgType, "error", err)
+		return
+	}
+	ws.write(graphQLWSMessage{ID: id, Type: msgType, Payload: raw})
+}
+
+func (ws *graphQLWSConn) write(msg graphQLWSMessage) {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(graphQLWriteWait))
+	if err := ws.conn.WriteJSON(msg); err != nil && ws.ctx.Err() == nil {
+		slog.DebugContext(ws.ctx, "graphql websocket write failed", "type", msg.Type, "error", err)
+	}
+}
+
+// close sends a close frame; the connection is closed when the handler returns
+func (ws *graphQLWSConn) close(code int, reason string) {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	_ = ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(graphQLWriteWait))
+}
@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/buildinfo"
 )
 
-// HealthHandler registers a health check endpoint to monitor service status
+// readinessCheckTimeout bounds each dependency check of /readyz
+const readinessCheckTimeout = 2 * time.Second
+
+// HealthHandler registers a health check endpoint to monitor service status,
+// plus /healthz for liveness probes and /version with the running build
 func HealthHandler(r *gin.Engine) {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -21,4 +28,61 @@ func HealthHandler(r *gin.Engine) {
 			"timestamp": time.Now().UTC(),
 		})
 	})
+	// Liveness: the process is up and serving; dependencies are left to /readyz
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/version", func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get())
+	})
+}
+
+// ReadinessCheck checks one dependency for /readyz. Check returns a short
+// description of the dependency's state and an error when it is not usable.
+// A failing Required check makes the instance not ready; any other failing
+// check only marks it degraded.
+type ReadinessCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) (string, error)
+}
+
+// checkResult is one check's entry in the /readyz response
+type checkResult struct {
+	Status   string `json:"status"` // "ok" or "failed"
+	Required bool   `json:"required"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReadinessHandler registers /readyz for readiness probes. It answers 200
+// with status "ready" or "degraded" and 503 with "not-ready" once a required
+// check fails.
+func ReadinessHandler(r *gin.Engine, checks ...ReadinessCheck) {
+	r.GET("/readyz", func(c *gin.Context) {
+		status, code := "ready", http.StatusOK
+		results := make(map[string]checkResult, len(checks))
+		for _, check := range checks {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), readinessCheckTimeout)
+			detail, err := check.Check(ctx)
+			cancel()
+
+			result := checkResult{Status: "ok", Required: check.Required, Detail: detail}
+			if err != nil {
+				result.Status = "failed"
+				result.Error = err.Error()
+				if check.Required {
+					status, code = "not-ready", http.StatusServiceUnavailable
+				} else if status == "ready" {
+					status = "degraded"
+				}
+			}
+			results[check.Name] = result
+		}
+		c.JSON(code, gin.H{
+			"status":    status,
+			"checks":    results,
+			"timestamp": time.Now().UTC(),
+		})
+	})
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	var mongoErr, feedsErr error
+	router := setupTestRouter()
+	HealthHandler(router)
+	ReadinessHandler(router,
+		ReadinessCheck{Name: "mongodb", Required: true, Check: func(context.Context) (string, error) { return "", mongoErr }},
+		ReadinessCheck{Name: "feeds", Check: func(context.Context) (string, error) { return "2 connected", feedsErr }},
+	)
+
+	get := func(path string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body["status"])
+	feeds := body["checks"].(map[string]interface{})["feeds"].(map[string]interface{})
+	assert.Equal(t, "2 connected", feeds["detail"])
+
+	// Optional checks only degrade readiness
+	feedsErr = errors.New("1 upstream feeds erroring")
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", body["status"])
+
+	mongoErr = errors.New("server selection timeout")
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not-ready", body["status"])
+	mongo := body["checks"].(map[string]interface{})["mongodb"].(map[string]interface{})
+	assert.Equal(t, "failed", mongo["status"])
+	assert.Equal(t, "server selection timeout", mongo["error"])
+
+	// Liveness ignores dependencies
+	code, body = get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+
+	code, body = get("/version")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "dev", body["version"])
+	assert.NotEmpty(t, body["goVersion"])
+}
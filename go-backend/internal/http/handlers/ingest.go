@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// maxIngestBodyBytes bounds the messages producers push to a feed
+const maxIngestBodyBytes = 1 << 20
+
+// RegisterIngest adds the public endpoint producers push data to push feeds on
+func (h *MarketplaceHandler) RegisterIngest(group *gin.RouterGroup) {
+	group.POST("/:feedToken", h.ingest)
+}
+
+// ingestCredentials is how newly issued ingestion credentials are returned;
+// the secret cannot be retrieved again
+func ingestCredentials(creds *services.IngestCredentials) gin.H {
+	return gin.H{
+		"path":   "/ingest/" + creds.Token,
+		"token":  creds.Token,
+		"secret": creds.Secret,
+	}
+}
+
+// issueIngestCredentials gives a push feed the user manages a new ingestion
+// URL and signing secret; the old ones stop working
+func (h *MarketplaceHandler) issueIngestCredentials(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	idStr := c.Param("id")
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.GetFeedByID(ctx, idStr)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	// Organization owners and admins manage org feeds like their owners do
+	manages, err := h.Service.CanManageFeed(ctx, feed, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !manages {
+		respondError(c, services.ErrNotAuthorized, http.StatusForbidden)
+		return
+	}
+	creds, err := h.Service.IssueIngestCredentials(ctx, feed.ID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditFeedIngestIssue, services.AuditTargetFeed, idStr))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": ingestCredentials(creds)})
+}
+
+// ingest broadcasts a message pushed to a push feed. The body is the message
+// as JSON and X-TurboStream-Event optionally names its event. Requests are
+// signed the way outgoing webhooks are: X-TurboStream-Signature carries
+// services.SignWebhookPayload of the body under the feed's ingestion secret
+// and the Unix time in X-TurboStream-Timestamp.
+func (h *MarketplaceHandler) ingest(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIngestBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"success": false, "message": "payload too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.VerifyIngest(ctx, c.Param("feedToken"),
+		c.GetHeader(services.WebhookTimestampHeader), c.GetHeader(services.WebhookSignatureHeader), body, time.Now())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "payload must be JSON"})
+		return
+	}
+	if !feed.IsActive {
+		respondError(c, services.ErrFeedPaused, http.StatusConflict)
+		return
+	}
+	eventName := c.GetHeader(services.WebhookEventHeader)
+	if eventName == "" {
+		eventName = feed.EventName
+	}
+	h.Sockets.BroadcastFeedData(*feed, data, eventName)
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Data accepted",
+		"data": gin.H{
+			"feedId":    feed.ID.Hex(),
+			"eventName": eventName,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
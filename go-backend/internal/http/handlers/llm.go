@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
 )
@@ -13,6 +20,12 @@ import (
 type LLMHandler struct {
 	llm     *services.LLMService
 	sockets *socket.Manager
+	// Usage records answered queries; nil disables the usage ledger
+	Usage *services.LLMUsageService
+	// Analyses stores scheduled analyses; nil disables them
+	Analyses *services.AnalysisService
+	// Conversations stores multi-turn chats; nil disables them
+	Conversations *services.ConversationService
 }
 
 // NewLLMHandler creates a new LLM handler
@@ -38,6 +51,9 @@ func (h *LLMHandler) GetFeedContext(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "feedId is required"})
 		return
 	}
+	if !h.canQueryFeed(c, feedID) {
+		return
+	}
 
 	ctx := h.llm.GetFeedContext(feedID)
 	if ctx == nil {
@@ -82,14 +98,21 @@ type QueryRequest struct {
 	Question     string `json:"question" binding:"required"`
 	Provider     string `json:"provider,omitempty"`
 	SystemPrompt string `json:"systemPrompt,omitempty"`
+	// Optional generation settings, validated against the provider's ranges
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	// ResponseFormat asks for a JSON answer, returned parsed as "json"
+	ResponseFormat *services.ResponseFormat `json:"responseFormat,omitempty"`
+	// Tools lets the model compute figures from the feed before answering
+	Tools bool `json:"tools,omitempty"`
 }
 
 // Query answers a question about feed data
 // POST /api/llm/query
 func (h *LLMHandler) Query(c *gin.Context) {
-	if !h.llm.Enabled() {
+	if !h.llm.EnabledFor(c.Request.Context(), requestUserID(c)) {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "No LLM providers configured. Please set API keys in environment variables.",
+			"error": "No LLM providers configured. Please set API keys in environment variables or add your own under /api/settings/llm-keys.",
 		})
 		return
 	}
@@ -99,18 +122,29 @@ func (h *LLMHandler) Query(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.canQueryFeed(c, req.FeedID) {
+		return
+	}
 
 	resp, err := h.llm.Query(c.Request.Context(), services.QueryRequest{
-		FeedID:       req.FeedID,
-		Question:     req.Question,
-		Provider:     req.Provider,
-		SystemPrompt: req.SystemPrompt,
+		FeedID:         req.FeedID,
+		Question:       req.Question,
+		Provider:       req.Provider,
+		SystemPrompt:   req.SystemPrompt,
+		Temperature:    req.Temperature,
+		MaxTokens:      req.MaxTokens,
+		ResponseFormat: req.ResponseFormat,
+		Tools:          req.Tools,
+		UserID:         requestUserID(c),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status, code := errorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{"error": err.Error(), "code": code})
 		return
 	}
 
+	h.recordUsage(c.Request.Context(), requestUserID(c), "query", resp)
+
 	// Broadcast the result to LLM subscribers
 	if h.sockets != nil {
 		h.sockets.BroadcastLLMOutput(req.FeedID, resp.Answer, resp.Provider)
@@ -122,7 +156,7 @@ func (h *LLMHandler) Query(c *gin.Context) {
 // StreamQuery streams the LLM response using Server-Sent Events
 // POST /api/llm/query/stream
 func (h *LLMHandler) StreamQuery(c *gin.Context) {
-	if !h.llm.Enabled() {
+	if !h.llm.EnabledFor(c.Request.Context(), requestUserID(c)) {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "No LLM providers configured",
 		})
@@ -134,6 +168,9 @@ func (h *LLMHandler) StreamQuery(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.canQueryFeed(c, req.FeedID) {
+		return
+	}
 
 	// Set SSE headers
 	c.Header("Content-Type", "text/event-stream")
@@ -142,15 +179,24 @@ func (h *LLMHandler) StreamQuery(c *gin.Context) {
 	c.Header("Transfer-Encoding", "chunked")
 
 	tokenChan := make(chan string, 100)
+	ctx, userID := c.Request.Context(), requestUserID(c)
 
 	// Start streaming in background
 	go func() {
-		_, _ = h.llm.StreamQuery(c.Request.Context(), services.QueryRequest{
-			FeedID:       req.FeedID,
-			Question:     req.Question,
-			Provider:     req.Provider,
-			SystemPrompt: req.SystemPrompt,
+		resp, err := h.llm.StreamQuery(ctx, services.QueryRequest{
+			FeedID:         req.FeedID,
+			Question:       req.Question,
+			Provider:       req.Provider,
+			SystemPrompt:   req.SystemPrompt,
+			Temperature:    req.Temperature,
+			MaxTokens:      req.MaxTokens,
+			ResponseFormat: req.ResponseFormat,
+			Tools:          req.Tools,
+			UserID:         userID,
 		}, tokenChan)
+		if err == nil {
+			h.recordUsage(ctx, userID, "stream", resp)
+		}
 	}()
 
 	// Stream tokens to client
@@ -173,7 +219,7 @@ type AnalyzeRequest struct {
 // Analyze provides analysis of feed data
 // POST /api/llm/analyze
 func (h *LLMHandler) Analyze(c *gin.Context) {
-	if !h.llm.Enabled() {
+	if !h.llm.EnabledFor(c.Request.Context(), requestUserID(c)) {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "No LLM providers configured",
 		})
@@ -185,12 +231,98 @@ func (h *LLMHandler) Analyze(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.canQueryFeed(c, req.FeedID) {
+		return
+	}
 
-	resp, err := h.llm.AnalyzeFeed(c.Request.Context(), req.FeedID, req.CustomPrompt)
+	resp, err := h.llm.AnalyzeFeed(c.Request.Context(), req.FeedID, req.CustomPrompt, requestUserID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status, code := errorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{"error": err.Error(), "code": code})
 		return
 	}
+	h.recordUsage(c.Request.Context(), requestUserID(c), "analyze", resp)
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// canQueryFeed checks the caller may read the feed a request draws on, under
+// the rules websocket subscriptions follow, answering the request and
+// returning false when they may not
+func (h *LLMHandler) canQueryFeed(c *gin.Context, feedID string) bool {
+	if h.sockets == nil {
+		return true
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	err := h.sockets.AuthorizeFeedRead(ctx, socket.Subscriber{UserID: requestUserID(c), IP: c.ClientIP()}, feedID)
+	var denied *socket.SubscriptionDeniedError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &denied) && denied.NotFound():
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "feed_not_found"})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "feed_access_denied"})
+	}
+	return false
+}
+
+// requestUserID returns the authenticated user's ID, or "" without one
+func requestUserID(c *gin.Context) string {
+	if id, ok := c.Get("userId"); ok {
+		return id.(primitive.ObjectID).Hex()
+	}
+	return ""
+}
+
+// recordUsage adds an answered query to the user's usage ledger
+func (h *LLMHandler) recordUsage(ctx context.Context, userID, mode string, resp *services.QueryResponse) {
+	if h.Usage == nil || userID == "" {
+		return
+	}
+	entry := models.LLMUsage{
+		UserID:           userID,
+		FeedID:           resp.FeedID,
+		Provider:         resp.Provider,
+		Mode:             mode,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		LatencyMs:        resp.Duration,
+		Cached:           resp.Cached,
+		OwnKey:           resp.OwnKey,
+	}
+	if fc := h.llm.GetFeedContext(resp.FeedID); fc != nil {
+		entry.FeedName = fc.FeedName
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := h.Usage.Record(ctx, entry); err != nil {
+		slog.WarnContext(ctx, "failed to record LLM usage", "user_id", userID, "error", err)
+	}
+}
+
+// GetUsage breaks the user's LLM usage down by feed, day or provider over an
+// optional RFC3339 from/to range, the last 30 days by default
+// GET /api/llm/usage?groupBy=feed|day|provider
+func (h *LLMHandler) GetUsage(c *gin.Context) {
+	if h.Usage == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage ledger is disabled"})
+		return
+	}
+	from, to, err := parseTimeRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	summary, err := h.Usage.Summary(ctx, userID.Hex(), c.Query("groupBy"), from, to)
+	if err != nil {
+		status, code := errorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{"error": err.Error(), "code": code})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
@@ -1,6 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -22,6 +27,21 @@ import (
 type MarketplaceHandler struct {
 	Service *services.MarketplaceService
 	Sockets *socket.Manager
+	// History serves past feed messages; nil when history is disabled
+	History *services.FeedHistoryService
+	// Categories validates feed categories; nil accepts any category
+	Categories *services.SettingsService
+	// Alerts stores subscribers' alert rules; nil disables the alert endpoints
+	Alerts *services.AlertService
+	// Webhooks stores users' feed webhooks; nil disables the webhook endpoints
+	Webhooks *services.WebhookService
+	// Access stores grants and invites to private feeds; nil leaves private
+	// feeds to their owners and disables the sharing endpoints
+	Access *services.FeedAccessService
+	// Orgs resolves the target of feed transfers; nil disables them
+	Orgs *services.OrganizationService
+	// Audit records feed and sharing changes; nil records nothing
+	Audit *services.AuditService
 }
 
 // NewMarketplaceHandler creates a new marketplace handler instance
@@ -41,27 +61,89 @@ func (h *MarketplaceHandler) RegisterRoutes(public, protected *gin.RouterGroup)
 	protected.PUT("/feeds/:id", h.updateFeed)
 	protected.DELETE("/feeds/:id", h.deleteFeed)
 	protected.GET("/my-feeds", h.myFeeds)
+	protected.GET("/my-feeds/deleted", h.deletedFeeds)
 	protected.POST("/subscribe/:feedId", h.subscribe)
 	protected.POST("/unsubscribe/:feedId", h.unsubscribe)
 	protected.GET("/subscriptions", h.subscriptions)
 	protected.PUT("/subscriptions/:feedId/settings", h.updateSubscription)
-	protected.POST("/feeds/:feedId/data", h.submitFeedData)
-	// Use the same wildcard name (:id) as the base feed route to avoid Gin conflicts.
+	protected.POST("/subscriptions/:feedId/renew", h.renewSubscription)
+	protected.GET("/subscriptions/:feedId/alerts", h.listAlerts)
+	protected.POST("/subscriptions/:feedId/alerts", h.createAlert)
+	protected.PUT("/subscriptions/:feedId/alerts/:alertId", h.updateAlert)
+	protected.DELETE("/subscriptions/:feedId/alerts/:alertId", h.deleteAlert)
+	// Every route under /feeds/ names the feed :id; Gin refuses to register
+	// two wildcard names at the same position
+	protected.POST("/feeds/:id/data", h.submitFeedData)
 	protected.PUT("/feeds/:id/ai-prompt", h.updatePrompt)
+	protected.GET("/feeds/:id/sample", h.sample)
+	protected.GET("/feeds/:id/history", h.history)
+	protected.GET("/feeds/:id/health", h.health)
+	protected.GET("/feeds/:id/schema", h.schema)
+	protected.GET("/feeds/:id/live-subscribers", h.liveSubscribers)
+	protected.GET("/feeds/:id/webhooks", h.listWebhooks)
+	protected.POST("/feeds/:id/webhooks", h.createWebhook)
+	protected.PUT("/feeds/:id/webhooks/:webhookId", h.updateWebhook)
+	protected.DELETE("/feeds/:id/webhooks/:webhookId", h.deleteWebhook)
+	protected.GET("/feeds/:id/webhooks/:webhookId/deliveries", h.webhookDeliveries)
+	protected.GET("/feeds/:id/access", h.listAccess)
+	protected.POST("/feeds/:id/access", h.grantAccess)
+	protected.DELETE("/feeds/:id/access/:grantId", h.revokeAccess)
+	protected.GET("/feeds/:id/invites", h.listInvites)
+	protected.POST("/feeds/:id/invites", h.createInvite)
+	protected.DELETE("/feeds/:id/invites/:inviteId", h.revokeInvite)
+	protected.POST("/invites/:token/accept", h.acceptInvite)
+	protected.POST("/feeds/:id/transfer", h.transferFeed)
+	protected.POST("/feeds/:id/restore", h.restoreFeed)
+	protected.POST("/feeds/:id/pause", h.pauseFeed)
+	protected.POST("/feeds/:id/resume", h.resumeFeed)
+	protected.POST("/feeds/:id/clone", h.cloneFeed)
+	protected.POST("/feeds/:id/ingest-credentials", h.issueIngestCredentials)
 	protected.POST("/test-feed", h.testFeed)
 }
 
-// listFeeds retrieves all public feeds with optional category filter
+// Page sizes for the public feed listing
+const (
+	defaultFeedPageSize = 50
+	maxFeedPageSize     = 200
+)
+
+// listFeeds retrieves a page of public feeds. Query parameters: category,
+// verified=true|false, sort=recent|subscribers|name, limit (default 50, max
+// 200) and offset. The envelope carries the page size as count and the
+// number of matching feeds as total.
 func (h *MarketplaceHandler) listFeeds(c *gin.Context) {
-	category := c.Query("category")
+	verified, err := parseOptionalBool(c.Query("verified"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "verified must be true or false"})
+		return
+	}
+	limit := parseLimit(c.Query("limit"), defaultFeedPageSize)
+	if limit > maxFeedPageSize {
+		limit = maxFeedPageSize
+	}
+	opts := services.FeedListOptions{
+		Category: c.Query("category"),
+		Verified: verified,
+		Sort:     c.Query("sort"),
+		Limit:    int64(limit),
+		Offset:   int64(parseLimit(c.Query("offset"), 0)),
+	}
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	feeds, err := h.Service.GetPublicFeeds(ctx, category)
+	feeds, total, err := h.Service.GetPublicFeeds(ctx, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": feeds, "count": len(feeds)})
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feeds,
+		"count":   len(feeds),
+		"total":   total,
+		"limit":   opts.Limit,
+		"offset":  opts.Offset,
+		"hasMore": opts.Offset+int64(len(feeds)) < total,
+	})
 }
 
 // popularFeeds retrieves feeds sorted by subscriber count with optional limit
@@ -98,7 +180,7 @@ func (h *MarketplaceHandler) searchFeeds(c *gin.Context) {
 	defer cancel()
 	feeds, err := h.Service.SearchFeeds(ctx, q, category)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": feeds, "count": len(feeds)})
@@ -111,12 +193,194 @@ func (h *MarketplaceHandler) getFeed(c *gin.Context) {
 	defer cancel()
 	feed, err := h.Service.GetFeedByID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Feed not found"})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
+	// Private feeds look missing to anyone who may not read them
+	if !feed.IsPublic {
+		var userID string
+		if oid, ok := c.Get("userId"); ok {
+			userID = oid.(primitive.ObjectID).Hex()
+		}
+		if (userID == "" || feed.OwnerID != userID) && !h.hasGrant(ctx, feed, userID) {
+			respondError(c, services.ErrFeedNotFound, http.StatusNotFound)
+			return
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": feed})
 }
 
+// sample returns the most recent message for a feed. Context data is used when
+// available; otherwise an idle feed is briefly connected to capture one message.
+func (h *MarketplaceHandler) sample(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	id := c.Param("id")
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !h.canReadFeed(ctx, c, feed, userID.Hex()) {
+		return
+	}
+
+	if entry, ok := h.Sockets.LatestFeedSample(id); ok {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+			"feedId":    id,
+			"source":    "context",
+			"sample":    entry,
+			"timestamp": entry["_timestamp"],
+		}})
+		return
+	}
+	if h.Sockets.IsFeedConnected(id) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "no data received from feed yet"})
+		return
+	}
+
+	data, err := h.Sockets.CaptureFeedSample(ctx, *feed)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "message": "failed to capture sample: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"feedId":    id,
+		"source":    "live",
+		"sample":    data,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}})
+}
+
+// maxHistoryLimit caps how many messages one history request returns
+const maxHistoryLimit = 1000
+
+// history returns recorded messages for a feed, newest first. from and to
+// are optional RFC3339 bounds and limit defaults to 100.
+func (h *MarketplaceHandler) history(c *gin.Context) {
+	if h.History == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "feed history is disabled"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	id := c.Param("id")
+	from, to, err := parseTimeRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	limit := parseLimit(c.Query("limit"), 100)
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !h.canReadFeed(ctx, c, feed, userID.Hex()) {
+		return
+	}
+
+	records, err := h.History.Query(ctx, feed.ID.Hex(), from, to, int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": records, "count": len(records)})
+}
+
+// health returns the upstream connection state of a feed the caller can access
+func (h *MarketplaceHandler) health(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	id := c.Param("id")
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !h.canReadFeed(ctx, c, feed, userID.Hex()) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": h.Sockets.FeedHealth(feed.ID.Hex())})
+}
+
+// liveSubscribers returns how many clients are connected to a feed the
+// caller manages right now, alongside its stored subscriber count
+func (h *MarketplaceHandler) liveSubscribers(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	id := c.Param("id")
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	manages, err := h.Service.CanManageFeed(ctx, feed, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !manages {
+		respondError(c, services.ErrNotAuthorized, http.StatusForbidden)
+		return
+	}
+	live := h.Sockets.LiveSubscribers(feed.ID.Hex())
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"feedId":          live.FeedID,
+		"connections":     live.Connections,
+		"users":           live.Users,
+		"subscriberCount": feed.SubscriberCount,
+	}})
+}
+
+// schema returns the schema inferred from the messages of a feed the caller
+// can access. Schemas are inferred once the feed has broadcast data.
+func (h *MarketplaceHandler) schema(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	id := c.Param("id")
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.GetFeedByID(ctx, id)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !h.canReadFeed(ctx, c, feed, userID.Hex()) {
+		return
+	}
+	if feed.Schema == nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "no schema inferred for this feed yet"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feed.Schema})
+}
+
+// parseTimeRange parses optional RFC3339 from/to query values; empty values are zero times
+func parseTimeRange(rawFrom, rawTo string) (from, to time.Time, err error) {
+	if rawFrom != "" {
+		if from, err = time.Parse(time.RFC3339, rawFrom); err != nil {
+			return from, to, errors.New("from must be an RFC3339 timestamp")
+		}
+	}
+	if rawTo != "" {
+		if to, err = time.Parse(time.RFC3339, rawTo); err != nil {
+			return from, to, errors.New("to must be an RFC3339 timestamp")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, errors.New("to must not be before from")
+	}
+	return from, to, nil
+}
+
 // createFeed creates a new feed in the marketplace and auto-subscribes the creator
 func (h *MarketplaceHandler) createFeed(c *gin.Context) {
 	userID := c.MustGet("userId").(primitive.ObjectID)
@@ -146,17 +410,27 @@ func (h *MarketplaceHandler) createFeed(c *gin.Context) {
 		ConnectionMessages:      filterMessages(body.ConnectionMessages),
 		ConnectionMessage:       body.ConnectionMessage,
 		ConnectionMessageFormat: body.ConnectionMessageFormat,
+		SecretMessages:          body.SecretMessages,
 		EventName:               body.EventName,
 		DataFormat:              body.DataFormat,
+		ProtobufType:            body.ProtobufType,
+		ProtobufDescriptor:      body.ProtobufDescriptor,
 		ReconnectionEnabled:     true,
 		ReconnectionDelay:       body.ReconnectionDelay,
 		ReconnectionAttempts:    body.ReconnectionAttempts,
+		ReassembleFragments:     body.ReassembleFragments,
+		FragmentMaxBytes:        body.FragmentMaxBytes,
+		FragmentTimeoutMs:       body.FragmentTimeoutMs,
 		HTTPConfig:              nil,
 		Tags:                    body.Tags,
 		Website:                 body.Website,
 		Documentation:           body.Documentation,
 		DefaultAIPrompt:         body.DefaultAIPrompt,
 		AIAnalysisEnabled:       body.AIAnalysisEnabled,
+		HistoryRetentionHours:   body.HistoryRetentionHours,
+		Transform:               body.Transform,
+		Throttle:                body.Throttle,
+		Aggregation:             body.Aggregation,
 	}
 
 	if body.ConnectionType == "http-polling" && body.HTTPConfig != nil {
@@ -171,17 +445,94 @@ func (h *MarketplaceHandler) createFeed(c *gin.Context) {
 		}
 	}
 
+	if body.ConnectionType == "mqtt" {
+		if body.MQTTConfig == nil || strings.TrimSpace(body.MQTTConfig.Topic) == "" || body.MQTTConfig.QoS > 2 {
+			respondInvalidFeed(c, []socket.FieldError{{Field: "mqttConfig", Message: "mqtt feeds require mqttConfig with a topic and qos 0-2"}})
+			return
+		}
+		feed.MQTTConfig = &models.MQTTConfig{
+			Topic:    strings.TrimSpace(body.MQTTConfig.Topic),
+			QoS:      body.MQTTConfig.QoS,
+			ClientID: body.MQTTConfig.ClientID,
+			Username: body.MQTTConfig.Username,
+			Password: body.MQTTConfig.Password,
+		}
+	}
+
+	if body.ConnectionType == "kafka" {
+		if body.KafkaConfig == nil || strings.TrimSpace(body.KafkaConfig.Topic) == "" ||
+			(len(body.KafkaConfig.Brokers) == 0 && strings.TrimSpace(body.URL) == "") {
+			respondInvalidFeed(c, []socket.FieldError{{Field: "kafkaConfig", Message: "kafka feeds require kafkaConfig with a topic and brokers"}})
+			return
+		}
+		feed.KafkaConfig = &models.KafkaConfig{
+			Brokers:     filterMessages(body.KafkaConfig.Brokers),
+			Topic:       strings.TrimSpace(body.KafkaConfig.Topic),
+			GroupID:     body.KafkaConfig.GroupID,
+			StartOffset: body.KafkaConfig.StartOffset,
+			TLS:         body.KafkaConfig.TLS,
+		}
+		if sasl := body.KafkaConfig.SASL; sasl != nil {
+			feed.KafkaConfig.SASL = &models.KafkaSASLConfig{
+				Mechanism: sasl.Mechanism,
+				Username:  sasl.Username,
+				Password:  sasl.Password,
+			}
+		}
+	}
+
+	if body.ConnectionType == "simulated" {
+		feed.SimulatedConfig = body.SimulatedConfig
+	}
+
+	if avro := body.AvroConfig; avro != nil {
+		feed.AvroConfig = &models.AvroConfig{
+			SchemaRegistryURL: strings.TrimSpace(avro.SchemaRegistryURL),
+			Schema:            avro.Schema,
+			Username:          avro.Username,
+			Password:          avro.Password,
+		}
+	}
+	if errs := socket.ValidateFeed(feed); len(errs) > 0 {
+		respondInvalidFeed(c, errs)
+		return
+	}
+
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
+	if h.Categories != nil {
+		category, err := h.Categories.ResolveCategory(ctx, feed.Category)
+		if err != nil {
+			respondError(c, err, http.StatusInternalServerError)
+			return
+		}
+		feed.Category = category
+	}
+	if c.Query("dryRun") == "true" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed is valid", "dryRun": true})
+		return
+	}
 	created, err := h.Service.CreateFeed(ctx, feed)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedCreate, services.AuditTargetFeed, created.ID.Hex()), nil, created))
+
 	// Auto-subscribe creator to their own feed for convenience.
 	_, _ = h.Service.Subscribe(ctx, userID.Hex(), created.ID.Hex(), "")
 
+	// Push feeds come with the URL and secret their producers post with
+	if created.ConnectionType == services.PushConnectionType {
+		creds, err := h.Service.IssueIngestCredentials(ctx, created.ID)
+		if err != nil {
+			respondError(c, err, http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": created, "ingest": ingestCredentials(creds)})
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{"success": true, "data": created})
 }
 
@@ -201,20 +552,106 @@ func (h *MarketplaceHandler) updateFeed(c *gin.Context) {
 	}
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	feed, err := h.Service.GetFeedByID(ctx, idStr)
-	if err != nil || feed.OwnerID != userID.Hex() {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "not authorized"})
+	existing, err := h.Service.GetOwnedFeed(ctx, idStr, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	merged, err := dataFormatSettings(*existing, body)
+	if err != nil {
+		respondInvalidFeed(c, []socket.FieldError{{Field: "avroConfig", Message: err.Error()}})
+		return
+	}
+	merged, errs := connectionSettings(merged, body)
+	if len(errs) > 0 {
+		respondInvalidFeed(c, errs)
+		return
+	}
+	if _, ok := body["simulatedConfig"]; ok {
+		body["simulatedConfig"] = merged.SimulatedConfig
+	}
+	throttle, throttleUpdated, err := throttleUpdate(body)
+	if err != nil {
+		respondInvalidFeed(c, []socket.FieldError{{Field: "throttle", Message: err.Error()}})
+		return
+	}
+	if throttleUpdated {
+		body["throttle"] = throttle
+		merged.Throttle = throttle
+	}
+	aggregation, aggregationUpdated, err := aggregationUpdate(body)
+	if err != nil {
+		respondInvalidFeed(c, []socket.FieldError{{Field: "aggregation", Message: err.Error()}})
+		return
+	}
+	if aggregationUpdated {
+		body["aggregation"] = aggregation
+		merged.Aggregation = aggregation
+	}
+	anomalyDetection, anomalyDetectionUpdated, err := anomalyDetectionUpdate(body)
+	if err != nil {
+		respondInvalidFeed(c, []socket.FieldError{{Field: "anomalyDetection", Message: err.Error()}})
+		return
+	}
+	if anomalyDetectionUpdated {
+		body["anomalyDetection"] = anomalyDetection
+		merged.AnomalyDetection = anomalyDetection
+	}
+	if errs := updatedFieldErrors(socket.ValidateFeed(merged), body); len(errs) > 0 {
+		respondInvalidFeed(c, errs)
 		return
 	}
 	delete(body, "_id")
 	delete(body, "ownerId")
 	delete(body, "ownerName")
+	delete(body, "orgId")
 	delete(body, "subscriberCount")
+	// Verification is granted by admins and lapses when the upstream changes
+	delete(body, "isVerified")
+	delete(body, "verification")
+	// The schema is inferred from the feed's messages
+	delete(body, "schema")
+	// Ingestion credentials are issued through their own endpoint
+	delete(body, "ingest")
+	if changesUpstream(body) {
+		body["isVerified"] = false
+	}
+	// Setting isActive pauses or resumes the feed
+	delete(body, "pausedAt")
+	if active, ok := body["isActive"].(bool); ok && active != existing.IsActive {
+		if active {
+			body["pausedAt"] = nil
+		} else {
+			body["pausedAt"] = time.Now()
+		}
+	}
+	if category, ok := body["category"].(string); ok && h.Categories != nil {
+		if body["category"], err = h.Categories.ResolveCategory(ctx, category); err != nil {
+			respondError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if c.Query("dryRun") == "true" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed is valid", "dryRun": true})
+		return
+	}
 	updated, err := h.Service.UpdateFeed(ctx, oid, bson.M(body))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedUpdate, services.AuditTargetFeed, idStr), existing, updated))
+	// Throttle, aggregation and anomaly detection changes apply to the running connection right away
+	if throttleUpdated && h.Sockets != nil {
+		h.Sockets.SetFeedThrottle(idStr, updated.Throttle)
+	}
+	if aggregationUpdated && h.Sockets != nil {
+		h.Sockets.SetFeedAggregation(idStr, updated.Aggregation)
+	}
+	if anomalyDetectionUpdated && h.Sockets != nil {
+		h.Sockets.SetFeedAnomalyDetection(idStr, updated.AnomalyDetection)
+	}
+	h.applyFeedActive(existing, updated)
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
 }
 
@@ -229,20 +666,162 @@ func (h *MarketplaceHandler) deleteFeed(c *gin.Context) {
 	}
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	feed, err := h.Service.GetFeedByID(ctx, idStr)
-	if err != nil || feed.OwnerID != userID.Hex() {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "not authorized"})
+	existing, err := h.Service.GetOwnedFeed(ctx, idStr, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 	if err := h.Service.DeleteFeed(ctx, oid); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedDelete, services.AuditTargetFeed, idStr), existing, nil))
 
 	// Stop the feed connection if it's active
 	h.Sockets.StopFeed(idStr)
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed deleted"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed deleted; it can be restored for 30 days"})
+}
+
+// cloneFeed copies a public feed, or one the caller manages, into a new
+// private and inactive draft owned by the caller. Body (optional): {"name"}.
+// Header values and passwords are left empty; set them and isActive with
+// PUT /feeds/:id.
+func (h *MarketplaceHandler) cloneFeed(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body struct {
+		Name string `json:"name"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+			return
+		}
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	created, err := h.Service.CloneFeed(ctx, c.Param("id"), userID.Hex(), c.GetString("username"), body.Name)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedClone, services.AuditTargetFeed, created.ID.Hex()), nil, created))
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": created})
+}
+
+// restoreFeed brings back a feed the caller deleted within the last 30 days
+func (h *MarketplaceHandler) restoreFeed(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feed, err := h.Service.RestoreFeed(ctx, c.Param("id"), userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditFeedRestore, services.AuditTargetFeed, feed.ID.Hex()))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feed})
+}
+
+// pauseFeed stops a feed the caller manages without deleting it. Its
+// upstream is disconnected and subscribers get feed-paused; nothing
+// reconnects it until it is resumed.
+func (h *MarketplaceHandler) pauseFeed(c *gin.Context) {
+	h.setFeedPaused(c, true)
+}
+
+// resumeFeed reactivates a paused feed the caller manages and reconnects it
+// for its subscribers
+func (h *MarketplaceHandler) resumeFeed(c *gin.Context) {
+	h.setFeedPaused(c, false)
+}
+
+func (h *MarketplaceHandler) setFeedPaused(c *gin.Context, paused bool) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	idStr := c.Param("id")
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	existing, err := h.Service.GetOwnedFeed(ctx, idStr, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	feed, err := h.Service.SetFeedPaused(ctx, existing.ID, paused)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	action := services.AuditFeedResume
+	if paused {
+		action = services.AuditFeedPause
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, action, services.AuditTargetFeed, idStr), existing, feed))
+	h.applyFeedActive(existing, feed)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feed})
+}
+
+// applyFeedActive stops or reconnects a feed's upstream when an update
+// paused or resumed it
+func (h *MarketplaceHandler) applyFeedActive(before, after *models.WebSocketFeed) {
+	if h.Sockets == nil || before.IsActive == after.IsActive {
+		return
+	}
+	if !after.IsActive {
+		h.Sockets.PauseFeed(after.ID.Hex())
+		return
+	}
+	if err := h.Sockets.ResumeFeed(*after); err != nil {
+		slog.Warn("failed to reconnect resumed feed", "feed_id", after.ID.Hex(), "error", err)
+	}
+}
+
+// deletedFeeds lists the caller's deleted feeds that can still be restored
+func (h *MarketplaceHandler) deletedFeeds(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	feeds, err := h.Service.GetDeletedFeeds(ctx, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feeds, "count": len(feeds)})
+}
+
+// transferFeed moves a personal feed the caller manages to an organization
+// where they are an owner or admin. Body: {"orgId"}.
+func (h *MarketplaceHandler) transferFeed(c *gin.Context) {
+	if h.Orgs == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "organizations are disabled"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body struct {
+		OrgID string `json:"orgId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "orgId is required"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	org, err := h.Orgs.Get(ctx, body.OrgID, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	existing, err := h.Service.GetOwnedFeed(ctx, c.Param("id"), userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	feed, err := h.Service.TransferFeed(ctx, c.Param("id"), userID.Hex(), *org)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditFeedTransfer, services.AuditTargetFeed, feed.ID.Hex()), existing, feed))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feed})
 }
 
 // myFeeds retrieves all feeds owned by the authenticated user
@@ -258,19 +837,53 @@ func (h *MarketplaceHandler) myFeeds(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": feeds, "count": len(feeds)})
 }
 
-// subscribe creates a subscription to a feed and initiates WebSocket connection
+// subscriptionTTLPayload is the optional body for subscribe and renew requests
+type subscriptionTTLPayload struct {
+	TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+// bindSubscriptionTTL reads an optional {"ttlSeconds": n} body; an empty body means no expiry
+func bindSubscriptionTTL(c *gin.Context) (time.Duration, bool) {
+	var body subscriptionTTLPayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+			return 0, false
+		}
+	}
+	if body.TTLSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ttlSeconds must not be negative"})
+		return 0, false
+	}
+	return time.Duration(body.TTLSeconds) * time.Second, true
+}
+
+// subscribe creates a subscription to a feed and initiates WebSocket connection.
+// An optional ttlSeconds in the body makes the subscription expire automatically.
 func (h *MarketplaceHandler) subscribe(c *gin.Context) {
 	userID := c.MustGet("userId").(primitive.ObjectID)
 	feedID := c.Param("feedId")
+	ttl, ok := bindSubscriptionTTL(c)
+	if !ok {
+		return
+	}
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	sub, err := h.Service.Subscribe(ctx, userID.Hex(), feedID, "")
+	feed, err := h.Service.GetFeedByID(ctx, feedID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !h.canReadFeed(ctx, c, feed, userID.Hex()) {
+		return
+	}
+	sub, err := h.Service.SubscribeWithTTL(ctx, userID.Hex(), feedID, "", ttl)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 	// Connect to the feed for streaming if not already connected.
-	if feed, err := h.Service.GetFeedByID(ctx, feedID); err == nil && feed != nil {
+	if feed.IsActive {
 		_ = h.Sockets.ConnectFeed(*feed)
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Subscribed", "subscription": sub})
@@ -311,6 +924,14 @@ func (h *MarketplaceHandler) updateSubscription(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
 		return
 	}
+	if err := validateSubscriptionFilters(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err := validateSubscriptionPrompts(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
 	if err := h.Service.UpdateSubscriptionSettings(ctx, userID.Hex(), feedID, bson.M(body)); err != nil {
@@ -320,10 +941,93 @@ func (h *MarketplaceHandler) updateSubscription(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Subscription updated"})
 }
 
-// submitFeedData allows feed owners to broadcast data to subscribers via WebSocket
-func (h *MarketplaceHandler) submitFeedData(c *gin.Context) {
+// validateSubscriptionFilters checks filters sent as {"settings": {"filters": [...]}}
+// or {"settings.filters": [...]} so invalid expressions are never stored
+func validateSubscriptionFilters(body map[string]interface{}) error {
+	exprs, ok := subscriptionSettingList(body, "filters")
+	if !ok {
+		return errors.New("filters must be a list of expressions")
+	}
+	return socket.ValidateFilters(exprs)
+}
+
+const (
+	// maxSavedPrompts and maxSavedPromptLength bound a subscription's prompt library
+	maxSavedPrompts      = 50
+	maxSavedPromptLength = 4000
+)
+
+// validateSubscriptionPrompts checks the saved prompt library sent as
+// {"settings": {"prompts": [...]}} or {"settings.prompts": [...]}
+func validateSubscriptionPrompts(body map[string]interface{}) error {
+	prompts, ok := subscriptionSettingList(body, "prompts")
+	if !ok {
+		return errors.New("prompts must be a list of strings")
+	}
+	if len(prompts) > maxSavedPrompts {
+		return fmt.Errorf("at most %d prompts can be saved", maxSavedPrompts)
+	}
+	for i, prompt := range prompts {
+		if strings.TrimSpace(prompt) == "" {
+			return fmt.Errorf("prompt %d is empty", i+1)
+		}
+		if len(prompt) > maxSavedPromptLength {
+			return fmt.Errorf("prompt %d is longer than %d characters", i+1, maxSavedPromptLength)
+		}
+	}
+	return nil
+}
+
+// subscriptionSettingList reads a list of strings from settings.<key> in an
+// update body, either nested or as a dotted key. A missing or null list is
+// empty; ok is false when it isn't a list of strings.
+func subscriptionSettingList(body map[string]interface{}, key string) ([]string, bool) {
+	raw := body["settings."+key]
+	if settings, isMap := body["settings"].(map[string]interface{}); isMap {
+		if v, found := settings[key]; found {
+			raw = v
+		}
+	}
+	if raw == nil {
+		return nil, true
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+// renewSubscription extends an active subscription's expiry; ttlSeconds of 0 removes the expiry
+func (h *MarketplaceHandler) renewSubscription(c *gin.Context) {
 	userID := c.MustGet("userId").(primitive.ObjectID)
 	feedID := c.Param("feedId")
+	ttl, ok := bindSubscriptionTTL(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	expiresAt, err := h.Service.RenewSubscription(ctx, userID.Hex(), feedID, ttl)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Subscription renewed", "expiresAt": expiresAt})
+}
+
+// submitFeedData allows feed managers to broadcast data to subscribers via WebSocket
+func (h *MarketplaceHandler) submitFeedData(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	feedID := c.Param("id")
 	var body struct {
 		Data      interface{} `json:"data"`
 		EventName string      `json:"eventName"`
@@ -336,11 +1040,20 @@ func (h *MarketplaceHandler) submitFeedData(c *gin.Context) {
 	defer cancel()
 	feed, err := h.Service.GetFeedByID(ctx, feedID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "feed not found"})
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	manages, err := h.Service.CanManageFeed(ctx, feed, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !manages {
+		respondError(c, services.ErrNotAuthorized, http.StatusForbidden)
 		return
 	}
-	if feed.OwnerID != userID.Hex() {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "not authorized"})
+	if !feed.IsActive {
+		respondError(c, services.ErrFeedPaused, http.StatusConflict)
 		return
 	}
 	// Broadcast to connected subscribers via socket.io
@@ -371,9 +1084,9 @@ func (h *MarketplaceHandler) updatePrompt(c *gin.Context) {
 	}
 	ctx, cancel := contextWithTimeout(c)
 	defer cancel()
-	feed, err := h.Service.GetFeedByID(ctx, feedID)
-	if err != nil || feed.OwnerID != userID.Hex() {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "not authorized"})
+	feed, err := h.Service.GetOwnedFeed(ctx, feedID, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 	updated, err := h.Service.UpdateFeed(ctx, feed.ID, bson.M{"defaultAIPrompt": body.DefaultAIPrompt})
@@ -395,6 +1108,214 @@ func parseLimit(raw string, fallback int) int {
 	return fallback
 }
 
+// upstreamFields are the feed settings verification checks depend on
+var upstreamFields = []string{"url", "connectionType", "queryParams", "headers", "connectionMessages", "connectionMessage",
+	"eventName", "dataFormat", "protobufType", "protobufDescriptor", "httpConfig", "mqttConfig", "kafkaConfig", "avroConfig", "simulatedConfig", "transform"}
+
+// dataFormatSettings returns feed with the connection type and data format
+// settings from a feed update applied, for validation
+func dataFormatSettings(feed models.WebSocketFeed, updates map[string]interface{}) (models.WebSocketFeed, error) {
+	for field, target := range map[string]*string{
+		"connectionType":     &feed.ConnectionType,
+		"dataFormat":         &feed.DataFormat,
+		"protobufType":       &feed.ProtobufType,
+		"protobufDescriptor": &feed.ProtobufDescriptor,
+	} {
+		if v, ok := updates[field].(string); ok {
+			*target = v
+		}
+	}
+	if raw, ok := updates["avroConfig"]; ok {
+		// The update replaces the whole avroConfig
+		feed.AvroConfig = nil
+		encoded, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(encoded, &feed.AvroConfig)
+		}
+		if err != nil {
+			return feed, errors.New("invalid avroConfig")
+		}
+	}
+	return feed, nil
+}
+
+// connectionSettings returns feed with the name and connection settings from
+// a feed update applied, for validation. Fields of the wrong type are
+// reported as field errors.
+func connectionSettings(feed models.WebSocketFeed, updates map[string]interface{}) (models.WebSocketFeed, []socket.FieldError) {
+	var errs []socket.FieldError
+	for field, target := range map[string]*string{
+		"name":                    &feed.Name,
+		"url":                     &feed.URL,
+		"eventName":               &feed.EventName,
+		"connectionMessage":       &feed.ConnectionMessage,
+		"connectionMessageFormat": &feed.ConnectionMessageFormat,
+	} {
+		raw, ok := updates[field]
+		if !ok {
+			continue
+		}
+		v, ok := raw.(string)
+		if !ok {
+			errs = append(errs, socket.FieldError{Field: field, Message: field + " must be a string"})
+			continue
+		}
+		*target = v
+	}
+	// The update replaces each of these whole, so decode into fresh values
+	// rather than the pointers the feed shares with its caller
+	var messages []string
+	var httpConfig *models.HTTPPollingConfig
+	var kafkaConfig *models.KafkaConfig
+	var simulatedConfig *models.SimulatedConfig
+	for field, target := range map[string]interface{}{
+		"connectionMessages": &messages,
+		"httpConfig":         &httpConfig,
+		"kafkaConfig":        &kafkaConfig,
+		"simulatedConfig":    &simulatedConfig,
+	} {
+		raw, ok := updates[field]
+		if !ok {
+			continue
+		}
+		if err := decodeUpdateField(raw, target); err != nil {
+			errs = append(errs, socket.FieldError{Field: field, Message: "invalid " + field})
+		}
+	}
+	if _, ok := updates["connectionMessages"]; ok {
+		feed.ConnectionMessages = messages
+	}
+	if _, ok := updates["httpConfig"]; ok {
+		feed.HTTPConfig = httpConfig
+	}
+	if _, ok := updates["kafkaConfig"]; ok {
+		feed.KafkaConfig = kafkaConfig
+	}
+	if _, ok := updates["simulatedConfig"]; ok {
+		feed.SimulatedConfig = simulatedConfig
+	}
+	return feed, errs
+}
+
+// decodeUpdateField decodes a raw update value into target via JSON
+func decodeUpdateField(raw, target interface{}) error {
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, target)
+}
+
+// feedFieldDependencies are update fields whose change can make another
+// field invalid, keyed by the field the error is reported on
+var feedFieldDependencies = map[string][]string{
+	"url":                {"connectionType", "kafkaConfig"},
+	"eventName":          {"connectionType"},
+	"connectionMessage":  {"connectionMessageFormat"},
+	"connectionMessages": {"connectionMessageFormat"},
+	"httpConfig":         {"connectionType"},
+	"dataFormat":         {"connectionType", "protobufType", "protobufDescriptor", "avroConfig"},
+}
+
+// updatedFieldErrors keeps the errors an update is responsible for: those on
+// fields it sets or on fields that depend on them. Problems a feed already
+// had do not block unrelated edits.
+func updatedFieldErrors(errs []socket.FieldError, updates map[string]interface{}) []socket.FieldError {
+	var out []socket.FieldError
+	for _, e := range errs {
+		field, _, _ := strings.Cut(e.Field, ".")
+		_, touched := updates[field]
+		for _, dep := range feedFieldDependencies[field] {
+			if _, ok := updates[dep]; ok {
+				touched = true
+			}
+		}
+		if touched {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// respondInvalidFeed rejects a feed with its field errors, leading with the
+// first one's message for clients that do not read them
+func respondInvalidFeed(c *gin.Context, errs []socket.FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": errs[0].Message, "code": "invalid_feed", "errors": errs})
+}
+
+// throttleUpdate decodes the throttle options from a feed update; ok is
+// false when the update leaves them alone
+func throttleUpdate(updates map[string]interface{}) (throttle *models.FeedThrottle, ok bool, err error) {
+	raw, ok := updates["throttle"]
+	if !ok {
+		return nil, false, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(encoded, &throttle)
+	}
+	if err != nil {
+		return nil, true, errors.New("invalid throttle")
+	}
+	return throttle, true, nil
+}
+
+// aggregationUpdate decodes the aggregation options from a feed update; ok is
+// false when the update leaves them alone
+func aggregationUpdate(updates map[string]interface{}) (aggregation *models.FeedAggregation, ok bool, err error) {
+	raw, ok := updates["aggregation"]
+	if !ok {
+		return nil, false, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(encoded, &aggregation)
+	}
+	if err != nil {
+		return nil, true, errors.New("invalid aggregation")
+	}
+	return aggregation, true, nil
+}
+
+// anomalyDetectionUpdate decodes the anomaly detection options from a feed
+// update; ok is false when the update leaves them alone
+func anomalyDetectionUpdate(updates map[string]interface{}) (detection *models.FeedAnomalyDetection, ok bool, err error) {
+	raw, ok := updates["anomalyDetection"]
+	if !ok {
+		return nil, false, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(encoded, &detection)
+	}
+	if err != nil {
+		return nil, true, errors.New("invalid anomalyDetection")
+	}
+	return detection, true, nil
+}
+
+// changesUpstream reports whether a feed update touches how its data is received
+func changesUpstream(updates map[string]interface{}) bool {
+	for _, field := range upstreamFields {
+		if _, ok := updates[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOptionalBool parses an optional boolean query value; empty is nil
+func parseOptionalBool(raw string) (*bool, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
 // helper to convert []{key,value} to map
 func sliceKeyValues(items []map[string]string) []models.KeyValue {
 	out := []models.KeyValue{}
@@ -438,7 +1359,9 @@ type testFeedPayload struct {
 	QueryParams             []map[string]string `json:"queryParams"`
 	Headers                 []map[string]string `json:"headers"`
 	ConnectionMessage       string              `json:"connectionMessage"`
+	ConnectionMessages      []string            `json:"connectionMessages"`
 	ConnectionMessageFormat string              `json:"connectionMessageFormat"`
+	Seconds                 int                 `json:"seconds"` // how long to read for samples; 5 by default, at most 30
 }
 
 // createFeedPayload matches the frontend feed creation form structure
@@ -456,10 +1379,16 @@ type createFeedPayload struct {
 	ConnectionMessage       string              `json:"connectionMessage"`
 	ConnectionMessages      []string            `json:"connectionMessages"`
 	ConnectionMessageFormat string              `json:"connectionMessageFormat"`
+	SecretMessages          bool                `json:"secretMessages"`
 	EventName               string              `json:"eventName"`
 	DataFormat              string              `json:"dataFormat"`
+	ProtobufType            string              `json:"protobufType"`
+	ProtobufDescriptor      string              `json:"protobufDescriptor"`
 	ReconnectionDelay       int                 `json:"reconnectionDelay"`
 	ReconnectionAttempts    int                 `json:"reconnectionAttempts"`
+	ReassembleFragments     bool                `json:"reassembleFragments"`
+	FragmentMaxBytes        int                 `json:"fragmentMaxBytes"`
+	FragmentTimeoutMs       int                 `json:"fragmentTimeoutMs"`
 	HTTPConfig              *struct {
 		Method          string              `json:"method"`
 		PollingInterval int                 `json:"pollingInterval"`
@@ -469,14 +1398,47 @@ type createFeedPayload struct {
 		ResponseFormat  string              `json:"responseFormat"`
 		DataPath        string              `json:"dataPath"`
 	} `json:"httpConfig"`
-	Tags              []string `json:"tags"`
-	Website           string   `json:"website"`
-	Documentation     string   `json:"documentation"`
-	DefaultAIPrompt   string   `json:"defaultAIPrompt"`
-	AIAnalysisEnabled bool     `json:"aiAnalysisEnabled"`
+	MQTTConfig *struct {
+		Topic    string `json:"topic"`
+		QoS      byte   `json:"qos"`
+		ClientID string `json:"clientId"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"mqttConfig"`
+	AvroConfig *struct {
+		SchemaRegistryURL string `json:"schemaRegistryUrl"`
+		Schema            string `json:"schema"`
+		Username          string `json:"username"`
+		Password          string `json:"password"`
+	} `json:"avroConfig"`
+	KafkaConfig *struct {
+		Brokers     []string `json:"brokers"`
+		Topic       string   `json:"topic"`
+		GroupID     string   `json:"groupId"`
+		StartOffset string   `json:"startOffset"`
+		TLS         bool     `json:"tls"`
+		SASL        *struct {
+			Mechanism string `json:"mechanism"`
+			Username  string `json:"username"`
+			Password  string `json:"password"`
+		} `json:"sasl"`
+	} `json:"kafkaConfig"`
+	SimulatedConfig       *models.SimulatedConfig `json:"simulatedConfig"`
+	Tags                  []string                `json:"tags"`
+	Website               string                  `json:"website"`
+	Documentation         string                  `json:"documentation"`
+	DefaultAIPrompt       string                  `json:"defaultAIPrompt"`
+	AIAnalysisEnabled     bool                    `json:"aiAnalysisEnabled"`
+	HistoryRetentionHours int                     `json:"historyRetentionHours"`
+	Transform             *models.FeedTransform   `json:"transform"`
+	Throttle              *models.FeedThrottle    `json:"throttle"`
+	Aggregation           *models.FeedAggregation `json:"aggregation"`
 }
 
-// testFeed validates feed connectivity by attempting a WebSocket connection
+// testFeed checks a feed before it is saved. Websocket feeds are read for up
+// to payload.Seconds and the response carries up to five sample messages,
+// their detected format, the message rate and whether the connection
+// messages got a reply; Socket.IO feeds are only dialed.
 func (h *MarketplaceHandler) testFeed(c *gin.Context) {
 	var payload testFeedPayload
 	if err := c.ShouldBindJSON(&payload); err != nil || payload.URL == "" {
@@ -485,7 +1447,28 @@ func (h *MarketplaceHandler) testFeed(c *gin.Context) {
 	}
 
 	switch payload.ConnectionType {
-	case "websocket", "socketio", "", "protobuf":
+	case "websocket", "", "protobuf":
+		feed := models.WebSocketFeed{
+			URL:                payload.URL,
+			ConnectionType:     payload.ConnectionType,
+			QueryParams:        sliceKeyValues(payload.QueryParams),
+			Headers:            sliceKeyValues(payload.Headers),
+			ConnectionMessage:  payload.ConnectionMessage,
+			ConnectionMessages: filterMessages(payload.ConnectionMessages),
+		}
+		window := time.Duration(payload.Seconds) * time.Second
+		ctx, cancel := context.WithTimeout(c.Request.Context(), socket.MaxProbeWindow+15*time.Second)
+		defer cancel()
+		probe, err := socket.ProbeFeed(ctx, feed, window)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": struct {
+			Success bool `json:"success"`
+			*socket.FeedProbe
+		}{true, probe}})
+	case "socketio":
 		// Attempt a basic websocket dial to validate connectivity.
 		success, err := dialWebSocket(payload)
 		if err != nil {
@@ -6,53 +6,31 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
 )
 
-func setupMarketplaceHandler(t *testing.T) (*MarketplaceHandler, *services.MarketplaceService, primitive.ObjectID, func()) {
-	ctx := context.Background()
-
-	clientOpts := options.Client().ApplyURI("mongodb://localhost:27017")
-	client, err := mongo.Connect(ctx, clientOpts)
-	if err != nil {
-		t.Skip("MongoDB not available for testing:", err)
-		return nil, nil, primitive.NilObjectID, func() {}
-	}
-
-	dbName := "test_marketplace_" + primitive.NewObjectID().Hex()
-	db := client.Database(dbName)
-
-	marketplaceService := services.NewMarketplaceService(db)
+func setupMarketplaceHandler() (*MarketplaceHandler, *services.MarketplaceService, primitive.ObjectID) {
+	marketplaceService := services.NewMarketplaceServiceWithRepos(services.NewMemoryFeedRepo(), services.NewMemorySubscriptionRepo())
 	socketManager := socket.NewManager(nil, nil, marketplaceService, []string{"*"})
 	handler := NewMarketplaceHandler(marketplaceService, socketManager)
 
-	testUserID := primitive.NewObjectID()
-
-	cleanup := func() {
-		_ = db.Drop(ctx)
-		_ = client.Disconnect(ctx)
-	}
-
-	return handler, marketplaceService, testUserID, cleanup
+	return handler, marketplaceService, primitive.NewObjectID()
 }
 
 func TestMarketplaceHandler_ListFeeds(t *testing.T) {
-	handler, marketplaceService, _, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	handler, marketplaceService, _ := setupMarketplaceHandler()
 
 	router := setupTestRouter()
 	public := router.Group("/api/marketplace")
@@ -104,6 +82,26 @@ func TestMarketplaceHandler_ListFeeds(t *testing.T) {
 				assert.GreaterOrEqual(t, len(data), 1)
 			},
 		},
+		{
+			name:           "paginated by name",
+			queryParams:    "?sort=name&limit=1",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, resp map[string]interface{}) {
+				data := resp["data"].([]interface{})
+				require.Len(t, data, 1)
+				assert.Equal(t, "Public Feed 1", data[0].(map[string]interface{})["name"])
+				assert.Equal(t, float64(2), resp["total"])
+				assert.Equal(t, true, resp["hasMore"])
+			},
+		},
+		{
+			name:           "invalid sort",
+			queryParams:    "?sort=price",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, resp map[string]interface{}) {
+				assert.Equal(t, "invalid_sort", resp["code"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,11 +124,7 @@ func TestMarketplaceHandler_ListFeeds(t *testing.T) {
 }
 
 func TestMarketplaceHandler_GetFeed(t *testing.T) {
-	handler, marketplaceService, _, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	handler, marketplaceService, _ := setupMarketplaceHandler()
 
 	router := setupTestRouter()
 	public := router.Group("/api/marketplace")
@@ -193,11 +187,7 @@ func TestMarketplaceHandler_GetFeed(t *testing.T) {
 }
 
 func TestMarketplaceHandler_CreateFeed(t *testing.T) {
-	handler, _, testUserID, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	handler, _, testUserID := setupMarketplaceHandler()
 
 	router := setupTestRouter()
 	protected := router.Group("/api/marketplace")
@@ -230,14 +220,19 @@ func TestMarketplaceHandler_CreateFeed(t *testing.T) {
 			},
 		},
 		{
-			name: "minimal payload",
+			name: "missing name and url",
 			payload: map[string]interface{}{
 				"name": "",
 				"url":  "",
 			},
-			expectedStatus: http.StatusCreated,
+			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, resp map[string]interface{}) {
-				assert.True(t, resp["success"].(bool))
+				assert.False(t, resp["success"].(bool))
+				assert.Equal(t, "invalid_feed", resp["code"])
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"field": "name", "message": "name is required"},
+					map[string]interface{}{"field": "url", "message": "url is required"},
+				}, resp["errors"])
 			},
 		},
 	}
@@ -272,11 +267,7 @@ func TestMarketplaceHandler_CreateFeed(t *testing.T) {
 }
 
 func TestMarketplaceHandler_Subscribe(t *testing.T) {
-	handler, marketplaceService, testUserID, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	handler, marketplaceService, testUserID := setupMarketplaceHandler()
 
 	// Create a test feed
 	ctx := context.Background()
@@ -314,12 +305,130 @@ func TestMarketplaceHandler_Subscribe(t *testing.T) {
 	assert.GreaterOrEqual(t, len(subs), 1)
 }
 
-func TestMarketplaceHandler_Unsubscribe(t *testing.T) {
-	handler, marketplaceService, testUserID, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
+func TestBindSubscriptionTTL(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		expectedOK bool
+		expected   time.Duration
+	}{
+		{"empty body", "", true, 0},
+		{"ttl", `{"ttlSeconds": 3600}`, true, time.Hour},
+		{"negative ttl", `{"ttlSeconds": -1}`, false, 0},
+		{"invalid json", `{"ttlSeconds":`, false, 0},
 	}
-	defer cleanup()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/api/marketplace/subscribe/abc", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			ttl, ok := bindSubscriptionTTL(c)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expected, ttl)
+			if !ok {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		from, to     string
+		expectedFrom time.Time
+		expectedTo   time.Time
+		expectError  bool
+	}{
+		{name: "open range"},
+		{name: "both bounds", from: "2024-01-01T00:00:00Z", to: "2024-01-02T00:00:00Z", expectedFrom: from, expectedTo: to},
+		{name: "from only", from: "2024-01-01T00:00:00Z", expectedFrom: from},
+		{name: "invalid from", from: "yesterday", expectError: true},
+		{name: "invalid to", to: "1704067200", expectError: true},
+		{name: "to before from", from: "2024-01-02T00:00:00Z", to: "2024-01-01T00:00:00Z", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrom, gotTo, err := parseTimeRange(tt.from, tt.to)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expectedFrom.Equal(gotFrom))
+			assert.True(t, tt.expectedTo.Equal(gotTo))
+		})
+	}
+}
+
+func TestValidateSubscriptionFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        map[string]interface{}
+		expectError bool
+	}{
+		{"no filters", map[string]interface{}{"customPrompt": "hi"}, false},
+		{"nested filters", map[string]interface{}{"settings": map[string]interface{}{"filters": []interface{}{`data.symbol == "BTCUSDT"`}}}, false},
+		{"dotted key", map[string]interface{}{"settings.filters": []interface{}{"data.price > 100"}}, false},
+		{"cleared filters", map[string]interface{}{"settings.filters": nil}, false},
+		{"invalid expression", map[string]interface{}{"settings.filters": []interface{}{"data.price >"}}, true},
+		{"not a list", map[string]interface{}{"settings": map[string]interface{}{"filters": "data.price > 1"}}, true},
+		{"non-string entry", map[string]interface{}{"settings.filters": []interface{}{1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSubscriptionFilters(tt.body)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateSubscriptionPrompts(t *testing.T) {
+	tooMany := make([]interface{}, maxSavedPrompts+1)
+	for i := range tooMany {
+		tooMany[i] = "prompt"
+	}
+	tests := []struct {
+		name        string
+		body        map[string]interface{}
+		expectError bool
+	}{
+		{"no prompts", map[string]interface{}{"customPrompt": "hi"}, false},
+		{"nested prompts", map[string]interface{}{"settings": map[string]interface{}{"prompts": []interface{}{"Summarize the last minute"}}}, false},
+		{"dotted key", map[string]interface{}{"settings.prompts": []interface{}{"Any anomalies?", "Line one\nline two"}}, false},
+		{"cleared prompts", map[string]interface{}{"settings.prompts": nil}, false},
+		{"empty prompt", map[string]interface{}{"settings.prompts": []interface{}{"  "}}, true},
+		{"too long", map[string]interface{}{"settings.prompts": []interface{}{strings.Repeat("a", maxSavedPromptLength+1)}}, true},
+		{"too many", map[string]interface{}{"settings.prompts": tooMany}, true},
+		{"non-string entry", map[string]interface{}{"settings.prompts": []interface{}{1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSubscriptionPrompts(tt.body)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMarketplaceHandler_Unsubscribe(t *testing.T) {
+	handler, marketplaceService, testUserID := setupMarketplaceHandler()
 
 	// Create a feed and subscribe
 	ctx := context.Background()
@@ -356,11 +465,7 @@ func TestMarketplaceHandler_Unsubscribe(t *testing.T) {
 }
 
 func TestMarketplaceHandler_DeleteFeed(t *testing.T) {
-	handler, marketplaceService, testUserID, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	handler, marketplaceService, testUserID := setupMarketplaceHandler()
 
 	// Create a feed owned by testUser
 	ctx := context.Background()
@@ -428,11 +533,7 @@ func TestMarketplaceHandler_DeleteFeed(t *testing.T) {
 }
 
 func TestMarketplaceHandler_SearchFeeds(t *testing.T) {
-	handler, marketplaceService, _, cleanup := setupMarketplaceHandler(t)
-	if handler == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	handler, marketplaceService, _ := setupMarketplaceHandler()
 
 	router := setupTestRouter()
 	public := router.Group("/api/marketplace")
@@ -490,3 +591,267 @@ func TestMarketplaceHandler_SearchFeeds(t *testing.T) {
 		})
 	}
 }
+
+func TestChangesUpstream(t *testing.T) {
+	assert.False(t, changesUpstream(map[string]interface{}{"name": "BTC", "description": "ticker"}))
+	assert.True(t, changesUpstream(map[string]interface{}{"name": "BTC", "url": "wss://example.com"}))
+	assert.True(t, changesUpstream(map[string]interface{}{"transform": map[string]interface{}{"path": "$.data"}}))
+}
+
+func TestDataFormatSettings(t *testing.T) {
+	feed := models.WebSocketFeed{
+		ConnectionType:     "protobuf",
+		ProtobufType:       "market.Tick",
+		ProtobufDescriptor: "old",
+		AvroConfig:         &models.AvroConfig{SchemaRegistryURL: "http://registry", Schema: "{}"},
+	}
+
+	updated, err := dataFormatSettings(feed, map[string]interface{}{"protobufDescriptor": "new", "name": "ticks"})
+	require.NoError(t, err)
+	assert.Equal(t, "protobuf", updated.ConnectionType)
+	assert.Equal(t, "market.Tick", updated.ProtobufType)
+	assert.Equal(t, "new", updated.ProtobufDescriptor)
+	assert.Equal(t, "old", feed.ProtobufDescriptor)
+
+	updated, err = dataFormatSettings(feed, map[string]interface{}{
+		"connectionType": "kafka",
+		"dataFormat":     "avro",
+		"avroConfig":     map[string]interface{}{"schema": `"string"`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "kafka", updated.ConnectionType)
+	assert.Equal(t, "avro", updated.DataFormat)
+	assert.Equal(t, &models.AvroConfig{Schema: `"string"`}, updated.AvroConfig)
+
+	_, err = dataFormatSettings(feed, map[string]interface{}{"avroConfig": "registry"})
+	assert.Error(t, err)
+}
+
+func TestConnectionSettings(t *testing.T) {
+	feed := models.WebSocketFeed{
+		Name:               "ticks",
+		URL:                "wss://example.com",
+		ConnectionMessages: []string{`{"op":"sub"}`},
+		HTTPConfig:         &models.HTTPPollingConfig{Method: "GET", PollingInterval: 1000},
+	}
+
+	updated, errs := connectionSettings(feed, map[string]interface{}{
+		"url":                "https://example.com/poll",
+		"connectionMessages": []interface{}{"a", "b"},
+		"httpConfig":         map[string]interface{}{"pollingInterval": 50},
+		"description":        "ignored",
+	})
+	assert.Empty(t, errs)
+	assert.Equal(t, "ticks", updated.Name)
+	assert.Equal(t, "https://example.com/poll", updated.URL)
+	assert.Equal(t, []string{"a", "b"}, updated.ConnectionMessages)
+	assert.Equal(t, &models.HTTPPollingConfig{PollingInterval: 50}, updated.HTTPConfig)
+	assert.Equal(t, 1000, feed.HTTPConfig.PollingInterval)
+
+	_, errs = connectionSettings(feed, map[string]interface{}{"url": 5, "httpConfig": "fast"})
+	assert.ElementsMatch(t, []socket.FieldError{
+		{Field: "url", Message: "url must be a string"},
+		{Field: "httpConfig", Message: "invalid httpConfig"},
+	}, errs)
+}
+
+func TestUpdatedFieldErrors(t *testing.T) {
+	errs := []socket.FieldError{
+		{Field: "url", Message: "url scheme must be ws, wss for this connection type"},
+		{Field: "eventName", Message: "eventName is required for socketio feeds"},
+		{Field: "connectionMessages.1", Message: "connection message 2 is not valid JSON"},
+	}
+
+	assert.Empty(t, updatedFieldErrors(errs, map[string]interface{}{"description": "new"}),
+		"problems the feed already had do not block unrelated edits")
+	assert.Equal(t, errs[:2], updatedFieldErrors(errs, map[string]interface{}{"connectionType": "socketio"}))
+	assert.Equal(t, errs[2:], updatedFieldErrors(errs, map[string]interface{}{"connectionMessages": []interface{}{"{}", "{"}}))
+}
+
+func TestThrottleUpdate(t *testing.T) {
+	throttle, ok, err := throttleUpdate(map[string]interface{}{"name": "ticks"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, throttle)
+
+	throttle, ok, err = throttleUpdate(map[string]interface{}{"throttle": map[string]interface{}{"dedupeKey": "$.id", "sampleEvery": 5}})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, &models.FeedThrottle{DedupeKey: "$.id", SampleEvery: 5}, throttle)
+
+	throttle, ok, err = throttleUpdate(map[string]interface{}{"throttle": nil})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, throttle)
+
+	_, _, err = throttleUpdate(map[string]interface{}{"throttle": map[string]interface{}{"minIntervalMs": "fast"}})
+	assert.Error(t, err)
+}
+
+func TestAggregationUpdate(t *testing.T) {
+	aggregation, ok, err := aggregationUpdate(map[string]interface{}{"name": "ticks"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, aggregation)
+
+	aggregation, ok, err = aggregationUpdate(map[string]interface{}{"aggregation": map[string]interface{}{"fields": []interface{}{"$.price"}, "windows": []interface{}{"1m"}}})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, &models.FeedAggregation{Fields: []string{"$.price"}, Windows: []string{"1m"}}, aggregation)
+
+	aggregation, ok, err = aggregationUpdate(map[string]interface{}{"aggregation": nil})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, aggregation)
+
+	_, _, err = aggregationUpdate(map[string]interface{}{"aggregation": map[string]interface{}{"fields": "price"}})
+	assert.Error(t, err)
+}
+
+func TestAlertRulePayload_Apply(t *testing.T) {
+	rule := models.AlertRule{Name: "high", Condition: "price > 1", Enabled: true, WebhookURL: "https://example.com/a"}
+	name, condition, enabled, webhook := " spike ", " price > 70000 ", false, ""
+	alertRulePayload{Name: &name, Condition: &condition, Enabled: &enabled, WebhookURL: &webhook}.apply(&rule)
+	assert.Equal(t, models.AlertRule{Name: "spike", Condition: "price > 70000"}, rule)
+
+	// Fields left out keep their value
+	alertRulePayload{}.apply(&rule)
+	assert.Equal(t, "price > 70000", rule.Condition)
+}
+
+func TestWebhookPayload_Apply(t *testing.T) {
+	hook := models.Webhook{URL: "https://example.com/a", Events: []string{"feed-data"}, Enabled: true}
+	url, events, enabled := " https://example.com/b ", []string{"feed-alert"}, false
+	webhookPayload{URL: &url, Events: &events, Enabled: &enabled}.apply(&hook)
+	assert.Equal(t, models.Webhook{URL: "https://example.com/b", Events: []string{"feed-alert"}}, hook)
+
+	// Fields left out keep their value
+	webhookPayload{}.apply(&hook)
+	assert.Equal(t, "https://example.com/b", hook.URL)
+}
+
+func TestMarketplaceIngest(t *testing.T) {
+	marketplaceService := services.NewMarketplaceServiceWithRepos(services.NewMemoryFeedRepo(), services.NewMemorySubscriptionRepo())
+	// The socket manager has no marketplace, so streams skip access checks
+	sockets := socket.NewManager(nil, nil, nil, nil)
+	handler := NewMarketplaceHandler(marketplaceService, sockets)
+	router := setupTestRouter()
+	handler.RegisterIngest(router.Group("/ingest"))
+
+	payload, err := json.Marshal(map[string]interface{}{"name": "Pushed", "connectionType": "push", "eventName": "tick"})
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, "/api/marketplace/feeds", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("userId", primitive.NewObjectID())
+	handler.createFeed(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data   models.WebSocketFeed `json:"data"`
+		Ingest struct {
+			Path   string `json:"path"`
+			Secret string `json:"secret"`
+		} `json:"ingest"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Ingest.Path)
+	require.NotEmpty(t, created.Ingest.Secret)
+
+	stream, err := sockets.OpenFeedStream(context.Background(), socket.Subscriber{}, created.Data.ID.Hex(), nil, 0)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	push := func(body, secret string, sign func(*http.Request)) *httptest.ResponseRecorder {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, created.Ingest.Path, strings.NewReader(body))
+		req.Header.Set(services.WebhookTimestampHeader, ts)
+		req.Header.Set(services.WebhookSignatureHeader, services.SignWebhookPayload(secret, ts, []byte(body)))
+		if sign != nil {
+			sign(req)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w = push(`{"price":12.5}`, created.Ingest.Secret, nil)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	event, err := stream.Next()
+	require.NoError(t, err)
+	require.NotNil(t, event.Data)
+	assert.Equal(t, "tick", event.Data.EventName, "the feed's event name is the default")
+	assert.Equal(t, map[string]interface{}{"price": 12.5}, event.Data.Data)
+
+	w = push(`{"price":13}`, created.Ingest.Secret, func(r *http.Request) { r.Header.Set(services.WebhookEventHeader, "quote") })
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	event, err = stream.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "quote", event.Data.EventName)
+
+	w = push(`{"price":14}`, "wrong", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_signature")
+	w = push(`not json`, created.Ingest.Secret, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, err = marketplaceService.SetFeedPaused(context.Background(), created.Data.ID, true)
+	require.NoError(t, err)
+	w = push(`{"price":15}`, created.Ingest.Secret, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/ingest/"+created.Data.ID.Hex()+".unknown", strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// orgRoles gives users fixed roles in one organization
+type orgRoles struct {
+	orgID string
+	roles map[string]string
+}
+
+func (o orgRoles) Role(_ context.Context, orgID, userID string) (string, error) {
+	if orgID != o.orgID {
+		return "", nil
+	}
+	return o.roles[userID], nil
+}
+
+func TestMarketplaceHandler_IssueIngestCredentialsForOrgFeed(t *testing.T) {
+	ctx := context.Background()
+	feeds := services.NewMemoryFeedRepo()
+	marketplaceService := services.NewMarketplaceServiceWithRepos(feeds, services.NewMemorySubscriptionRepo())
+	handler := NewMarketplaceHandler(marketplaceService, socket.NewManager(nil, nil, marketplaceService, nil))
+	orgID := primitive.NewObjectID().Hex()
+	admin, member := primitive.NewObjectID(), primitive.NewObjectID()
+	marketplaceService.SetOrgRoles(orgRoles{orgID: orgID, roles: map[string]string{
+		admin.Hex():  models.OrgRoleAdmin,
+		member.Hex(): models.OrgRoleMember,
+	}})
+	feed := models.WebSocketFeed{Name: "Team Push Feed", OrgID: orgID, ConnectionType: services.PushConnectionType, IsActive: true}
+	require.NoError(t, feeds.Insert(ctx, &feed))
+
+	issue := func(userID primitive.ObjectID, feedID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/marketplace/feeds/"+feedID+"/ingest-credentials", nil)
+		c.Params = gin.Params{{Key: "id", Value: feedID}}
+		c.Set("userId", userID)
+		handler.issueIngestCredentials(c)
+		return w
+	}
+
+	w := issue(admin, feed.ID.Hex())
+	require.Equal(t, http.StatusOK, w.Code, "organization admins manage the org's push feeds")
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Data["secret"])
+
+	assert.Equal(t, http.StatusForbidden, issue(member, feed.ID.Hex()).Code, "plain members do not")
+	assert.Equal(t, http.StatusNotFound, issue(admin, primitive.NewObjectID().Hex()).Code)
+}
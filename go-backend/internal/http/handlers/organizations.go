@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// OrganizationHandler handles HTTP requests for organizations and their members
+type OrganizationHandler struct {
+	Service     *services.OrganizationService
+	Marketplace *services.MarketplaceService
+	// Audit records organization and membership changes; nil records nothing
+	Audit *services.AuditService
+}
+
+// NewOrganizationHandler creates a new organization handler instance
+func NewOrganizationHandler(svc *services.OrganizationService, marketplace *services.MarketplaceService) *OrganizationHandler {
+	return &OrganizationHandler{Service: svc, Marketplace: marketplace}
+}
+
+// RegisterRoutes attaches organization endpoints; the group must require
+// authentication
+func (h *OrganizationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("", h.create)
+	r.GET("", h.list)
+	r.GET("/:id", h.get)
+	r.GET("/:id/members", h.members)
+	r.POST("/:id/members", h.addMember)
+	r.PUT("/:id/members/:memberId", h.updateMember)
+	r.DELETE("/:id/members/:memberId", h.removeMember)
+	r.GET("/:id/feeds", h.feeds)
+}
+
+// create creates an organization owned by the caller. Body: {"name"}.
+func (h *OrganizationHandler) create(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	org, err := h.Service.Create(ctx, body.Name, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditOrgCreate, services.AuditTargetOrg, org.ID.Hex()), nil, org))
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": org})
+}
+
+// list returns the caller's organizations with their role in each
+func (h *OrganizationHandler) list(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	orgs, err := h.Service.ListForUser(ctx, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": orgs, "count": len(orgs)})
+}
+
+// get returns one of the caller's organizations
+func (h *OrganizationHandler) get(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	org, err := h.Service.Get(ctx, c.Param("id"), userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": org})
+}
+
+// members lists an organization's members to any of its members
+func (h *OrganizationHandler) members(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	org, err := h.Service.Get(ctx, c.Param("id"), userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	members, err := h.Service.Members(ctx, org.ID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": members, "count": len(members)})
+}
+
+// addMember gives an email a role in the organization. Body: {"email",
+// "role"}, where role defaults to member.
+func (h *OrganizationHandler) addMember(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "email is required"})
+		return
+	}
+	if body.Role == "" {
+		body.Role = models.OrgRoleMember
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	member, err := h.Service.AddMember(ctx, c.Param("id"), userID.Hex(), body.Email, body.Role)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditOrgMemberAdd, services.AuditTargetMember, member.ID.Hex()), nil, member))
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": member})
+}
+
+// updateMember changes a member's role. Body: {"role"}.
+func (h *OrganizationHandler) updateMember(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	memberID, err := primitive.ObjectIDFromHex(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid member id"})
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "role is required"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	before, err := h.Service.UpdateMemberRole(ctx, c.Param("id"), userID.Hex(), memberID, body.Role)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	after := *before
+	after.Role = body.Role
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditOrgMemberUpdate, services.AuditTargetMember, memberID.Hex()), before, after))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": after})
+}
+
+// removeMember removes a member from the organization; members may remove
+// themselves to leave it
+func (h *OrganizationHandler) removeMember(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	memberID, err := primitive.ObjectIDFromHex(c.Param("memberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid member id"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	member, err := h.Service.RemoveMember(ctx, c.Param("id"), userID.Hex(), memberID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditOrgMemberRemove, services.AuditTargetMember, memberID.Hex()), member, nil))
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Member removed"})
+}
+
+// feeds lists the feeds the organization owns to any of its members
+func (h *OrganizationHandler) feeds(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	org, err := h.Service.Get(ctx, c.Param("id"), userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	feeds, err := h.Marketplace.GetOrgFeeds(ctx, org.ID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": feeds, "count": len(feeds)})
+}
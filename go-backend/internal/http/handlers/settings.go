@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 )
@@ -13,6 +14,10 @@ import (
 // SettingsHandler handles HTTP requests for application settings and categories
 type SettingsHandler struct {
 	Service *services.SettingsService
+	// LLMKeys stores users' own LLM provider keys; nil disables them
+	LLMKeys *services.LLMKeyService
+	// Audit records category and key changes; nil records nothing
+	Audit *services.AuditService
 }
 
 // NewSettingsHandler creates a new settings handler instance
@@ -33,11 +38,19 @@ func (h *SettingsHandler) RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("/user/categories/check/:key", h.checkCategoryKey)
 }
 
-// categories retrieves all available feed categories
+// RegisterCurator attaches category editing endpoints; the group must
+// require the curator or admin role. Categories are disabled rather than
+// deleted so feeds never point at a missing category.
+func (h *SettingsHandler) RegisterCurator(r *gin.RouterGroup) {
+	r.POST("/categories", h.createCategory)
+	r.PUT("/categories/:key", h.updateCategory)
+}
+
+// categories retrieves the enabled feed categories, or all of them with ?all=true
 func (h *SettingsHandler) categories(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
-	cats, err := h.Service.ListCategories(ctx)
+	cats, err := h.Service.ListCategories(ctx, c.Query("all") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
@@ -62,6 +75,128 @@ func (h *SettingsHandler) category(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": cat})
 }
 
+// createCategory adds a global category
+func (h *SettingsHandler) createCategory(c *gin.Context) {
+	var body struct {
+		Key   string `json:"key"`
+		Label string `json:"label"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	cat, err := h.Service.CreateCategory(ctx, body.Key, body.Label)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditCategoryCreate, services.AuditTargetCategory, cat.Key), nil, cat))
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": cat})
+}
+
+// updateCategory renames a category and/or disables or re-enables it
+func (h *SettingsHandler) updateCategory(c *gin.Context) {
+	var body struct {
+		Label    *string `json:"label"`
+		Disabled *bool   `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	before, err := h.Service.GetCategory(ctx, c.Param("key"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	cat, err := h.Service.UpdateCategory(ctx, c.Param("key"), body.Label, body.Disabled)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditCategoryUpdate, services.AuditTargetCategory, cat.Key), before, cat))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": cat})
+}
+
+// RegisterUser attaches endpoints for the signed-in user's own settings; the
+// group must require authentication
+func (h *SettingsHandler) RegisterUser(r *gin.RouterGroup) {
+	r.GET("/llm-keys", h.listLLMKeys)
+	r.PUT("/llm-keys/:provider", h.setLLMKey)
+	r.DELETE("/llm-keys/:provider", h.deleteLLMKey)
+}
+
+// llmKeysEnabled answers 503 when users cannot store their own keys
+func (h *SettingsHandler) llmKeysEnabled(c *gin.Context) bool {
+	if h.LLMKeys == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "own LLM keys are disabled"})
+		return false
+	}
+	return true
+}
+
+// listLLMKeys returns the providers the user stored a key for, with a hint of each key
+func (h *SettingsHandler) listLLMKeys(c *gin.Context) {
+	if !h.llmKeysEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	keys, err := h.LLMKeys.ListKeys(ctx, userID.Hex())
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": keys, "count": len(keys)})
+}
+
+// setLLMKey stores or replaces the user's key for a provider; their queries
+// on it no longer count against the server token quota
+func (h *SettingsHandler) setLLMKey(c *gin.Context) {
+	if !h.llmKeysEnabled(c) {
+		return
+	}
+	var body struct {
+		APIKey string `json:"apiKey"`
+		Model  string `json:"model"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	key, err := h.LLMKeys.SetKey(ctx, userID.Hex(), c.Param("provider"), body.APIKey, body.Model)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditLLMKeySet, services.AuditTargetLLMKey, c.Param("provider")), nil, key))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": key})
+}
+
+// deleteLLMKey removes the user's key for a provider
+func (h *SettingsHandler) deleteLLMKey(c *gin.Context) {
+	if !h.llmKeysEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.LLMKeys.DeleteKey(ctx, userID.Hex(), c.Param("provider")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditLLMKeyDelete, services.AuditTargetLLMKey, c.Param("provider")))
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Key deleted"})
+}
+
 // allSettings returns all settings (currently just categories for backend parity)
 func (h *SettingsHandler) allSettings(c *gin.Context) {
 	// For parity with the TS backend, this simply returns categories for now.
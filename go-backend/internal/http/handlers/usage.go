@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+)
+
+// UsageHandler reports the caller's metered feed-data egress
+type UsageHandler struct {
+	Sockets *socket.Manager
+}
+
+// NewUsageHandler creates a new usage handler instance
+func NewUsageHandler(sockets *socket.Manager) *UsageHandler {
+	return &UsageHandler{Sockets: sockets}
+}
+
+// RegisterRoutes attaches usage endpoints; the group must require authentication
+func (h *UsageHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/bandwidth", h.bandwidth)
+}
+
+// bandwidth returns today's feed-data bytes and messages per subscribed feed
+// against the bandwidth quotas, as metered by this instance
+func (h *UsageHandler) bandwidth(c *gin.Context) {
+	if h.Sockets == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "websocket server is disabled"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": h.Sockets.BandwidthUsage(userID.Hex())})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// webAuthnRegisterBegin returns the options the browser passes to
+// navigator.credentials.create to register a security key
+func (h *AuthHandler) webAuthnRegisterBegin(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	options, err := h.Service.BeginWebAuthnRegistration(ctx, userID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": options})
+}
+
+// webAuthnRegisterFinish stores the security key created with the options
+// from webAuthnRegisterBegin
+func (h *AuthHandler) webAuthnRegisterFinish(c *gin.Context) {
+	var body struct {
+		Name       string                       `json:"name"`
+		Credential services.WebAuthnAttestation `json:"credential"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	cred, codes, err := h.Service.FinishWebAuthnRegistration(ctx, userID, body.Name, body.Credential)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditChange(auditEvent(c, services.AuditWebAuthnRegister, services.AuditTargetWebAuthn, cred.ID.Hex()), nil, map[string]interface{}{"name": cred.Name}))
+	resp := gin.H{"success": true, "data": cred}
+	if codes != nil {
+		resp["backupCodes"] = codes
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// webAuthnCredentials lists the user's security keys
+func (h *AuthHandler) webAuthnCredentials(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	creds, err := h.Service.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": creds})
+}
+
+// deleteWebAuthnCredential removes one of the user's security keys
+func (h *AuthHandler) deleteWebAuthnCredential(c *gin.Context) {
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Service.DeleteWebAuthnCredential(ctx, userID, c.Param("id")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, h.Audit, auditEvent(c, services.AuditWebAuthnRemove, services.AuditTargetWebAuthn, c.Param("id")))
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// twoFactorChallenge answers a sign-in, password reset or confirmation that
+// still needs a second factor
+func (h *AuthHandler) twoFactorChallenge(c *gin.Context, user models.User, err error, purpose string) {
+	status, resp := h.twoFactorResponse(c, user, err, purpose)
+	c.JSON(status, resp)
+}
+
+// twoFactorResponse lists the factors the user has and, when one is a
+// security key, the options for navigator.credentials.get with a challenge
+// issued for purpose
+func (h *AuthHandler) twoFactorResponse(c *gin.Context, user models.User, err error, purpose string) (int, gin.H) {
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	status, code := errorStatus(err, http.StatusUnauthorized)
+	methods := h.Service.TwoFactorMethods(ctx, user)
+	resp := gin.H{"success": false, "message": err.Error(), "code": code, "requiresTwoFactor": len(methods) > 0, "twoFactorMethods": methods}
+	for _, method := range methods {
+		if method != services.TwoFactorWebAuthn {
+			continue
+		}
+		if options, err := h.Service.BeginWebAuthnAssertion(ctx, user, purpose); err == nil {
+			resp["webauthn"] = options
+		}
+	}
+	return status, resp
+}
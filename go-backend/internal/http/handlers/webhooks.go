@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// maxWebhookDeliveriesLimit caps the delivery log page size
+const maxWebhookDeliveriesLimit = 200
+
+// webhookPayload is the body of webhook create and update requests; fields
+// left out keep their current value
+type webhookPayload struct {
+	URL     *string   `json:"url"`
+	Events  *[]string `json:"events"`
+	Enabled *bool     `json:"enabled"`
+}
+
+// apply copies the fields present in the payload onto hook
+func (p webhookPayload) apply(hook *models.Webhook) {
+	if p.URL != nil {
+		hook.URL = strings.TrimSpace(*p.URL)
+	}
+	if p.Events != nil {
+		hook.Events = *p.Events
+	}
+	if p.Enabled != nil {
+		hook.Enabled = *p.Enabled
+	}
+}
+
+// webhooksEnabled answers 503 when webhooks are not available
+func (h *MarketplaceHandler) webhooksEnabled(c *gin.Context) bool {
+	if h.Webhooks == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "webhooks are disabled"})
+		return false
+	}
+	return true
+}
+
+// webhookFeed checks the caller can access the feed in the :id parameter,
+// answering the request and returning false when they cannot
+func (h *MarketplaceHandler) webhookFeed(ctx context.Context, c *gin.Context, userID primitive.ObjectID) bool {
+	feed, err := h.Service.GetFeedByID(ctx, c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return false
+	}
+	return h.canReadFeed(ctx, c, feed, userID.Hex())
+}
+
+// listWebhooks returns the user's webhooks for a feed
+func (h *MarketplaceHandler) listWebhooks(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	hooks, err := h.Webhooks.ListWebhooks(ctx, userID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": hooks, "count": len(hooks)})
+}
+
+// createWebhook registers an HTTPS endpoint for events of a feed the user can
+// access. The signing secret is only returned here.
+func (h *MarketplaceHandler) createWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	var body webhookPayload
+	if err := c.ShouldBindJSON(&body); err != nil || body.URL == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "url is required"})
+		return
+	}
+	hook := models.Webhook{UserID: userID.Hex(), FeedID: c.Param("id")}
+	body.apply(&hook)
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if !h.webhookFeed(ctx, c, userID) {
+		return
+	}
+	secret, created, err := h.Webhooks.CreateWebhook(ctx, hook)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Store this secret now; it is used to sign deliveries and is not shown again",
+		"data":    gin.H{"webhook": created, "secret": secret},
+	})
+}
+
+// updateWebhook edits one of the user's webhooks for a feed
+func (h *MarketplaceHandler) updateWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	webhookID, err := primitive.ObjectIDFromHex(c.Param("webhookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid webhook id"})
+		return
+	}
+	var body webhookPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid payload"})
+		return
+	}
+
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	hook, err := h.Webhooks.GetWebhook(ctx, userID.Hex(), c.Param("id"), webhookID)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	body.apply(hook)
+	updated, err := h.Webhooks.UpdateWebhook(ctx, *hook)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
+}
+
+// deleteWebhook removes one of the user's webhooks for a feed
+func (h *MarketplaceHandler) deleteWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	webhookID, err := primitive.ObjectIDFromHex(c.Param("webhookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid webhook id"})
+		return
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	if err := h.Webhooks.DeleteWebhook(ctx, userID.Hex(), c.Param("id"), webhookID); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Webhook deleted"})
+}
+
+// webhookDeliveries returns the newest entries of a webhook's delivery log
+func (h *MarketplaceHandler) webhookDeliveries(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	userID := c.MustGet("userId").(primitive.ObjectID)
+	webhookID, err := primitive.ObjectIDFromHex(c.Param("webhookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "invalid webhook id"})
+		return
+	}
+	limit := parseLimit(c.Query("limit"), 50)
+	if limit > maxWebhookDeliveriesLimit {
+		limit = maxWebhookDeliveriesLimit
+	}
+	ctx, cancel := contextWithTimeout(c)
+	defer cancel()
+	deliveries, err := h.Webhooks.ListDeliveries(ctx, userID.Hex(), c.Param("id"), webhookID, int64(limit))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": deliveries, "count": len(deliveries)})
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+)
+
+func TestRequestID_AccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logging.New(&out, "info", "json"))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	var seen string
+	router := gin.New()
+	router.Use(RequestID(), AccessLog())
+	router.GET("/api/ping", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusTeapot)
+	})
+
+	get := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+		if requestID != "" {
+			req.Header.Set(logging.RequestIDHeader, requestID)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("client-id-1")
+	assert.Equal(t, "client-id-1", rec.Header().Get(logging.RequestIDHeader))
+	assert.Equal(t, "client-id-1", seen)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "client-id-1", record["request_id"])
+	assert.Equal(t, "/api/ping", record["path"])
+	assert.Equal(t, float64(http.StatusTeapot), record["status"])
+
+	// Unusable IDs are replaced
+	rec = get("not a valid id")
+	generated := rec.Header().Get(logging.RequestIDHeader)
+	assert.NotEqual(t, "not a valid id", generated)
+	assert.True(t, logging.ValidRequestID(generated))
+	assert.Equal(t, generated, seen)
+}
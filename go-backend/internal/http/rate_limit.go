@@ -0,0 +1,65 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
+)
+
+// IPRateLimit limits /api requests per client IP. Health checks and metrics
+// scrapes are not limited.
+func IPRateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+		enforceRateLimit(c, limiter, "ip", c.ClientIP())
+	}
+}
+
+// UserRateLimit limits requests per authenticated user; it must run after AuthMiddleware.
+// scope labels the limit in metrics, e.g. "user" or "llm".
+func UserRateLimit(limiter *ratelimit.Limiter, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userId")
+		if !ok {
+			c.Next()
+			return
+		}
+		oid, _ := userID.(primitive.ObjectID)
+		enforceRateLimit(c, limiter, scope, oid.Hex())
+	}
+}
+
+// IngestRateLimit limits pushes per push feed ingestion token
+func IngestRateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enforceRateLimit(c, limiter, "ingest", c.Param("feedToken"))
+	}
+}
+
+// enforceRateLimit aborts with 429 and a Retry-After header when key is over its limit
+func enforceRateLimit(c *gin.Context, limiter *ratelimit.Limiter, scope, key string) {
+	ok, wait := limiter.Allow(key)
+	if ok {
+		c.Next()
+		return
+	}
+	metrics.RateLimited.WithLabelValues(scope).Inc()
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"success":      false,
+		"message":      "rate limit exceeded",
+		"code":         "rate_limited",
+		"retryAfterMs": wait.Round(time.Millisecond).Milliseconds(),
+	})
+}
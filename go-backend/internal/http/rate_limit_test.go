@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
+)
+
+func TestIPRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IPRateLimit(ratelimit.New(60, 2)))
+	router.GET("/api/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, get("/api/ping", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, get("/api/ping", "10.0.0.1").Code)
+	rec := get("/api/ping", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"code":"rate_limited"`)
+
+	assert.Equal(t, http.StatusOK, get("/api/ping", "10.0.0.2").Code, "other IPs are unaffected")
+	assert.Equal(t, http.StatusOK, get("/health", "10.0.0.1").Code, "non-API routes are not limited")
+}
+
+func TestUserRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if id, err := primitive.ObjectIDFromHex(c.GetHeader("X-User")); err == nil {
+			c.Set("userId", id)
+		}
+	}, UserRateLimit(ratelimit.New(60, 1), "user"))
+	router.GET("/api/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get(alice.Hex()))
+	assert.Equal(t, http.StatusTooManyRequests, get(alice.Hex()))
+	assert.Equal(t, http.StatusOK, get(bob.Hex()))
+	assert.Equal(t, http.StatusOK, get(""), "unauthenticated requests are left to the IP limit")
+	assert.Equal(t, http.StatusOK, get(""))
+}
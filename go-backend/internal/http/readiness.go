@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/http/handlers"
+)
+
+// probePaths are hit by health checks and scrapers; they are logged at debug
+// level and not traced
+var probePaths = map[string]bool{"/health": true, "/healthz": true, "/readyz": true}
+
+func isProbePath(path string) bool {
+	return probePaths[path] || strings.HasPrefix(path, "/metrics")
+}
+
+// readinessChecks are the dependencies /readyz reports: MongoDB, the LLM
+// providers (required only with READY_REQUIRE_LLM) and the upstream feeds,
+// which never fail readiness as restarting this instance won't fix them
+func readinessChecks(deps RouterDeps) []handlers.ReadinessCheck {
+	var checks []handlers.ReadinessCheck
+	if deps.DB != nil {
+		checks = append(checks, handlers.ReadinessCheck{
+			Name:     "mongodb",
+			Required: true,
+			Check: func(ctx context.Context) (string, error) {
+				return "", deps.DB.Client().Ping(ctx, readpref.Primary())
+			},
+		})
+	}
+	if deps.LLM != nil {
+		checks = append(checks, handlers.ReadinessCheck{
+			Name:     "llm",
+			Required: deps.Config.ReadyRequireLLM,
+			Check: func(context.Context) (string, error) {
+				providers := deps.LLM.GetAvailableProviders()
+				if len(providers) == 0 {
+					return "", errors.New("no LLM provider configured")
+				}
+				return strings.Join(providers, ", "), nil
+			},
+		})
+	}
+	if deps.Sockets != nil {
+		checks = append(checks, handlers.ReadinessCheck{
+			Name: "feeds",
+			Check: func(context.Context) (string, error) {
+				counts := deps.Sockets.FeedStatusCounts()
+				detail := fmt.Sprintf("%d connected, %d erroring, %d disconnected", counts["connected"], counts["erroring"], counts["disconnected"])
+				if counts["erroring"] > 0 {
+					return detail, fmt.Errorf("%d upstream feeds erroring", counts["erroring"])
+				}
+				return detail, nil
+			},
+		})
+	}
+	return checks
+}
@@ -6,70 +6,160 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/turboline-ai/turbostream/go-backend/internal/config"
 	"github.com/turboline-ai/turbostream/go-backend/internal/http/handlers"
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
 )
 
 type RouterDeps struct {
-	Config      config.Config
-	AuthService *services.AuthService
-	Marketplace *services.MarketplaceService
-	Settings    *services.SettingsService
-	LLM         *services.LLMService
-	Sockets     *socket.Manager
+	Config        config.Config
+	DB            *mongo.Database
+	AuthService   *services.AuthService
+	Marketplace   *services.MarketplaceService
+	Settings      *services.SettingsService
+	LLM           *services.LLMService
+	History       *services.FeedHistoryService
+	Alerts        *services.AlertService
+	Webhooks      *services.WebhookService
+	FeedAccess    *services.FeedAccessService
+	Organizations *services.OrganizationService
+	Audit         *services.AuditService
+	Account       *services.AccountService
+	LLMUsage      *services.LLMUsageService
+	LLMKeys       *services.LLMKeyService
+	Analyses      *services.AnalysisService
+	Conversations *services.ConversationService
+	Sockets       *socket.Manager
+	RateLimits    ratelimit.Limits
 }
 
 // BuildEngine wires up the HTTP and Socket.IO server.
 func BuildEngine(deps RouterDeps) *gin.Engine {
 	router := gin.New()
-	router.Use(gin.Logger())
+	router.Use(RequestID())
+	router.Use(Tracing())
+	router.Use(AccessLog())
 	router.Use(gin.Recovery())
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{deps.Config.CORSOrigin},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", APIKeyHeader, "X-Confirm-Password", "X-Confirm-TOTP", "X-Confirm-Email", logging.RequestIDHeader},
+		ExposeHeaders:    []string{logging.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	router.Use(IPRateLimit(deps.RateLimits.IP))
+	userLimit := UserRateLimit(deps.RateLimits.User, "user")
 
 	handlers.HealthHandler(router)
+	handlers.ReadinessHandler(router, readinessChecks(deps)...)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Auth routes (public + protected)
 	authHandler := handlers.NewAuthHandler(deps.AuthService)
+	authHandler.OAuthFrontendURL = deps.Config.OAuthFrontendURL
+	authHandler.Audit = deps.Audit
+	authHandler.Account = deps.Account
+	authHandler.Sockets = deps.Sockets
 	publicAuth := router.Group("/api/auth")
 	authHandler.RegisterPublic(publicAuth)
-	protectedAuth := router.Group("/api/auth", AuthMiddleware(deps.AuthService))
+	protectedAuth := router.Group("/api/auth", AuthMiddleware(deps.AuthService), userLimit)
 	authHandler.RegisterProtected(protectedAuth)
 	protectedAuth.GET("/token-usage", authHandler.GetTokenUsage)
 
 	// Marketplace routes
 	marketplaceHandler := handlers.NewMarketplaceHandler(deps.Marketplace, deps.Sockets)
-	marketplacePublic := router.Group("/api/marketplace")
-	marketplaceProtected := router.Group("/api/marketplace", AuthMiddleware(deps.AuthService))
+	marketplaceHandler.History = deps.History
+	marketplaceHandler.Categories = deps.Settings
+	marketplaceHandler.Alerts = deps.Alerts
+	marketplaceHandler.Webhooks = deps.Webhooks
+	marketplaceHandler.Access = deps.FeedAccess
+	marketplaceHandler.Orgs = deps.Organizations
+	marketplaceHandler.Audit = deps.Audit
+	marketplacePublic := router.Group("/api/marketplace", OptionalAuthMiddleware(deps.AuthService))
+	marketplaceProtected := router.Group("/api/marketplace", AuthMiddleware(deps.AuthService), userLimit)
 	marketplaceHandler.RegisterRoutes(marketplacePublic, marketplaceProtected)
 
+	// Producers push to push feeds here, authenticated by the token and a
+	// request signature rather than a user
+	marketplaceHandler.RegisterIngest(router.Group("/ingest", IngestRateLimit(deps.RateLimits.Ingest)))
+
+	// GraphQL over the marketplace services; subscriptions are served on the
+	// same path with the graphql-transport-ws protocol
+	graphQLHandler := handlers.NewGraphQLHandler(marketplaceHandler, deps.AuthService)
+	graphQLHandler.OriginPatterns = []string{deps.Config.CORSOrigin}
+	graphQLHandler.RegisterRoutes(router.Group("/api/graphql", OptionalAuthMiddleware(deps.AuthService), userLimit))
+
+	// Organizations
+	if deps.Organizations != nil {
+		orgHandler := handlers.NewOrganizationHandler(deps.Organizations, deps.Marketplace)
+		orgHandler.Audit = deps.Audit
+		orgGroup := router.Group("/api/orgs", AuthMiddleware(deps.AuthService), userLimit)
+		orgHandler.RegisterRoutes(orgGroup)
+	}
+
 	// Settings
 	settingsHandler := handlers.NewSettingsHandler(deps.Settings)
+	settingsHandler.LLMKeys = deps.LLMKeys
+	settingsHandler.Audit = deps.Audit
 	settingsGroup := router.Group("/api/settings")
 	settingsHandler.RegisterRoutes(settingsGroup)
+	userSettings := router.Group("/api/settings", AuthMiddleware(deps.AuthService), userLimit)
+	settingsHandler.RegisterUser(userSettings)
+	curatorSettings := router.Group("/api/settings", AuthMiddleware(deps.AuthService), userLimit,
+		RequireRole(deps.AuthService, models.RoleCurator, models.RoleAdmin))
+	settingsHandler.RegisterCurator(curatorSettings)
+
+	// Admin: user management, feed moderation, token quota overrides and the audit log
+	adminHandler := handlers.NewAdminHandler(deps.AuthService, deps.Marketplace, deps.Sockets)
+	adminHandler.Audit = deps.Audit
+	adminGroup := router.Group("/api/admin", AuthMiddleware(deps.AuthService), userLimit,
+		RequireRole(deps.AuthService, models.RoleAdmin))
+	adminHandler.RegisterRoutes(adminGroup)
+
+	// Metered websocket egress against the bandwidth quotas
+	usageHandler := handlers.NewUsageHandler(deps.Sockets)
+	usageGroup := router.Group("/api/usage", AuthMiddleware(deps.AuthService), userLimit)
+	usageHandler.RegisterRoutes(usageGroup)
 
 	// LLM routes
 	if deps.LLM != nil {
 		llmHandler := handlers.NewLLMHandler(deps.LLM, deps.Sockets)
+		llmHandler.Usage = deps.LLMUsage
+		llmHandler.Analyses = deps.Analyses
+		llmHandler.Conversations = deps.Conversations
 		llmPublic := router.Group("/api/llm")
 		{
 			llmPublic.GET("/providers", llmHandler.GetProviders)
 		}
-		llmProtected := router.Group("/api/llm", AuthMiddleware(deps.AuthService))
+		llmProtected := router.Group("/api/llm", AuthMiddleware(deps.AuthService), userLimit)
 		{
 			llmProtected.GET("/context/:feedId", llmHandler.GetFeedContext)
 			llmProtected.DELETE("/context/:feedId", llmHandler.ClearFeedContext)
-			llmProtected.POST("/query", llmHandler.Query)
-			llmProtected.POST("/query/stream", llmHandler.StreamQuery)
-			llmProtected.POST("/analyze", llmHandler.Analyze)
+			llmProtected.GET("/usage", llmHandler.GetUsage)
+			llmProtected.GET("/schedules", llmHandler.ListSchedules)
+			llmProtected.POST("/schedules", llmHandler.CreateSchedule)
+			llmProtected.GET("/schedules/:id", llmHandler.GetSchedule)
+			llmProtected.PUT("/schedules/:id", llmHandler.UpdateSchedule)
+			llmProtected.DELETE("/schedules/:id", llmHandler.DeleteSchedule)
+			llmProtected.GET("/schedules/:id/analyses", llmHandler.ListAnalyses)
+			llmProtected.GET("/conversations", llmHandler.ListConversations)
+			llmProtected.GET("/conversations/:id", llmHandler.GetConversation)
+			llmProtected.DELETE("/conversations/:id", llmHandler.DeleteConversation)
+		}
+		// Provider calls cost money, so they get their own tighter limit
+		llmQueries := llmProtected.Group("", UserRateLimit(deps.RateLimits.LLM, "llm"))
+		{
+			llmQueries.POST("/query", llmHandler.Query)
+			llmQueries.POST("/query/stream", llmHandler.StreamQuery)
+			llmQueries.POST("/analyze", llmHandler.Analyze)
 		}
 	}
 
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	"github.com/turboline-ai/turbostream/go-backend/internal/socket"
+)
+
+// TestBuildEngine registers every route group the server mounts; Gin panics
+// on conflicting paths, so a clash fails here instead of at startup
+func TestBuildEngine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Config{JWTSecret: "test-secret", CORSOrigin: "http://localhost:3000"}
+	marketplace := services.NewMarketplaceServiceWithRepos(services.NewMemoryFeedRepo(), services.NewMemorySubscriptionRepo())
+	llm, err := services.NewLLMService(cfg)
+	require.NoError(t, err)
+
+	var router *gin.Engine
+	require.NotPanics(t, func() {
+		router = BuildEngine(RouterDeps{
+			Config:        cfg,
+			AuthService:   services.NewAuthService(cfg, nil, nil),
+			Marketplace:   marketplace,
+			LLM:           llm,
+			Organizations: services.NewOrganizationService(nil),
+			Sockets:       socket.NewManager(nil, nil, marketplace, nil),
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/marketplace/feeds/abc/data", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "the publish route is mounted behind auth")
+}
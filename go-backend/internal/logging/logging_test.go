@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_JSONWithContext(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, "debug", "json")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, "conn_id", "c1")
+	ctx = With(ctx, "event", "llm-query")
+	logger.DebugContext(ctx, "handling message", "feed_id", "f1")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "handling message", record["msg"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "c1", record["conn_id"])
+	assert.Equal(t, "llm-query", record["event"])
+	assert.Equal(t, "f1", record["feed_id"])
+}
+
+func TestNew_Level(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, "warn", "text")
+	logger.Info("hidden")
+	logger.With("component", "test").Warn("shown")
+
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "level=WARN msg=shown component=test")
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
+
+func TestWith_DoesNotShareAttrs(t *testing.T) {
+	base := With(context.Background(), "conn_id", "c1")
+	a := With(base, "event", "a")
+	b := With(base, "event", "b")
+
+	var out bytes.Buffer
+	logger := New(&out, "info", "text")
+	logger.InfoContext(a, "first")
+	logger.InfoContext(b, "second")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "event=a")
+	assert.Contains(t, lines[1], "event=b")
+	assert.NotContains(t, lines[1], "event=a")
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("3f2a-b_c.9"))
+	assert.True(t, ValidRequestID(NewID()))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("has space"))
+	assert.False(t, ValidRequestID("line\nbreak"))
+	assert.False(t, ValidRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+}
+
+func TestNew_TraceIDs(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, "info", "json")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "traced")
+	logger.Info("untraced")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, sc.TraceID().String(), record["trace_id"])
+	assert.Equal(t, sc.SpanID().String(), record["span_id"])
+	assert.NotContains(t, lines[1], "trace_id")
+}
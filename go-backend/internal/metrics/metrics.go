@@ -0,0 +1,203 @@
+// Package metrics defines the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const namespace = "turbostream"
+
+var (
+	// WSClients is the number of connected websocket clients
+	WSClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_clients",
+		Help:      "Connected websocket clients.",
+	})
+
+	// Rooms is the number of websocket rooms with at least one member
+	Rooms = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_rooms",
+		Help:      "Websocket rooms with at least one client.",
+	})
+
+	// WSDroppedMessages counts messages dropped from full client send queues
+	WSDroppedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_dropped_messages_total",
+		Help:      "Messages dropped because a client's send queue was full.",
+	}, []string{"type"})
+
+	// WSQuotaDropped counts feed-data messages dropped by bandwidth quotas
+	WSQuotaDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_quota_dropped_messages_total",
+		Help:      "Feed-data messages not sent because a user was over a bandwidth quota.",
+	}, []string{"quota"})
+
+	// WSSlowConsumers counts clients disconnected for not keeping up
+	WSSlowConsumers = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_slow_consumers_total",
+		Help:      "Websocket clients disconnected as slow consumers.",
+	})
+
+	// WSStaleClients counts clients disconnected for missing a heartbeat pong
+	WSStaleClients = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_stale_clients_total",
+		Help:      "Websocket clients disconnected for not answering a ping.",
+	})
+
+	// AuditEvents counts recorded audit events by action and outcome
+	AuditEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_events_total",
+		Help:      "Security-relevant actions recorded in the audit log.",
+	}, []string{"action", "outcome"})
+
+	// EmailsSent counts emails handed to the mail provider by kind and outcome
+	EmailsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_sent_total",
+		Help:      "Verification, password reset and alert emails sent, by outcome (sent or failed).",
+	}, []string{"kind", "outcome"})
+
+	// WSSubscriptionsDenied counts feed subscriptions refused for lack of access
+	WSSubscriptionsDenied = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_subscriptions_denied_total",
+		Help:      "Websocket feed subscriptions refused because the client may not read the feed.",
+	})
+
+	// WSCompressedClients is the number of connected clients using permessage-deflate
+	WSCompressedClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_compressed_clients",
+		Help:      "Connected websocket clients using permessage-deflate.",
+	})
+
+	// WSFeedDataBytes counts feed-data bytes written to clients by encoding,
+	// before any permessage-deflate
+	WSFeedDataBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_feed_data_bytes_total",
+		Help:      "Feed-data bytes written to websocket clients by encoding (json or msgpack).",
+	}, []string{"encoding"})
+
+	// WSBinaryBytesSaved counts bytes saved by sending feed-data as msgpack instead of JSON
+	WSBinaryBytesSaved = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_binary_bytes_saved_total",
+		Help:      "Bytes saved by sending feed-data to websocket clients as msgpack instead of JSON.",
+	})
+
+	// RateLimited counts requests and websocket messages rejected by rate limits
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests and websocket messages rejected by rate limits.",
+	}, []string{"scope"})
+
+	// FeedMessages counts messages broadcast per feed; use rate() for message rates
+	FeedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "feed_messages_total",
+		Help:      "Feed messages broadcast to subscribers.",
+	}, []string{"feed_id"})
+
+	// FeedMessagesSkipped counts feed messages held back by the feed's throttle options
+	FeedMessagesSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "feed_messages_skipped_total",
+		Help:      "Feed messages not broadcast because of the feed's dedupe, interval or sampling options.",
+	}, []string{"feed_id", "reason"})
+
+	// WebhookDeliveries counts webhook deliveries by event and result
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook deliveries by event and result (delivered, failed or dropped).",
+	}, []string{"event", "result"})
+
+	// FeedReconnects counts reconnect attempts to upstream feeds
+	FeedReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "feed_reconnects_total",
+		Help:      "Reconnect attempts to upstream feeds.",
+	}, []string{"feed_id", "connection_type"})
+
+	// LLMRequestDuration observes LLM provider call latency
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "LLM provider request latency.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60},
+	}, []string{"provider", "mode", "status"})
+
+	// LLMTokens counts tokens reported by LLM providers
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens used by LLM provider requests.",
+	}, []string{"provider"})
+
+	// LLMCacheLookups counts LLM answer cache lookups by result
+	LLMCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_cache_lookups_total",
+		Help:      "LLM answer cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	// LLMFailovers counts queries moved off a provider after it failed
+	LLMFailovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_failovers_total",
+		Help:      "LLM queries that failed over from a provider to the next one.",
+	}, []string{"from", "to"})
+
+	// MongoOpDuration observes MongoDB command latency
+	MongoOpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_op_duration_seconds",
+		Help:      "MongoDB command latency.",
+		Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"command", "status"})
+)
+
+// Handler serves the registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveLLM records one LLM provider call
+func ObserveLLM(provider, mode string, start time.Time, tokens int, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	LLMRequestDuration.WithLabelValues(provider, mode, status).Observe(time.Since(start).Seconds())
+	if tokens > 0 {
+		LLMTokens.WithLabelValues(provider).Add(float64(tokens))
+	}
+}
+
+// MongoMonitor returns a command monitor that records MongoDB command latency
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			MongoOpDuration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			MongoOpDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+		},
+	}
+}
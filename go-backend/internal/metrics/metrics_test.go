@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveLLM(t *testing.T) {
+	before := testutil.ToFloat64(LLMTokens.WithLabelValues("test-provider"))
+
+	ObserveLLM("test-provider", "query", time.Now(), 42, nil)
+	ObserveLLM("test-provider", "query", time.Now(), 0, errors.New("boom"))
+
+	assert.Equal(t, before+42, testutil.ToFloat64(LLMTokens.WithLabelValues("test-provider")))
+	assert.Equal(t, 2, testutil.CollectAndCount(LLMRequestDuration.MustCurryWith(map[string]string{"provider": "test-provider"})),
+		"ok and error calls are separate series")
+}
+
+func TestHandler(t *testing.T) {
+	FeedMessages.WithLabelValues("feed-1").Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `turbostream_feed_messages_total{feed_id="feed-1"}`)
+	assert.Contains(t, w.Body.String(), "turbostream_ws_clients")
+}
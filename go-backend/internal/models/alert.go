@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlertRule notifies a subscriber when a feed's data meets a condition
+type AlertRule struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID string             `bson:"userId" json:"userId"`
+	FeedID string             `bson:"feedId" json:"feedId"`
+	Name   string             `bson:"name,omitempty" json:"name,omitempty"`
+	// Condition is a filter on the transformed message, e.g. `price > 70000`,
+	// or "no message for 60s" to fire when the feed goes quiet
+	Condition       string     `bson:"condition" json:"condition"`
+	CooldownSeconds int        `bson:"cooldownSeconds,omitempty" json:"cooldownSeconds,omitempty"` // minimum time between alerts; 0 uses the default
+	Enabled         bool       `bson:"enabled" json:"enabled"`
+	NotifyEmail     bool       `bson:"notifyEmail,omitempty" json:"notifyEmail,omitempty"`
+	WebhookURL      string     `bson:"webhookUrl,omitempty" json:"webhookUrl,omitempty"`
+	LastTriggeredAt *time.Time `bson:"lastTriggeredAt,omitempty" json:"lastTriggeredAt,omitempty"`
+	CreatedAt       time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// FeedAlert is a triggered alert rule, sent to the rule's owner as a feed-alert event
+type FeedAlert struct {
+	RuleID      string      `json:"ruleId"`
+	FeedID      string      `json:"feedId"`
+	FeedName    string      `json:"feedName,omitempty"`
+	UserID      string      `json:"userId"`
+	Name        string      `json:"name,omitempty"`
+	Condition   string      `json:"condition"`
+	Data        interface{} `json:"data,omitempty"` // the message that met the condition; none for silence alerts
+	TriggeredAt time.Time   `json:"triggeredAt"`
+}
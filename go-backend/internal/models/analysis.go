@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnalysisSchedule runs an AI analysis of a feed on the server at a fixed interval
+type AnalysisSchedule struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID string             `bson:"userId" json:"userId"`
+	FeedID string             `bson:"feedId" json:"feedId"`
+	Name   string             `bson:"name,omitempty" json:"name,omitempty"`
+	// Prompt is the question asked on every run; empty runs the default
+	// summary of patterns, trends and anomalies
+	Prompt          string     `bson:"prompt,omitempty" json:"prompt,omitempty"`
+	Provider        string     `bson:"provider,omitempty" json:"provider,omitempty"` // empty uses the default provider
+	IntervalSeconds int        `bson:"intervalSeconds" json:"intervalSeconds"`
+	Enabled         bool       `bson:"enabled" json:"enabled"`
+	NextRunAt       time.Time  `bson:"nextRunAt" json:"nextRunAt"`
+	LastRunAt       *time.Time `bson:"lastRunAt,omitempty" json:"lastRunAt,omitempty"`
+	LastError       string     `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt       time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Analysis is the result of one scheduled analysis run, sent to the
+// schedule's owner as an analysis-result event
+type Analysis struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ScheduleID       primitive.ObjectID `bson:"scheduleId" json:"scheduleId"`
+	UserID           string             `bson:"userId" json:"userId"`
+	FeedID           string             `bson:"feedId" json:"feedId"`
+	FeedName         string             `bson:"feedName,omitempty" json:"feedName,omitempty"`
+	Name             string             `bson:"name,omitempty" json:"name,omitempty"`
+	Prompt           string             `bson:"prompt" json:"prompt"`
+	Provider         string             `bson:"provider,omitempty" json:"provider,omitempty"`
+	Answer           string             `bson:"answer,omitempty" json:"answer,omitempty"`
+	Error            string             `bson:"error,omitempty" json:"error,omitempty"`
+	PromptTokens     int                `bson:"promptTokens" json:"promptTokens"`
+	CompletionTokens int                `bson:"completionTokens" json:"completionTokens"`
+	DurationMs       int64              `bson:"durationMs" json:"durationMs"`
+	OwnKey           bool               `bson:"ownKey,omitempty" json:"ownKey,omitempty"`
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+}
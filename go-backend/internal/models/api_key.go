@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key scopes granted per feed
+const (
+	APIKeyScopeRead    = "read"
+	APIKeyScopePublish = "publish"
+)
+
+// APIKeyGrant gives an API key scopes on one feed
+type APIKeyGrant struct {
+	FeedID string   `bson:"feedId" json:"feedId"`
+	Scopes []string `bson:"scopes" json:"scopes"`
+}
+
+// APIKey lets programmatic clients act as its owner, limited to the feeds
+// and scopes in Grants. Only a hash of the key is stored.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // leading characters of the key, to tell keys apart
+	KeyHash    string             `bson:"keyHash" json:"-"`
+	Grants     []APIKeyGrant      `bson:"grants" json:"grants"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastUsedAt *time.Time         `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// Allows reports whether the key grants scope on the feed
+func (k APIKey) Allows(feedID, scope string) bool {
+	for _, grant := range k.Grants {
+		if grant.FeedID != feedID {
+			continue
+		}
+		for _, s := range grant.Scopes {
+			if s == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Outcomes of an audited action
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent records a security-relevant action: who did what to which
+// target, from where, and which fields it changed
+type AuditEvent struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	Action  string             `bson:"action" json:"action"` // e.g. "auth.login" or "feed.delete"
+	Outcome string             `bson:"outcome" json:"outcome"`
+	// ActorID is empty for anonymous actions such as a failed login, which
+	// record the attempted email instead
+	ActorID    string `bson:"actorId,omitempty" json:"actorId,omitempty"`
+	ActorEmail string `bson:"actorEmail,omitempty" json:"actorEmail,omitempty"`
+	IP         string `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent  string `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	TargetType string `bson:"targetType,omitempty" json:"targetType,omitempty"` // user, feed, api_key, ...
+	TargetID   string `bson:"targetId,omitempty" json:"targetId,omitempty"`
+	// Before and After hold only the fields the action changed
+	Before    map[string]interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After     map[string]interface{} `bson:"after,omitempty" json:"after,omitempty"`
+	Reason    string                 `bson:"reason,omitempty" json:"reason,omitempty"` // why a failed action failed
+	CreatedAt time.Time              `bson:"createdAt" json:"createdAt"`
+	ExpiresAt *time.Time             `bson:"expiresAt,omitempty" json:"-"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Conversation is a user's multi-turn AI chat about a feed. Messages holds
+// the questions and answers without the feed data they were asked about.
+type Conversation struct {
+	ID        primitive.ObjectID    `bson:"_id,omitempty" json:"_id"`
+	UserID    string                `bson:"userId" json:"userId"`
+	FeedID    string                `bson:"feedId" json:"feedId"`
+	Title     string                `bson:"title" json:"title"` // the first question
+	Messages  []ConversationMessage `bson:"messages" json:"messages,omitempty"`
+	Turns     int                   `bson:"turns" json:"turns"` // questions asked, including ones trimmed from Messages
+	CreatedAt time.Time             `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time             `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ConversationMessage is one question or answer of a conversation
+type ConversationMessage struct {
+	Role      string    `bson:"role" json:"role"` // "user" or "assistant"
+	Content   string    `bson:"content" json:"content"`
+	Provider  string    `bson:"provider,omitempty" json:"provider,omitempty"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+}
@@ -1,57 +1,305 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type WebSocketFeed struct {
-	ID                      primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
-	Name                    string             `bson:"name" json:"name"`
-	Description             string             `bson:"description" json:"description"`
-	SystemPrompt            string             `bson:"systemPrompt,omitempty" json:"systemPrompt,omitempty"`
-	URL                     string             `bson:"url" json:"url"`
-	Category                string             `bson:"category" json:"category"`
-	Icon                    string             `bson:"icon,omitempty" json:"icon,omitempty"`
-	IsActive                bool               `bson:"isActive" json:"isActive"`
-	IsVerified              bool               `bson:"isVerified" json:"isVerified"`
-	IsPublic                bool               `bson:"isPublic" json:"isPublic"`
-	FeedType                string             `bson:"feedType" json:"feedType"`
-	OwnerID                 string             `bson:"ownerId" json:"ownerId"`
-	OwnerName               string             `bson:"ownerName" json:"ownerName"`
-	ConnectionType          string             `bson:"connectionType,omitempty" json:"connectionType,omitempty"`
-	QueryParams             []KeyValue         `bson:"queryParams,omitempty" json:"queryParams,omitempty"`
-	Headers                 []KeyValue         `bson:"headers,omitempty" json:"headers,omitempty"`
-	ConnectionMessages      []string           `bson:"connectionMessages,omitempty" json:"connectionMessages,omitempty"`
-	ConnectionMessage       string             `bson:"connectionMessage,omitempty" json:"connectionMessage,omitempty"`
-	ConnectionMessageFormat string             `bson:"connectionMessageFormat,omitempty" json:"connectionMessageFormat,omitempty"`
-	EventName               string             `bson:"eventName,omitempty" json:"eventName,omitempty"`
-	DataFormat              string             `bson:"dataFormat,omitempty" json:"dataFormat,omitempty"`
-	ProtobufType            string             `bson:"protobufType,omitempty" json:"protobufType,omitempty"`
-	ReconnectionEnabled     bool               `bson:"reconnectionEnabled" json:"reconnectionEnabled"`
-	ReconnectionDelay       int                `bson:"reconnectionDelay,omitempty" json:"reconnectionDelay,omitempty"`
-	ReconnectionAttempts    int                `bson:"reconnectionAttempts,omitempty" json:"reconnectionAttempts,omitempty"`
-	SubscriberCount         int                `bson:"subscriberCount" json:"subscriberCount"`
-	HTTPConfig              *HTTPPollingConfig `bson:"httpConfig,omitempty" json:"httpConfig,omitempty"`
-	Tags                    []string           `bson:"tags" json:"tags"`
-	Website                 string             `bson:"website,omitempty" json:"website,omitempty"`
-	Documentation           string             `bson:"documentation,omitempty" json:"documentation,omitempty"`
-	DefaultAIPrompt         string             `bson:"defaultAIPrompt,omitempty" json:"defaultAIPrompt,omitempty"`
-	AIAnalysisEnabled       bool               `bson:"aiAnalysisEnabled,omitempty" json:"aiAnalysisEnabled,omitempty"`
-	CreatedAt               time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt               time.Time          `bson:"updatedAt" json:"updatedAt"`
-	LastActiveAt            *time.Time         `bson:"lastActiveAt,omitempty" json:"lastActiveAt,omitempty"`
+	ID                      primitive.ObjectID    `bson:"_id,omitempty" json:"_id"`
+	Name                    string                `bson:"name" json:"name"`
+	Description             string                `bson:"description" json:"description"`
+	SystemPrompt            string                `bson:"systemPrompt,omitempty" json:"systemPrompt,omitempty"`
+	URL                     string                `bson:"url" json:"url"`
+	Category                string                `bson:"category" json:"category"`
+	Icon                    string                `bson:"icon,omitempty" json:"icon,omitempty"`
+	IsActive                bool                  `bson:"isActive" json:"isActive"`
+	PausedAt                *time.Time            `bson:"pausedAt,omitempty" json:"pausedAt,omitempty"` // set while the feed is paused; inactive feeds are never connected
+	IsVerified              bool                  `bson:"isVerified" json:"isVerified"`
+	Verification            *FeedVerification     `bson:"verification,omitempty" json:"verification,omitempty"`
+	Schema                  *FeedSchema           `bson:"schema,omitempty" json:"schema,omitempty"`
+	IsPublic                bool                  `bson:"isPublic" json:"isPublic"`
+	FeedType                string                `bson:"feedType" json:"feedType"`
+	OwnerID                 string                `bson:"ownerId" json:"ownerId"`
+	OwnerName               string                `bson:"ownerName" json:"ownerName"`
+	OrgID                   string                `bson:"orgId,omitempty" json:"orgId,omitempty"` // set, with OwnerID empty, when an organization owns the feed
+	ConnectionType          string                `bson:"connectionType,omitempty" json:"connectionType,omitempty"`
+	QueryParams             []KeyValue            `bson:"queryParams,omitempty" json:"queryParams,omitempty"`
+	Headers                 []KeyValue            `bson:"headers,omitempty" json:"headers,omitempty"`
+	ConnectionMessages      []string              `bson:"connectionMessages,omitempty" json:"connectionMessages,omitempty"`
+	ConnectionMessage       string                `bson:"connectionMessage,omitempty" json:"connectionMessage,omitempty"`
+	ConnectionMessageFormat string                `bson:"connectionMessageFormat,omitempty" json:"connectionMessageFormat,omitempty"`
+	SecretMessages          bool                  `bson:"secretMessages,omitempty" json:"secretMessages,omitempty"` // connection messages carry credentials; encrypted at rest and redacted like sensitive headers
+	EventName               string                `bson:"eventName,omitempty" json:"eventName,omitempty"`
+	DataFormat              string                `bson:"dataFormat,omitempty" json:"dataFormat,omitempty"`                 // json (default), msgpack, avro or protobuf
+	ProtobufType            string                `bson:"protobufType,omitempty" json:"protobufType,omitempty"`             // fully-qualified message name for "protobuf" feeds
+	ProtobufDescriptor      string                `bson:"protobufDescriptor,omitempty" json:"protobufDescriptor,omitempty"` // base64 FileDescriptorSet defining ProtobufType
+	ReconnectionEnabled     bool                  `bson:"reconnectionEnabled" json:"reconnectionEnabled"`
+	ReconnectionDelay       int                   `bson:"reconnectionDelay,omitempty" json:"reconnectionDelay,omitempty"`
+	ReconnectionAttempts    int                   `bson:"reconnectionAttempts,omitempty" json:"reconnectionAttempts,omitempty"`
+	ReassembleFragments     bool                  `bson:"reassembleFragments,omitempty" json:"reassembleFragments,omitempty"`
+	FragmentMaxBytes        int                   `bson:"fragmentMaxBytes,omitempty" json:"fragmentMaxBytes,omitempty"`
+	FragmentTimeoutMs       int                   `bson:"fragmentTimeoutMs,omitempty" json:"fragmentTimeoutMs,omitempty"`
+	SubscriberCount         int                   `bson:"subscriberCount" json:"subscriberCount"`
+	HTTPConfig              *HTTPPollingConfig    `bson:"httpConfig,omitempty" json:"httpConfig,omitempty"`
+	MQTTConfig              *MQTTConfig           `bson:"mqttConfig,omitempty" json:"mqttConfig,omitempty"`
+	KafkaConfig             *KafkaConfig          `bson:"kafkaConfig,omitempty" json:"kafkaConfig,omitempty"`
+	SimulatedConfig         *SimulatedConfig      `bson:"simulatedConfig,omitempty" json:"simulatedConfig,omitempty"`
+	Ingest                  *FeedIngest           `bson:"ingest,omitempty" json:"-"` // push feeds only; never sent to clients
+	AvroConfig              *AvroConfig           `bson:"avroConfig,omitempty" json:"avroConfig,omitempty"`
+	Transform               *FeedTransform        `bson:"transform,omitempty" json:"transform,omitempty"`
+	Throttle                *FeedThrottle         `bson:"throttle,omitempty" json:"throttle,omitempty"`
+	Aggregation             *FeedAggregation      `bson:"aggregation,omitempty" json:"aggregation,omitempty"`
+	AnomalyDetection        *FeedAnomalyDetection `bson:"anomalyDetection,omitempty" json:"anomalyDetection,omitempty"`
+	Tags                    []string              `bson:"tags" json:"tags"`
+	Website                 string                `bson:"website,omitempty" json:"website,omitempty"`
+	Documentation           string                `bson:"documentation,omitempty" json:"documentation,omitempty"`
+	DefaultAIPrompt         string                `bson:"defaultAIPrompt,omitempty" json:"defaultAIPrompt,omitempty"`
+	AIAnalysisEnabled       bool                  `bson:"aiAnalysisEnabled,omitempty" json:"aiAnalysisEnabled,omitempty"`
+	HistoryRetentionHours   int                   `bson:"historyRetentionHours,omitempty" json:"historyRetentionHours,omitempty"` // 0 uses the server default, negative disables history
+	CreatedAt               time.Time             `bson:"createdAt" json:"createdAt"`
+	UpdatedAt               time.Time             `bson:"updatedAt" json:"updatedAt"`
+	LastActiveAt            *time.Time            `bson:"lastActiveAt,omitempty" json:"lastActiveAt,omitempty"`
+	CircuitState            string                `bson:"circuitState,omitempty" json:"circuitState,omitempty"` // "open" once reconnect attempts are exhausted
+	CircuitOpenedAt         *time.Time            `bson:"circuitOpenedAt,omitempty" json:"circuitOpenedAt,omitempty"`
+	DeletedAt               *time.Time            `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"` // set while the feed can still be restored
+}
+
+// MarshalJSON writes the feed with its secrets redacted, so stored
+// credentials never reach API clients
+func (f WebSocketFeed) MarshalJSON() ([]byte, error) {
+	type plain WebSocketFeed
+	return json.Marshal(plain(f.RedactSecrets()))
+}
+
+// RedactSecrets returns a copy of the feed with the values of sensitive
+// headers, query params and HTTP request headers, and of secret connection
+// messages, replaced by RedactedValue
+func (f WebSocketFeed) RedactSecrets() WebSocketFeed {
+	f.Headers = redactKeyValues(f.Headers)
+	f.QueryParams = redactKeyValues(f.QueryParams)
+	if f.HTTPConfig != nil && f.HTTPConfig.RequestHeaders != nil {
+		cfg := *f.HTTPConfig
+		cfg.RequestHeaders = make(map[string]string, len(f.HTTPConfig.RequestHeaders))
+		for k, v := range f.HTTPConfig.RequestHeaders {
+			if v != "" && (SensitiveKey(k) || IsSealedSecret(v)) {
+				v = RedactedValue
+			}
+			cfg.RequestHeaders[k] = v
+		}
+		f.HTTPConfig = &cfg
+	}
+	if f.ConnectionMessages != nil {
+		messages := make([]string, len(f.ConnectionMessages))
+		for i, msg := range f.ConnectionMessages {
+			if msg != "" && (f.SecretMessages || IsSealedSecret(msg)) {
+				msg = RedactedValue
+			}
+			messages[i] = msg
+		}
+		f.ConnectionMessages = messages
+	}
+	if f.ConnectionMessage != "" && (f.SecretMessages || IsSealedSecret(f.ConnectionMessage)) {
+		f.ConnectionMessage = RedactedValue
+	}
+	return f
+}
+
+// FeedIngest holds the credentials producers use to push data to a "push"
+// feed. The token is stored hashed and the signing secret sealed like other
+// feed secrets.
+type FeedIngest struct {
+	TokenHash string    `bson:"tokenHash"`
+	Secret    string    `bson:"secret"`
+	IssuedAt  time.Time `bson:"issuedAt"`
+}
+
+// FeedVerification records the automatic checks from the latest verification
+// attempt and, once verified, who verified the feed
+type FeedVerification struct {
+	Checks     []FeedCheck `bson:"checks" json:"checks"`
+	Passed     bool        `bson:"passed" json:"passed"`
+	Forced     bool        `bson:"forced,omitempty" json:"forced,omitempty"` // verified by an admin despite failed checks
+	CheckedAt  time.Time   `bson:"checkedAt" json:"checkedAt"`
+	VerifiedBy string      `bson:"verifiedBy,omitempty" json:"verifiedBy,omitempty"`
+	VerifiedAt *time.Time  `bson:"verifiedAt,omitempty" json:"verifiedAt,omitempty"`
+}
+
+// FeedCheck is the outcome of one automatic verification check
+type FeedCheck struct {
+	Name    string `bson:"name" json:"name"` // "connectivity" or "schema"
+	Passed  bool   `bson:"passed" json:"passed"`
+	Message string `bson:"message,omitempty" json:"message,omitempty"`
+}
+
+// FeedSchema is the shape of a feed's messages, inferred from recent
+// messages after the feed's transform
+type FeedSchema struct {
+	Fields      []SchemaField `bson:"fields" json:"fields"`
+	SampleCount int           `bson:"sampleCount" json:"sampleCount"` // messages the schema was inferred from
+	InferredAt  time.Time     `bson:"inferredAt" json:"inferredAt"`
+}
+
+// SchemaField is one field observed in a feed's messages
+type SchemaField struct {
+	Path     string      `bson:"path" json:"path"`   // dotted path; "[]" marks array items, e.g. "trades[].price"
+	Types    []string    `bson:"types" json:"types"` // JSON types: string, number, boolean, object, array, null
+	Example  interface{} `bson:"example,omitempty" json:"example,omitempty"`
+	Optional bool        `bson:"optional,omitempty" json:"optional,omitempty"` // missing from some sampled messages
 }
 
 type HTTPPollingConfig struct {
 	Method          string            `bson:"method" json:"method"`
-	PollingInterval int               `bson:"pollingInterval" json:"pollingInterval"`
-	Timeout         int               `bson:"timeout" json:"timeout"`
+	PollingInterval int               `bson:"pollingInterval" json:"pollingInterval"` // milliseconds
+	Timeout         int               `bson:"timeout" json:"timeout"`                 // milliseconds
 	RequestHeaders  map[string]string `bson:"requestHeaders,omitempty" json:"requestHeaders,omitempty"`
 	RequestBody     string            `bson:"requestBody,omitempty" json:"requestBody,omitempty"`
 	ResponseFormat  string            `bson:"responseFormat" json:"responseFormat"`
-	DataPath        string            `bson:"dataPath,omitempty" json:"dataPath,omitempty"`
+	DataPath        string            `bson:"dataPath,omitempty" json:"dataPath,omitempty"` // JSONPath-style selector, e.g. "$.data.items[0]"
+}
+
+// MQTTConfig describes the topic an "mqtt" feed subscribes to. The broker
+// address is the feed URL (tcp://, ssl://, ws:// or wss://).
+type MQTTConfig struct {
+	Topic    string `bson:"topic" json:"topic"`
+	QoS      byte   `bson:"qos" json:"qos"`
+	ClientID string `bson:"clientId,omitempty" json:"clientId,omitempty"`
+	Username string `bson:"username,omitempty" json:"username,omitempty"`
+	// Password is never returned to API clients
+	Password string `bson:"password,omitempty" json:"-"`
+}
+
+// AvroConfig describes how "avro" feeds are decoded. With a schema registry,
+// payloads carry a schema ID and their schemas are fetched from the registry;
+// otherwise every payload is written with Schema.
+type AvroConfig struct {
+	SchemaRegistryURL string `bson:"schemaRegistryUrl,omitempty" json:"schemaRegistryUrl,omitempty"`
+	Schema            string `bson:"schema,omitempty" json:"schema,omitempty"` // Avro schema JSON
+	Username          string `bson:"username,omitempty" json:"username,omitempty"`
+	// Password is never returned to API clients
+	Password string `bson:"password,omitempty" json:"-"`
+}
+
+// KafkaConfig describes the topic a "kafka" feed consumes. When Brokers is
+// empty the feed URL is used as a comma-separated broker list.
+type KafkaConfig struct {
+	Brokers []string `bson:"brokers,omitempty" json:"brokers,omitempty"`
+	Topic   string   `bson:"topic" json:"topic"`
+	GroupID string   `bson:"groupId,omitempty" json:"groupId,omitempty"`
+	// StartOffset is "earliest" or "latest" (default) for a new consumer group
+	StartOffset string           `bson:"startOffset,omitempty" json:"startOffset,omitempty"`
+	TLS         bool             `bson:"tls,omitempty" json:"tls,omitempty"`
+	SASL        *KafkaSASLConfig `bson:"sasl,omitempty" json:"sasl,omitempty"`
+}
+
+// KafkaSASLConfig holds broker credentials. Mechanism is "plain",
+// "scram-sha-256" or "scram-sha-512".
+type KafkaSASLConfig struct {
+	Mechanism string `bson:"mechanism" json:"mechanism"`
+	Username  string `bson:"username" json:"username"`
+	// Password is never returned to API clients
+	Password string `bson:"password" json:"-"`
+}
+
+// SimulatedConfig describes the synthetic messages a "simulated" feed
+// generates in the backend, for trying the platform without an upstream.
+// Simulated feeds need no URL; zero values use the defaults.
+type SimulatedConfig struct {
+	Generator  string   `bson:"generator" json:"generator"`                       // "prices" (default), a sine wave per symbol, or "news" headlines
+	IntervalMs int      `bson:"intervalMs,omitempty" json:"intervalMs,omitempty"` // time between ticks; default 1000
+	Symbols    []string `bson:"symbols,omitempty" json:"symbols,omitempty"`       // one price per symbol each tick; news mentions them; default ["DEMO"]
+	BasePrice  float64  `bson:"basePrice,omitempty" json:"basePrice,omitempty"`   // price the wave centres on; default 100
+	Amplitude  float64  `bson:"amplitude,omitempty" json:"amplitude,omitempty"`   // swing as a fraction of BasePrice; default 0.05
+	PeriodSec  int      `bson:"periodSec,omitempty" json:"periodSec,omitempty"`   // length of one wave; default 60
+	Noise      float64  `bson:"noise,omitempty" json:"noise,omitempty"`           // random jitter as a fraction of BasePrice; default 0.002
+	Seed       int64    `bson:"seed,omitempty" json:"seed,omitempty"`             // fixes the random sequence, e.g. for tests; 0 seeds from the clock
+}
+
+// FeedTransform reshapes each upstream message before it is broadcast and
+// added to the LLM context. Steps run in order: Path, Fields, Rename, Numeric.
+// Fields, Rename and Numeric apply to an object or to each object in an array.
+type FeedTransform struct {
+	Path    string            `bson:"path,omitempty" json:"path,omitempty"`       // JSONPath-style selector, e.g. "$.data.items[*]"
+	Fields  []string          `bson:"fields,omitempty" json:"fields,omitempty"`   // keys to keep; dotted paths keep their last segment
+	Rename  map[string]string `bson:"rename,omitempty" json:"rename,omitempty"`   // old key -> new key
+	Numeric []string          `bson:"numeric,omitempty" json:"numeric,omitempty"` // keys whose string values are converted to numbers
+}
+
+// FeedThrottle limits which of a feed's messages are broadcast. Options
+// apply to transformed messages, in field order; zero values disable them.
+type FeedThrottle struct {
+	DedupeKey     string `bson:"dedupeKey,omitempty" json:"dedupeKey,omitempty"`         // path whose value identifies a message, e.g. "$.id"; "$" compares whole messages
+	MinIntervalMs int    `bson:"minIntervalMs,omitempty" json:"minIntervalMs,omitempty"` // minimum time between broadcasts
+	SampleEvery   int    `bson:"sampleEvery,omitempty" json:"sampleEvery,omitempty"`     // broadcast 1 in N messages
+}
+
+// FeedAggregation configures the rollups computed over a feed's messages.
+// Rollups cover every transformed message, including ones the throttle holds back.
+type FeedAggregation struct {
+	Fields  []string `bson:"fields" json:"fields"`                       // numeric paths to summarise, e.g. "$.price"
+	Windows []string `bson:"windows,omitempty" json:"windows,omitempty"` // "1s", "10s" or "1m"; empty computes all three
+}
+
+// FeedAnomalyDetection configures the statistical detector that flags
+// unusual values of a feed's numeric fields. Like rollups it sees every
+// transformed message, including ones the throttle holds back.
+type FeedAnomalyDetection struct {
+	Fields    []string `bson:"fields" json:"fields"`                           // numeric paths to watch, e.g. "$.price"
+	Method    string   `bson:"method,omitempty" json:"method,omitempty"`       // "zscore" (default) against the last Window values, or "ewma"
+	Threshold float64  `bson:"threshold,omitempty" json:"threshold,omitempty"` // standard deviations from normal that count as an anomaly; default 3
+	Window    int      `bson:"window,omitempty" json:"window,omitempty"`       // values kept for zscore, or the EWMA span; default 100
+	Explain   bool     `bson:"explain,omitempty" json:"explain,omitempty"`     // ask the AI to explain anomalies
+}
+
+// FeedAnomaly is an unusual value of a feed field, broadcast as a
+// feed-anomaly event
+type FeedAnomaly struct {
+	ID        string    `json:"id"`
+	FeedID    string    `json:"feedId"`
+	FeedName  string    `json:"feedName"`
+	Field     string    `json:"field"`
+	Value     float64   `json:"value"`
+	Expected  float64   `json:"expected"` // mean or EWMA of the values before it
+	StdDev    float64   `json:"stdDev"`
+	Score     float64   `json:"score"`    // standard deviations from Expected, negative below it
+	Severity  string    `json:"severity"` // "low", "medium" or "high"
+	Method    string    `json:"method"`
+	Timestamp time.Time `json:"timestamp"`
+	// Explanation is the AI's account of the anomaly, sent in a later
+	// feed-anomaly-explained event
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// FeedAggregate is the rollup of a feed's messages over one closed window,
+// broadcast as a feed-aggregate event
+type FeedAggregate struct {
+	FeedID string                    `json:"feedId"`
+	Window string                    `json:"window"`
+	Start  time.Time                 `json:"start"`
+	End    time.Time                 `json:"end"`
+	Count  int                       `json:"count"` // messages in the window
+	Fields map[string]AggregateStats `json:"fields"`
+}
+
+// AggregateStats summarises one numeric field over a window. Open and Close
+// are the first and last values seen; with Min and Max they give OHLC.
+type AggregateStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Open  float64 `json:"open"`
+	Close float64 `json:"close"`
+}
+
+// FeedDataRecord is a feed message kept in the feed_data history collection
+type FeedDataRecord struct {
+	FeedID    string      `json:"feedId"`
+	EventName string      `json:"eventName,omitempty"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
 }
 
 type UserSubscription struct {
@@ -62,9 +310,16 @@ type UserSubscription struct {
 	IsActive     bool                  `bson:"isActive" json:"isActive"`
 	CustomPrompt string                `bson:"customPrompt,omitempty" json:"customPrompt,omitempty"`
 	Settings     *SubscriptionSettings `bson:"settings,omitempty" json:"settings,omitempty"`
+	// ExpiresAt is set for subscriptions created with a TTL; expired subscriptions are deactivated by a background job
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
 }
 
 type SubscriptionSettings struct {
 	Notifications bool `bson:"notifications" json:"notifications"`
 	AutoConnect   bool `bson:"autoConnect" json:"autoConnect"`
+	// Filters limit which feed-data events the subscriber receives, e.g.
+	// `data.symbol == "BTCUSDT"` or `data.price > 100`; all must match
+	Filters []string `bson:"filters,omitempty" json:"filters,omitempty"`
+	// Prompts is the subscriber's library of saved AI prompts for the feed
+	Prompts []string `bson:"prompts,omitempty" json:"prompts,omitempty"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeedAccessGrant lets a user read a private feed. Grants are keyed by email
+// so an owner can grant access before the user signs up.
+type FeedAccessGrant struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	FeedID    string             `bson:"feedId" json:"feedId"`
+	Email     string             `bson:"email" json:"email"` // lowercased
+	GrantedBy string             `bson:"grantedBy" json:"grantedBy"`
+	// InviteID is set when the grant came from redeeming an invite
+	InviteID  *primitive.ObjectID `bson:"inviteId,omitempty" json:"inviteId,omitempty"`
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// FeedInvite is a token the owner of a private feed hands out; whoever
+// redeems it is granted access
+type FeedInvite struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	FeedID string             `bson:"feedId" json:"feedId"`
+	// TokenHash is the SHA-256 of the token, which is only shown when the invite is created
+	TokenHash string     `bson:"tokenHash" json:"-"`
+	CreatedBy string     `bson:"createdBy" json:"createdBy"`
+	MaxUses   int        `bson:"maxUses" json:"maxUses"` // 0 allows any number of uses
+	Uses      int        `bson:"uses" json:"uses"`
+	Revoked   bool       `bson:"revoked" json:"revoked"`
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `bson:"createdAt" json:"createdAt"`
+}
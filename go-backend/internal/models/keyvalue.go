@@ -1,6 +1,51 @@
 package models
 
+import "strings"
+
+const (
+	// RedactedValue replaces feed secrets in API responses. An update that
+	// sends it back keeps the stored value.
+	RedactedValue = "[redacted]"
+	// SealedSecretPrefix marks a feed secret encrypted at rest
+	SealedSecretPrefix = "enc:v1:"
+)
+
+// sensitiveKeyParts mark header and query param names that carry credentials
+var sensitiveKeyParts = []string{"auth", "key", "token", "secret", "password", "passwd", "cookie", "signature", "credential", "session"}
+
 type KeyValue struct {
 	Key   string `bson:"key" json:"key"`
 	Value string `bson:"value" json:"value"`
 }
+
+// SensitiveKey reports whether a header or query param named key carries a
+// credential, such as Authorization, X-API-Key or access_token
+func SensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSealedSecret reports whether a value was encrypted at rest
+func IsSealedSecret(value string) bool {
+	return strings.HasPrefix(value, SealedSecretPrefix)
+}
+
+// redactKeyValues returns a copy of items with credential values redacted
+func redactKeyValues(items []KeyValue) []KeyValue {
+	if items == nil {
+		return nil
+	}
+	out := make([]KeyValue, len(items))
+	for i, kv := range items {
+		if kv.Value != "" && (SensitiveKey(kv.Key) || IsSealedSecret(kv.Value)) {
+			kv.Value = RedactedValue
+		}
+		out[i] = kv
+	}
+	return out
+}
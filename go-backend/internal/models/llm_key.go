@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserLLMKey is a user's own API key for an LLM provider, used instead of
+// the server's key for their queries
+type UserLLMKey struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID   string             `bson:"userId" json:"userId"`
+	Provider string             `bson:"provider" json:"provider"`
+	// EncryptedKey is the AES-GCM sealed key, nonce first; it is never returned
+	EncryptedKey []byte    `bson:"encryptedKey" json:"-"`
+	KeyHint      string    `bson:"keyHint" json:"keyHint"`       // last characters of the key, to tell keys apart
+	Model        string    `bson:"model,omitempty" json:"model"` // empty uses the server's model for the provider
+	CreatedAt    time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time `bson:"updatedAt" json:"updatedAt"`
+}
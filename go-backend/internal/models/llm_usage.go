@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LLMUsage is one entry of the LLM usage ledger: a single answered query
+type LLMUsage struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID           string             `bson:"userId" json:"userId"`
+	FeedID           string             `bson:"feedId" json:"feedId"`
+	FeedName         string             `bson:"feedName,omitempty" json:"feedName,omitempty"`
+	Provider         string             `bson:"provider" json:"provider"`
+	Mode             string             `bson:"mode" json:"mode"` // "query", "stream", "analyze" or "scheduled"
+	PromptTokens     int                `bson:"promptTokens" json:"promptTokens"`
+	CompletionTokens int                `bson:"completionTokens" json:"completionTokens"`
+	LatencyMs        int64              `bson:"latencyMs" json:"latencyMs"`
+	// CostUSD is estimated from list prices; providers bill separately
+	CostUSD   float64   `bson:"costUsd" json:"costUsd"`
+	Cached    bool      `bson:"cached" json:"cached"`
+	OwnKey    bool      `bson:"ownKey" json:"ownKey"` // called with the user's own provider key
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// LLMUsageGroup totals the ledger entries sharing a feed, day or provider
+type LLMUsageGroup struct {
+	Key              string  `bson:"_id" json:"key"`
+	FeedName         string  `bson:"feedName,omitempty" json:"feedName,omitempty"`
+	Requests         int     `bson:"requests" json:"requests"`
+	CachedRequests   int     `bson:"cachedRequests" json:"cachedRequests"`
+	PromptTokens     int     `bson:"promptTokens" json:"promptTokens"`
+	CompletionTokens int     `bson:"completionTokens" json:"completionTokens"`
+	TotalTokens      int     `bson:"totalTokens" json:"totalTokens"`
+	CostUSD          float64 `bson:"costUsd" json:"costUsd"`
+	AvgLatencyMs     float64 `bson:"avgLatencyMs" json:"avgLatencyMs"`
+}
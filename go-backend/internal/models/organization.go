@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization roles. Owners and admins manage the organization's feeds and
+// members, though only owners may make or remove owners; members read the
+// organization's private feeds.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// ValidOrgRole reports whether role is one of the organization roles
+func ValidOrgRole(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin || role == OrgRoleMember
+}
+
+// OrgRoleManages reports whether role may manage the organization's feeds and members
+func OrgRoleManages(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin
+}
+
+// Organization is a team that owns feeds together
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	Name      string             `bson:"name" json:"name"`
+	CreatedBy string             `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// Role is the caller's role when listing their organizations
+	Role string `bson:"-" json:"role,omitempty"`
+}
+
+// OrgMember gives an email a role in an organization. Members are keyed by
+// email so people can be invited before they sign up.
+type OrgMember struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	OrgID     string             `bson:"orgId" json:"orgId"`
+	Email     string             `bson:"email" json:"email"` // lowercased
+	Role      string             `bson:"role" json:"role"`
+	InvitedBy string             `bson:"invitedBy" json:"invitedBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -31,18 +31,60 @@ type BackupCode struct {
 	UsedAt time.Time `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
 }
 
+// User roles. Admins moderate any feed and manage users, curators edit
+// categories; a user without a role is a regular user.
+const (
+	RoleAdmin   = "admin"
+	RoleCurator = "curator"
+	RoleUser    = "user"
+)
+
 type User struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
-	Email           string             `bson:"email" json:"email"`
-	Password        string             `bson:"password" json:"-"`
-	Name            string             `bson:"name" json:"name"`
-	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
-	LastLogin       *time.Time         `bson:"lastLogin,omitempty" json:"lastLogin,omitempty"`
-	TokenUsage      *TokenUsage        `bson:"tokenUsage,omitempty" json:"tokenUsage,omitempty"`
-	Preferences     *UserPreferences   `bson:"preferences,omitempty" json:"preferences,omitempty"`
-	TwoFactor       bool               `bson:"twoFactorEnabled,omitempty" json:"twoFactorEnabled"`
-	TwoFactorSecret string             `bson:"twoFactorSecret,omitempty" json:"-"`
-	BackupCodes     []BackupCode       `bson:"backupCodes,omitempty" json:"backupCodes,omitempty"`
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	Email string             `bson:"email" json:"email"`
+	// EmailVerified is set once the user follows the verification email, resets
+	// their password or signs in with an OAuth provider that verified the address
+	EmailVerified   bool             `bson:"emailVerified,omitempty" json:"emailVerified"`
+	Password        string           `bson:"password" json:"-"`
+	Name            string           `bson:"name" json:"name"`
+	CreatedAt       time.Time        `bson:"createdAt" json:"createdAt"`
+	LastLogin       *time.Time       `bson:"lastLogin,omitempty" json:"lastLogin,omitempty"`
+	TokenUsage      *TokenUsage      `bson:"tokenUsage,omitempty" json:"tokenUsage,omitempty"`
+	Preferences     *UserPreferences `bson:"preferences,omitempty" json:"preferences,omitempty"`
+	TwoFactor       bool             `bson:"twoFactorEnabled,omitempty" json:"twoFactorEnabled"`
+	TwoFactorSecret string           `bson:"twoFactorSecret,omitempty" json:"-"`
+	BackupCodes     []BackupCode     `bson:"backupCodes,omitempty" json:"backupCodes,omitempty"`
+	OAuthAccounts   []OAuthAccount   `bson:"oauthAccounts,omitempty" json:"oauthAccounts,omitempty"`
+	Role            string           `bson:"role,omitempty" json:"role"`
+	// TokenQuotaOverride replaces the configured monthly token quota when set by an admin
+	TokenQuotaOverride *int64 `bson:"tokenQuotaOverride,omitempty" json:"tokenQuotaOverride,omitempty"`
+}
+
+// HasRole reports whether the user has one of roles; an empty role counts as RoleUser
+func (u User) HasRole(roles ...string) bool {
+	role := u.Role
+	if role == "" {
+		role = RoleUser
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleCurator || role == RoleUser
+}
+
+// OAuthAccount links a user to an account at an OAuth provider
+type OAuthAccount struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"-"` // the provider's stable user ID
+	Email    string    `bson:"email" json:"email"`
+	LinkedAt time.Time `bson:"linkedAt" json:"linkedAt"`
 }
 
 type UserSession struct {
@@ -56,6 +98,31 @@ type UserSession struct {
 	IsActive     bool               `bson:"isActive" json:"isActive"`
 	DeviceName   string             `bson:"deviceName,omitempty" json:"deviceName,omitempty"`
 	DeviceType   string             `bson:"deviceType,omitempty" json:"deviceType,omitempty"`
+	// Refresh tokens are stored as SHA-256 hashes and rotated on every use;
+	// the previous hash is kept to detect a rotated token being replayed
+	RefreshTokenHash     string    `bson:"refreshTokenHash,omitempty" json:"-"`
+	PrevRefreshTokenHash string    `bson:"prevRefreshTokenHash,omitempty" json:"-"`
+	ExpiresAt            time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+}
+
+// Purposes of an EmailToken
+const (
+	EmailTokenVerify = "verify_email"
+	EmailTokenReset  = "reset_password"
+)
+
+// EmailToken is a single-use token sent by email to verify an address or
+// reset a password. Only its SHA-256 hash is stored.
+type EmailToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Email     string             `bson:"email" json:"email"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	// FailedAttempts counts wrong second factors given with a reset token
+	FailedAttempts int       `bson:"failedAttempts,omitempty" json:"failedAttempts,omitempty"`
+	CreatedAt      time.Time `bson:"createdAt" json:"createdAt"`
+	ExpiresAt      time.Time `bson:"expiresAt" json:"expiresAt"`
 }
 
 type LoginActivity struct {
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebAuthnCredential is a security key or passkey registered as a second
+// factor. Each device a user registers is stored separately.
+type WebAuthnCredential struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID primitive.ObjectID `bson:"userId" json:"userId"`
+	Name   string             `bson:"name" json:"name"`
+	// CredentialID is the authenticator's credential ID, base64url encoded
+	CredentialID string `bson:"credentialId" json:"credentialId"`
+	// PublicKey is the credential's COSE encoded public key
+	PublicKey  []byte     `bson:"publicKey" json:"-"`
+	Algorithm  int64      `bson:"algorithm" json:"algorithm"`
+	SignCount  uint32     `bson:"signCount" json:"signCount"`
+	AAGUID     string     `bson:"aaguid,omitempty" json:"aaguid,omitempty"`
+	Transports []string   `bson:"transports,omitempty" json:"transports,omitempty"`
+	CreatedAt  time.Time  `bson:"createdAt" json:"createdAt"`
+	LastUsedAt *time.Time `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+}
+
+// Purposes of a WebAuthnChallenge
+const (
+	WebAuthnChallengeRegister = "register"
+	WebAuthnChallengeLogin    = "login"
+	// WebAuthnChallengeReset answers a password reset
+	WebAuthnChallengeReset = "reset"
+	// WebAuthnChallengeConfirm re-confirms a signed-in user before a
+	// sensitive account action
+	WebAuthnChallengeConfirm = "confirm"
+)
+
+// WebAuthnChallenge is a single-use challenge handed to the browser for one
+// registration, sign-in or confirmation. Only its SHA-256 hash is stored.
+type WebAuthnChallenge struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID        primitive.ObjectID `bson:"userId" json:"userId"`
+	Purpose       string             `bson:"purpose" json:"purpose"`
+	ChallengeHash string             `bson:"challengeHash" json:"-"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt     time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
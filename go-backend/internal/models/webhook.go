@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook is an HTTPS endpoint a user registered to receive a feed's events
+type Webhook struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID string             `bson:"userId" json:"userId"`
+	FeedID string             `bson:"feedId" json:"feedId"`
+	URL    string             `bson:"url" json:"url"`
+	// Secret signs each delivery with HMAC-SHA256; it is only shown when the webhook is created
+	Secret    string    `bson:"secret" json:"-"`
+	Events    []string  `bson:"events" json:"events"` // "feed-data", "feed-alert" and/or "feed-health"
+	Enabled   bool      `bson:"enabled" json:"enabled"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// WebhookDelivery records the outcome of sending one event to a webhook,
+// after any retries
+type WebhookDelivery struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	WebhookID   primitive.ObjectID `bson:"webhookId" json:"webhookId"`
+	FeedID      string             `bson:"feedId" json:"feedId"`
+	Event       string             `bson:"event" json:"event"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	Success     bool               `bson:"success" json:"success"`
+	StatusCode  int                `bson:"statusCode,omitempty" json:"statusCode,omitempty"` // from the last attempt
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	CompletedAt time.Time          `bson:"completedAt" json:"completedAt"`
+	ExpiresAt   time.Time          `bson:"expiresAt" json:"-"`
+}
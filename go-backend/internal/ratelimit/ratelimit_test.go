@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_DisabledIsNil(t *testing.T) {
+	assert.Nil(t, New(0, 10))
+	var l *Limiter
+	ok, wait := l.Allow("anyone")
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := New(60, 2) // one token a second, bursts of two
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+	ok, wait := l.Allow("a")
+	assert.False(t, ok, "burst used up")
+	assert.Equal(t, time.Second, wait)
+
+	ok, _ = l.Allow("b")
+	assert.True(t, ok, "keys have separate buckets")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, wait = l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok, "refilled after a second")
+
+	now = now.Add(time.Hour)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok, "refill is capped at the burst")
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiter_SweepsIdleBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := New(60, 5)
+	l.now = func() time.Time { return now }
+
+	l.Allow("idle")
+	now = now.Add(2 * time.Minute)
+	l.Allow("active")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert.NotContains(t, l.buckets, "idle")
+	assert.Contains(t, l.buckets, "active")
+}
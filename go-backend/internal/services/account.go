@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// AccountExport is every record kept about a user, as returned by
+// GET /api/auth/export. Secrets such as password and key hashes are left out.
+type AccountExport struct {
+	ExportedAt        time.Time                   `json:"exportedAt"`
+	User              models.User                 `json:"user"`
+	Sessions          []models.UserSession        `json:"sessions"`
+	LoginActivity     []models.LoginActivity      `json:"loginActivity"`
+	APIKeys           []models.APIKey             `json:"apiKeys"`
+	SecurityKeys      []models.WebAuthnCredential `json:"securityKeys"`
+	Feeds             []models.WebSocketFeed      `json:"feeds"`
+	Subscriptions     []models.UserSubscription   `json:"subscriptions"`
+	AlertRules        []models.AlertRule          `json:"alertRules"`
+	Webhooks          []models.Webhook            `json:"webhooks"`
+	AccessGrants      []models.FeedAccessGrant    `json:"accessGrants"` // grants to the user's email
+	OrgMemberships    []models.OrgMember          `json:"orgMemberships"`
+	LLMKeys           []models.UserLLMKey         `json:"llmKeys"`
+	LLMUsage          []models.LLMUsage           `json:"llmUsage"`
+	AnalysisSchedules []models.AnalysisSchedule   `json:"analysisSchedules"`
+	Analyses          []models.Analysis           `json:"analyses"`
+	Conversations     []models.Conversation       `json:"conversations"`
+	AuditEvents       []models.AuditEvent         `json:"auditEvents"` // actions the user took
+}
+
+// AccountDeletion reports what deleting an account removed
+type AccountDeletion struct {
+	// FeedIDs are the user's feeds, which callers should disconnect
+	FeedIDs []string `json:"feedIds"`
+	// Deleted counts the documents removed from each collection
+	Deleted map[string]int64 `json:"deleted"`
+}
+
+// AccountService exports and deletes everything stored about a user
+type AccountService struct {
+	db          *mongo.Database
+	store       Store
+	marketplace *MarketplaceService
+}
+
+// NewAccountService creates the account service; marketplace keeps the
+// subscriber counts of other users' feeds right when a subscriber is deleted
+func NewAccountService(db *mongo.Database, marketplace *MarketplaceService) *AccountService {
+	return &AccountService{db: db, store: NewMongoStore(db), marketplace: marketplace}
+}
+
+// SetStore exports and deletes users, feeds, subscriptions, sessions and
+// usage in the store's repositories
+func (s *AccountService) SetStore(store Store) {
+	s.store = store
+}
+
+// ConfirmIdentity checks a user's password, and their second factor when
+// they have one, before a destructive or sensitive account action: a TOTP or
+// backup code, or a security key assertion answering a
+// WebAuthnChallengeConfirm challenge. Accounts without a password (signed up
+// with OAuth) confirm by typing their email instead.
+func (s *AuthService) ConfirmIdentity(ctx context.Context, userID primitive.ObjectID, password, email, totpCode string, assertion *WebAuthnAssertion) (models.User, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.Password != "" {
+		if password == "" {
+			return models.User{}, ErrPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			return models.User{}, ErrIncorrectPassword
+		}
+	} else if !strings.EqualFold(strings.TrimSpace(email), user.Email) {
+		return models.User{}, ErrEmailConfirmation
+	}
+	if s.secondFactorRequired(ctx, user) {
+		if err := s.verifySecondFactor(ctx, user, totpCode, assertion, models.WebAuthnChallengeConfirm); err != nil {
+			return user, err
+		}
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// ownFeeds returns the user's feeds, including deleted ones not yet purged
+func (s *AccountService) ownFeeds(ctx context.Context, userID string) ([]models.WebSocketFeed, error) {
+	feeds, err := s.store.Feeds.List(ctx, FeedFilter{OwnerID: userID}, FeedSortRecent, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.store.Feeds.List(ctx, FeedFilter{OwnerID: userID, Deleted: true}, FeedSortRecent, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(feeds, deleted...), nil
+}
+
+// exportAll decodes every document of a collection matching filter into out
+func (s *AccountService) exportAll(ctx context.Context, collection string, filter bson.M, out interface{}) error {
+	cur, err := s.db.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	return cur.All(ctx, out)
+}
+
+// Export gathers the user's records from every collection
+func (s *AccountService) Export(ctx context.Context, user models.User) (AccountExport, error) {
+	user.Password = ""
+	user.TwoFactorSecret = ""
+	user.BackupCodes = nil
+	export := AccountExport{ExportedAt: time.Now().UTC(), User: user}
+	uid, hex := user.ID, user.ID.Hex()
+	var err error
+	if export.Sessions, err = s.store.Sessions.ListByUser(ctx, uid); err != nil {
+		return AccountExport{}, err
+	}
+	if export.Feeds, err = s.ownFeeds(ctx, hex); err != nil {
+		return AccountExport{}, err
+	}
+	if export.Subscriptions, err = s.store.Subscriptions.ListByUser(ctx, hex); err != nil {
+		return AccountExport{}, err
+	}
+	if export.LLMUsage, err = s.store.Usage.ListByUser(ctx, hex); err != nil {
+		return AccountExport{}, err
+	}
+	for _, q := range []struct {
+		collection string
+		filter     bson.M
+		out        interface{}
+	}{
+		{"login_activity", bson.M{"userId": uid}, &export.LoginActivity},
+		{"api_keys", bson.M{"userId": uid}, &export.APIKeys},
+		{"webauthn_credentials", bson.M{"userId": uid}, &export.SecurityKeys},
+		{"alert_rules", bson.M{"userId": hex}, &export.AlertRules},
+		{"webhooks", bson.M{"userId": hex}, &export.Webhooks},
+		{"feed_access_grants", bson.M{"email": user.Email}, &export.AccessGrants},
+		{"organization_members", bson.M{"email": user.Email}, &export.OrgMemberships},
+		{"user_llm_keys", bson.M{"userId": hex}, &export.LLMKeys},
+		{"analysis_schedules", bson.M{"userId": hex}, &export.AnalysisSchedules},
+		{"analyses", bson.M{"userId": hex}, &export.Analyses},
+		{"conversations", bson.M{"userId": hex}, &export.Conversations},
+		{"audit_events", bson.M{"actorId": hex}, &export.AuditEvents},
+	} {
+		if err := s.exportAll(ctx, q.collection, q.filter, q.out); err != nil {
+			return AccountExport{}, err
+		}
+	}
+	return export, nil
+}
+
+// Delete removes the user and everything they own: their feeds with the
+// subscriptions, rules, webhooks, grants, invites and history attached to
+// them, and the user's sessions, keys, subscriptions, usage, analyses and
+// conversations. Audit events are kept until they expire. The user record
+// goes last, so a delete that fails part way can be retried.
+func (s *AccountService) Delete(ctx context.Context, user models.User) (AccountDeletion, error) {
+	uid, hex := user.ID, user.ID.Hex()
+	result := AccountDeletion{FeedIDs: []string{}, Deleted: map[string]int64{}}
+
+	// Leave other users' feeds first so their subscriber counts drop
+	subs, err := s.store.Subscriptions.ListByUser(ctx, hex)
+	if err != nil {
+		return result, err
+	}
+	for _, sub := range subs {
+		if !sub.IsActive {
+			continue
+		}
+		if err := s.marketplace.Unsubscribe(ctx, hex, sub.FeedID); err != nil {
+			return result, err
+		}
+	}
+
+	feeds, err := s.ownFeeds(ctx, hex)
+	if err != nil {
+		return result, err
+	}
+	for _, feed := range feeds {
+		result.FeedIDs = append(result.FeedIDs, feed.ID.Hex())
+	}
+	ownFeeds := bson.M{"feedId": bson.M{"$in": result.FeedIDs}}
+
+	var webhooks []models.Webhook
+	if err := s.exportAll(ctx, "webhooks", bson.M{"$or": []bson.M{{"userId": hex}, ownFeeds}}, &webhooks); err != nil {
+		return result, err
+	}
+	webhookIDs := make([]primitive.ObjectID, 0, len(webhooks))
+	for _, w := range webhooks {
+		webhookIDs = append(webhookIDs, w.ID)
+	}
+
+	for _, d := range []struct {
+		collection string
+		filter     bson.M
+	}{
+		{"webhook_deliveries", bson.M{"webhookId": bson.M{"$in": webhookIDs}}},
+		{"webhooks", bson.M{"_id": bson.M{"$in": webhookIDs}}},
+		{"alert_rules", bson.M{"$or": []bson.M{{"userId": hex}, ownFeeds}}},
+		{"feed_access_grants", bson.M{"$or": []bson.M{{"email": user.Email}, ownFeeds}}},
+		{"feed_invites", bson.M{"$or": []bson.M{{"createdBy": hex}, ownFeeds}}},
+		{"organization_members", bson.M{"email": user.Email}},
+		{"feed_data", ownFeeds},
+		{"llm_feed_contexts", bson.M{"_id": bson.M{"$in": result.FeedIDs}}},
+		{"analysis_schedules", bson.M{"$or": []bson.M{{"userId": hex}, ownFeeds}}},
+		{"analyses", bson.M{"$or": []bson.M{{"userId": hex}, ownFeeds}}},
+		{"conversations", bson.M{"$or": []bson.M{{"userId": hex}, ownFeeds}}},
+		{"user_llm_keys", bson.M{"userId": hex}},
+		{"api_keys", bson.M{"userId": uid}},
+		{"email_tokens", bson.M{"userId": uid}},
+		{"webauthn_credentials", bson.M{"userId": uid}},
+		{"webauthn_challenges", bson.M{"userId": uid}},
+		{"login_activity", bson.M{"userId": uid}},
+	} {
+		res, err := s.db.Collection(d.collection).DeleteMany(ctx, d.filter)
+		if err != nil {
+			return result, err
+		}
+		if res.DeletedCount > 0 {
+			result.Deleted[d.collection] += res.DeletedCount
+		}
+	}
+	for _, d := range []struct {
+		collection string
+		delete     func() (int64, error)
+	}{
+		{"user_subscriptions", func() (int64, error) { return s.store.Subscriptions.DeleteByUser(ctx, hex, result.FeedIDs) }},
+		{"llm_usage", func() (int64, error) { return s.store.Usage.DeleteByUser(ctx, hex) }},
+		{"sessions", func() (int64, error) { return s.store.Sessions.DeleteByUser(ctx, uid) }},
+		{"websocket_feeds", func() (int64, error) { return s.store.Feeds.DeleteByOwner(ctx, hex) }},
+		{"users", func() (int64, error) { return s.store.Users.Delete(ctx, uid) }},
+	} {
+		n, err := d.delete()
+		if err != nil {
+			return result, err
+		}
+		if n > 0 {
+			result.Deleted[d.collection] += n
+		}
+	}
+	return result, nil
+}
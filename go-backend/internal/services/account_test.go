@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestAuthService_ConfirmIdentity(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "confirm@example.com", "password", "Confirm Test")
+	require.NoError(t, err)
+
+	_, err = service.ConfirmIdentity(ctx, user.ID, "", "", "", nil)
+	assert.ErrorIs(t, err, ErrPasswordRequired)
+	_, err = service.ConfirmIdentity(ctx, user.ID, "wrong", "", "", nil)
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	confirmed, err := service.ConfirmIdentity(ctx, user.ID, "password", "", "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, confirmed.Password)
+
+	// Accounts without a password confirm with their email
+	err = service.users.Update(ctx, user.ID, bson.M{"password": ""})
+	require.NoError(t, err)
+	_, err = service.ConfirmIdentity(ctx, user.ID, "", "other@example.com", "", nil)
+	assert.ErrorIs(t, err, ErrEmailConfirmation)
+	_, err = service.ConfirmIdentity(ctx, user.ID, "", "Confirm@Example.com", "", nil)
+	require.NoError(t, err)
+}
+
+func TestAccountService_ExportAndDelete(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	marketplace := NewMarketplaceService(service.db)
+	accounts := NewAccountService(service.db, marketplace)
+
+	_, owner, err := service.Register(ctx, "owner@example.com", "password", "Owner")
+	require.NoError(t, err)
+	_, other, err := service.Register(ctx, "other@example.com", "password", "Other")
+	require.NoError(t, err)
+
+	ownFeed, err := marketplace.CreateFeed(ctx, models.WebSocketFeed{Name: "Own", OwnerID: owner.ID.Hex(), IsPublic: true})
+	require.NoError(t, err)
+	otherFeed, err := marketplace.CreateFeed(ctx, models.WebSocketFeed{Name: "Other", OwnerID: other.ID.Hex(), IsPublic: true})
+	require.NoError(t, err)
+	_, err = marketplace.Subscribe(ctx, other.ID.Hex(), ownFeed.ID.Hex(), "")
+	require.NoError(t, err)
+	_, err = marketplace.Subscribe(ctx, owner.ID.Hex(), otherFeed.ID.Hex(), "")
+	require.NoError(t, err)
+
+	export, err := accounts.Export(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, owner.Email, export.User.Email)
+	assert.Len(t, export.Feeds, 1)
+	assert.Len(t, export.Subscriptions, 1)
+	assert.NotEmpty(t, export.Sessions)
+
+	deletion, err := accounts.Delete(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ownFeed.ID.Hex()}, deletion.FeedIDs)
+	assert.Equal(t, int64(1), deletion.Deleted["users"])
+
+	_, err = service.GetUser(ctx, owner.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = marketplace.GetFeedByID(ctx, ownFeed.ID.Hex())
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+	// Subscribers of the deleted feed lose the subscription, and feeds the
+	// deleted user subscribed to lose a subscriber
+	_, err = marketplace.GetSubscription(ctx, other.ID.Hex(), ownFeed.ID.Hex())
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	remaining, err := marketplace.GetFeedByID(ctx, otherFeed.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 0, remaining.SubscriberCount)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// EnsureAdmins gives the admin role to the users whose verified emails are
+// listed in ADMIN_EMAILS. Anyone can register an address they do not own, so
+// an unverified one grants nothing.
+func (s *AuthService) EnsureAdmins(ctx context.Context) (int64, error) {
+	if len(s.cfg.AdminEmails) == 0 {
+		return 0, nil
+	}
+	return s.users.PromoteAdmins(ctx, s.cfg.AdminEmails)
+}
+
+// promoteIfAdmin gives the admin role to a user whose email has just been
+// verified if it is listed in ADMIN_EMAILS, and returns the user
+func (s *AuthService) promoteIfAdmin(ctx context.Context, user models.User) (models.User, error) {
+	if !user.EmailVerified || user.Role == models.RoleAdmin || !s.isAdminEmail(user.Email) {
+		return user, nil
+	}
+	if err := s.users.Update(ctx, user.ID, bson.M{"role": models.RoleAdmin}); err != nil {
+		return user, err
+	}
+	user.Role = models.RoleAdmin
+	return user, nil
+}
+
+// isAdminEmail reports whether email is listed in ADMIN_EMAILS
+func (s *AuthService) isAdminEmail(email string) bool {
+	for _, admin := range s.cfg.AdminEmails {
+		if admin == email {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserRole returns the user's role, RoleUser when none is stored
+func (s *AuthService) GetUserRole(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.Role == "" {
+		return models.RoleUser, nil
+	}
+	return user.Role, nil
+}
+
+// ListUsers returns users newest first, optionally filtered by a case-insensitive
+// match on email or name
+func (s *AuthService) ListUsers(ctx context.Context, search string, limit, skip int64) ([]models.User, error) {
+	return s.users.List(ctx, search, limit, skip)
+}
+
+// SetRole changes a user's role
+func (s *AuthService) SetRole(ctx context.Context, userID primitive.ObjectID, role string) (*models.User, error) {
+	if !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if err := s.users.Update(ctx, userID, bson.M{"role": role}); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, userID)
+}
+
+// SetTokenQuota overrides the user's monthly token quota; nil returns the
+// user to the configured TOKEN_QUOTA_PER_MONTH
+func (s *AuthService) SetTokenQuota(ctx context.Context, userID primitive.ObjectID, quota *int64) (*models.User, error) {
+	var err error
+	if quota == nil {
+		err = s.users.Update(ctx, userID, nil, "tokenQuotaOverride")
+	} else {
+		if *quota < 0 {
+			return nil, ErrInvalidTokenQuota
+		}
+		err = s.users.Update(ctx, userID, bson.M{"tokenQuotaOverride": *quota})
+	}
+	if err != nil {
+		return nil, err
+	}
+	// GetUser brings tokenUsage.limit in line with the new quota
+	return s.GetUser(ctx, userID)
+}
+
+// tokenLimit is the user's monthly token quota: the admin override when set,
+// otherwise TOKEN_QUOTA_PER_MONTH
+func (s *AuthService) tokenLimit(user models.User) int64 {
+	if user.TokenQuotaOverride != nil {
+		return *user.TokenQuotaOverride
+	}
+	return s.cfg.TokenQuotaPerMonth
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestTokenLimit(t *testing.T) {
+	s := &AuthService{cfg: config.Config{TokenQuotaPerMonth: 1000}}
+	override := int64(5000)
+	zero := int64(0)
+
+	assert.Equal(t, int64(1000), s.tokenLimit(models.User{}), "configured quota without an override")
+	assert.Equal(t, int64(5000), s.tokenLimit(models.User{TokenQuotaOverride: &override}))
+	assert.Equal(t, int64(0), s.tokenLimit(models.User{TokenQuotaOverride: &zero}), "a zero override blocks usage")
+}
+
+func TestAdminEmailNeedsVerification(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := &AuthService{cfg: config.Config{JWTSecret: "test-secret", AdminEmails: []string{"root@example.com"}}}
+	s.SetStore(store)
+
+	// Registering the address is not proof of owning it
+	_, user, err := s.Register(ctx, "root@example.com", "password", "Root")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, user.Role)
+	n, err := s.EnsureAdmins(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	user, err = s.promoteIfAdmin(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, user.Role)
+	stored, err := store.Users.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, stored.Role, "an unverified admin email stays a user")
+
+	verified, err := store.Users.VerifyEmail(ctx, user.ID, user.Email)
+	require.NoError(t, err)
+	promoted, err := s.promoteIfAdmin(ctx, verified)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, promoted.Role)
+	stored, err = store.Users.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, stored.Role)
+
+	// Other verified emails are left alone
+	_, other, err := s.Register(ctx, "someone@example.com", "password", "Someone")
+	require.NoError(t, err)
+	other, err = store.Users.VerifyEmail(ctx, other.ID, other.Email)
+	require.NoError(t, err)
+	other, err = s.promoteIfAdmin(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, other.Role)
+}
+
+func TestAuthService_RolesAndQuota(t *testing.T) {
+	client, db, cleanup := setupTestDB(t)
+	if client == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+	ctx := context.Background()
+	service := NewAuthService(config.Config{
+		JWTSecret:          "test-secret-key-for-testing-only",
+		TokenQuotaPerMonth: 1000,
+		AdminEmails:        []string{"admin@example.com"},
+	}, client, db)
+
+	_, admin, err := service.Register(ctx, "admin@example.com", "password123", "Admin")
+	require.NoError(t, err)
+	_, user, err := service.Register(ctx, "user@example.com", "password123", "User")
+	require.NoError(t, err)
+
+	role, err := service.GetUserRole(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, role, "the admin email is not verified yet")
+	token, err := service.issueEmailToken(ctx, admin, models.EmailTokenVerify, emailVerificationTTL)
+	require.NoError(t, err)
+	verified, err := service.VerifyEmail(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, verified.Role)
+	role, err = service.GetUserRole(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+
+	updated, err := service.SetRole(ctx, user.ID, models.RoleCurator)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleCurator, updated.Role)
+	_, err = service.SetRole(ctx, user.ID, "owner")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = service.SetRole(ctx, primitive.NewObjectID(), models.RoleUser)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	quota := int64(5000)
+	updated, err = service.SetTokenQuota(ctx, user.ID, &quota)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), updated.TokenUsage.Limit)
+	updated, err = service.SetTokenQuota(ctx, user.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), updated.TokenUsage.Limit, "clearing the override restores the configured quota")
+
+	users, err := service.ListUsers(ctx, "USER@", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+	assert.Empty(t, users[0].Password)
+}
+
+func TestCheckTokenQuota(t *testing.T) {
+	usage := models.TokenUsage{TokensUsed: 900, Limit: 1000}
+
+	quota, err := checkTokenQuota(usage, 100)
+	require.NoError(t, err, "a call that exactly reaches the limit is allowed")
+	assert.Equal(t, TokenQuota{TokensUsed: 900, Limit: 1000, Remaining: 100, EstimatedTokens: 100}, quota)
+
+	quota, err = checkTokenQuota(usage, 101)
+	assert.ErrorIs(t, err, ErrTokenQuotaExceeded)
+	assert.Equal(t, int64(100), quota.Remaining)
+
+	usage.OverdraftAllowed = true
+	_, err = checkTokenQuota(usage, 101)
+	assert.NoError(t, err, "overdraft lets usage pass the limit")
+
+	quota, err = checkTokenQuota(models.TokenUsage{TokensUsed: 1200, Limit: 1000}, 1)
+	assert.ErrorIs(t, err, ErrTokenQuotaExceeded)
+	assert.Zero(t, quota.Remaining)
+}
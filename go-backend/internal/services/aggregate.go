@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// describeAggregates renders a feed's latest rollups for a system prompt,
+// shortest window first
+func describeAggregates(aggs []models.FeedAggregate) string {
+	if len(aggs) == 0 {
+		return ""
+	}
+	sort.Slice(aggs, func(i, j int) bool {
+		return aggs[i].End.Sub(aggs[i].Start) < aggs[j].End.Sub(aggs[j].Start)
+	})
+
+	var b strings.Builder
+	b.WriteString("Latest rollups of this feed's numeric fields (open/high/low/close, average):\n")
+	for _, agg := range aggs {
+		fmt.Fprintf(&b, "- %s window ending %s, %d messages", agg.Window, agg.End.UTC().Format(time.RFC3339), agg.Count)
+		fields := make([]string, 0, len(agg.Fields))
+		for field := range agg.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			s := agg.Fields[field]
+			fmt.Fprintf(&b, "; %s %s/%s/%s/%s, avg %s", field,
+				formatRollup(s.Open), formatRollup(s.Max), formatRollup(s.Min), formatRollup(s.Close), formatRollup(s.Avg))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatRollup prints a rollup value in its shortest exact form
+func formatRollup(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestLLMService_WithFeedAggregates(t *testing.T) {
+	svc, err := NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "base", svc.withFeedAggregates("feed-1", "base"))
+
+	end := time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC)
+	svc.SetFeedAggregate(models.FeedAggregate{
+		FeedID: "feed-1", Window: "1m", Start: end.Add(-time.Minute), End: end, Count: 60,
+		Fields: map[string]models.AggregateStats{"price": {Count: 60, Min: 9, Max: 12.5, Avg: 10.25, Open: 10, Close: 11}},
+	})
+	svc.SetFeedAggregate(models.FeedAggregate{
+		FeedID: "feed-1", Window: "1s", Start: end.Add(-time.Second), End: end, Count: 2,
+		Fields: map[string]models.AggregateStats{"price": {Count: 2, Min: 11, Max: 11, Avg: 11, Open: 11, Close: 11}},
+	})
+
+	assert.Equal(t, "base\n\nLatest rollups of this feed's numeric fields (open/high/low/close, average):\n"+
+		"- 1s window ending 2024-01-01T12:01:00Z, 2 messages; price 11/11/11/11, avg 11\n"+
+		"- 1m window ending 2024-01-01T12:01:00Z, 60 messages; price 10/12.5/9/11, avg 10.25",
+		svc.withFeedAggregates("feed-1", "base"))
+
+	svc.ClearFeedAggregates("feed-1")
+	assert.Equal(t, "base", svc.withFeedAggregates("feed-1", "base"))
+}
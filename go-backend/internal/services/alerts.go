@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// maxAlertRulesPerSubscription caps how many rules a user can set on one feed
+	maxAlertRulesPerSubscription = 20
+	// maxAlertCooldownSeconds caps AlertRule.CooldownSeconds at one day
+	maxAlertCooldownSeconds = 24 * 60 * 60
+	// alertWebhookTimeout bounds one webhook delivery
+	alertWebhookTimeout = 10 * time.Second
+)
+
+// AlertService stores subscribers' alert rules and delivers triggered alerts
+// by email and webhook. Rules are evaluated by the socket manager.
+type AlertService struct {
+	db            *mongo.Database
+	subscriptions SubscriptionRepo
+	auth          *AuthService
+	email         *EmailService
+	webhook       *http.Client
+}
+
+// NewAlertService creates the alert service. Email is sent only when email is
+// configured; auth looks up the address to send to.
+func NewAlertService(db *mongo.Database, auth *AuthService, email *EmailService) *AlertService {
+	return &AlertService{
+		db:            db,
+		subscriptions: NewMongoSubscriptionRepo(db),
+		auth:          auth,
+		email:         email,
+		webhook:       &http.Client{Timeout: alertWebhookTimeout},
+	}
+}
+
+// SetStore reads subscriptions from the store's repository
+func (s *AlertService) SetStore(store Store) {
+	s.subscriptions = store.Subscriptions
+}
+
+// rules returns the MongoDB alert_rules collection
+func (s *AlertService) rules() *mongo.Collection {
+	return s.db.Collection("alert_rules")
+}
+
+// EnsureIndexes creates the indexes used to list a subscriber's rules and a feed's rules
+func (s *AlertService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.rules().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "feedId", Value: 1}}},
+		{Keys: bson.D{{Key: "feedId", Value: 1}, {Key: "enabled", Value: 1}}},
+	})
+	return err
+}
+
+// ValidateAlertRule checks the parts of a rule the service owns; conditions
+// are checked by the socket package, which evaluates them
+func ValidateAlertRule(rule models.AlertRule) error {
+	if strings.TrimSpace(rule.Condition) == "" {
+		return ErrAlertConditionRequired
+	}
+	if rule.CooldownSeconds < 0 || rule.CooldownSeconds > maxAlertCooldownSeconds {
+		return ErrInvalidAlertCooldown
+	}
+	if rule.WebhookURL != "" {
+		u, err := url.Parse(rule.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidWebhookURL
+		}
+	}
+	return nil
+}
+
+// CreateAlertRule stores a new rule on the user's subscription to a feed
+func (s *AlertService) CreateAlertRule(ctx context.Context, rule models.AlertRule) (*models.AlertRule, error) {
+	if err := ValidateAlertRule(rule); err != nil {
+		return nil, err
+	}
+	count, err := s.rules().CountDocuments(ctx, bson.M{"userId": rule.UserID, "feedId": rule.FeedID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAlertRulesPerSubscription {
+		return nil, ErrTooManyAlertRules
+	}
+	now := time.Now()
+	rule.ID = primitive.NilObjectID
+	rule.LastTriggeredAt = nil
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	res, err := s.rules().InsertOne(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = res.InsertedID.(primitive.ObjectID)
+	return &rule, nil
+}
+
+// ListAlertRules returns the user's rules for a feed, oldest first
+func (s *AlertService) ListAlertRules(ctx context.Context, userID, feedID string) ([]models.AlertRule, error) {
+	cur, err := s.rules().Find(ctx, bson.M{"userId": userID, "feedId": feedID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	rules := []models.AlertRule{}
+	if err := cur.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetAlertRule returns one of the user's rules for a feed
+func (s *AlertService) GetAlertRule(ctx context.Context, userID, feedID string, ruleID primitive.ObjectID) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := s.rules().FindOne(ctx, bson.M{"_id": ruleID, "userId": userID, "feedId": feedID}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAlertRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ReplaceAlertRule saves an edited rule, keeping its owner, feed and history
+func (s *AlertService) ReplaceAlertRule(ctx context.Context, rule models.AlertRule) (*models.AlertRule, error) {
+	if err := ValidateAlertRule(rule); err != nil {
+		return nil, err
+	}
+	rule.UpdatedAt = time.Now()
+	var updated models.AlertRule
+	err := s.rules().FindOneAndUpdate(ctx,
+		bson.M{"_id": rule.ID, "userId": rule.UserID, "feedId": rule.FeedID},
+		bson.M{"$set": bson.M{
+			"name":            rule.Name,
+			"condition":       rule.Condition,
+			"cooldownSeconds": rule.CooldownSeconds,
+			"enabled":         rule.Enabled,
+			"notifyEmail":     rule.NotifyEmail,
+			"webhookUrl":      rule.WebhookURL,
+			"updatedAt":       rule.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAlertRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteAlertRule removes one of the user's rules for a feed
+func (s *AlertService) DeleteAlertRule(ctx context.Context, userID, feedID string, ruleID primitive.ObjectID) error {
+	res, err := s.rules().DeleteOne(ctx, bson.M{"_id": ruleID, "userId": userID, "feedId": feedID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+// FeedAlertRules returns a feed's enabled rules whose owners still have an
+// active subscription to it
+func (s *AlertService) FeedAlertRules(ctx context.Context, feedID string) ([]models.AlertRule, error) {
+	userIDs, err := s.subscriptions.ActiveUserIDs(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	cur, err := s.rules().Find(ctx, bson.M{"feedId": feedID, "enabled": true, "userId": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var rules []models.AlertRule
+	if err := cur.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// MarkAlertTriggered records when a rule last fired
+func (s *AlertService) MarkAlertTriggered(ctx context.Context, ruleID primitive.ObjectID, at time.Time) error {
+	_, err := s.rules().UpdateOne(ctx, bson.M{"_id": ruleID}, bson.M{"$set": bson.M{"lastTriggeredAt": at}})
+	return err
+}
+
+// Notify delivers a triggered alert by the rule's email and webhook options,
+// returning the first delivery error
+func (s *AlertService) Notify(ctx context.Context, rule models.AlertRule, alert models.FeedAlert) error {
+	var errs []error
+	if rule.NotifyEmail {
+		errs = append(errs, s.sendAlertEmail(ctx, rule, alert))
+	}
+	if rule.WebhookURL != "" {
+		errs = append(errs, s.postAlertWebhook(ctx, rule.WebhookURL, alert))
+	}
+	return errors.Join(errs...)
+}
+
+// sendAlertEmail emails the alert to the rule's owner when email is configured
+func (s *AlertService) sendAlertEmail(ctx context.Context, rule models.AlertRule, alert models.FeedAlert) error {
+	if !s.email.Enabled() || s.auth == nil {
+		return nil
+	}
+	userID, err := primitive.ObjectIDFromHex(rule.UserID)
+	if err != nil {
+		return err
+	}
+	user, err := s.auth.GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("alert email: %w", err)
+	}
+	return s.email.Send(ctx, alertEmail(user.Email, alert))
+}
+
+// alertEmail renders the email sent for an alert
+func alertEmail(to string, alert models.FeedAlert) Email {
+	title := alert.Name
+	if title == "" {
+		title = alert.Condition
+	}
+	feed := alert.FeedName
+	if feed == "" {
+		feed = alert.FeedID
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your alert on feed %s fired at %s.\r\n\r\nCondition: %s\r\n", feed, alert.TriggeredAt.UTC().Format(time.RFC3339), alert.Condition)
+	if alert.Data != nil {
+		if data, err := json.Marshal(alert.Data); err == nil {
+			fmt.Fprintf(&b, "Message: %s\r\n", data)
+		}
+	}
+	return Email{To: to, Subject: "TurboStream alert: " + title, Body: b.String(), Kind: EmailKindAlert}
+}
+
+// postAlertWebhook POSTs the alert as JSON to the rule's webhook
+func (s *AlertService) postAlertWebhook(ctx context.Context, webhookURL string, alert models.FeedAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.webhook.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestValidateAlertRule(t *testing.T) {
+	assert.NoError(t, ValidateAlertRule(models.AlertRule{Condition: "price > 1", WebhookURL: "https://example.com/hook"}))
+	assert.ErrorIs(t, ValidateAlertRule(models.AlertRule{Condition: " "}), ErrAlertConditionRequired)
+	assert.ErrorIs(t, ValidateAlertRule(models.AlertRule{Condition: "price > 1", CooldownSeconds: -1}), ErrInvalidAlertCooldown)
+	assert.ErrorIs(t, ValidateAlertRule(models.AlertRule{Condition: "price > 1", CooldownSeconds: maxAlertCooldownSeconds + 1}), ErrInvalidAlertCooldown)
+	assert.ErrorIs(t, ValidateAlertRule(models.AlertRule{Condition: "price > 1", WebhookURL: "ftp://example.com"}), ErrInvalidWebhookURL)
+	assert.ErrorIs(t, ValidateAlertRule(models.AlertRule{Condition: "price > 1", WebhookURL: "/relative"}), ErrInvalidWebhookURL)
+}
+
+func TestAlertEmail(t *testing.T) {
+	msg := string(emailMessage("alerts@example.com", alertEmail("user@example.com", models.FeedAlert{
+		FeedName:    "BTC",
+		Condition:   "price > 70000",
+		Data:        map[string]interface{}{"price": 70001.0},
+		TriggeredAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	})))
+	assert.True(t, strings.HasPrefix(msg, "From: alerts@example.com\r\nTo: user@example.com\r\n"))
+	assert.Contains(t, msg, "Subject: TurboStream alert: price > 70000\r\n")
+	assert.Contains(t, msg, "Your alert on feed BTC fired at 2024-01-01T12:00:00Z.")
+	assert.Contains(t, msg, `Message: {"price":70001}`)
+}
+
+func TestAlertService_NotifyWebhook(t *testing.T) {
+	var got models.FeedAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	// Email is skipped without email settings
+	svc := NewAlertService(nil, nil, NewEmailService(config.Config{}))
+	rule := models.AlertRule{NotifyEmail: true, WebhookURL: server.URL}
+	require.NoError(t, svc.Notify(context.Background(), rule, models.FeedAlert{RuleID: "r1", FeedID: "feed-1", Condition: "price > 1"}))
+	assert.Equal(t, "r1", got.RuleID)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	err := svc.Notify(context.Background(), models.AlertRule{WebhookURL: failing.URL}, models.FeedAlert{RuleID: "r1"})
+	assert.ErrorContains(t, err, "502")
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// minAnalysisInterval and maxAnalysisInterval bound how often a schedule runs
+	minAnalysisInterval = time.Minute
+	maxAnalysisInterval = 7 * 24 * time.Hour
+	// maxAnalysisPromptLen caps a schedule's prompt, in characters
+	maxAnalysisPromptLen = 2000
+	// maxAnalysisSchedulesPerUser caps how many schedules a user can keep
+	maxAnalysisSchedulesPerUser = 20
+	// maxDueAnalyses caps how many schedules one check claims
+	maxDueAnalyses = 20
+	// analysisRetention is how long analysis results are kept
+	analysisRetention = 30 * 24 * time.Hour
+	// defaultAnalysesLimit and maxAnalysesLimit bound one page of results
+	defaultAnalysesLimit = 20
+	maxAnalysesLimit     = 100
+)
+
+// AnalysisService stores users' scheduled AI analyses and their results.
+// Schedules are run by the socket manager, which has the feeds' data.
+type AnalysisService struct {
+	db *mongo.Database
+}
+
+// NewAnalysisService creates the scheduled analysis service
+func NewAnalysisService(db *mongo.Database) *AnalysisService {
+	return &AnalysisService{db: db}
+}
+
+// schedules returns the MongoDB analysis_schedules collection
+func (s *AnalysisService) schedules() *mongo.Collection {
+	return s.db.Collection("analysis_schedules")
+}
+
+// analyses returns the MongoDB analyses collection
+func (s *AnalysisService) analyses() *mongo.Collection {
+	return s.db.Collection("analyses")
+}
+
+// EnsureIndexes creates the indexes used to list a user's schedules, find
+// due schedules and list a schedule's results, and the TTL index that
+// expires old results
+func (s *AnalysisService) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.schedules().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "enabled", Value: 1}, {Key: "nextRunAt", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+	_, err := s.analyses().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "scheduleId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(analysisRetention.Seconds()))},
+	})
+	return err
+}
+
+// ValidateAnalysisSchedule checks a schedule's interval, prompt and provider
+func ValidateAnalysisSchedule(schedule models.AnalysisSchedule) error {
+	interval := time.Duration(schedule.IntervalSeconds) * time.Second
+	if interval < minAnalysisInterval || interval > maxAnalysisInterval {
+		return ErrInvalidAnalysisInterval
+	}
+	if utf8.RuneCountInString(schedule.Prompt) > maxAnalysisPromptLen {
+		return ErrAnalysisPromptTooLong
+	}
+	if schedule.Provider != "" {
+		known := false
+		for _, name := range fallbackOrder {
+			known = known || name == schedule.Provider
+		}
+		if !known {
+			return ErrUnknownLLMProvider
+		}
+	}
+	return nil
+}
+
+// nextAnalysisRun returns when a schedule runs next: one interval after its
+// last run, or straight away when it has not run yet
+func nextAnalysisRun(schedule models.AnalysisSchedule, now time.Time) time.Time {
+	if schedule.LastRunAt == nil {
+		return now
+	}
+	return schedule.LastRunAt.Add(time.Duration(schedule.IntervalSeconds) * time.Second)
+}
+
+// AnalysisRequest returns the query a schedule runs
+func AnalysisRequest(schedule models.AnalysisSchedule) QueryRequest {
+	question := schedule.Prompt
+	if question == "" {
+		question = defaultAnalysisPrompt
+	}
+	return QueryRequest{
+		FeedID:   schedule.FeedID,
+		Question: question,
+		Provider: schedule.Provider,
+		UserID:   schedule.UserID,
+	}
+}
+
+// CreateSchedule stores a new schedule for the user; it first runs on the
+// next check
+func (s *AnalysisService) CreateSchedule(ctx context.Context, schedule models.AnalysisSchedule) (*models.AnalysisSchedule, error) {
+	if err := ValidateAnalysisSchedule(schedule); err != nil {
+		return nil, err
+	}
+	count, err := s.schedules().CountDocuments(ctx, bson.M{"userId": schedule.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAnalysisSchedulesPerUser {
+		return nil, ErrTooManyAnalysisSchedules
+	}
+	now := time.Now().UTC()
+	schedule.ID = primitive.NilObjectID
+	schedule.LastRunAt = nil
+	schedule.LastError = ""
+	schedule.NextRunAt = nextAnalysisRun(schedule, now)
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	res, err := s.schedules().InsertOne(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	schedule.ID = res.InsertedID.(primitive.ObjectID)
+	return &schedule, nil
+}
+
+// ListSchedules returns the user's schedules, oldest first
+func (s *AnalysisService) ListSchedules(ctx context.Context, userID string) ([]models.AnalysisSchedule, error) {
+	cur, err := s.schedules().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	schedules := []models.AnalysisSchedule{}
+	if err := cur.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetSchedule returns one of the user's schedules
+func (s *AnalysisService) GetSchedule(ctx context.Context, userID string, scheduleID primitive.ObjectID) (*models.AnalysisSchedule, error) {
+	var schedule models.AnalysisSchedule
+	err := s.schedules().FindOne(ctx, bson.M{"_id": scheduleID, "userId": userID}).Decode(&schedule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAnalysisScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ReplaceSchedule saves an edited schedule, keeping its owner and run
+// history; a new interval applies from the last run
+func (s *AnalysisService) ReplaceSchedule(ctx context.Context, schedule models.AnalysisSchedule) (*models.AnalysisSchedule, error) {
+	if err := ValidateAnalysisSchedule(schedule); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	var updated models.AnalysisSchedule
+	err := s.schedules().FindOneAndUpdate(ctx,
+		bson.M{"_id": schedule.ID, "userId": schedule.UserID},
+		bson.M{"$set": bson.M{
+			"feedId":          schedule.FeedID,
+			"name":            schedule.Name,
+			"prompt":          schedule.Prompt,
+			"provider":        schedule.Provider,
+			"intervalSeconds": schedule.IntervalSeconds,
+			"enabled":         schedule.Enabled,
+			"nextRunAt":       nextAnalysisRun(schedule, now),
+			"updatedAt":       now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAnalysisScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteSchedule removes one of the user's schedules and its results
+func (s *AnalysisService) DeleteSchedule(ctx context.Context, userID string, scheduleID primitive.ObjectID) error {
+	res, err := s.schedules().DeleteOne(ctx, bson.M{"_id": scheduleID, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrAnalysisScheduleNotFound
+	}
+	_, err = s.analyses().DeleteMany(ctx, bson.M{"scheduleId": scheduleID})
+	return err
+}
+
+// ListAnalyses returns the latest results of one of the user's schedules, newest first
+func (s *AnalysisService) ListAnalyses(ctx context.Context, userID string, scheduleID primitive.ObjectID, limit int) ([]models.Analysis, error) {
+	if limit <= 0 {
+		limit = defaultAnalysesLimit
+	}
+	if limit > maxAnalysesLimit {
+		limit = maxAnalysesLimit
+	}
+	cur, err := s.analyses().Find(ctx,
+		bson.M{"scheduleId": scheduleID, "userId": userID},
+		options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	analyses := []models.Analysis{}
+	if err := cur.All(ctx, &analyses); err != nil {
+		return nil, err
+	}
+	return analyses, nil
+}
+
+// ClaimDueSchedules returns the enabled schedules of the given feeds that are
+// due at now, moving each one's next run on by its interval. A schedule is
+// only claimed once, so instances sharing the database never run it twice.
+func (s *AnalysisService) ClaimDueSchedules(ctx context.Context, feedIDs []string, now time.Time) ([]models.AnalysisSchedule, error) {
+	if len(feedIDs) == 0 {
+		return nil, nil
+	}
+	cur, err := s.schedules().Find(ctx,
+		bson.M{"enabled": true, "feedId": bson.M{"$in": feedIDs}, "nextRunAt": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.M{"nextRunAt": 1}).SetLimit(maxDueAnalyses),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var due []models.AnalysisSchedule
+	err = cur.All(ctx, &due)
+	cur.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []models.AnalysisSchedule
+	for _, schedule := range due {
+		res, err := s.schedules().UpdateOne(ctx,
+			bson.M{"_id": schedule.ID, "nextRunAt": schedule.NextRunAt},
+			bson.M{"$set": bson.M{
+				"nextRunAt": now.Add(time.Duration(schedule.IntervalSeconds) * time.Second),
+				"lastRunAt": now,
+			}},
+		)
+		if err != nil {
+			return claimed, err
+		}
+		if res.ModifiedCount == 1 {
+			claimed = append(claimed, schedule)
+		}
+	}
+	return claimed, nil
+}
+
+// RecordAnalysis stores a run's result and its error, if any, on the schedule
+func (s *AnalysisService) RecordAnalysis(ctx context.Context, analysis *models.Analysis) error {
+	if analysis.CreatedAt.IsZero() {
+		analysis.CreatedAt = time.Now().UTC()
+	}
+	res, err := s.analyses().InsertOne(ctx, analysis)
+	if err != nil {
+		return err
+	}
+	analysis.ID = res.InsertedID.(primitive.ObjectID)
+	_, err = s.schedules().UpdateOne(ctx, bson.M{"_id": analysis.ScheduleID}, bson.M{"$set": bson.M{"lastError": analysis.Error}})
+	return err
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestValidateAnalysisSchedule(t *testing.T) {
+	valid := models.AnalysisSchedule{FeedID: "feed-1", IntervalSeconds: 300, Provider: "anthropic"}
+	assert.NoError(t, ValidateAnalysisSchedule(valid))
+
+	tests := []struct {
+		name   string
+		modify func(*models.AnalysisSchedule)
+		err    error
+	}{
+		{"interval too short", func(s *models.AnalysisSchedule) { s.IntervalSeconds = 30 }, ErrInvalidAnalysisInterval},
+		{"interval too long", func(s *models.AnalysisSchedule) { s.IntervalSeconds = 8 * 24 * 60 * 60 }, ErrInvalidAnalysisInterval},
+		{"prompt too long", func(s *models.AnalysisSchedule) { s.Prompt = strings.Repeat("é", maxAnalysisPromptLen+1) }, ErrAnalysisPromptTooLong},
+		{"unknown provider", func(s *models.AnalysisSchedule) { s.Provider = "gpt" }, ErrUnknownLLMProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := valid
+			tt.modify(&schedule)
+			assert.ErrorIs(t, ValidateAnalysisSchedule(schedule), tt.err)
+		})
+	}
+}
+
+func TestNextAnalysisRun(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	schedule := models.AnalysisSchedule{IntervalSeconds: 600}
+
+	// A schedule that never ran is due straight away
+	assert.Equal(t, now, nextAnalysisRun(schedule, now))
+
+	// Otherwise one interval after its last run, so a new interval applies from it
+	last := now.Add(-2 * time.Minute)
+	schedule.LastRunAt = &last
+	assert.Equal(t, last.Add(10*time.Minute), nextAnalysisRun(schedule, now))
+}
+
+func TestAnalysisRequest(t *testing.T) {
+	schedule := models.AnalysisSchedule{UserID: "user-1", FeedID: "feed-1", Provider: "gemini"}
+	req := AnalysisRequest(schedule)
+	assert.Equal(t, defaultAnalysisPrompt, req.Question)
+	assert.Equal(t, "feed-1", req.FeedID)
+	assert.Equal(t, "gemini", req.Provider)
+	assert.Equal(t, "user-1", req.UserID)
+
+	schedule.Prompt = "Is the price above 100?"
+	assert.Equal(t, "Is the price above 100?", AnalysisRequest(schedule).Question)
+}
+
+func TestLLMService_ContextUpdates(t *testing.T) {
+	svc := newStructuredLLMService(t, &cannedProvider{name: "openai"})
+	svc.feedContexts["empty"] = &FeedContext{FeedID: "empty"}
+
+	updates := svc.ContextUpdates()
+	assert.Len(t, updates, 1)
+	assert.Equal(t, svc.GetFeedContext("feed-1").UpdatedAt, updates["feed-1"])
+}
@@ -35,6 +35,9 @@ func NewAnthropicClient(apiKey, model string) *AnthropicClient {
 // Name returns the provider identifier
 func (c *AnthropicClient) Name() string { return "anthropic" }
 
+// Model returns the model queries are sent to
+func (c *AnthropicClient) Model() string { return c.model }
+
 // Enabled returns true if Anthropic is configured
 func (c *AnthropicClient) Enabled() bool {
 	return c.apiKey != ""
@@ -60,26 +63,29 @@ func (c *AnthropicClient) convertMessages(messages []ChatMessage) (string, []map
 }
 
 // Chat sends a non-streaming chat completion request
-func (c *AnthropicClient) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (c *AnthropicClient) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !c.Enabled() {
-		return "", 0, errors.New("anthropic not configured")
+		return "", TokenCount{}, fmt.Errorf("anthropic: %w", ErrProviderNotConfigured)
 	}
 
 	system, msgs := c.convertMessages(messages)
 
 	reqBody := map[string]interface{}{
 		"model":      c.model,
-		"max_tokens": 1024,
+		"max_tokens": opts.maxTokens(1024),
 		"messages":   msgs,
 	}
 	if system != "" {
 		reqBody["system"] = system
 	}
+	if opts.Temperature != nil {
+		reqBody["temperature"] = *opts.Temperature
+	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
@@ -87,13 +93,13 @@ func (c *AnthropicClient) Chat(ctx context.Context, messages []ChatMessage) (str
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("anthropic error %d: %s", resp.StatusCode, string(body))
+		return "", TokenCount{}, fmt.Errorf("anthropic error %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -106,39 +112,41 @@ func (c *AnthropicClient) Chat(ctx context.Context, messages []ChatMessage) (str
 		} `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	if len(result.Content) == 0 {
-		return "", 0, errors.New("anthropic returned no content")
+		return "", TokenCount{}, errors.New("anthropic returned no content")
 	}
-	totalTokens := result.Usage.InputTokens + result.Usage.OutputTokens
-	return result.Content[0].Text, totalTokens, nil
+	return result.Content[0].Text, TokenCount{Prompt: result.Usage.InputTokens, Completion: result.Usage.OutputTokens}, nil
 }
 
 // StreamChat sends a streaming chat completion request
-func (c *AnthropicClient) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (c *AnthropicClient) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
 	if !c.Enabled() {
-		return 0, errors.New("anthropic not configured")
+		return TokenCount{}, fmt.Errorf("anthropic: %w", ErrProviderNotConfigured)
 	}
 
 	system, msgs := c.convertMessages(messages)
 
 	reqBody := map[string]interface{}{
 		"model":      c.model,
-		"max_tokens": 1024,
+		"max_tokens": opts.maxTokens(1024),
 		"messages":   msgs,
 		"stream":     true,
 	}
 	if system != "" {
 		reqBody["system"] = system
 	}
+	if opts.Temperature != nil {
+		reqBody["temperature"] = *opts.Temperature
+	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
@@ -146,17 +154,17 @@ func (c *AnthropicClient) StreamChat(ctx context.Context, messages []ChatMessage
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("anthropic error %d: %s", resp.StatusCode, string(body))
+		return TokenCount{}, fmt.Errorf("anthropic error %d: %s", resp.StatusCode, string(body))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
-	var totalTokens int
+	var usage TokenCount
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -170,6 +178,12 @@ func (c *AnthropicClient) StreamChat(ctx context.Context, messages []ChatMessage
 			Delta struct {
 				Text string `json:"text"`
 			} `json:"delta"`
+			// message_start carries the prompt tokens, message_delta the output so far
+			Message struct {
+				Usage struct {
+					InputTokens int `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
 			Usage struct {
 				OutputTokens int `json:"output_tokens"`
 			} `json:"usage"`
@@ -183,12 +197,14 @@ func (c *AnthropicClient) StreamChat(ctx context.Context, messages []ChatMessage
 			if event.Delta.Text != "" {
 				tokens <- event.Delta.Text
 			}
+		case "message_start":
+			usage.Prompt = event.Message.Usage.InputTokens
 		case "message_delta":
-			totalTokens = event.Usage.OutputTokens
+			usage.Completion = event.Usage.OutputTokens
 		}
 	}
 
-	return totalTokens, scanner.Err()
+	return usage, scanner.Err()
 }
 
 // Ensure AnthropicClient implements LLMProvider
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// apiKeyPrefix marks TurboStream API keys so they are easy to spot in configs and leaks
+	apiKeyPrefix = "tsk_"
+	// apiKeyDisplayLen is how much of the key is stored in clear to identify it
+	apiKeyDisplayLen = 12
+)
+
+// apiKeys returns the MongoDB api_keys collection
+func (s *AuthService) apiKeys() *mongo.Collection {
+	return s.db.Collection("api_keys")
+}
+
+// CreateAPIKey creates a key for the user with the given per-feed grants and
+// returns the key itself, which is not stored and cannot be shown again
+func (s *AuthService) CreateAPIKey(ctx context.Context, userID primitive.ObjectID, name string, grants []models.APIKeyGrant) (string, models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", models.APIKey{}, ErrAPIKeyNameRequired
+	}
+	grants, err := normalizeAPIKeyGrants(grants)
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+
+	secret, err := newAPIKey()
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:apiKeyDisplayLen],
+		KeyHash:   hashSecret(secret),
+		Grants:    grants,
+		CreatedAt: time.Now(),
+	}
+	res, err := s.apiKeys().InsertOne(ctx, key)
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+	key.ID = res.InsertedID.(primitive.ObjectID)
+	return secret, key, nil
+}
+
+// ListAPIKeys returns the user's keys, newest first, including revoked ones
+func (s *AuthService) ListAPIKeys(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	cursor, err := s.apiKeys().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops one of the user's keys from authenticating
+func (s *AuthService) RevokeAPIKey(ctx context.Context, userID, keyID primitive.ObjectID) error {
+	res, err := s.apiKeys().UpdateOne(ctx,
+		bson.M{"_id": keyID, "userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey returns the unrevoked key matching secret and records its use
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, secret string) (models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	var key models.APIKey
+	err := s.apiKeys().FindOneAndUpdate(ctx,
+		bson.M{"keyHash": hashSecret(secret), "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"lastUsedAt": time.Now()}},
+	).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+// normalizeAPIKeyGrants checks feed IDs and scopes and merges grants for the same feed
+func normalizeAPIKeyGrants(grants []models.APIKeyGrant) ([]models.APIKeyGrant, error) {
+	if len(grants) == 0 {
+		return nil, ErrInvalidAPIKeyScope
+	}
+	byFeed := make(map[string]map[string]bool)
+	var order []string
+	for _, grant := range grants {
+		if _, err := primitive.ObjectIDFromHex(grant.FeedID); err != nil || len(grant.Scopes) == 0 {
+			return nil, ErrInvalidAPIKeyScope
+		}
+		if byFeed[grant.FeedID] == nil {
+			byFeed[grant.FeedID] = make(map[string]bool)
+			order = append(order, grant.FeedID)
+		}
+		for _, scope := range grant.Scopes {
+			if scope != models.APIKeyScopeRead && scope != models.APIKeyScopePublish {
+				return nil, ErrInvalidAPIKeyScope
+			}
+			byFeed[grant.FeedID][scope] = true
+		}
+	}
+
+	out := make([]models.APIKeyGrant, 0, len(order))
+	for _, feedID := range order {
+		grant := models.APIKeyGrant{FeedID: feedID}
+		for _, scope := range []string{models.APIKeyScopeRead, models.APIKeyScopePublish} {
+			if byFeed[feedID][scope] {
+				grant.Scopes = append(grant.Scopes, scope)
+			}
+		}
+		out = append(out, grant)
+	}
+	return out, nil
+}
+
+// newAPIKey returns a random API key
+func newAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
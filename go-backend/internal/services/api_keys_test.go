@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestNormalizeAPIKeyGrants(t *testing.T) {
+	feedID := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name    string
+		grants  []models.APIKeyGrant
+		want    []models.APIKeyGrant
+		wantErr bool
+	}{
+		{
+			name:   "merges grants for the same feed",
+			grants: []models.APIKeyGrant{{FeedID: feedID, Scopes: []string{"publish"}}, {FeedID: feedID, Scopes: []string{"read", "publish"}}},
+			want:   []models.APIKeyGrant{{FeedID: feedID, Scopes: []string{"read", "publish"}}},
+		},
+		{name: "no grants", grants: nil, wantErr: true},
+		{name: "invalid feed id", grants: []models.APIKeyGrant{{FeedID: "nope", Scopes: []string{"read"}}}, wantErr: true},
+		{name: "no scopes", grants: []models.APIKeyGrant{{FeedID: feedID}}, wantErr: true},
+		{name: "unknown scope", grants: []models.APIKeyGrant{{FeedID: feedID, Scopes: []string{"admin"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeAPIKeyGrants(tt.grants)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAPIKeyScope)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuthService_APIKeys(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	userID := primitive.NewObjectID()
+	feedID := primitive.NewObjectID().Hex()
+
+	secret, key, err := service.CreateAPIKey(ctx, userID, "ingest bot", []models.APIKeyGrant{{FeedID: feedID, Scopes: []string{"publish"}}})
+	require.NoError(t, err)
+	assert.Contains(t, secret, key.Prefix)
+
+	found, err := service.AuthenticateAPIKey(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, userID, found.UserID)
+	assert.True(t, found.Allows(feedID, models.APIKeyScopePublish))
+	assert.False(t, found.Allows(feedID, models.APIKeyScopeRead))
+
+	keys, err := service.ListAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotEqual(t, secret, keys[0].KeyHash, "only the hash is stored")
+
+	require.NoError(t, service.RevokeAPIKey(ctx, userID, key.ID))
+	_, err = service.AuthenticateAPIKey(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.ErrorIs(t, service.RevokeAPIKey(ctx, userID, key.ID), ErrAPIKeyNotFound)
+}
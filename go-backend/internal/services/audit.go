@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Audited actions
+const (
+	AuditRegister         = "auth.register"
+	AuditLogin            = "auth.login"
+	AuditOAuthLogin       = "auth.oauth_login"
+	AuditLogout           = "auth.logout"
+	AuditPasswordChange   = "auth.password_change"
+	AuditPasswordForgot   = "auth.password_reset_request"
+	AuditPasswordReset    = "auth.password_reset"
+	AuditEmailVerify      = "auth.email_verify"
+	AuditAccountExport    = "account.export"
+	AuditAccountDelete    = "account.delete"
+	AuditTwoFactorEnable  = "auth.2fa_enable"
+	AuditTwoFactorDisable = "auth.2fa_disable"
+	AuditWebAuthnRegister = "auth.webauthn_register"
+	AuditWebAuthnRemove   = "auth.webauthn_remove"
+	AuditAPIKeyCreate     = "api_key.create"
+	AuditAPIKeyRevoke     = "api_key.revoke"
+	AuditLLMKeySet        = "llm_key.set"
+	AuditLLMKeyDelete     = "llm_key.delete"
+	AuditFeedCreate       = "feed.create"
+	AuditFeedUpdate       = "feed.update"
+	AuditFeedDelete       = "feed.delete"
+	AuditFeedRestore      = "feed.restore"
+	AuditFeedPause        = "feed.pause"
+	AuditFeedResume       = "feed.resume"
+	AuditFeedClone        = "feed.clone"
+	AuditFeedAccessGrant  = "feed.access_grant"
+	AuditFeedAccessRevoke = "feed.access_revoke"
+	AuditFeedInviteCreate = "feed.invite_create"
+	AuditFeedInviteRevoke = "feed.invite_revoke"
+	AuditFeedInviteAccept = "feed.invite_accept"
+	AuditFeedTransfer     = "feed.transfer"
+	AuditFeedIngestIssue  = "feed.ingest_issue"
+	AuditOrgCreate        = "org.create"
+	AuditOrgMemberAdd     = "org.member_add"
+	AuditOrgMemberUpdate  = "org.member_update"
+	AuditOrgMemberRemove  = "org.member_remove"
+	AuditCategoryCreate   = "category.create"
+	AuditCategoryUpdate   = "category.update"
+	AuditAdminSetRole     = "admin.set_role"
+	AuditAdminSetQuota    = "admin.set_token_quota"
+	AuditAdminModerate    = "admin.moderate_feed"
+	AuditAdminVerify      = "admin.verify_feed"
+)
+
+// Audit targets
+const (
+	AuditTargetUser     = "user"
+	AuditTargetFeed     = "feed"
+	AuditTargetAPIKey   = "api_key"
+	AuditTargetLLMKey   = "llm_key"
+	AuditTargetGrant    = "feed_access_grant"
+	AuditTargetInvite   = "feed_invite"
+	AuditTargetCategory = "category"
+	AuditTargetWebAuthn = "webauthn_credential"
+	AuditTargetOrg      = "organization"
+	AuditTargetMember   = "organization_member"
+)
+
+const (
+	// defaultAuditPageSize and maxAuditPageSize bound one audit log page
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// auditRedacted are fields whose values are never copied into an audit diff;
+// a change to one shows as "[redacted]". Feed headers and query parameters
+// often carry upstream credentials.
+var auditRedacted = map[string]bool{
+	"headers":         true,
+	"queryParams":     true,
+	"httpConfig":      true,
+	"password":        true,
+	"secret":          true,
+	"apiKey":          true,
+	"keyHash":         true,
+	"tokenHash":       true,
+	"refreshToken":    true,
+	"twoFactorSecret": true,
+	"backupCodes":     true,
+}
+
+// AuditFilter narrows an audit log query; empty fields match everything
+type AuditFilter struct {
+	Action     string
+	ActorID    string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	From       time.Time
+	To         time.Time
+	Limit      int64
+	Offset     int64
+}
+
+// AuditService keeps the audit log of security-relevant actions
+type AuditService struct {
+	db *mongo.Database
+	// retention is how long events are kept; 0 keeps them forever
+	retention time.Duration
+}
+
+// NewAuditService creates the audit service; events older than retention are
+// removed, and a retention of 0 keeps them forever
+func NewAuditService(db *mongo.Database, retention time.Duration) *AuditService {
+	return &AuditService{db: db, retention: retention}
+}
+
+// events returns the MongoDB audit_events collection
+func (s *AuditService) events() *mongo.Collection {
+	return s.db.Collection("audit_events")
+}
+
+// EnsureIndexes creates the audit log query indexes and its TTL index
+func (s *AuditService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.events().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "targetType", Value: 1}, {Key: "targetId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Record stores an audit event, stamping its time and expiry
+func (s *AuditService) Record(ctx context.Context, event models.AuditEvent) error {
+	if event.Outcome == "" {
+		event.Outcome = models.AuditSuccess
+	}
+	event.CreatedAt = time.Now().UTC()
+	if s.retention > 0 {
+		expiresAt := event.CreatedAt.Add(s.retention)
+		event.ExpiresAt = &expiresAt
+	}
+	if _, err := s.events().InsertOne(ctx, event); err != nil {
+		return err
+	}
+	metrics.AuditEvents.WithLabelValues(event.Action, event.Outcome).Inc()
+	return nil
+}
+
+// auditQuery builds the MongoDB filter for an audit log query
+func auditQuery(f AuditFilter) bson.M {
+	query := bson.M{}
+	for field, value := range map[string]string{
+		"action":     f.Action,
+		"actorId":    f.ActorID,
+		"targetType": f.TargetType,
+		"targetId":   f.TargetID,
+		"outcome":    f.Outcome,
+	} {
+		if value != "" {
+			query[field] = value
+		}
+	}
+	created := bson.M{}
+	if !f.From.IsZero() {
+		created["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		created["$lte"] = f.To
+	}
+	if len(created) > 0 {
+		query["createdAt"] = created
+	}
+	return query
+}
+
+// List returns a page of audit events matching the filter, newest first,
+// with the number of matching events
+func (s *AuditService) List(ctx context.Context, f AuditFilter) ([]models.AuditEvent, int64, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultAuditPageSize
+	}
+	if f.Limit > maxAuditPageSize {
+		f.Limit = maxAuditPageSize
+	}
+	query := auditQuery(f)
+	total, err := s.events().CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	cur, err := s.events().Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(f.Offset).
+		SetLimit(f.Limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+	events := []models.AuditEvent{}
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// auditRedactedValue replaces the value of redacted fields in audit diffs
+const auditRedactedValue = "[redacted]"
+
+// AuditDiff returns the fields that differ between two versions of a record,
+// as they appear in its JSON. A nil before (a create) or after (a delete)
+// yields the other version's fields. Secrets are never included.
+func AuditDiff(before, after interface{}) (map[string]interface{}, map[string]interface{}) {
+	b, a := auditFields(before), auditFields(after)
+	if b == nil || a == nil {
+		return redactAudit(b), redactAudit(a)
+	}
+	diffBefore := map[string]interface{}{}
+	diffAfter := map[string]interface{}{}
+	for key, value := range b {
+		if other, ok := a[key]; !ok || !reflect.DeepEqual(value, other) {
+			diffBefore[key] = value
+		}
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || !reflect.DeepEqual(value, other) {
+			diffAfter[key] = value
+		}
+	}
+	return redactAudit(diffBefore), redactAudit(diffAfter)
+}
+
+// auditFields flattens a record to its top-level JSON fields
+func auditFields(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// redactAudit masks secret fields, returning nil for an empty diff
+func redactAudit(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	for key := range fields {
+		if auditRedacted[key] {
+			fields[key] = auditRedactedValue
+		}
+	}
+	return fields
+}
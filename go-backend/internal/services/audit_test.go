@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestAuditDiff_ChangedFieldsOnly(t *testing.T) {
+	before := Category{Key: "crypto", Label: "Crypto", Disabled: false}
+	after := Category{Key: "crypto", Label: "Cryptocurrency", Disabled: true}
+
+	b, a := AuditDiff(before, after)
+	assert.Equal(t, map[string]interface{}{"label": "Crypto", "disabled": false}, b)
+	assert.Equal(t, map[string]interface{}{"label": "Cryptocurrency", "disabled": true}, a)
+}
+
+func TestAuditDiff_CreateAndDelete(t *testing.T) {
+	category := &Category{Key: "crypto", Label: "Crypto"}
+
+	b, a := AuditDiff(nil, category)
+	assert.Nil(t, b)
+	assert.Equal(t, "crypto", a["key"])
+
+	var missing *Category
+	b, a = AuditDiff(category, missing)
+	assert.Equal(t, "Crypto", b["label"])
+	assert.Nil(t, a)
+}
+
+func TestAuditDiff_NoChange(t *testing.T) {
+	category := Category{Key: "crypto", Label: "Crypto"}
+	b, a := AuditDiff(category, category)
+	assert.Nil(t, b)
+	assert.Nil(t, a)
+}
+
+func TestAuditDiff_RedactsCredentials(t *testing.T) {
+	before := map[string]interface{}{"name": "Prices", "headers": map[string]string{"Authorization": "Bearer old"}}
+	after := map[string]interface{}{"name": "Prices", "headers": map[string]string{"Authorization": "Bearer new"}}
+
+	b, a := AuditDiff(before, after)
+	assert.Equal(t, map[string]interface{}{"headers": auditRedactedValue}, b)
+	assert.Equal(t, map[string]interface{}{"headers": auditRedactedValue}, a)
+}
+
+func TestAuditQuery(t *testing.T) {
+	assert.Equal(t, bson.M{}, auditQuery(AuditFilter{}))
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	query := auditQuery(AuditFilter{Action: AuditLogin, Outcome: models.AuditFailure, From: from, To: to})
+	assert.Equal(t, bson.M{
+		"action":    AuditLogin,
+		"outcome":   models.AuditFailure,
+		"createdAt": bson.M{"$gte": from, "$lte": to},
+	}, query)
+}
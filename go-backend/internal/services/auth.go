@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
@@ -21,26 +24,50 @@ import (
 	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// AuthTokens is the token pair issued on registration, login and refresh.
+// The access token is a short-lived JWT tied to a session; the refresh token
+// is exchanged for a new pair once it expires.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64 // access token lifetime in seconds
+}
+
 // AuthService handles user authentication, sessions, and 2FA
 type AuthService struct {
-	cfg    config.Config
-	client *mongo.Client
-	db     *mongo.Database
+	cfg      config.Config
+	client   *mongo.Client
+	db       *mongo.Database
+	users    UserRepo
+	sessions SessionRepo
+	// oauth holds the configured OAuth sign-in providers by name
+	oauth map[string]*oauthProvider
+	// email sends verification and password reset emails; nil sends none
+	email *EmailService
 }
 
 // NewAuthService creates a new authentication service instance
 func NewAuthService(cfg config.Config, client *mongo.Client, db *mongo.Database) *AuthService {
-	return &AuthService{cfg: cfg, client: client, db: db}
-}
-
-// users returns the MongoDB users collection
-func (s *AuthService) users() *mongo.Collection {
-	return s.db.Collection("users")
+	return &AuthService{
+		cfg:      cfg,
+		client:   client,
+		db:       db,
+		users:    NewMongoUserRepo(db),
+		sessions: NewMongoSessionRepo(db),
+		oauth:    oauthProviders(cfg),
+	}
 }
 
-// sessions returns the MongoDB sessions collection
-func (s *AuthService) sessions() *mongo.Collection {
-	return s.db.Collection("sessions")
+// SetStore moves users and sessions to the store's repositories; login
+// activity, API keys and the other auth records stay in MongoDB
+func (s *AuthService) SetStore(store Store) {
+	s.users = store.Users
+	s.sessions = store.Sessions
 }
 
 // loginActivity returns the MongoDB login activity collection
@@ -48,30 +75,44 @@ func (s *AuthService) loginActivity() *mongo.Collection {
 	return s.db.Collection("login_activity")
 }
 
-// Register creates a new user and returns a JWT + safe payload.
-func (s *AuthService) Register(ctx context.Context, email, password, name string) (string, models.User, error) {
+// Register creates a new user, opens a session for it and returns its tokens + safe payload.
+func (s *AuthService) Register(ctx context.Context, email, password, name string) (AuthTokens, models.User, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 	if email == "" || password == "" {
-		return "", models.User{}, errors.New("email and password required")
+		return AuthTokens{}, models.User{}, ErrMissingCredentials
 	}
 
-	exists, err := s.users().CountDocuments(ctx, bson.M{"email": email})
-	if err != nil {
-		return "", models.User{}, err
+	_, err := s.users.GetByEmail(ctx, email)
+	if err == nil {
+		return AuthTokens{}, models.User{}, ErrUserExists
 	}
-	if exists > 0 {
-		return "", models.User{}, errors.New("user already exists")
+	if !errors.Is(err, ErrUserNotFound) {
+		return AuthTokens{}, models.User{}, err
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", models.User{}, err
+		return AuthTokens{}, models.User{}, err
+	}
+
+	user := s.newUser(email, string(hash), name)
+	if err := s.users.Insert(ctx, &user); err != nil {
+		return AuthTokens{}, models.User{}, err
 	}
 
+	tokens, err := s.createSession(ctx, user, "", "")
+	return tokens, user, err
+}
+
+// newUser builds a user record with a fresh monthly token quota. Emails in
+// ADMIN_EMAILS start as users too; promoteIfAdmin makes them admins once
+// the email is verified.
+func (s *AuthService) newUser(email, passwordHash, name string) models.User {
 	now := time.Now()
-	user := models.User{
+	return models.User{
+		Role:      models.RoleUser,
 		Email:     email,
-		Password:  string(hash),
+		Password:  passwordHash,
 		Name:      name,
 		CreatedAt: now,
 		TokenUsage: &models.TokenUsage{
@@ -82,39 +123,50 @@ func (s *AuthService) Register(ctx context.Context, email, password, name string
 			OverdraftAllowed: true,
 		},
 	}
+}
 
-	res, err := s.users().InsertOne(ctx, user)
-	if err != nil {
-		return "", models.User{}, err
-	}
-	user.ID = res.InsertedID.(primitive.ObjectID)
-
-	token, err := s.generateToken(user)
-	return token, user, err
+// Login authenticates a user with email/password and optional 2FA, opens a
+// session and returns its access and refresh tokens
+func (s *AuthService) Login(ctx context.Context, email, password, totpToken string, ip, ua string) (AuthTokens, models.User, error) {
+	return s.login(ctx, email, password, ip, ua, func(user models.User) error {
+		ok, err := s.verifyTotpOrBackup(ctx, user, totpToken)
+		if err != nil || !ok {
+			return ErrTwoFactorRequired
+		}
+		return nil
+	})
 }
 
-// Login authenticates a user with email/password and optional 2FA, returns JWT token
-func (s *AuthService) Login(ctx context.Context, email, password, totpToken string, ip, ua string) (string, models.User, error) {
+// login checks the password and, when the user has 2FA or a security key,
+// the second factor with verify; then it opens a session
+func (s *AuthService) login(ctx context.Context, email, password, ip, ua string, verify func(models.User) error) (AuthTokens, models.User, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
-	var user models.User
-	err := s.users().FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	user, err := s.users.GetByEmail(ctx, email)
 	if err != nil {
-		return "", models.User{}, errors.New("invalid email or password")
+		return AuthTokens{}, models.User{}, ErrInvalidCredentials
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
-		return "", models.User{}, errors.New("invalid email or password")
+		return AuthTokens{}, models.User{}, ErrInvalidCredentials
 	}
 
-	if user.TwoFactor {
-		ok, err := s.verifyTotpOrBackup(ctx, user, totpToken)
-		if err != nil || !ok {
-			return "", user, errors.New("two-factor authentication required")
+	if s.secondFactorRequired(ctx, user) {
+		if err := verify(user); err != nil {
+			return AuthTokens{}, user, err
 		}
 	}
 
+	tokens, err := s.startSession(ctx, user, ip, ua)
+	if err != nil {
+		return AuthTokens{}, models.User{}, err
+	}
+	return tokens, user, nil
+}
+
+// startSession records a successful sign-in and opens a session for it
+func (s *AuthService) startSession(ctx context.Context, user models.User, ip, ua string) (AuthTokens, error) {
 	now := time.Now()
-	_, _ = s.users().UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"lastLogin": now}})
+	_ = s.users.Update(ctx, user.ID, bson.M{"lastLogin": now})
 	_, _ = s.loginActivity().InsertOne(ctx, models.LoginActivity{
 		UserID:    user.ID,
 		IPAddress: ip,
@@ -122,30 +174,104 @@ func (s *AuthService) Login(ctx context.Context, email, password, totpToken stri
 		Success:   true,
 		Timestamp: now,
 	})
+	return s.createSession(ctx, user, ua, ip)
+}
 
-	token, err := s.generateToken(user)
+// Refresh exchanges a refresh token for a new token pair and rotates the
+// session's refresh token. Replaying a token that was already rotated means
+// it was copied, so the session holding it is terminated.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (AuthTokens, models.User, error) {
+	if refreshToken == "" {
+		return AuthTokens{}, models.User{}, ErrInvalidToken
+	}
+	hash := hashSecret(refreshToken)
+	next, err := newRefreshToken()
+	if err != nil {
+		return AuthTokens{}, models.User{}, err
+	}
+
+	now := time.Now()
+	session, err := s.sessions.Rotate(ctx, hash, hashSecret(next), now, now.Add(s.refreshTokenTTL()))
+	if errors.Is(err, ErrSessionNotFound) {
+		_ = s.sessions.RevokeReplayed(ctx, hash)
+		return AuthTokens{}, models.User{}, ErrInvalidToken
+	}
 	if err != nil {
-		return "", models.User{}, err
+		return AuthTokens{}, models.User{}, err
 	}
 
-	_ = s.createSession(ctx, user.ID, ua, ip)
+	user, err := s.users.Get(ctx, session.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return AuthTokens{}, models.User{}, ErrInvalidToken
+	}
+	if err != nil {
+		return AuthTokens{}, models.User{}, err
+	}
+	access, err := s.generateToken(user, session.ID)
+	if err != nil {
+		return AuthTokens{}, models.User{}, err
+	}
+	return AuthTokens{AccessToken: access, RefreshToken: next, ExpiresIn: int64(s.accessTokenTTL().Seconds())}, user, nil
+}
 
-	return token, user, nil
+// Authenticate parses an access token and checks that its session is still
+// active, so a terminated session loses access before the token expires
+func (s *AuthService) Authenticate(ctx context.Context, tokenStr string) (jwt.MapClaims, error) {
+	claims, err := s.ParseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	userIDStr, _ := claims["userId"].(string)
+	sessionIDStr, _ := claims["sessionId"].(string)
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	sessionID, err := primitive.ObjectIDFromHex(sessionIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	active, err := s.sessions.Active(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
 }
 
-// generateToken creates a JWT token for the authenticated user with 7-day expiration
-func (s *AuthService) generateToken(user models.User) (string, error) {
+// generateToken creates a short-lived access token for the user's session
+func (s *AuthService) generateToken(user models.User, sessionID primitive.ObjectID) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"userId":   user.ID.Hex(),
-		"email":    user.Email,
-		"username": user.Name,
-		"exp":      time.Now().Add(7 * 24 * time.Hour).Unix(),
-		"iat":      time.Now().Unix(),
+		"userId":    user.ID.Hex(),
+		"sessionId": sessionID.Hex(),
+		"email":     user.Email,
+		"username":  user.Name,
+		"exp":       now.Add(s.accessTokenTTL()).Unix(),
+		"iat":       now.Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.cfg.JWTSecret))
 }
 
+// accessTokenTTL returns the configured access token lifetime, 15 minutes by default
+func (s *AuthService) accessTokenTTL() time.Duration {
+	if s.cfg.AccessTokenTTL > 0 {
+		return s.cfg.AccessTokenTTL
+	}
+	return defaultAccessTokenTTL
+}
+
+// refreshTokenTTL returns how long an unused refresh token stays valid, 30 days by default
+func (s *AuthService) refreshTokenTTL() time.Duration {
+	if s.cfg.RefreshTokenTTL > 0 {
+		return s.cfg.RefreshTokenTTL
+	}
+	return defaultRefreshTokenTTL
+}
+
 // ParseToken validates and parses a JWT token, returning the claims
 func (s *AuthService) ParseToken(tokenStr string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
@@ -155,23 +281,23 @@ func (s *AuthService) ParseToken(tokenStr string) (jwt.MapClaims, error) {
 		return []byte(s.cfg.JWTSecret), nil
 	})
 	if err != nil || !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, ErrInvalidToken
 	}
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		return claims, nil
 	}
-	return nil, errors.New("invalid token payload")
+	return nil, ErrInvalidToken
 }
 
 // ChangePassword updates a user's password after verifying the current password
 func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.ObjectID, current, next string) error {
-	var user models.User
-	if err := s.users().FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
 		return err
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(current)) != nil {
-		return errors.New("current password is incorrect")
+		return ErrIncorrectPassword
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(next), bcrypt.DefaultCost)
@@ -179,14 +305,14 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.Objec
 		return err
 	}
 
-	_, err = s.users().UpdateByID(ctx, userID, bson.M{"$set": bson.M{"password": string(hash)}})
+	err = s.users.Update(ctx, userID, bson.M{"password": string(hash)})
 	return err
 }
 
 // GetUser retrieves a user by ID, handles monthly token quota reset, and removes password from response
 func (s *AuthService) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
-	var user models.User
-	if err := s.users().FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+	user, err := s.users.Get(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 	user.Password = "" // Don't expose password
@@ -199,12 +325,12 @@ func (s *AuthService) GetUser(ctx context.Context, id primitive.ObjectID) (*mode
 		user.TokenUsage = &models.TokenUsage{
 			CurrentMonth:     currentMonth,
 			TokensUsed:       0,
-			Limit:            s.cfg.TokenQuotaPerMonth,
+			Limit:            s.tokenLimit(user),
 			LastResetDate:    now,
 			OverdraftAllowed: true, // Or based on some logic
 		}
 		// Persist the new token usage record.
-		_, err := s.users().UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"tokenUsage": user.TokenUsage}})
+		err := s.users.Update(ctx, user.ID, bson.M{"tokenUsage": user.TokenUsage})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create token usage record: %w", err)
 		}
@@ -213,18 +339,18 @@ func (s *AuthService) GetUser(ctx context.Context, id primitive.ObjectID) (*mode
 		user.TokenUsage.CurrentMonth = currentMonth
 		user.TokenUsage.TokensUsed = 0
 		user.TokenUsage.LastResetDate = now
-		user.TokenUsage.Limit = s.cfg.TokenQuotaPerMonth // Ensure limit is updated from config.
+		user.TokenUsage.Limit = s.tokenLimit(user) // Ensure limit is updated from config or override.
 
 		// Persist the changes to the database.
-		_, err := s.users().UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"tokenUsage": user.TokenUsage}})
+		err := s.users.Update(ctx, user.ID, bson.M{"tokenUsage": user.TokenUsage})
 		if err != nil {
 			// If the update fails, we should probably return an error as the user's state is inconsistent.
 			return nil, fmt.Errorf("failed to reset token usage: %w", err)
 		}
-	} else if user.TokenUsage.Limit != s.cfg.TokenQuotaPerMonth {
-		// The monthly quota might have changed, so update the user's limit.
-		user.TokenUsage.Limit = s.cfg.TokenQuotaPerMonth
-		_, err := s.users().UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"tokenUsage.limit": user.TokenUsage.Limit}})
+	} else if limit := s.tokenLimit(user); user.TokenUsage.Limit != limit {
+		// The monthly quota or the user's override might have changed, so update the user's limit.
+		user.TokenUsage.Limit = limit
+		err := s.users.Update(ctx, user.ID, bson.M{"tokenUsage.limit": user.TokenUsage.Limit})
 		if err != nil {
 			return nil, fmt.Errorf("failed to update token limit: %w", err)
 		}
@@ -235,10 +361,40 @@ func (s *AuthService) GetUser(ctx context.Context, id primitive.ObjectID) (*mode
 
 // UpdateTokenUsage increments the token usage counter for a user's monthly quota
 func (s *AuthService) UpdateTokenUsage(ctx context.Context, userID primitive.ObjectID, tokensUsed int) error {
-	_, err := s.users().UpdateByID(ctx, userID, bson.M{
-		"$inc": bson.M{"tokenUsage.tokensUsed": tokensUsed},
-	})
-	return err
+	return s.users.AddTokensUsed(ctx, userID, tokensUsed)
+}
+
+// TokenQuota is a user's monthly token quota as checked before an LLM call
+type TokenQuota struct {
+	TokensUsed      int64 `json:"tokensUsed"`
+	Limit           int64 `json:"limit"`
+	Remaining       int64 `json:"remaining"`
+	EstimatedTokens int   `json:"estimatedTokens"`
+}
+
+// CheckTokenQuota returns ErrTokenQuotaExceeded when a call estimated to use
+// estimate tokens would take the user past their monthly limit, unless
+// their quota allows overdraft
+func (s *AuthService) CheckTokenQuota(ctx context.Context, userID primitive.ObjectID, estimate int) (TokenQuota, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return TokenQuota{}, err
+	}
+	return checkTokenQuota(*user.TokenUsage, estimate)
+}
+
+// checkTokenQuota checks an estimated call against the month's usage
+func checkTokenQuota(usage models.TokenUsage, estimate int) (TokenQuota, error) {
+	quota := TokenQuota{
+		TokensUsed:      usage.TokensUsed,
+		Limit:           usage.Limit,
+		Remaining:       max(usage.Limit-usage.TokensUsed, 0),
+		EstimatedTokens: estimate,
+	}
+	if !usage.OverdraftAllowed && usage.TokensUsed+int64(estimate) > usage.Limit {
+		return quota, ErrTokenQuotaExceeded
+	}
+	return quota, nil
 }
 
 // TwoFactorSetup generates a TOTP secret and QR code for 2FA enrollment
@@ -260,7 +416,7 @@ func (s *AuthService) TwoFactorSetup(email string) (secret, qrData, manualKey st
 // EnableTwoFactor activates 2FA for a user after validating TOTP token and generates backup codes
 func (s *AuthService) EnableTwoFactor(ctx context.Context, userID primitive.ObjectID, secret, token string) ([]string, error) {
 	if ok := totp.Validate(token, secret); !ok {
-		return nil, errors.New("invalid verification code")
+		return nil, ErrInvalidVerificationCode
 	}
 
 	backup := generateBackupCodes()
@@ -269,12 +425,10 @@ func (s *AuthService) EnableTwoFactor(ctx context.Context, userID primitive.Obje
 		codes = append(codes, models.BackupCode{Code: code, Used: false})
 	}
 
-	_, err := s.users().UpdateByID(ctx, userID, bson.M{
-		"$set": bson.M{
-			"twoFactorEnabled": true,
-			"twoFactorSecret":  secret,
-			"backupCodes":      codes,
-		},
+	err := s.users.Update(ctx, userID, bson.M{
+		"twoFactorEnabled": true,
+		"twoFactorSecret":  secret,
+		"backupCodes":      codes,
 	})
 	if err != nil {
 		return nil, err
@@ -283,78 +437,64 @@ func (s *AuthService) EnableTwoFactor(ctx context.Context, userID primitive.Obje
 	return backup, nil
 }
 
-// DisableTwoFactor removes 2FA from a user's account and clears backup codes
+// DisableTwoFactor removes TOTP 2FA from a user's account and clears backup
+// codes, which are kept while the user still has a security key
 func (s *AuthService) DisableTwoFactor(ctx context.Context, userID primitive.ObjectID) error {
-	_, err := s.users().UpdateByID(ctx, userID, bson.M{
-		"$set": bson.M{
-			"twoFactorEnabled": false,
-			"twoFactorSecret":  "",
-			"backupCodes":      []models.BackupCode{},
-		},
-	})
+	set := bson.M{
+		"twoFactorEnabled": false,
+		"twoFactorSecret":  "",
+	}
+	if !s.hasWebAuthn(ctx, userID) {
+		set["backupCodes"] = []models.BackupCode{}
+	}
+	err := s.users.Update(ctx, userID, set)
 	return err
 }
 
 // GetBackupCodeStatus returns the number of unused backup codes for a user
 func (s *AuthService) GetBackupCodeStatus(ctx context.Context, userID primitive.ObjectID) (int, error) {
-	var user models.User
-	if err := s.users().FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
 		return 0, err
 	}
-	count := 0
-	for _, code := range user.BackupCodes {
-		if !code.Used {
-			count++
-		}
-	}
-	return count, nil
+	return unusedBackupCodes(user), nil
 }
 
-// RegenerateBackupCodes creates a new set of backup codes after TOTP verification
-func (s *AuthService) RegenerateBackupCodes(ctx context.Context, userID primitive.ObjectID, totpCode string) ([]string, error) {
-	var user models.User
-	if err := s.users().FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+// RegenerateBackupCodes creates a new set of backup codes. Users with a
+// second factor confirm with a TOTP or backup code, or a security key
+// assertion answering a WebAuthnChallengeConfirm challenge.
+func (s *AuthService) RegenerateBackupCodes(ctx context.Context, userID primitive.ObjectID, code string, assertion *WebAuthnAssertion) ([]string, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
 		return nil, err
 	}
-	if user.TwoFactor && !totp.Validate(totpCode, user.TwoFactorSecret) {
-		return nil, errors.New("invalid verification code")
+	if s.secondFactorRequired(ctx, user) {
+		if err := s.verifySecondFactor(ctx, user, code, assertion, models.WebAuthnChallengeConfirm); err != nil {
+			return nil, err
+		}
 	}
 	backup := generateBackupCodes()
 	codes := make([]models.BackupCode, 0, len(backup))
 	for _, code := range backup {
 		codes = append(codes, models.BackupCode{Code: code, Used: false})
 	}
-	_, err := s.users().UpdateByID(ctx, userID, bson.M{"$set": bson.M{"backupCodes": codes}})
+	err = s.users.Update(ctx, userID, bson.M{"backupCodes": codes})
 	return backup, err
 }
 
 // GetSessions retrieves all active sessions for a user
 func (s *AuthService) GetSessions(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
-	cursor, err := s.sessions().Find(ctx, bson.M{"userId": userID})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-	var sessions []models.UserSession
-	if err := cursor.All(ctx, &sessions); err != nil {
-		return nil, err
-	}
-	return sessions, nil
+	return s.sessions.ListByUser(ctx, userID)
 }
 
 // TerminateSession deactivates a specific user session
 func (s *AuthService) TerminateSession(ctx context.Context, userID primitive.ObjectID, sessionID primitive.ObjectID) error {
-	_, err := s.sessions().UpdateOne(ctx, bson.M{"_id": sessionID, "userId": userID}, bson.M{"$set": bson.M{"isActive": false}})
-	return err
+	return s.sessions.Revoke(ctx, userID, sessionID)
 }
 
 // TerminateOtherSessions deactivates all user sessions except the current one
 func (s *AuthService) TerminateOtherSessions(ctx context.Context, userID, currentSessionID primitive.ObjectID) (int64, error) {
-	res, err := s.sessions().UpdateMany(ctx, bson.M{"userId": userID, "_id": bson.M{"$ne": currentSessionID}}, bson.M{"$set": bson.M{"isActive": false}})
-	if err != nil {
-		return 0, err
-	}
-	return res.ModifiedCount, nil
+	return s.sessions.RevokeAll(ctx, userID, currentSessionID)
 }
 
 // GetLoginActivity retrieves recent login attempts for a user with optional limit
@@ -375,35 +515,67 @@ func (s *AuthService) GetLoginActivity(ctx context.Context, userID primitive.Obj
 }
 
 // createSession records a new user session with device and location info
-func (s *AuthService) createSession(ctx context.Context, userID primitive.ObjectID, ua, ip string) error {
+// and issues its first token pair
+func (s *AuthService) createSession(ctx context.Context, user models.User, ua, ip string) (AuthTokens, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return AuthTokens{}, err
+	}
+	now := time.Now()
 	session := models.UserSession{
-		UserID:       userID,
-		UserAgent:    ua,
-		UserAgentRaw: ua,
-		IPAddress:    ip,
-		CreatedAt:    time.Now(),
-		LastActive:   time.Now(),
-		IsActive:     true,
-		DeviceName:   "web",
-		DeviceType:   "browser",
-	}
-	_, err := s.sessions().InsertOne(ctx, session)
-	return err
+		UserID:           user.ID,
+		UserAgent:        ua,
+		UserAgentRaw:     ua,
+		IPAddress:        ip,
+		CreatedAt:        now,
+		LastActive:       now,
+		IsActive:         true,
+		DeviceName:       "web",
+		DeviceType:       "browser",
+		RefreshTokenHash: hashSecret(refreshToken),
+		ExpiresAt:        now.Add(s.refreshTokenTTL()),
+	}
+	if err := s.sessions.Insert(ctx, &session); err != nil {
+		return AuthTokens{}, err
+	}
+	access, err := s.generateToken(user, session.ID)
+	if err != nil {
+		return AuthTokens{}, err
+	}
+	return AuthTokens{AccessToken: access, RefreshToken: refreshToken, ExpiresIn: int64(s.accessTokenTTL().Seconds())}, nil
+}
+
+// newRefreshToken returns a random opaque refresh token
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecret is how refresh tokens and API keys are stored, so a leaked
+// collection does not hand out working credentials
+func hashSecret(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // verifyTotpOrBackup validates a 2FA code using either TOTP or backup codes
 func (s *AuthService) verifyTotpOrBackup(ctx context.Context, user models.User, code string) (bool, error) {
 	if code == "" {
-		return false, errors.New("verification code required")
+		return false, ErrVerificationCodeRequired
 	}
-	if totp.Validate(code, user.TwoFactorSecret) {
+	// Users with only a security key have no TOTP secret, and an empty one
+	// would validate codes anyone can compute
+	if user.TwoFactor && user.TwoFactorSecret != "" && totp.Validate(code, user.TwoFactorSecret) {
 		return true, nil
 	}
 	for i, b := range user.BackupCodes {
 		if !b.Used && b.Code == code {
 			user.BackupCodes[i].Used = true
 			user.BackupCodes[i].UsedAt = time.Now()
-			_, _ = s.users().UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"backupCodes": user.BackupCodes}})
+			_ = s.users.Update(ctx, user.ID, bson.M{"backupCodes": user.BackupCodes})
 			return true, nil
 		}
 	}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// emailVerificationTTL is how long a verification token can be used
+	emailVerificationTTL = 48 * time.Hour
+	// passwordResetTTL is how long a password reset token can be used
+	passwordResetTTL = time.Hour
+	// passwordResetCooldown is how soon after one reset email another is sent
+	passwordResetCooldown = time.Minute
+	// maxResetAttempts is how many wrong second factors burn a reset token
+	maxResetAttempts = 5
+)
+
+// SetEmailService sets the service that sends verification and password
+// reset emails; without one neither is available
+func (s *AuthService) SetEmailService(email *EmailService) {
+	s.email = email
+}
+
+// EmailEnabled reports whether verification and password reset emails can be sent
+func (s *AuthService) EmailEnabled() bool {
+	return s.email.Enabled()
+}
+
+// emailTokens returns the MongoDB email_tokens collection
+func (s *AuthService) emailTokens() *mongo.Collection {
+	return s.db.Collection("email_tokens")
+}
+
+// EnsureIndexes creates the email token and security key indexes, and the
+// TTL indexes that expire tokens and challenges
+func (s *AuthService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.emailTokens().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+	return s.ensureWebAuthnIndexes(ctx)
+}
+
+// issueEmailToken creates a token for purpose, replacing any the user was
+// sent before, and returns it
+func (s *AuthService) issueEmailToken(ctx context.Context, user models.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.emailTokens().DeleteMany(ctx, bson.M{"userId": user.ID, "purpose": purpose}); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	_, err = s.emailTokens().InsertOne(ctx, models.EmailToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Purpose:   purpose,
+		TokenHash: hashSecret(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// findEmailToken returns the unexpired token issued for purpose
+func (s *AuthService) findEmailToken(ctx context.Context, token, purpose string) (models.EmailToken, error) {
+	var record models.EmailToken
+	if token == "" {
+		return record, ErrInvalidEmailToken
+	}
+	err := s.emailTokens().FindOne(ctx, bson.M{
+		"tokenHash": hashSecret(token),
+		"purpose":   purpose,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return record, ErrInvalidEmailToken
+	}
+	return record, err
+}
+
+// consumeEmailToken redeems a token found with findEmailToken; each token
+// works once
+func (s *AuthService) consumeEmailToken(ctx context.Context, record models.EmailToken) error {
+	res, err := s.emailTokens().DeleteOne(ctx, bson.M{"_id": record.ID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrInvalidEmailToken
+	}
+	return nil
+}
+
+// failEmailToken records a wrong second factor given with a token and
+// deletes the token once maxResetAttempts have failed
+func (s *AuthService) failEmailToken(ctx context.Context, record models.EmailToken) error {
+	var updated models.EmailToken
+	err := s.emailTokens().FindOneAndUpdate(ctx,
+		bson.M{"_id": record.ID},
+		bson.M{"$inc": bson.M{"failedAttempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if updated.FailedAttempts < maxResetAttempts {
+		return nil
+	}
+	_, err = s.emailTokens().DeleteOne(ctx, bson.M{"_id": record.ID})
+	return err
+}
+
+// SendVerificationEmail emails the user a token that verifies their address.
+// Users who are already verified are skipped.
+func (s *AuthService) SendVerificationEmail(ctx context.Context, user models.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+	if !s.email.Enabled() {
+		return ErrEmailDisabled
+	}
+	token, err := s.issueEmailToken(ctx, user, models.EmailTokenVerify, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.email.Send(ctx, s.email.verificationEmail(user.Email, token))
+}
+
+// ResendVerificationEmail sends a signed-in user a new verification email
+func (s *AuthService) ResendVerificationEmail(ctx context.Context, userID primitive.ObjectID) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return s.SendVerificationEmail(ctx, *user)
+}
+
+// VerifyEmail marks the address a verification token was sent to as verified
+// and returns its user
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (models.User, error) {
+	record, err := s.findEmailToken(ctx, token, models.EmailTokenVerify)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := s.consumeEmailToken(ctx, record); err != nil {
+		return models.User{}, err
+	}
+	user, err := s.users.VerifyEmail(ctx, record.UserID, record.Email)
+	if errors.Is(err, ErrUserNotFound) {
+		return models.User{}, ErrInvalidEmailToken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	return s.promoteIfAdmin(ctx, user)
+}
+
+// RequestPasswordReset emails a password reset token to the account with
+// this email, at most once a minute. An unknown email is not an error, so
+// callers cannot tell which addresses have accounts.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	if !s.email.Enabled() {
+		return ErrEmailDisabled
+	}
+	user, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	recent, err := s.emailTokens().CountDocuments(ctx, bson.M{
+		"userId":    user.ID,
+		"purpose":   models.EmailTokenReset,
+		"createdAt": bson.M{"$gt": time.Now().Add(-passwordResetCooldown)},
+	})
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return nil
+	}
+	token, err := s.issueEmailToken(ctx, user, models.EmailTokenReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.email.Send(ctx, s.email.passwordResetEmail(user.Email, token))
+}
+
+// ResetPassword sets a new password with a reset token, verifies the email it
+// was sent to and ends all of the user's sessions. Accounts with 2FA or a
+// security key also need a TOTP or backup code, or an assertion answering a
+// WebAuthnChallengeReset challenge, so a stolen mailbox alone cannot take
+// them over. The token stays usable while the second factor is missing, but
+// maxResetAttempts wrong ones void it.
+func (s *AuthService) ResetPassword(ctx context.Context, token, password, totpToken string, assertion *WebAuthnAssertion) (models.User, error) {
+	if password == "" {
+		return models.User{}, ErrPasswordRequired
+	}
+	record, err := s.findEmailToken(ctx, token, models.EmailTokenReset)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := s.users.Get(ctx, record.UserID)
+	// The token is void once the account's email has changed
+	if errors.Is(err, ErrUserNotFound) || err == nil && user.Email != record.Email {
+		return models.User{}, ErrInvalidEmailToken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if s.secondFactorRequired(ctx, user) {
+		if err := s.verifySecondFactor(ctx, user, totpToken, assertion, models.WebAuthnChallengeReset); err != nil {
+			if !errors.Is(err, ErrTwoFactorRequired) {
+				if ferr := s.failEmailToken(ctx, record); ferr != nil {
+					return user, ferr
+				}
+			}
+			return user, err
+		}
+	}
+	if err := s.consumeEmailToken(ctx, record); err != nil {
+		return models.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := s.users.Update(ctx, user.ID, bson.M{"password": string(hash), "emailVerified": true}); err != nil {
+		return models.User{}, err
+	}
+	user.EmailVerified = true
+	if user, err = s.promoteIfAdmin(ctx, user); err != nil {
+		return user, err
+	}
+	if _, err := s.sessions.RevokeAll(ctx, user.ID, primitive.NilObjectID); err != nil {
+		return user, err
+	}
+	return user, nil
+}
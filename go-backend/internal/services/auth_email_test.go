@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "reset@example.com", "old-password", "Reset Test")
+	require.NoError(t, err)
+	tokens, _, err := service.Login(ctx, "reset@example.com", "old-password", "", "127.0.0.1", "agent1")
+	require.NoError(t, err)
+
+	token, err := service.issueEmailToken(ctx, user, models.EmailTokenReset, passwordResetTTL)
+	require.NoError(t, err)
+
+	_, err = service.ResetPassword(ctx, "not-a-token", "new-password", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+	_, err = service.ResetPassword(ctx, token, "", "", nil)
+	assert.ErrorIs(t, err, ErrPasswordRequired)
+
+	reset, err := service.ResetPassword(ctx, token, "new-password", "", nil)
+	require.NoError(t, err)
+	assert.True(t, reset.EmailVerified)
+
+	// The new password works, the old sessions are over and the token is spent
+	_, _, err = service.Login(ctx, "reset@example.com", "new-password", "", "127.0.0.1", "agent1")
+	require.NoError(t, err)
+	_, _, err = service.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.ResetPassword(ctx, token, "another-password", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+
+	// Expired tokens are rejected
+	expired, err := service.issueEmailToken(ctx, user, models.EmailTokenReset, -time.Minute)
+	require.NoError(t, err)
+	_, err = service.ResetPassword(ctx, expired, "new-password", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+}
+
+func TestAuthService_ResetPasswordTwoFactor(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "reset2fa@example.com", "old-password", "Reset 2FA Test")
+	require.NoError(t, err)
+	secret, _, _, err := service.TwoFactorSetup(user.Email)
+	require.NoError(t, err)
+	err = service.users.Update(ctx, user.ID, bson.M{"twoFactorEnabled": true, "twoFactorSecret": secret})
+	require.NoError(t, err)
+
+	token, err := service.issueEmailToken(ctx, user, models.EmailTokenReset, passwordResetTTL)
+	require.NoError(t, err)
+
+	// The token is kept until the 2FA code is right
+	_, err = service.ResetPassword(ctx, token, "new-password", "", nil)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	_, err = service.ResetPassword(ctx, token, "new-password", "000000", nil)
+	assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	_, err = service.ResetPassword(ctx, token, "new-password", code, nil)
+	require.NoError(t, err)
+}
+
+func TestAuthService_ResetPasswordBurnsTokenAfterWrongCodes(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "reset-guess@example.com", "old-password", "Reset Guess Test")
+	require.NoError(t, err)
+	secret, _, _, err := service.TwoFactorSetup(user.Email)
+	require.NoError(t, err)
+	err = service.users.Update(ctx, user.ID, bson.M{"twoFactorEnabled": true, "twoFactorSecret": secret})
+	require.NoError(t, err)
+
+	token, err := service.issueEmailToken(ctx, user, models.EmailTokenReset, passwordResetTTL)
+	require.NoError(t, err)
+
+	// Asking for the code is not a failed attempt
+	_, err = service.ResetPassword(ctx, token, "new-password", "", nil)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	for i := 0; i < maxResetAttempts; i++ {
+		_, err = service.ResetPassword(ctx, token, "new-password", "000000", nil)
+		assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+	}
+
+	// The right code comes too late: guessing has voided the token
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	_, err = service.ResetPassword(ctx, token, "new-password", code, nil)
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+	_, _, err = service.Login(ctx, user.Email, "old-password", code, "", "")
+	require.NoError(t, err, "the password is unchanged")
+}
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "verify@example.com", "password", "Verify Test")
+	require.NoError(t, err)
+	assert.False(t, user.EmailVerified)
+
+	// Without email settings nothing can be sent
+	assert.ErrorIs(t, service.SendVerificationEmail(ctx, user), ErrEmailDisabled)
+
+	token, err := service.issueEmailToken(ctx, user, models.EmailTokenVerify, emailVerificationTTL)
+	require.NoError(t, err)
+	// A verification token cannot reset the password
+	_, err = service.ResetPassword(ctx, token, "new-password", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+
+	verified, err := service.VerifyEmail(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified)
+	_, err = service.VerifyEmail(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidEmailToken)
+	assert.ErrorIs(t, service.ResendVerificationEmail(ctx, user.ID), ErrEmailAlreadyVerified)
+}
@@ -67,12 +67,12 @@ func TestAuthService_Register(t *testing.T) {
 	ctx := context.Background()
 
 	tests := []struct {
-		name        string
-		email       string
-		password    string
-		username    string
-		wantErr     bool
-		errContains string
+		name      string
+		email     string
+		password  string
+		username  string
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name:     "successful registration",
@@ -82,20 +82,20 @@ func TestAuthService_Register(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name:        "empty email",
-			email:       "",
-			password:    "password123",
-			username:    "Test User",
-			wantErr:     true,
-			errContains: "email and password required",
+			name:      "empty email",
+			email:     "",
+			password:  "password123",
+			username:  "Test User",
+			wantErr:   true,
+			wantErrIs: ErrMissingCredentials,
 		},
 		{
-			name:        "empty password",
-			email:       "test2@example.com",
-			password:    "",
-			username:    "Test User",
-			wantErr:     true,
-			errContains: "email and password required",
+			name:      "empty password",
+			email:     "test2@example.com",
+			password:  "",
+			username:  "Test User",
+			wantErr:   true,
+			wantErrIs: ErrMissingCredentials,
 		},
 		{
 			name:     "email normalization",
@@ -112,8 +112,8 @@ func TestAuthService_Register(t *testing.T) {
 
 			if tt.wantErr {
 				assert.Error(t, err)
-				if tt.errContains != "" {
-					assert.Contains(t, err.Error(), tt.errContains)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
 				}
 				return
 			}
@@ -138,7 +138,7 @@ func TestAuthService_Register(t *testing.T) {
 
 		_, _, err = service.Register(ctx, email, "password2", "User2")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "user already exists")
+		assert.ErrorIs(t, err, ErrUserExists)
 	})
 }
 
@@ -158,12 +158,12 @@ func TestAuthService_Login(t *testing.T) {
 	require.NoError(t, err)
 
 	tests := []struct {
-		name        string
-		email       string
-		password    string
-		totpToken   string
-		wantErr     bool
-		errContains string
+		name      string
+		email     string
+		password  string
+		totpToken string
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name:     "successful login",
@@ -172,18 +172,18 @@ func TestAuthService_Login(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name:        "wrong password",
-			email:       email,
-			password:    "wrong-password",
-			wantErr:     true,
-			errContains: "invalid email or password",
+			name:      "wrong password",
+			email:     email,
+			password:  "wrong-password",
+			wantErr:   true,
+			wantErrIs: ErrInvalidCredentials,
 		},
 		{
-			name:        "non-existent user",
-			email:       "nonexistent@example.com",
-			password:    "password123",
-			wantErr:     true,
-			errContains: "invalid email or password",
+			name:      "non-existent user",
+			email:     "nonexistent@example.com",
+			password:  "password123",
+			wantErr:   true,
+			wantErrIs: ErrInvalidCredentials,
 		},
 		{
 			name:     "email case insensitive",
@@ -199,8 +199,8 @@ func TestAuthService_Login(t *testing.T) {
 
 			if tt.wantErr {
 				assert.Error(t, err)
-				if tt.errContains != "" {
-					assert.Contains(t, err.Error(), tt.errContains)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
 				}
 				return
 			}
@@ -227,8 +227,10 @@ func TestAuthService_GenerateAndParseToken(t *testing.T) {
 		Name:  "Token Test",
 	}
 
+	sessionID := primitive.NewObjectID()
+
 	// Test token generation
-	token, err := service.generateToken(user)
+	token, err := service.generateToken(user, sessionID)
 	require.NoError(t, err)
 	assert.NotEmpty(t, token)
 
@@ -236,6 +238,7 @@ func TestAuthService_GenerateAndParseToken(t *testing.T) {
 	claims, err := service.ParseToken(token)
 	require.NoError(t, err)
 	assert.Equal(t, user.ID.Hex(), claims["userId"])
+	assert.Equal(t, sessionID.Hex(), claims["sessionId"])
 	assert.Equal(t, user.Email, claims["email"])
 	assert.Equal(t, user.Name, claims["username"])
 
@@ -265,11 +268,11 @@ func TestAuthService_ChangePassword(t *testing.T) {
 	require.NoError(t, err)
 
 	tests := []struct {
-		name        string
-		current     string
-		next        string
-		wantErr     bool
-		errContains string
+		name      string
+		current   string
+		next      string
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name:    "successful password change",
@@ -278,11 +281,11 @@ func TestAuthService_ChangePassword(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:        "wrong current password",
-			current:     "wrong-password",
-			next:        "new-password",
-			wantErr:     true,
-			errContains: "current password is incorrect",
+			name:      "wrong current password",
+			current:   "wrong-password",
+			next:      "new-password",
+			wantErr:   true,
+			wantErrIs: ErrIncorrectPassword,
 		},
 	}
 
@@ -292,8 +295,8 @@ func TestAuthService_ChangePassword(t *testing.T) {
 
 			if tt.wantErr {
 				assert.Error(t, err)
-				if tt.errContains != "" {
-					assert.Contains(t, err.Error(), tt.errContains)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
 				}
 				return
 			}
@@ -401,9 +404,7 @@ func TestAuthService_MonthlyTokenReset(t *testing.T) {
 
 	// Simulate month change by manually updating the user's token usage month
 	lastMonth := time.Now().AddDate(0, -1, 0).Format("2006-01")
-	_, err = service.users().UpdateByID(ctx, user.ID, bson.M{
-		"$set": bson.M{"tokenUsage.currentMonth": lastMonth},
-	})
+	err = service.users.Update(ctx, user.ID, bson.M{"tokenUsage.currentMonth": lastMonth})
 	require.NoError(t, err)
 
 	// GetUser should reset the token usage
@@ -446,6 +447,109 @@ func TestAuthService_Sessions(t *testing.T) {
 	assert.False(t, sessions[0].IsActive)
 }
 
+func TestAuthService_Refresh(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "refresh@example.com", "password", "Refresh Test")
+	require.NoError(t, err)
+	tokens, _, err := service.Login(ctx, "refresh@example.com", "password", "", "127.0.0.1", "agent1")
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, int64(defaultAccessTokenTTL.Seconds()), tokens.ExpiresIn)
+
+	_, err = service.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+
+	// Refreshing rotates the refresh token
+	next, refreshed, err := service.Refresh(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, refreshed.ID)
+	assert.NotEqual(t, tokens.RefreshToken, next.RefreshToken)
+	_, err = service.Authenticate(ctx, next.AccessToken)
+	require.NoError(t, err)
+
+	// Unknown tokens are rejected
+	_, _, err = service.Refresh(ctx, "not-a-refresh-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Replaying the rotated token terminates the session
+	_, _, err = service.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, _, err = service.Refresh(ctx, next.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.Authenticate(ctx, next.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthService_TerminateSessionRevokesTokens(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, user, err := service.Register(ctx, "revoke@example.com", "password", "Revoke Test")
+	require.NoError(t, err)
+	tokens, _, err := service.Login(ctx, "revoke@example.com", "password", "", "127.0.0.1", "agent1")
+	require.NoError(t, err)
+
+	claims, err := service.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	sessionID, err := primitive.ObjectIDFromHex(claims["sessionId"].(string))
+	require.NoError(t, err)
+
+	require.NoError(t, service.TerminateSession(ctx, user.ID, sessionID))
+
+	_, err = service.Authenticate(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, _, err = service.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAccessTokenLifetime(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{name: "default", ttl: 0, want: defaultAccessTokenTTL},
+		{name: "configured", ttl: 5 * time.Minute, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &AuthService{cfg: config.Config{JWTSecret: "test-secret", AccessTokenTTL: tt.ttl}}
+			token, err := service.generateToken(models.User{ID: primitive.NewObjectID()}, primitive.NewObjectID())
+			require.NoError(t, err)
+
+			claims, err := service.ParseToken(token)
+			require.NoError(t, err)
+			exp, err := claims.GetExpirationTime()
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(tt.want), exp.Time, 5*time.Second)
+		})
+	}
+}
+
+func TestRefreshTokenHashing(t *testing.T) {
+	a, err := newRefreshToken()
+	require.NoError(t, err)
+	b, err := newRefreshToken()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, hashSecret(a), hashSecret(a))
+	assert.NotEqual(t, hashSecret(a), hashSecret(b))
+	assert.NotContains(t, hashSecret(a), a)
+}
+
 func TestPasswordHashing(t *testing.T) {
 	password := "test-password-123"
 
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Second factors a user can sign in with, as listed by TwoFactorMethods
+const (
+	TwoFactorTOTP       = "totp"
+	TwoFactorWebAuthn   = "webauthn"
+	TwoFactorBackupCode = "backup_code"
+)
+
+// webAuthnCredentials returns the MongoDB webauthn_credentials collection
+func (s *AuthService) webAuthnCredentials() *mongo.Collection {
+	return s.db.Collection("webauthn_credentials")
+}
+
+// webAuthnChallenges returns the MongoDB webauthn_challenges collection
+func (s *AuthService) webAuthnChallenges() *mongo.Collection {
+	return s.db.Collection("webauthn_challenges")
+}
+
+// ensureWebAuthnIndexes creates the credential lookup indexes and the
+// challenge TTL index
+func (s *AuthService) ensureWebAuthnIndexes(ctx context.Context) error {
+	_, err := s.webAuthnCredentials().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "credentialId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.webAuthnChallenges().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// WebAuthnEnabled reports whether security keys can be registered and used
+func (s *AuthService) WebAuthnEnabled() bool {
+	return s.cfg.WebAuthnRPID != "" && len(s.cfg.WebAuthnOrigins) > 0
+}
+
+// webAuthnRP returns the configured relying party
+func (s *AuthService) webAuthnRP() webAuthnRP {
+	name := s.cfg.WebAuthnRPName
+	if name == "" {
+		name = s.cfg.WebAuthnRPID
+	}
+	return webAuthnRP{id: s.cfg.WebAuthnRPID, name: name, origins: s.cfg.WebAuthnOrigins}
+}
+
+// issueWebAuthnChallenge creates a challenge for purpose, replacing any the
+// user was given before, and returns it base64url encoded
+func (s *AuthService) issueWebAuthnChallenge(ctx context.Context, userID primitive.ObjectID, purpose string) (string, error) {
+	challenge, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.webAuthnChallenges().DeleteMany(ctx, bson.M{"userId": userID, "purpose": purpose}); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	_, err = s.webAuthnChallenges().InsertOne(ctx, models.WebAuthnChallenge{
+		UserID:        userID,
+		Purpose:       purpose,
+		ChallengeHash: hashSecret(challenge),
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(webAuthnTimeout),
+	})
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeWebAuthnChallenge redeems a challenge the user was issued for
+// purpose; each challenge works once
+func (s *AuthService) consumeWebAuthnChallenge(ctx context.Context, userID primitive.ObjectID, purpose, challenge string) error {
+	err := s.webAuthnChallenges().FindOneAndDelete(ctx, bson.M{
+		"userId":        userID,
+		"purpose":       purpose,
+		"challengeHash": hashSecret(challenge),
+		"expiresAt":     bson.M{"$gt": time.Now()},
+	}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrWebAuthnChallenge
+	}
+	return err
+}
+
+// GetWebAuthnCredentials lists the security keys a user registered
+func (s *AuthService) GetWebAuthnCredentials(ctx context.Context, userID primitive.ObjectID) ([]models.WebAuthnCredential, error) {
+	cursor, err := s.webAuthnCredentials().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	creds := []models.WebAuthnCredential{}
+	if err := cursor.All(ctx, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// webAuthnDescriptors names credentials for the browser
+func webAuthnDescriptors(creds []models.WebAuthnCredential) []WebAuthnCredentialDescriptor {
+	out := make([]WebAuthnCredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		out = append(out, WebAuthnCredentialDescriptor{Type: "public-key", ID: cred.CredentialID, Transports: cred.Transports})
+	}
+	return out
+}
+
+// BeginWebAuthnRegistration returns the options for registering a new
+// security key; keys the user already registered are excluded
+func (s *AuthService) BeginWebAuthnRegistration(ctx context.Context, userID primitive.ObjectID) (WebAuthnCreationOptions, error) {
+	if !s.WebAuthnEnabled() {
+		return WebAuthnCreationOptions{}, ErrWebAuthnDisabled
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return WebAuthnCreationOptions{}, err
+	}
+	creds, err := s.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return WebAuthnCreationOptions{}, err
+	}
+	challenge, err := s.issueWebAuthnChallenge(ctx, userID, models.WebAuthnChallengeRegister)
+	if err != nil {
+		return WebAuthnCreationOptions{}, err
+	}
+	rp := s.webAuthnRP()
+	displayName := user.Name
+	if displayName == "" {
+		displayName = user.Email
+	}
+	return WebAuthnCreationOptions{
+		Challenge: challenge,
+		RP:        WebAuthnRelyingParty{ID: rp.id, Name: rp.name},
+		// The user handle is the account's object ID, which reveals nothing
+		// about the user
+		User: WebAuthnUser{ID: base64.RawURLEncoding.EncodeToString(userID[:]), Name: user.Email, DisplayName: displayName},
+		PubKeyCredParams: []WebAuthnCredentialParam{
+			{Type: "public-key", Alg: coseAlgES256},
+			{Type: "public-key", Alg: coseAlgEdDSA},
+			{Type: "public-key", Alg: coseAlgRS256},
+		},
+		Timeout:                webAuthnTimeout.Milliseconds(),
+		ExcludeCredentials:     webAuthnDescriptors(creds),
+		AuthenticatorSelection: WebAuthnAuthenticatorSelection{ResidentKey: "discouraged", UserVerification: "discouraged"},
+		Attestation:            "none",
+	}, nil
+}
+
+// FinishWebAuthnRegistration verifies and stores a new security key. Users
+// without unused backup codes are given a fresh set, returned here, so they
+// can still sign in where security keys cannot be used (such as the TUI).
+func (s *AuthService) FinishWebAuthnRegistration(ctx context.Context, userID primitive.ObjectID, name string, att WebAuthnAttestation) (models.WebAuthnCredential, []string, error) {
+	if !s.WebAuthnEnabled() {
+		return models.WebAuthnCredential{}, nil, ErrWebAuthnDisabled
+	}
+	reg, err := s.webAuthnRP().parseAttestation(att)
+	if err != nil {
+		return models.WebAuthnCredential{}, nil, err
+	}
+	if err := s.consumeWebAuthnChallenge(ctx, userID, models.WebAuthnChallengeRegister, reg.challenge); err != nil {
+		return models.WebAuthnCredential{}, nil, err
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return models.WebAuthnCredential{}, nil, err
+	}
+
+	cred := reg.credential
+	cred.UserID = userID
+	cred.Name = strings.TrimSpace(name)
+	if cred.Name == "" {
+		cred.Name = "Security key"
+	}
+	cred.CreatedAt = time.Now()
+	res, err := s.webAuthnCredentials().InsertOne(ctx, cred)
+	if mongo.IsDuplicateKeyError(err) {
+		return models.WebAuthnCredential{}, nil, ErrWebAuthnCredentialExists
+	}
+	if err != nil {
+		return models.WebAuthnCredential{}, nil, err
+	}
+	cred.ID = res.InsertedID.(primitive.ObjectID)
+
+	if unusedBackupCodes(*user) > 0 {
+		return cred, nil, nil
+	}
+	backup := generateBackupCodes()
+	codes := make([]models.BackupCode, 0, len(backup))
+	for _, code := range backup {
+		codes = append(codes, models.BackupCode{Code: code, Used: false})
+	}
+	if err := s.users.Update(ctx, userID, bson.M{"backupCodes": codes}); err != nil {
+		return cred, nil, err
+	}
+	return cred, backup, nil
+}
+
+// DeleteWebAuthnCredential removes one of the user's security keys
+func (s *AuthService) DeleteWebAuthnCredential(ctx context.Context, userID primitive.ObjectID, credentialID string) error {
+	id, err := primitive.ObjectIDFromHex(credentialID)
+	if err != nil {
+		return ErrWebAuthnCredentialNotFound
+	}
+	res, err := s.webAuthnCredentials().DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebAuthnCredentialNotFound
+	}
+	return nil
+}
+
+// hasWebAuthn reports whether the user registered any security key
+func (s *AuthService) hasWebAuthn(ctx context.Context, userID primitive.ObjectID) bool {
+	n, err := s.webAuthnCredentials().CountDocuments(ctx, bson.M{"userId": userID}, options.Count().SetLimit(1))
+	return err == nil && n > 0
+}
+
+// secondFactorRequired reports whether signing in needs a TOTP code, backup
+// code or security key
+func (s *AuthService) secondFactorRequired(ctx context.Context, user models.User) bool {
+	return user.TwoFactor || s.hasWebAuthn(ctx, user.ID)
+}
+
+// TwoFactorMethods lists the second factors the user can sign in with.
+// Security keys are left out while WebAuthn is not configured.
+func (s *AuthService) TwoFactorMethods(ctx context.Context, user models.User) []string {
+	methods := []string{}
+	if user.TwoFactor {
+		methods = append(methods, TwoFactorTOTP)
+	}
+	if s.WebAuthnEnabled() && s.hasWebAuthn(ctx, user.ID) {
+		methods = append(methods, TwoFactorWebAuthn)
+	}
+	if unusedBackupCodes(user) > 0 && s.secondFactorRequired(ctx, user) {
+		methods = append(methods, TwoFactorBackupCode)
+	}
+	return methods
+}
+
+// BeginWebAuthnLogin returns the options for signing in with one of the
+// user's security keys. It is called once the password has been checked.
+func (s *AuthService) BeginWebAuthnLogin(ctx context.Context, user models.User) (WebAuthnRequestOptions, error) {
+	return s.BeginWebAuthnAssertion(ctx, user, models.WebAuthnChallengeLogin)
+}
+
+// BeginWebAuthnAssertion returns the options for answering a challenge
+// issued for purpose with one of the user's security keys
+func (s *AuthService) BeginWebAuthnAssertion(ctx context.Context, user models.User, purpose string) (WebAuthnRequestOptions, error) {
+	if !s.WebAuthnEnabled() {
+		return WebAuthnRequestOptions{}, ErrWebAuthnDisabled
+	}
+	creds, err := s.GetWebAuthnCredentials(ctx, user.ID)
+	if err != nil {
+		return WebAuthnRequestOptions{}, err
+	}
+	if len(creds) == 0 {
+		return WebAuthnRequestOptions{}, ErrWebAuthnCredentialNotFound
+	}
+	challenge, err := s.issueWebAuthnChallenge(ctx, user.ID, purpose)
+	if err != nil {
+		return WebAuthnRequestOptions{}, err
+	}
+	return WebAuthnRequestOptions{
+		Challenge:        challenge,
+		Timeout:          webAuthnTimeout.Milliseconds(),
+		RPID:             s.cfg.WebAuthnRPID,
+		AllowCredentials: webAuthnDescriptors(creds),
+		UserVerification: "discouraged",
+	}, nil
+}
+
+// LoginWebAuthn authenticates a user with email/password and a security key
+// assertion answering the challenge from BeginWebAuthnLogin
+func (s *AuthService) LoginWebAuthn(ctx context.Context, email, password string, assertion WebAuthnAssertion, ip, ua string) (AuthTokens, models.User, error) {
+	return s.login(ctx, email, password, ip, ua, func(user models.User) error {
+		return s.verifyWebAuthnAssertion(ctx, user, assertion, models.WebAuthnChallengeLogin)
+	})
+}
+
+// verifyWebAuthnAssertion checks an assertion from one of the user's
+// security keys answering a challenge issued for purpose, and records the
+// key's new signature counter
+func (s *AuthService) verifyWebAuthnAssertion(ctx context.Context, user models.User, assertion WebAuthnAssertion, purpose string) error {
+	if !s.WebAuthnEnabled() {
+		return ErrWebAuthnDisabled
+	}
+	var cred models.WebAuthnCredential
+	err := s.webAuthnCredentials().FindOne(ctx, bson.M{"userId": user.ID, "credentialId": strings.TrimRight(assertion.ID, "=")}).Decode(&cred)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrWebAuthnCredentialNotFound
+	}
+	if err != nil {
+		return err
+	}
+	challenge, signCount, err := s.webAuthnRP().verifyAssertion(cred, assertion)
+	if err != nil {
+		return err
+	}
+	if err := s.consumeWebAuthnChallenge(ctx, user.ID, purpose, challenge); err != nil {
+		return err
+	}
+	_, err = s.webAuthnCredentials().UpdateByID(ctx, cred.ID, bson.M{"$set": bson.M{"signCount": signCount, "lastUsedAt": time.Now()}})
+	return err
+}
+
+// verifySecondFactor checks the second factor of a user who has one: a
+// security key assertion answering a challenge issued for purpose, or else a
+// TOTP or backup code. Without either it returns ErrTwoFactorRequired.
+func (s *AuthService) verifySecondFactor(ctx context.Context, user models.User, code string, assertion *WebAuthnAssertion, purpose string) error {
+	if assertion != nil {
+		return s.verifyWebAuthnAssertion(ctx, user, *assertion, purpose)
+	}
+	ok, err := s.verifyTotpOrBackup(ctx, user, code)
+	if errors.Is(err, ErrVerificationCodeRequired) {
+		return ErrTwoFactorRequired
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidVerificationCode
+	}
+	return nil
+}
+
+// unusedBackupCodes counts the backup codes the user has not used
+func unusedBackupCodes(user models.User) int {
+	count := 0
+	for _, code := range user.BackupCodes {
+		if !code.Used {
+			count++
+		}
+	}
+	return count
+}
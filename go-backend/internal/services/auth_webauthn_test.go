@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestAuthService_WebAuthnLogin(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := service.BeginWebAuthnRegistration(ctx, primitive.NilObjectID)
+	assert.ErrorIs(t, err, ErrWebAuthnDisabled)
+	service.cfg.WebAuthnRPID = testRP.id
+	service.cfg.WebAuthnOrigins = testRP.origins
+
+	_, user, err := service.Register(ctx, "webauthn@example.com", "password", "WebAuthn Test")
+	require.NoError(t, err)
+	key := newTestAuthenticator(t)
+
+	options, err := service.BeginWebAuthnRegistration(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, options.ExcludeCredentials)
+	cred, codes, err := service.FinishWebAuthnRegistration(ctx, user.ID, "YubiKey", key.create(options.Challenge))
+	require.NoError(t, err)
+	assert.Equal(t, "YubiKey", cred.Name)
+	assert.Len(t, codes, 10)
+
+	// The challenge works once
+	_, _, err = service.FinishWebAuthnRegistration(ctx, user.ID, "YubiKey", key.create(options.Challenge))
+	assert.ErrorIs(t, err, ErrWebAuthnChallenge)
+
+	// Signing in now needs the key, or a backup code where keys cannot be used
+	_, loginUser, err := service.Login(ctx, "webauthn@example.com", "password", "", "127.0.0.1", "agent1")
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	assert.Equal(t, []string{TwoFactorWebAuthn, TwoFactorBackupCode}, service.TwoFactorMethods(ctx, loginUser))
+
+	request, err := service.BeginWebAuthnLogin(ctx, loginUser)
+	require.NoError(t, err)
+	require.Len(t, request.AllowCredentials, 1)
+	assert.Equal(t, cred.CredentialID, request.AllowCredentials[0].ID)
+	_, _, err = service.LoginWebAuthn(ctx, "webauthn@example.com", "password", key.get(request.Challenge), "127.0.0.1", "agent1")
+	require.NoError(t, err)
+	_, _, err = service.LoginWebAuthn(ctx, "webauthn@example.com", "password", key.get(request.Challenge), "127.0.0.1", "agent1")
+	assert.ErrorIs(t, err, ErrWebAuthnChallenge)
+
+	_, _, err = service.Login(ctx, "webauthn@example.com", "password", codes[0], "127.0.0.1", "agent1")
+	require.NoError(t, err)
+
+	// Without keys the password is enough again
+	require.NoError(t, service.DeleteWebAuthnCredential(ctx, user.ID, cred.ID.Hex()))
+	assert.ErrorIs(t, service.DeleteWebAuthnCredential(ctx, user.ID, cred.ID.Hex()), ErrWebAuthnCredentialNotFound)
+	_, _, err = service.Login(ctx, "webauthn@example.com", "password", "", "127.0.0.1", "agent1")
+	require.NoError(t, err)
+}
+
+// registerTestKey registers a security key for a new user, whose only second
+// factor is then the key and the backup codes it came with
+func registerTestKey(t *testing.T, service *AuthService, email string) (models.User, *testAuthenticator, []string) {
+	ctx := context.Background()
+	service.cfg.WebAuthnRPID = testRP.id
+	service.cfg.WebAuthnOrigins = testRP.origins
+	_, user, err := service.Register(ctx, email, "password", "WebAuthn Only")
+	require.NoError(t, err)
+	key := newTestAuthenticator(t)
+	options, err := service.BeginWebAuthnRegistration(ctx, user.ID)
+	require.NoError(t, err)
+	_, codes, err := service.FinishWebAuthnRegistration(ctx, user.ID, "YubiKey", key.create(options.Challenge))
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+	return user, key, codes
+}
+
+func TestAuthService_WebAuthnOnlyResetPassword(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	user, key, codes := registerTestKey(t, service, "reset-key@example.com")
+	token, err := service.issueEmailToken(ctx, user, models.EmailTokenReset, passwordResetTTL)
+	require.NoError(t, err)
+
+	// The emailed token alone is not enough
+	_, err = service.ResetPassword(ctx, token, "new-password", "", nil)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	// Without a TOTP secret no TOTP code is accepted
+	if code, err := totp.GenerateCode("", time.Now()); err == nil {
+		_, err = service.ResetPassword(ctx, token, "new-password", code, nil)
+		assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+	}
+	// A sign-in challenge does not answer a reset
+	login, err := service.BeginWebAuthnLogin(ctx, user)
+	require.NoError(t, err)
+	assertion := key.get(login.Challenge)
+	_, err = service.ResetPassword(ctx, token, "new-password", "", &assertion)
+	assert.ErrorIs(t, err, ErrWebAuthnChallenge)
+
+	request, err := service.BeginWebAuthnAssertion(ctx, user, models.WebAuthnChallengeReset)
+	require.NoError(t, err)
+	assertion = key.get(request.Challenge)
+	_, err = service.ResetPassword(ctx, token, "new-password", "", &assertion)
+	require.NoError(t, err)
+
+	// A backup code works too
+	token, err = service.issueEmailToken(ctx, user, models.EmailTokenReset, passwordResetTTL)
+	require.NoError(t, err)
+	_, err = service.ResetPassword(ctx, token, "newer-password", codes[0], nil)
+	require.NoError(t, err)
+}
+
+func TestAuthService_WebAuthnOnlyConfirmIdentity(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	user, key, codes := registerTestKey(t, service, "confirm-key@example.com")
+
+	// The password alone cannot export or delete the account
+	_, err := service.ConfirmIdentity(ctx, user.ID, "password", "", "", nil)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	_, err = service.ConfirmIdentity(ctx, user.ID, "password", "", "WRONG123", nil)
+	assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+
+	request, err := service.BeginWebAuthnAssertion(ctx, user, models.WebAuthnChallengeConfirm)
+	require.NoError(t, err)
+	assertion := key.get(request.Challenge)
+	_, err = service.ConfirmIdentity(ctx, user.ID, "password", "", "", &assertion)
+	require.NoError(t, err)
+	// Each challenge works once
+	_, err = service.ConfirmIdentity(ctx, user.ID, "password", "", "", &assertion)
+	assert.ErrorIs(t, err, ErrWebAuthnChallenge)
+
+	_, err = service.ConfirmIdentity(ctx, user.ID, "password", "", codes[0], nil)
+	require.NoError(t, err)
+}
+
+func TestAuthService_WebAuthnOnlyRegenerateBackupCodes(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	user, key, codes := registerTestKey(t, service, "backup-key@example.com")
+
+	_, err := service.RegenerateBackupCodes(ctx, user.ID, "", nil)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+
+	request, err := service.BeginWebAuthnAssertion(ctx, user, models.WebAuthnChallengeConfirm)
+	require.NoError(t, err)
+	assertion := key.get(request.Challenge)
+	fresh, err := service.RegenerateBackupCodes(ctx, user.ID, "", &assertion)
+	require.NoError(t, err)
+	assert.Len(t, fresh, 10)
+
+	// The old codes are gone; a new one confirms the next regeneration
+	_, err = service.RegenerateBackupCodes(ctx, user.ID, codes[0], nil)
+	assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+	_, err = service.RegenerateBackupCodes(ctx, user.ID, fresh[0], nil)
+	require.NoError(t, err)
+}
@@ -7,7 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -33,8 +33,10 @@ type ChatMessage struct {
 type chatRequest struct {
 	Messages    []ChatMessage `json:"messages"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
-	Temperature float64       `json:"temperature,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
 	Stream      bool          `json:"stream,omitempty"`
+	// ResponseFormat is OpenAI's JSON mode, when the query asks for JSON
+	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
 }
 
 type chatResponse struct {
@@ -65,50 +67,51 @@ func (s *AzureOpenAI) Enabled() bool {
 }
 
 // Chat sends a non-streaming chat completion request and returns the first response message.
-func (s *AzureOpenAI) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (s *AzureOpenAI) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !s.Enabled() {
-		return "", 0, errors.New("azure openai not configured")
+		return "", TokenCount{}, fmt.Errorf("azure openai: %w", ErrProviderNotConfigured)
 	}
 
 	// Remove trailing slash from endpoint if present
 	endpoint := strings.TrimSuffix(s.endpoint, "/")
 	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s", endpoint, s.deployment, s.apiVersion)
 
-	log.Printf("Azure OpenAI request URL: %s", url)
+	slog.DebugContext(ctx, "Azure OpenAI request", "url", url)
 
 	reqBody := chatRequest{
-		Messages:    messages,
-		MaxTokens:   512,
-		Temperature: 0.5,
+		Messages:       messages,
+		MaxTokens:      opts.maxTokens(512),
+		Temperature:    floatPtr(opts.temperature(0.5)),
+		ResponseFormat: opts.openAIResponseFormat(true),
 	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	req.Header.Set("api-key", s.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// Read response body for more details
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Azure OpenAI error response: %s", string(body))
-		return "", 0, fmt.Errorf("azure openai request failed: %s - %s", resp.Status, string(body))
+		slog.WarnContext(ctx, "Azure OpenAI error response", "status", resp.StatusCode, "body", string(body))
+		return "", TokenCount{}, fmt.Errorf("azure openai request failed: %s - %s", resp.Status, string(body))
 	}
 
 	var parsed chatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	if len(parsed.Choices) == 0 || parsed.Choices[0].Message.Content == "" {
-		return "", 0, errors.New("azure openai returned no content")
+		return "", TokenCount{}, errors.New("azure openai returned no content")
 	}
-	return parsed.Choices[0].Message.Content, parsed.Usage.TotalTokens, nil
+	return parsed.Choices[0].Message.Content, TokenCount{Prompt: parsed.Usage.PromptTokens, Completion: parsed.Usage.CompletionTokens}, nil
 }
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultContextWindow is the context window assumed for unknown models
+const defaultContextWindow = 8192
+
+// modelContextWindows holds the context windows of known models, in tokens,
+// by model name prefix; the longest matching prefix wins
+var modelContextWindows = map[string]int{
+	"gpt-3.5-turbo":     16385,
+	"gpt-4":             8192,
+	"gpt-4-turbo":       128000,
+	"gpt-4o":            128000,
+	"gpt-4.1":           1047576,
+	"o1":                200000,
+	"o3":                200000,
+	"o4":                200000,
+	"claude":            200000,
+	"gemini":            32768,
+	"gemini-1.5-flash":  1048576,
+	"gemini-1.5-pro":    2097152,
+	"gemini-2":          1048576,
+	"mistral":           32000,
+	"mistral-large":     128000,
+	"open-mistral-nemo": 128000,
+	"grok":              131072,
+	"llama3":            8192,
+	"llama3.1":          128000,
+	"llama3.2":          128000,
+}
+
+// modelNamer is implemented by providers that know their model
+type modelNamer interface {
+	Model() string
+}
+
+// providerModel returns the model a provider sends queries to, or "" when
+// it doesn't say
+func providerModel(provider LLMProvider) string {
+	if named, ok := provider.(modelNamer); ok {
+		return named.Model()
+	}
+	return ""
+}
+
+// contextWindow returns the context window of the provider's model in
+// tokens, from LLM_MODEL_CONTEXT_WINDOWS when the model is listed there
+func (s *LLMService) contextWindow(provider LLMProvider) int {
+	model := providerModel(provider)
+	if window, ok := s.cfg.LLMModelContextWindows[model]; ok && window > 0 {
+		return window
+	}
+	best := ""
+	for prefix := range modelContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return defaultContextWindow
+	}
+	return modelContextWindows[best]
+}
+
+// queryPrompt is the user message of a query: the feed data under its
+// header, then the question
+func queryPrompt(header, contextData, question string) string {
+	return header + "\n\n" + contextData + "\n\nQuestion: " + question
+}
+
+// fitContext renders as many of the entries, newest first, as fit in the
+// provider's context window once the rest of the prompt and an answer of
+// maxTokens are allowed for, dropping the oldest first. The newest entry is
+// always sent. It returns the rendered entries and how many there are.
+func (s *LLMService) fitContext(provider LLMProvider, req QueryRequest, systemPrompt, header string, maxTokens int, entries []map[string]interface{}, render func([]map[string]interface{}) string) (string, int) {
+	if len(entries) == 0 {
+		return "", 0
+	}
+	name := provider.Name()
+	overhead := countMessageTokens(name, req.messages(systemPrompt, queryPrompt(header, "", req.Question)))
+	budget := s.contextWindow(provider) - overhead - maxTokens
+
+	// Rendering is tabular, so its size grows with the entries it holds and
+	// the most that fit can be found by bisection
+	rendered := make(map[int]string)
+	fits := func(n int) bool {
+		text := render(entries[:n])
+		rendered[n] = text
+		return countTokens(name, text) <= budget
+	}
+	if fits(len(entries)) {
+		return rendered[len(entries)], len(entries)
+	}
+	n := sort.Search(len(entries), func(i int) bool { return !fits(i + 1) })
+	n = max(n, 1)
+	if _, ok := rendered[n]; !ok {
+		rendered[n] = render(entries[:n])
+	}
+	return rendered[n], n
+}
+
+// contextUtilization is the share of the window, in percent, a prompt of
+// promptTokens fills
+func contextUtilization(promptTokens, window int) float64 {
+	if window <= 0 {
+		return 0
+	}
+	return float64(promptTokens) / float64(window) * 100
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// modelProvider is a cannedProvider that names its model
+type modelProvider struct {
+	cannedProvider
+	model string
+}
+
+func (p *modelProvider) Model() string { return p.model }
+
+func TestLLMService_ContextWindow(t *testing.T) {
+	svc := &LLMService{cfg: config.Config{LLMModelContextWindows: map[string]int{"my-finetune": 16384}}}
+
+	assert.Equal(t, 128000, svc.contextWindow(&modelProvider{model: "gpt-4o-mini"}))
+	assert.Equal(t, 8192, svc.contextWindow(&modelProvider{model: "gpt-4"}))
+	assert.Equal(t, 200000, svc.contextWindow(&modelProvider{model: "claude-3-5-sonnet-20241022"}))
+	assert.Equal(t, 2097152, svc.contextWindow(&modelProvider{model: "gemini-1.5-pro"}))
+	assert.Equal(t, 16384, svc.contextWindow(&modelProvider{model: "my-finetune"}))
+	assert.Equal(t, defaultContextWindow, svc.contextWindow(&modelProvider{model: "unknown"}))
+	assert.Equal(t, defaultContextWindow, svc.contextWindow(&cannedProvider{name: "test"}))
+}
+
+// wideEntries returns n entries, newest first, each with a long text field
+func wideEntries(n int) []map[string]interface{} {
+	entries := make([]map[string]interface{}, n)
+	for i := range entries {
+		entries[i] = map[string]interface{}{"seq": float64(n - i), "text": strings.Repeat(fmt.Sprintf("word%d ", i), 40)}
+	}
+	return entries
+}
+
+func TestLLMService_FitContext(t *testing.T) {
+	provider := &modelProvider{cannedProvider: cannedProvider{name: "openai"}, model: "small"}
+	svc := &LLMService{cfg: config.Config{LLMModelContextWindows: map[string]int{"small": 2000}}, numPrecision: -1}
+	req := QueryRequest{FeedID: "feed-1", Question: "What changed?"}
+	entries := wideEntries(50)
+
+	text, n := svc.fitContext(provider, req, "system", contextHeader(0), 500, entries, svc.csvContext)
+	require.Greater(t, n, 1)
+	require.Less(t, n, len(entries))
+	// The newest entries are kept and the result fits the budget
+	assert.Equal(t, svc.csvContext(entries[:n]), text)
+	overhead := countMessageTokens("openai", req.messages("system", queryPrompt(contextHeader(0), "", req.Question)))
+	assert.LessOrEqual(t, countTokens("openai", text), 2000-overhead-500)
+	assert.Greater(t, countTokens("openai", svc.csvContext(entries[:n+1])), 2000-overhead-500)
+
+	// A small context is sent whole, and the newest entry always goes
+	_, n = svc.fitContext(provider, req, "system", contextHeader(0), 500, entries[:2], svc.csvContext)
+	assert.Equal(t, 2, n)
+	_, n = svc.fitContext(provider, req, "system", contextHeader(0), 5000, entries, svc.csvContext)
+	assert.Equal(t, 1, n)
+}
+
+func TestLLMService_QueryReportsContextUtilization(t *testing.T) {
+	provider := &modelProvider{cannedProvider: cannedProvider{name: "openai", answer: "ok"}, model: "small"}
+	svc, err := NewLLMService(config.Config{LLMContextLimit: 50, LLMMaxProviderAttempts: 1, LLMModelContextWindows: map[string]int{"small": 2000}})
+	require.NoError(t, err)
+	svc.providers["openai"] = provider
+	svc.defaultProv = "openai"
+	svc.feedContexts["feed-1"] = &FeedContext{FeedID: "feed-1", FeedName: "Feed", Entries: wideEntries(50)}
+
+	for _, stream := range []bool{false, true} {
+		var resp *QueryResponse
+		if stream {
+			tokens := make(chan string, 10)
+			resp, err = svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest?", MaxTokens: 500}, tokens)
+		} else {
+			resp, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest?", MaxTokens: 500})
+		}
+		require.NoError(t, err)
+		assert.Greater(t, resp.ContextEntries, 0)
+		assert.Less(t, resp.ContextEntries, 50)
+		assert.Equal(t, 2000, resp.ContextWindow)
+		// The canned provider reports 10 prompt tokens
+		assert.InDelta(t, 0.5, resp.ContextUtilizationPercent, 1e-9)
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// feedContextSnapshot is the persisted form of a FeedContext. Entries are kept
+// as JSON so restored values have the same Go types as live feed data.
+type feedContextSnapshot struct {
+	FeedID    string    `bson:"_id"`
+	FeedName  string    `bson:"feedName"`
+	Entries   string    `bson:"entries"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// FeedContextStore persists LLM feed contexts so AI analysis keeps its history
+// across backend restarts.
+type FeedContextStore struct {
+	db *mongo.Database
+}
+
+func NewFeedContextStore(db *mongo.Database) *FeedContextStore {
+	return &FeedContextStore{db: db}
+}
+
+// snapshots returns the MongoDB llm_feed_contexts collection
+func (s *FeedContextStore) snapshots() *mongo.Collection {
+	return s.db.Collection("llm_feed_contexts")
+}
+
+// Save replaces the stored snapshots with contexts. Feeds no longer in
+// contexts are removed so cleared contexts are not restored later.
+func (s *FeedContextStore) Save(ctx context.Context, contexts []FeedContext) error {
+	ids := make([]string, 0, len(contexts))
+	writes := make([]mongo.WriteModel, 0, len(contexts))
+	for _, fc := range contexts {
+		entries, err := json.Marshal(fc.Entries)
+		if err != nil {
+			return fmt.Errorf("encode context for feed %s: %w", fc.FeedID, err)
+		}
+		doc := feedContextSnapshot{
+			FeedID:    fc.FeedID,
+			FeedName:  fc.FeedName,
+			Entries:   string(entries),
+			UpdatedAt: fc.UpdatedAt,
+		}
+		ids = append(ids, fc.FeedID)
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": fc.FeedID}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+
+	if len(writes) > 0 {
+		if _, err := s.snapshots().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	_, err := s.snapshots().DeleteMany(ctx, bson.M{"_id": bson.M{"$nin": ids}})
+	return err
+}
+
+// Load returns all stored snapshots. Snapshots that fail to decode are skipped.
+func (s *FeedContextStore) Load(ctx context.Context) ([]FeedContext, error) {
+	cursor, err := s.snapshots().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var docs []feedContextSnapshot
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	contexts := make([]FeedContext, 0, len(docs))
+	for _, doc := range docs {
+		var entries []map[string]interface{}
+		if err := json.Unmarshal([]byte(doc.Entries), &entries); err != nil {
+			slog.Warn("skipping unreadable context snapshot", "feed_id", doc.FeedID, "error", err)
+			continue
+		}
+		contexts = append(contexts, FeedContext{
+			FeedID:    doc.FeedID,
+			FeedName:  doc.FeedName,
+			Entries:   entries,
+			UpdatedAt: doc.UpdatedAt,
+		})
+	}
+	return contexts, nil
+}
+
+// Restore loads stored snapshots into llm, dropping entries older than maxAge
+// (0 keeps all). It returns the number of feeds restored.
+func (s *FeedContextStore) Restore(ctx context.Context, llm *LLMService, maxAge time.Duration) (int, error) {
+	contexts, err := s.Load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return llm.RestoreContexts(contexts, maxAge, time.Now()), nil
+}
+
+// Run saves llm's contexts every interval and once more when ctx is
+// cancelled, so a graceful shutdown keeps the latest data. It blocks until
+// the final save completes.
+func (s *FeedContextStore) Run(ctx context.Context, llm *LLMService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already cancelled, so the final save gets its own deadline
+			s.save(context.Background(), llm)
+			return
+		case <-ticker.C:
+			s.save(ctx, llm)
+		}
+	}
+}
+
+func (s *FeedContextStore) save(ctx context.Context, llm *LLMService) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := s.Save(ctx, llm.SnapshotContexts()); err != nil {
+		slog.Warn("failed to snapshot feed contexts", "error", err)
+	}
+}
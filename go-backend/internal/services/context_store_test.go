@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedContextStore_SaveLoad(t *testing.T) {
+	client, db, cleanup := setupTestDB(t)
+	if client == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	store := NewFeedContextStore(db)
+	ctx := context.Background()
+	updated := time.Now().UTC().Truncate(time.Millisecond)
+
+	err := store.Save(ctx, []FeedContext{
+		{FeedID: "feed-1", FeedName: "Feed 1", UpdatedAt: updated, Entries: []map[string]interface{}{
+			{"price": 1.5, "nested": map[string]interface{}{"ok": true}},
+		}},
+		{FeedID: "feed-2", FeedName: "Feed 2", UpdatedAt: updated},
+	})
+	require.NoError(t, err)
+
+	// Feeds missing from a later save are removed
+	require.NoError(t, store.Save(ctx, []FeedContext{
+		{FeedID: "feed-1", FeedName: "Feed 1", UpdatedAt: updated, Entries: []map[string]interface{}{
+			{"price": 1.5, "nested": map[string]interface{}{"ok": true}},
+		}},
+	}))
+
+	loaded, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, "feed-1", loaded[0].FeedID)
+	assert.Equal(t, updated, loaded[0].UpdatedAt.UTC())
+	assert.Equal(t, []map[string]interface{}{
+		{"price": 1.5, "nested": map[string]interface{}{"ok": true}},
+	}, loaded[0].Entries)
+}
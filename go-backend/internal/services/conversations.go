@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// maxConversationMessages caps a conversation's stored messages whatever
+	// their size
+	maxConversationMessages = 200
+	// maxConversationTitleLen caps a conversation's title, in characters
+	maxConversationTitleLen = 80
+	// maxListedConversations caps one listing of a user's conversations
+	maxListedConversations = 100
+	// conversationRetention is how long a conversation is kept after its last turn
+	conversationRetention = 30 * 24 * time.Hour
+)
+
+// ConversationService stores users' multi-turn AI chats about feeds
+type ConversationService struct {
+	db        *mongo.Database
+	maxTokens int
+}
+
+// NewConversationService creates the conversation store. Each conversation
+// keeps the latest turns that fit in maxTokens; 0 keeps every turn.
+func NewConversationService(db *mongo.Database, maxTokens int) *ConversationService {
+	return &ConversationService{db: db, maxTokens: maxTokens}
+}
+
+// conversations returns the MongoDB conversations collection
+func (s *ConversationService) conversations() *mongo.Collection {
+	return s.db.Collection("conversations")
+}
+
+// EnsureIndexes creates the index used to list a user's conversations and
+// the TTL index that expires idle ones
+func (s *ConversationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.conversations().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "updatedAt", Value: -1}}},
+		{Keys: bson.D{{Key: "updatedAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(conversationRetention.Seconds()))},
+	})
+	return err
+}
+
+// GetConversation returns one of the user's conversations
+func (s *ConversationService) GetConversation(ctx context.Context, userID string, id primitive.ObjectID) (*models.Conversation, error) {
+	var conv models.Conversation
+	err := s.conversations().FindOne(ctx, bson.M{"_id": id, "userId": userID}).Decode(&conv)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// ListConversations returns the user's conversations, on one feed when feedID
+// is set, most recent first and without their messages
+func (s *ConversationService) ListConversations(ctx context.Context, userID, feedID string) ([]models.Conversation, error) {
+	filter := bson.M{"userId": userID}
+	if feedID != "" {
+		filter["feedId"] = feedID
+	}
+	cur, err := s.conversations().Find(ctx, filter, options.Find().
+		SetSort(bson.M{"updatedAt": -1}).
+		SetLimit(maxListedConversations).
+		SetProjection(bson.M{"messages": 0}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	conversations := []models.Conversation{}
+	if err := cur.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// DeleteConversation removes one of the user's conversations
+func (s *ConversationService) DeleteConversation(ctx context.Context, userID string, id primitive.ObjectID) error {
+	res, err := s.conversations().DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+// ConversationHistory returns a conversation's messages as earlier chat turns
+func ConversationHistory(conv *models.Conversation) []ChatMessage {
+	history := make([]ChatMessage, 0, len(conv.Messages))
+	for _, m := range conv.Messages {
+		history = append(history, ChatMessage{Role: m.Role, Content: m.Content})
+	}
+	return history
+}
+
+// AddTurn appends a question and its answer to a conversation, dropping the
+// oldest turns past the token budget, and saves it. A conversation without an
+// ID is created.
+func (s *ConversationService) AddTurn(ctx context.Context, conv *models.Conversation, question, answer, provider string) error {
+	now := time.Now().UTC()
+	if conv.Title == "" {
+		conv.Title = conversationTitle(question)
+	}
+	conv.Messages = append(conv.Messages,
+		models.ConversationMessage{Role: "user", Content: question, CreatedAt: now},
+		models.ConversationMessage{Role: "assistant", Content: answer, Provider: provider, CreatedAt: now},
+	)
+	conv.Messages = trimConversation(conv.Messages, s.maxTokens, provider)
+	conv.Turns++
+	conv.UpdatedAt = now
+
+	if conv.ID.IsZero() {
+		conv.CreatedAt = now
+		res, err := s.conversations().InsertOne(ctx, conv)
+		if err != nil {
+			return err
+		}
+		conv.ID = res.InsertedID.(primitive.ObjectID)
+		return nil
+	}
+	res, err := s.conversations().UpdateOne(ctx,
+		bson.M{"_id": conv.ID, "userId": conv.UserID},
+		bson.M{"$set": bson.M{
+			"title":     conv.Title,
+			"messages":  conv.Messages,
+			"turns":     conv.Turns,
+			"updatedAt": conv.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+// conversationTitle shortens a conversation's first question to its title
+func conversationTitle(question string) string {
+	r := []rune(question)
+	if len(r) <= maxConversationTitleLen {
+		return question
+	}
+	return string(r[:maxConversationTitleLen-1]) + "…"
+}
+
+// trimConversation keeps the newest messages whose tokens fit in maxTokens,
+// always keeping the latest turn and starting on a question. maxTokens of 0
+// only applies the maxConversationMessages cap.
+func trimConversation(messages []models.ConversationMessage, maxTokens int, provider string) []models.ConversationMessage {
+	start := 0
+	if len(messages) > maxConversationMessages {
+		start = len(messages) - maxConversationMessages
+	}
+	if maxTokens > 0 {
+		tokens := 0
+		for i := len(messages) - 1; i >= start; i-- {
+			tokens += countTokens(provider, messages[i].Content)
+			if tokens > maxTokens && i < len(messages)-2 {
+				start = i + 1
+				break
+			}
+		}
+	}
+	for start < len(messages)-2 && messages[start].Role != "user" {
+		start++
+	}
+	return append([]models.ConversationMessage(nil), messages[start:]...)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func turn(question, answer string) []models.ConversationMessage {
+	return []models.ConversationMessage{
+		{Role: "user", Content: question},
+		{Role: "assistant", Content: answer},
+	}
+}
+
+func TestTrimConversation(t *testing.T) {
+	var messages []models.ConversationMessage
+	for _, q := range []string{"first", "second", "third"} {
+		messages = append(messages, turn(q+" question", strings.Repeat(q+" ", 40))...)
+	}
+
+	// Without a budget every turn is kept
+	assert.Len(t, trimConversation(messages, 0, "openai"), 6)
+
+	// A budget drops the oldest turns and starts on a question
+	trimmed := trimConversation(messages, 60, "openai")
+	require.Len(t, trimmed, 2)
+	assert.Equal(t, "third question", trimmed[0].Content)
+
+	// The latest turn is kept even when it alone is over budget
+	trimmed = trimConversation(messages, 1, "openai")
+	require.Len(t, trimmed, 2)
+	assert.Equal(t, "user", trimmed[0].Role)
+}
+
+func TestTrimConversation_MessageCap(t *testing.T) {
+	var messages []models.ConversationMessage
+	for i := 0; i < maxConversationMessages; i++ {
+		messages = append(messages, turn("q", "a")...)
+	}
+	trimmed := trimConversation(messages, 0, "openai")
+	assert.Len(t, trimmed, maxConversationMessages)
+	assert.Equal(t, "user", trimmed[0].Role)
+}
+
+func TestConversationTitle(t *testing.T) {
+	assert.Equal(t, "What is the trend?", conversationTitle("What is the trend?"))
+
+	title := conversationTitle(strings.Repeat("é", maxConversationTitleLen+10))
+	assert.Equal(t, maxConversationTitleLen, len([]rune(title)))
+	assert.True(t, strings.HasSuffix(title, "…"))
+}
+
+func TestLLMService_QueryWithHistory(t *testing.T) {
+	provider := &cannedProvider{name: "anthropic", answer: "Still rising."}
+	svc := newStructuredLLMService(t, provider)
+	conv := &models.Conversation{Messages: turn("What is the trend?", "Rising.")}
+	req := QueryRequest{FeedID: "feed-1", Question: "And now?", History: ConversationHistory(conv)}
+
+	_, err := svc.Query(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, provider.messages, 4)
+	assert.Equal(t, "system", provider.messages[0].Role)
+	assert.Equal(t, ChatMessage{Role: "user", Content: "What is the trend?"}, provider.messages[1])
+	assert.Equal(t, ChatMessage{Role: "assistant", Content: "Rising."}, provider.messages[2])
+	assert.Equal(t, "user", provider.messages[3].Role)
+	assert.Contains(t, provider.messages[3].Content, "And now?")
+
+	// The same question in another conversation is not served from the cache
+	provider.answer = "Falling."
+	req.History = nil
+	resp, err := svc.Query(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, resp.Cached)
+	assert.Equal(t, "Falling.", resp.Answer)
+}
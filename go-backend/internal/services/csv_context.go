@@ -0,0 +1,101 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buildCSVContext renders feed entries as a compact CSV table for the LLM prompt.
+// Columns come from the newest entry and are sorted for stable output. Numbers are
+// written in fixed notation with the given precision (-1 keeps the shortest exact
+// representation) and cells containing delimiters are quoted.
+func buildCSVContext(entries []map[string]interface{}, precision int) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(entries[0]))
+	for k := range entries[0] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	header := make([]string, len(keys))
+	for i, k := range keys {
+		header[i] = quoteCSVCell(k)
+	}
+	sb.WriteString(strings.Join(header, ", "))
+	sb.WriteString("\n")
+
+	for _, entry := range entries {
+		values := make([]string, len(keys))
+		for i, k := range keys {
+			values[i] = quoteCSVCell(formatCSVValue(entry[k], precision))
+		}
+		sb.WriteString(strings.Join(values, ", "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatCSVValue converts a decoded JSON value into its CSV cell text
+func formatCSVValue(v interface{}, precision int) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return formatCSVNumber(val, precision)
+	case float32:
+		return formatCSVNumber(float64(val), precision)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case json.Number:
+		str := val.String()
+		if !strings.ContainsAny(str, ".eE") {
+			return str
+		}
+		if f, err := val.Float64(); err == nil {
+			return formatCSVNumber(f, precision)
+		}
+		return str
+	case map[string]interface{}, []interface{}:
+		bytes, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(bytes)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// formatCSVNumber writes a float in fixed notation. Whole numbers never get a
+// fractional part so integer IDs and counters stay exact.
+func formatCSVNumber(f float64, precision int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if f == math.Trunc(f) {
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(f, 'f', precision, 64)
+}
+
+// quoteCSVCell quotes a cell that contains a comma, quote or newline
+func quoteCSVCell(s string) string {
+	if !strings.ContainsAny(s, ",\"\n\r") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatCSVValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     interface{}
+		precision int
+		expected  string
+	}{
+		{name: "float shortest", value: 123.456, precision: -1, expected: "123.456"},
+		{name: "float fixed precision", value: 3.14159, precision: 2, expected: "3.14"},
+		{name: "small float not scientific", value: 0.00000123, precision: -1, expected: "0.00000123"},
+		{name: "large int not scientific", value: float64(12345678901234567890), precision: -1, expected: "12345678901234567168"},
+		{name: "whole float ignores precision", value: float64(1700000000000), precision: 4, expected: "1700000000000"},
+		{name: "int", value: 42, precision: 2, expected: "42"},
+		{name: "bool", value: true, precision: -1, expected: "true"},
+		{name: "nil", value: nil, precision: -1, expected: ""},
+		{name: "nested object", value: map[string]interface{}{"a": 1.5}, precision: -1, expected: `{"a":1.5}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatCSVValue(tt.value, tt.precision))
+		})
+	}
+}
+
+func TestBuildCSVContext(t *testing.T) {
+	entries := []map[string]interface{}{
+		{"price": 65432.12345678, "volume": float64(1e21), "note": "up, then down"},
+		{"price": 1.5e-7, "volume": float64(250), "note": `said "hi"`},
+	}
+
+	expected := "note, price, volume\n" +
+		"\"up, then down\", 65432.12345678, 1000000000000000000000\n" +
+		"\"said \"\"hi\"\"\", 0.00000015, 250\n"
+	assert.Equal(t, expected, buildCSVContext(entries, -1))
+
+	expected = "note, price, volume\n" +
+		"\"up, then down\", 65432.12, 1000000000000000000000\n" +
+		"\"said \"\"hi\"\"\", 0.00, 250\n"
+	assert.Equal(t, expected, buildCSVContext(entries, 2))
+
+	assert.Empty(t, buildCSVContext(nil, -1))
+}
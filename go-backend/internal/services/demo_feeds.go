@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// SystemFeedOwner owns the built-in feeds the server seeds. No user can
+// manage them.
+const SystemFeedOwner = "system"
+
+// demoFeeds are the simulated feeds seeded for trying streaming, dashboards
+// and AI analysis without an upstream
+func demoFeeds() []models.WebSocketFeed {
+	return []models.WebSocketFeed{
+		{
+			Name:           "Demo Prices",
+			Description:    "Simulated prices for three made-up coins, moving in sine waves with noise, every second.",
+			Category:       "crypto",
+			ConnectionType: "simulated",
+			EventName:      "price",
+			SimulatedConfig: &models.SimulatedConfig{
+				Generator:  "prices",
+				IntervalMs: 1000,
+				Symbols:    []string{"DEMO-BTC", "DEMO-ETH", "DEMO-SOL"},
+				BasePrice:  100,
+				Amplitude:  0.05,
+				PeriodSec:  120,
+			},
+			Aggregation:     &models.FeedAggregation{Fields: []string{"$.price"}},
+			Tags:            []string{"demo", "simulated", "prices"},
+			DefaultAIPrompt: "Summarise how each symbol's price is trending.",
+		},
+		{
+			Name:           "Demo News",
+			Description:    "Simulated market headlines with a sentiment label, every five seconds.",
+			Category:       "custom",
+			ConnectionType: "simulated",
+			EventName:      "news",
+			SimulatedConfig: &models.SimulatedConfig{
+				Generator:  "news",
+				IntervalMs: 5000,
+				Symbols:    []string{"DEMO-BTC", "DEMO-ETH", "DEMO-SOL"},
+			},
+			Tags:            []string{"demo", "simulated", "news"},
+			DefaultAIPrompt: "What is the overall sentiment of the latest headlines?",
+		},
+	}
+}
+
+// EnsureDemoFeeds creates the public system feeds of simulated prices and
+// news that do not exist yet, matched by name, and returns how many it created
+func (s *MarketplaceService) EnsureDemoFeeds(ctx context.Context) (int, error) {
+	existing, err := s.feeds.List(ctx, FeedFilter{OwnerID: SystemFeedOwner}, "", 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	names := make(map[string]bool, len(existing))
+	for _, feed := range existing {
+		names[feed.Name] = true
+	}
+	created := 0
+	for _, feed := range demoFeeds() {
+		if names[feed.Name] {
+			continue
+		}
+		feed.FeedType = "system"
+		feed.OwnerID = SystemFeedOwner
+		feed.OwnerName = "TurboStream"
+		feed.IsPublic = true
+		feed.IsActive = true
+		feed.IsVerified = true
+		if _, err := s.CreateFeed(ctx, feed); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarketplaceService_EnsureDemoFeeds(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+
+	n, err := service.EnsureDemoFeeds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(demoFeeds()), n)
+
+	feeds, err := service.feeds.List(ctx, FeedFilter{Public: true}, FeedSortName, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, feeds, n)
+	for _, feed := range feeds {
+		assert.Equal(t, "system", feed.FeedType)
+		assert.Equal(t, SystemFeedOwner, feed.OwnerID)
+		assert.Equal(t, "simulated", feed.ConnectionType)
+		assert.True(t, feed.IsActive)
+		assert.NotNil(t, feed.SimulatedConfig)
+	}
+
+	n, err = service.EnsureDemoFeeds(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "existing demo feeds are not duplicated")
+}
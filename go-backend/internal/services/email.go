@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+)
+
+// Email providers
+const (
+	EmailProviderSMTP     = "smtp"
+	EmailProviderSendGrid = "sendgrid"
+)
+
+// Kinds of email, the kind label of turbostream_emails_sent_total
+const (
+	EmailKindVerification  = "verification"
+	EmailKindPasswordReset = "password_reset"
+	EmailKindAlert         = "alert"
+)
+
+const (
+	// sendGridURL is the SendGrid v3 mail send endpoint
+	sendGridURL = "https://api.sendgrid.com/v3/mail/send"
+	// sendGridTimeout bounds one SendGrid request
+	sendGridTimeout = 15 * time.Second
+)
+
+// Email is a plain-text message to one recipient
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+	Kind    string
+}
+
+// EmailService sends account and alert emails through SMTP or SendGrid
+type EmailService struct {
+	cfg      config.Config
+	provider string
+	http     *http.Client
+	// sendGridURL is replaced in tests
+	sendGridURL string
+}
+
+// NewEmailService creates the email service for the configured provider.
+// Without a provider's settings Enabled reports false and nothing is sent.
+func NewEmailService(cfg config.Config) *EmailService {
+	provider := cfg.EmailProvider
+	if provider == "" {
+		provider = EmailProviderSMTP
+		if cfg.SMTPHost == "" && cfg.SendGridAPIKey != "" {
+			provider = EmailProviderSendGrid
+		}
+	}
+	return &EmailService{cfg: cfg, provider: provider, http: &http.Client{Timeout: sendGridTimeout}, sendGridURL: sendGridURL}
+}
+
+// Enabled reports whether emails can be sent; a nil service sends nothing
+func (s *EmailService) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	switch s.provider {
+	case EmailProviderSMTP:
+		return s.cfg.SMTPHost != ""
+	case EmailProviderSendGrid:
+		return s.cfg.SendGridAPIKey != ""
+	}
+	return false
+}
+
+// Provider returns the provider emails are sent through
+func (s *EmailService) Provider() string {
+	return s.provider
+}
+
+// Send delivers an email, returning ErrEmailDisabled when no provider is configured
+func (s *EmailService) Send(ctx context.Context, email Email) error {
+	if !s.Enabled() {
+		return ErrEmailDisabled
+	}
+	var err error
+	if s.provider == EmailProviderSendGrid {
+		err = s.sendSendGrid(ctx, email)
+	} else {
+		err = s.sendSMTP(email)
+	}
+	outcome := "sent"
+	if err != nil {
+		outcome = "failed"
+	}
+	metrics.EmailsSent.WithLabelValues(email.Kind, outcome).Inc()
+	return err
+}
+
+// sendSMTP sends an email through the configured SMTP server
+func (s *EmailService) sendSMTP(email Email) error {
+	var auth smtp.Auth
+	if s.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	}
+	addr := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(s.cfg.SMTPPort))
+	if err := smtp.SendMail(addr, auth, s.cfg.EmailFrom, []string{email.To}, emailMessage(s.cfg.EmailFrom, email)); err != nil {
+		return fmt.Errorf("%s email: %w", email.Kind, err)
+	}
+	return nil
+}
+
+// sendSendGrid sends an email with the SendGrid mail send API
+func (s *EmailService) sendSendGrid(ctx context.Context, email Email) error {
+	body, err := json.Marshal(sendGridMessage(s.cfg.EmailFrom, email))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.sendGridURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.cfg.SendGridAPIKey)
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s email: %w", email.Kind, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s email: SendGrid returned %s: %s", email.Kind, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// sendGridMessage builds the SendGrid mail send request body
+func sendGridMessage(from string, email Email) map[string]interface{} {
+	return map[string]interface{}{
+		"personalizations": []map[string]interface{}{{"to": []map[string]string{{"email": email.To}}}},
+		"from":             map[string]string{"email": from},
+		"subject":          email.Subject,
+		"content":          []map[string]string{{"type": "text/plain", "value": email.Body}},
+	}
+}
+
+// emailMessage renders an email as an RFC 5322 message for SMTP
+func emailMessage(from string, email Email) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\n", from, email.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", email.Subject)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(email.Body)
+	return []byte(b.String())
+}
+
+// emailLink returns the link for an emailed token, or "" when no link base
+// URL is configured
+func (s *EmailService) emailLink(path, token string) string {
+	if s.cfg.EmailLinkBaseURL == "" {
+		return ""
+	}
+	return s.cfg.EmailLinkBaseURL + path + "?token=" + token
+}
+
+// verificationEmail renders the email that verifies a new account's address
+func (s *EmailService) verificationEmail(to, token string) Email {
+	var b strings.Builder
+	b.WriteString("Welcome to TurboStream!\r\n\r\nConfirm this is your email address")
+	if link := s.emailLink("/verify-email", token); link != "" {
+		fmt.Fprintf(&b, " by opening:\r\n\r\n%s\r\n\r\nor", link)
+	}
+	fmt.Fprintf(&b, " by sending this token to POST /api/auth/verify-email:\r\n\r\n%s\r\n\r\n", token)
+	fmt.Fprintf(&b, "The token expires in %d hours. If you did not sign up, ignore this email.\r\n", int(emailVerificationTTL.Hours()))
+	return Email{To: to, Subject: "Verify your TurboStream email", Body: b.String(), Kind: EmailKindVerification}
+}
+
+// passwordResetEmail renders the email that lets a user choose a new password
+func (s *EmailService) passwordResetEmail(to, token string) Email {
+	var b strings.Builder
+	b.WriteString("Someone asked to reset the password of your TurboStream account.\r\n\r\nChoose a new password")
+	if link := s.emailLink("/reset-password", token); link != "" {
+		fmt.Fprintf(&b, " at:\r\n\r\n%s\r\n\r\nor", link)
+	}
+	fmt.Fprintf(&b, " by sending this token with your new password to POST /api/auth/reset-password:\r\n\r\n%s\r\n\r\n", token)
+	fmt.Fprintf(&b, "The token expires in %d minutes and signs out all your sessions. If you did not ask for this, ignore this email.\r\n", int(passwordResetTTL.Minutes()))
+	return Email{To: to, Subject: "Reset your TurboStream password", Body: b.String(), Kind: EmailKindPasswordReset}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+func TestNewEmailService_Provider(t *testing.T) {
+	assert.False(t, NewEmailService(config.Config{}).Enabled())
+	var none *EmailService
+	assert.False(t, none.Enabled())
+
+	smtpOnly := NewEmailService(config.Config{SMTPHost: "smtp.example.com"})
+	assert.Equal(t, EmailProviderSMTP, smtpOnly.Provider())
+	assert.True(t, smtpOnly.Enabled())
+
+	sendGridOnly := NewEmailService(config.Config{SendGridAPIKey: "SG.key"})
+	assert.Equal(t, EmailProviderSendGrid, sendGridOnly.Provider())
+	assert.True(t, sendGridOnly.Enabled())
+
+	// An explicit provider needs its own settings
+	assert.False(t, NewEmailService(config.Config{EmailProvider: EmailProviderSendGrid, SMTPHost: "smtp.example.com"}).Enabled())
+	assert.False(t, NewEmailService(config.Config{EmailProvider: "postmark", SMTPHost: "smtp.example.com"}).Enabled())
+}
+
+func TestEmailService_SendDisabled(t *testing.T) {
+	err := NewEmailService(config.Config{}).Send(context.Background(), Email{To: "user@example.com"})
+	assert.ErrorIs(t, err, ErrEmailDisabled)
+}
+
+func TestEmailService_SendGrid(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer SG.key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	svc := NewEmailService(config.Config{SendGridAPIKey: "SG.key", EmailFrom: "noreply@example.com"})
+	svc.sendGridURL = server.URL
+	require.NoError(t, svc.Send(context.Background(), Email{To: "user@example.com", Subject: "Hi", Body: "Hello", Kind: EmailKindAlert}))
+	assert.Equal(t, map[string]interface{}{"email": "noreply@example.com"}, got["from"])
+	assert.Equal(t, "Hi", got["subject"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"to": []interface{}{map[string]interface{}{"email": "user@example.com"}}}}, got["personalizations"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer failing.Close()
+	svc.sendGridURL = failing.URL
+	err := svc.Send(context.Background(), Email{To: "user@example.com", Kind: EmailKindAlert})
+	assert.ErrorContains(t, err, "401")
+	assert.ErrorContains(t, err, "bad key")
+}
+
+func TestEmailMessage(t *testing.T) {
+	msg := string(emailMessage("noreply@example.com", Email{To: "user@example.com", Subject: "Hi", Body: "Hello"}))
+	assert.Equal(t, "From: noreply@example.com\r\nTo: user@example.com\r\nSubject: Hi\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nHello", msg)
+}
+
+func TestAccountEmails(t *testing.T) {
+	plain := NewEmailService(config.Config{})
+	email := plain.verificationEmail("user@example.com", "tok123")
+	assert.Equal(t, EmailKindVerification, email.Kind)
+	assert.Contains(t, email.Body, "POST /api/auth/verify-email:\r\n\r\ntok123\r\n")
+	assert.NotContains(t, email.Body, "http")
+
+	linked := NewEmailService(config.Config{EmailLinkBaseURL: "https://app.example.com"})
+	email = linked.passwordResetEmail("user@example.com", "tok123")
+	assert.Equal(t, EmailKindPasswordReset, email.Kind)
+	assert.Contains(t, email.Body, "https://app.example.com/reset-password?token=tok123")
+	assert.Contains(t, email.Body, "expires in 60 minutes")
+}
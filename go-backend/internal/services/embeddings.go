@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// localEmbeddingDims is the size of the vectors the local embedder builds
+const localEmbeddingDims = 512
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related the texts are
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Name() string
+}
+
+// newEmbedder returns the embedder configured for retrieval
+func newEmbedder(cfg config.Config) (Embedder, error) {
+	switch strings.ToLower(cfg.LLMRetrievalEmbedder) {
+	case "", "local":
+		return localEmbedder{}, nil
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("openai embeddings: %w", ErrProviderNotConfigured)
+		}
+		return &openAIEmbedder{
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			apiKey:     cfg.OpenAIAPIKey,
+			model:      cfg.OpenAIEmbeddingModel,
+			baseURL:    "https://api.openai.com/v1",
+		}, nil
+	case "ollama":
+		if cfg.OllamaBaseURL == "" {
+			return nil, fmt.Errorf("ollama embeddings: %w", ErrProviderNotConfigured)
+		}
+		return &ollamaEmbedder{
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			baseURL:    strings.TrimRight(cfg.OllamaBaseURL, "/"),
+			model:      cfg.OllamaEmbeddingModel,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown embedder %q", cfg.LLMRetrievalEmbedder)
+}
+
+// localEmbedder hashes the words and numbers of a text into a fixed-size
+// vector. It needs no API and matches texts that share terms, such as a
+// symbol or field name in the question.
+type localEmbedder struct{}
+
+// Name returns the embedder identifier
+func (localEmbedder) Name() string { return "local" }
+
+// Embed returns the normalised term vector of each text
+func (localEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, localEmbeddingDims)
+		for _, term := range embeddingTerms(text) {
+			h := fnv.New32a()
+			h.Write([]byte(term))
+			sum := h.Sum32()
+			// The top bit picks the sign so unrelated terms sharing a slot cancel out
+			if sum&(1<<31) != 0 {
+				v[sum%localEmbeddingDims]--
+			} else {
+				v[sum%localEmbeddingDims]++
+			}
+		}
+		vectors[i] = normalizeVector(v)
+	}
+	return vectors, nil
+}
+
+// embeddingTerms splits a text into lowercase words and numbers
+func embeddingTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '_' && r != '-'
+	})
+}
+
+// normalizeVector scales v to unit length, leaving a zero vector as is
+func normalizeVector(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+// cosineSimilarity compares two vectors of the same embedder
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// openAIEmbedder calls OpenAI's embeddings API
+type openAIEmbedder struct {
+	httpClient *http.Client
+	apiKey     string
+	model      string
+	baseURL    string
+}
+
+// Name returns the embedder identifier
+func (e *openAIEmbedder) Name() string { return "openai" }
+
+// Embed returns the vectors of texts in their order
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{"model": e.model, "input": texts})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai embeddings error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("openai embeddings returned %d vectors for %d texts", len(result.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("openai embeddings returned index %d for %d texts", d.Index, len(texts))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// ollamaEmbedder calls a local Ollama server's embed API
+type ollamaEmbedder struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+}
+
+// Name returns the embedder identifier
+func (e *ollamaEmbedder) Name() string { return "ollama" }
+
+// Embed returns the vectors of texts in their order
+func (e *ollamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{"model": e.model, "input": texts})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama embeddings error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama embeddings returned %d vectors for %d texts", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import "errors"
+
+// Sentinel errors returned by the services. Callers should compare with
+// errors.Is rather than matching on the message text.
+var (
+	// Auth
+	ErrMissingCredentials       = errors.New("email and password required")
+	ErrUserExists               = errors.New("user already exists")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidCredentials       = errors.New("invalid email or password")
+	ErrTwoFactorRequired        = errors.New("two-factor authentication required")
+	ErrVerificationCodeRequired = errors.New("verification code required")
+	ErrInvalidVerificationCode  = errors.New("invalid verification code")
+	ErrIncorrectPassword        = errors.New("current password is incorrect")
+	ErrInvalidToken             = errors.New("invalid token")
+	ErrInvalidAPIKey            = errors.New("invalid API key")
+	ErrAPIKeyNotFound           = errors.New("API key not found")
+	ErrAPIKeyNameRequired       = errors.New("API key name required")
+	ErrInvalidAPIKeyScope       = errors.New("API key needs at least one feed with read or publish scope")
+	// ErrSessionNotFound is returned by SessionRepo.Rotate
+	ErrSessionNotFound = errors.New("session not found")
+
+	// Email
+	ErrEmailDisabled        = errors.New("email delivery is not configured")
+	ErrInvalidEmailToken    = errors.New("token is invalid or expired")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrPasswordRequired     = errors.New("password required")
+
+	// WebAuthn
+	ErrWebAuthnDisabled           = errors.New("security keys are not configured")
+	ErrInvalidWebAuthnResponse    = errors.New("invalid security key response")
+	ErrWebAuthnChallenge          = errors.New("security key challenge is invalid or expired")
+	ErrWebAuthnCredentialNotFound = errors.New("security key not found")
+	ErrWebAuthnCredentialExists   = errors.New("security key is already registered")
+	ErrUnsupportedWebAuthnKey     = errors.New("security key algorithm must be ES256, RS256 or EdDSA")
+	ErrWebAuthnSignCount          = errors.New("security key signature counter did not increase")
+
+	// Account
+	ErrEmailConfirmation = errors.New("accounts without a password confirm by sending their email")
+
+	// Admin
+	ErrInvalidRole       = errors.New("role must be admin, curator or user")
+	ErrInvalidTokenQuota = errors.New("token quota must not be negative")
+
+	// LLM
+	ErrTokenQuotaExceeded = errors.New("monthly token quota exceeded")
+	ErrInvalidUsageGroup  = errors.New("groupBy must be feed, day or provider")
+
+	// OAuth
+	ErrOAuthProviderNotConfigured = errors.New("OAuth provider not configured")
+	ErrInvalidOAuthState          = errors.New("invalid or expired OAuth state")
+	ErrOAuthEmailUnverified       = errors.New("OAuth account has no verified email")
+	ErrOAuthAccountUnverified     = errors.New("an account with this email exists but its email is not verified; sign in with its password and verify the email first")
+	ErrOAuthExchangeFailed        = errors.New("OAuth provider request failed")
+
+	// Marketplace
+	ErrFeedNotFound  = errors.New("feed not found")
+	ErrNotAuthorized = errors.New("not authorized")
+	ErrQueryRequired = errors.New("query required")
+	ErrInvalidSort   = errors.New("sort must be recent, subscribers or name")
+
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrSubscriptionExists is returned by SubscriptionRepo.Insert
+	ErrSubscriptionExists = errors.New("subscription already exists")
+
+	// Alerts
+	ErrAlertRuleNotFound      = errors.New("alert rule not found")
+	ErrAlertConditionRequired = errors.New("alert condition required")
+	ErrInvalidAlertCooldown   = errors.New("cooldownSeconds must be between 0 and 86400")
+	ErrInvalidWebhookURL      = errors.New("webhookUrl must be an http or https URL")
+	ErrTooManyAlertRules      = errors.New("too many alert rules for this subscription")
+
+	// Scheduled analyses
+	ErrAnalysisScheduleNotFound = errors.New("analysis schedule not found")
+	ErrInvalidAnalysisInterval  = errors.New("intervalSeconds must be between 60 and 604800")
+	ErrAnalysisPromptTooLong    = errors.New("prompt must be at most 2000 characters")
+	ErrUnknownLLMProvider       = errors.New("unknown LLM provider")
+	ErrTooManyAnalysisSchedules = errors.New("too many analysis schedules")
+
+	// Webhooks
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrWebhookURLNotHTTPS  = errors.New("webhook url must be an https URL")
+	ErrInvalidWebhookEvent = errors.New("webhook events must be feed-data, feed-alert or feed-health")
+	ErrTooManyWebhooks     = errors.New("too many webhooks for this feed")
+
+	// Feed access
+	ErrInvalidGrantEmail   = errors.New("a valid email is required")
+	ErrAccessGrantNotFound = errors.New("access grant not found")
+	ErrInviteNotFound      = errors.New("invite not found")
+	ErrInvalidInvite       = errors.New("invite is invalid, expired or used up")
+
+	// Organizations
+	ErrOrgNotFound       = errors.New("organization not found")
+	ErrInvalidOrgName    = errors.New("organization name must be 1 to 100 characters")
+	ErrInvalidOrgRole    = errors.New("role must be owner, admin or member")
+	ErrOrgMemberExists   = errors.New("already a member of this organization")
+	ErrOrgMemberNotFound = errors.New("organization member not found")
+	ErrLastOrgOwner      = errors.New("an organization needs at least one owner")
+	ErrFeedOwnedByOrg    = errors.New("feed already belongs to an organization")
+
+	// Deleted feeds
+	ErrFeedNotDeleted     = errors.New("feed is not deleted")
+	ErrFeedRestoreExpired = errors.New("feed was deleted too long ago to restore")
+
+	// Paused feeds
+	ErrFeedPaused = errors.New("feed is paused")
+
+	// Push feeds
+	ErrNotPushFeed            = errors.New("feed is not a push feed")
+	ErrInvalidIngestSignature = errors.New("invalid or expired ingestion signature")
+
+	// Feed secrets
+	ErrInvalidFeedUpdate = errors.New("headers, queryParams, httpConfig and connection messages must match the feed format")
+
+	// Settings
+	ErrCategoryKeyRequired = errors.New("category key and label required")
+	ErrInvalidCategoryKey  = errors.New("category key must be a lowercase slug of letters, digits and dashes")
+	ErrCategoryExists      = errors.New("category already exists")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrUnknownCategory     = errors.New("unknown or disabled category")
+
+	// LLM
+	ErrProviderNotConfigured   = errors.New("provider not configured")
+	ErrNoProviders             = errors.New("no LLM providers available")
+	ErrInvalidChatOptions      = errors.New("invalid generation options")
+	ErrInvalidLLMKeyProvider   = errors.New("own keys are supported for openai, anthropic, gemini, mistral and grok")
+	ErrLLMKeyRequired          = errors.New("apiKey required")
+	ErrLLMKeyNotFound          = errors.New("no key stored for this provider")
+	ErrInvalidResponseFormat   = errors.New("invalid response format")
+	ErrInvalidStructuredOutput = errors.New("the model did not return the requested JSON")
+	ErrConversationNotFound    = errors.New("conversation not found")
+	ErrConversationFeed        = errors.New("conversation is about another feed")
+	ErrLLMToolsDisabled        = errors.New("LLM tools are disabled")
+	ErrLLMToolsStreaming       = errors.New("LLM tools are not available for streamed queries")
+	ErrTooManyToolCalls        = errors.New("the model kept calling tools without answering")
+)
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// feedInvitePrefix marks feed invite tokens
+	feedInvitePrefix = "fi_"
+	// maxInviteTTL caps how long an invite stays redeemable
+	maxInviteTTL = 30 * 24 * time.Hour
+)
+
+// FeedAccessService decides who may read private feeds. Owners grant access
+// by email, directly or through invite tokens that grant it to whoever
+// redeems them.
+type FeedAccessService struct {
+	db    *mongo.Database
+	users UserRepo
+	orgs  OrgRoles
+}
+
+// NewFeedAccessService creates the feed access service
+func NewFeedAccessService(db *mongo.Database) *FeedAccessService {
+	return &FeedAccessService{db: db, users: NewMongoUserRepo(db)}
+}
+
+// SetStore looks users up in the store's repository
+func (s *FeedAccessService) SetStore(store Store) {
+	s.users = store.Users
+}
+
+// SetOrgRoles lets organization members read the organization's private feeds
+func (s *FeedAccessService) SetOrgRoles(orgs OrgRoles) {
+	s.orgs = orgs
+}
+
+// grants returns the MongoDB feed_access_grants collection
+func (s *FeedAccessService) grants() *mongo.Collection {
+	return s.db.Collection("feed_access_grants")
+}
+
+// invites returns the MongoDB feed_invites collection
+func (s *FeedAccessService) invites() *mongo.Collection {
+	return s.db.Collection("feed_invites")
+}
+
+// EnsureIndexes creates the unique grant and invite token indexes
+func (s *FeedAccessService) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.grants().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "feedId", Value: 1}, {Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	_, err := s.invites().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "feedId", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	return err
+}
+
+// normalizeGrantEmail trims and lowercases an email, the way accounts store them
+func normalizeGrantEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidGrantEmail
+	}
+	return email, nil
+}
+
+// CanAccess reports whether a user may read a feed: anyone may read public
+// feeds, while private ones need the owner, membership of the owning
+// organization or a grant for the user's verified email. An empty userID is
+// anonymous.
+func (s *FeedAccessService) CanAccess(ctx context.Context, feed *models.WebSocketFeed, userID string) (bool, error) {
+	if feed.IsPublic || (userID != "" && feed.OwnerID == userID) {
+		return true, nil
+	}
+	if userID == "" {
+		return false, nil
+	}
+	if feed.OrgID != "" && s.orgs != nil {
+		role, err := s.orgs.Role(ctx, feed.OrgID, userID)
+		if err != nil {
+			return false, err
+		}
+		if role != "" {
+			return true, nil
+		}
+	}
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, nil
+	}
+	user, err := s.users.Get(ctx, oid)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// Grants are for whoever owns the address, which anyone can register
+	// before its owner does
+	if !user.EmailVerified {
+		return false, nil
+	}
+	count, err := s.grants().CountDocuments(ctx, bson.M{"feedId": feed.ID.Hex(), "email": user.Email})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// userEmail looks up a user's email by ID
+func (s *FeedAccessService) userEmail(ctx context.Context, userID string) (string, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	user, err := s.users.Get(ctx, oid)
+	if err != nil {
+		return "", err
+	}
+	return user.Email, nil
+}
+
+// UserIDByEmail returns the ID of the account with the email, or
+// ErrUserNotFound when nobody has signed up with it
+func (s *FeedAccessService) UserIDByEmail(ctx context.Context, email string) (string, error) {
+	user, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return "", err
+	}
+	return user.ID.Hex(), nil
+}
+
+// GrantAccess lets the email read the feed; granting it twice returns the
+// existing grant
+func (s *FeedAccessService) GrantAccess(ctx context.Context, feedID, email, grantedBy string) (*models.FeedAccessGrant, error) {
+	email, err := normalizeGrantEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	return s.grant(ctx, models.FeedAccessGrant{FeedID: feedID, Email: email, GrantedBy: grantedBy})
+}
+
+func (s *FeedAccessService) grant(ctx context.Context, grant models.FeedAccessGrant) (*models.FeedAccessGrant, error) {
+	grant.ID = primitive.NilObjectID
+	grant.CreatedAt = time.Now()
+	res, err := s.grants().InsertOne(ctx, grant)
+	if mongo.IsDuplicateKeyError(err) {
+		var existing models.FeedAccessGrant
+		if err := s.grants().FindOne(ctx, bson.M{"feedId": grant.FeedID, "email": grant.Email}).Decode(&existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	grant.ID = res.InsertedID.(primitive.ObjectID)
+	return &grant, nil
+}
+
+// ListGrants returns the feed's access grants, oldest first
+func (s *FeedAccessService) ListGrants(ctx context.Context, feedID string) ([]models.FeedAccessGrant, error) {
+	cur, err := s.grants().Find(ctx, bson.M{"feedId": feedID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	grants := []models.FeedAccessGrant{}
+	if err := cur.All(ctx, &grants); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// RevokeGrant removes one of the feed's access grants and returns it
+func (s *FeedAccessService) RevokeGrant(ctx context.Context, feedID string, id primitive.ObjectID) (*models.FeedAccessGrant, error) {
+	var grant models.FeedAccessGrant
+	err := s.grants().FindOneAndDelete(ctx, bson.M{"_id": id, "feedId": feedID}).Decode(&grant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAccessGrantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// CreateInvite creates an invite to the feed and returns its token, which is
+// only stored hashed. maxUses of 0 allows any number of redemptions and a
+// ttl of 0 uses the longest allowed lifetime.
+func (s *FeedAccessService) CreateInvite(ctx context.Context, feedID, createdBy string, maxUses int, ttl time.Duration) (string, models.FeedInvite, error) {
+	if maxUses < 0 || ttl < 0 || ttl > maxInviteTTL {
+		return "", models.FeedInvite{}, ErrInvalidInvite
+	}
+	if ttl == 0 {
+		ttl = maxInviteTTL
+	}
+	token, err := newFeedInviteToken()
+	if err != nil {
+		return "", models.FeedInvite{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	invite := models.FeedInvite{
+		FeedID:    feedID,
+		TokenHash: hashSecret(token),
+		CreatedBy: createdBy,
+		MaxUses:   maxUses,
+		ExpiresAt: &expiresAt,
+		CreatedAt: now,
+	}
+	res, err := s.invites().InsertOne(ctx, invite)
+	if err != nil {
+		return "", models.FeedInvite{}, err
+	}
+	invite.ID = res.InsertedID.(primitive.ObjectID)
+	return token, invite, nil
+}
+
+// ListInvites returns the feed's invites, newest first
+func (s *FeedAccessService) ListInvites(ctx context.Context, feedID string) ([]models.FeedInvite, error) {
+	cur, err := s.invites().Find(ctx, bson.M{"feedId": feedID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	invites := []models.FeedInvite{}
+	if err := cur.All(ctx, &invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// RevokeInvite stops an invite from being redeemed; grants it already made stay
+func (s *FeedAccessService) RevokeInvite(ctx context.Context, feedID string, id primitive.ObjectID) error {
+	res, err := s.invites().UpdateOne(ctx, bson.M{"_id": id, "feedId": feedID}, bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// RedeemInvite grants the user access to the invite's feed. Invites that are
+// revoked, expired or used up are rejected with ErrInvalidInvite.
+func (s *FeedAccessService) RedeemInvite(ctx context.Context, token, userID string) (*models.FeedAccessGrant, error) {
+	email, err := s.userEmail(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"tokenHash": hashSecret(token),
+		"revoked":   false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+		"$or": bson.A{
+			bson.M{"maxUses": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$maxUses"}}},
+		},
+	}
+	var invite models.FeedInvite
+	err = s.invites().FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}}).Decode(&invite)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidInvite
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.grant(ctx, models.FeedAccessGrant{FeedID: invite.FeedID, Email: email, GrantedBy: invite.CreatedBy, InviteID: &invite.ID})
+}
+
+// newFeedInviteToken returns a random invite token
+func newFeedInviteToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return feedInvitePrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
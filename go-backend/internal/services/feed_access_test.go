@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestNormalizeGrantEmail(t *testing.T) {
+	email, err := normalizeGrantEmail("  Alice@Example.COM ")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", email)
+
+	for _, bad := range []string{"", "alice", "Alice <alice@example.com>", "@example.com"} {
+		_, err := normalizeGrantEmail(bad)
+		assert.ErrorIs(t, err, ErrInvalidGrantEmail, bad)
+	}
+}
+
+func TestNewFeedInviteToken(t *testing.T) {
+	a, err := newFeedInviteToken()
+	require.NoError(t, err)
+	b, err := newFeedInviteToken()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(a, feedInvitePrefix))
+	assert.NotEqual(t, a, b)
+	assert.NotEqual(t, a, hashSecret(a), "only the hash is stored")
+}
+
+func TestFeedAccessService_CanAccessWithoutGrants(t *testing.T) {
+	// These cases are decided without a database
+	s := NewFeedAccessService(nil)
+	ctx := context.Background()
+	owner := primitive.NewObjectID().Hex()
+	public := &models.WebSocketFeed{ID: primitive.NewObjectID(), IsPublic: true, OwnerID: owner}
+	private := &models.WebSocketFeed{ID: primitive.NewObjectID(), OwnerID: owner}
+
+	ok, err := s.CanAccess(ctx, public, "")
+	require.NoError(t, err)
+	assert.True(t, ok, "anyone may read public feeds")
+
+	ok, err = s.CanAccess(ctx, private, owner)
+	require.NoError(t, err)
+	assert.True(t, ok, "owners may read their private feeds")
+
+	ok, err = s.CanAccess(ctx, private, "")
+	require.NoError(t, err)
+	assert.False(t, ok, "anonymous users may not read private feeds")
+
+	ok, err = s.CanAccess(ctx, private, "not-an-id")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFeedAccessService_CanAccessNeedsVerifiedEmail(t *testing.T) {
+	// The grants collection is never reached, so no database is needed
+	s := NewFeedAccessService(nil)
+	store := NewMemoryStore()
+	s.SetStore(store)
+	ctx := context.Background()
+	user := models.User{Email: "alice@example.com", CreatedAt: time.Now()}
+	require.NoError(t, store.Users.Insert(ctx, &user))
+	private := &models.WebSocketFeed{ID: primitive.NewObjectID(), OwnerID: primitive.NewObjectID().Hex()}
+
+	ok, err := s.CanAccess(ctx, private, user.ID.Hex())
+	require.NoError(t, err)
+	assert.False(t, ok, "grants to the email do not apply until it is verified")
+
+	ok, err = s.CanAccess(ctx, private, primitive.NewObjectID().Hex())
+	require.NoError(t, err)
+	assert.False(t, ok, "unknown users have no grants")
+}
+
+func TestFeedAccessService_CreateInviteRejectsBadLimits(t *testing.T) {
+	s := NewFeedAccessService(nil)
+	ctx := context.Background()
+	_, _, err := s.CreateInvite(ctx, "feed", "owner", -1, 0)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+	_, _, err = s.CreateInvite(ctx, "feed", "owner", 0, maxInviteTTL+time.Second)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+}
+
+func TestFeedAccessService_CanAccessOrganizationFeed(t *testing.T) {
+	s := NewFeedAccessService(nil)
+	ctx := context.Background()
+	orgID := primitive.NewObjectID().Hex()
+	s.SetOrgRoles(stubOrgRoles{orgID: orgID, roles: map[string]string{"member": models.OrgRoleMember}})
+	feed := &models.WebSocketFeed{ID: primitive.NewObjectID(), OrgID: orgID}
+
+	ok, err := s.CanAccess(ctx, feed, "member")
+	require.NoError(t, err)
+	assert.True(t, ok, "any member may read the organization's private feeds")
+
+	ok, err = s.CanAccess(ctx, feed, "")
+	require.NoError(t, err)
+	assert.False(t, ok, "organization feeds have no personal owner to match anonymous users")
+}
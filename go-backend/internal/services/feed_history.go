@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// historyQueueSize bounds messages waiting to be written; newer messages are dropped when full
+	historyQueueSize = 1024
+	// historyBatchSize is the most messages written in one insert
+	historyBatchSize = 100
+	// historyFlushInterval is how long a partial batch waits before it is written
+	historyFlushInterval = time.Second
+)
+
+// feedDataDoc is the persisted form of a FeedDataRecord. Data is kept as JSON
+// so queried values have the same Go types as live feed data.
+type feedDataDoc struct {
+	FeedID    string    `bson:"feedId"`
+	EventName string    `bson:"eventName,omitempty"`
+	Data      string    `bson:"data"`
+	Timestamp time.Time `bson:"timestamp"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// FeedHistoryService records feed messages in the feed_data collection so
+// subscribers can query past events. Writes are buffered and batched so
+// broadcasting never waits on MongoDB.
+type FeedHistoryService struct {
+	db               *mongo.Database
+	defaultRetention time.Duration
+	queue            chan feedDataDoc
+}
+
+// NewFeedHistoryService creates a history writer. Feeds without their own
+// retention keep messages for defaultRetention (0 records nothing).
+func NewFeedHistoryService(db *mongo.Database, defaultRetention time.Duration) *FeedHistoryService {
+	return &FeedHistoryService{
+		db:               db,
+		defaultRetention: defaultRetention,
+		queue:            make(chan feedDataDoc, historyQueueSize),
+	}
+}
+
+// records returns the MongoDB feed_data collection
+func (s *FeedHistoryService) records() *mongo.Collection {
+	return s.db.Collection("feed_data")
+}
+
+// EnsureIndexes creates the TTL index that expires old messages and the
+// index used by history queries.
+func (s *FeedHistoryService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.records().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "feedId", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	return err
+}
+
+// Retention returns how long the feed's messages are kept; 0 means the feed
+// is not recorded.
+func (s *FeedHistoryService) Retention(feed models.WebSocketFeed) time.Duration {
+	switch {
+	case feed.HistoryRetentionHours > 0:
+		return time.Duration(feed.HistoryRetentionHours) * time.Hour
+	case feed.HistoryRetentionHours < 0:
+		return 0
+	default:
+		return s.defaultRetention
+	}
+}
+
+// Record queues a feed message for writing. The payload is encoded
+// immediately because callers may modify it afterwards.
+func (s *FeedHistoryService) Record(feed models.WebSocketFeed, eventName string, data interface{}, at time.Time) {
+	retention := s.Retention(feed)
+	if retention <= 0 {
+		return
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		slog.Warn("skipping feed history", "feed_id", feed.ID.Hex(), "error", err)
+		return
+	}
+	doc := feedDataDoc{
+		FeedID:    feed.ID.Hex(),
+		EventName: eventName,
+		Data:      string(encoded),
+		Timestamp: at,
+		ExpiresAt: at.Add(retention),
+	}
+	select {
+	case s.queue <- doc:
+	default:
+		slog.Warn("feed history queue full, dropping message", "feed_id", doc.FeedID)
+	}
+}
+
+// Run writes queued messages in batches until ctx is cancelled, then writes
+// whatever is still queued. It blocks until the final write completes.
+func (s *FeedHistoryService) Run(ctx context.Context) {
+	ticker := time.NewTicker(historyFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]interface{}, 0, historyBatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		writeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if _, err := s.records().InsertMany(writeCtx, batch, options.InsertMany().SetOrdered(false)); err != nil {
+			slog.Warn("failed to write feed history", "records", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already cancelled, so the final writes get their own deadline
+			for {
+				select {
+				case doc := <-s.queue:
+					batch = append(batch, doc)
+					if len(batch) == historyBatchSize {
+						flush(context.Background())
+					}
+				default:
+					flush(context.Background())
+					return
+				}
+			}
+		case doc := <-s.queue:
+			batch = append(batch, doc)
+			if len(batch) == historyBatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// Query returns up to limit messages for a feed, newest first. Zero from/to
+// leave that end of the time range open. Records that fail to decode are skipped.
+func (s *FeedHistoryService) Query(ctx context.Context, feedID string, from, to time.Time, limit int64) ([]models.FeedDataRecord, error) {
+	filter := bson.M{"feedId": feedID}
+	timeRange := bson.M{}
+	if !from.IsZero() {
+		timeRange["$gte"] = from
+	}
+	if !to.IsZero() {
+		timeRange["$lte"] = to
+	}
+	if len(timeRange) > 0 {
+		filter["timestamp"] = timeRange
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(limit)
+	cursor, err := s.records().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []feedDataDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	out := make([]models.FeedDataRecord, 0, len(docs))
+	for _, doc := range docs {
+		var data interface{}
+		if err := json.Unmarshal([]byte(doc.Data), &data); err != nil {
+			slog.WarnContext(ctx, "skipping unreadable history record", "feed_id", doc.FeedID, "error", err)
+			continue
+		}
+		out = append(out, models.FeedDataRecord{
+			FeedID:    doc.FeedID,
+			EventName: doc.EventName,
+			Data:      data,
+			Timestamp: doc.Timestamp,
+		})
+	}
+	return out, nil
+}
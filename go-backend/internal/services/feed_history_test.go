@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestFeedHistoryService_Retention(t *testing.T) {
+	svc := NewFeedHistoryService(nil, 24*time.Hour)
+	tests := []struct {
+		name     string
+		hours    int
+		expected time.Duration
+	}{
+		{"server default", 0, 24 * time.Hour},
+		{"feed override", 2, 2 * time.Hour},
+		{"disabled for feed", -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, svc.Retention(models.WebSocketFeed{HistoryRetentionHours: tt.hours}))
+		})
+	}
+
+	assert.Zero(t, NewFeedHistoryService(nil, 0).Retention(models.WebSocketFeed{}), "no default records nothing")
+}
+
+func TestFeedHistoryService_Record(t *testing.T) {
+	svc := NewFeedHistoryService(nil, 0)
+	now := time.Now().UTC()
+
+	svc.Record(models.WebSocketFeed{ID: primitive.NewObjectID()}, "tick", map[string]interface{}{"price": 1}, now)
+	assert.Empty(t, svc.queue, "feeds without retention are not recorded")
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), HistoryRetentionHours: 1}
+	data := map[string]interface{}{"price": 1.5}
+	svc.Record(feed, "tick", data, now)
+	// Later changes to the payload don't leak into the queued record
+	data["_timestamp"] = now
+
+	require.Len(t, svc.queue, 1)
+	doc := <-svc.queue
+	assert.Equal(t, feedDataDoc{
+		FeedID:    feed.ID.Hex(),
+		EventName: "tick",
+		Data:      `{"price":1.5}`,
+		Timestamp: now,
+		ExpiresAt: now.Add(time.Hour),
+	}, doc)
+}
+
+func TestFeedHistoryService_RunQuery(t *testing.T) {
+	client, db, cleanup := setupTestDB(t)
+	if client == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	svc := NewFeedHistoryService(db, time.Hour)
+	require.NoError(t, svc.EnsureIndexes(context.Background()))
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID()}
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	for i := 0; i < 3; i++ {
+		svc.Record(feed, "tick", map[string]interface{}{"seq": i}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	// Cancelling Run writes everything still queued
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.Run(ctx)
+
+	records, err := svc.Query(context.Background(), feed.ID.Hex(), start.Add(time.Second), time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, map[string]interface{}{"seq": float64(2)}, records[0].Data, "newest first")
+	assert.Equal(t, start.Add(time.Second), records[1].Timestamp.UTC())
+
+	records, err = svc.Query(context.Background(), feed.ID.Hex(), time.Time{}, time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
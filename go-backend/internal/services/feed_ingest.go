@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// PushConnectionType is the connection type of feeds whose producers push
+// data to the ingestion endpoint instead of being connected to
+const PushConnectionType = "push"
+
+const (
+	ingestSecretPrefix = "ingsec_"
+	// IngestSignatureTolerance is how far an ingestion request's timestamp
+	// may be from the server clock, which bounds replays of a signed request
+	IngestSignatureTolerance = 5 * time.Minute
+)
+
+// IngestCredentials are a push feed's ingestion token, which goes in the
+// ingestion URL, and the secret producers sign requests with. They are only
+// returned when issued.
+type IngestCredentials struct {
+	Token  string `json:"token"`
+	Secret string `json:"secret"`
+}
+
+// IssueIngestCredentials gives a push feed a new ingestion token and signing
+// secret, replacing any it had
+func (s *MarketplaceService) IssueIngestCredentials(ctx context.Context, id primitive.ObjectID) (*IngestCredentials, error) {
+	feed, err := s.GetFeedByID(ctx, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if feed.ConnectionType != PushConnectionType {
+		return nil, ErrNotPushFeed
+	}
+	creds, ingest, err := s.newIngestCredentials(feed.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.feeds.Update(ctx, feed.ID, bson.M{"ingest": ingest, "updatedAt": time.Now()}); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// newIngestCredentials returns fresh credentials for a feed and how they are
+// stored. The token starts with the feed ID so requests can find their feed.
+func (s *MarketplaceService) newIngestCredentials(feedID primitive.ObjectID) (*IngestCredentials, *models.FeedIngest, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, nil, err
+	}
+	token := feedID.Hex() + "." + base64.RawURLEncoding.EncodeToString(buf)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, nil, err
+	}
+	secret := ingestSecretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	sealed := models.WebSocketFeed{Ingest: &models.FeedIngest{TokenHash: hashSecret(token), Secret: secret, IssuedAt: time.Now()}}
+	if err := s.secrets.Seal(&sealed); err != nil {
+		return nil, nil, err
+	}
+	return &IngestCredentials{Token: token, Secret: secret}, sealed.Ingest, nil
+}
+
+// VerifyIngest returns the push feed an ingestion token belongs to once the
+// request's signature checks out. The signature is SignWebhookPayload of the
+// body with the feed's ingestion secret and the request's Unix timestamp,
+// which must be within IngestSignatureTolerance of now. Unknown tokens are
+// reported as ErrFeedNotFound and bad signatures as ErrInvalidIngestSignature.
+func (s *MarketplaceService) VerifyIngest(ctx context.Context, token, timestamp, signature string, body []byte, now time.Time) (*models.WebSocketFeed, error) {
+	feedID, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrFeedNotFound
+	}
+	feed, err := s.GetFeedByID(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+	if feed.ConnectionType != PushConnectionType || feed.Ingest == nil ||
+		!hmac.Equal([]byte(feed.Ingest.TokenHash), []byte(hashSecret(token))) {
+		return nil, ErrFeedNotFound
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidIngestSignature
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > IngestSignatureTolerance || skew < -IngestSignatureTolerance {
+		return nil, ErrInvalidIngestSignature
+	}
+	opened, err := s.OpenFeedSecrets(*feed)
+	if err != nil {
+		return nil, err
+	}
+	expected := SignWebhookPayload(opened.Ingest.Secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidIngestSignature
+	}
+	return feed, nil
+}
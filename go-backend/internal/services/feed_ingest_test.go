@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestMarketplaceService_IngestCredentials(t *testing.T) {
+	ctx := context.Background()
+	feeds := NewMemoryFeedRepo()
+	svc := NewMarketplaceServiceWithRepos(feeds, NewMemorySubscriptionRepo())
+	svc.SetFeedSecrets(NewFeedSecrets("secret"))
+	feed, err := svc.CreateFeed(ctx, models.WebSocketFeed{Name: "Pushed", ConnectionType: PushConnectionType, IsActive: true})
+	require.NoError(t, err)
+
+	creds, err := svc.IssueIngestCredentials(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(creds.Token, feed.ID.Hex()+"."))
+	assert.True(t, strings.HasPrefix(creds.Secret, ingestSecretPrefix))
+
+	stored, err := feeds.Get(ctx, feed.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Ingest)
+	assert.Equal(t, hashSecret(creds.Token), stored.Ingest.TokenHash, "the token is stored hashed")
+	assert.True(t, models.IsSealedSecret(stored.Ingest.Secret), "the secret is sealed at rest")
+
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"price":12.5}`)
+	got, err := svc.VerifyIngest(ctx, creds.Token, ts, SignWebhookPayload(creds.Secret, ts, body), body, now)
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, got.ID)
+
+	_, err = svc.VerifyIngest(ctx, creds.Token, ts, SignWebhookPayload("wrong", ts, body), body, now)
+	assert.ErrorIs(t, err, ErrInvalidIngestSignature)
+	_, err = svc.VerifyIngest(ctx, creds.Token, ts, SignWebhookPayload(creds.Secret, ts, body), []byte(`{"price":1}`), now)
+	assert.ErrorIs(t, err, ErrInvalidIngestSignature, "the signature covers the body")
+	_, err = svc.VerifyIngest(ctx, creds.Token, ts, SignWebhookPayload(creds.Secret, ts, body), body, now.Add(IngestSignatureTolerance+time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidIngestSignature, "old signatures cannot be replayed")
+	_, err = svc.VerifyIngest(ctx, feed.ID.Hex()+".guess", ts, SignWebhookPayload(creds.Secret, ts, body), body, now)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+	_, err = svc.VerifyIngest(ctx, "nonsense", ts, "", body, now)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+
+	rotated, err := svc.IssueIngestCredentials(ctx, feed.ID)
+	require.NoError(t, err)
+	_, err = svc.VerifyIngest(ctx, creds.Token, ts, SignWebhookPayload(creds.Secret, ts, body), body, now)
+	assert.ErrorIs(t, err, ErrFeedNotFound, "issuing new credentials retires the old token")
+	_, err = svc.VerifyIngest(ctx, rotated.Token, ts, SignWebhookPayload(rotated.Secret, ts, body), body, now)
+	assert.NoError(t, err)
+}
+
+func TestMarketplaceService_IngestCredentialsNeedPushFeed(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMarketplaceServiceWithRepos(NewMemoryFeedRepo(), NewMemorySubscriptionRepo())
+	feed, err := svc.CreateFeed(ctx, models.WebSocketFeed{Name: "Prices", URL: "wss://example.com/feed"})
+	require.NoError(t, err)
+
+	_, err = svc.IssueIngestCredentials(ctx, feed.ID)
+	assert.ErrorIs(t, err, ErrNotPushFeed)
+	_, err = svc.IssueIngestCredentials(ctx, primitive.NewObjectID())
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// feedSecretAAD is the authenticated data of sealed feed secrets, so they do
+// not open as LLM keys and the reverse
+const feedSecretAAD = "feed-secret"
+
+// feedSecretFields are the feed fields that may hold secrets
+var feedSecretFields = []string{"headers", "queryParams", "httpConfig", "connectionMessages", "connectionMessage", "secretMessages"}
+
+// FeedSecrets encrypts the credentials in feeds at rest with AES-GCM under
+// the server's encryption key: the values of sensitive headers, query params
+// and HTTP request headers, connection messages marked secret and push feed
+// ingestion secrets
+type FeedSecrets struct {
+	aead cipher.AEAD
+}
+
+// NewFeedSecrets creates feed secret encryption keyed by the server secret
+func NewFeedSecrets(secret string) *FeedSecrets {
+	return &FeedSecrets{aead: newKeyCipher(secret)}
+}
+
+// Seal encrypts the feed's plaintext secrets; sealed values are kept as they are
+func (s *FeedSecrets) Seal(feed *models.WebSocketFeed) error {
+	if s == nil {
+		return nil
+	}
+	return eachFeedSecret(feed, true, s.seal)
+}
+
+// Open decrypts every sealed value of the feed, for connecting upstream
+func (s *FeedSecrets) Open(feed *models.WebSocketFeed) error {
+	if s == nil {
+		return nil
+	}
+	return eachFeedSecret(feed, false, s.open)
+}
+
+func (s *FeedSecrets) seal(value string) (string, error) {
+	if value == "" || models.IsSealedSecret(value) {
+		return value, nil
+	}
+	sealed, err := sealKey(s.aead, value, feedSecretAAD)
+	if err != nil {
+		return "", err
+	}
+	return models.SealedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *FeedSecrets) open(value string) (string, error) {
+	if !models.IsSealedSecret(value) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(models.SealedSecretPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("feed secret: %w", err)
+	}
+	plain, err := openKey(s.aead, sealed, feedSecretAAD)
+	if err != nil {
+		return "", fmt.Errorf("feed secret: %w", err)
+	}
+	return plain, nil
+}
+
+// eachFeedSecret replaces the feed's secret values with fn's result. With
+// sensitiveOnly, only sensitive values are visited; otherwise every value of
+// the secret-bearing fields is. Slices and maps are copied first so copies of
+// the feed sharing them are left alone.
+func eachFeedSecret(feed *models.WebSocketFeed, sensitiveOnly bool, fn func(string) (string, error)) error {
+	var err error
+	keyValues := func(items []models.KeyValue) []models.KeyValue {
+		if items == nil {
+			return nil
+		}
+		out := append([]models.KeyValue(nil), items...)
+		for i := range out {
+			if err == nil && (!sensitiveOnly || models.SensitiveKey(out[i].Key)) {
+				out[i].Value, err = fn(out[i].Value)
+			}
+		}
+		return out
+	}
+	feed.Headers = keyValues(feed.Headers)
+	feed.QueryParams = keyValues(feed.QueryParams)
+	if feed.HTTPConfig != nil && feed.HTTPConfig.RequestHeaders != nil {
+		cfg := *feed.HTTPConfig
+		cfg.RequestHeaders = make(map[string]string, len(feed.HTTPConfig.RequestHeaders))
+		for k, v := range feed.HTTPConfig.RequestHeaders {
+			if err == nil && (!sensitiveOnly || models.SensitiveKey(k)) {
+				v, err = fn(v)
+			}
+			cfg.RequestHeaders[k] = v
+		}
+		feed.HTTPConfig = &cfg
+	}
+	if feed.SecretMessages || !sensitiveOnly {
+		if feed.ConnectionMessages != nil {
+			messages := append([]string(nil), feed.ConnectionMessages...)
+			for i := range messages {
+				if err == nil {
+					messages[i], err = fn(messages[i])
+				}
+			}
+			feed.ConnectionMessages = messages
+		}
+		if err == nil {
+			feed.ConnectionMessage, err = fn(feed.ConnectionMessage)
+		}
+	}
+	if feed.Ingest != nil && err == nil {
+		ingest := *feed.Ingest
+		ingest.Secret, err = fn(ingest.Secret)
+		feed.Ingest = &ingest
+	}
+	return err
+}
+
+// keepRedactedSecrets puts back the stored value wherever feed holds
+// models.RedactedValue, as sent by clients that edit a feed they fetched.
+// Headers and params are matched by key and connection messages by position;
+// a placeholder with nothing to keep becomes empty.
+func keepRedactedSecrets(feed *models.WebSocketFeed, stored models.WebSocketFeed) {
+	keyValues := func(items, stored []models.KeyValue) []models.KeyValue {
+		if items == nil {
+			return nil
+		}
+		out := append([]models.KeyValue(nil), items...)
+		for i := range out {
+			if out[i].Value != models.RedactedValue {
+				continue
+			}
+			out[i].Value = ""
+			for _, kv := range stored {
+				if kv.Key == out[i].Key {
+					out[i].Value = kv.Value
+					break
+				}
+			}
+		}
+		return out
+	}
+	feed.Headers = keyValues(feed.Headers, stored.Headers)
+	feed.QueryParams = keyValues(feed.QueryParams, stored.QueryParams)
+	if feed.HTTPConfig != nil && feed.HTTPConfig.RequestHeaders != nil {
+		cfg := *feed.HTTPConfig
+		cfg.RequestHeaders = make(map[string]string, len(feed.HTTPConfig.RequestHeaders))
+		for k, v := range feed.HTTPConfig.RequestHeaders {
+			if v == models.RedactedValue {
+				v = ""
+				if stored.HTTPConfig != nil {
+					v = stored.HTTPConfig.RequestHeaders[k]
+				}
+			}
+			cfg.RequestHeaders[k] = v
+		}
+		feed.HTTPConfig = &cfg
+	}
+	if feed.ConnectionMessages != nil {
+		messages := append([]string(nil), feed.ConnectionMessages...)
+		for i, msg := range messages {
+			if msg != models.RedactedValue {
+				continue
+			}
+			messages[i] = ""
+			if i < len(stored.ConnectionMessages) {
+				messages[i] = stored.ConnectionMessages[i]
+			}
+		}
+		feed.ConnectionMessages = messages
+	}
+	if feed.ConnectionMessage == models.RedactedValue {
+		feed.ConnectionMessage = stored.ConnectionMessage
+	}
+}
+
+// sealFeedUpdates rewrites the secret-bearing fields of a feed update with
+// redacted placeholders resolved against the stored feed and secrets sealed.
+// Updates that touch none of them are left alone.
+func (s *MarketplaceService) sealFeedUpdates(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	touched := bson.M{}
+	for _, key := range feedSecretFields {
+		if v, ok := updates[key]; ok {
+			touched[key] = v
+		}
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+	stored, err := s.feeds.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	raw, err := bson.Marshal(touched)
+	if err != nil {
+		return err
+	}
+	var changed models.WebSocketFeed
+	if err := bson.Unmarshal(raw, &changed); err != nil {
+		return ErrInvalidFeedUpdate
+	}
+	// The secretMessages flag applies whichever of it and the messages the
+	// update sets, so the changes are laid over the stored feed
+	merged := stored
+	for key := range touched {
+		switch key {
+		case "headers":
+			merged.Headers = changed.Headers
+		case "queryParams":
+			merged.QueryParams = changed.QueryParams
+		case "httpConfig":
+			merged.HTTPConfig = changed.HTTPConfig
+		case "connectionMessages":
+			merged.ConnectionMessages = changed.ConnectionMessages
+		case "connectionMessage":
+			merged.ConnectionMessage = changed.ConnectionMessage
+		case "secretMessages":
+			merged.SecretMessages = changed.SecretMessages
+		}
+	}
+	keepRedactedSecrets(&merged, stored)
+	if err := s.secrets.Seal(&merged); err != nil {
+		return err
+	}
+	values := bson.M{
+		"headers":            merged.Headers,
+		"queryParams":        merged.QueryParams,
+		"httpConfig":         merged.HTTPConfig,
+		"connectionMessages": merged.ConnectionMessages,
+		"connectionMessage":  merged.ConnectionMessage,
+	}
+	for key := range touched {
+		if v, ok := values[key]; ok {
+			updates[key] = v
+		}
+	}
+	if _, ok := touched["secretMessages"]; ok {
+		// Turning the flag on seals messages the update did not resend
+		updates["connectionMessages"] = merged.ConnectionMessages
+		updates["connectionMessage"] = merged.ConnectionMessage
+	}
+	return nil
+}
+
+// SealStoredSecrets encrypts the secrets of feeds saved in plaintext, such as
+// those created before encryption was enabled, and returns how many feeds it
+// changed
+func (s *MarketplaceService) SealStoredSecrets(ctx context.Context) (int, error) {
+	if s.secrets == nil {
+		return 0, nil
+	}
+	changed := 0
+	for _, filter := range []FeedFilter{{}, {Deleted: true}} {
+		feeds, err := s.feeds.List(ctx, filter, FeedSortRecent, 0, 0)
+		if err != nil {
+			return changed, err
+		}
+		for _, feed := range feeds {
+			sealed := feed
+			if err := s.secrets.Seal(&sealed); err != nil {
+				return changed, err
+			}
+			if reflect.DeepEqual(sealed, feed) {
+				continue
+			}
+			if err := s.feeds.Update(ctx, feed.ID, bson.M{
+				"headers":            sealed.Headers,
+				"queryParams":        sealed.QueryParams,
+				"httpConfig":         sealed.HTTPConfig,
+				"connectionMessages": sealed.ConnectionMessages,
+				"connectionMessage":  sealed.ConnectionMessage,
+			}); err != nil {
+				return changed, err
+			}
+			changed++
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func secretFeed() models.WebSocketFeed {
+	return models.WebSocketFeed{
+		Name:        "Prices",
+		URL:         "wss://example.com/feed",
+		OwnerID:     "owner",
+		Headers:     []models.KeyValue{{Key: "Authorization", Value: "Bearer sk-live"}, {Key: "Accept", Value: "application/json"}},
+		QueryParams: []models.KeyValue{{Key: "api_key", Value: "qk-live"}, {Key: "symbol", Value: "BTC"}},
+		HTTPConfig: &models.HTTPPollingConfig{
+			Method:         "GET",
+			RequestHeaders: map[string]string{"X-Auth-Token": "tk-live", "User-Agent": "turbostream"},
+		},
+		ConnectionMessages: []string{`{"auth":"msg-live"}`},
+		SecretMessages:     true,
+	}
+}
+
+func TestFeedSecrets_SealAndOpen(t *testing.T) {
+	secrets := NewFeedSecrets("secret")
+	feed := secretFeed()
+	sealed := feed
+	require.NoError(t, secrets.Seal(&sealed))
+
+	assert.True(t, models.IsSealedSecret(sealed.Headers[0].Value))
+	assert.Equal(t, "application/json", sealed.Headers[1].Value)
+	assert.True(t, models.IsSealedSecret(sealed.QueryParams[0].Value))
+	assert.Equal(t, "BTC", sealed.QueryParams[1].Value)
+	assert.True(t, models.IsSealedSecret(sealed.HTTPConfig.RequestHeaders["X-Auth-Token"]))
+	assert.Equal(t, "turbostream", sealed.HTTPConfig.RequestHeaders["User-Agent"])
+	assert.True(t, models.IsSealedSecret(sealed.ConnectionMessages[0]))
+	assert.Equal(t, "Bearer sk-live", feed.Headers[0].Value, "the original feed is left alone")
+
+	again := sealed
+	require.NoError(t, secrets.Seal(&again))
+	assert.Equal(t, sealed.Headers, again.Headers, "sealed values are not sealed twice")
+
+	opened := sealed
+	require.NoError(t, secrets.Open(&opened))
+	assert.Equal(t, feed.Headers, opened.Headers)
+	assert.Equal(t, feed.QueryParams, opened.QueryParams)
+	assert.Equal(t, feed.HTTPConfig.RequestHeaders, opened.HTTPConfig.RequestHeaders)
+	assert.Equal(t, feed.ConnectionMessages, opened.ConnectionMessages)
+
+	assert.Error(t, NewFeedSecrets("rotated").Open(&sealed))
+}
+
+func TestWebSocketFeed_MarshalJSONRedactsSecrets(t *testing.T) {
+	feed := secretFeed()
+	require.NoError(t, NewFeedSecrets("secret").Seal(&feed))
+	feed.Headers = append(feed.Headers, models.KeyValue{Key: "X-Api-Key", Value: "plain-live"})
+
+	raw, err := json.Marshal(feed)
+	require.NoError(t, err)
+	for _, secret := range []string{"sk-live", "qk-live", "tk-live", "msg-live", "plain-live", models.SealedSecretPrefix} {
+		assert.NotContains(t, string(raw), secret)
+	}
+	assert.Contains(t, string(raw), "application/json")
+	assert.Contains(t, string(raw), "BTC")
+
+	var out models.WebSocketFeed
+	require.NoError(t, json.Unmarshal(raw, &out))
+	assert.Equal(t, models.RedactedValue, out.Headers[0].Value)
+	assert.Equal(t, models.RedactedValue, out.Headers[2].Value)
+	assert.Equal(t, models.RedactedValue, out.HTTPConfig.RequestHeaders["X-Auth-Token"])
+	assert.Equal(t, []string{models.RedactedValue}, out.ConnectionMessages)
+}
+
+func TestMarketplaceService_FeedSecrets(t *testing.T) {
+	service := setupMarketplaceService()
+	secrets := NewFeedSecrets("secret")
+	service.SetFeedSecrets(secrets)
+	ctx := context.Background()
+
+	created, err := service.CreateFeed(ctx, secretFeed())
+	require.NoError(t, err)
+	stored, err := service.GetFeedByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	assert.True(t, models.IsSealedSecret(stored.Headers[0].Value), "secrets are encrypted at rest")
+
+	// Clients send back what they fetched, placeholders included
+	updated, err := service.UpdateFeed(ctx, created.ID, bson.M{
+		"headers": []interface{}{
+			map[string]interface{}{"key": "Authorization", "value": models.RedactedValue},
+			map[string]interface{}{"key": "X-Api-Key", "value": "new-live"},
+			map[string]interface{}{"key": "Cookie", "value": models.RedactedValue},
+		},
+		"connectionMessages": []interface{}{models.RedactedValue, `{"op":"subscribe"}`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, stored.Headers[0].Value, updated.Headers[0].Value, "a placeholder keeps the stored value")
+	assert.True(t, models.IsSealedSecret(updated.Headers[1].Value))
+	assert.Empty(t, updated.Headers[2].Value, "a placeholder with nothing stored is cleared")
+	assert.Equal(t, stored.ConnectionMessages[0], updated.ConnectionMessages[0])
+	assert.True(t, models.IsSealedSecret(updated.ConnectionMessages[1]))
+
+	opened, err := service.OpenFeedSecrets(*updated)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer sk-live", opened.Headers[0].Value)
+	assert.Equal(t, "new-live", opened.Headers[1].Value)
+	assert.Equal(t, []string{`{"auth":"msg-live"}`, `{"op":"subscribe"}`}, opened.ConnectionMessages)
+
+	_, err = service.UpdateFeed(ctx, created.ID, bson.M{"headers": "Authorization: sk"})
+	assert.ErrorIs(t, err, ErrInvalidFeedUpdate)
+}
+
+func TestMarketplaceService_SealStoredSecrets(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+
+	// Saved before encryption was enabled
+	created, err := service.CreateFeed(ctx, secretFeed())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer sk-live", created.Headers[0].Value)
+
+	service.SetFeedSecrets(NewFeedSecrets("secret"))
+	n, err := service.SealStoredSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	stored, err := service.GetFeedByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	assert.True(t, models.IsSealedSecret(stored.Headers[0].Value))
+	assert.True(t, models.IsSealedSecret(stored.HTTPConfig.RequestHeaders["X-Auth-Token"]))
+
+	n, err = service.SealStoredSecrets(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
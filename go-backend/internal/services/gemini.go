@@ -35,6 +35,9 @@ func NewGeminiClient(apiKey, model string) *GeminiClient {
 // Name returns the provider identifier
 func (c *GeminiClient) Name() string { return "gemini" }
 
+// Model returns the model queries are sent to
+func (c *GeminiClient) Model() string { return c.model }
+
 // Enabled returns true if Gemini is configured
 func (c *GeminiClient) Enabled() bool {
 	return c.apiKey != ""
@@ -65,19 +68,16 @@ func (c *GeminiClient) convertMessages(messages []ChatMessage) (string, []map[st
 }
 
 // Chat sends a non-streaming chat completion request
-func (c *GeminiClient) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (c *GeminiClient) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !c.Enabled() {
-		return "", 0, errors.New("gemini not configured")
+		return "", TokenCount{}, fmt.Errorf("gemini: %w", ErrProviderNotConfigured)
 	}
 
 	systemInstruction, contents := c.convertMessages(messages)
 
 	reqBody := map[string]interface{}{
-		"contents": contents,
-		"generationConfig": map[string]interface{}{
-			"maxOutputTokens": 1024,
-			"temperature":     0.7,
-		},
+		"contents":         contents,
+		"generationConfig": opts.geminiConfig(),
 	}
 	if systemInstruction != "" {
 		reqBody["systemInstruction"] = map[string]interface{}{
@@ -91,19 +91,19 @@ func (c *GeminiClient) Chat(ctx context.Context, messages []ChatMessage) (string
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", c.model, c.apiKey)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("gemini error %d: %s", resp.StatusCode, string(body))
+		return "", TokenCount{}, fmt.Errorf("gemini error %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -114,37 +114,30 @@ func (c *GeminiClient) Chat(ctx context.Context, messages []ChatMessage) (string
 				} `json:"parts"`
 			} `json:"content"`
 		} `json:"candidates"`
-		UsageMetadata struct {
-			PromptTokenCount     int `json:"promptTokenCount"`
-			CandidatesTokenCount int `json:"candidatesTokenCount"`
-			TotalTokenCount      int `json:"totalTokenCount"`
-		} `json:"usageMetadata"`
+		UsageMetadata geminiUsage `json:"usageMetadata"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", 0, errors.New("gemini returned no content")
+		return "", TokenCount{}, errors.New("gemini returned no content")
 	}
-	return result.Candidates[0].Content.Parts[0].Text, result.UsageMetadata.TotalTokenCount, nil
+	return result.Candidates[0].Content.Parts[0].Text, result.UsageMetadata.count(), nil
 }
 
 // StreamChat sends a streaming chat completion request
-func (c *GeminiClient) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (c *GeminiClient) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
 	if !c.Enabled() {
-		return 0, errors.New("gemini not configured")
+		return TokenCount{}, fmt.Errorf("gemini: %w", ErrProviderNotConfigured)
 	}
 
 	systemInstruction, contents := c.convertMessages(messages)
 
 	reqBody := map[string]interface{}{
-		"contents": contents,
-		"generationConfig": map[string]interface{}{
-			"maxOutputTokens": 1024,
-			"temperature":     0.7,
-		},
+		"contents":         contents,
+		"generationConfig": opts.geminiConfig(),
 	}
 	if systemInstruction != "" {
 		reqBody["systemInstruction"] = map[string]interface{}{
@@ -158,23 +151,23 @@ func (c *GeminiClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", c.model, c.apiKey)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("gemini error %d: %s", resp.StatusCode, string(body))
+		return TokenCount{}, fmt.Errorf("gemini error %d: %s", resp.StatusCode, string(body))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
-	var totalTokens int
+	var usage TokenCount
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -191,9 +184,7 @@ func (c *GeminiClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 					} `json:"parts"`
 				} `json:"content"`
 			} `json:"candidates"`
-			UsageMetadata struct {
-				TotalTokenCount int `json:"totalTokenCount"`
-			} `json:"usageMetadata"`
+			UsageMetadata geminiUsage `json:"usageMetadata"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			continue
@@ -201,12 +192,24 @@ func (c *GeminiClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 		if len(chunk.Candidates) > 0 && len(chunk.Candidates[0].Content.Parts) > 0 {
 			tokens <- chunk.Candidates[0].Content.Parts[0].Text
 		}
-		if chunk.UsageMetadata.TotalTokenCount > 0 {
-			totalTokens = chunk.UsageMetadata.TotalTokenCount
+		// Each chunk reports the usage so far; the last one is the total
+		if chunk.UsageMetadata.PromptTokenCount > 0 {
+			usage = chunk.UsageMetadata.count()
 		}
 	}
 
-	return totalTokens, scanner.Err()
+	return usage, scanner.Err()
+}
+
+// geminiUsage is the usageMetadata Gemini reports with a response
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+}
+
+// count converts the usage to a TokenCount
+func (u geminiUsage) count() TokenCount {
+	return TokenCount{Prompt: u.PromptTokenCount, Completion: u.CandidatesTokenCount}
 }
 
 // Ensure GeminiClient implements LLMProvider
@@ -36,42 +36,48 @@ func NewGrokClient(apiKey, model string) *GrokClient {
 // Name returns the provider identifier
 func (c *GrokClient) Name() string { return "grok" }
 
+// Model returns the model queries are sent to
+func (c *GrokClient) Model() string { return c.model }
+
 // Enabled returns true if Grok is configured
 func (c *GrokClient) Enabled() bool {
 	return c.apiKey != ""
 }
 
 // Chat sends a non-streaming chat completion request
-func (c *GrokClient) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (c *GrokClient) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !c.Enabled() {
-		return "", 0, errors.New("grok not configured")
+		return "", TokenCount{}, fmt.Errorf("grok: %w", ErrProviderNotConfigured)
 	}
 
 	// xAI uses OpenAI-compatible format
 	reqBody := map[string]interface{}{
 		"model":       c.model,
 		"messages":    messages,
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  opts.maxTokens(1024),
+		"temperature": opts.temperature(0.7),
+	}
+	if format := opts.openAIResponseFormat(true); format != nil {
+		reqBody["response_format"] = format
 	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.x.ai/v1/chat/completions", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("grok error %d: %s", resp.StatusCode, string(body))
+		return "", TokenCount{}, fmt.Errorf("grok error %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -80,56 +86,59 @@ func (c *GrokClient) Chat(ctx context.Context, messages []ChatMessage) (string,
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
-		Usage struct {
-			TotalTokens int `json:"total_tokens"`
-		} `json:"usage"`
+		Usage openAIUsage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	if len(result.Choices) == 0 {
-		return "", 0, errors.New("grok returned no choices")
+		return "", TokenCount{}, errors.New("grok returned no choices")
 	}
-	return result.Choices[0].Message.Content, result.Usage.TotalTokens, nil
+	return result.Choices[0].Message.Content, result.Usage.count(), nil
 }
 
 // StreamChat sends a streaming chat completion request
-func (c *GrokClient) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (c *GrokClient) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
 	if !c.Enabled() {
-		return 0, errors.New("grok not configured")
+		return TokenCount{}, fmt.Errorf("grok: %w", ErrProviderNotConfigured)
 	}
 
 	reqBody := map[string]interface{}{
 		"model":       c.model,
 		"messages":    messages,
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  opts.maxTokens(1024),
+		"temperature": opts.temperature(0.7),
 		"stream":      true,
+		// Ask for a final chunk with the token usage
+		"stream_options": map[string]bool{"include_usage": true},
+	}
+	if format := opts.openAIResponseFormat(true); format != nil {
+		reqBody["response_format"] = format
 	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.x.ai/v1/chat/completions", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("grok error %d: %s", resp.StatusCode, string(body))
+		return TokenCount{}, fmt.Errorf("grok error %d: %s", resp.StatusCode, string(body))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
-	var totalContent strings.Builder
+	var usage TokenCount
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -147,20 +156,20 @@ func (c *GrokClient) StreamChat(ctx context.Context, messages []ChatMessage, tok
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			continue
 		}
 		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-			content := chunk.Choices[0].Delta.Content
-			totalContent.WriteString(content)
-			tokens <- content
+			tokens <- chunk.Choices[0].Delta.Content
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.count()
 		}
 	}
 
-	// Estimate tokens (actual count not available in streaming)
-	estimatedTokens := len(totalContent.String()) / 4
-	return estimatedTokens, scanner.Err()
+	return usage, scanner.Err()
 }
 
 // Ensure GrokClient implements LLMProvider
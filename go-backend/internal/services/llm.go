@@ -3,15 +3,18 @@ package services
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/turboline-ai/tsln-golang"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/tracing"
 )
 
 // FeedContext represents accumulated feed data for LLM context
@@ -31,7 +34,14 @@ type LLMService struct {
 	// Feed context storage
 	contextMu    sync.RWMutex
 	feedContexts map[string]*FeedContext
+	schemas      map[string]*models.FeedSchema              // inferred feed schemas, included in system prompts
+	aggregates   map[string]map[string]models.FeedAggregate // latest rollup per feed and window, included in system prompts
 	contextLimit int
+	numPrecision int                 // Decimal places for floats in CSV context (-1 = shortest exact)
+	cache        *llmCache           // answers to repeated questions; nil when caching is disabled
+	userKeys     *LLMKeyService      // users' own provider keys; nil disables them
+	history      *FeedHistoryService // stored feed messages read by tools; nil limits them to the context
+	retrieval    *retrievalIndex     // picks the relevant entries of large contexts; nil sends them all
 }
 
 // NewLLMService creates a new LLM service with multi-provider support
@@ -41,7 +51,12 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		providers:    make(map[string]LLMProvider),
 		defaultProv:  cfg.DefaultAIProvider,
 		feedContexts: make(map[string]*FeedContext),
+		schemas:      make(map[string]*models.FeedSchema),
+		aggregates:   make(map[string]map[string]models.FeedAggregate),
 		contextLimit: cfg.LLMContextLimit,
+		numPrecision: cfg.LLMNumberPrecision,
+		cache:        newLLMCache(cfg.LLMCacheTTL),
+		retrieval:    newRetrievalIndex(cfg),
 	}
 
 	// Register all configured providers
@@ -50,7 +65,7 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 	azure := NewAzureOpenAI(cfg)
 	if azure.Enabled() {
 		svc.providers["azure-openai"] = azure
-		log.Printf("✓ Azure OpenAI enabled (endpoint: %s, deployment: %s)", cfg.AzureEndpoint, cfg.AzureDeployment)
+		slog.Info("Azure OpenAI enabled", "endpoint", cfg.AzureEndpoint, "deployment", cfg.AzureDeployment)
 	}
 
 	// OpenAI
@@ -58,7 +73,7 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		openai := NewOpenAIClient(cfg.OpenAIAPIKey, cfg.OpenAIModel)
 		if openai.Enabled() {
 			svc.providers["openai"] = openai
-			log.Printf("✓ OpenAI enabled (model: %s)", cfg.OpenAIModel)
+			slog.Info("OpenAI enabled", "model", cfg.OpenAIModel)
 		}
 	}
 
@@ -67,7 +82,7 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		anthropic := NewAnthropicClient(cfg.AnthropicAPIKey, cfg.AnthropicModel)
 		if anthropic.Enabled() {
 			svc.providers["anthropic"] = anthropic
-			log.Printf("✓ Anthropic enabled (model: %s)", cfg.AnthropicModel)
+			slog.Info("Anthropic enabled", "model", cfg.AnthropicModel)
 		}
 	}
 
@@ -76,7 +91,7 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		gemini := NewGeminiClient(cfg.GoogleAPIKey, cfg.GoogleModel)
 		if gemini.Enabled() {
 			svc.providers["gemini"] = gemini
-			log.Printf("✓ Gemini enabled (model: %s)", cfg.GoogleModel)
+			slog.Info("Gemini enabled", "model", cfg.GoogleModel)
 		}
 	}
 
@@ -85,7 +100,7 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		mistral := NewMistralClient(cfg.MistralAPIKey, cfg.MistralModel)
 		if mistral.Enabled() {
 			svc.providers["mistral"] = mistral
-			log.Printf("✓ Mistral enabled (model: %s)", cfg.MistralModel)
+			slog.Info("Mistral enabled", "model", cfg.MistralModel)
 		}
 	}
 
@@ -94,7 +109,7 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		grok := NewGrokClient(cfg.XAIAPIKey, cfg.XAIModel)
 		if grok.Enabled() {
 			svc.providers["grok"] = grok
-			log.Printf("✓ Grok enabled (model: %s)", cfg.XAIModel)
+			slog.Info("Grok enabled", "model", cfg.XAIModel)
 		}
 	}
 
@@ -103,14 +118,14 @@ func NewLLMService(cfg config.Config) (*LLMService, error) {
 		ollama := NewOllamaClient(cfg.OllamaBaseURL, cfg.OllamaModel)
 		if ollama.Enabled() {
 			svc.providers["ollama"] = ollama
-			log.Printf("✓ Ollama enabled (model: %s)", cfg.OllamaModel)
+			slog.Info("Ollama enabled", "model", cfg.OllamaModel)
 		}
 	}
 
 	if len(svc.providers) == 0 {
-		log.Printf("⚠ No LLM providers configured - AI features will be disabled")
+		slog.Warn("no LLM providers configured, AI features will be disabled")
 	} else {
-		log.Printf("✓ %d LLM provider(s) available: %v", len(svc.providers), svc.GetAvailableProviders())
+		slog.Info("LLM providers available", "providers", svc.GetAvailableProviders())
 	}
 
 	return svc, nil
@@ -121,6 +136,19 @@ func (s *LLMService) Enabled() bool {
 	return len(s.providers) > 0
 }
 
+// EnabledFor returns true if the user can query a provider, on the server's
+// keys or their own
+func (s *LLMService) EnabledFor(ctx context.Context, userID string) bool {
+	if s.Enabled() {
+		return true
+	}
+	if s.userKeys == nil || userID == "" {
+		return false
+	}
+	keys, err := s.userKeys.ProviderKeys(ctx, userID)
+	return err == nil && len(keys) > 0
+}
+
 // GetProvider returns a provider by name, or the default/first available
 func (s *LLMService) GetProvider(name string) (LLMProvider, error) {
 	// If specific provider requested
@@ -128,7 +156,7 @@ func (s *LLMService) GetProvider(name string) (LLMProvider, error) {
 		if p, ok := s.providers[name]; ok {
 			return p, nil
 		}
-		return nil, fmt.Errorf("provider '%s' not configured", name)
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, name)
 	}
 
 	// Try default provider
@@ -146,7 +174,85 @@ func (s *LLMService) GetProvider(name string) (LLMProvider, error) {
 		}
 	}
 
-	return nil, errors.New("no LLM providers available")
+	return nil, ErrNoProviders
+}
+
+// SetUserKeys lets queries with a UserID run on the user's own provider keys
+func (s *LLMService) SetUserKeys(keys *LLMKeyService) {
+	s.userKeys = keys
+}
+
+// SetFeedHistory lets tools read feed messages older than the context
+func (s *LLMService) SetFeedHistory(history *FeedHistoryService) {
+	s.history = history
+}
+
+// pickUserKeyProvider returns the provider a query runs on with the user's
+// own key: the requested one, or else the default or first one they have a
+// key for. It reports false when the query should use the server's keys.
+func pickUserKeyProvider(keys map[string]ProviderKey, requested, defaultProv string) (string, bool) {
+	if requested != "" {
+		_, ok := keys[requested]
+		return requested, ok
+	}
+	if _, ok := keys[defaultProv]; ok {
+		return defaultProv, true
+	}
+	for _, name := range userKeyProviders {
+		if _, ok := keys[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// newUserKeyProvider builds a provider client with a user's key, using the
+// server's model for the provider unless the user picked one
+func (s *LLMService) newUserKeyProvider(name string, key ProviderKey) LLMProvider {
+	model := func(server string) string {
+		if key.Model != "" {
+			return key.Model
+		}
+		return server
+	}
+	switch name {
+	case "openai":
+		return NewOpenAIClient(key.APIKey, model(s.cfg.OpenAIModel))
+	case "anthropic":
+		return NewAnthropicClient(key.APIKey, model(s.cfg.AnthropicModel))
+	case "gemini":
+		return NewGeminiClient(key.APIKey, model(s.cfg.GoogleModel))
+	case "mistral":
+		return NewMistralClient(key.APIKey, model(s.cfg.MistralModel))
+	case "grok":
+		return NewGrokClient(key.APIKey, model(s.cfg.XAIModel))
+	}
+	return nil
+}
+
+// resolveProvider returns the provider a query runs on and whether it uses
+// the user's own key. Queries fall back to the server's providers when the
+// user has no matching key or their keys cannot be loaded.
+func (s *LLMService) resolveProvider(ctx context.Context, req QueryRequest) (LLMProvider, bool, error) {
+	if s.userKeys != nil && req.UserID != "" {
+		keys, err := s.userKeys.ProviderKeys(ctx, req.UserID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to load LLM keys", "user_id", req.UserID, "error", err)
+		} else if name, ok := pickUserKeyProvider(keys, req.Provider, s.defaultProv); ok {
+			if p := s.newUserKeyProvider(name, keys[name]); p != nil {
+				return p, true, nil
+			}
+		}
+	}
+	p, err := s.GetProvider(req.Provider)
+	return p, false, err
+}
+
+// UsesOwnKey reports whether a query would run on the user's own key, in
+// which case the server's token quota does not apply
+func (s *LLMService) UsesOwnKey(ctx context.Context, req QueryRequest) bool {
+	_, own, err := s.resolveProvider(ctx, req)
+	return err == nil && own
 }
 
 // GetAvailableProviders returns a list of configured provider names
@@ -204,6 +310,7 @@ func (s *LLMService) AddFeedData(feedID, feedName string, data interface{}) {
 	}
 
 	ctx.UpdatedAt = time.Now()
+	s.cache.invalidate(feedID)
 }
 
 // GetFeedContext returns the current context for a feed
@@ -213,40 +320,284 @@ func (s *LLMService) GetFeedContext(feedID string) *FeedContext {
 	return s.feedContexts[feedID]
 }
 
+// ContextUpdates returns when the context of each feed with data on this
+// instance last changed
+func (s *LLMService) ContextUpdates() map[string]time.Time {
+	s.contextMu.RLock()
+	defer s.contextMu.RUnlock()
+	updates := make(map[string]time.Time, len(s.feedContexts))
+	for feedID, fc := range s.feedContexts {
+		if len(fc.Entries) > 0 {
+			updates[feedID] = fc.UpdatedAt
+		}
+	}
+	return updates
+}
+
+// LatestEntry returns the most recent context entry for a feed
+func (s *LLMService) LatestEntry(feedID string) (map[string]interface{}, bool) {
+	s.contextMu.RLock()
+	defer s.contextMu.RUnlock()
+	ctx, ok := s.feedContexts[feedID]
+	if !ok || len(ctx.Entries) == 0 {
+		return nil, false
+	}
+	return ctx.Entries[0], true
+}
+
+// SetFeedSchema sets the schema described to the LLM for a feed's data
+func (s *LLMService) SetFeedSchema(feedID string, schema *models.FeedSchema) {
+	s.contextMu.Lock()
+	defer s.contextMu.Unlock()
+	s.cache.invalidate(feedID)
+	if schema == nil {
+		delete(s.schemas, feedID)
+		return
+	}
+	s.schemas[feedID] = schema
+}
+
+// withFeedSchema appends the feed's schema, when known, to a system prompt so
+// answers can refer to the real field names
+func (s *LLMService) withFeedSchema(feedID, systemPrompt string) string {
+	s.contextMu.RLock()
+	schema := s.schemas[feedID]
+	s.contextMu.RUnlock()
+	if described := describeSchema(schema); described != "" {
+		return systemPrompt + "\n\n" + described
+	}
+	return systemPrompt
+}
+
+// SetFeedAggregate keeps a closed rollup as the latest summary of its window
+func (s *LLMService) SetFeedAggregate(agg models.FeedAggregate) {
+	s.contextMu.Lock()
+	defer s.contextMu.Unlock()
+	windows, ok := s.aggregates[agg.FeedID]
+	if !ok {
+		windows = make(map[string]models.FeedAggregate)
+		s.aggregates[agg.FeedID] = windows
+	}
+	windows[agg.Window] = agg
+	s.cache.invalidate(agg.FeedID)
+}
+
+// ClearFeedAggregates forgets a feed's rollups, e.g. when aggregation is turned off
+func (s *LLMService) ClearFeedAggregates(feedID string) {
+	s.contextMu.Lock()
+	defer s.contextMu.Unlock()
+	delete(s.aggregates, feedID)
+	s.cache.invalidate(feedID)
+}
+
+// withFeedAggregates appends the feed's latest rollups, when known, to a
+// system prompt so answers about trends need not scan every entry
+func (s *LLMService) withFeedAggregates(feedID, systemPrompt string) string {
+	s.contextMu.RLock()
+	aggs := make([]models.FeedAggregate, 0, len(s.aggregates[feedID]))
+	for _, agg := range s.aggregates[feedID] {
+		aggs = append(aggs, agg)
+	}
+	s.contextMu.RUnlock()
+	if described := describeAggregates(aggs); described != "" {
+		return systemPrompt + "\n\n" + described
+	}
+	return systemPrompt
+}
+
 // ClearFeedContext removes context for a feed
 func (s *LLMService) ClearFeedContext(feedID string) {
 	s.contextMu.Lock()
 	defer s.contextMu.Unlock()
 	delete(s.feedContexts, feedID)
+	s.cache.invalidate(feedID)
+	s.retrieval.forget(feedID)
+}
+
+// SnapshotContexts returns copies of all feed contexts for persistence
+func (s *LLMService) SnapshotContexts() []FeedContext {
+	s.contextMu.RLock()
+	defer s.contextMu.RUnlock()
+	out := make([]FeedContext, 0, len(s.feedContexts))
+	for _, fc := range s.feedContexts {
+		snap := *fc
+		snap.Entries = append([]map[string]interface{}(nil), fc.Entries...)
+		out = append(out, snap)
+	}
+	return out
+}
+
+// RestoreContexts loads persisted contexts, keeping at most contextLimit
+// entries per feed and dropping entries older than maxAge (0 keeps all).
+// Feeds that already have live data are left alone. It returns the number of
+// feeds restored.
+func (s *LLMService) RestoreContexts(contexts []FeedContext, maxAge time.Duration, now time.Time) int {
+	s.contextMu.Lock()
+	defer s.contextMu.Unlock()
+
+	restored := 0
+	for _, fc := range contexts {
+		if _, exists := s.feedContexts[fc.FeedID]; exists || fc.FeedID == "" {
+			continue
+		}
+		entries := make([]map[string]interface{}, 0, len(fc.Entries))
+		for _, entry := range fc.Entries {
+			if maxAge > 0 && entryTooOld(entry, now.Add(-maxAge)) {
+				continue
+			}
+			entries = append(entries, entry)
+			if len(entries) == s.contextLimit {
+				break
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		s.feedContexts[fc.FeedID] = &FeedContext{
+			FeedID:    fc.FeedID,
+			FeedName:  fc.FeedName,
+			Entries:   entries,
+			UpdatedAt: fc.UpdatedAt,
+		}
+		s.cache.invalidate(fc.FeedID)
+		restored++
+	}
+	return restored
+}
+
+// entryTooOld reports whether a context entry was recorded before cutoff.
+// Entries without a readable timestamp are treated as too old.
+func entryTooOld(entry map[string]interface{}, cutoff time.Time) bool {
+	ts, _ := entry["_timestamp"].(string)
+	t, err := time.Parse(time.RFC3339, ts)
+	return err != nil || t.Before(cutoff)
 }
 
 // QueryRequest represents a question about feed data
 type QueryRequest struct {
 	FeedID       string `json:"feedId"`
 	Question     string `json:"question"`
-	Provider     string `json:"provider,omitempty"` // Optional: specify provider (ignored, always uses Azure)
+	Provider     string `json:"provider,omitempty"` // Optional: specify provider
 	SystemPrompt string `json:"systemPrompt,omitempty"`
+	// UserID, when set, runs the query on the user's own provider key if
+	// they stored one
+	UserID string `json:"-"`
+
+	// Optional generation settings; unset values use the provider defaults
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	// ResponseFormat asks for the answer as JSON, optionally matching a schema
+	ResponseFormat *ResponseFormat `json:"responseFormat,omitempty"`
+	// History holds the earlier turns of a conversation, oldest first; the
+	// feed data is only sent with the new question
+	History []ChatMessage `json:"-"`
+	// Tools lets the model call whitelisted tools that compute figures from
+	// the feed before answering; streamed queries can't use them
+	Tools bool `json:"tools,omitempty"`
+}
+
+// chatOptions returns the request's generation settings
+func (r QueryRequest) chatOptions() ChatOptions {
+	return ChatOptions{Temperature: r.Temperature, MaxTokens: r.MaxTokens, Format: r.ResponseFormat}
+}
+
+// messages returns the chat sent for the request: the system prompt, any
+// earlier turns, then the new question
+func (r QueryRequest) messages(systemPrompt, userPrompt string) []ChatMessage {
+	messages := make([]ChatMessage, 0, len(r.History)+2)
+	messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt})
+	messages = append(messages, r.History...)
+	return append(messages, ChatMessage{Role: "user", Content: userPrompt})
 }
 
 // QueryResponse represents the LLM response
 type QueryResponse struct {
-	Answer     string `json:"answer"`
-	Provider   string `json:"provider"`
-	FeedID     string `json:"feedId"`
-	TokensUsed int    `json:"tokensUsed,omitempty"`
-	Duration   int64  `json:"durationMs"`
-	Error      string `json:"error,omitempty"`
+	Answer   string `json:"answer"`
+	Provider string `json:"provider"`
+	// Model is the provider's model, when it says which
+	Model  string `json:"model,omitempty"`
+	FeedID string `json:"feedId"`
+	// Tokens as reported by the provider, counted locally where it reports
+	// none; TokensUsed is their sum
+	TokensUsed       int    `json:"tokensUsed,omitempty"`
+	PromptTokens     int    `json:"promptTokens,omitempty"`
+	CompletionTokens int    `json:"completionTokens,omitempty"`
+	Duration         int64  `json:"durationMs"`
+	Error            string `json:"error,omitempty"`
+	// Cached is set when the answer was reused from an identical earlier
+	// question about the same feed data; no tokens were used
+	Cached bool `json:"cached,omitempty"`
+	// OwnKey is set when the provider was called with the user's own key
+	OwnKey bool `json:"ownKey,omitempty"`
+	// Failover lists every provider tried, in order, when the first one
+	// failed; Provider is the one that answered
+	Failover []ProviderAttempt `json:"failover,omitempty"`
+	// JSON is the parsed answer of a query that asked for JSON
+	JSON interface{} `json:"json,omitempty"`
+	// ToolCalls lists the tools the model called, in order
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	// Retrieved counts the entries sent because they were relevant to the
+	// question, when the feed's context was too large to send whole
+	Retrieved int `json:"retrieved,omitempty"`
+	// ContextEntries counts the feed entries sent, the newest that fit in the
+	// model's context window. ContextWindow is that window in tokens and
+	// ContextUtilizationPercent the share of it the prompt filled.
+	ContextEntries            int     `json:"contextEntries,omitempty"`
+	ContextWindow             int     `json:"contextWindow,omitempty"`
+	ContextUtilizationPercent float64 `json:"contextUtilizationPercent,omitempty"`
+}
+
+// setContextUtilization records the window of the model that answered and
+// how much of it the prompt filled
+func (r *QueryResponse) setContextUtilization(window int) {
+	r.ContextWindow = window
+	r.ContextUtilizationPercent = contextUtilization(r.PromptTokens, window)
+}
+
+// startQuery starts the span covering a whole question, from building the
+// context to the provider's answer
+func startQuery(ctx context.Context, name string, req QueryRequest) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(
+		attribute.String("feed.id", req.FeedID),
+		attribute.String("llm.provider_requested", req.Provider),
+		attribute.Bool("llm.tools", req.Tools),
+	))
+}
+
+// endQuery ends a question's span with the provider that answered it
+func endQuery(span trace.Span, resp *QueryResponse, err error) {
+	if resp != nil {
+		span.SetAttributes(
+			attribute.String("llm.provider", resp.Provider),
+			attribute.Bool("llm.cached", resp.Cached),
+			attribute.Bool("llm.failover", resp.Failover != nil),
+			attribute.Int("llm.tokens", resp.TokensUsed),
+		)
+	}
+	tracing.End(span, err)
 }
 
 // Query answers a question based on feed context
-func (s *LLMService) Query(ctx context.Context, req QueryRequest) (*QueryResponse, error) {
+func (s *LLMService) Query(ctx context.Context, req QueryRequest) (resp *QueryResponse, err error) {
+	ctx, span := startQuery(ctx, "llm.query", req)
+	defer func() { endQuery(span, resp, err) }()
 	start := time.Now()
+	if req.Tools && s.cfg.LLMToolMaxCalls <= 0 {
+		return nil, ErrLLMToolsDisabled
+	}
 
-	// Get the appropriate provider
-	provider, err := s.GetProvider(req.Provider)
+	// Get the selected provider and the ones to fail over to
+	opts := req.chatOptions()
+	chain, ownKey, err := s.providerChain(ctx, req, opts)
 	if err != nil {
 		return nil, err
 	}
+	provider := chain[0]
+
+	// Read the context version before the context so an answer is never
+	// cached under a newer version than the data it saw
+	version := s.cache.version(req.FeedID)
+	cacheKey := s.cache.key(req, provider.Name(), version)
 
 	// Get feed context
 	feedCtx := s.GetFeedContext(req.FeedID)
@@ -258,52 +609,13 @@ func (s *LLMService) Query(ctx context.Context, req QueryRequest) (*QueryRespons
 			Duration: time.Since(start).Milliseconds(),
 		}, nil
 	}
-
-	// OPTIMIZATION: Convert JSON entries to TSLN format to save tokens
-	var contextData string
-	if len(feedCtx.Entries) > 0 {
-		var points []tsln.BufferedDataPoint
-		for _, entry := range feedCtx.Entries {
-			// Clone entry to avoid modifying the original source
-			data := make(map[string]interface{})
-			var ts time.Time
-
-			for k, v := range entry {
-				if k == "_timestamp" {
-					if tStr, ok := v.(string); ok {
-						parsed, err := time.Parse(time.RFC3339, tStr)
-						if err == nil {
-							ts = parsed
-						}
-					}
-				} else {
-					data[k] = v
-				}
-			}
-
-			// If no timestamp found, default to now
-			if ts.IsZero() {
-				ts = time.Now()
-			}
-
-			points = append(points, tsln.BufferedDataPoint{
-				Timestamp: ts,
-				Data:      data,
-			})
-		}
-
-		// Convert to TSLN
-		result, err := tsln.ConvertToTSLN(points, nil)
-		if err != nil {
-			// Fallback to JSON if TSLN fails
-			log.Printf("⚠️ TSLN conversion failed: %v", err)
-			bytes, _ := json.Marshal(feedCtx.Entries)
-			contextData = string(bytes)
-		} else {
-			contextData = result.TSLN
-		}
+	if cached, ok := s.cachedAnswer(req, cacheKey, start); ok {
+		return cached, nil
 	}
 
+	// Large contexts are cut down to the entries relevant to the question
+	entries, retrieved := s.contextEntries(ctx, req, feedCtx.Entries)
+
 	// Build system prompt
 	systemPrompt := req.SystemPrompt
 	if systemPrompt == "" {
@@ -311,44 +623,79 @@ func (s *LLMService) Query(ctx context.Context, req QueryRequest) (*QueryRespons
 Answer questions based ONLY on the provided data context (in TSLN format). Be concise and accurate.
 If the data doesn't contain information to answer the question, say so clearly.`, feedCtx.FeedName)
 	}
+	systemPrompt = s.withFeedSchema(req.FeedID, systemPrompt)
+	systemPrompt = s.withFeedAggregates(req.FeedID, systemPrompt)
+	systemPrompt = withResponseFormat(systemPrompt, req.ResponseFormat)
+	if req.Tools {
+		systemPrompt = withTools(systemPrompt)
+	}
 
-	// Build user prompt with context
-	userPrompt := fmt.Sprintf(`Here is the recent streaming data (newest first):
-
-%s
-
-Question: %s`, contextData, req.Question)
+	// Build user prompt with as much context as the model's window takes;
+	// TSLN is used as it takes fewer tokens than JSON
+	header := contextHeader(retrieved)
+	contextData, sent := s.fitContext(chain[0], req, systemPrompt, header, opts.maxTokens(s.cfg.LLMMaxTokens), entries, buildTSLNContext)
+	userPrompt := queryPrompt(header, contextData, req.Question)
 
 	// Call the provider
-	messages := []ChatMessage{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
-	}
+	messages := req.messages(systemPrompt, userPrompt)
 
-	answer, tokensUsed, err := provider.Chat(ctx, messages)
+	answer, usage, answered, attempts, err := s.chatWithFailover(ctx, chain, messages, opts)
 	if err != nil {
-		return nil, fmt.Errorf("%s error: %w", provider.Name(), err)
+		return nil, err
+	}
+	var toolCalls []ToolCall
+	if req.Tools {
+		if answer, usage, toolCalls, err = s.answerWithTools(ctx, answered, req.FeedID, messages, opts, answer, usage); err != nil {
+			return nil, err
+		}
 	}
 
-	return &QueryResponse{
-		Answer:     answer,
-		Provider:   provider.Name(),
-		FeedID:     req.FeedID,
-		TokensUsed: tokensUsed,
-		Duration:   time.Since(start).Milliseconds(),
-	}, nil
+	resp = &QueryResponse{
+		Answer:           answer,
+		Provider:         answered.Name(),
+		Model:            providerModel(answered),
+		FeedID:           req.FeedID,
+		TokensUsed:       usage.Total(),
+		PromptTokens:     usage.Prompt,
+		CompletionTokens: usage.Completion,
+		Duration:         time.Since(start).Milliseconds(),
+		OwnKey:           ownKey,
+		Failover:         failover(attempts),
+		ToolCalls:        toolCalls,
+		Retrieved:        retrieved,
+		ContextEntries:   sent,
+	}
+	resp.setContextUtilization(s.contextWindow(answered))
+	if req.ResponseFormat.isJSON() {
+		if resp.JSON, err = parseStructuredAnswer(answer, req.ResponseFormat); err != nil {
+			return nil, err
+		}
+	}
+	s.cache.put(req.FeedID, cacheKey, version, *resp, time.Now())
+	return resp, nil
 }
 
 // StreamQuery streams the LLM response token by token
-func (s *LLMService) StreamQuery(ctx context.Context, req QueryRequest, tokenChan chan<- string) (*QueryResponse, error) {
+func (s *LLMService) StreamQuery(ctx context.Context, req QueryRequest, tokenChan chan<- string) (resp *QueryResponse, err error) {
+	ctx, span := startQuery(ctx, "llm.stream_query", req)
+	defer func() { endQuery(span, resp, err) }()
 	start := time.Now()
+	if req.Tools {
+		close(tokenChan)
+		return nil, ErrLLMToolsStreaming
+	}
 
-	// Get the appropriate provider
-	provider, err := s.GetProvider(req.Provider)
+	// Get the selected provider and the ones to fail over to
+	opts := req.chatOptions()
+	chain, ownKey, err := s.providerChain(ctx, req, opts)
 	if err != nil {
 		close(tokenChan)
 		return nil, err
 	}
+	provider := chain[0]
+
+	version := s.cache.version(req.FeedID)
+	cacheKey := s.cache.key(req, provider.Name(), version)
 
 	// Get feed context
 	feedCtx := s.GetFeedContext(req.FeedID)
@@ -363,74 +710,165 @@ func (s *LLMService) StreamQuery(ctx context.Context, req QueryRequest, tokenCha
 			Duration: time.Since(start).Milliseconds(),
 		}, nil
 	}
-
-	// OPTIMIZATION: Convert JSON entries to CSV-like format to save tokens
-	var contextData string
-	if len(feedCtx.Entries) > 0 {
-		var keys []string
-		for k := range feedCtx.Entries[0] {
-			keys = append(keys, k)
-		}
-		var sb strings.Builder
-		sb.WriteString(strings.Join(keys, ", "))
-		sb.WriteString("\n")
-		for _, entry := range feedCtx.Entries {
-			var values []string
-			for _, k := range keys {
-				val := entry[k]
-				values = append(values, fmt.Sprintf("%v", val))
-			}
-			sb.WriteString(strings.Join(values, ", "))
-			sb.WriteString("\n")
-		}
-		contextData = sb.String()
+	if cached, ok := s.cachedAnswer(req, cacheKey, start); ok {
+		tokenChan <- cached.Answer
+		close(tokenChan)
+		return cached, nil
 	}
 
+	entries, retrieved := s.contextEntries(ctx, req, feedCtx.Entries)
+
 	systemPrompt := req.SystemPrompt
 	if systemPrompt == "" {
 		systemPrompt = fmt.Sprintf(`You are an AI assistant analyzing real-time streaming data from feed "%s".
 Answer questions based ONLY on the provided tabular data context. Be concise and accurate.`, feedCtx.FeedName)
 	}
+	systemPrompt = s.withFeedSchema(req.FeedID, systemPrompt)
+	systemPrompt = s.withFeedAggregates(req.FeedID, systemPrompt)
+	systemPrompt = withResponseFormat(systemPrompt, req.ResponseFormat)
 
-	userPrompt := fmt.Sprintf(`Here is the recent streaming data (newest first):
+	// OPTIMIZATION: Convert JSON entries to CSV-like format to save tokens,
+	// sending as many as the model's window takes
+	header := contextHeader(retrieved)
+	contextData, sent := s.fitContext(chain[0], req, systemPrompt, header, opts.maxTokens(s.cfg.LLMMaxTokens), entries, s.csvContext)
+	userPrompt := queryPrompt(header, contextData, req.Question)
+
+	// Build messages
+	messages := req.messages(systemPrompt, userPrompt)
 
-%s
+	answer, usage, answered, attempts, err := s.streamWithFailover(ctx, chain, messages, opts, tokenChan)
+	if answered == nil {
+		return nil, err
+	}
 
-Question: %s`, contextData, req.Question)
+	resp = &QueryResponse{
+		Answer:           answer,
+		Provider:         answered.Name(),
+		Model:            providerModel(answered),
+		FeedID:           req.FeedID,
+		TokensUsed:       usage.Total(),
+		PromptTokens:     usage.Prompt,
+		CompletionTokens: usage.Completion,
+		Duration:         time.Since(start).Milliseconds(),
+		OwnKey:           ownKey,
+		Failover:         failover(attempts),
+		Retrieved:        retrieved,
+		ContextEntries:   sent,
+	}
+	resp.setContextUtilization(s.contextWindow(answered))
+	if err == nil && req.ResponseFormat.isJSON() {
+		// The answer has already been streamed, so a bad one is reported alongside it
+		if resp.JSON, err = parseStructuredAnswer(answer, req.ResponseFormat); err != nil {
+			resp.Error = err.Error()
+		}
+	}
+	// Only complete answers are reused, not those cut short by a cancel
+	if err == nil && ctx.Err() == nil && resp.Answer != "" {
+		s.cache.put(req.FeedID, cacheKey, version, *resp, time.Now())
+	}
+	return resp, nil
+}
 
-	// Build messages
-	messages := []ChatMessage{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
+// EstimatePromptTokens counts the prompt tokens a query would send with the
+// feed's current context, so quotas can be checked before the call
+func (s *LLMService) EstimatePromptTokens(req QueryRequest) int {
+	provider, err := s.GetProvider(req.Provider)
+	if err != nil {
+		return 0
 	}
+	feedCtx := s.GetFeedContext(req.FeedID)
+	if feedCtx == nil || len(feedCtx.Entries) == 0 {
+		return 0
+	}
+	systemPrompt := s.withFeedAggregates(req.FeedID, s.withFeedSchema(req.FeedID, req.SystemPrompt))
+	if req.Tools {
+		systemPrompt = withTools(systemPrompt)
+	}
+	// Retrieval sends at most topK entries, so as many of the newest stand in for them
+	entries := feedCtx.Entries
+	header := contextHeader(0)
+	if s.retrieval.applies(len(entries)) {
+		entries = entries[:s.retrieval.topK]
+		header = contextHeader(len(entries))
+	}
+	contextData, _ := s.fitContext(provider, req, systemPrompt, header, req.chatOptions().maxTokens(s.cfg.LLMMaxTokens), entries, s.csvContext)
+	return countMessageTokens(provider.Name(), req.messages(systemPrompt, queryPrompt(header, contextData, req.Question)))
+}
+
+// csvContext renders entries as CSV at the configured number precision
+func (s *LLMService) csvContext(entries []map[string]interface{}) string {
+	return buildCSVContext(entries, s.numPrecision)
+}
+
+// buildTSLNContext renders entries, newest first, in TSLN, falling back to
+// JSON when they can't be converted
+func buildTSLNContext(entries []map[string]interface{}) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var points []tsln.BufferedDataPoint
+	for _, entry := range entries {
+		// Clone entry to avoid modifying the original source
+		data := make(map[string]interface{})
+		var ts time.Time
+
+		for k, v := range entry {
+			if k == "_timestamp" {
+				if tStr, ok := v.(string); ok {
+					parsed, err := time.Parse(time.RFC3339, tStr)
+					if err == nil {
+						ts = parsed
+					}
+				}
+			} else {
+				data[k] = v
+			}
+		}
 
-	// Collect streamed tokens for the full answer
-	var fullAnswer strings.Builder
-	internalChan := make(chan string, 100)
+		// If no timestamp found, default to now
+		if ts.IsZero() {
+			ts = time.Now()
+		}
 
-	// Start streaming from provider
-	go func() {
-		_, _ = provider.StreamChat(ctx, messages, internalChan)
-	}()
+		points = append(points, tsln.BufferedDataPoint{
+			Timestamp: ts,
+			Data:      data,
+		})
+	}
 
-	// Forward tokens and collect full answer
-	for token := range internalChan {
-		fullAnswer.WriteString(token)
-		tokenChan <- token
+	// Convert to TSLN
+	result, err := tsln.ConvertToTSLN(points, nil)
+	if err != nil {
+		// Fallback to JSON if TSLN fails
+		slog.Warn("TSLN conversion failed", "error", err)
+		bytes, _ := json.Marshal(entries)
+		return string(bytes)
 	}
-	close(tokenChan)
+	return result.TSLN
+}
 
-	return &QueryResponse{
-		Answer:   fullAnswer.String(),
-		Provider: provider.Name(),
-		FeedID:   req.FeedID,
-		Duration: time.Since(start).Milliseconds(),
-	}, nil
+// cachedAnswer returns the cached answer to the same question about the same
+// feed data, if there is one
+func (s *LLMService) cachedAnswer(req QueryRequest, key string, start time.Time) (*QueryResponse, bool) {
+	cached, ok := s.cache.get(req.FeedID, key, time.Now())
+	if !ok {
+		return nil, false
+	}
+	cached.TokensUsed = 0
+	cached.PromptTokens = 0
+	cached.CompletionTokens = 0
+	cached.Cached = true
+	cached.Duration = time.Since(start).Milliseconds()
+	return &cached, true
 }
 
-// AnalyzeFeed provides a general analysis of feed data
-func (s *LLMService) AnalyzeFeed(ctx context.Context, feedID string, customPrompt string) (*QueryResponse, error) {
-	question := "Provide a brief summary and analysis of this data. Highlight any notable patterns, trends, or anomalies."
+// defaultAnalysisPrompt is the question of an analysis without its own prompt
+const defaultAnalysisPrompt = "Provide a brief summary and analysis of this data. Highlight any notable patterns, trends, or anomalies."
+
+// AnalyzeFeed provides a general analysis of feed data, on the user's own
+// key when userID is set and they stored one
+func (s *LLMService) AnalyzeFeed(ctx context.Context, feedID, customPrompt, userID string) (*QueryResponse, error) {
+	question := defaultAnalysisPrompt
 	if customPrompt != "" {
 		question = customPrompt
 	}
@@ -438,5 +876,6 @@ func (s *LLMService) AnalyzeFeed(ctx context.Context, feedID string, customPromp
 	return s.Query(ctx, QueryRequest{
 		FeedID:   feedID,
 		Question: question,
+		UserID:   userID,
 	})
 }
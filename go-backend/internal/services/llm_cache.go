@@ -0,0 +1,159 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+)
+
+// llmCacheMaxEntries bounds the cached answers across all feeds
+const llmCacheMaxEntries = 1000
+
+// llmCacheEntry is one cached answer
+type llmCacheEntry struct {
+	resp    QueryResponse
+	expires time.Time
+}
+
+// llmCache keeps answers to repeated questions while a feed's context is
+// unchanged. Each feed has a context version that moves whenever its data,
+// schema or rollups change; that drops the feed's answers, and answers computed
+// against an older version are never looked up again.
+type llmCache struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	versions map[string]uint64
+	entries  map[string]map[string]llmCacheEntry // feed ID -> key -> answer
+	size     int
+}
+
+// newLLMCache returns a cache keeping answers for ttl, or nil when ttl is not positive
+func newLLMCache(ttl time.Duration) *llmCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &llmCache{
+		ttl:      ttl,
+		versions: make(map[string]uint64),
+		entries:  make(map[string]map[string]llmCacheEntry),
+	}
+}
+
+// version returns the feed's current context version
+func (c *llmCache) version(feedID string) uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.versions[feedID]
+}
+
+// invalidate moves the feed to a new context version and drops its answers
+func (c *llmCache) invalidate(feedID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions[feedID]++
+	c.size -= len(c.entries[feedID])
+	delete(c.entries, feedID)
+}
+
+// key identifies a question about a feed at a context version. The provider
+// and generation settings are part of it since they change the answer.
+func (c *llmCache) key(req QueryRequest, provider string, version uint64) string {
+	h := sha256.New()
+	for _, part := range []string{provider, strings.TrimSpace(req.Question), req.SystemPrompt, strconv.Itoa(req.MaxTokens)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	if req.Temperature != nil {
+		h.Write([]byte(strconv.FormatFloat(*req.Temperature, 'g', -1, 64)))
+	}
+	h.Write([]byte{0})
+	if req.ResponseFormat != nil {
+		format, _ := json.Marshal(req.ResponseFormat)
+		h.Write(format)
+	}
+	if req.Tools {
+		h.Write([]byte("tools"))
+	}
+	// Follow-up questions are answered in light of the turns before them
+	for _, m := range req.History {
+		h.Write([]byte{0})
+		h.Write([]byte(m.Role + ":" + m.Content))
+	}
+	return strconv.FormatUint(version, 10) + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the cached answer for key, counting the lookup as a hit or miss
+func (c *llmCache) get(feedID, key string, now time.Time) (QueryResponse, bool) {
+	if c == nil {
+		return QueryResponse{}, false
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[feedID][key]
+	if ok && !now.Before(entry.expires) {
+		delete(c.entries[feedID], key)
+		c.size--
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		metrics.LLMCacheLookups.WithLabelValues("miss").Inc()
+		return QueryResponse{}, false
+	}
+	metrics.LLMCacheLookups.WithLabelValues("hit").Inc()
+	return entry.resp, true
+}
+
+// put caches an answer computed at version; answers to a context that has
+// since changed are discarded
+func (c *llmCache) put(feedID, key string, version uint64, resp QueryResponse, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.versions[feedID] != version {
+		return
+	}
+	if c.size >= llmCacheMaxEntries {
+		c.evictExpired(now)
+		if c.size >= llmCacheMaxEntries {
+			return
+		}
+	}
+	answers, ok := c.entries[feedID]
+	if !ok {
+		answers = make(map[string]llmCacheEntry)
+		c.entries[feedID] = answers
+	}
+	if _, exists := answers[key]; !exists {
+		c.size++
+	}
+	answers[key] = llmCacheEntry{resp: resp, expires: now.Add(c.ttl)}
+}
+
+// evictExpired drops every expired answer; the caller holds c.mu
+func (c *llmCache) evictExpired(now time.Time) {
+	for feedID, answers := range c.entries {
+		for key, entry := range answers {
+			if !now.Before(entry.expires) {
+				delete(answers, key)
+				c.size--
+			}
+		}
+		if len(answers) == 0 {
+			delete(c.entries, feedID)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// countingProvider answers every question the same way and counts calls
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
+	p.calls++
+	return "answer", TokenCount{Prompt: 40, Completion: 2}, nil
+}
+
+func (p *countingProvider) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
+	defer close(tokens)
+	p.calls++
+	tokens <- "ans"
+	tokens <- "wer"
+	return TokenCount{Prompt: 40, Completion: 2}, nil
+}
+
+func (p *countingProvider) Enabled() bool { return true }
+func (p *countingProvider) Name() string  { return "counting" }
+
+func newCachingLLMService(t *testing.T, ttl time.Duration) (*LLMService, *countingProvider) {
+	t.Helper()
+	svc, err := NewLLMService(config.Config{LLMContextLimit: 10, LLMCacheTTL: ttl})
+	require.NoError(t, err)
+	provider := &countingProvider{}
+	svc.providers[provider.Name()] = provider
+	svc.defaultProv = provider.Name()
+	return svc, provider
+}
+
+func TestLLMService_Query_CachesUntilFeedChanges(t *testing.T) {
+	svc, provider := newCachingLLMService(t, time.Minute)
+	svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"price": 1.0})
+	req := QueryRequest{FeedID: "feed-1", Question: "What is the price?"}
+
+	first, err := svc.Query(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, first.Cached)
+	assert.Equal(t, 42, first.TokensUsed)
+
+	second, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: " What is the price? "})
+	require.NoError(t, err)
+	assert.True(t, second.Cached)
+	assert.Equal(t, "answer", second.Answer)
+	assert.Zero(t, second.TokensUsed)
+	assert.Equal(t, 1, provider.calls)
+
+	// A different question or different settings is a miss
+	temp := 0.1
+	_, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "What is the price?", Temperature: &temp})
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls)
+
+	// New data, schema or rollups invalidate the feed's answers
+	svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"price": 2.0})
+	third, err := svc.Query(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, third.Cached)
+	assert.Equal(t, 3, provider.calls)
+
+	svc.SetFeedSchema("feed-1", &models.FeedSchema{})
+	_, err = svc.Query(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 4, provider.calls)
+}
+
+func TestLLMService_StreamQuery_Cached(t *testing.T) {
+	svc, provider := newCachingLLMService(t, time.Minute)
+	svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"price": 1.0})
+	req := QueryRequest{FeedID: "feed-1", Question: "What is the price?"}
+
+	stream := func() (*QueryResponse, []string) {
+		tokens := make(chan string, 10)
+		resp, err := svc.StreamQuery(context.Background(), req, tokens)
+		require.NoError(t, err)
+		var got []string
+		for token := range tokens {
+			got = append(got, token)
+		}
+		return resp, got
+	}
+
+	first, tokens := stream()
+	assert.False(t, first.Cached)
+	assert.Equal(t, []string{"ans", "wer"}, tokens)
+
+	second, tokens := stream()
+	assert.True(t, second.Cached)
+	assert.Equal(t, []string{"answer"}, tokens)
+	assert.Equal(t, 1, provider.calls)
+
+	// Streamed and non-streamed answers share the cache
+	resp, err := svc.Query(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, resp.Cached)
+}
+
+func TestLLMService_Query_CacheDisabled(t *testing.T) {
+	svc, provider := newCachingLLMService(t, 0)
+	svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"price": 1.0})
+	req := QueryRequest{FeedID: "feed-1", Question: "What is the price?"}
+	for i := 0; i < 2; i++ {
+		resp, err := svc.Query(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Cached)
+	}
+	assert.Equal(t, 2, provider.calls)
+}
+
+func TestLLMCache_ExpiryAndStaleVersions(t *testing.T) {
+	c := newLLMCache(time.Minute)
+	now := time.Now()
+	req := QueryRequest{FeedID: "feed-1", Question: "q"}
+	key := c.key(req, "p", c.version("feed-1"))
+
+	c.put("feed-1", key, 0, QueryResponse{Answer: "a"}, now)
+	_, ok := c.get("feed-1", key, now.Add(30*time.Second))
+	assert.True(t, ok)
+	_, ok = c.get("feed-1", key, now.Add(time.Minute))
+	assert.False(t, ok)
+	assert.Zero(t, c.size)
+
+	// An answer finished after the feed changed is not kept
+	c.invalidate("feed-1")
+	c.put("feed-1", key, 0, QueryResponse{Answer: "a"}, now)
+	assert.Zero(t, c.size)
+	assert.NotEqual(t, key, c.key(req, "p", c.version("feed-1")))
+}
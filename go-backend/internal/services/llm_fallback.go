@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/tracing"
+)
+
+// fallbackOrder is the order the server's providers are tried in after the
+// selected one fails
+var fallbackOrder = []string{"azure-openai", "openai", "anthropic", "gemini", "mistral", "grok", "ollama"}
+
+// ProviderAttempt is one provider call of a query that failed over
+type ProviderAttempt struct {
+	Provider   string `json:"provider"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// failover returns the attempts to report in a response: none unless the
+// query moved past its first provider
+func failover(attempts []ProviderAttempt) []ProviderAttempt {
+	if len(attempts) < 2 {
+		return nil
+	}
+	return attempts
+}
+
+// providerChain returns the providers a query tries in order and whether
+// they use the user's own keys: the selected provider, then the other
+// providers that accept the query's options, up to LLMMaxProviderAttempts.
+// Own-key queries only fail over to the user's other keys, so they never
+// spend the server's keys without a quota check.
+func (s *LLMService) providerChain(ctx context.Context, req QueryRequest, opts ChatOptions) ([]LLMProvider, bool, error) {
+	first, own, err := s.resolveProvider(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := opts.Validate(first.Name()); err != nil {
+		return nil, false, err
+	}
+	chain := []LLMProvider{first}
+	add := func(p LLMProvider) {
+		if len(chain) < s.cfg.LLMMaxProviderAttempts && p.Name() != first.Name() && opts.Validate(p.Name()) == nil {
+			chain = append(chain, p)
+		}
+	}
+	if own {
+		keys, err := s.userKeys.ProviderKeys(ctx, req.UserID)
+		if err != nil {
+			return chain, own, nil
+		}
+		for _, name := range userKeyProviders {
+			if key, ok := keys[name]; ok {
+				if p := s.newUserKeyProvider(name, key); p != nil {
+					add(p)
+				}
+			}
+		}
+		return chain, own, nil
+	}
+	for _, name := range fallbackOrder {
+		if p, ok := s.providers[name]; ok {
+			add(p)
+		}
+	}
+	return chain, own, nil
+}
+
+// fallbackDeadline returns when a query started at start stops failing over;
+// zero without a budget
+func (s *LLMService) fallbackDeadline(start time.Time) time.Time {
+	if s.cfg.LLMFallbackBudget <= 0 {
+		return time.Time{}
+	}
+	return start.Add(s.cfg.LLMFallbackBudget)
+}
+
+// canFailOver reports whether another provider may be tried: the caller is
+// still waiting and the budget is not spent
+func canFailOver(ctx context.Context, deadline time.Time) bool {
+	return ctx.Err() == nil && (deadline.IsZero() || time.Now().Before(deadline))
+}
+
+// attemptContext bounds one provider call by the provider timeout and what
+// is left of the budget
+func (s *LLMService) attemptContext(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	timeout := s.cfg.LLMProviderTimeout
+	if !deadline.IsZero() {
+		if left := time.Until(deadline); timeout <= 0 || left < timeout {
+			timeout = left
+		}
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// attemptError describes a failed attempt for the failover chain
+func attemptError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// startAttempt starts the span of one provider call
+func startAttempt(ctx context.Context, provider LLMProvider, mode string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "llm.chat "+provider.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", provider.Name()),
+			attribute.String("gen_ai.request.model", providerModel(provider)),
+			attribute.String("llm.mode", mode),
+		))
+}
+
+// endAttempt ends the span of one provider call and logs it with the request
+// ID of ctx, at debug level unless it failed
+func endAttempt(ctx context.Context, span trace.Span, provider, mode string, start time.Time, usage TokenCount, err error) {
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", usage.Prompt),
+		attribute.Int("gen_ai.usage.output_tokens", usage.Completion),
+	)
+	tracing.End(span, err)
+
+	level := slog.LevelDebug
+	attrs := []slog.Attr{
+		slog.String("provider", provider),
+		slog.String("mode", mode),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("tokens", usage.Total()),
+	}
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, level, "LLM provider call", attrs...)
+}
+
+// chatWithFailover asks the chain's providers in turn until one answers,
+// returning the answer, its token usage, the provider that gave it and
+// every attempt made
+func (s *LLMService) chatWithFailover(ctx context.Context, chain []LLMProvider, messages []ChatMessage, opts ChatOptions) (string, TokenCount, LLMProvider, []ProviderAttempt, error) {
+	deadline := s.fallbackDeadline(time.Now())
+	var attempts []ProviderAttempt
+	var lastErr error
+	for i, provider := range chain {
+		if i > 0 {
+			if !canFailOver(ctx, deadline) {
+				break
+			}
+			metrics.LLMFailovers.WithLabelValues(chain[i-1].Name(), provider.Name()).Inc()
+		}
+		callCtx, cancel := s.attemptContext(ctx, deadline)
+		callCtx, span := startAttempt(callCtx, provider, "query")
+		callStart := time.Now()
+		answer, usage, err := provider.Chat(callCtx, messages, opts)
+		cancel()
+		if err == nil {
+			usage = completeTokenCount(provider.Name(), messages, answer, usage)
+		}
+		metrics.ObserveLLM(provider.Name(), "query", callStart, usage.Total(), err)
+		endAttempt(callCtx, span, provider.Name(), "query", callStart, usage, err)
+		attempts = append(attempts, ProviderAttempt{Provider: provider.Name(), Error: attemptError(err), DurationMs: time.Since(callStart).Milliseconds()})
+		if err == nil {
+			return answer, usage, provider, attempts, nil
+		}
+		lastErr = fmt.Errorf("%s error: %w", provider.Name(), err)
+	}
+	return "", TokenCount{}, nil, attempts, lastErr
+}
+
+// streamWithFailover streams from the chain's providers in turn, moving on
+// only while nothing has been forwarded to tokenChan; once a provider has
+// streamed tokens its answer is kept even if it fails part way. It closes
+// tokenChan and returns the answer, its token usage, the provider that gave
+// it, every attempt made and the error of a partial answer.
+func (s *LLMService) streamWithFailover(ctx context.Context, chain []LLMProvider, messages []ChatMessage, opts ChatOptions, tokenChan chan<- string) (string, TokenCount, LLMProvider, []ProviderAttempt, error) {
+	defer close(tokenChan)
+	deadline := s.fallbackDeadline(time.Now())
+	type streamResult struct {
+		usage TokenCount
+		err   error
+	}
+	var attempts []ProviderAttempt
+	var lastErr error
+	for i, provider := range chain {
+		if i > 0 {
+			if !canFailOver(ctx, deadline) {
+				break
+			}
+			metrics.LLMFailovers.WithLabelValues(chain[i-1].Name(), provider.Name()).Inc()
+		}
+		callCtx, cancel := s.attemptContext(ctx, deadline)
+		callCtx, span := startAttempt(callCtx, provider, "stream")
+		callStart := time.Now()
+		internalChan := make(chan string, 100)
+		done := make(chan streamResult, 1)
+		go func() {
+			usage, err := provider.StreamChat(callCtx, messages, opts, internalChan)
+			done <- streamResult{usage, err}
+		}()
+
+		// Forward tokens and collect the full answer
+		var answer strings.Builder
+		for token := range internalChan {
+			answer.WriteString(token)
+			tokenChan <- token
+		}
+		result := <-done
+		cancel()
+
+		usage := result.usage
+		if answer.Len() > 0 {
+			// Tokens streamed before a failure were still billed
+			usage = completeTokenCount(provider.Name(), messages, answer.String(), usage)
+		}
+		metrics.ObserveLLM(provider.Name(), "stream", callStart, usage.Total(), result.err)
+		endAttempt(callCtx, span, provider.Name(), "stream", callStart, usage, result.err)
+		attempts = append(attempts, ProviderAttempt{Provider: provider.Name(), Error: attemptError(result.err), DurationMs: time.Since(callStart).Milliseconds()})
+		if result.err == nil || answer.Len() > 0 {
+			return answer.String(), usage, provider, attempts, result.err
+		}
+		lastErr = fmt.Errorf("%s error: %w", provider.Name(), result.err)
+	}
+	return "", TokenCount{}, nil, attempts, lastErr
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// scriptedProvider fails with err after streaming tokens, or hangs until its
+// call is cancelled when hang is set
+type scriptedProvider struct {
+	name   string
+	tokens []string
+	err    error
+	hang   bool
+	calls  int
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
+	p.calls++
+	if p.hang {
+		<-ctx.Done()
+		return "", TokenCount{}, ctx.Err()
+	}
+	if p.err != nil {
+		return "", TokenCount{}, p.err
+	}
+	return "answer from " + p.name, TokenCount{Prompt: 10, Completion: 3}, nil
+}
+
+func (p *scriptedProvider) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
+	defer close(tokens)
+	p.calls++
+	if p.hang {
+		<-ctx.Done()
+		return TokenCount{}, ctx.Err()
+	}
+	for _, t := range p.tokens {
+		tokens <- t
+	}
+	return TokenCount{Prompt: 10, Completion: len(p.tokens)}, p.err
+}
+
+func (p *scriptedProvider) Enabled() bool { return true }
+func (p *scriptedProvider) Name() string  { return p.name }
+
+func newFailoverLLMService(t *testing.T, cfg config.Config, providers ...*scriptedProvider) *LLMService {
+	t.Helper()
+	cfg.LLMContextLimit = 10
+	svc, err := NewLLMService(cfg)
+	require.NoError(t, err)
+	for _, p := range providers {
+		svc.providers[p.name] = p
+	}
+	svc.defaultProv = providers[0].name
+	svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"price": 1.0})
+	return svc
+}
+
+func TestLLMService_Query_FailsOverToNextProvider(t *testing.T) {
+	down := &scriptedProvider{name: "openai", err: errors.New("503 service unavailable")}
+	up := &scriptedProvider{name: "anthropic"}
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3}, down, up)
+
+	resp, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"})
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", resp.Provider)
+	assert.Equal(t, "answer from anthropic", resp.Answer)
+	require.Len(t, resp.Failover, 2)
+	assert.Equal(t, "openai", resp.Failover[0].Provider)
+	assert.Contains(t, resp.Failover[0].Error, "503")
+	assert.Equal(t, "anthropic", resp.Failover[1].Provider)
+	assert.Empty(t, resp.Failover[1].Error)
+}
+
+func TestLLMService_Query_NoFailoverReportedWhenFirstAnswers(t *testing.T) {
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3}, &scriptedProvider{name: "openai"}, &scriptedProvider{name: "anthropic"})
+
+	resp, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"})
+	require.NoError(t, err)
+	assert.Equal(t, "openai", resp.Provider)
+	assert.Nil(t, resp.Failover)
+}
+
+func TestLLMService_Query_ProviderTimeout(t *testing.T) {
+	slow := &scriptedProvider{name: "openai", hang: true}
+	up := &scriptedProvider{name: "anthropic"}
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3, LLMProviderTimeout: 20 * time.Millisecond}, slow, up)
+
+	resp, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"})
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", resp.Provider)
+	assert.Contains(t, resp.Failover[0].Error, "deadline exceeded")
+}
+
+func TestLLMService_Query_AttemptLimitAndBudget(t *testing.T) {
+	down := &scriptedProvider{name: "openai", err: errors.New("boom")}
+	up := &scriptedProvider{name: "anthropic"}
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 1}, down, up)
+
+	_, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"})
+	assert.ErrorContains(t, err, "openai error: boom")
+	assert.Zero(t, up.calls)
+
+	// A spent budget stops failover even with attempts left
+	slow := &scriptedProvider{name: "openai", hang: true}
+	up = &scriptedProvider{name: "anthropic"}
+	svc = newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3, LLMFallbackBudget: 20 * time.Millisecond}, slow, up)
+	_, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"})
+	assert.Error(t, err)
+	assert.Zero(t, up.calls)
+}
+
+func TestLLMService_Query_NoFailoverAfterCallerCancels(t *testing.T) {
+	slow := &scriptedProvider{name: "openai", hang: true}
+	up := &scriptedProvider{name: "anthropic"}
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3}, slow, up)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := svc.Query(ctx, QueryRequest{FeedID: "feed-1", Question: "price?"})
+	assert.Error(t, err)
+	assert.Zero(t, up.calls)
+}
+
+func collectTokens(ch <-chan string) []string {
+	var tokens []string
+	for t := range ch {
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+func TestLLMService_StreamQuery_FailsOverBeforeFirstToken(t *testing.T) {
+	down := &scriptedProvider{name: "openai", err: errors.New("connection refused")}
+	up := &scriptedProvider{name: "anthropic", tokens: []string{"ok"}}
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3}, down, up)
+
+	tokenChan := make(chan string, 10)
+	resp, err := svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"}, tokenChan)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ok"}, collectTokens(tokenChan))
+	assert.Equal(t, "anthropic", resp.Provider)
+	assert.Len(t, resp.Failover, 2)
+}
+
+func TestLLMService_StreamQuery_KeepsPartialAnswer(t *testing.T) {
+	partial := &scriptedProvider{name: "openai", tokens: []string{"half"}, err: errors.New("stream reset")}
+	up := &scriptedProvider{name: "anthropic", tokens: []string{"ok"}}
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3}, partial, up)
+
+	tokenChan := make(chan string, 10)
+	resp, err := svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"}, tokenChan)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"half"}, collectTokens(tokenChan))
+	assert.Equal(t, "openai", resp.Provider)
+	assert.Equal(t, "half", resp.Answer)
+	assert.Nil(t, resp.Failover)
+	assert.Zero(t, up.calls)
+}
+
+func TestLLMService_StreamQuery_AllProvidersFail(t *testing.T) {
+	svc := newFailoverLLMService(t, config.Config{LLMMaxProviderAttempts: 3},
+		&scriptedProvider{name: "openai", err: errors.New("down")},
+		&scriptedProvider{name: "anthropic", err: errors.New("also down")})
+
+	tokenChan := make(chan string, 10)
+	_, err := svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "price?"}, tokenChan)
+	assert.ErrorContains(t, err, "anthropic error: also down")
+	assert.Empty(t, collectTokens(tokenChan))
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// userKeyProviders are the providers users can bring their own key for, in
+// the order one is picked when a query names no provider. Azure OpenAI needs
+// a per-deployment endpoint and Ollama no key, so both stay server-only.
+var userKeyProviders = []string{"openai", "anthropic", "gemini", "mistral", "grok"}
+
+const (
+	// userKeyCacheTTL is how long a user's decrypted keys are reused; edits
+	// made through other instances show up after it
+	userKeyCacheTTL = time.Minute
+	// maxCachedKeyUsers is how many users' keys are cached before expired
+	// entries are swept
+	maxCachedKeyUsers = 1000
+)
+
+// ProviderKey is a decrypted user key and the model to use it with
+type ProviderKey struct {
+	APIKey string
+	Model  string
+}
+
+// cachedUserKeys is a user's decrypted keys and when they were loaded
+type cachedUserKeys struct {
+	keys     map[string]ProviderKey
+	loadedAt time.Time
+}
+
+// LLMKeyService stores users' own LLM provider keys, encrypted with the
+// server's ENCRYPTION_KEY
+type LLMKeyService struct {
+	db      *mongo.Database
+	aead    cipher.AEAD
+	cacheMu sync.Mutex
+	cache   map[string]cachedUserKeys
+}
+
+// NewLLMKeyService creates the user key store
+func NewLLMKeyService(cfg config.Config, db *mongo.Database) *LLMKeyService {
+	return &LLMKeyService{db: db, aead: newKeyCipher(cfg.EncryptionKey), cache: make(map[string]cachedUserKeys)}
+}
+
+// newKeyCipher derives an AES-256-GCM cipher from the encryption secret
+func newKeyCipher(secret string) cipher.AEAD {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err) // unreachable: the key is always 32 bytes
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}
+
+// sealKey encrypts an API key, prefixing the random nonce
+func sealKey(aead cipher.AEAD, apiKey, userID string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	// The user ID is authenticated data, so a key copied to another user's record does not open
+	return aead.Seal(nonce, nonce, []byte(apiKey), []byte(userID)), nil
+}
+
+// openKey decrypts a key sealed by sealKey for the same user
+func openKey(aead cipher.AEAD, sealed []byte, userID string) (string, error) {
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("sealed key too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(userID))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// keyHint returns the last four characters of a key
+func keyHint(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return "…"
+	}
+	return "…" + apiKey[len(apiKey)-4:]
+}
+
+// isUserKeyProvider reports whether users can store a key for the provider
+func isUserKeyProvider(provider string) bool {
+	for _, p := range userKeyProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns the MongoDB user_llm_keys collection
+func (s *LLMKeyService) keys() *mongo.Collection {
+	return s.db.Collection("user_llm_keys")
+}
+
+// EnsureIndexes creates the index that keeps one key per user and provider
+func (s *LLMKeyService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.keys().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "provider", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// SetKey stores or replaces the user's key for a provider
+func (s *LLMKeyService) SetKey(ctx context.Context, userID, provider, apiKey, model string) (*models.UserLLMKey, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	apiKey = strings.TrimSpace(apiKey)
+	if !isUserKeyProvider(provider) {
+		return nil, ErrInvalidLLMKeyProvider
+	}
+	if apiKey == "" {
+		return nil, ErrLLMKeyRequired
+	}
+	sealed, err := sealKey(s.aead, apiKey, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var key models.UserLLMKey
+	err = s.keys().FindOneAndUpdate(ctx,
+		bson.M{"userId": userID, "provider": provider},
+		bson.M{
+			"$set": bson.M{
+				"encryptedKey": sealed,
+				"keyHint":      keyHint(apiKey),
+				"model":        strings.TrimSpace(model),
+				"updatedAt":    now,
+			},
+			"$setOnInsert": bson.M{"createdAt": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+	s.forget(userID)
+	return &key, nil
+}
+
+// ListKeys returns the user's stored keys, without the keys themselves
+func (s *LLMKeyService) ListKeys(ctx context.Context, userID string) ([]models.UserLLMKey, error) {
+	cur, err := s.keys().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"provider": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	keys := []models.UserLLMKey{}
+	if err := cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// DeleteKey removes the user's key for a provider
+func (s *LLMKeyService) DeleteKey(ctx context.Context, userID, provider string) error {
+	res, err := s.keys().DeleteOne(ctx, bson.M{"userId": userID, "provider": provider})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrLLMKeyNotFound
+	}
+	s.forget(userID)
+	return nil
+}
+
+// ProviderKeys returns the user's decrypted keys by provider. Keys that no
+// longer decrypt, such as after ENCRYPTION_KEY changed, are left out.
+func (s *LLMKeyService) ProviderKeys(ctx context.Context, userID string) (map[string]ProviderKey, error) {
+	s.cacheMu.Lock()
+	cached, ok := s.cache[userID]
+	s.cacheMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < userKeyCacheTTL {
+		return cached.keys, nil
+	}
+
+	stored, err := s.ListKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]ProviderKey, len(stored))
+	for _, k := range stored {
+		apiKey, err := openKey(s.aead, k.EncryptedKey, userID)
+		if err != nil {
+			continue
+		}
+		keys[k.Provider] = ProviderKey{APIKey: apiKey, Model: k.Model}
+	}
+	now := time.Now()
+	s.cacheMu.Lock()
+	if len(s.cache) >= maxCachedKeyUsers {
+		for id, c := range s.cache {
+			if now.Sub(c.loadedAt) >= userKeyCacheTTL {
+				delete(s.cache, id)
+			}
+		}
+	}
+	s.cache[userID] = cachedUserKeys{keys: keys, loadedAt: now}
+	s.cacheMu.Unlock()
+	return keys, nil
+}
+
+// forget drops the user's cached keys after an edit
+func (s *LLMKeyService) forget(userID string) {
+	s.cacheMu.Lock()
+	delete(s.cache, userID)
+	s.cacheMu.Unlock()
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+func TestSealKey_RoundTrip(t *testing.T) {
+	aead := newKeyCipher("secret")
+	sealed, err := sealKey(aead, "sk-user-key", "user-1")
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "sk-user-key")
+
+	key, err := openKey(aead, sealed, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-user-key", key)
+
+	again, err := sealKey(aead, "sk-user-key", "user-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "each seal uses a fresh nonce")
+}
+
+func TestOpenKey_Rejects(t *testing.T) {
+	aead := newKeyCipher("secret")
+	sealed, err := sealKey(aead, "sk-user-key", "user-1")
+	require.NoError(t, err)
+
+	_, err = openKey(aead, sealed, "user-2")
+	assert.Error(t, err, "a key is bound to its user")
+
+	_, err = openKey(newKeyCipher("rotated"), sealed, "user-1")
+	assert.Error(t, err)
+
+	_, err = openKey(aead, sealed[:4], "user-1")
+	assert.Error(t, err)
+}
+
+func TestKeyHint(t *testing.T) {
+	assert.Equal(t, "…wxyz", keyHint("sk-abcdefghijklmnopqrstuvwxyz"))
+	assert.Equal(t, "…", keyHint("short"))
+}
+
+func TestPickUserKeyProvider(t *testing.T) {
+	keys := map[string]ProviderKey{"anthropic": {APIKey: "a"}, "mistral": {APIKey: "m"}}
+
+	name, ok := pickUserKeyProvider(keys, "mistral", "openai")
+	assert.True(t, ok)
+	assert.Equal(t, "mistral", name)
+
+	_, ok = pickUserKeyProvider(keys, "openai", "openai")
+	assert.False(t, ok, "a requested provider without a user key uses the server's")
+
+	name, ok = pickUserKeyProvider(keys, "", "mistral")
+	assert.True(t, ok)
+	assert.Equal(t, "mistral", name)
+
+	name, ok = pickUserKeyProvider(keys, "", "openai")
+	assert.True(t, ok)
+	assert.Equal(t, "anthropic", name)
+
+	_, ok = pickUserKeyProvider(nil, "", "openai")
+	assert.False(t, ok)
+}
+
+// cachedKeyService returns a key store holding keys for one user without a database
+func cachedKeyService(userID string, keys map[string]ProviderKey) *LLMKeyService {
+	s := NewLLMKeyService(config.Config{EncryptionKey: "secret"}, nil)
+	s.cache[userID] = cachedUserKeys{keys: keys, loadedAt: time.Now()}
+	return s
+}
+
+func TestLLMService_ResolveProviderWithUserKey(t *testing.T) {
+	svc, err := NewLLMService(config.Config{OpenAIAPIKey: "server-key", DefaultAIProvider: "openai"})
+	require.NoError(t, err)
+	keys := cachedKeyService("user-1", map[string]ProviderKey{"anthropic": {APIKey: "user-key", Model: "claude-custom"}})
+	keys.cache["user-2"] = cachedUserKeys{keys: map[string]ProviderKey{}, loadedAt: time.Now()}
+	svc.SetUserKeys(keys)
+	ctx := context.Background()
+
+	p, own, err := svc.resolveProvider(ctx, QueryRequest{UserID: "user-1"})
+	require.NoError(t, err)
+	assert.True(t, own)
+	assert.Equal(t, "anthropic", p.Name())
+	assert.Equal(t, "user-key", p.(*AnthropicClient).apiKey)
+	assert.Equal(t, "claude-custom", p.(*AnthropicClient).model)
+
+	p, own, err = svc.resolveProvider(ctx, QueryRequest{UserID: "user-1", Provider: "openai"})
+	require.NoError(t, err)
+	assert.False(t, own)
+	assert.Equal(t, "openai", p.Name())
+
+	p, own, err = svc.resolveProvider(ctx, QueryRequest{UserID: "user-2"})
+	require.NoError(t, err)
+	assert.False(t, own)
+	assert.Equal(t, "openai", p.Name())
+
+	assert.True(t, svc.UsesOwnKey(ctx, QueryRequest{UserID: "user-1"}))
+	assert.False(t, svc.UsesOwnKey(ctx, QueryRequest{}))
+}
+
+func TestLLMService_EnabledForUserKeys(t *testing.T) {
+	svc, err := NewLLMService(config.Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+	assert.False(t, svc.EnabledFor(ctx, "user-1"))
+
+	svc.SetUserKeys(cachedKeyService("user-1", map[string]ProviderKey{"gemini": {APIKey: "user-key"}}))
+	assert.True(t, svc.EnabledFor(ctx, "user-1"))
+	assert.False(t, svc.EnabledFor(ctx, ""))
+}
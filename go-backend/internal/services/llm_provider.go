@@ -1,17 +1,22 @@
 package services
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // LLMProvider defines the interface all LLM providers must implement.
 // This enables a "Bring Your Own Model" (BYOM) experience where developers
 // can configure any supported provider via environment variables.
 type LLMProvider interface {
-	// Chat sends a non-streaming request and returns response + token count
-	Chat(ctx context.Context, messages []ChatMessage) (string, int, error)
+	// Chat sends a non-streaming request and returns the response and the
+	// tokens the provider reported using
+	Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error)
 
 	// StreamChat sends a streaming request, tokens arrive via channel.
-	// The channel is closed when streaming completes.
-	StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error)
+	// The channel is closed when streaming completes. Counts the provider
+	// does not report are left zero.
+	StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error)
 
 	// Enabled returns true if the provider is properly configured
 	Enabled() bool
@@ -20,6 +25,112 @@ type LLMProvider interface {
 	Name() string
 }
 
+// TokenCount is the number of tokens a provider call used
+type TokenCount struct {
+	Prompt     int `json:"promptTokens"`
+	Completion int `json:"completionTokens"`
+}
+
+// Total returns the prompt and completion tokens together
+func (t TokenCount) Total() int {
+	return t.Prompt + t.Completion
+}
+
+// ChatOptions carries optional generation settings for a request. Zero values
+// mean "use the provider default".
+type ChatOptions struct {
+	Temperature *float64
+	MaxTokens   int
+	// Format asks for a JSON answer; nil means plain text
+	Format *ResponseFormat
+}
+
+// chatLimits is the accepted range of generation settings for a provider
+type chatLimits struct {
+	maxTemperature float64
+	maxTokens      int
+}
+
+// providerChatLimits lists per-provider ranges; unknown providers use defaultChatLimits
+var providerChatLimits = map[string]chatLimits{
+	"openai":       {maxTemperature: 2, maxTokens: 16384},
+	"azure-openai": {maxTemperature: 2, maxTokens: 16384},
+	"anthropic":    {maxTemperature: 1, maxTokens: 8192},
+	"gemini":       {maxTemperature: 2, maxTokens: 8192},
+	"mistral":      {maxTemperature: 1, maxTokens: 32768},
+	"grok":         {maxTemperature: 2, maxTokens: 32768},
+	"ollama":       {maxTemperature: 2, maxTokens: 32768},
+}
+
+var defaultChatLimits = chatLimits{maxTemperature: 1, maxTokens: 4096}
+
+// Validate checks the options against the provider's accepted ranges
+func (o ChatOptions) Validate(provider string) error {
+	limits, ok := providerChatLimits[provider]
+	if !ok {
+		limits = defaultChatLimits
+	}
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > limits.maxTemperature) {
+		return fmt.Errorf("%w: temperature must be between 0 and %g for %s", ErrInvalidChatOptions, limits.maxTemperature, provider)
+	}
+	if o.MaxTokens < 0 || o.MaxTokens > limits.maxTokens {
+		return fmt.Errorf("%w: maxTokens must be between 1 and %d for %s", ErrInvalidChatOptions, limits.maxTokens, provider)
+	}
+	return validateResponseFormat(o.Format)
+}
+
+func (o ChatOptions) temperature(fallback float64) float64 {
+	if o.Temperature == nil {
+		return fallback
+	}
+	return *o.Temperature
+}
+
+func (o ChatOptions) maxTokens(fallback int) int {
+	if o.MaxTokens <= 0 {
+		return fallback
+	}
+	return o.MaxTokens
+}
+
+// ollamaOptions maps the settings onto Ollama's "options" object
+func (o ChatOptions) ollamaOptions() map[string]interface{} {
+	options := map[string]interface{}{}
+	if o.Temperature != nil {
+		options["temperature"] = *o.Temperature
+	}
+	if o.MaxTokens > 0 {
+		options["num_predict"] = o.MaxTokens
+	}
+	return options
+}
+
+// geminiConfig maps the settings onto Gemini's "generationConfig" object
+func (o ChatOptions) geminiConfig() map[string]interface{} {
+	config := map[string]interface{}{
+		"maxOutputTokens": o.maxTokens(1024),
+		"temperature":     o.temperature(0.7),
+	}
+	if o.Format.isJSON() {
+		config["responseMimeType"] = "application/json"
+	}
+	return config
+}
+
+// ollamaFormat maps a JSON format onto Ollama's "format": the schema when
+// there is one, otherwise plain JSON mode
+func (o ChatOptions) ollamaFormat() interface{} {
+	if !o.Format.isJSON() {
+		return nil
+	}
+	if o.Format.Schema != nil {
+		return o.Format.Schema
+	}
+	return "json"
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
 // Ensure AzureOpenAI implements LLMProvider
 var _ LLMProvider = (*AzureOpenAI)(nil)
 
@@ -28,16 +139,21 @@ func (s *AzureOpenAI) Name() string {
 	return "azure-openai"
 }
 
+// Model returns the deployment queries are sent to
+func (s *AzureOpenAI) Model() string {
+	return s.deployment
+}
+
 // StreamChat implements streaming for AzureOpenAI (currently falls back to non-streaming)
-func (s *AzureOpenAI) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (s *AzureOpenAI) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
-	answer, tokensUsed, err := s.Chat(ctx, messages)
+	answer, usage, err := s.Chat(ctx, messages, opts)
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 
 	// Send complete response as one chunk (streaming not yet implemented for Azure)
 	tokens <- answer
-	return tokensUsed, nil
+	return usage, nil
 }
@@ -2,9 +2,14 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test OpenAI Provider
@@ -77,9 +82,9 @@ func TestOpenAIClient_Chat_NotEnabled(t *testing.T) {
 
 	_, _, err := client.Chat(context.Background(), []ChatMessage{
 		{Role: "user", Content: "test"},
-	})
+	}, ChatOptions{})
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not configured")
+	assert.ErrorIs(t, err, ErrProviderNotConfigured)
 }
 
 func TestOpenAIClient_StreamChat_NotEnabled(t *testing.T) {
@@ -89,9 +94,30 @@ func TestOpenAIClient_StreamChat_NotEnabled(t *testing.T) {
 	tokens := make(chan string, 10)
 	_, err := client.StreamChat(context.Background(), []ChatMessage{
 		{Role: "user", Content: "test"},
-	}, tokens)
+	}, ChatOptions{}, tokens)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not configured")
+	assert.ErrorIs(t, err, ErrProviderNotConfigured)
+}
+
+func TestOpenAIClient_StreamChat_Usage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, body["stream_options"])
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":1}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient("test-key", "")
+	client.baseURL = srv.URL
+	tokens := make(chan string, 10)
+	usage, err := client.StreamChat(context.Background(), []ChatMessage{{Role: "user", Content: "hello"}}, ChatOptions{}, tokens)
+	require.NoError(t, err)
+	assert.Equal(t, TokenCount{Prompt: 12, Completion: 1}, usage)
+	assert.Equal(t, 13, usage.Total())
+	assert.Equal(t, "Hi", <-tokens)
 }
 
 // Test Anthropic Provider
@@ -219,9 +245,9 @@ func TestAnthropicClient_Chat_NotEnabled(t *testing.T) {
 
 	_, _, err := client.Chat(context.Background(), []ChatMessage{
 		{Role: "user", Content: "test"},
-	})
+	}, ChatOptions{})
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not configured")
+	assert.ErrorIs(t, err, ErrProviderNotConfigured)
 }
 
 func TestAnthropicClient_StreamChat_NotEnabled(t *testing.T) {
@@ -231,9 +257,9 @@ func TestAnthropicClient_StreamChat_NotEnabled(t *testing.T) {
 	tokens := make(chan string, 10)
 	_, err := client.StreamChat(context.Background(), []ChatMessage{
 		{Role: "user", Content: "test"},
-	}, tokens)
+	}, ChatOptions{}, tokens)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not configured")
+	assert.ErrorIs(t, err, ErrProviderNotConfigured)
 }
 
 // Test Gemini Provider
@@ -435,3 +461,48 @@ func TestLLMProvider_InterfaceCompliance(t *testing.T) {
 	var _ LLMProvider = (*GrokClient)(nil)
 	var _ LLMProvider = (*AzureOpenAI)(nil)
 }
+
+func TestChatOptions_Validate(t *testing.T) {
+	temp := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		provider string
+		opts     ChatOptions
+		wantErr  bool
+	}{
+		{"defaults", "openai", ChatOptions{}, false},
+		{"openai temperature 1.5", "openai", ChatOptions{Temperature: temp(1.5)}, false},
+		{"anthropic temperature 1.5", "anthropic", ChatOptions{Temperature: temp(1.5)}, true},
+		{"zero temperature", "anthropic", ChatOptions{Temperature: temp(0)}, false},
+		{"negative temperature", "gemini", ChatOptions{Temperature: temp(-0.1)}, true},
+		{"max tokens in range", "anthropic", ChatOptions{MaxTokens: 4096}, false},
+		{"max tokens above limit", "anthropic", ChatOptions{MaxTokens: 100000}, true},
+		{"negative max tokens", "openai", ChatOptions{MaxTokens: -1}, true},
+		{"unknown provider uses defaults", "custom", ChatOptions{Temperature: temp(1.2)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate(tt.provider)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidChatOptions)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChatOptions_Defaults(t *testing.T) {
+	temp := 0.0
+	opts := ChatOptions{Temperature: &temp, MaxTokens: 256}
+	assert.Equal(t, 0.0, opts.temperature(0.7))
+	assert.Equal(t, 256, opts.maxTokens(1024))
+	assert.Equal(t, map[string]interface{}{"temperature": 0.0, "num_predict": 256}, opts.ollamaOptions())
+
+	var empty ChatOptions
+	assert.Equal(t, 0.7, empty.temperature(0.7))
+	assert.Equal(t, 1024, empty.maxTokens(1024))
+	assert.Empty(t, empty.ollamaOptions())
+}
@@ -305,6 +305,80 @@ func TestLLMService_ClearFeedContext(t *testing.T) {
 	assert.Nil(t, ctx)
 }
 
+func TestLLMService_LatestEntry(t *testing.T) {
+	cfg := config.Config{
+		OpenAIAPIKey:    "test-key",
+		LLMContextLimit: 100,
+	}
+
+	svc, err := NewLLMService(cfg)
+	require.NoError(t, err)
+
+	feedID := "test-feed"
+	_, ok := svc.LatestEntry(feedID)
+	assert.False(t, ok)
+
+	svc.AddFeedData(feedID, "Test", map[string]interface{}{"value": 1})
+	svc.AddFeedData(feedID, "Test", map[string]interface{}{"value": 2})
+
+	entry, ok := svc.LatestEntry(feedID)
+	require.True(t, ok)
+	assert.Equal(t, 2, entry["value"])
+	assert.Contains(t, entry, "_timestamp")
+}
+
+func TestLLMService_RestoreContexts(t *testing.T) {
+	cfg := config.Config{
+		OpenAIAPIKey:    "test-key",
+		LLMContextLimit: 2,
+	}
+
+	svc, err := NewLLMService(cfg)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) map[string]interface{} {
+		return map[string]interface{}{"_timestamp": now.Add(-ago).Format(time.RFC3339)}
+	}
+
+	svc.AddFeedData("live", "Live", map[string]interface{}{"value": 1})
+
+	restored := svc.RestoreContexts([]FeedContext{
+		{FeedID: "recent", FeedName: "Recent", Entries: []map[string]interface{}{at(time.Minute), at(2 * time.Minute), at(3 * time.Minute)}},
+		{FeedID: "mixed", FeedName: "Mixed", Entries: []map[string]interface{}{at(time.Minute), at(2 * time.Hour)}},
+		{FeedID: "stale", FeedName: "Stale", Entries: []map[string]interface{}{at(2 * time.Hour)}},
+		{FeedID: "live", FeedName: "Live", Entries: []map[string]interface{}{at(time.Minute)}},
+	}, time.Hour, now)
+
+	assert.Equal(t, 2, restored)
+	assert.Len(t, svc.GetFeedContext("recent").Entries, 2, "trimmed to the context limit")
+	assert.Len(t, svc.GetFeedContext("mixed").Entries, 1, "old entries dropped")
+	assert.Nil(t, svc.GetFeedContext("stale"))
+
+	live := svc.GetFeedContext("live")
+	require.Len(t, live.Entries, 1)
+	assert.Equal(t, 1, live.Entries[0]["value"], "live data is not overwritten")
+}
+
+func TestLLMService_SnapshotContexts(t *testing.T) {
+	cfg := config.Config{
+		OpenAIAPIKey:    "test-key",
+		LLMContextLimit: 100,
+	}
+
+	svc, err := NewLLMService(cfg)
+	require.NoError(t, err)
+
+	svc.AddFeedData("feed-1", "Feed 1", map[string]interface{}{"value": 1})
+	snap := svc.SnapshotContexts()
+	require.Len(t, snap, 1)
+
+	// The snapshot is not affected by later updates
+	svc.AddFeedData("feed-1", "Feed 1", map[string]interface{}{"value": 2})
+	assert.Len(t, snap[0].Entries, 1)
+	assert.Equal(t, "Feed 1", snap[0].FeedName)
+}
+
 func TestLLMService_Query_NoProvider(t *testing.T) {
 	cfg := config.Config{
 		LLMContextLimit: 100,
@@ -320,7 +394,7 @@ func TestLLMService_Query_NoProvider(t *testing.T) {
 
 	_, err = svc.Query(context.Background(), req)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "no LLM providers available")
+	assert.ErrorIs(t, err, ErrNoProviders)
 }
 
 func TestLLMService_Query_NoData(t *testing.T) {
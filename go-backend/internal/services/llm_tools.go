@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultToolEvents is how many events get_recent_events returns without n
+	defaultToolEvents = 10
+	// maxToolEvents caps the events one get_recent_events call returns
+	maxToolEvents = 50
+	// maxToolHistoryRecords caps the history records one aggregate call reads
+	maxToolHistoryRecords = 5000
+	// maxToolResultLen caps a tool result sent back to the model, in bytes
+	maxToolResultLen = 8000
+)
+
+// ToolCall is a tool the model called while answering a query
+type ToolCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Result    interface{}            `json:"result,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// llmTool is a tool offered to the model; run computes its result from the
+// queried feed's context and history
+type llmTool struct {
+	signature   string
+	description string
+	run         func(ctx context.Context, s *LLMService, feedID string, args map[string]interface{}) (interface{}, error)
+}
+
+// llmTools is the whitelist of tools a query may call, in the order they
+// are described to the model
+var llmTools = []struct {
+	name string
+	llmTool
+}{
+	{"get_recent_events", llmTool{
+		signature:   "get_recent_events(feedId?, n?)",
+		description: fmt.Sprintf("the n most recent messages of the feed, newest first (default %d, at most %d)", defaultToolEvents, maxToolEvents),
+		run:         getRecentEvents,
+	}},
+	{"aggregate", llmTool{
+		signature:   "aggregate(field, op, window?)",
+		description: "op (count, sum, avg, min, max, first, last or change) of a numeric field over the window, a duration such as 30s, 5m or 24h; without a window over all data in context. Nested fields use dots, e.g. quote.price",
+		run:         aggregateField,
+	}},
+}
+
+// findLLMTool returns the whitelisted tool called name
+func findLLMTool(name string) (llmTool, bool) {
+	for _, t := range llmTools {
+		if t.name == name {
+			return t.llmTool, true
+		}
+	}
+	return llmTool{}, false
+}
+
+// withTools appends the tool instructions to a system prompt
+func withTools(systemPrompt string) string {
+	var b strings.Builder
+	b.WriteString(systemPrompt)
+	b.WriteString("\n\nYou can call tools to compute exact figures from this feed rather than estimating them from the data above. ")
+	b.WriteString(`To call one, reply with only a JSON object such as {"tool": "aggregate", "arguments": {"field": "price", "op": "avg", "window": "5m"}}. `)
+	b.WriteString("Its result is sent back to you; then call another tool or give your answer. Tools:")
+	for _, t := range llmTools {
+		fmt.Fprintf(&b, "\n- %s: %s", t.signature, t.description)
+	}
+	return b.String()
+}
+
+// parseToolCall reads a tool call from an answer, reporting false when the
+// answer is not a call to a whitelisted tool
+func parseToolCall(answer string) (ToolCall, bool) {
+	var call ToolCall
+	if err := json.Unmarshal([]byte(stripCodeFence(answer)), &call); err != nil {
+		return ToolCall{}, false
+	}
+	if _, ok := findLLMTool(call.Tool); !ok {
+		return ToolCall{}, false
+	}
+	return call, true
+}
+
+// runTool runs a tool call against a feed, recording its result or error
+func (s *LLMService) runTool(ctx context.Context, feedID string, call ToolCall) ToolCall {
+	tool, _ := findLLMTool(call.Tool)
+	result, err := tool.run(ctx, s, feedID, call.Arguments)
+	if err != nil {
+		call.Error = err.Error()
+		return call
+	}
+	call.Result = result
+	return call
+}
+
+// toolResultMessage tells the model the outcome of its tool call; last
+// asks it to answer since no more calls will be run
+func toolResultMessage(call ToolCall, last bool) string {
+	var content string
+	if call.Error != "" {
+		content = fmt.Sprintf("Tool %s failed: %s", call.Tool, call.Error)
+	} else {
+		result, _ := json.Marshal(call.Result)
+		if len(result) > maxToolResultLen {
+			result = append(result[:maxToolResultLen], "…(truncated)"...)
+		}
+		content = fmt.Sprintf("Result of %s: %s", call.Tool, result)
+	}
+	if last {
+		content += "\nNo more tools can be called; answer the question now."
+	}
+	return content
+}
+
+// answerWithTools runs the tool calls the model answers with, sending each
+// result back to the provider that answered, until it gives a real answer.
+// It returns that answer, the usage of every call and the tools called.
+func (s *LLMService) answerWithTools(ctx context.Context, provider LLMProvider, feedID string, messages []ChatMessage, opts ChatOptions, answer string, usage TokenCount) (string, TokenCount, []ToolCall, error) {
+	var calls []ToolCall
+	for {
+		call, ok := parseToolCall(answer)
+		if !ok {
+			return answer, usage, calls, nil
+		}
+		if len(calls) == s.cfg.LLMToolMaxCalls {
+			return "", usage, calls, ErrTooManyToolCalls
+		}
+		call = s.runTool(ctx, feedID, call)
+		calls = append(calls, call)
+		messages = append(messages,
+			ChatMessage{Role: "assistant", Content: answer},
+			ChatMessage{Role: "user", Content: toolResultMessage(call, len(calls) == s.cfg.LLMToolMaxCalls)},
+		)
+
+		next, nextUsage, _, _, err := s.chatWithFailover(ctx, []LLMProvider{provider}, messages, opts)
+		if err != nil {
+			return "", usage, calls, err
+		}
+		answer = next
+		usage.Prompt += nextUsage.Prompt
+		usage.Completion += nextUsage.Completion
+	}
+}
+
+// checkToolFeed refuses a feedId argument naming a feed other than the one
+// queried, so tools never read data the question was not about
+func checkToolFeed(feedID string, args map[string]interface{}) error {
+	if requested, ok := args["feedId"].(string); ok && requested != "" && requested != feedID {
+		return fmt.Errorf("only feed %s can be read", feedID)
+	}
+	return nil
+}
+
+// getRecentEvents returns the feed's latest messages from its context, or
+// from its history when the context holds fewer than asked for
+func getRecentEvents(ctx context.Context, s *LLMService, feedID string, args map[string]interface{}) (interface{}, error) {
+	if err := checkToolFeed(feedID, args); err != nil {
+		return nil, err
+	}
+	n := defaultToolEvents
+	if raw, ok := args["n"]; ok {
+		v, ok := toolNumber(raw)
+		if !ok || v < 1 || v != math.Trunc(v) {
+			return nil, fmt.Errorf("n must be a positive integer")
+		}
+		n = int(math.Min(v, maxToolEvents))
+	}
+
+	var entries []map[string]interface{}
+	if fc := s.GetFeedContext(feedID); fc != nil {
+		entries = fc.Entries
+	}
+	if len(entries) < n && s.history != nil {
+		records, err := s.history.Query(ctx, feedID, time.Time{}, time.Time{}, int64(n))
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > len(entries) {
+			events := make([]interface{}, 0, len(records))
+			for _, r := range records {
+				events = append(events, map[string]interface{}{"timestamp": r.Timestamp.UTC().Format(time.RFC3339), "data": r.Data})
+			}
+			return map[string]interface{}{"events": events, "source": "history"}, nil
+		}
+	}
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return map[string]interface{}{"events": entries, "source": "context"}, nil
+}
+
+// toolSample is a numeric field value and when it was seen
+type toolSample struct {
+	at    time.Time
+	value float64
+}
+
+// aggregateField computes a statistic of one field over a time window,
+// reading the feed's history when the window reaches past its context
+func aggregateField(ctx context.Context, s *LLMService, feedID string, args map[string]interface{}) (interface{}, error) {
+	if err := checkToolFeed(feedID, args); err != nil {
+		return nil, err
+	}
+	field, _ := args["field"].(string)
+	if field == "" {
+		return nil, fmt.Errorf("field is required")
+	}
+	op, _ := args["op"].(string)
+	window, _ := args["window"].(string)
+	var since time.Time
+	if window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("window must be a positive duration such as 5m")
+		}
+		since = time.Now().Add(-d)
+	}
+
+	samples, source, err := s.toolSamples(ctx, feedID, field, since)
+	if err != nil {
+		return nil, err
+	}
+	value, err := aggregateSamples(samples, op)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"field":   field,
+		"op":      op,
+		"value":   value,
+		"samples": len(samples),
+		"source":  source,
+	}
+	if window != "" {
+		result["window"] = window
+	}
+	if len(samples) > 0 {
+		result["from"] = samples[len(samples)-1].at.UTC().Format(time.RFC3339)
+		result["to"] = samples[0].at.UTC().Format(time.RFC3339)
+	}
+	return result, nil
+}
+
+// toolSamples returns a field's numeric values since a time, newest first.
+// The context is used unless the window starts before its oldest entry and
+// there is a history to read instead.
+func (s *LLMService) toolSamples(ctx context.Context, feedID, field string, since time.Time) ([]toolSample, string, error) {
+	var entries []map[string]interface{}
+	if fc := s.GetFeedContext(feedID); fc != nil {
+		entries = fc.Entries
+	}
+	reachesPast := len(entries) == 0 || (!since.IsZero() && !entryTooOld(entries[len(entries)-1], since))
+	if reachesPast && !since.IsZero() && s.history != nil {
+		records, err := s.history.Query(ctx, feedID, since, time.Time{}, maxToolHistoryRecords)
+		if err != nil {
+			return nil, "", err
+		}
+		var samples []toolSample
+		for _, r := range records {
+			if v, ok := toolNumber(toolField(r.Data, field)); ok {
+				samples = append(samples, toolSample{at: r.Timestamp, value: v})
+			}
+		}
+		return samples, "history", nil
+	}
+
+	var samples []toolSample
+	for _, entry := range entries {
+		ts, _ := entry["_timestamp"].(string)
+		at, err := time.Parse(time.RFC3339, ts)
+		if err != nil || (!since.IsZero() && at.Before(since)) {
+			continue
+		}
+		if v, ok := toolNumber(toolField(entry, field)); ok {
+			samples = append(samples, toolSample{at: at, value: v})
+		}
+	}
+	return samples, "context", nil
+}
+
+// aggregateSamples applies op to samples ordered newest first
+func aggregateSamples(samples []toolSample, op string) (float64, error) {
+	if op == "count" {
+		return float64(len(samples)), nil
+	}
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("no numeric values in the window")
+	}
+	switch op {
+	case "sum", "avg":
+		sum := 0.0
+		for _, s := range samples {
+			sum += s.value
+		}
+		if op == "avg" {
+			return sum / float64(len(samples)), nil
+		}
+		return sum, nil
+	case "min", "max":
+		v := samples[0].value
+		for _, s := range samples[1:] {
+			if (op == "min" && s.value < v) || (op == "max" && s.value > v) {
+				v = s.value
+			}
+		}
+		return v, nil
+	case "first":
+		return samples[len(samples)-1].value, nil
+	case "last":
+		return samples[0].value, nil
+	case "change":
+		return samples[0].value - samples[len(samples)-1].value, nil
+	}
+	return 0, fmt.Errorf("unknown op %q; use count, sum, avg, min, max, first, last or change", op)
+}
+
+// toolField reads a dotted field path from a decoded message
+func toolField(data interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		data = obj[key]
+	}
+	return data
+}
+
+// toolNumber reads a number, accepting numeric strings as feeds often send
+// prices that way
+func toolNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolCallingProvider gives its answers in turn, repeating the last, and keeps
+// the messages of every call
+type toolCallingProvider struct {
+	cannedProvider
+	answers []string
+	calls   [][]ChatMessage
+}
+
+func (p *toolCallingProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
+	p.calls = append(p.calls, messages)
+	answer := p.answers[min(len(p.calls), len(p.answers))-1]
+	return answer, TokenCount{Prompt: 10, Completion: 5}, nil
+}
+
+func newToolLLMService(t *testing.T, provider *toolCallingProvider) *LLMService {
+	t.Helper()
+	svc := newStructuredLLMService(t, &provider.cannedProvider)
+	svc.providers[provider.name] = provider
+	svc.cfg.LLMToolMaxCalls = 2
+	svc.ClearFeedContext("feed-1")
+	for _, price := range []interface{}{1.0, "2", 3.0} {
+		svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"quote": map[string]interface{}{"price": price}})
+	}
+	return svc
+}
+
+func TestParseToolCall(t *testing.T) {
+	call, ok := parseToolCall("```json\n{\"tool\":\"get_recent_events\",\"arguments\":{\"n\":3}}\n```")
+	require.True(t, ok)
+	assert.Equal(t, "get_recent_events", call.Tool)
+	assert.Equal(t, 3.0, call.Arguments["n"])
+
+	_, ok = parseToolCall(`{"tool":"delete_feed","arguments":{}}`)
+	assert.False(t, ok)
+	_, ok = parseToolCall("The price is rising.")
+	assert.False(t, ok)
+}
+
+func TestAggregateSamples(t *testing.T) {
+	now := time.Now()
+	// Newest first, as the context holds them
+	samples := []toolSample{{now, 4}, {now.Add(-time.Second), 1}, {now.Add(-2 * time.Second), 7}}
+
+	tests := map[string]float64{"count": 3, "sum": 12, "avg": 4, "min": 1, "max": 7, "first": 7, "last": 4, "change": -3}
+	for op, want := range tests {
+		got, err := aggregateSamples(samples, op)
+		require.NoError(t, err, op)
+		assert.Equal(t, want, got, op)
+	}
+
+	_, err := aggregateSamples(samples, "median")
+	assert.Error(t, err)
+	_, err = aggregateSamples(nil, "avg")
+	assert.Error(t, err)
+	count, err := aggregateSamples(nil, "count")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestGetRecentEvents(t *testing.T) {
+	svc := newToolLLMService(t, &toolCallingProvider{cannedProvider: cannedProvider{name: "anthropic"}})
+
+	result, err := getRecentEvents(context.Background(), svc, "feed-1", map[string]interface{}{"n": 2.0})
+	require.NoError(t, err)
+	events := result.(map[string]interface{})["events"].([]map[string]interface{})
+	require.Len(t, events, 2)
+	assert.Equal(t, 3.0, toolField(events[0], "quote.price"))
+
+	_, err = getRecentEvents(context.Background(), svc, "feed-1", map[string]interface{}{"feedId": "feed-2"})
+	assert.Error(t, err)
+	_, err = getRecentEvents(context.Background(), svc, "feed-1", map[string]interface{}{"n": 1.5})
+	assert.Error(t, err)
+}
+
+func TestLLMService_QueryWithTools(t *testing.T) {
+	provider := &toolCallingProvider{
+		cannedProvider: cannedProvider{name: "anthropic"},
+		answers: []string{
+			`{"tool":"aggregate","arguments":{"field":"quote.price","op":"avg","window":"1h"}}`,
+			"The average price is 2.",
+		},
+	}
+	svc := newToolLLMService(t, provider)
+
+	resp, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Average price?", Tools: true})
+	require.NoError(t, err)
+	assert.Equal(t, "The average price is 2.", resp.Answer)
+	assert.Equal(t, 20, resp.PromptTokens)
+	assert.Equal(t, 10, resp.CompletionTokens)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Empty(t, resp.ToolCalls[0].Error)
+	assert.Equal(t, 2.0, resp.ToolCalls[0].Result.(map[string]interface{})["value"])
+
+	require.Len(t, provider.calls, 2)
+	assert.Contains(t, provider.calls[0][0].Content, "get_recent_events")
+	followUp := provider.calls[1]
+	assert.Equal(t, "assistant", followUp[len(followUp)-2].Role)
+	assert.Contains(t, followUp[len(followUp)-1].Content, `Result of aggregate: {"field":"quote.price"`)
+}
+
+func TestLLMService_QueryWithTools_Limits(t *testing.T) {
+	provider := &toolCallingProvider{
+		cannedProvider: cannedProvider{name: "anthropic"},
+		answers:        []string{`{"tool":"get_recent_events","arguments":{}}`},
+	}
+	svc := newToolLLMService(t, provider)
+
+	_, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest?", Tools: true})
+	assert.ErrorIs(t, err, ErrTooManyToolCalls)
+	require.Len(t, provider.calls, 3)
+	last := provider.calls[2]
+	assert.Contains(t, last[len(last)-1].Content, "No more tools can be called")
+
+	_, err = svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest?", Tools: true}, make(chan string, 1))
+	assert.ErrorIs(t, err, ErrLLMToolsStreaming)
+
+	svc.cfg.LLMToolMaxCalls = 0
+	_, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest?", Tools: true})
+	assert.ErrorIs(t, err, ErrLLMToolsDisabled)
+}
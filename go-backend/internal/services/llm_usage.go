@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// UsageGroupFeed, UsageGroupDay and UsageGroupProvider are the ways the
+	// usage ledger can be broken down
+	UsageGroupFeed     = "feed"
+	UsageGroupDay      = "day"
+	UsageGroupProvider = "provider"
+
+	// defaultUsageWindow is how far back a usage summary looks without a start
+	defaultUsageWindow = 30 * 24 * time.Hour
+)
+
+// providerPrice is a provider's list price in USD per million tokens
+type providerPrice struct {
+	prompt, completion float64
+}
+
+// providerPrices are rough list prices of each provider's default model,
+// used only to estimate what a query cost
+var providerPrices = map[string]providerPrice{
+	"openai":       {prompt: 2.5, completion: 10},
+	"azure-openai": {prompt: 2.5, completion: 10},
+	"anthropic":    {prompt: 3, completion: 15},
+	"gemini":       {prompt: 1.25, completion: 5},
+	"mistral":      {prompt: 2, completion: 6},
+	"grok":         {prompt: 5, completion: 15},
+	"ollama":       {},
+}
+
+// EstimateLLMCost estimates the USD cost of a call from the provider's list
+// prices; providers without a price cost nothing
+func EstimateLLMCost(provider string, usage TokenCount) float64 {
+	price := providerPrices[provider]
+	return (float64(usage.Prompt)*price.prompt + float64(usage.Completion)*price.completion) / 1e6
+}
+
+// LLMUsageSummary is a user's usage ledger over a time range, broken down by GroupBy
+type LLMUsageSummary struct {
+	GroupBy string                 `json:"groupBy"`
+	From    time.Time              `json:"from"`
+	To      time.Time              `json:"to"`
+	Totals  models.LLMUsageGroup   `json:"totals"`
+	Groups  []models.LLMUsageGroup `json:"groups"`
+}
+
+// LLMUsageService keeps the ledger of answered LLM queries
+type LLMUsageService struct {
+	usage UsageRepo
+}
+
+// NewLLMUsageService creates the usage ledger service
+func NewLLMUsageService(db *mongo.Database) *LLMUsageService {
+	return &LLMUsageService{usage: NewMongoUsageRepo(db)}
+}
+
+// SetStore moves the ledger to the store's usage repository
+func (s *LLMUsageService) SetStore(store Store) {
+	s.usage = store.Usage
+}
+
+// EnsureIndexes creates the index used to summarise a user's usage over
+// time; SQL stores create theirs with the schema
+func (s *LLMUsageService) EnsureIndexes(ctx context.Context) error {
+	if r, ok := s.usage.(mongoUsageRepo); ok {
+		return r.ensureIndexes(ctx)
+	}
+	return nil
+}
+
+// Record adds an answered query to the ledger, estimating its cost
+func (s *LLMUsageService) Record(ctx context.Context, entry models.LLMUsage) error {
+	entry.CostUSD = EstimateLLMCost(entry.Provider, TokenCount{Prompt: entry.PromptTokens, Completion: entry.CompletionTokens})
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	return s.usage.Insert(ctx, &entry)
+}
+
+// usageWindow fills in a summary's time range: the last 30 days up to now
+// when either end is left out
+func usageWindow(from, to, now time.Time) (time.Time, time.Time) {
+	if to.IsZero() {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultUsageWindow)
+	}
+	return from.UTC(), to.UTC()
+}
+
+// sumUsageGroups totals the groups of a summary
+func sumUsageGroups(groups []models.LLMUsageGroup) models.LLMUsageGroup {
+	var total models.LLMUsageGroup
+	var latency float64
+	for _, g := range groups {
+		total.Requests += g.Requests
+		total.CachedRequests += g.CachedRequests
+		total.PromptTokens += g.PromptTokens
+		total.CompletionTokens += g.CompletionTokens
+		total.TotalTokens += g.TotalTokens
+		total.CostUSD += g.CostUSD
+		latency += g.AvgLatencyMs * float64(g.Requests)
+	}
+	if total.Requests > 0 {
+		total.AvgLatencyMs = latency / float64(total.Requests)
+	}
+	return total
+}
+
+// groupUsage totals ledger entries as UsageRepo.Summarize does, for stores
+// that cannot group them in the database
+func groupUsage(entries []models.LLMUsage, groupBy string) ([]models.LLMUsageGroup, error) {
+	key := func(e models.LLMUsage) string { return e.FeedID }
+	switch groupBy {
+	case UsageGroupFeed:
+	case UsageGroupProvider:
+		key = func(e models.LLMUsage) string { return e.Provider }
+	case UsageGroupDay:
+		key = func(e models.LLMUsage) string { return e.CreatedAt.UTC().Format("2006-01-02") }
+	default:
+		return nil, ErrInvalidUsageGroup
+	}
+	// Newest first, so each group takes the feed name of its latest entry
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	groups := []models.LLMUsageGroup{}
+	index := map[string]int{}
+	latency := map[string]int64{}
+	for _, e := range entries {
+		k := key(e)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, models.LLMUsageGroup{Key: k, FeedName: e.FeedName})
+		}
+		g := &groups[i]
+		g.Requests++
+		if e.Cached {
+			g.CachedRequests++
+		}
+		g.PromptTokens += e.PromptTokens
+		g.CompletionTokens += e.CompletionTokens
+		g.TotalTokens += e.PromptTokens + e.CompletionTokens
+		g.CostUSD += e.CostUSD
+		latency[k] += e.LatencyMs
+	}
+	for i := range groups {
+		groups[i].AvgLatencyMs = float64(latency[groups[i].Key]) / float64(groups[i].Requests)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groupBy != UsageGroupDay && groups[i].TotalTokens != groups[j].TotalTokens {
+			return groups[i].TotalTokens > groups[j].TotalTokens
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+// Summary breaks a user's usage between from and to down by feed, day or
+// provider. Days are listed in order; feeds and providers by tokens used.
+func (s *LLMUsageService) Summary(ctx context.Context, userID, groupBy string, from, to time.Time) (*LLMUsageSummary, error) {
+	if groupBy == "" {
+		groupBy = UsageGroupFeed
+	}
+	from, to = usageWindow(from, to, time.Now())
+	groups, err := s.usage.Summarize(ctx, userID, groupBy, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if groupBy != UsageGroupFeed {
+		for i := range groups {
+			groups[i].FeedName = ""
+		}
+	}
+	return &LLMUsageSummary{GroupBy: groupBy, From: from, To: to, Totals: sumUsageGroups(groups), Groups: groups}, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestEstimateLLMCost(t *testing.T) {
+	usage := TokenCount{Prompt: 1_000_000, Completion: 500_000}
+	assert.InDelta(t, 2.5+5, EstimateLLMCost("openai", usage), 1e-9)
+	assert.InDelta(t, 3+7.5, EstimateLLMCost("anthropic", usage), 1e-9)
+	assert.Zero(t, EstimateLLMCost("ollama", usage))
+	assert.Zero(t, EstimateLLMCost("unknown", usage))
+}
+
+func TestUsageGroupKey(t *testing.T) {
+	key, err := usageGroupKey(UsageGroupFeed)
+	require.NoError(t, err)
+	assert.Equal(t, "$feedId", key)
+
+	key, err = usageGroupKey(UsageGroupProvider)
+	require.NoError(t, err)
+	assert.Equal(t, "$provider", key)
+
+	_, err = usageGroupKey(UsageGroupDay)
+	require.NoError(t, err)
+
+	_, err = usageGroupKey("model")
+	assert.ErrorIs(t, err, ErrInvalidUsageGroup)
+}
+
+func TestUsageWindow(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	from, to := usageWindow(time.Time{}, time.Time{}, now)
+	assert.Equal(t, now, to)
+	assert.Equal(t, now.Add(-defaultUsageWindow), from)
+
+	start := now.Add(-time.Hour)
+	from, to = usageWindow(start, time.Time{}, now)
+	assert.Equal(t, start, from)
+	assert.Equal(t, now, to)
+}
+
+func TestSumUsageGroups(t *testing.T) {
+	total := sumUsageGroups([]models.LLMUsageGroup{
+		{Key: "a", Requests: 3, CachedRequests: 1, PromptTokens: 300, CompletionTokens: 30, TotalTokens: 330, CostUSD: 0.5, AvgLatencyMs: 100},
+		{Key: "b", Requests: 1, PromptTokens: 100, CompletionTokens: 10, TotalTokens: 110, CostUSD: 0.25, AvgLatencyMs: 500},
+	})
+	assert.Equal(t, 4, total.Requests)
+	assert.Equal(t, 1, total.CachedRequests)
+	assert.Equal(t, 440, total.TotalTokens)
+	assert.InDelta(t, 0.75, total.CostUSD, 1e-9)
+	assert.InDelta(t, 200, total.AvgLatencyMs, 1e-9)
+
+	assert.Zero(t, sumUsageGroups(nil).AvgLatencyMs)
+}
@@ -3,35 +3,60 @@ package services
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 )
 
+const (
+	// FeedRestoreWindow is how long a deleted feed can be restored before it
+	// is purged
+	FeedRestoreWindow = 30 * 24 * time.Hour
+	// FeedPurgeInterval is how often deleted feeds are checked for purging
+	FeedPurgeInterval = time.Hour
+)
+
 // MarketplaceService handles feed marketplace operations and subscriptions
 type MarketplaceService struct {
-	db *mongo.Database
+	feeds         FeedRepo
+	subscriptions SubscriptionRepo
+	orgs          OrgRoles
+	secrets       *FeedSecrets
 }
 
 // NewMarketplaceService creates a new marketplace service instance
 func NewMarketplaceService(db *mongo.Database) *MarketplaceService {
-	return &MarketplaceService{db: db}
+	return NewMarketplaceServiceWithRepos(NewMongoFeedRepo(db), NewMongoSubscriptionRepo(db))
+}
+
+// NewMarketplaceServiceWithRepos creates a marketplace service on the given stores
+func NewMarketplaceServiceWithRepos(feeds FeedRepo, subscriptions SubscriptionRepo) *MarketplaceService {
+	return &MarketplaceService{feeds: feeds, subscriptions: subscriptions}
+}
+
+// SetOrgRoles lets organization owners and admins manage the organization's
+// feeds; without it only personal owners can
+func (s *MarketplaceService) SetOrgRoles(orgs OrgRoles) {
+	s.orgs = orgs
 }
 
-// feeds returns the MongoDB websocket_feeds collection
-func (s *MarketplaceService) feeds() *mongo.Collection {
-	return s.db.Collection("websocket_feeds")
+// SetFeedSecrets encrypts feed credentials at rest; without it they are
+// stored as sent, though still redacted from API responses
+func (s *MarketplaceService) SetFeedSecrets(secrets *FeedSecrets) {
+	s.secrets = secrets
 }
 
-// subscriptions returns the MongoDB user_subscriptions collection
-func (s *MarketplaceService) subscriptions() *mongo.Collection {
-	return s.db.Collection("user_subscriptions")
+// OpenFeedSecrets returns the feed with its sealed secrets decrypted, for
+// connecting upstream. The result must never be returned to clients.
+func (s *MarketplaceService) OpenFeedSecrets(feed models.WebSocketFeed) (models.WebSocketFeed, error) {
+	err := s.secrets.Open(&feed)
+	return feed, err
 }
 
 // CreateFeed creates a new feed in the marketplace with initial settings
@@ -46,152 +71,442 @@ func (s *MarketplaceService) CreateFeed(ctx context.Context, feed models.WebSock
 	if !feed.ReconnectionEnabled {
 		feed.ReconnectionEnabled = true
 	}
-	res, err := s.feeds().InsertOne(ctx, feed)
-	if err != nil {
+	if err := s.secrets.Seal(&feed); err != nil {
+		return nil, err
+	}
+	if err := s.feeds.Insert(ctx, &feed); err != nil {
 		return nil, err
 	}
-	feed.ID = res.InsertedID.(primitive.ObjectID)
 	return &feed, nil
 }
 
-// UpdateFeed updates a feed's properties and refreshes the updatedAt timestamp
+// CloneFeed copies a public feed, or one the user manages, into a new
+// private and inactive draft owned by the user, named name or after the
+// original. Header values, credential query params, secret connection
+// messages and connection passwords are left empty for the user to fill in;
+// private feeds the user cannot manage are not found.
+func (s *MarketplaceService) CloneFeed(ctx context.Context, id, userID, userName, name string) (*models.WebSocketFeed, error) {
+	src, err := s.GetFeedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !src.IsPublic {
+		ok, err := s.CanManageFeed(ctx, src, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrFeedNotFound
+		}
+	}
+	draft := draftFeed(*src)
+	draft.OwnerID = userID
+	draft.OwnerName = userName
+	draft.Name = strings.TrimSpace(name)
+	if draft.Name == "" {
+		draft.Name = src.Name + " (copy)"
+	}
+	return s.CreateFeed(ctx, draft)
+}
+
+// draftFeed returns the connection and display settings of a feed as a new
+// private, inactive feed, without its owner, state or secrets
+func draftFeed(src models.WebSocketFeed) models.WebSocketFeed {
+	draft := src
+	draft.ID = primitive.NilObjectID
+	draft.IsActive = false
+	draft.PausedAt = nil
+	draft.IsPublic = false
+	draft.IsVerified = false
+	draft.Verification = nil
+	draft.Schema = nil
+	draft.FeedType = ""
+	draft.OrgID = ""
+	draft.LastActiveAt = nil
+	draft.CircuitState = ""
+	draft.CircuitOpenedAt = nil
+	draft.DeletedAt = nil
+	draft.Ingest = nil
+	draft.Headers = nil
+	for _, h := range src.Headers {
+		draft.Headers = append(draft.Headers, models.KeyValue{Key: h.Key})
+	}
+	draft.QueryParams = nil
+	for _, p := range src.QueryParams {
+		if models.SensitiveKey(p.Key) || models.IsSealedSecret(p.Value) {
+			p.Value = ""
+		}
+		draft.QueryParams = append(draft.QueryParams, p)
+	}
+	draft.ConnectionMessages = append([]string(nil), src.ConnectionMessages...)
+	if src.SecretMessages {
+		draft.ConnectionMessages = nil
+		draft.ConnectionMessage = ""
+	}
+	draft.Tags = append([]string(nil), src.Tags...)
+	if src.HTTPConfig != nil {
+		cfg := *src.HTTPConfig
+		cfg.RequestHeaders = nil
+		for key := range src.HTTPConfig.RequestHeaders {
+			if cfg.RequestHeaders == nil {
+				cfg.RequestHeaders = map[string]string{}
+			}
+			cfg.RequestHeaders[key] = ""
+		}
+		draft.HTTPConfig = &cfg
+	}
+	if src.MQTTConfig != nil {
+		cfg := *src.MQTTConfig
+		cfg.ClientID = ""
+		cfg.Password = ""
+		draft.MQTTConfig = &cfg
+	}
+	if src.KafkaConfig != nil {
+		cfg := *src.KafkaConfig
+		cfg.Brokers = append([]string(nil), src.KafkaConfig.Brokers...)
+		cfg.GroupID = ""
+		if cfg.SASL != nil {
+			sasl := *cfg.SASL
+			sasl.Password = ""
+			cfg.SASL = &sasl
+		}
+		draft.KafkaConfig = &cfg
+	}
+	if src.AvroConfig != nil {
+		cfg := *src.AvroConfig
+		cfg.Password = ""
+		draft.AvroConfig = &cfg
+	}
+	return draft
+}
+
+// UpdateFeed updates a feed's properties and refreshes the updatedAt
+// timestamp. Secrets in the update are sealed, and redacted placeholders
+// keep the stored values.
 func (s *MarketplaceService) UpdateFeed(ctx context.Context, id primitive.ObjectID, updates bson.M) (*models.WebSocketFeed, error) {
+	if err := s.sealFeedUpdates(ctx, id, updates); err != nil {
+		return nil, err
+	}
 	updates["updatedAt"] = time.Now()
-	_, err := s.feeds().UpdateByID(ctx, id, bson.M{"$set": updates})
+	if err := s.feeds.Update(ctx, id, updates); err != nil {
+		return nil, err
+	}
+	return s.GetFeedByID(ctx, id.Hex())
+}
+
+// SetFeedPaused pauses or resumes a feed. Paused feeds are inactive, so
+// nothing connects them upstream, and carry the time they were paused.
+func (s *MarketplaceService) SetFeedPaused(ctx context.Context, id primitive.ObjectID, paused bool) (*models.WebSocketFeed, error) {
+	now := time.Now()
+	var err error
+	if paused {
+		err = s.feeds.Update(ctx, id, bson.M{"isActive": false, "pausedAt": now, "updatedAt": now})
+	} else {
+		err = s.feeds.Update(ctx, id, bson.M{"isActive": true, "updatedAt": now}, "pausedAt")
+	}
 	if err != nil {
 		return nil, err
 	}
 	return s.GetFeedByID(ctx, id.Hex())
 }
 
-// DeleteFeed removes a feed from the marketplace and all associated subscriptions
+// DeleteFeed removes a feed from the marketplace. The feed and its
+// subscriptions are kept for FeedRestoreWindow so its managers can restore
+// it, then purged.
 func (s *MarketplaceService) DeleteFeed(ctx context.Context, id primitive.ObjectID) error {
-	// Delete the feed
-	if _, err := s.feeds().DeleteOne(ctx, bson.M{"_id": id}); err != nil {
-		return err
-	}
-	// Delete all subscriptions to this feed
-	_, err := s.subscriptions().DeleteMany(ctx, bson.M{"feedId": id.Hex()})
-	return err
+	now := time.Now()
+	return s.feeds.Update(ctx, id, bson.M{"deletedAt": now, "updatedAt": now})
 }
 
-// GetFeedByID retrieves a single feed by its ID
-func (s *MarketplaceService) GetFeedByID(ctx context.Context, id string) (*models.WebSocketFeed, error) {
+// RestoreFeed brings back a feed the user manages that was deleted less
+// than FeedRestoreWindow ago. Feeds the user cannot see are reported as
+// ErrNotAuthorized, like GetOwnedFeed.
+func (s *MarketplaceService) RestoreFeed(ctx context.Context, id, userID string) (*models.WebSocketFeed, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotAuthorized
+	}
+	feed, err := s.feeds.Get(ctx, oid)
+	if errors.Is(err, ErrFeedNotFound) {
+		return nil, ErrNotAuthorized
+	}
 	if err != nil {
 		return nil, err
 	}
-	var feed models.WebSocketFeed
-	if err := s.feeds().FindOne(ctx, bson.M{"_id": oid}).Decode(&feed); err != nil {
+	ok, err := s.CanManageFeed(ctx, &feed, userID)
+	if err != nil {
 		return nil, err
 	}
-	return &feed, nil
+	if !ok {
+		return nil, ErrNotAuthorized
+	}
+	if feed.DeletedAt == nil {
+		return nil, ErrFeedNotDeleted
+	}
+	if time.Since(*feed.DeletedAt) >= FeedRestoreWindow {
+		return nil, ErrFeedRestoreExpired
+	}
+	if err := s.feeds.Update(ctx, oid, bson.M{"updatedAt": time.Now()}, "deletedAt"); err != nil {
+		return nil, err
+	}
+	return s.GetFeedByID(ctx, id)
 }
 
-// GetPublicFeeds retrieves all public feeds, optionally filtered by category
-func (s *MarketplaceService) GetPublicFeeds(ctx context.Context, category string) ([]models.WebSocketFeed, error) {
-	// Align with existing data that may not have isPublic set; include public feeds and those without the flag.
-	filter := bson.M{
-		"$or": []bson.M{
-			{"isPublic": true},
-			{"isPublic": bson.M{"$exists": false}},
-		},
+// GetDeletedFeeds retrieves the user's deleted feeds that can still be
+// restored, most recently created first
+func (s *MarketplaceService) GetDeletedFeeds(ctx context.Context, userID string) ([]models.WebSocketFeed, error) {
+	return s.feeds.List(ctx, FeedFilter{OwnerID: userID, Deleted: true}, FeedSortRecent, 0, 0)
+}
+
+// PurgeDeletedFeeds permanently removes feeds deleted more than
+// FeedRestoreWindow before now, with their subscriptions, and returns the
+// IDs of the feeds it removed
+func (s *MarketplaceService) PurgeDeletedFeeds(ctx context.Context, now time.Time) ([]string, error) {
+	feeds, err := s.feeds.List(ctx, FeedFilter{Deleted: true, DeletedBefore: now.Add(-FeedRestoreWindow)}, FeedSortRecent, 0, 0)
+	if err != nil {
+		return nil, err
 	}
-	if category != "" {
-		filter["category"] = category
+	purged := []string{}
+	for _, feed := range feeds {
+		if err := s.feeds.Delete(ctx, feed.ID); err != nil {
+			return purged, err
+		}
+		if err := s.subscriptions.DeleteByFeed(ctx, feed.ID.Hex()); err != nil {
+			return purged, err
+		}
+		purged = append(purged, feed.ID.Hex())
 	}
-	cur, err := s.feeds().Find(ctx, filter)
+	return purged, nil
+}
+
+// DeleteOrphanedSubscriptions removes subscriptions to feeds that no longer
+// exist and returns the IDs of those feeds
+func (s *MarketplaceService) DeleteOrphanedSubscriptions(ctx context.Context) ([]string, error) {
+	feedIDs, err := s.subscriptions.FeedIDs(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(ctx)
-	var feeds []models.WebSocketFeed
-	if err := cur.All(ctx, &feeds); err != nil {
-		return nil, err
+	orphaned := []string{}
+	for _, feedID := range feedIDs {
+		if oid, err := primitive.ObjectIDFromHex(feedID); err == nil {
+			_, err := s.feeds.Get(ctx, oid)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, ErrFeedNotFound) {
+				return orphaned, err
+			}
+		}
+		if err := s.subscriptions.DeleteByFeed(ctx, feedID); err != nil {
+			return orphaned, err
+		}
+		orphaned = append(orphaned, feedID)
 	}
-	return feeds, nil
+	return orphaned, nil
 }
 
-// GetPopularFeeds retrieves feeds sorted by subscriber count with a limit
-func (s *MarketplaceService) GetPopularFeeds(ctx context.Context, limit int64) ([]models.WebSocketFeed, error) {
-	opts := options.Find().SetSort(bson.M{"subscriberCount": -1}).SetLimit(limit)
-	cur, err := s.feeds().Find(ctx, bson.M{"isPublic": true}, opts)
+// RunFeedPurge purges deleted feeds past the restore window and orphaned
+// subscriptions every interval until ctx is cancelled
+func (s *MarketplaceService) RunFeedPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.purge(ctx, now)
+		}
+	}
+}
+
+func (s *MarketplaceService) purge(ctx context.Context, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	purged, err := s.PurgeDeletedFeeds(ctx, now)
 	if err != nil {
-		return nil, err
+		slog.Warn("failed to purge deleted feeds", "error", err)
+	}
+	if len(purged) > 0 {
+		slog.Info("purged deleted feeds", "feeds", len(purged))
+	}
+	orphaned, err := s.DeleteOrphanedSubscriptions(ctx)
+	if err != nil {
+		slog.Warn("failed to delete orphaned subscriptions", "error", err)
 	}
-	defer cur.Close(ctx)
-	var feeds []models.WebSocketFeed
-	if err := cur.All(ctx, &feeds); err != nil {
+	if len(orphaned) > 0 {
+		slog.Info("deleted orphaned subscriptions", "feeds", len(orphaned))
+	}
+}
+
+// GetFeedByID retrieves a single feed by its ID; deleted feeds are not found
+func (s *MarketplaceService) GetFeedByID(ctx context.Context, id string) (*models.WebSocketFeed, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrFeedNotFound
+	}
+	feed, err := s.feeds.Get(ctx, oid)
+	if err != nil {
 		return nil, err
 	}
-	return feeds, nil
+	if feed.DeletedAt != nil {
+		return nil, ErrFeedNotFound
+	}
+	return &feed, nil
 }
 
-// GetRecentFeeds retrieves the most recently created feeds with a limit
-func (s *MarketplaceService) GetRecentFeeds(ctx context.Context, limit int64) ([]models.WebSocketFeed, error) {
-	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
-	cur, err := s.feeds().Find(ctx, bson.M{}, opts)
+// GetOwnedFeed retrieves a feed the given user may manage. Missing feeds are reported
+// as ErrNotAuthorized so callers cannot probe for IDs they do not own.
+func (s *MarketplaceService) GetOwnedFeed(ctx context.Context, id, userID string) (*models.WebSocketFeed, error) {
+	feed, err := s.GetFeedByID(ctx, id)
+	if errors.Is(err, ErrFeedNotFound) {
+		return nil, ErrNotAuthorized
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(ctx)
-	var feeds []models.WebSocketFeed
-	if err := cur.All(ctx, &feeds); err != nil {
+	ok, err := s.CanManageFeed(ctx, feed, userID)
+	if err != nil {
 		return nil, err
 	}
-	return feeds, nil
+	if !ok {
+		return nil, ErrNotAuthorized
+	}
+	return feed, nil
 }
 
-// SearchFeeds searches feeds by name, description, or tags with optional category filter
-func (s *MarketplaceService) SearchFeeds(ctx context.Context, q, category string) ([]models.WebSocketFeed, error) {
-	q = strings.TrimSpace(q)
-	if q == "" {
-		return nil, errors.New("query required")
+// CanManageFeed reports whether a user may manage a feed: its owner for
+// personal feeds, or an owner or admin of the organization that owns it
+func (s *MarketplaceService) CanManageFeed(ctx context.Context, feed *models.WebSocketFeed, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
 	}
-	filter := bson.M{
-		"$or": []bson.M{
-			{"name": bson.M{"$regex": q, "$options": "i"}},
-			{"description": bson.M{"$regex": q, "$options": "i"}},
-			{"tags": bson.M{"$regex": q, "$options": "i"}},
-		},
+	if feed.OrgID == "" {
+		return feed.OwnerID == userID, nil
 	}
-	if category != "" {
-		filter["category"] = category
+	if s.orgs == nil {
+		return false, nil
 	}
-	cur, err := s.feeds().Find(ctx, filter)
+	role, err := s.orgs.Role(ctx, feed.OrgID, userID)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	defer cur.Close(ctx)
-	var feeds []models.WebSocketFeed
-	if err := cur.All(ctx, &feeds); err != nil {
+	return models.OrgRoleManages(role), nil
+}
+
+// TransferFeed moves a personal feed the user manages to an organization
+// where they are an owner or admin; org carries the user's role in it
+func (s *MarketplaceService) TransferFeed(ctx context.Context, id, userID string, org models.Organization) (*models.WebSocketFeed, error) {
+	feed, err := s.GetOwnedFeed(ctx, id, userID)
+	if err != nil {
 		return nil, err
 	}
-	return feeds, nil
+	if feed.OrgID != "" {
+		return nil, ErrFeedOwnedByOrg
+	}
+	if !models.OrgRoleManages(org.Role) {
+		return nil, ErrNotAuthorized
+	}
+	return s.UpdateFeed(ctx, feed.ID, bson.M{"orgId": org.ID.Hex(), "ownerId": "", "ownerName": org.Name})
 }
 
-// GetUserFeeds retrieves all feeds owned by a specific user
-func (s *MarketplaceService) GetUserFeeds(ctx context.Context, userID string) ([]models.WebSocketFeed, error) {
-	filter := bson.M{
-		"$or": []bson.M{
-			{"ownerId": userID},
-			{"ownerId": bson.M{"$exists": false}}, // fallback for legacy data without owner
-		},
+// GetOrgFeeds retrieves every feed an organization owns, newest first
+func (s *MarketplaceService) GetOrgFeeds(ctx context.Context, orgID string) ([]models.WebSocketFeed, error) {
+	return s.feeds.List(ctx, FeedFilter{OrgID: orgID}, FeedSortRecent, 0, 0)
+}
+
+// Sort orders for public feed listings
+const (
+	FeedSortRecent      = "recent"
+	FeedSortSubscribers = "subscribers"
+	FeedSortName        = "name"
+)
+
+// FeedListOptions filters, sorts and pages public feed listings. A zero Limit
+// returns every matching feed.
+type FeedListOptions struct {
+	Category string
+	Verified *bool
+	Sort     string // FeedSortRecent (default), FeedSortSubscribers or FeedSortName
+	Limit    int64
+	Offset   int64
+}
+
+// feedListSort returns the sort document for a listing; _id breaks ties so
+// pages stay stable
+func feedListSort(sort string) (bson.D, error) {
+	switch sort {
+	case "", FeedSortRecent:
+		return bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, nil
+	case FeedSortSubscribers:
+		return bson.D{{Key: "subscriberCount", Value: -1}, {Key: "_id", Value: -1}}, nil
+	case FeedSortName:
+		return bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}, nil
 	}
-	cur, err := s.feeds().Find(ctx, filter)
+	return nil, ErrInvalidSort
+}
+
+// GetPublicFeeds retrieves a page of public feeds and the total number of
+// public feeds matching the filters
+func (s *MarketplaceService) GetPublicFeeds(ctx context.Context, opts FeedListOptions) ([]models.WebSocketFeed, int64, error) {
+	if _, err := feedListSort(opts.Sort); err != nil {
+		return nil, 0, err
+	}
+	filter := FeedFilter{Public: true, Category: opts.Category, Verified: opts.Verified}
+	total, err := s.feeds.Count(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer cur.Close(ctx)
-	var feeds []models.WebSocketFeed
-	if err := cur.All(ctx, &feeds); err != nil {
-		return nil, err
+	feeds, err := s.feeds.List(ctx, filter, opts.Sort, opts.Limit, opts.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return feeds, total, nil
+}
+
+// GetPopularFeeds retrieves feeds sorted by subscriber count with a limit
+func (s *MarketplaceService) GetPopularFeeds(ctx context.Context, limit int64) ([]models.WebSocketFeed, error) {
+	return s.feeds.List(ctx, FeedFilter{Public: true}, FeedSortSubscribers, limit, 0)
+}
+
+// GetRecentFeeds retrieves the most recently created feeds with a limit
+func (s *MarketplaceService) GetRecentFeeds(ctx context.Context, limit int64) ([]models.WebSocketFeed, error) {
+	return s.feeds.List(ctx, FeedFilter{}, FeedSortRecent, limit, 0)
+}
+
+// SearchFeeds searches feeds by name, description, or tags with optional
+// category filter, best matches first. On MongoDB it matches whole words and
+// their stems, case insensitively.
+func (s *MarketplaceService) SearchFeeds(ctx context.Context, q, category string) ([]models.WebSocketFeed, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, ErrQueryRequired
 	}
-	return feeds, nil
+	return s.feeds.Search(ctx, q, category)
+}
+
+// GetAllFeeds retrieves every feed, including private and deactivated ones,
+// newest first and optionally filtered by verification state, for moderation
+func (s *MarketplaceService) GetAllFeeds(ctx context.Context, verified *bool, limit, skip int64) ([]models.WebSocketFeed, error) {
+	return s.feeds.List(ctx, FeedFilter{Verified: verified}, FeedSortRecent, limit, skip)
+}
+
+// GetUserFeeds retrieves all feeds owned by a specific user
+func (s *MarketplaceService) GetUserFeeds(ctx context.Context, userID string) ([]models.WebSocketFeed, error) {
+	// Includes legacy feeds without an owner
+	return s.feeds.List(ctx, FeedFilter{OwnerID: userID, Unowned: true}, FeedSortRecent, 0, 0)
 }
 
 // Subscribe creates or reactivates a user's subscription to a feed with optional custom prompt
 func (s *MarketplaceService) Subscribe(ctx context.Context, userID, feedID string, customPrompt string) (*models.UserSubscription, error) {
+	return s.SubscribeWithTTL(ctx, userID, feedID, customPrompt, 0)
+}
+
+// SubscribeWithTTL subscribes like Subscribe; a positive ttl makes the subscription expire after that duration
+func (s *MarketplaceService) SubscribeWithTTL(ctx context.Context, userID, feedID string, customPrompt string, ttl time.Duration) (*models.UserSubscription, error) {
 	now := time.Now()
 	sub := models.UserSubscription{
 		UserID:       userID,
@@ -199,11 +514,11 @@ func (s *MarketplaceService) Subscribe(ctx context.Context, userID, feedID strin
 		Subscribed:   now,
 		IsActive:     true,
 		CustomPrompt: customPrompt,
+		ExpiresAt:    expiryFrom(now, ttl),
 	}
-	_, err := s.subscriptions().InsertOne(ctx, sub)
-	if mongo.IsDuplicateKeyError(err) {
-		_, err = s.subscriptions().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID}, bson.M{"$set": bson.M{"isActive": true, "customPrompt": customPrompt}})
-		if err != nil {
+	err := s.subscriptions.Insert(ctx, &sub)
+	if errors.Is(err, ErrSubscriptionExists) {
+		if err := s.subscriptions.Reactivate(ctx, userID, feedID, customPrompt, sub.ExpiresAt); err != nil {
 			return nil, err
 		}
 		return &sub, nil
@@ -217,31 +532,101 @@ func (s *MarketplaceService) Subscribe(ctx context.Context, userID, feedID strin
 
 // Unsubscribe deactivates a user's subscription to a feed and decrements subscriber count
 func (s *MarketplaceService) Unsubscribe(ctx context.Context, userID, feedID string) error {
-	res, err := s.subscriptions().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID}, bson.M{"$set": bson.M{"isActive": false}})
-	if err == nil && res.ModifiedCount > 0 {
+	wasActive, err := s.subscriptions.Deactivate(ctx, userID, feedID)
+	if err == nil && wasActive {
 		_ = s.incrementSubscriber(ctx, feedID, -1)
 	}
 	return err
 }
 
-// GetSubscriptions retrieves all subscriptions (active and inactive) for a user
-func (s *MarketplaceService) GetSubscriptions(ctx context.Context, userID string) ([]models.UserSubscription, error) {
-	cur, err := s.subscriptions().Find(ctx, bson.M{"userId": userID})
+// RenewSubscription resets the expiry of an active subscription to ttl from now.
+// A zero ttl removes the expiry so the subscription no longer lapses.
+func (s *MarketplaceService) RenewSubscription(ctx context.Context, userID, feedID string, ttl time.Duration) (*time.Time, error) {
+	expiresAt := expiryFrom(time.Now(), ttl)
+	if err := s.subscriptions.SetExpiry(ctx, userID, feedID, expiresAt); err != nil {
+		return nil, err
+	}
+	return expiresAt, nil
+}
+
+// ExpireSubscriptions deactivates active subscriptions whose expiry has passed and returns them
+func (s *MarketplaceService) ExpireSubscriptions(ctx context.Context, now time.Time) ([]models.UserSubscription, error) {
+	candidates, err := s.subscriptions.ListExpired(ctx, now)
 	if err != nil {
 		return nil, err
 	}
-	defer cur.Close(ctx)
-	var subs []models.UserSubscription
-	if err := cur.All(ctx, &subs); err != nil {
+
+	var expired []models.UserSubscription
+	for _, sub := range candidates {
+		// Re-check the expiry so a renewal that raced the query is not undone
+		ok, err := s.subscriptions.Expire(ctx, sub.ID, now)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			_ = s.incrementSubscriber(ctx, sub.FeedID, -1)
+			sub.IsActive = false
+			expired = append(expired, sub)
+		}
+	}
+	return expired, nil
+}
+
+// CountActiveSubscriptions returns the number of active subscriptions to a feed
+func (s *MarketplaceService) CountActiveSubscriptions(ctx context.Context, feedID string) (int64, error) {
+	return s.subscriptions.CountActive(ctx, feedID)
+}
+
+// expiryFrom returns now+ttl, or nil when ttl is not positive
+func expiryFrom(now time.Time, ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	t := now.Add(ttl)
+	return &t
+}
+
+// GetSubscriptions retrieves all subscriptions (active and inactive) for a user
+func (s *MarketplaceService) GetSubscriptions(ctx context.Context, userID string) ([]models.UserSubscription, error) {
+	return s.subscriptions.ListByUser(ctx, userID)
+}
+
+// GetSubscription returns the user's active subscription to a feed
+func (s *MarketplaceService) GetSubscription(ctx context.Context, userID, feedID string) (*models.UserSubscription, error) {
+	sub, err := s.subscriptions.Get(ctx, userID, feedID)
+	if err != nil {
 		return nil, err
 	}
-	return subs, nil
+	return &sub, nil
 }
 
 // UpdateSubscriptionSettings modifies subscription properties like custom prompts or preferences
 func (s *MarketplaceService) UpdateSubscriptionSettings(ctx context.Context, userID, feedID string, updates bson.M) error {
-	_, err := s.subscriptions().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID}, bson.M{"$set": updates})
-	return err
+	return s.subscriptions.Update(ctx, userID, feedID, updates)
+}
+
+// SetFeedCircuit records a feed's reconnect circuit-breaker state. An empty
+// state closes the circuit. It leaves updatedAt alone since the feed's
+// configuration did not change.
+func (s *MarketplaceService) SetFeedCircuit(ctx context.Context, feedID, state string, openedAt *time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(feedID)
+	if err != nil {
+		return err
+	}
+	if state == "" {
+		return s.feeds.Update(ctx, oid, nil, "circuitState", "circuitOpenedAt")
+	}
+	return s.feeds.Update(ctx, oid, bson.M{"circuitState": state, "circuitOpenedAt": openedAt})
+}
+
+// SetFeedSchema stores the schema inferred from a feed's messages. Like
+// SetFeedCircuit it leaves updatedAt alone.
+func (s *MarketplaceService) SetFeedSchema(ctx context.Context, feedID string, schema models.FeedSchema) error {
+	oid, err := primitive.ObjectIDFromHex(feedID)
+	if err != nil {
+		return err
+	}
+	return s.feeds.Update(ctx, oid, bson.M{"schema": schema})
 }
 
 // incrementSubscriber updates the subscriber count for a feed by the specified delta
@@ -250,6 +635,5 @@ func (s *MarketplaceService) incrementSubscriber(ctx context.Context, feedID str
 	if err != nil {
 		return err
 	}
-	_, err = s.feeds().UpdateByID(ctx, oid, bson.M{"$inc": bson.M{"subscriberCount": delta}})
-	return err
+	return s.feeds.AddSubscribers(ctx, oid, delta)
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,22 +13,12 @@ import (
 	"github.com/turboline-ai/turbostream/go-backend/internal/models"
 )
 
-func setupMarketplaceService(t *testing.T) (*MarketplaceService, func()) {
-	client, db, cleanup := setupTestDB(t)
-	if client == nil {
-		return nil, func() {}
-	}
-
-	service := NewMarketplaceService(db)
-	return service, cleanup
+func setupMarketplaceService() *MarketplaceService {
+	return NewMarketplaceServiceWithRepos(NewMemoryFeedRepo(), NewMemorySubscriptionRepo())
 }
 
 func TestMarketplaceService_CreateFeed(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -56,11 +47,7 @@ func TestMarketplaceService_CreateFeed(t *testing.T) {
 }
 
 func TestMarketplaceService_GetFeedByID(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -90,11 +77,7 @@ func TestMarketplaceService_GetFeedByID(t *testing.T) {
 }
 
 func TestMarketplaceService_UpdateFeed(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -109,6 +92,9 @@ func TestMarketplaceService_UpdateFeed(t *testing.T) {
 	created, err := service.CreateFeed(ctx, feed)
 	require.NoError(t, err)
 
+	// Timestamps are stored to the millisecond
+	time.Sleep(2 * time.Millisecond)
+
 	// Update feed
 	updates := bson.M{
 		"name":        "Updated Name",
@@ -124,11 +110,7 @@ func TestMarketplaceService_UpdateFeed(t *testing.T) {
 }
 
 func TestMarketplaceService_DeleteFeed(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -152,11 +134,7 @@ func TestMarketplaceService_DeleteFeed(t *testing.T) {
 }
 
 func TestMarketplaceService_GetPublicFeeds(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -190,12 +168,13 @@ func TestMarketplaceService_GetPublicFeeds(t *testing.T) {
 	require.NoError(t, err)
 
 	// Get all public feeds
-	feeds, err := service.GetPublicFeeds(ctx, "")
+	feeds, total, err := service.GetPublicFeeds(ctx, FeedListOptions{})
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(feeds), 2)
+	assert.Equal(t, int64(len(feeds)), total)
 
 	// Get public feeds by category
-	cryptoFeeds, err := service.GetPublicFeeds(ctx, "Crypto")
+	cryptoFeeds, _, err := service.GetPublicFeeds(ctx, FeedListOptions{Category: "Crypto"})
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(cryptoFeeds), 1)
 	for _, feed := range cryptoFeeds {
@@ -204,11 +183,7 @@ func TestMarketplaceService_GetPublicFeeds(t *testing.T) {
 }
 
 func TestMarketplaceService_GetPopularFeeds(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -244,11 +219,7 @@ func TestMarketplaceService_GetPopularFeeds(t *testing.T) {
 }
 
 func TestMarketplaceService_GetRecentFeeds(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -275,11 +246,7 @@ func TestMarketplaceService_GetRecentFeeds(t *testing.T) {
 }
 
 func TestMarketplaceService_SearchFeeds(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -359,11 +326,7 @@ func TestMarketplaceService_SearchFeeds(t *testing.T) {
 }
 
 func TestMarketplaceService_GetUserFeeds(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -422,11 +385,7 @@ func TestMarketplaceService_GetUserFeeds(t *testing.T) {
 }
 
 func TestMarketplaceService_Subscribe(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -468,11 +427,7 @@ func TestMarketplaceService_Subscribe(t *testing.T) {
 }
 
 func TestMarketplaceService_Unsubscribe(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -508,12 +463,63 @@ func TestMarketplaceService_Unsubscribe(t *testing.T) {
 	assert.Equal(t, 0, updated.SubscriberCount)
 }
 
+func TestMarketplaceService_SubscriptionExpiry(t *testing.T) {
+	service := setupMarketplaceService()
+
+	ctx := context.Background()
+
+	created, err := service.CreateFeed(ctx, models.WebSocketFeed{
+		Name:     "Expiry Test Feed",
+		URL:      "wss://example.com/feed",
+		Category: "Test",
+		IsPublic: true,
+	})
+	require.NoError(t, err)
+
+	userID := "user123"
+	feedID := created.ID.Hex()
+
+	sub, err := service.SubscribeWithTTL(ctx, userID, feedID, "", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, sub.ExpiresAt)
+
+	// Not yet expired
+	expired, err := service.ExpireSubscriptions(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// Renewing pushes the expiry out
+	renewed, err := service.RenewSubscription(ctx, userID, feedID, 2*time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, renewed)
+	assert.True(t, renewed.After(*sub.ExpiresAt))
+
+	// Past the expiry the subscription is deactivated
+	expired, err = service.ExpireSubscriptions(ctx, time.Now().Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, feedID, expired[0].FeedID)
+
+	count, err := service.CountActiveSubscriptions(ctx, feedID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	_, err = service.RenewSubscription(ctx, userID, feedID, time.Hour)
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+}
+
+func TestExpiryUpdate(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, bson.M{"$set": bson.M{"isActive": true, "expiresAt": at}}, expiryUpdate(bson.M{"isActive": true}, &at))
+	assert.Equal(t, bson.M{"$set": bson.M{"isActive": true}, "$unset": bson.M{"expiresAt": ""}}, expiryUpdate(bson.M{"isActive": true}, nil))
+	assert.Equal(t, bson.M{"$unset": bson.M{"expiresAt": ""}}, expiryUpdate(bson.M{}, nil))
+	assert.Nil(t, expiryFrom(at, 0))
+	assert.Equal(t, at.Add(time.Minute), *expiryFrom(at, time.Minute))
+}
+
 func TestMarketplaceService_GetSubscriptions(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -546,11 +552,7 @@ func TestMarketplaceService_GetSubscriptions(t *testing.T) {
 }
 
 func TestMarketplaceService_UpdateSubscriptionSettings(t *testing.T) {
-	service, cleanup := setupMarketplaceService(t)
-	if service == nil {
-		t.Skip("Skipping test: MongoDB not available")
-	}
-	defer cleanup()
+	service := setupMarketplaceService()
 
 	ctx := context.Background()
 
@@ -593,3 +595,252 @@ func TestMarketplaceService_UpdateSubscriptionSettings(t *testing.T) {
 	}
 	assert.True(t, found, "subscription should be found")
 }
+
+func TestFeedListSort(t *testing.T) {
+	tests := []struct {
+		sort     string
+		firstKey string
+		wantErr  bool
+	}{
+		{"", "createdAt", false},
+		{FeedSortRecent, "createdAt", false},
+		{FeedSortSubscribers, "subscriberCount", false},
+		{FeedSortName, "name", false},
+		{"price", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			sort, err := feedListSort(tt.sort)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSort)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, sort, 2)
+			assert.Equal(t, tt.firstKey, sort[0].Key)
+			assert.Equal(t, "_id", sort[1].Key, "ties are broken by _id")
+		})
+	}
+}
+
+func TestMarketplaceService_GetPublicFeedsPaging(t *testing.T) {
+	service := setupMarketplaceService()
+
+	ctx := context.Background()
+	for _, name := range []string{"Charlie", "Alpha", "Bravo"} {
+		_, err := service.CreateFeed(ctx, models.WebSocketFeed{Name: name, URL: "wss://example.com/" + name, IsPublic: true})
+		require.NoError(t, err)
+	}
+
+	page, total, err := service.GetPublicFeeds(ctx, FeedListOptions{Sort: FeedSortName, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, page, 2)
+	assert.Equal(t, "Alpha", page[0].Name)
+	assert.Equal(t, "Bravo", page[1].Name)
+
+	page, total, err = service.GetPublicFeeds(ctx, FeedListOptions{Sort: FeedSortName, Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, page, 1)
+	assert.Equal(t, "Charlie", page[0].Name)
+
+	_, _, err = service.GetPublicFeeds(ctx, FeedListOptions{Sort: "price"})
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
+
+// stubOrgRoles gives users fixed roles in one organization
+type stubOrgRoles struct {
+	orgID string
+	roles map[string]string
+}
+
+func (s stubOrgRoles) Role(_ context.Context, orgID, userID string) (string, error) {
+	if orgID != s.orgID {
+		return "", nil
+	}
+	return s.roles[userID], nil
+}
+
+func TestMarketplaceService_OrganizationFeeds(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+	org := models.Organization{ID: primitive.NewObjectID(), Name: "Acme"}
+	service.SetOrgRoles(stubOrgRoles{orgID: org.ID.Hex(), roles: map[string]string{"admin": models.OrgRoleAdmin, "member": models.OrgRoleMember}})
+
+	feed, err := service.CreateFeed(ctx, models.WebSocketFeed{Name: "Team Feed", OwnerID: "admin", OwnerName: "Admin"})
+	require.NoError(t, err)
+
+	// Moving a feed needs its owner acting as an organization owner or admin
+	org.Role = models.OrgRoleMember
+	_, err = service.TransferFeed(ctx, feed.ID.Hex(), "admin", org)
+	assert.ErrorIs(t, err, ErrNotAuthorized)
+	org.Role = models.OrgRoleAdmin
+	_, err = service.TransferFeed(ctx, feed.ID.Hex(), "member", org)
+	assert.ErrorIs(t, err, ErrNotAuthorized)
+
+	moved, err := service.TransferFeed(ctx, feed.ID.Hex(), "admin", org)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID.Hex(), moved.OrgID)
+	assert.Empty(t, moved.OwnerID)
+	assert.Equal(t, "Acme", moved.OwnerName)
+	_, err = service.TransferFeed(ctx, feed.ID.Hex(), "admin", org)
+	assert.ErrorIs(t, err, ErrFeedOwnedByOrg)
+
+	// The organization's owners and admins manage the feed from now on
+	for user, want := range map[string]bool{"admin": true, "member": false, "stranger": false, "": false} {
+		ok, err := service.CanManageFeed(ctx, moved, user)
+		require.NoError(t, err)
+		assert.Equal(t, want, ok, user)
+	}
+
+	feeds, err := service.GetOrgFeeds(ctx, org.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	assert.Equal(t, feed.ID, feeds[0].ID)
+	mine, err := service.GetUserFeeds(ctx, "admin")
+	require.NoError(t, err)
+	assert.Empty(t, mine)
+}
+
+func TestMarketplaceService_RestoreAndPurgeFeeds(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+
+	created, err := service.CreateFeed(ctx, models.WebSocketFeed{Name: "Deleted Feed", OwnerID: "owner", IsPublic: true})
+	require.NoError(t, err)
+	_, err = service.Subscribe(ctx, "reader", created.ID.Hex(), "")
+	require.NoError(t, err)
+	id := created.ID.Hex()
+
+	_, err = service.RestoreFeed(ctx, id, "owner")
+	assert.ErrorIs(t, err, ErrFeedNotDeleted)
+	require.NoError(t, service.DeleteFeed(ctx, created.ID))
+
+	// Deleted feeds drop out of lookups and listings but keep their subscriptions
+	_, err = service.GetFeedByID(ctx, id)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+	feeds, _, err := service.GetPublicFeeds(ctx, FeedListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, feeds)
+	deleted, err := service.GetDeletedFeeds(ctx, "owner")
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	_, err = service.RestoreFeed(ctx, id, "someone-else")
+	assert.ErrorIs(t, err, ErrNotAuthorized)
+	restored, err := service.RestoreFeed(ctx, id, "owner")
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	_, err = service.GetSubscription(ctx, "reader", id)
+	require.NoError(t, err)
+
+	// Past the restore window the feed is purged with its subscriptions
+	require.NoError(t, service.DeleteFeed(ctx, created.ID))
+	purged, err := service.PurgeDeletedFeeds(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, purged, "recently deleted feeds are kept")
+	require.NoError(t, service.feeds.Update(ctx, created.ID, bson.M{"deletedAt": time.Now().Add(-FeedRestoreWindow - time.Hour)}))
+	_, err = service.RestoreFeed(ctx, id, "owner")
+	assert.ErrorIs(t, err, ErrFeedRestoreExpired)
+	purged, err = service.PurgeDeletedFeeds(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{id}, purged)
+	subs, err := service.GetSubscriptions(ctx, "reader")
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+}
+
+func TestMarketplaceService_DeleteOrphanedSubscriptions(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+
+	live, err := service.CreateFeed(ctx, models.WebSocketFeed{Name: "Live", IsPublic: true})
+	require.NoError(t, err)
+	_, err = service.Subscribe(ctx, "reader", live.ID.Hex(), "")
+	require.NoError(t, err)
+	gone := primitive.NewObjectID().Hex()
+	require.NoError(t, service.subscriptions.Insert(ctx, &models.UserSubscription{UserID: "reader", FeedID: gone, IsActive: true}))
+
+	orphaned, err := service.DeleteOrphanedSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{gone}, orphaned)
+	subs, err := service.GetSubscriptions(ctx, "reader")
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, live.ID.Hex(), subs[0].FeedID)
+}
+
+func TestMarketplaceService_CloneFeed(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+
+	src, err := service.CreateFeed(ctx, models.WebSocketFeed{
+		Name:               "BTC Trades",
+		URL:                "wss://stream.example.com/ws",
+		IsPublic:           true,
+		IsActive:           true,
+		IsVerified:         true,
+		OwnerID:            "owner",
+		ConnectionType:     "websocket",
+		QueryParams:        []models.KeyValue{{Key: "symbol", Value: "btcusdt"}},
+		Headers:            []models.KeyValue{{Key: "X-API-Key", Value: "secret"}},
+		ConnectionMessages: []string{`{"subscribe":"btcusdt@trade"}`},
+		HTTPConfig:         &models.HTTPPollingConfig{Method: "GET", RequestHeaders: map[string]string{"Authorization": "Bearer secret"}},
+		KafkaConfig:        &models.KafkaConfig{Topic: "trades", GroupID: "owner-group", SASL: &models.KafkaSASLConfig{Mechanism: "plain", Username: "u", Password: "secret"}},
+		Tags:               []string{"crypto"},
+	})
+	require.NoError(t, err)
+
+	draft, err := service.CloneFeed(ctx, src.ID.Hex(), "cloner", "Cloner", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, src.ID, draft.ID)
+	assert.Equal(t, "BTC Trades (copy)", draft.Name)
+	assert.Equal(t, "cloner", draft.OwnerID)
+	assert.False(t, draft.IsPublic)
+	assert.False(t, draft.IsActive)
+	assert.False(t, draft.IsVerified)
+	assert.Equal(t, src.URL, draft.URL)
+	assert.Equal(t, src.QueryParams, draft.QueryParams)
+	assert.Equal(t, src.ConnectionMessages, draft.ConnectionMessages)
+	assert.Equal(t, []models.KeyValue{{Key: "X-API-Key"}}, draft.Headers)
+	assert.Equal(t, map[string]string{"Authorization": ""}, draft.HTTPConfig.RequestHeaders)
+	assert.Empty(t, draft.KafkaConfig.SASL.Password)
+	assert.Empty(t, draft.KafkaConfig.GroupID)
+	assert.Equal(t, "trades", draft.KafkaConfig.Topic)
+
+	// The original keeps its secrets
+	got, err := service.GetFeedByID(ctx, src.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got.Headers[0].Value)
+	assert.Equal(t, "secret", got.KafkaConfig.SASL.Password)
+
+	// Private feeds can only be cloned by their managers
+	_, err = service.CloneFeed(ctx, draft.ID.Hex(), "someone-else", "", "")
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+	again, err := service.CloneFeed(ctx, draft.ID.Hex(), "cloner", "Cloner", "  ETH Trades ")
+	require.NoError(t, err)
+	assert.Equal(t, "ETH Trades", again.Name)
+}
+
+func TestMarketplaceService_SetFeedPaused(t *testing.T) {
+	service := setupMarketplaceService()
+	ctx := context.Background()
+
+	created, err := service.CreateFeed(ctx, models.WebSocketFeed{Name: "Ticks", URL: "wss://example.com", OwnerID: "owner", IsActive: true})
+	require.NoError(t, err)
+
+	paused, err := service.SetFeedPaused(ctx, created.ID, true)
+	require.NoError(t, err)
+	assert.False(t, paused.IsActive)
+	require.NotNil(t, paused.PausedAt)
+
+	resumed, err := service.SetFeedPaused(ctx, created.ID, false)
+	require.NoError(t, err)
+	assert.True(t, resumed.IsActive)
+	assert.Nil(t, resumed.PausedAt)
+
+	_, err = service.SetFeedPaused(ctx, primitive.NewObjectID(), true)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+}
@@ -36,42 +36,48 @@ func NewMistralClient(apiKey, model string) *MistralClient {
 // Name returns the provider identifier
 func (c *MistralClient) Name() string { return "mistral" }
 
+// Model returns the model queries are sent to
+func (c *MistralClient) Model() string { return c.model }
+
 // Enabled returns true if Mistral is configured
 func (c *MistralClient) Enabled() bool {
 	return c.apiKey != ""
 }
 
 // Chat sends a non-streaming chat completion request
-func (c *MistralClient) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (c *MistralClient) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !c.Enabled() {
-		return "", 0, errors.New("mistral not configured")
+		return "", TokenCount{}, fmt.Errorf("mistral: %w", ErrProviderNotConfigured)
 	}
 
 	// Mistral uses OpenAI-compatible format
 	reqBody := map[string]interface{}{
 		"model":       c.model,
 		"messages":    messages,
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  opts.maxTokens(1024),
+		"temperature": opts.temperature(0.7),
+	}
+	if format := opts.openAIResponseFormat(false); format != nil {
+		reqBody["response_format"] = format
 	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.mistral.ai/v1/chat/completions", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("mistral error %d: %s", resp.StatusCode, string(body))
+		return "", TokenCount{}, fmt.Errorf("mistral error %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -80,56 +86,57 @@ func (c *MistralClient) Chat(ctx context.Context, messages []ChatMessage) (strin
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
-		Usage struct {
-			TotalTokens int `json:"total_tokens"`
-		} `json:"usage"`
+		Usage openAIUsage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	if len(result.Choices) == 0 {
-		return "", 0, errors.New("mistral returned no choices")
+		return "", TokenCount{}, errors.New("mistral returned no choices")
 	}
-	return result.Choices[0].Message.Content, result.Usage.TotalTokens, nil
+	return result.Choices[0].Message.Content, result.Usage.count(), nil
 }
 
 // StreamChat sends a streaming chat completion request
-func (c *MistralClient) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (c *MistralClient) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
 	if !c.Enabled() {
-		return 0, errors.New("mistral not configured")
+		return TokenCount{}, fmt.Errorf("mistral: %w", ErrProviderNotConfigured)
 	}
 
 	reqBody := map[string]interface{}{
 		"model":       c.model,
 		"messages":    messages,
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  opts.maxTokens(1024),
+		"temperature": opts.temperature(0.7),
 		"stream":      true,
 	}
+	if format := opts.openAIResponseFormat(false); format != nil {
+		reqBody["response_format"] = format
+	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.mistral.ai/v1/chat/completions", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("mistral error %d: %s", resp.StatusCode, string(body))
+		return TokenCount{}, fmt.Errorf("mistral error %d: %s", resp.StatusCode, string(body))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
-	var totalContent strings.Builder
+	var usage TokenCount
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -147,20 +154,20 @@ func (c *MistralClient) StreamChat(ctx context.Context, messages []ChatMessage,
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			continue
 		}
 		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-			content := chunk.Choices[0].Delta.Content
-			totalContent.WriteString(content)
-			tokens <- content
+			tokens <- chunk.Choices[0].Delta.Content
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.count()
 		}
 	}
 
-	// Estimate tokens (actual count not available in streaming)
-	estimatedTokens := len(totalContent.String()) / 4
-	return estimatedTokens, scanner.Err()
+	return usage, scanner.Err()
 }
 
 // Ensure MistralClient implements LLMProvider
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// oauthStateTTL is how long a user has to finish signing in at the provider
+	oauthStateTTL = 10 * time.Minute
+	// oauthPendingTTL is how long a user who signed in at the provider has to
+	// give their second factor
+	oauthPendingTTL = 5 * time.Minute
+)
+
+// oauthProfile is the identity an OAuth provider reports for the signed-in user
+type oauthProfile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// oauthProvider signs users in with the OAuth2 authorization code flow
+type oauthProvider struct {
+	name         string
+	clientID     string
+	clientSecret string
+	authURL      string
+	tokenURL     string
+	scopes       []string
+	httpClient   *http.Client
+	// profile looks up the user with the provider's access token
+	profile func(ctx context.Context, p *oauthProvider, accessToken string) (oauthProfile, error)
+}
+
+// oauthProviders returns the providers configured with a client ID and secret
+func oauthProviders(cfg config.Config) map[string]*oauthProvider {
+	httpClient := &http.Client{Timeout: 15 * time.Second}
+	providers := make(map[string]*oauthProvider)
+	if cfg.GoogleOAuthClientID != "" && cfg.GoogleOAuthClientSecret != "" {
+		providers["google"] = &oauthProvider{
+			name:         "google",
+			clientID:     cfg.GoogleOAuthClientID,
+			clientSecret: cfg.GoogleOAuthClientSecret,
+			authURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+			tokenURL:     "https://oauth2.googleapis.com/token",
+			scopes:       []string{"openid", "email", "profile"},
+			httpClient:   httpClient,
+			profile:      googleProfile("https://openidconnect.googleapis.com/v1/userinfo"),
+		}
+	}
+	if cfg.GitHubOAuthClientID != "" && cfg.GitHubOAuthClientSecret != "" {
+		providers["github"] = &oauthProvider{
+			name:         "github",
+			clientID:     cfg.GitHubOAuthClientID,
+			clientSecret: cfg.GitHubOAuthClientSecret,
+			authURL:      "https://github.com/login/oauth/authorize",
+			tokenURL:     "https://github.com/login/oauth/access_token",
+			scopes:       []string{"read:user", "user:email"},
+			httpClient:   httpClient,
+			profile:      githubProfile("https://api.github.com"),
+		}
+	}
+	return providers
+}
+
+// OAuthProviders lists the names of the configured OAuth providers
+func (s *AuthService) OAuthProviders() []string {
+	names := make([]string, 0, len(s.oauth))
+	for _, name := range []string{"google", "github"} {
+		if _, ok := s.oauth[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// OAuthAuthURL returns the provider URL that starts sign-in, and the state
+// the callback must echo back
+func (s *AuthService) OAuthAuthURL(providerName string) (string, string, error) {
+	provider, ok := s.oauth[providerName]
+	if !ok {
+		return "", "", ErrOAuthProviderNotConfigured
+	}
+	state, err := s.signOAuthState(providerName)
+	if err != nil {
+		return "", "", err
+	}
+	q := url.Values{
+		"client_id":     {provider.clientID},
+		"redirect_uri":  {s.oauthCallbackURL(providerName)},
+		"response_type": {"code"},
+		"scope":         {strings.Join(provider.scopes, " ")},
+		"state":         {state},
+	}
+	return provider.authURL + "?" + q.Encode(), state, nil
+}
+
+// OAuthLogin completes sign-in with the code the provider sent to the
+// callback. The provider account is linked to the user with the same
+// verified email, or a new passwordless user is created. Users with 2FA or a
+// security key are not signed in yet: OAuthLogin returns ErrTwoFactorRequired
+// with a pending token that CompleteOAuthLogin exchanges for a session
+// together with the second factor.
+func (s *AuthService) OAuthLogin(ctx context.Context, providerName, code, state, ip, ua string) (AuthTokens, models.User, string, error) {
+	provider, ok := s.oauth[providerName]
+	if !ok {
+		return AuthTokens{}, models.User{}, "", ErrOAuthProviderNotConfigured
+	}
+	if err := s.verifyOAuthState(providerName, state); err != nil {
+		return AuthTokens{}, models.User{}, "", err
+	}
+	if code == "" {
+		return AuthTokens{}, models.User{}, "", ErrInvalidOAuthState
+	}
+
+	accessToken, err := provider.exchange(ctx, code, s.oauthCallbackURL(providerName))
+	if err != nil {
+		return AuthTokens{}, models.User{}, "", err
+	}
+	profile, err := provider.profile(ctx, provider, accessToken)
+	if err != nil {
+		return AuthTokens{}, models.User{}, "", err
+	}
+	if profile.Subject == "" || profile.Email == "" || !profile.EmailVerified {
+		return AuthTokens{}, models.User{}, "", ErrOAuthEmailUnverified
+	}
+
+	user, err := s.linkOAuthUser(ctx, providerName, profile)
+	if err != nil {
+		return AuthTokens{}, models.User{}, "", err
+	}
+
+	if s.secondFactorRequired(ctx, user) {
+		pending, err := s.signOAuthPending(providerName, user)
+		if err != nil {
+			return AuthTokens{}, models.User{}, "", err
+		}
+		return AuthTokens{}, user, pending, ErrTwoFactorRequired
+	}
+	tokens, err := s.startSession(ctx, user, ip, ua)
+	if err != nil {
+		return AuthTokens{}, models.User{}, "", err
+	}
+	return tokens, user, "", nil
+}
+
+// CompleteOAuthLogin signs in a user that OAuthLogin left pending once they
+// give a TOTP or backup code, or an assertion answering a
+// WebAuthnChallengeLogin challenge
+func (s *AuthService) CompleteOAuthLogin(ctx context.Context, pending, code string, assertion *WebAuthnAssertion, ip, ua string) (AuthTokens, models.User, error) {
+	claims, err := s.ParseToken(pending)
+	if err != nil || claims["purpose"] != "oauth-pending" {
+		return AuthTokens{}, models.User{}, ErrInvalidOAuthState
+	}
+	userIDStr, _ := claims["userId"].(string)
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return AuthTokens{}, models.User{}, ErrInvalidOAuthState
+	}
+	user, err := s.users.Get(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return AuthTokens{}, models.User{}, ErrInvalidOAuthState
+	}
+	if err != nil {
+		return AuthTokens{}, models.User{}, err
+	}
+	if s.secondFactorRequired(ctx, user) {
+		if err := s.verifySecondFactor(ctx, user, code, assertion, models.WebAuthnChallengeLogin); err != nil {
+			return AuthTokens{}, user, err
+		}
+	}
+	tokens, err := s.startSession(ctx, user, ip, ua)
+	if err != nil {
+		return AuthTokens{}, models.User{}, err
+	}
+	return tokens, user, nil
+}
+
+// linkOAuthUser finds the user already linked to the provider account, else
+// links the account to the user with the same verified email, else creates a
+// user. An account whose email was never verified may have been registered
+// by someone else, so it is not linked: its password and second factor would
+// keep working next to the provider.
+func (s *AuthService) linkOAuthUser(ctx context.Context, providerName string, profile oauthProfile) (models.User, error) {
+	user, err := s.users.GetByOAuth(ctx, providerName, profile.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return models.User{}, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(profile.Email))
+	account := models.OAuthAccount{Provider: providerName, Subject: profile.Subject, Email: email, LinkedAt: time.Now()}
+	user, err = s.users.LinkOAuth(ctx, email, account)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return models.User{}, err
+	}
+	_, err = s.users.GetByEmail(ctx, email)
+	if err == nil {
+		return models.User{}, ErrOAuthAccountUnverified
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return models.User{}, err
+	}
+
+	name := profile.Name
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+	user = s.newUser(email, "", name)
+	user.OAuthAccounts = []models.OAuthAccount{account}
+	user.EmailVerified = true
+	if err := s.users.Insert(ctx, &user); err != nil {
+		return models.User{}, err
+	}
+	return s.promoteIfAdmin(ctx, user)
+}
+
+// oauthCallbackURL is where the provider sends the user back after sign-in
+func (s *AuthService) oauthCallbackURL(providerName string) string {
+	return s.cfg.OAuthRedirectBaseURL + "/api/auth/oauth/" + providerName + "/callback"
+}
+
+// signOAuthState returns a short-lived signed state naming the provider, so
+// callbacks that did not start here are rejected
+func (s *AuthService) signOAuthState(providerName string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"purpose":  "oauth-state",
+		"provider": providerName,
+		"nonce":    uuid.NewString(),
+		"exp":      now.Add(oauthStateTTL).Unix(),
+		"iat":      now.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.JWTSecret))
+}
+
+// signOAuthPending returns a short-lived signed token naming the user who
+// signed in at the provider but still has to give their second factor
+func (s *AuthService) signOAuthPending(providerName string, user models.User) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"purpose":  "oauth-pending",
+		"provider": providerName,
+		"userId":   user.ID.Hex(),
+		"exp":      now.Add(oauthPendingTTL).Unix(),
+		"iat":      now.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.JWTSecret))
+}
+
+// verifyOAuthState checks that state was issued here for the provider and has not expired
+func (s *AuthService) verifyOAuthState(providerName, state string) error {
+	claims, err := s.ParseToken(state)
+	if err != nil || claims["purpose"] != "oauth-state" || claims["provider"] != providerName {
+		return ErrInvalidOAuthState
+	}
+	return nil
+}
+
+// exchange trades the authorization code for the provider's access token
+func (p *oauthProvider) exchange(ctx context.Context, code, redirectURI string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.doJSON(req, &result); err != nil {
+		return "", err
+	}
+	// GitHub reports a bad code with 200 and an error field
+	if result.Error != "" || result.AccessToken == "" {
+		return "", fmt.Errorf("%s: %w: %s %s", p.name, ErrOAuthExchangeFailed, result.Error, result.ErrorDescription)
+	}
+	return result.AccessToken, nil
+}
+
+// getJSON calls a provider API with the user's access token
+func (p *oauthProvider) getJSON(ctx context.Context, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return p.doJSON(req, out)
+}
+
+func (p *oauthProvider) doJSON(req *http.Request, out interface{}) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w: %v", p.name, ErrOAuthExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s: %w: status %d: %s", p.name, ErrOAuthExchangeFailed, resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// googleProfile reads the OpenID Connect userinfo endpoint
+func googleProfile(userinfoURL string) func(context.Context, *oauthProvider, string) (oauthProfile, error) {
+	return func(ctx context.Context, p *oauthProvider, accessToken string) (oauthProfile, error) {
+		var info struct {
+			Sub           string `json:"sub"`
+			Email         string `json:"email"`
+			EmailVerified bool   `json:"email_verified"`
+			Name          string `json:"name"`
+		}
+		if err := p.getJSON(ctx, userinfoURL, accessToken, &info); err != nil {
+			return oauthProfile{}, err
+		}
+		return oauthProfile{Subject: info.Sub, Email: info.Email, EmailVerified: info.EmailVerified, Name: info.Name}, nil
+	}
+}
+
+// githubProfile reads the GitHub user and its primary verified email, which
+// /user leaves out when the user keeps it private
+func githubProfile(apiURL string) func(context.Context, *oauthProvider, string) (oauthProfile, error) {
+	return func(ctx context.Context, p *oauthProvider, accessToken string) (oauthProfile, error) {
+		var user struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		}
+		if err := p.getJSON(ctx, apiURL+"/user", accessToken, &user); err != nil {
+			return oauthProfile{}, err
+		}
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := p.getJSON(ctx, apiURL+"/user/emails", accessToken, &emails); err != nil {
+			return oauthProfile{}, err
+		}
+
+		profile := oauthProfile{Name: user.Name}
+		if user.ID != 0 {
+			profile.Subject = strconv.FormatInt(user.ID, 10)
+		}
+		if profile.Name == "" {
+			profile.Name = user.Login
+		}
+		for _, e := range emails {
+			if e.Primary {
+				profile.Email, profile.EmailVerified = e.Email, e.Verified
+			}
+		}
+		return profile, nil
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// fakeGitHub serves the GitHub token, user and emails endpoints
+func fakeGitHub(t *testing.T, primaryVerified bool) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "good-code" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "gh-token"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 42, "login": "octocat"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": primaryVerified},
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+func testGitHubProvider(srv *httptest.Server) *oauthProvider {
+	return &oauthProvider{
+		name:         "github",
+		clientID:     "client",
+		clientSecret: "secret",
+		authURL:      srv.URL + "/login/oauth/authorize",
+		tokenURL:     srv.URL + "/login/oauth/access_token",
+		httpClient:   srv.Client(),
+		profile:      githubProfile(srv.URL),
+	}
+}
+
+func TestOAuthProviders(t *testing.T) {
+	service := NewAuthService(config.Config{JWTSecret: "test-secret", GitHubOAuthClientID: "id", GitHubOAuthClientSecret: "secret", GoogleOAuthClientID: "id-only"}, nil, nil)
+	assert.Equal(t, []string{"github"}, service.OAuthProviders(), "providers need both a client ID and secret")
+}
+
+func TestOAuthAuthURLAndState(t *testing.T) {
+	service := NewAuthService(config.Config{
+		JWTSecret:               "test-secret",
+		GoogleOAuthClientID:     "google-client",
+		GoogleOAuthClientSecret: "google-secret",
+		OAuthRedirectBaseURL:    "https://api.example.com",
+	}, nil, nil)
+
+	authURL, state, err := service.OAuthAuthURL("google")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "accounts.google.com", parsed.Host)
+	assert.Equal(t, "google-client", parsed.Query().Get("client_id"))
+	assert.Equal(t, "https://api.example.com/api/auth/oauth/google/callback", parsed.Query().Get("redirect_uri"))
+	assert.Equal(t, state, parsed.Query().Get("state"))
+
+	assert.NoError(t, service.verifyOAuthState("google", state))
+	assert.ErrorIs(t, service.verifyOAuthState("github", state), ErrInvalidOAuthState, "state is bound to its provider")
+	assert.ErrorIs(t, service.verifyOAuthState("google", "forged"), ErrInvalidOAuthState)
+
+	_, _, err = service.OAuthAuthURL("myspace")
+	assert.ErrorIs(t, err, ErrOAuthProviderNotConfigured)
+}
+
+func TestOAuthStateIsNotAnAccessToken(t *testing.T) {
+	service := &AuthService{cfg: config.Config{JWTSecret: "test-secret"}}
+	state, err := service.signOAuthState("google")
+	require.NoError(t, err)
+
+	_, err = service.Authenticate(context.Background(), state)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestOAuthPendingTokenIsNotStateOrAccessToken(t *testing.T) {
+	service := &AuthService{cfg: config.Config{JWTSecret: "test-secret"}}
+	pending, err := service.signOAuthPending("github", models.User{ID: primitive.NewObjectID()})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, service.verifyOAuthState("github", pending), ErrInvalidOAuthState)
+	_, err = service.Authenticate(context.Background(), pending)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// A state cannot stand in for a pending sign-in
+	state, err := service.signOAuthState("github")
+	require.NoError(t, err)
+	_, _, err = service.CompleteOAuthLogin(context.Background(), state, "123456", nil, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+}
+
+func TestOAuthGitHubExchangeAndProfile(t *testing.T) {
+	srv := fakeGitHub(t, true)
+	defer srv.Close()
+	provider := testGitHubProvider(srv)
+	ctx := context.Background()
+
+	token, err := provider.exchange(ctx, "good-code", "https://api.example.com/callback")
+	require.NoError(t, err)
+	assert.Equal(t, "gh-token", token)
+
+	profile, err := provider.profile(ctx, provider, token)
+	require.NoError(t, err)
+	assert.Equal(t, oauthProfile{Subject: "42", Email: "octo@example.com", EmailVerified: true, Name: "octocat"}, profile)
+
+	_, err = provider.exchange(ctx, "bad-code", "https://api.example.com/callback")
+	assert.ErrorIs(t, err, ErrOAuthExchangeFailed)
+}
+
+func TestAuthService_OAuthLogin(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	_, existing, err := service.Register(ctx, "octo@example.com", "password", "Octo")
+	require.NoError(t, err)
+
+	srv := fakeGitHub(t, true)
+	defer srv.Close()
+	service.oauth = map[string]*oauthProvider{"github": testGitHubProvider(srv)}
+
+	state, err := service.signOAuthState("github")
+	require.NoError(t, err)
+	// Whoever registered the address first has not proven they own it
+	_, _, _, err = service.OAuthLogin(ctx, "github", "good-code", state, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrOAuthAccountUnverified)
+	_, err = service.users.GetByOAuth(ctx, "github", "42")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	_, err = service.users.VerifyEmail(ctx, existing.ID, existing.Email)
+	require.NoError(t, err)
+	tokens, user, _, err := service.OAuthLogin(ctx, "github", "good-code", state, "127.0.0.1", "browser")
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID, "linked to the account with the same verified email")
+	require.Len(t, user.OAuthAccounts, 1)
+	_, err = service.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+
+	// Signing in again finds the link instead of adding another
+	_, user, _, err = service.OAuthLogin(ctx, "github", "good-code", state, "127.0.0.1", "browser")
+	require.NoError(t, err)
+	assert.Len(t, user.OAuthAccounts, 1)
+
+	unverified := fakeGitHub(t, false)
+	defer unverified.Close()
+	service.oauth = map[string]*oauthProvider{"github": testGitHubProvider(unverified)}
+	_, _, _, err = service.OAuthLogin(ctx, "github", "good-code", state, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrOAuthEmailUnverified)
+}
+
+func TestAuthService_OAuthLoginTwoFactor(t *testing.T) {
+	service, cleanup := setupAuthService(t)
+	if service == nil {
+		t.Skip("Skipping test: MongoDB not available")
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	srv := fakeGitHub(t, true)
+	defer srv.Close()
+	service.oauth = map[string]*oauthProvider{"github": testGitHubProvider(srv)}
+
+	state, err := service.signOAuthState("github")
+	require.NoError(t, err)
+	_, user, _, err := service.OAuthLogin(ctx, "github", "good-code", state, "127.0.0.1", "browser")
+	require.NoError(t, err)
+	secret, _, _, err := service.TwoFactorSetup(user.Email)
+	require.NoError(t, err)
+	require.NoError(t, service.users.Update(ctx, user.ID, bson.M{"twoFactorEnabled": true, "twoFactorSecret": secret}))
+
+	// The provider alone does not open a session
+	tokens, _, pending, err := service.OAuthLogin(ctx, "github", "good-code", state, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	assert.Empty(t, tokens.AccessToken)
+	require.NotEmpty(t, pending)
+
+	_, _, err = service.CompleteOAuthLogin(ctx, pending, "", nil, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	_, _, err = service.CompleteOAuthLogin(ctx, pending, "000000", nil, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+	_, _, err = service.CompleteOAuthLogin(ctx, "forged", "000000", nil, "127.0.0.1", "browser")
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	tokens, signedIn, err := service.CompleteOAuthLogin(ctx, pending, code, nil, "127.0.0.1", "browser")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, signedIn.ID)
+	_, err = service.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+}
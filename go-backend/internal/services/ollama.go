@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -38,15 +37,18 @@ func NewOllamaClient(baseURL, model string) *OllamaClient {
 // Name returns the provider identifier
 func (c *OllamaClient) Name() string { return "ollama" }
 
+// Model returns the model queries are sent to
+func (c *OllamaClient) Model() string { return c.model }
+
 // Enabled returns true if Ollama is configured (always true if instantiated, but we check URL)
 func (c *OllamaClient) Enabled() bool {
 	return c.baseURL != ""
 }
 
 // Chat sends a non-streaming chat completion request
-func (c *OllamaClient) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (c *OllamaClient) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !c.Enabled() {
-		return "", 0, errors.New("ollama not configured")
+		return "", TokenCount{}, fmt.Errorf("ollama: %w", ErrProviderNotConfigured)
 	}
 
 	reqBody := map[string]interface{}{
@@ -54,49 +56,56 @@ func (c *OllamaClient) Chat(ctx context.Context, messages []ChatMessage) (string
 		"messages": messages,
 		"stream":   false,
 	}
+	if options := opts.ollamaOptions(); len(options) > 0 {
+		reqBody["options"] = options
+	}
+	if format := opts.ollamaFormat(); format != nil {
+		reqBody["format"] = format
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to marshal request: %w", err)
+		return "", TokenCount{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to create request: %w", err)
+		return "", TokenCount{}, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, fmt.Errorf("ollama request failed: %w", err)
+		return "", TokenCount{}, fmt.Errorf("ollama request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, string(body))
+		return "", TokenCount{}, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
-		EvalCount int `json:"eval_count"` // Token count
+		PromptEvalCount int `json:"prompt_eval_count"` // Prompt tokens
+		EvalCount       int `json:"eval_count"`        // Completion tokens
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", 0, fmt.Errorf("failed to decode response: %w", err)
+		return "", TokenCount{}, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return result.Message.Content, result.EvalCount, nil
+	return result.Message.Content, TokenCount{Prompt: result.PromptEvalCount, Completion: result.EvalCount}, nil
 }
 
 // StreamChat sends a streaming chat completion request
-func (c *OllamaClient) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (c *OllamaClient) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
 	if !c.Enabled() {
-		return 0, errors.New("ollama not configured")
+		return TokenCount{}, fmt.Errorf("ollama: %w", ErrProviderNotConfigured)
 	}
 
 	reqBody := map[string]interface{}{
@@ -104,31 +113,37 @@ func (c *OllamaClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 		"messages": messages,
 		"stream":   true,
 	}
+	if options := opts.ollamaOptions(); len(options) > 0 {
+		reqBody["options"] = options
+	}
+	if format := opts.ollamaFormat(); format != nil {
+		reqBody["format"] = format
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return 0, fmt.Errorf("failed to marshal request: %w", err)
+		return TokenCount{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return TokenCount{}, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("ollama request failed: %w", err)
+		return TokenCount{}, fmt.Errorf("ollama request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, string(body))
+		return TokenCount{}, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
-	totalTokens := 0
+	var usage TokenCount
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -140,8 +155,9 @@ func (c *OllamaClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
-			Done      bool `json:"done"`
-			EvalCount int  `json:"eval_count"`
+			Done            bool `json:"done"`
+			PromptEvalCount int  `json:"prompt_eval_count"`
+			EvalCount       int  `json:"eval_count"`
 		}
 
 		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
@@ -155,13 +171,13 @@ func (c *OllamaClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 		}
 
 		if chunk.Done {
-			totalTokens = chunk.EvalCount
+			usage = TokenCount{Prompt: chunk.PromptEvalCount, Completion: chunk.EvalCount}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return totalTokens, fmt.Errorf("stream reading error: %w", err)
+		return usage, fmt.Errorf("stream reading error: %w", err)
 	}
 
-	return totalTokens, nil
+	return usage, nil
 }
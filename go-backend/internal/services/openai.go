@@ -37,41 +37,47 @@ func NewOpenAIClient(apiKey, model string) *OpenAIClient {
 // Name returns the provider identifier
 func (c *OpenAIClient) Name() string { return "openai" }
 
+// Model returns the model queries are sent to
+func (c *OpenAIClient) Model() string { return c.model }
+
 // Enabled returns true if OpenAI is configured
 func (c *OpenAIClient) Enabled() bool {
 	return c.apiKey != ""
 }
 
 // Chat sends a non-streaming chat completion request
-func (c *OpenAIClient) Chat(ctx context.Context, messages []ChatMessage) (string, int, error) {
+func (c *OpenAIClient) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
 	if !c.Enabled() {
-		return "", 0, errors.New("openai not configured")
+		return "", TokenCount{}, fmt.Errorf("openai: %w", ErrProviderNotConfigured)
 	}
 
 	reqBody := map[string]interface{}{
 		"model":       c.model,
 		"messages":    messages,
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  opts.maxTokens(1024),
+		"temperature": opts.temperature(0.7),
+	}
+	if format := opts.openAIResponseFormat(true); format != nil {
+		reqBody["response_format"] = format
 	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("openai error %d: %s", resp.StatusCode, string(body))
+		return "", TokenCount{}, fmt.Errorf("openai error %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -80,56 +86,59 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []ChatMessage) (string
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
-		Usage struct {
-			TotalTokens int `json:"total_tokens"`
-		} `json:"usage"`
+		Usage openAIUsage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", 0, err
+		return "", TokenCount{}, err
 	}
 	if len(result.Choices) == 0 {
-		return "", 0, errors.New("openai returned no choices")
+		return "", TokenCount{}, errors.New("openai returned no choices")
 	}
-	return result.Choices[0].Message.Content, result.Usage.TotalTokens, nil
+	return result.Choices[0].Message.Content, result.Usage.count(), nil
 }
 
 // StreamChat sends a streaming chat completion request
-func (c *OpenAIClient) StreamChat(ctx context.Context, messages []ChatMessage, tokens chan<- string) (int, error) {
+func (c *OpenAIClient) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
 	defer close(tokens)
 
 	if !c.Enabled() {
-		return 0, errors.New("openai not configured")
+		return TokenCount{}, fmt.Errorf("openai: %w", ErrProviderNotConfigured)
 	}
 
 	reqBody := map[string]interface{}{
 		"model":       c.model,
 		"messages":    messages,
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  opts.maxTokens(1024),
+		"temperature": opts.temperature(0.7),
 		"stream":      true,
+		// Ask for a final chunk with the token usage
+		"stream_options": map[string]bool{"include_usage": true},
+	}
+	if format := opts.openAIResponseFormat(true); format != nil {
+		reqBody["response_format"] = format
 	}
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return TokenCount{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("openai error %d: %s", resp.StatusCode, string(body))
+		return TokenCount{}, fmt.Errorf("openai error %d: %s", resp.StatusCode, string(body))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
-	var totalContent strings.Builder
+	var usage TokenCount
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -147,20 +156,31 @@ func (c *OpenAIClient) StreamChat(ctx context.Context, messages []ChatMessage, t
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			continue
 		}
 		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-			content := chunk.Choices[0].Delta.Content
-			totalContent.WriteString(content)
-			tokens <- content
+			tokens <- chunk.Choices[0].Delta.Content
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.count()
 		}
 	}
 
-	// Estimate tokens (actual count not available in streaming)
-	estimatedTokens := len(totalContent.String()) / 4
-	return estimatedTokens, scanner.Err()
+	return usage, scanner.Err()
+}
+
+// openAIUsage is the usage object of OpenAI-compatible chat completions
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// count converts the usage to a TokenCount
+func (u openAIUsage) count() TokenCount {
+	return TokenCount{Prompt: u.PromptTokens, Completion: u.CompletionTokens}
 }
 
 // Ensure OpenAIClient implements LLMProvider
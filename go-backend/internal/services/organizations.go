@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// maxOrgNameLength caps organization names, in characters
+const maxOrgNameLength = 100
+
+// OrgRoles looks up a user's role in an organization; "" means they are not
+// a member
+type OrgRoles interface {
+	Role(ctx context.Context, orgID, userID string) (string, error)
+}
+
+// OrganizationService manages organizations and their members. Members are
+// added by email, so a role applies to whoever signs up with it.
+type OrganizationService struct {
+	db    *mongo.Database
+	users UserRepo
+}
+
+// NewOrganizationService creates the organization service
+func NewOrganizationService(db *mongo.Database) *OrganizationService {
+	return &OrganizationService{db: db, users: NewMongoUserRepo(db)}
+}
+
+// SetStore looks users up in the store's repository
+func (s *OrganizationService) SetStore(store Store) {
+	s.users = store.Users
+}
+
+// orgs returns the MongoDB organizations collection
+func (s *OrganizationService) orgs() *mongo.Collection {
+	return s.db.Collection("organizations")
+}
+
+// members returns the MongoDB organization_members collection
+func (s *OrganizationService) members() *mongo.Collection {
+	return s.db.Collection("organization_members")
+}
+
+// EnsureIndexes creates the unique membership index and the lookup by email
+func (s *OrganizationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.members().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}},
+	})
+	return err
+}
+
+// Create creates an organization owned by the user
+func (s *OrganizationService) Create(ctx context.Context, name, userID string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrgNameLength {
+		return nil, ErrInvalidOrgName
+	}
+	email, err := s.userEmail(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	org := models.Organization{Name: name, CreatedBy: userID, CreatedAt: now, UpdatedAt: now}
+	res, err := s.orgs().InsertOne(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	org.ID = res.InsertedID.(primitive.ObjectID)
+	owner := models.OrgMember{OrgID: org.ID.Hex(), Email: email, Role: models.OrgRoleOwner, InvitedBy: userID, CreatedAt: now}
+	if _, err := s.members().InsertOne(ctx, owner); err != nil {
+		return nil, err
+	}
+	org.Role = models.OrgRoleOwner
+	return &org, nil
+}
+
+// Get returns an organization with the user's role in it. Organizations the
+// user is not a member of are reported as not found.
+func (s *OrganizationService) Get(ctx context.Context, orgID, userID string) (*models.Organization, error) {
+	role, err := s.Role(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrOrgNotFound
+	}
+	oid, _ := primitive.ObjectIDFromHex(orgID)
+	var org models.Organization
+	err = s.orgs().FindOne(ctx, bson.M{"_id": oid}).Decode(&org)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrgNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	org.Role = role
+	return &org, nil
+}
+
+// ListForUser returns the organizations the user belongs to, by name
+func (s *OrganizationService) ListForUser(ctx context.Context, userID string) ([]models.Organization, error) {
+	email, err := s.userEmail(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := s.members().Find(ctx, bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+	var memberships []models.OrgMember
+	if err := cur.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+	roles := make(map[primitive.ObjectID]string, len(memberships))
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, m := range memberships {
+		oid, err := primitive.ObjectIDFromHex(m.OrgID)
+		if err != nil {
+			continue
+		}
+		roles[oid] = m.Role
+		ids = append(ids, oid)
+	}
+	orgs := []models.Organization{}
+	if len(ids) == 0 {
+		return orgs, nil
+	}
+	cur, err = s.orgs().Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	for i := range orgs {
+		orgs[i].Role = roles[orgs[i].ID]
+	}
+	sort.SliceStable(orgs, func(i, j int) bool { return orgs[i].Name < orgs[j].Name })
+	return orgs, nil
+}
+
+// Role returns the user's role in the organization, or "" when the user is
+// not a member
+func (s *OrganizationService) Role(ctx context.Context, orgID, userID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	email, err := s.userEmail(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var member models.OrgMember
+	err = s.members().FindOne(ctx, bson.M{"orgId": orgID, "email": email}).Decode(&member)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Members returns the organization's members, oldest first
+func (s *OrganizationService) Members(ctx context.Context, orgID string) ([]models.OrgMember, error) {
+	cur, err := s.members().Find(ctx, bson.M{"orgId": orgID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	members := []models.OrgMember{}
+	if err := cur.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember gives the email a role in the organization on behalf of actorID
+func (s *OrganizationService) AddMember(ctx context.Context, orgID, actorID, email, role string) (*models.OrgMember, error) {
+	email, err := normalizeGrantEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if !models.ValidOrgRole(role) {
+		return nil, ErrInvalidOrgRole
+	}
+	actorRole, err := s.Role(ctx, orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actorRole == "" {
+		return nil, ErrOrgNotFound
+	}
+	if !canChangeMember(actorRole, "", role) {
+		return nil, ErrNotAuthorized
+	}
+	member := models.OrgMember{OrgID: orgID, Email: email, Role: role, InvitedBy: actorID, CreatedAt: time.Now()}
+	res, err := s.members().InsertOne(ctx, member)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrOrgMemberExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	member.ID = res.InsertedID.(primitive.ObjectID)
+	return &member, nil
+}
+
+// UpdateMemberRole changes a member's role on behalf of actorID and returns
+// the member as it was before the change
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, orgID, actorID string, memberID primitive.ObjectID, role string) (*models.OrgMember, error) {
+	if !models.ValidOrgRole(role) {
+		return nil, ErrInvalidOrgRole
+	}
+	member, err := s.authorizeMemberChange(ctx, orgID, actorID, memberID, role)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.members().UpdateOne(ctx, bson.M{"_id": memberID}, bson.M{"$set": bson.M{"role": role}}); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember removes a member on behalf of actorID and returns them.
+// Anyone may remove themselves, leaving the organization.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, actorID string, memberID primitive.ObjectID) (*models.OrgMember, error) {
+	member, err := s.authorizeMemberChange(ctx, orgID, actorID, memberID, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.members().DeleteOne(ctx, bson.M{"_id": memberID}); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// authorizeMemberChange loads a member and checks actorID may give them
+// newRole, or remove them when newRole is empty, without leaving the
+// organization without an owner
+func (s *OrganizationService) authorizeMemberChange(ctx context.Context, orgID, actorID string, memberID primitive.ObjectID, newRole string) (*models.OrgMember, error) {
+	actorEmail, err := s.userEmail(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	var actor models.OrgMember
+	err = s.members().FindOne(ctx, bson.M{"orgId": orgID, "email": actorEmail}).Decode(&actor)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrgNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var member models.OrgMember
+	err = s.members().FindOne(ctx, bson.M{"_id": memberID, "orgId": orgID}).Decode(&member)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrgMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	leaving := newRole == "" && member.ID == actor.ID
+	if !leaving && !canChangeMember(actor.Role, member.Role, newRole) {
+		return nil, ErrNotAuthorized
+	}
+	if member.Role == models.OrgRoleOwner && newRole != models.OrgRoleOwner {
+		owners, err := s.members().CountDocuments(ctx, bson.M{"orgId": orgID, "role": models.OrgRoleOwner})
+		if err != nil {
+			return nil, err
+		}
+		if owners <= 1 {
+			return nil, ErrLastOrgOwner
+		}
+	}
+	return &member, nil
+}
+
+// canChangeMember reports whether a member with actorRole may move another
+// member from currentRole to newRole; an empty currentRole adds a member and
+// an empty newRole removes one. Owners and admins manage members, but only
+// owners may add, change or remove owners.
+func canChangeMember(actorRole, currentRole, newRole string) bool {
+	if !models.OrgRoleManages(actorRole) {
+		return false
+	}
+	if currentRole == models.OrgRoleOwner || newRole == models.OrgRoleOwner {
+		return actorRole == models.OrgRoleOwner
+	}
+	return true
+}
+
+// userEmail looks up a user's email by ID
+func (s *OrganizationService) userEmail(ctx context.Context, userID string) (string, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	user, err := s.users.Get(ctx, oid)
+	if err != nil {
+		return "", err
+	}
+	return user.Email, nil
+}
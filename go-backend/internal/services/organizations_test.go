@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestCanChangeMember(t *testing.T) {
+	owner, admin, member := models.OrgRoleOwner, models.OrgRoleAdmin, models.OrgRoleMember
+	cases := []struct {
+		actor, current, next string
+		want                 bool
+	}{
+		{owner, "", owner, true},
+		{owner, owner, admin, true},
+		{owner, admin, "", true},
+		{admin, "", member, true},
+		{admin, member, admin, true},
+		{admin, admin, "", true},
+		{admin, "", owner, false},
+		{admin, owner, member, false},
+		{admin, owner, "", false},
+		{member, "", member, false},
+		{member, member, admin, false},
+		{"", "", member, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, canChangeMember(c.actor, c.current, c.next), "%s: %q -> %q", c.actor, c.current, c.next)
+	}
+}
+
+func TestOrgRoles(t *testing.T) {
+	for _, role := range []string{models.OrgRoleOwner, models.OrgRoleAdmin, models.OrgRoleMember} {
+		assert.True(t, models.ValidOrgRole(role), role)
+	}
+	assert.False(t, models.ValidOrgRole(""))
+	assert.False(t, models.ValidOrgRole("Owner"))
+	assert.True(t, models.OrgRoleManages(models.OrgRoleOwner))
+	assert.True(t, models.OrgRoleManages(models.OrgRoleAdmin))
+	assert.False(t, models.OrgRoleManages(models.OrgRoleMember))
+}
+
+func TestOrganizationService_CreateRejectsBadNames(t *testing.T) {
+	// Names are checked before any lookup
+	s := NewOrganizationService(nil)
+	for _, name := range []string{"", "   ", strings.Repeat("a", maxOrgNameLength+1)} {
+		_, err := s.Create(context.Background(), name, "user")
+		assert.ErrorIs(t, err, ErrInvalidOrgName)
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Repositories hide the storage of users, feeds, subscriptions, sessions and
+// the LLM usage ledger from the services, so services can run on MongoDB, a
+// SQL database or, in tests, in memory. Field names in set and unset maps are
+// the models' bson names; dotted paths reach into embedded documents.
+
+// Store is the set of repositories a storage backend provides
+type Store struct {
+	Users         UserRepo
+	Feeds         FeedRepo
+	Subscriptions SubscriptionRepo
+	Sessions      SessionRepo
+	Usage         UsageRepo
+}
+
+// NewMongoStore keeps every repository in db
+func NewMongoStore(db *mongo.Database) Store {
+	return Store{
+		Users:         NewMongoUserRepo(db),
+		Feeds:         NewMongoFeedRepo(db),
+		Subscriptions: NewMongoSubscriptionRepo(db),
+		Sessions:      NewMongoSessionRepo(db),
+		Usage:         NewMongoUsageRepo(db),
+	}
+}
+
+// NewMemoryStore keeps every repository in memory, for tests
+func NewMemoryStore() Store {
+	return Store{
+		Users:         NewMemoryUserRepo(),
+		Feeds:         NewMemoryFeedRepo(),
+		Subscriptions: NewMemorySubscriptionRepo(),
+		Sessions:      NewMemorySessionRepo(),
+		Usage:         NewMemoryUsageRepo(),
+	}
+}
+
+// UserRepo stores user accounts
+type UserRepo interface {
+	// Insert stores a new user and sets its ID
+	Insert(ctx context.Context, user *models.User) error
+	// Get, GetByEmail and GetByOAuth return ErrUserNotFound when no user matches
+	Get(ctx context.Context, id primitive.ObjectID) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	GetByOAuth(ctx context.Context, provider, subject string) (models.User, error)
+	// List returns users newest first, without credentials, optionally
+	// filtered by a case-insensitive match on email or name
+	List(ctx context.Context, search string, limit, skip int64) ([]models.User, error)
+	// Update sets and unsets fields, returning ErrUserNotFound when the user does not exist
+	Update(ctx context.Context, id primitive.ObjectID, set bson.M, unset ...string) error
+	// AddTokensUsed adds to the user's token usage this period
+	AddTokensUsed(ctx context.Context, id primitive.ObjectID, tokens int) error
+	// LinkOAuth adds a sign-in account to the user with this email if the
+	// email is verified, returning the updated user or ErrUserNotFound
+	LinkOAuth(ctx context.Context, email string, account models.OAuthAccount) (models.User, error)
+	// VerifyEmail marks the user's email verified if it is still email,
+	// returning the updated user or ErrUserNotFound
+	VerifyEmail(ctx context.Context, id primitive.ObjectID, email string) (models.User, error)
+	// PromoteAdmins gives the admin role to the users with these verified
+	// emails and returns how many changed
+	PromoteAdmins(ctx context.Context, emails []string) (int64, error)
+	// Delete removes the user, returning how many records it removed
+	Delete(ctx context.Context, id primitive.ObjectID) (int64, error)
+}
+
+// FeedFilter selects feeds for listing; the zero value matches every feed
+// that has not been deleted
+type FeedFilter struct {
+	// Public matches public feeds, including legacy ones without the flag
+	Public   bool
+	Category string
+	Verified *bool
+	OwnerID  string
+	// Unowned also matches legacy feeds without an owner or organization
+	// when OwnerID is set
+	Unowned bool
+	OrgID   string
+	// Deleted matches soft-deleted feeds instead, only those deleted before
+	// DeletedBefore when it is set
+	Deleted       bool
+	DeletedBefore time.Time
+}
+
+// FeedRepo stores marketplace feeds
+type FeedRepo interface {
+	// Insert stores a new feed and sets its ID
+	Insert(ctx context.Context, feed *models.WebSocketFeed) error
+	// Get returns ErrFeedNotFound when the feed does not exist
+	Get(ctx context.Context, id primitive.ObjectID) (models.WebSocketFeed, error)
+	// Update sets and unsets fields, returning ErrFeedNotFound when the feed does not exist
+	Update(ctx context.Context, id primitive.ObjectID, set bson.M, unset ...string) error
+	AddSubscribers(ctx context.Context, id primitive.ObjectID, delta int) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List returns a page of matching feeds in a FeedSort order; a zero
+	// limit returns them all
+	List(ctx context.Context, filter FeedFilter, sort string, limit, offset int64) ([]models.WebSocketFeed, error)
+	Count(ctx context.Context, filter FeedFilter) (int64, error)
+	// Search returns the feeds whose name, description or tags match the
+	// query, best matches first, leaving out deleted feeds
+	Search(ctx context.Context, query, category string) ([]models.WebSocketFeed, error)
+	// DeleteByOwner removes the owner's feeds, returning how many it removed
+	DeleteByOwner(ctx context.Context, ownerID string) (int64, error)
+}
+
+// SubscriptionRepo stores users' feed subscriptions
+type SubscriptionRepo interface {
+	// Insert stores a new subscription, returning ErrSubscriptionExists when
+	// the store knows the user already has one for the feed
+	Insert(ctx context.Context, sub *models.UserSubscription) error
+	// Get returns the user's active subscription to the feed or ErrSubscriptionNotFound
+	Get(ctx context.Context, userID, feedID string) (models.UserSubscription, error)
+	// ListByUser returns the user's subscriptions, active and inactive
+	ListByUser(ctx context.Context, userID string) ([]models.UserSubscription, error)
+	// Reactivate turns the user's subscription back on with a new prompt and expiry
+	Reactivate(ctx context.Context, userID, feedID, customPrompt string, expiresAt *time.Time) error
+	// Deactivate turns the user's subscription off, reporting whether it was active
+	Deactivate(ctx context.Context, userID, feedID string) (bool, error)
+	// SetExpiry changes an active subscription's expiry, nil for none,
+	// returning ErrSubscriptionNotFound when there is no active subscription
+	SetExpiry(ctx context.Context, userID, feedID string, expiresAt *time.Time) error
+	// Update sets fields of the user's subscription
+	Update(ctx context.Context, userID, feedID string, set bson.M) error
+	// ListExpired returns active subscriptions whose expiry is at or before now
+	ListExpired(ctx context.Context, now time.Time) ([]models.UserSubscription, error)
+	// Expire deactivates the subscription if it is still active and expired
+	// at now, reporting whether it did
+	Expire(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error)
+	CountActive(ctx context.Context, feedID string) (int64, error)
+	// ActiveUserIDs returns the users with an active subscription to the feed
+	ActiveUserIDs(ctx context.Context, feedID string) ([]string, error)
+	DeleteByFeed(ctx context.Context, feedID string) error
+	// FeedIDs returns the IDs of every feed with a subscription, active or not
+	FeedIDs(ctx context.Context) ([]string, error)
+	// DeleteByUser removes the user's subscriptions and every subscription to
+	// feedIDs, returning how many it removed
+	DeleteByUser(ctx context.Context, userID string, feedIDs []string) (int64, error)
+}
+
+// SessionRepo stores users' sign-in sessions
+type SessionRepo interface {
+	// Insert stores a new session and sets its ID
+	Insert(ctx context.Context, session *models.UserSession) error
+	// Rotate moves the active, unexpired session holding the refresh token
+	// hash on to nextHash, keeping hash as the previous one, and returns the
+	// updated session or ErrSessionNotFound
+	Rotate(ctx context.Context, hash, nextHash string, now, expiresAt time.Time) (models.UserSession, error)
+	// RevokeReplayed ends the active session whose previous refresh token hash is hash
+	RevokeReplayed(ctx context.Context, hash string) error
+	// Active reports whether the user's session is active
+	Active(ctx context.Context, userID, id primitive.ObjectID) (bool, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error)
+	// Revoke ends one of the user's sessions
+	Revoke(ctx context.Context, userID, id primitive.ObjectID) error
+	// RevokeAll ends the user's active sessions other than except, which may
+	// be the zero ID, returning how many it ended
+	RevokeAll(ctx context.Context, userID, except primitive.ObjectID) (int64, error)
+	// DeleteByUser removes the user's sessions, returning how many it removed
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+// UsageRepo stores the LLM usage ledger
+type UsageRepo interface {
+	Insert(ctx context.Context, entry *models.LLMUsage) error
+	// Summarize totals the user's entries created between from and to by
+	// UsageGroupFeed, UsageGroupDay or UsageGroupProvider. Days are listed in
+	// order, feeds and providers by tokens used; feed groups carry the name
+	// of the feed's latest entry.
+	Summarize(ctx context.Context, userID, groupBy string, from, to time.Time) ([]models.LLMUsageGroup, error)
+	ListByUser(ctx context.Context, userID string) ([]models.LLMUsage, error)
+	// DeleteByUser removes the user's entries, returning how many it removed
+	DeleteByUser(ctx context.Context, userID string) (int64, error)
+}
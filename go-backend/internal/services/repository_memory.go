@@ -0,0 +1,809 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// The memory repositories keep records in maps, for tests that exercise
+// services without a MongoDB server. Records go through a BSON round trip
+// on the way in and out, so callers never share slices or pointers with the
+// store and times are truncated to milliseconds as MongoDB does.
+
+// clone deep-copies a record through BSON
+func clone[T any](v T) T {
+	var out T
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// applyUpdate sets and unsets fields of a record by their bson names;
+// dotted paths reach into embedded documents, creating them when missing
+func applyUpdate[T any](v *T, set bson.M, unset []string) error {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	for path, value := range set {
+		parent, key := docPath(doc, path, true)
+		parent[key] = value
+	}
+	for _, path := range unset {
+		if parent, key := docPath(doc, path, false); parent != nil {
+			delete(parent, key)
+		}
+	}
+	if raw, err = bson.Marshal(doc); err != nil {
+		return err
+	}
+	var out T
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		return err
+	}
+	*v = out
+	return nil
+}
+
+// docPath returns the document holding the last key of a dotted path, or
+// nil when it does not exist and create is false
+func docPath(doc bson.M, path string, create bool) (bson.M, string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := doc[key].(bson.M)
+		if !ok {
+			if !create {
+				return nil, ""
+			}
+			next = bson.M{}
+			doc[key] = next
+		}
+		doc = next
+	}
+	return doc, keys[len(keys)-1]
+}
+
+// containsFold reports whether substr is in s, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// page returns the part of records after skip, at most limit long; a zero
+// limit returns the rest
+func page[T any](records []T, limit, skip int64) []T {
+	if skip >= int64(len(records)) {
+		return []T{}
+	}
+	records = records[skip:]
+	if limit > 0 && limit < int64(len(records)) {
+		records = records[:limit]
+	}
+	return records
+}
+
+// memoryUserRepo keeps users in memory
+type memoryUserRepo struct {
+	mu    sync.Mutex
+	users map[primitive.ObjectID]models.User
+}
+
+// NewMemoryUserRepo stores users in memory, for tests
+func NewMemoryUserRepo() UserRepo {
+	return &memoryUserRepo{users: map[primitive.ObjectID]models.User{}}
+}
+
+func (r *memoryUserRepo) Insert(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.users[user.ID] = clone(*user)
+	return nil
+}
+
+func (r *memoryUserRepo) Get(_ context.Context, id primitive.ObjectID) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return clone(user), nil
+}
+
+// find returns the first user matching fn; the caller holds r.mu
+func (r *memoryUserRepo) find(fn func(models.User) bool) (models.User, bool) {
+	for _, user := range r.users {
+		if fn(user) {
+			return user, true
+		}
+	}
+	return models.User{}, false
+}
+
+func (r *memoryUserRepo) GetByEmail(_ context.Context, email string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.find(func(u models.User) bool { return u.Email == email })
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return clone(user), nil
+}
+
+func (r *memoryUserRepo) GetByOAuth(_ context.Context, provider, subject string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.find(func(u models.User) bool {
+		for _, account := range u.OAuthAccounts {
+			if account.Provider == provider && account.Subject == subject {
+				return true
+			}
+		}
+		return false
+	})
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return clone(user), nil
+}
+
+func (r *memoryUserRepo) List(_ context.Context, search string, limit, skip int64) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	search = strings.TrimSpace(search)
+	users := []models.User{}
+	for _, user := range r.users {
+		if search != "" && !containsFold(user.Email, search) && !containsFold(user.Name, search) {
+			continue
+		}
+		user = clone(user)
+		user.Password, user.TwoFactorSecret, user.BackupCodes = "", "", nil
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID.Hex() > users[j].ID.Hex()
+	})
+	return page(users, limit, skip), nil
+}
+
+func (r *memoryUserRepo) Update(_ context.Context, id primitive.ObjectID, set bson.M, unset ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if err := applyUpdate(&user, set, unset); err != nil {
+		return err
+	}
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepo) AddTokensUsed(_ context.Context, id primitive.ObjectID, tokens int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	if user.TokenUsage == nil {
+		user.TokenUsage = &models.TokenUsage{}
+	}
+	user.TokenUsage.TokensUsed += int64(tokens)
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepo) LinkOAuth(_ context.Context, email string, account models.OAuthAccount) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.find(func(u models.User) bool { return u.Email == email && u.EmailVerified })
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	user.OAuthAccounts = append(user.OAuthAccounts, account)
+	r.users[user.ID] = clone(user)
+	return clone(user), nil
+}
+
+func (r *memoryUserRepo) VerifyEmail(_ context.Context, id primitive.ObjectID, email string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.Email != email {
+		return models.User{}, ErrUserNotFound
+	}
+	user.EmailVerified = true
+	r.users[id] = user
+	return clone(user), nil
+}
+
+func (r *memoryUserRepo) PromoteAdmins(_ context.Context, emails []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, user := range r.users {
+		for _, email := range emails {
+			if user.Email == email && user.EmailVerified && user.Role != models.RoleAdmin {
+				user.Role = models.RoleAdmin
+				r.users[id] = user
+				n++
+				break
+			}
+		}
+	}
+	return n, nil
+}
+
+func (r *memoryUserRepo) Delete(_ context.Context, id primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return 0, nil
+	}
+	delete(r.users, id)
+	return 1, nil
+}
+
+// memoryFeedRepo keeps feeds in memory
+type memoryFeedRepo struct {
+	mu    sync.Mutex
+	feeds map[primitive.ObjectID]models.WebSocketFeed
+}
+
+// NewMemoryFeedRepo stores feeds in memory, for tests
+func NewMemoryFeedRepo() FeedRepo {
+	return &memoryFeedRepo{feeds: map[primitive.ObjectID]models.WebSocketFeed{}}
+}
+
+func (r *memoryFeedRepo) Insert(_ context.Context, feed *models.WebSocketFeed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if feed.ID.IsZero() {
+		feed.ID = primitive.NewObjectID()
+	}
+	r.feeds[feed.ID] = clone(*feed)
+	return nil
+}
+
+func (r *memoryFeedRepo) Get(_ context.Context, id primitive.ObjectID) (models.WebSocketFeed, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	feed, ok := r.feeds[id]
+	if !ok {
+		return models.WebSocketFeed{}, ErrFeedNotFound
+	}
+	return clone(feed), nil
+}
+
+func (r *memoryFeedRepo) Update(_ context.Context, id primitive.ObjectID, set bson.M, unset ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	feed, ok := r.feeds[id]
+	if !ok {
+		return ErrFeedNotFound
+	}
+	if err := applyUpdate(&feed, set, unset); err != nil {
+		return err
+	}
+	r.feeds[id] = feed
+	return nil
+}
+
+func (r *memoryFeedRepo) AddSubscribers(_ context.Context, id primitive.ObjectID, delta int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if feed, ok := r.feeds[id]; ok {
+		feed.SubscriberCount += delta
+		r.feeds[id] = feed
+	}
+	return nil
+}
+
+func (r *memoryFeedRepo) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.feeds, id)
+	return nil
+}
+
+// matches reports whether a feed passes the filter
+func (f FeedFilter) matches(feed models.WebSocketFeed) bool {
+	return (!f.Public || feed.IsPublic) &&
+		(f.OwnerID == "" || feed.OwnerID == f.OwnerID || f.Unowned && feed.OwnerID == "" && feed.OrgID == "") &&
+		(f.OrgID == "" || feed.OrgID == f.OrgID) &&
+		(f.Category == "" || feed.Category == f.Category) &&
+		(f.Verified == nil || feed.IsVerified == *f.Verified) &&
+		f.Deleted == (feed.DeletedAt != nil) &&
+		(f.DeletedBefore.IsZero() || feed.DeletedAt != nil && feed.DeletedAt.Before(f.DeletedBefore))
+}
+
+// filter returns copies of the feeds passing the filter; the caller holds r.mu
+func (r *memoryFeedRepo) filter(filter FeedFilter) []models.WebSocketFeed {
+	feeds := []models.WebSocketFeed{}
+	for _, feed := range r.feeds {
+		if filter.matches(feed) {
+			feeds = append(feeds, clone(feed))
+		}
+	}
+	return feeds
+}
+
+func (r *memoryFeedRepo) List(_ context.Context, filter FeedFilter, order string, limit, offset int64) ([]models.WebSocketFeed, error) {
+	if _, err := feedListSort(order); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	feeds := r.filter(filter)
+	// Same orders as feedListSort, ties broken by ID
+	sort.Slice(feeds, func(i, j int) bool {
+		a, b := feeds[i], feeds[j]
+		switch order {
+		case FeedSortSubscribers:
+			if a.SubscriberCount != b.SubscriberCount {
+				return a.SubscriberCount > b.SubscriberCount
+			}
+		case FeedSortName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.ID.Hex() < b.ID.Hex()
+		default:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+		}
+		return a.ID.Hex() > b.ID.Hex()
+	})
+	return page(feeds, limit, offset), nil
+}
+
+func (r *memoryFeedRepo) Count(_ context.Context, filter FeedFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.filter(filter))), nil
+}
+
+func (r *memoryFeedRepo) Search(_ context.Context, query, category string) ([]models.WebSocketFeed, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return rankFeeds(r.filter(FeedFilter{Category: category}), query), nil
+}
+
+// rankFeeds returns the feeds matching a word of the query as a
+// case-insensitive substring, weighting name matches over tags over
+// descriptions like the feed_search text index, best matches first
+func rankFeeds(candidates []models.WebSocketFeed, query string) []models.WebSocketFeed {
+	scores := map[primitive.ObjectID]int{}
+	feeds := []models.WebSocketFeed{}
+	for _, feed := range candidates {
+		score := 0
+		for _, word := range strings.Fields(query) {
+			if containsFold(feed.Name, word) {
+				score += 10
+			}
+			for _, tag := range feed.Tags {
+				if containsFold(tag, word) {
+					score += 5
+				}
+			}
+			if containsFold(feed.Description, word) {
+				score++
+			}
+		}
+		if score > 0 {
+			scores[feed.ID] = score
+			feeds = append(feeds, feed)
+		}
+	}
+	sort.Slice(feeds, func(i, j int) bool {
+		if scores[feeds[i].ID] != scores[feeds[j].ID] {
+			return scores[feeds[i].ID] > scores[feeds[j].ID]
+		}
+		return feeds[i].ID.Hex() < feeds[j].ID.Hex()
+	})
+	return feeds
+}
+
+func (r *memoryFeedRepo) DeleteByOwner(_ context.Context, ownerID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, feed := range r.feeds {
+		if feed.OwnerID == ownerID {
+			delete(r.feeds, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memorySubscriptionRepo keeps subscriptions in memory. Unlike MongoDB
+// without a unique index, it allows one subscription per user and feed.
+type memorySubscriptionRepo struct {
+	mu   sync.Mutex
+	subs map[primitive.ObjectID]models.UserSubscription
+}
+
+// NewMemorySubscriptionRepo stores subscriptions in memory, for tests
+func NewMemorySubscriptionRepo() SubscriptionRepo {
+	return &memorySubscriptionRepo{subs: map[primitive.ObjectID]models.UserSubscription{}}
+}
+
+// find returns the user's subscription to the feed; the caller holds r.mu
+func (r *memorySubscriptionRepo) find(userID, feedID string) (models.UserSubscription, bool) {
+	for _, sub := range r.subs {
+		if sub.UserID == userID && sub.FeedID == feedID {
+			return sub, true
+		}
+	}
+	return models.UserSubscription{}, false
+}
+
+func (r *memorySubscriptionRepo) Insert(_ context.Context, sub *models.UserSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.find(sub.UserID, sub.FeedID); ok {
+		return ErrSubscriptionExists
+	}
+	if sub.ID.IsZero() {
+		sub.ID = primitive.NewObjectID()
+	}
+	r.subs[sub.ID] = clone(*sub)
+	return nil
+}
+
+func (r *memorySubscriptionRepo) Get(_ context.Context, userID, feedID string) (models.UserSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.find(userID, feedID)
+	if !ok || !sub.IsActive {
+		return models.UserSubscription{}, ErrSubscriptionNotFound
+	}
+	return clone(sub), nil
+}
+
+func (r *memorySubscriptionRepo) ListByUser(_ context.Context, userID string) ([]models.UserSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(func(sub models.UserSubscription) bool { return sub.UserID == userID }), nil
+}
+
+// list returns copies of the subscriptions matching fn, oldest first; the
+// caller holds r.mu
+func (r *memorySubscriptionRepo) list(fn func(models.UserSubscription) bool) []models.UserSubscription {
+	subs := []models.UserSubscription{}
+	for _, sub := range r.subs {
+		if fn(sub) {
+			subs = append(subs, clone(sub))
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID.Hex() < subs[j].ID.Hex() })
+	return subs
+}
+
+func (r *memorySubscriptionRepo) Reactivate(_ context.Context, userID, feedID, customPrompt string, expiresAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.find(userID, feedID)
+	if !ok {
+		return nil
+	}
+	sub.IsActive = true
+	sub.CustomPrompt = customPrompt
+	sub.ExpiresAt = expiresAt
+	r.subs[sub.ID] = clone(sub)
+	return nil
+}
+
+func (r *memorySubscriptionRepo) Deactivate(_ context.Context, userID, feedID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.find(userID, feedID)
+	if !ok || !sub.IsActive {
+		return false, nil
+	}
+	sub.IsActive = false
+	r.subs[sub.ID] = sub
+	return true, nil
+}
+
+func (r *memorySubscriptionRepo) SetExpiry(_ context.Context, userID, feedID string, expiresAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.find(userID, feedID)
+	if !ok || !sub.IsActive {
+		return ErrSubscriptionNotFound
+	}
+	sub.ExpiresAt = expiresAt
+	r.subs[sub.ID] = clone(sub)
+	return nil
+}
+
+func (r *memorySubscriptionRepo) Update(_ context.Context, userID, feedID string, set bson.M) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.find(userID, feedID)
+	if !ok {
+		return nil
+	}
+	if err := applyUpdate(&sub, set, nil); err != nil {
+		return err
+	}
+	r.subs[sub.ID] = sub
+	return nil
+}
+
+// expired reports whether an active subscription has expired at now
+func expired(sub models.UserSubscription, now time.Time) bool {
+	return sub.IsActive && sub.ExpiresAt != nil && !sub.ExpiresAt.After(now)
+}
+
+func (r *memorySubscriptionRepo) ListExpired(_ context.Context, now time.Time) ([]models.UserSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(func(sub models.UserSubscription) bool { return expired(sub, now) }), nil
+}
+
+func (r *memorySubscriptionRepo) Expire(_ context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subs[id]
+	if !ok || !expired(sub, now) {
+		return false, nil
+	}
+	sub.IsActive = false
+	r.subs[id] = sub
+	return true, nil
+}
+
+func (r *memorySubscriptionRepo) CountActive(_ context.Context, feedID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs := r.list(func(sub models.UserSubscription) bool { return sub.FeedID == feedID && sub.IsActive })
+	return int64(len(subs)), nil
+}
+
+func (r *memorySubscriptionRepo) ActiveUserIDs(_ context.Context, feedID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := []string{}
+	for _, sub := range r.list(func(sub models.UserSubscription) bool { return sub.FeedID == feedID && sub.IsActive }) {
+		ids = append(ids, sub.UserID)
+	}
+	return ids, nil
+}
+
+func (r *memorySubscriptionRepo) DeleteByFeed(_ context.Context, feedID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, sub := range r.subs {
+		if sub.FeedID == feedID {
+			delete(r.subs, id)
+		}
+	}
+	return nil
+}
+
+func (r *memorySubscriptionRepo) FeedIDs(_ context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, sub := range r.subs {
+		if !seen[sub.FeedID] {
+			seen[sub.FeedID] = true
+			ids = append(ids, sub.FeedID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (r *memorySubscriptionRepo) DeleteByUser(_ context.Context, userID string, feedIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	feeds := map[string]bool{}
+	for _, id := range feedIDs {
+		feeds[id] = true
+	}
+	var n int64
+	for id, sub := range r.subs {
+		if sub.UserID == userID || feeds[sub.FeedID] {
+			delete(r.subs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memorySessionRepo keeps sessions in memory
+type memorySessionRepo struct {
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]models.UserSession
+}
+
+// NewMemorySessionRepo stores sessions in memory, for tests
+func NewMemorySessionRepo() SessionRepo {
+	return &memorySessionRepo{sessions: map[primitive.ObjectID]models.UserSession{}}
+}
+
+func (r *memorySessionRepo) Insert(_ context.Context, session *models.UserSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	r.sessions[session.ID] = clone(*session)
+	return nil
+}
+
+func (r *memorySessionRepo) Rotate(_ context.Context, hash, nextHash string, now, expiresAt time.Time) (models.UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.sessions {
+		if session.RefreshTokenHash == hash && session.IsActive && session.ExpiresAt.After(now) {
+			session.RefreshTokenHash = nextHash
+			session.PrevRefreshTokenHash = hash
+			session.LastActive = now
+			session.ExpiresAt = expiresAt
+			r.sessions[id] = clone(session)
+			return clone(session), nil
+		}
+	}
+	return models.UserSession{}, ErrSessionNotFound
+}
+
+func (r *memorySessionRepo) RevokeReplayed(_ context.Context, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.sessions {
+		if session.PrevRefreshTokenHash == hash && session.IsActive {
+			session.IsActive = false
+			r.sessions[id] = session
+			break
+		}
+	}
+	return nil
+}
+
+func (r *memorySessionRepo) Active(_ context.Context, userID, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	return ok && session.UserID == userID && session.IsActive, nil
+}
+
+func (r *memorySessionRepo) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []models.UserSession{}
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, clone(session))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID.Hex() < sessions[j].ID.Hex() })
+	return sessions, nil
+}
+
+func (r *memorySessionRepo) Revoke(_ context.Context, userID, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[id]; ok && session.UserID == userID {
+		session.IsActive = false
+		r.sessions[id] = session
+	}
+	return nil
+}
+
+func (r *memorySessionRepo) RevokeAll(_ context.Context, userID, except primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, session := range r.sessions {
+		if session.UserID == userID && id != except && session.IsActive {
+			session.IsActive = false
+			r.sessions[id] = session
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memorySessionRepo) DeleteByUser(_ context.Context, userID primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memoryUsageRepo keeps the usage ledger in memory
+type memoryUsageRepo struct {
+	mu      sync.Mutex
+	entries []models.LLMUsage
+}
+
+// NewMemoryUsageRepo stores the usage ledger in memory, for tests
+func NewMemoryUsageRepo() UsageRepo {
+	return &memoryUsageRepo{}
+}
+
+func (r *memoryUsageRepo) Insert(_ context.Context, entry *models.LLMUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	r.entries = append(r.entries, clone(*entry))
+	return nil
+}
+
+// byUser returns copies of the user's entries; the caller holds r.mu
+func (r *memoryUsageRepo) byUser(userID string, keep func(models.LLMUsage) bool) []models.LLMUsage {
+	entries := []models.LLMUsage{}
+	for _, e := range r.entries {
+		if e.UserID == userID && keep(e) {
+			entries = append(entries, clone(e))
+		}
+	}
+	return entries
+}
+
+func (r *memoryUsageRepo) Summarize(_ context.Context, userID, groupBy string, from, to time.Time) ([]models.LLMUsageGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return groupUsage(r.byUser(userID, func(e models.LLMUsage) bool {
+		return !e.CreatedAt.Before(from) && !e.CreatedAt.After(to)
+	}), groupBy)
+}
+
+func (r *memoryUsageRepo) ListByUser(_ context.Context, userID string) ([]models.LLMUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byUser(userID, func(models.LLMUsage) bool { return true }), nil
+}
+
+func (r *memoryUsageRepo) DeleteByUser(_ context.Context, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.entries[:0]
+	for _, e := range r.entries {
+		if e.UserID != userID {
+			kept = append(kept, e)
+		}
+	}
+	n := int64(len(r.entries) - len(kept))
+	r.entries = kept
+	return n, nil
+}
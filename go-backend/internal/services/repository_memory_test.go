@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestMemoryUserRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepo()
+
+	quota := int64(500)
+	user := models.User{
+		Email:              "ada@example.com",
+		Name:               "Ada",
+		Password:           "hash",
+		CreatedAt:          time.Now(),
+		TokenUsage:         &models.TokenUsage{CurrentMonth: "2026-01", Limit: 1000},
+		TokenQuotaOverride: &quota,
+	}
+	require.NoError(t, repo.Insert(ctx, &user))
+	require.False(t, user.ID.IsZero())
+
+	// Dotted paths update embedded documents; unset removes fields
+	require.NoError(t, repo.Update(ctx, user.ID, bson.M{"tokenUsage.currentMonth": "2026-02", "role": models.RoleCurator}, "tokenQuotaOverride"))
+	require.NoError(t, repo.AddTokensUsed(ctx, user.ID, 42))
+	got, err := repo.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02", got.TokenUsage.CurrentMonth)
+	assert.Equal(t, int64(1000), got.TokenUsage.Limit)
+	assert.Equal(t, int64(42), got.TokenUsage.TokensUsed)
+	assert.Equal(t, models.RoleCurator, got.Role)
+	assert.Nil(t, got.TokenQuotaOverride)
+
+	_, err = repo.GetByOAuth(ctx, "github", "123")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	// Only verified emails are linked
+	_, err = repo.LinkOAuth(ctx, "ada@example.com", models.OAuthAccount{Provider: "github", Subject: "123"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = repo.VerifyEmail(ctx, user.ID, "ada@example.com")
+	require.NoError(t, err)
+	linked, err := repo.LinkOAuth(ctx, "ada@example.com", models.OAuthAccount{Provider: "github", Subject: "123"})
+	require.NoError(t, err)
+	assert.True(t, linked.EmailVerified)
+	got, err = repo.GetByOAuth(ctx, "github", "123")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	_, err = repo.VerifyEmail(ctx, user.ID, "old@example.com")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.ErrorIs(t, repo.Update(ctx, models.User{}.ID, bson.M{"name": "x"}), ErrUserNotFound)
+
+	// Listings leave credentials out
+	users, err := repo.List(ctx, "ADA", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Empty(t, users[0].Password)
+
+	require.NoError(t, repo.Insert(ctx, &models.User{Email: "eve@example.com", Name: "Eve", CreatedAt: time.Now()}))
+	n, err := repo.PromoteAdmins(ctx, []string{"ada@example.com", "eve@example.com", "nobody@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "only verified emails become admins")
+	n, err = repo.PromoteAdmins(ctx, []string{"ada@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestMemoryFeedRepo_List(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryFeedRepo()
+	now := time.Now()
+	for _, feed := range []models.WebSocketFeed{
+		{Name: "Bravo", IsPublic: true, SubscriberCount: 5, CreatedAt: now.Add(-time.Hour)},
+		{Name: "Alpha", IsPublic: true, SubscriberCount: 1, CreatedAt: now},
+		{Name: "Charlie", OwnerID: "owner", SubscriberCount: 9, CreatedAt: now.Add(-2 * time.Hour)},
+	} {
+		require.NoError(t, repo.Insert(ctx, &feed))
+	}
+
+	names := func(filter FeedFilter, sort string, limit, offset int64) []string {
+		feeds, err := repo.List(ctx, filter, sort, limit, offset)
+		require.NoError(t, err)
+		var out []string
+		for _, f := range feeds {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(FeedFilter{}, FeedSortRecent, 0, 0))
+	assert.Equal(t, []string{"Charlie", "Bravo", "Alpha"}, names(FeedFilter{}, FeedSortSubscribers, 0, 0))
+	assert.Equal(t, []string{"Bravo"}, names(FeedFilter{Public: true}, FeedSortName, 1, 1))
+	assert.Equal(t, []string{"Charlie"}, names(FeedFilter{OwnerID: "owner"}, FeedSortName, 0, 0))
+	// Unowned legacy feeds belong to everyone
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(FeedFilter{OwnerID: "owner", Unowned: true}, FeedSortName, 0, 0))
+
+	// Organization feeds are neither personal nor legacy unowned feeds
+	org := models.WebSocketFeed{Name: "Delta", OrgID: "org", CreatedAt: now}
+	require.NoError(t, repo.Insert(ctx, &org))
+	assert.Equal(t, []string{"Delta"}, names(FeedFilter{OrgID: "org"}, "", 0, 0))
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(FeedFilter{OwnerID: "owner", Unowned: true}, FeedSortName, 0, 0))
+	require.NoError(t, repo.Delete(ctx, org.ID))
+
+	// Deleted feeds only match filters asking for them
+	deletedAt := now.Add(-time.Hour)
+	gone := models.WebSocketFeed{Name: "Echo", IsPublic: true, CreatedAt: now, DeletedAt: &deletedAt}
+	require.NoError(t, repo.Insert(ctx, &gone))
+	assert.Equal(t, []string{"Alpha", "Bravo"}, names(FeedFilter{Public: true}, FeedSortName, 0, 0))
+	assert.Equal(t, []string{"Echo"}, names(FeedFilter{Deleted: true}, "", 0, 0))
+	assert.Equal(t, []string{"Echo"}, names(FeedFilter{Deleted: true, DeletedBefore: now}, "", 0, 0))
+	assert.Empty(t, names(FeedFilter{Deleted: true, DeletedBefore: deletedAt.Add(-time.Minute)}, "", 0, 0))
+	hits, searchErr := repo.Search(ctx, "echo", "")
+	require.NoError(t, searchErr)
+	assert.Empty(t, hits)
+	require.NoError(t, repo.Delete(ctx, gone.ID))
+
+	_, err := repo.List(ctx, FeedFilter{}, "bogus", 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
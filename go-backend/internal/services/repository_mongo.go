@@ -0,0 +1,602 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// userListProjection leaves credentials out of admin user listings
+var userListProjection = bson.M{"password": 0, "twoFactorSecret": 0, "backupCodes": 0}
+
+// updateDoc builds a $set/$unset update
+func updateDoc(set bson.M, unset []string) bson.M {
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		fields := bson.M{}
+		for _, f := range unset {
+			fields[f] = ""
+		}
+		update["$unset"] = fields
+	}
+	return update
+}
+
+// findAll decodes every document matching filter
+func findAll[T any](ctx context.Context, coll *mongo.Collection, filter interface{}, opts ...*options.FindOptions) ([]T, error) {
+	cur, err := coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	docs := []T{}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// mongoUserRepo stores users in the users collection
+type mongoUserRepo struct {
+	db *mongo.Database
+}
+
+// NewMongoUserRepo stores users in db's users collection
+func NewMongoUserRepo(db *mongo.Database) UserRepo {
+	return mongoUserRepo{db: db}
+}
+
+func (r mongoUserRepo) coll() *mongo.Collection {
+	return r.db.Collection("users")
+}
+
+func (r mongoUserRepo) Insert(ctx context.Context, user *models.User) error {
+	res, err := r.coll().InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+	user.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r mongoUserRepo) findOne(ctx context.Context, filter bson.M) (models.User, error) {
+	var user models.User
+	err := r.coll().FindOne(ctx, filter).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r mongoUserRepo) Get(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r mongoUserRepo) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r mongoUserRepo) GetByOAuth(ctx context.Context, provider, subject string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"oauthAccounts": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}})
+}
+
+func (r mongoUserRepo) List(ctx context.Context, search string, limit, skip int64) ([]models.User, error) {
+	filter := bson.M{}
+	if search = strings.TrimSpace(search); search != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(search), "$options": "i"}
+		filter["$or"] = []bson.M{{"email": pattern}, {"name": pattern}}
+	}
+	opts := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetLimit(limit).
+		SetSkip(skip).
+		SetProjection(userListProjection)
+	return findAll[models.User](ctx, r.coll(), filter, opts)
+}
+
+func (r mongoUserRepo) Update(ctx context.Context, id primitive.ObjectID, set bson.M, unset ...string) error {
+	res, err := r.coll().UpdateByID(ctx, id, updateDoc(set, unset))
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r mongoUserRepo) AddTokensUsed(ctx context.Context, id primitive.ObjectID, tokens int) error {
+	_, err := r.coll().UpdateByID(ctx, id, bson.M{"$inc": bson.M{"tokenUsage.tokensUsed": tokens}})
+	return err
+}
+
+func (r mongoUserRepo) findOneAndUpdate(ctx context.Context, filter, update bson.M) (models.User, error) {
+	var user models.User
+	err := r.coll().FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r mongoUserRepo) LinkOAuth(ctx context.Context, email string, account models.OAuthAccount) (models.User, error) {
+	return r.findOneAndUpdate(ctx,
+		bson.M{"email": email, "emailVerified": true},
+		bson.M{"$push": bson.M{"oauthAccounts": account}})
+}
+
+func (r mongoUserRepo) VerifyEmail(ctx context.Context, id primitive.ObjectID, email string) (models.User, error) {
+	return r.findOneAndUpdate(ctx,
+		bson.M{"_id": id, "email": email},
+		bson.M{"$set": bson.M{"emailVerified": true}})
+}
+
+func (r mongoUserRepo) PromoteAdmins(ctx context.Context, emails []string) (int64, error) {
+	res, err := r.coll().UpdateMany(ctx,
+		bson.M{"email": bson.M{"$in": emails}, "emailVerified": true, "role": bson.M{"$ne": models.RoleAdmin}},
+		bson.M{"$set": bson.M{"role": models.RoleAdmin}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r mongoUserRepo) Delete(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	res, err := r.coll().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// mongoFeedRepo stores feeds in the websocket_feeds collection
+type mongoFeedRepo struct {
+	db *mongo.Database
+}
+
+// NewMongoFeedRepo stores feeds in db's websocket_feeds collection
+func NewMongoFeedRepo(db *mongo.Database) FeedRepo {
+	return mongoFeedRepo{db: db}
+}
+
+func (r mongoFeedRepo) coll() *mongo.Collection {
+	return r.db.Collection("websocket_feeds")
+}
+
+func (r mongoFeedRepo) Insert(ctx context.Context, feed *models.WebSocketFeed) error {
+	res, err := r.coll().InsertOne(ctx, feed)
+	if err != nil {
+		return err
+	}
+	feed.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r mongoFeedRepo) Get(ctx context.Context, id primitive.ObjectID) (models.WebSocketFeed, error) {
+	var feed models.WebSocketFeed
+	err := r.coll().FindOne(ctx, bson.M{"_id": id}).Decode(&feed)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.WebSocketFeed{}, ErrFeedNotFound
+	}
+	return feed, err
+}
+
+func (r mongoFeedRepo) Update(ctx context.Context, id primitive.ObjectID, set bson.M, unset ...string) error {
+	res, err := r.coll().UpdateByID(ctx, id, updateDoc(set, unset))
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrFeedNotFound
+	}
+	return nil
+}
+
+func (r mongoFeedRepo) AddSubscribers(ctx context.Context, id primitive.ObjectID, delta int) error {
+	_, err := r.coll().UpdateByID(ctx, id, bson.M{"$inc": bson.M{"subscriberCount": delta}})
+	return err
+}
+
+func (r mongoFeedRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.coll().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// feedFilter returns the query document for a FeedFilter
+func feedFilter(f FeedFilter) bson.M {
+	filter := bson.M{}
+	var or []bson.M
+	if f.Public {
+		// Align with existing data that may not have isPublic set
+		or = append(or, bson.M{"$or": []bson.M{{"isPublic": true}, {"isPublic": bson.M{"$exists": false}}}})
+	}
+	if f.OwnerID != "" && f.Unowned {
+		// Legacy data has no owner
+		or = append(or, bson.M{"$or": []bson.M{{"ownerId": f.OwnerID}, {"ownerId": bson.M{"$exists": false}}}})
+	}
+	switch len(or) {
+	case 1:
+		filter = or[0]
+	case 2:
+		filter["$and"] = or
+	}
+	if f.OwnerID != "" && !f.Unowned {
+		filter["ownerId"] = f.OwnerID
+	}
+	if f.OrgID != "" {
+		filter["orgId"] = f.OrgID
+	}
+	if f.Category != "" {
+		filter["category"] = f.Category
+	}
+	if f.Verified != nil {
+		filter["isVerified"] = *f.Verified
+	}
+	switch {
+	case !f.Deleted:
+		filter["deletedAt"] = nil
+	case !f.DeletedBefore.IsZero():
+		filter["deletedAt"] = bson.M{"$lt": f.DeletedBefore}
+	default:
+		filter["deletedAt"] = bson.M{"$ne": nil}
+	}
+	return filter
+}
+
+func (r mongoFeedRepo) List(ctx context.Context, filter FeedFilter, sort string, limit, offset int64) ([]models.WebSocketFeed, error) {
+	order, err := feedListSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(order).SetSkip(offset)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	return findAll[models.WebSocketFeed](ctx, r.coll(), feedFilter(filter), opts)
+}
+
+func (r mongoFeedRepo) Count(ctx context.Context, filter FeedFilter) (int64, error) {
+	return r.coll().CountDocuments(ctx, feedFilter(filter))
+}
+
+// Search uses the feed_search text index created by the database
+// migrations, so it matches whole words and their stems
+func (r mongoFeedRepo) Search(ctx context.Context, query, category string) ([]models.WebSocketFeed, error) {
+	filter := bson.M{"$text": bson.M{"$search": query}, "deletedAt": nil}
+	if category != "" {
+		filter["category"] = category
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}})
+	return findAll[models.WebSocketFeed](ctx, r.coll(), filter, opts)
+}
+
+func (r mongoFeedRepo) DeleteByOwner(ctx context.Context, ownerID string) (int64, error) {
+	res, err := r.coll().DeleteMany(ctx, bson.M{"ownerId": ownerID})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// mongoSubscriptionRepo stores subscriptions in the user_subscriptions collection
+type mongoSubscriptionRepo struct {
+	db *mongo.Database
+}
+
+// NewMongoSubscriptionRepo stores subscriptions in db's user_subscriptions collection
+func NewMongoSubscriptionRepo(db *mongo.Database) SubscriptionRepo {
+	return mongoSubscriptionRepo{db: db}
+}
+
+func (r mongoSubscriptionRepo) coll() *mongo.Collection {
+	return r.db.Collection("user_subscriptions")
+}
+
+func (r mongoSubscriptionRepo) Insert(ctx context.Context, sub *models.UserSubscription) error {
+	res, err := r.coll().InsertOne(ctx, sub)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrSubscriptionExists
+	}
+	if err != nil {
+		return err
+	}
+	sub.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r mongoSubscriptionRepo) Get(ctx context.Context, userID, feedID string) (models.UserSubscription, error) {
+	var sub models.UserSubscription
+	err := r.coll().FindOne(ctx, bson.M{"userId": userID, "feedId": feedID, "isActive": true}).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.UserSubscription{}, ErrSubscriptionNotFound
+	}
+	return sub, err
+}
+
+func (r mongoSubscriptionRepo) ListByUser(ctx context.Context, userID string) ([]models.UserSubscription, error) {
+	return findAll[models.UserSubscription](ctx, r.coll(), bson.M{"userId": userID})
+}
+
+func (r mongoSubscriptionRepo) Reactivate(ctx context.Context, userID, feedID, customPrompt string, expiresAt *time.Time) error {
+	update := expiryUpdate(bson.M{"isActive": true, "customPrompt": customPrompt}, expiresAt)
+	_, err := r.coll().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID}, update)
+	return err
+}
+
+func (r mongoSubscriptionRepo) Deactivate(ctx context.Context, userID, feedID string) (bool, error) {
+	res, err := r.coll().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID}, bson.M{"$set": bson.M{"isActive": false}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (r mongoSubscriptionRepo) SetExpiry(ctx context.Context, userID, feedID string, expiresAt *time.Time) error {
+	res, err := r.coll().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID, "isActive": true}, expiryUpdate(bson.M{}, expiresAt))
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r mongoSubscriptionRepo) Update(ctx context.Context, userID, feedID string, set bson.M) error {
+	_, err := r.coll().UpdateOne(ctx, bson.M{"userId": userID, "feedId": feedID}, bson.M{"$set": set})
+	return err
+}
+
+func (r mongoSubscriptionRepo) ListExpired(ctx context.Context, now time.Time) ([]models.UserSubscription, error) {
+	return findAll[models.UserSubscription](ctx, r.coll(), bson.M{"isActive": true, "expiresAt": bson.M{"$lte": now}})
+}
+
+func (r mongoSubscriptionRepo) Expire(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	res, err := r.coll().UpdateOne(ctx,
+		bson.M{"_id": id, "isActive": true, "expiresAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"isActive": false}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (r mongoSubscriptionRepo) CountActive(ctx context.Context, feedID string) (int64, error) {
+	return r.coll().CountDocuments(ctx, bson.M{"feedId": feedID, "isActive": true})
+}
+
+func (r mongoSubscriptionRepo) ActiveUserIDs(ctx context.Context, feedID string) ([]string, error) {
+	values, err := r.coll().Distinct(ctx, "userId", bson.M{"feedId": feedID, "isActive": true})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r mongoSubscriptionRepo) DeleteByFeed(ctx context.Context, feedID string) error {
+	_, err := r.coll().DeleteMany(ctx, bson.M{"feedId": feedID})
+	return err
+}
+
+func (r mongoSubscriptionRepo) FeedIDs(ctx context.Context) ([]string, error) {
+	values, err := r.coll().Distinct(ctx, "feedId", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r mongoSubscriptionRepo) DeleteByUser(ctx context.Context, userID string, feedIDs []string) (int64, error) {
+	res, err := r.coll().DeleteMany(ctx, bson.M{"$or": []bson.M{{"userId": userID}, {"feedId": bson.M{"$in": feedIDs}}}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// expiryUpdate extends a $set document with expiresAt, or unsets the field when nil
+func expiryUpdate(set bson.M, expiresAt *time.Time) bson.M {
+	update := bson.M{}
+	if expiresAt != nil {
+		set["expiresAt"] = *expiresAt
+	} else {
+		update["$unset"] = bson.M{"expiresAt": ""}
+	}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	return update
+}
+
+// mongoSessionRepo stores sessions in the sessions collection
+type mongoSessionRepo struct {
+	db *mongo.Database
+}
+
+// NewMongoSessionRepo stores sessions in db's sessions collection
+func NewMongoSessionRepo(db *mongo.Database) SessionRepo {
+	return mongoSessionRepo{db: db}
+}
+
+func (r mongoSessionRepo) coll() *mongo.Collection {
+	return r.db.Collection("sessions")
+}
+
+func (r mongoSessionRepo) Insert(ctx context.Context, session *models.UserSession) error {
+	res, err := r.coll().InsertOne(ctx, session)
+	if err != nil {
+		return err
+	}
+	session.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r mongoSessionRepo) Rotate(ctx context.Context, hash, nextHash string, now, expiresAt time.Time) (models.UserSession, error) {
+	var session models.UserSession
+	err := r.coll().FindOneAndUpdate(ctx,
+		bson.M{"refreshTokenHash": hash, "isActive": true, "expiresAt": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{
+			"refreshTokenHash":     nextHash,
+			"prevRefreshTokenHash": hash,
+			"lastActiveAt":         now,
+			"expiresAt":            expiresAt,
+		}},
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.UserSession{}, ErrSessionNotFound
+	}
+	return session, err
+}
+
+func (r mongoSessionRepo) RevokeReplayed(ctx context.Context, hash string) error {
+	_, err := r.coll().UpdateOne(ctx, bson.M{"prevRefreshTokenHash": hash, "isActive": true}, bson.M{"$set": bson.M{"isActive": false}})
+	return err
+}
+
+func (r mongoSessionRepo) Active(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+	n, err := r.coll().CountDocuments(ctx, bson.M{"_id": id, "userId": userID, "isActive": true}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r mongoSessionRepo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	return findAll[models.UserSession](ctx, r.coll(), bson.M{"userId": userID})
+}
+
+func (r mongoSessionRepo) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	_, err := r.coll().UpdateOne(ctx, bson.M{"_id": id, "userId": userID}, bson.M{"$set": bson.M{"isActive": false}})
+	return err
+}
+
+func (r mongoSessionRepo) RevokeAll(ctx context.Context, userID, except primitive.ObjectID) (int64, error) {
+	res, err := r.coll().UpdateMany(ctx, bson.M{"userId": userID, "_id": bson.M{"$ne": except}}, bson.M{"$set": bson.M{"isActive": false}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r mongoSessionRepo) DeleteByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	res, err := r.coll().DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// mongoUsageRepo stores the usage ledger in the llm_usage collection
+type mongoUsageRepo struct {
+	db *mongo.Database
+}
+
+// NewMongoUsageRepo stores the usage ledger in db's llm_usage collection
+func NewMongoUsageRepo(db *mongo.Database) UsageRepo {
+	return mongoUsageRepo{db: db}
+}
+
+func (r mongoUsageRepo) coll() *mongo.Collection {
+	return r.db.Collection("llm_usage")
+}
+
+// ensureIndexes creates the index used to summarise a user's usage over time
+func (r mongoUsageRepo) ensureIndexes(ctx context.Context) error {
+	_, err := r.coll().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	return err
+}
+
+func (r mongoUsageRepo) Insert(ctx context.Context, entry *models.LLMUsage) error {
+	res, err := r.coll().InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// usageGroupKey returns the expression grouping the ledger by groupBy
+func usageGroupKey(groupBy string) (interface{}, error) {
+	switch groupBy {
+	case UsageGroupFeed:
+		return "$feedId", nil
+	case UsageGroupProvider:
+		return "$provider", nil
+	case UsageGroupDay:
+		return bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$createdAt"}}, nil
+	}
+	return nil, ErrInvalidUsageGroup
+}
+
+func (r mongoUsageRepo) Summarize(ctx context.Context, userID, groupBy string, from, to time.Time) ([]models.LLMUsageGroup, error) {
+	key, err := usageGroupKey(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	sort := bson.D{{Key: "totalTokens", Value: -1}, {Key: "_id", Value: 1}}
+	if groupBy == UsageGroupDay {
+		sort = bson.D{{Key: "_id", Value: 1}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": userID, "createdAt": bson.M{"$gte": from, "$lte": to}}}},
+		{{Key: "$sort", Value: bson.M{"createdAt": -1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              key,
+			"feedName":         bson.M{"$first": "$feedName"},
+			"requests":         bson.M{"$sum": 1},
+			"cachedRequests":   bson.M{"$sum": bson.M{"$cond": bson.A{"$cached", 1, 0}}},
+			"promptTokens":     bson.M{"$sum": "$promptTokens"},
+			"completionTokens": bson.M{"$sum": "$completionTokens"},
+			"totalTokens":      bson.M{"$sum": bson.M{"$add": bson.A{"$promptTokens", "$completionTokens"}}},
+			"costUsd":          bson.M{"$sum": "$costUsd"},
+			"avgLatencyMs":     bson.M{"$avg": "$latencyMs"},
+		}}},
+		{{Key: "$sort", Value: sort}},
+	}
+	cur, err := r.coll().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	groups := []models.LLMUsageGroup{}
+	if err := cur.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r mongoUsageRepo) ListByUser(ctx context.Context, userID string) ([]models.LLMUsage, error) {
+	return findAll[models.LLMUsage](ctx, r.coll(), bson.M{"userId": userID})
+}
+
+func (r mongoUsageRepo) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+	res, err := r.coll().DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,746 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// The SQL repositories keep records in the Postgres or SQLite tables created
+// by db.SQLMigrations. Each row holds the whole record as canonical extended
+// JSON in doc, so models keep their bson shape, plus the columns queries
+// filter and sort on; updates rewrite both inside a transaction. Queries use
+// $n placeholders, which both drivers accept.
+
+// NewSQLStore keeps every repository in a database migrated with
+// db.SQLMigrations; driver is "postgres" or "sqlite"
+func NewSQLStore(db *sql.DB, driver string) Store {
+	lock := ""
+	if driver == "postgres" {
+		lock = " FOR UPDATE"
+	}
+	return Store{
+		Users: &sqlUserRepo{t: &sqlTable[models.User]{
+			db: db, name: "users", lock: lock,
+			columns: []string{"email", "name", "role", "created_at"},
+			values: func(u *models.User) []any {
+				return []any{u.Email, u.Name, u.Role, u.CreatedAt.UnixMilli()}
+			},
+			id: func(u *models.User) *primitive.ObjectID { return &u.ID },
+		}},
+		Feeds: &sqlFeedRepo{t: &sqlTable[models.WebSocketFeed]{
+			db: db, name: "websocket_feeds", lock: lock,
+			columns: []string{"name", "description", "tags", "category", "is_public", "is_verified", "owner_id", "org_id", "subscriber_count", "created_at", "deleted_at"},
+			values: func(f *models.WebSocketFeed) []any {
+				var deletedAt any
+				if f.DeletedAt != nil {
+					deletedAt = f.DeletedAt.UnixMilli()
+				}
+				return []any{f.Name, f.Description, strings.Join(f.Tags, " "), f.Category, f.IsPublic, f.IsVerified, f.OwnerID, f.OrgID, f.SubscriberCount, f.CreatedAt.UnixMilli(), deletedAt}
+			},
+			id: func(f *models.WebSocketFeed) *primitive.ObjectID { return &f.ID },
+		}},
+		Subscriptions: &sqlSubscriptionRepo{t: &sqlTable[models.UserSubscription]{
+			db: db, name: "user_subscriptions", lock: lock,
+			columns: []string{"user_id", "feed_id", "is_active", "expires_at"},
+			values: func(s *models.UserSubscription) []any {
+				var expiresAt any
+				if s.ExpiresAt != nil {
+					expiresAt = s.ExpiresAt.UnixMilli()
+				}
+				return []any{s.UserID, s.FeedID, s.IsActive, expiresAt}
+			},
+			id: func(s *models.UserSubscription) *primitive.ObjectID { return &s.ID },
+		}},
+		Sessions: &sqlSessionRepo{t: &sqlTable[models.UserSession]{
+			db: db, name: "sessions", lock: lock,
+			columns: []string{"user_id", "refresh_token_hash", "prev_refresh_token_hash", "is_active", "expires_at"},
+			values: func(s *models.UserSession) []any {
+				return []any{s.UserID.Hex(), s.RefreshTokenHash, s.PrevRefreshTokenHash, s.IsActive, s.ExpiresAt.UnixMilli()}
+			},
+			id: func(s *models.UserSession) *primitive.ObjectID { return &s.ID },
+		}},
+		Usage: &sqlUsageRepo{t: &sqlTable[models.LLMUsage]{
+			db: db, name: "llm_usage", lock: lock,
+			columns: []string{"user_id", "created_at"},
+			values:  func(e *models.LLMUsage) []any { return []any{e.UserID, e.CreatedAt.UnixMilli()} },
+			id:      func(e *models.LLMUsage) *primitive.ObjectID { return &e.ID },
+		}},
+	}
+}
+
+// querier is a *sql.DB or *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// sqlTable reads and writes one model's table
+type sqlTable[T any] struct {
+	db   *sql.DB
+	name string
+	// lock is appended to the query reading rows for an update
+	lock string
+	// columns are the table's columns besides id and doc, and values their
+	// values for a record
+	columns []string
+	values  func(*T) []any
+	id      func(*T) *primitive.ObjectID
+}
+
+// placeholders returns n comma-separated placeholders starting at $from
+func placeholders(from, n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = fmt.Sprintf("$%d", from+i)
+	}
+	return strings.Join(ps, ", ")
+}
+
+// likePattern matches s as a case-insensitive substring in a LIKE clause
+// with ESCAPE '\'
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(s))
+	return "%" + s + "%"
+}
+
+// limitArg turns a zero limit into no limit
+func limitArg(limit int64) int64 {
+	if limit <= 0 {
+		return math.MaxInt64
+	}
+	return limit
+}
+
+// insert stores a new record, setting its ID, and returns how many rows it
+// added; onConflict may make conflicting inserts add none
+func (t *sqlTable[T]) insert(ctx context.Context, q querier, rec *T, onConflict string) (int64, error) {
+	id := t.id(rec)
+	if id.IsZero() {
+		*id = primitive.NewObjectID()
+	}
+	doc, err := bson.MarshalExtJSON(rec, true, false)
+	if err != nil {
+		return 0, err
+	}
+	cols := append([]string{"id", "doc"}, t.columns...)
+	args := append([]any{id.Hex(), string(doc)}, t.values(rec)...)
+	res, err := q.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)%s",
+		t.name, strings.Join(cols, ", "), placeholders(1, len(cols)), onConflict), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// find returns the records matching where, which may end in ORDER BY and LIMIT
+func (t *sqlTable[T]) find(ctx context.Context, q querier, where string, args ...any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, "SELECT doc FROM "+t.name+" WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	recs := []T{}
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var rec T
+		if err := bson.UnmarshalExtJSON([]byte(doc), true, &rec); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+// findOne returns the first record matching where, reporting whether there was one
+func (t *sqlTable[T]) findOne(ctx context.Context, where string, args ...any) (T, bool, error) {
+	recs, err := t.find(ctx, t.db, where+" LIMIT 1", args...)
+	if err != nil || len(recs) == 0 {
+		var zero T
+		return zero, false, err
+	}
+	return recs[0], true, nil
+}
+
+func (t *sqlTable[T]) count(ctx context.Context, where string, args ...any) (int64, error) {
+	var n int64
+	err := t.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+t.name+" WHERE "+where, args...).Scan(&n)
+	return n, err
+}
+
+func (t *sqlTable[T]) delete(ctx context.Context, q querier, where string, args ...any) (int64, error) {
+	res, err := q.ExecContext(ctx, "DELETE FROM "+t.name+" WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// update passes each record matching where to fn in one transaction and
+// writes back the ones fn reports changing, returning them. fn must use tx
+// rather than the database, which may allow only one connection.
+func (t *sqlTable[T]) update(ctx context.Context, where string, args []any, fn func(tx *sql.Tx, rec *T) (bool, error)) ([]T, error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	recs, err := t.find(ctx, tx, where+t.lock, args...)
+	if err != nil {
+		return nil, err
+	}
+	changed := []T{}
+	for i := range recs {
+		ok, err := fn(tx, &recs[i])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err := t.write(ctx, tx, &recs[i]); err != nil {
+			return nil, err
+		}
+		changed = append(changed, recs[i])
+	}
+	return changed, tx.Commit()
+}
+
+// write replaces a stored record
+func (t *sqlTable[T]) write(ctx context.Context, q querier, rec *T) error {
+	doc, err := bson.MarshalExtJSON(rec, true, false)
+	if err != nil {
+		return err
+	}
+	sets := []string{"doc = $1"}
+	for i, col := range t.columns {
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, i+2))
+	}
+	args := append(append([]any{string(doc)}, t.values(rec)...), t.id(rec).Hex())
+	_, err = q.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d",
+		t.name, strings.Join(sets, ", "), len(args)), args...)
+	return err
+}
+
+// setFields returns an update func applying set and unset
+func setFields[T any](set bson.M, unset []string) func(*sql.Tx, *T) (bool, error) {
+	return func(_ *sql.Tx, rec *T) (bool, error) {
+		return true, applyUpdate(rec, set, unset)
+	}
+}
+
+// sqlUserRepo keeps users in SQL, with sign-in accounts indexed in
+// user_oauth_accounts
+type sqlUserRepo struct {
+	t *sqlTable[models.User]
+}
+
+func (r *sqlUserRepo) Insert(ctx context.Context, user *models.User) error {
+	tx, err := r.t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := r.t.insert(ctx, tx, user, ""); err != nil {
+		return err
+	}
+	for _, account := range user.OAuthAccounts {
+		if err := insertOAuthAccount(ctx, tx, user.ID, account); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertOAuthAccount(ctx context.Context, q querier, userID primitive.ObjectID, account models.OAuthAccount) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO user_oauth_accounts (provider, subject, user_id) VALUES ($1, $2, $3)`,
+		account.Provider, account.Subject, userID.Hex())
+	return err
+}
+
+// one returns the first user matching where or ErrUserNotFound
+func (r *sqlUserRepo) one(ctx context.Context, where string, args ...any) (models.User, error) {
+	user, ok, err := r.t.findOne(ctx, where, args...)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *sqlUserRepo) Get(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return r.one(ctx, "id = $1", id.Hex())
+}
+
+func (r *sqlUserRepo) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.one(ctx, "email = $1", email)
+}
+
+func (r *sqlUserRepo) GetByOAuth(ctx context.Context, provider, subject string) (models.User, error) {
+	return r.one(ctx, "id = (SELECT user_id FROM user_oauth_accounts WHERE provider = $1 AND subject = $2)", provider, subject)
+}
+
+func (r *sqlUserRepo) List(ctx context.Context, search string, limit, skip int64) ([]models.User, error) {
+	where, args := "TRUE", []any{}
+	if search = strings.TrimSpace(search); search != "" {
+		where, args = `(LOWER(email) LIKE $1 ESCAPE '\' OR LOWER(name) LIKE $1 ESCAPE '\')`, []any{likePattern(search)}
+	}
+	n := len(args)
+	users, err := r.t.find(ctx, r.t.db, fmt.Sprintf("%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", where, n+1, n+2),
+		append(args, limitArg(limit), skip)...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Password, users[i].TwoFactorSecret, users[i].BackupCodes = "", "", nil
+	}
+	return users, nil
+}
+
+func (r *sqlUserRepo) Update(ctx context.Context, id primitive.ObjectID, set bson.M, unset ...string) error {
+	changed, err := r.t.update(ctx, "id = $1", []any{id.Hex()}, setFields[models.User](set, unset))
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *sqlUserRepo) AddTokensUsed(ctx context.Context, id primitive.ObjectID, tokens int) error {
+	_, err := r.t.update(ctx, "id = $1", []any{id.Hex()}, func(_ *sql.Tx, user *models.User) (bool, error) {
+		if user.TokenUsage == nil {
+			user.TokenUsage = &models.TokenUsage{}
+		}
+		user.TokenUsage.TokensUsed += int64(tokens)
+		return true, nil
+	})
+	return err
+}
+
+func (r *sqlUserRepo) LinkOAuth(ctx context.Context, email string, account models.OAuthAccount) (models.User, error) {
+	changed, err := r.t.update(ctx, "email = $1", []any{email}, func(tx *sql.Tx, user *models.User) (bool, error) {
+		if !user.EmailVerified {
+			return false, nil
+		}
+		user.OAuthAccounts = append(user.OAuthAccounts, account)
+		return true, insertOAuthAccount(ctx, tx, user.ID, account)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	if len(changed) == 0 {
+		return models.User{}, ErrUserNotFound
+	}
+	return changed[0], nil
+}
+
+func (r *sqlUserRepo) VerifyEmail(ctx context.Context, id primitive.ObjectID, email string) (models.User, error) {
+	changed, err := r.t.update(ctx, "id = $1 AND email = $2", []any{id.Hex(), email}, func(_ *sql.Tx, user *models.User) (bool, error) {
+		user.EmailVerified = true
+		return true, nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	if len(changed) == 0 {
+		return models.User{}, ErrUserNotFound
+	}
+	return changed[0], nil
+}
+
+func (r *sqlUserRepo) PromoteAdmins(ctx context.Context, emails []string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	args := []any{models.RoleAdmin}
+	for _, email := range emails {
+		args = append(args, email)
+	}
+	changed, err := r.t.update(ctx, fmt.Sprintf("role <> $1 AND email IN (%s)", placeholders(2, len(emails))), args,
+		func(_ *sql.Tx, user *models.User) (bool, error) {
+			if !user.EmailVerified {
+				return false, nil
+			}
+			user.Role = models.RoleAdmin
+			return true, nil
+		})
+	return int64(len(changed)), err
+}
+
+func (r *sqlUserRepo) Delete(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	tx, err := r.t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_oauth_accounts WHERE user_id = $1`, id.Hex()); err != nil {
+		return 0, err
+	}
+	n, err := r.t.delete(ctx, tx, "id = $1", id.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// sqlFeedRepo keeps feeds in SQL
+type sqlFeedRepo struct {
+	t *sqlTable[models.WebSocketFeed]
+}
+
+// sqlFeedOrders are the ORDER BY clauses of the feedListSort orders
+var sqlFeedOrders = map[string]string{
+	"":                  "created_at DESC, id DESC",
+	FeedSortRecent:      "created_at DESC, id DESC",
+	FeedSortSubscribers: "subscriber_count DESC, id DESC",
+	FeedSortName:        "name, id",
+}
+
+// where returns the filter as a WHERE clause and its arguments
+func (f FeedFilter) where() (string, []any) {
+	clauses, args := []string{"TRUE"}, []any{}
+	add := func(clause string, arg any) {
+		args = append(args, arg)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+	if f.Public {
+		clauses = append(clauses, "is_public = TRUE")
+	}
+	if f.OwnerID != "" {
+		if f.Unowned {
+			add("(owner_id = $%d OR owner_id = '' AND org_id = '')", f.OwnerID)
+		} else {
+			add("owner_id = $%d", f.OwnerID)
+		}
+	}
+	if f.OrgID != "" {
+		add("org_id = $%d", f.OrgID)
+	}
+	if f.Category != "" {
+		add("category = $%d", f.Category)
+	}
+	if f.Verified != nil {
+		add("is_verified = $%d", *f.Verified)
+	}
+	switch {
+	case !f.Deleted:
+		clauses = append(clauses, "deleted_at IS NULL")
+	case !f.DeletedBefore.IsZero():
+		add("deleted_at < $%d", f.DeletedBefore.UnixMilli())
+	default:
+		clauses = append(clauses, "deleted_at IS NOT NULL")
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+func (r *sqlFeedRepo) Insert(ctx context.Context, feed *models.WebSocketFeed) error {
+	_, err := r.t.insert(ctx, r.t.db, feed, "")
+	return err
+}
+
+func (r *sqlFeedRepo) Get(ctx context.Context, id primitive.ObjectID) (models.WebSocketFeed, error) {
+	feed, ok, err := r.t.findOne(ctx, "id = $1", id.Hex())
+	if err != nil {
+		return models.WebSocketFeed{}, err
+	}
+	if !ok {
+		return models.WebSocketFeed{}, ErrFeedNotFound
+	}
+	return feed, nil
+}
+
+func (r *sqlFeedRepo) Update(ctx context.Context, id primitive.ObjectID, set bson.M, unset ...string) error {
+	changed, err := r.t.update(ctx, "id = $1", []any{id.Hex()}, setFields[models.WebSocketFeed](set, unset))
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return ErrFeedNotFound
+	}
+	return nil
+}
+
+func (r *sqlFeedRepo) AddSubscribers(ctx context.Context, id primitive.ObjectID, delta int) error {
+	_, err := r.t.update(ctx, "id = $1", []any{id.Hex()}, func(_ *sql.Tx, feed *models.WebSocketFeed) (bool, error) {
+		feed.SubscriberCount += delta
+		return true, nil
+	})
+	return err
+}
+
+func (r *sqlFeedRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.t.delete(ctx, r.t.db, "id = $1", id.Hex())
+	return err
+}
+
+func (r *sqlFeedRepo) List(ctx context.Context, filter FeedFilter, sort string, limit, offset int64) ([]models.WebSocketFeed, error) {
+	order, ok := sqlFeedOrders[sort]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+	where, args := filter.where()
+	n := len(args)
+	return r.t.find(ctx, r.t.db, fmt.Sprintf("%s ORDER BY %s LIMIT $%d OFFSET $%d", where, order, n+1, n+2),
+		append(args, limitArg(limit), offset)...)
+}
+
+func (r *sqlFeedRepo) Count(ctx context.Context, filter FeedFilter) (int64, error) {
+	where, args := filter.where()
+	return r.t.count(ctx, where, args...)
+}
+
+// Search narrows the feeds down to those containing a word of the query in
+// SQL and ranks them like the memory repository
+func (r *sqlFeedRepo) Search(ctx context.Context, query, category string) ([]models.WebSocketFeed, error) {
+	where, args := FeedFilter{Category: category}.where()
+	var words []string
+	for _, word := range strings.Fields(query) {
+		args = append(args, likePattern(word))
+		n := len(args)
+		words = append(words, fmt.Sprintf(`LOWER(name) LIKE $%d ESCAPE '\' OR LOWER(tags) LIKE $%d ESCAPE '\' OR LOWER(description) LIKE $%d ESCAPE '\'`, n, n, n))
+	}
+	if len(words) == 0 {
+		return []models.WebSocketFeed{}, nil
+	}
+	candidates, err := r.t.find(ctx, r.t.db, where+" AND ("+strings.Join(words, " OR ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	return rankFeeds(candidates, query), nil
+}
+
+func (r *sqlFeedRepo) DeleteByOwner(ctx context.Context, ownerID string) (int64, error) {
+	return r.t.delete(ctx, r.t.db, "owner_id = $1", ownerID)
+}
+
+// sqlSubscriptionRepo keeps subscriptions in SQL, one per user and feed
+type sqlSubscriptionRepo struct {
+	t *sqlTable[models.UserSubscription]
+}
+
+func (r *sqlSubscriptionRepo) Insert(ctx context.Context, sub *models.UserSubscription) error {
+	n, err := r.t.insert(ctx, r.t.db, sub, " ON CONFLICT (user_id, feed_id) DO NOTHING")
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSubscriptionExists
+	}
+	return nil
+}
+
+func (r *sqlSubscriptionRepo) Get(ctx context.Context, userID, feedID string) (models.UserSubscription, error) {
+	sub, ok, err := r.t.findOne(ctx, "user_id = $1 AND feed_id = $2 AND is_active = TRUE", userID, feedID)
+	if err != nil {
+		return models.UserSubscription{}, err
+	}
+	if !ok {
+		return models.UserSubscription{}, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (r *sqlSubscriptionRepo) ListByUser(ctx context.Context, userID string) ([]models.UserSubscription, error) {
+	return r.t.find(ctx, r.t.db, "user_id = $1 ORDER BY id", userID)
+}
+
+func (r *sqlSubscriptionRepo) Reactivate(ctx context.Context, userID, feedID, customPrompt string, expiresAt *time.Time) error {
+	_, err := r.t.update(ctx, "user_id = $1 AND feed_id = $2", []any{userID, feedID}, func(_ *sql.Tx, sub *models.UserSubscription) (bool, error) {
+		sub.IsActive = true
+		sub.CustomPrompt = customPrompt
+		sub.ExpiresAt = expiresAt
+		return true, nil
+	})
+	return err
+}
+
+func (r *sqlSubscriptionRepo) Deactivate(ctx context.Context, userID, feedID string) (bool, error) {
+	changed, err := r.t.update(ctx, "user_id = $1 AND feed_id = $2 AND is_active = TRUE", []any{userID, feedID}, func(_ *sql.Tx, sub *models.UserSubscription) (bool, error) {
+		sub.IsActive = false
+		return true, nil
+	})
+	return len(changed) > 0, err
+}
+
+func (r *sqlSubscriptionRepo) SetExpiry(ctx context.Context, userID, feedID string, expiresAt *time.Time) error {
+	changed, err := r.t.update(ctx, "user_id = $1 AND feed_id = $2 AND is_active = TRUE", []any{userID, feedID}, func(_ *sql.Tx, sub *models.UserSubscription) (bool, error) {
+		sub.ExpiresAt = expiresAt
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *sqlSubscriptionRepo) Update(ctx context.Context, userID, feedID string, set bson.M) error {
+	_, err := r.t.update(ctx, "user_id = $1 AND feed_id = $2", []any{userID, feedID}, setFields[models.UserSubscription](set, nil))
+	return err
+}
+
+func (r *sqlSubscriptionRepo) ListExpired(ctx context.Context, now time.Time) ([]models.UserSubscription, error) {
+	return r.t.find(ctx, r.t.db, "is_active = TRUE AND expires_at <= $1 ORDER BY id", now.UnixMilli())
+}
+
+func (r *sqlSubscriptionRepo) Expire(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	changed, err := r.t.update(ctx, "id = $1 AND is_active = TRUE AND expires_at <= $2", []any{id.Hex(), now.UnixMilli()}, func(_ *sql.Tx, sub *models.UserSubscription) (bool, error) {
+		sub.IsActive = false
+		return true, nil
+	})
+	return len(changed) > 0, err
+}
+
+func (r *sqlSubscriptionRepo) CountActive(ctx context.Context, feedID string) (int64, error) {
+	return r.t.count(ctx, "feed_id = $1 AND is_active = TRUE", feedID)
+}
+
+func (r *sqlSubscriptionRepo) ActiveUserIDs(ctx context.Context, feedID string) ([]string, error) {
+	subs, err := r.t.find(ctx, r.t.db, "feed_id = $1 AND is_active = TRUE ORDER BY id", feedID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.UserID)
+	}
+	return ids, nil
+}
+
+func (r *sqlSubscriptionRepo) DeleteByFeed(ctx context.Context, feedID string) error {
+	_, err := r.t.delete(ctx, r.t.db, "feed_id = $1", feedID)
+	return err
+}
+
+func (r *sqlSubscriptionRepo) FeedIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.t.db.QueryContext(ctx, `SELECT DISTINCT feed_id FROM user_subscriptions ORDER BY feed_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *sqlSubscriptionRepo) DeleteByUser(ctx context.Context, userID string, feedIDs []string) (int64, error) {
+	where, args := "user_id = $1", []any{userID}
+	if len(feedIDs) > 0 {
+		where += fmt.Sprintf(" OR feed_id IN (%s)", placeholders(2, len(feedIDs)))
+		for _, id := range feedIDs {
+			args = append(args, id)
+		}
+	}
+	return r.t.delete(ctx, r.t.db, where, args...)
+}
+
+// sqlSessionRepo keeps sessions in SQL
+type sqlSessionRepo struct {
+	t *sqlTable[models.UserSession]
+}
+
+func (r *sqlSessionRepo) Insert(ctx context.Context, session *models.UserSession) error {
+	_, err := r.t.insert(ctx, r.t.db, session, "")
+	return err
+}
+
+func (r *sqlSessionRepo) Rotate(ctx context.Context, hash, nextHash string, now, expiresAt time.Time) (models.UserSession, error) {
+	changed, err := r.t.update(ctx, "refresh_token_hash = $1 AND is_active = TRUE AND expires_at > $2 LIMIT 1", []any{hash, now.UnixMilli()},
+		func(_ *sql.Tx, session *models.UserSession) (bool, error) {
+			session.RefreshTokenHash = nextHash
+			session.PrevRefreshTokenHash = hash
+			session.LastActive = now
+			session.ExpiresAt = expiresAt
+			return true, nil
+		})
+	if err != nil {
+		return models.UserSession{}, err
+	}
+	if len(changed) == 0 {
+		return models.UserSession{}, ErrSessionNotFound
+	}
+	return changed[0], nil
+}
+
+func (r *sqlSessionRepo) RevokeReplayed(ctx context.Context, hash string) error {
+	_, err := r.t.update(ctx, "prev_refresh_token_hash = $1 AND is_active = TRUE LIMIT 1", []any{hash}, revokeSession)
+	return err
+}
+
+// revokeSession is an update func ending a session
+func revokeSession(_ *sql.Tx, session *models.UserSession) (bool, error) {
+	session.IsActive = false
+	return true, nil
+}
+
+func (r *sqlSessionRepo) Active(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+	n, err := r.t.count(ctx, "id = $1 AND user_id = $2 AND is_active = TRUE", id.Hex(), userID.Hex())
+	return n > 0, err
+}
+
+func (r *sqlSessionRepo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.UserSession, error) {
+	return r.t.find(ctx, r.t.db, "user_id = $1 ORDER BY id", userID.Hex())
+}
+
+func (r *sqlSessionRepo) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	_, err := r.t.update(ctx, "id = $1 AND user_id = $2", []any{id.Hex(), userID.Hex()}, revokeSession)
+	return err
+}
+
+func (r *sqlSessionRepo) RevokeAll(ctx context.Context, userID, except primitive.ObjectID) (int64, error) {
+	changed, err := r.t.update(ctx, "user_id = $1 AND id <> $2 AND is_active = TRUE", []any{userID.Hex(), except.Hex()}, revokeSession)
+	return int64(len(changed)), err
+}
+
+func (r *sqlSessionRepo) DeleteByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.t.delete(ctx, r.t.db, "user_id = $1", userID.Hex())
+}
+
+// sqlUsageRepo keeps the usage ledger in SQL
+type sqlUsageRepo struct {
+	t *sqlTable[models.LLMUsage]
+}
+
+func (r *sqlUsageRepo) Insert(ctx context.Context, entry *models.LLMUsage) error {
+	_, err := r.t.insert(ctx, r.t.db, entry, "")
+	return err
+}
+
+func (r *sqlUsageRepo) Summarize(ctx context.Context, userID, groupBy string, from, to time.Time) ([]models.LLMUsageGroup, error) {
+	entries, err := r.t.find(ctx, r.t.db, "user_id = $1 AND created_at >= $2 AND created_at <= $3", userID, from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	return groupUsage(entries, groupBy)
+}
+
+func (r *sqlUsageRepo) ListByUser(ctx context.Context, userID string) ([]models.LLMUsage, error) {
+	return r.t.find(ctx, r.t.db, "user_id = $1 ORDER BY id", userID)
+}
+
+func (r *sqlUsageRepo) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+	return r.t.delete(ctx, r.t.db, "user_id = $1", userID)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/db"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// newSQLiteStore returns a store in a fresh SQLite file
+func newSQLiteStore(t *testing.T) Store {
+	t.Helper()
+	ctx := context.Background()
+	sqlDB, err := db.OpenSQL(ctx, "sqlite", filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	applied, err := db.MigrateSQL(ctx, sqlDB, db.SQLMigrations)
+	require.NoError(t, err)
+	require.Equal(t, len(db.SQLMigrations), applied)
+	// Migrating again is a no-op
+	applied, err = db.MigrateSQL(ctx, sqlDB, db.SQLMigrations)
+	require.NoError(t, err)
+	require.Zero(t, applied)
+	return NewSQLStore(sqlDB, "sqlite")
+}
+
+func TestSQLUserRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteStore(t).Users
+
+	quota := int64(500)
+	user := models.User{
+		Email:              "ada@example.com",
+		Name:               "Ada",
+		Password:           "hash",
+		CreatedAt:          time.Now(),
+		TokenUsage:         &models.TokenUsage{CurrentMonth: "2026-01", Limit: 1000},
+		TokenQuotaOverride: &quota,
+	}
+	require.NoError(t, repo.Insert(ctx, &user))
+	require.False(t, user.ID.IsZero())
+	require.NoError(t, repo.Insert(ctx, &models.User{Email: "bob@example.com", Name: "Bob_", CreatedAt: time.Now().Add(-time.Hour)}))
+
+	require.NoError(t, repo.Update(ctx, user.ID, bson.M{"tokenUsage.currentMonth": "2026-02", "role": models.RoleCurator}, "tokenQuotaOverride"))
+	require.NoError(t, repo.AddTokensUsed(ctx, user.ID, 42))
+	got, err := repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02", got.TokenUsage.CurrentMonth)
+	assert.Equal(t, int64(42), got.TokenUsage.TokensUsed)
+	assert.Equal(t, models.RoleCurator, got.Role)
+	assert.Nil(t, got.TokenQuotaOverride)
+	assert.Equal(t, user.CreatedAt.UnixMilli(), got.CreatedAt.UnixMilli())
+
+	// Only verified emails are linked
+	_, err = repo.LinkOAuth(ctx, "ada@example.com", models.OAuthAccount{Provider: "github", Subject: "123"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = repo.GetByOAuth(ctx, "github", "123")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = repo.VerifyEmail(ctx, user.ID, "ada@example.com")
+	require.NoError(t, err)
+	linked, err := repo.LinkOAuth(ctx, "ada@example.com", models.OAuthAccount{Provider: "github", Subject: "123"})
+	require.NoError(t, err)
+	assert.True(t, linked.EmailVerified)
+	got, err = repo.GetByOAuth(ctx, "github", "123")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	_, err = repo.GetByOAuth(ctx, "github", "456")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.ErrorIs(t, repo.Update(ctx, primitive.NewObjectID(), bson.M{"name": "x"}), ErrUserNotFound)
+
+	// Search is case-insensitive and treats LIKE wildcards literally
+	users, err := repo.List(ctx, "ADA", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Empty(t, users[0].Password)
+	users, err = repo.List(ctx, "_", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "Bob_", users[0].Name)
+	users, err = repo.List(ctx, "", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "Bob_", users[0].Name)
+
+	n, err := repo.PromoteAdmins(ctx, []string{"ada@example.com", "bob@example.com", "nobody@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "bob's email is not verified")
+	n, err = repo.PromoteAdmins(ctx, []string{"ada@example.com"})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = repo.Delete(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = repo.GetByOAuth(ctx, "github", "123")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestSQLFeedRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteStore(t).Feeds
+	now := time.Now()
+	verified := true
+	for _, feed := range []models.WebSocketFeed{
+		{Name: "Bravo", IsPublic: true, SubscriberCount: 5, CreatedAt: now.Add(-time.Hour), Tags: []string{"crypto"}},
+		{Name: "Alpha", IsPublic: true, IsVerified: true, SubscriberCount: 1, CreatedAt: now, Description: "bitcoin prices"},
+		{Name: "Charlie", OwnerID: "owner", SubscriberCount: 9, CreatedAt: now.Add(-2 * time.Hour)},
+	} {
+		require.NoError(t, repo.Insert(ctx, &feed))
+	}
+
+	names := func(filter FeedFilter, sort string, limit, offset int64) []string {
+		feeds, err := repo.List(ctx, filter, sort, limit, offset)
+		require.NoError(t, err)
+		var out []string
+		for _, f := range feeds {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(FeedFilter{}, FeedSortRecent, 0, 0))
+	assert.Equal(t, []string{"Charlie", "Bravo", "Alpha"}, names(FeedFilter{}, FeedSortSubscribers, 0, 0))
+	assert.Equal(t, []string{"Bravo"}, names(FeedFilter{Public: true}, FeedSortName, 1, 1))
+	assert.Equal(t, []string{"Alpha"}, names(FeedFilter{Verified: &verified}, "", 0, 0))
+	assert.Equal(t, []string{"Charlie"}, names(FeedFilter{OwnerID: "owner"}, FeedSortName, 0, 0))
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(FeedFilter{OwnerID: "owner", Unowned: true}, FeedSortName, 0, 0))
+
+	// Organization feeds are neither personal nor legacy unowned feeds
+	org := models.WebSocketFeed{Name: "Delta", OrgID: "org", CreatedAt: now}
+	require.NoError(t, repo.Insert(ctx, &org))
+	assert.Equal(t, []string{"Delta"}, names(FeedFilter{OrgID: "org"}, "", 0, 0))
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(FeedFilter{OwnerID: "owner", Unowned: true}, FeedSortName, 0, 0))
+	require.NoError(t, repo.Delete(ctx, org.ID))
+
+	// Deleted feeds only match filters asking for them
+	deletedAt := now.Add(-time.Hour)
+	gone := models.WebSocketFeed{Name: "Echo", IsPublic: true, CreatedAt: now, DeletedAt: &deletedAt}
+	require.NoError(t, repo.Insert(ctx, &gone))
+	assert.Equal(t, []string{"Alpha", "Bravo"}, names(FeedFilter{Public: true}, FeedSortName, 0, 0))
+	assert.Equal(t, []string{"Echo"}, names(FeedFilter{Deleted: true}, "", 0, 0))
+	assert.Equal(t, []string{"Echo"}, names(FeedFilter{Deleted: true, DeletedBefore: now}, "", 0, 0))
+	assert.Empty(t, names(FeedFilter{Deleted: true, DeletedBefore: deletedAt.Add(-time.Minute)}, "", 0, 0))
+	hits, searchErr := repo.Search(ctx, "echo", "")
+	require.NoError(t, searchErr)
+	assert.Empty(t, hits)
+	require.NoError(t, repo.Delete(ctx, gone.ID))
+	_, err := repo.List(ctx, FeedFilter{}, "bogus", 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidSort)
+
+	count, err := repo.Count(ctx, FeedFilter{Public: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// Name matches outrank tag matches, which outrank descriptions
+	found, err := repo.Search(ctx, "CRYPTO bitcoin alpha", "")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "Alpha", found[0].Name)
+	assert.Equal(t, "Bravo", found[1].Name)
+
+	feed := found[1]
+	require.NoError(t, repo.Update(ctx, feed.ID, bson.M{"subscriberCount": 30}))
+	require.NoError(t, repo.AddSubscribers(ctx, feed.ID, -1))
+	assert.Equal(t, []string{"Bravo", "Charlie", "Alpha"}, names(FeedFilter{}, FeedSortSubscribers, 0, 0))
+	assert.ErrorIs(t, repo.Update(ctx, primitive.NewObjectID(), bson.M{"name": "x"}), ErrFeedNotFound)
+
+	n, err := repo.DeleteByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	require.NoError(t, repo.Delete(ctx, feed.ID))
+	_, err = repo.Get(ctx, feed.ID)
+	assert.ErrorIs(t, err, ErrFeedNotFound)
+}
+
+func TestSQLSubscriptionRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteStore(t).Subscriptions
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	sub := models.UserSubscription{UserID: "u1", FeedID: "f1", IsActive: true, ExpiresAt: &past}
+	require.NoError(t, repo.Insert(ctx, &sub))
+	assert.ErrorIs(t, repo.Insert(ctx, &models.UserSubscription{UserID: "u1", FeedID: "f1"}), ErrSubscriptionExists)
+	require.NoError(t, repo.Insert(ctx, &models.UserSubscription{UserID: "u2", FeedID: "f1", IsActive: true}))
+
+	ids, err := repo.ActiveUserIDs(ctx, "f1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2"}, ids)
+
+	expired, err := repo.ListExpired(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, sub.ID, expired[0].ID)
+	ok, err := repo.Expire(ctx, sub.ID, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.Expire(ctx, sub.ID, now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = repo.Get(ctx, "u1", "f1")
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	assert.ErrorIs(t, repo.SetExpiry(ctx, "u1", "f1", nil), ErrSubscriptionNotFound)
+
+	require.NoError(t, repo.Reactivate(ctx, "u1", "f1", "summarize", nil))
+	require.NoError(t, repo.Update(ctx, "u1", "f1", bson.M{"settings": models.SubscriptionSettings{}}))
+	got, err := repo.Get(ctx, "u1", "f1")
+	require.NoError(t, err)
+	assert.Equal(t, "summarize", got.CustomPrompt)
+	assert.Nil(t, got.ExpiresAt)
+	assert.NotNil(t, got.Settings)
+	expired, err = repo.ListExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	ok, err = repo.Deactivate(ctx, "u2", "f1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	active, err := repo.CountActive(ctx, "f1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), active)
+
+	require.NoError(t, repo.Insert(ctx, &models.UserSubscription{UserID: "u1", FeedID: "f0"}))
+	feedIDs, err := repo.FeedIDs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f0", "f1"}, feedIDs)
+
+	n, err := repo.DeleteByUser(ctx, "u3", []string{"f1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestSQLSessionRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteStore(t).Sessions
+	now := time.Now()
+	userID := primitive.NewObjectID()
+
+	session := models.UserSession{UserID: userID, IsActive: true, RefreshTokenHash: "a", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Insert(ctx, &session))
+	other := models.UserSession{UserID: userID, IsActive: true, RefreshTokenHash: "x", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Insert(ctx, &other))
+
+	rotated, err := repo.Rotate(ctx, "a", "b", now, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, rotated.ID)
+	assert.Equal(t, "b", rotated.RefreshTokenHash)
+	assert.Equal(t, "a", rotated.PrevRefreshTokenHash)
+	// The old token is spent; replaying it revokes the session
+	_, err = repo.Rotate(ctx, "a", "c", now, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	require.NoError(t, repo.RevokeReplayed(ctx, "a"))
+	active, err := repo.Active(ctx, userID, session.ID)
+	require.NoError(t, err)
+	assert.False(t, active)
+
+	active, err = repo.Active(ctx, primitive.NewObjectID(), other.ID)
+	require.NoError(t, err)
+	assert.False(t, active)
+	n, err := repo.RevokeAll(ctx, userID, primitive.NilObjectID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	sessions, err := repo.ListByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	n, err = repo.DeleteByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestSQLUsageRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteStore(t).Usage
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, e := range []models.LLMUsage{
+		{UserID: "u1", FeedID: "f1", Provider: "openai", PromptTokens: 10, CreatedAt: day},
+		{UserID: "u1", FeedID: "f1", Provider: "openai", PromptTokens: 5, CreatedAt: day.Add(24 * time.Hour)},
+		{UserID: "u1", FeedID: "f2", Provider: "openai", PromptTokens: 7, CreatedAt: day.AddDate(0, 1, 0)},
+		{UserID: "u2", FeedID: "f1", Provider: "openai", PromptTokens: 99, CreatedAt: day},
+	} {
+		require.NoError(t, repo.Insert(ctx, &e))
+	}
+
+	groups, err := repo.Summarize(ctx, "u1", UsageGroupDay, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "2026-03-01", groups[0].Key)
+	assert.Equal(t, 10, groups[0].TotalTokens)
+
+	entries, err := repo.ListByUser(ctx, "u1")
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	n, err := repo.DeleteByUser(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// embeddingBatchSize caps the texts sent in one embedding call
+const embeddingBatchSize = 256
+
+// retrievalIndex keeps the embeddings of each feed's context entries, so
+// each entry is embedded once rather than on every question, and picks the
+// entries most relevant to a question
+type retrievalIndex struct {
+	embedder      Embedder
+	minEntries    int
+	topK          int
+	minSimilarity float64
+
+	mu      sync.Mutex
+	vectors map[string]map[uint64][]float32 // feed ID -> entry content hash -> embedding
+}
+
+// newRetrievalIndex returns the configured retrieval index, or nil when
+// retrieval is disabled or its embedder can't be set up
+func newRetrievalIndex(cfg config.Config) *retrievalIndex {
+	if cfg.LLMRetrievalMinEntries <= 0 || cfg.LLMRetrievalTopK <= 0 {
+		return nil
+	}
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		slog.Warn("retrieval disabled", "error", err)
+		return nil
+	}
+	slog.Info("retrieval enabled", "embedder", embedder.Name(), "top_k", cfg.LLMRetrievalTopK, "min_entries", cfg.LLMRetrievalMinEntries)
+	return &retrievalIndex{
+		embedder:      embedder,
+		minEntries:    cfg.LLMRetrievalMinEntries,
+		topK:          cfg.LLMRetrievalTopK,
+		minSimilarity: cfg.LLMRetrievalMinSimilarity,
+		vectors:       make(map[string]map[uint64][]float32),
+	}
+}
+
+// applies reports whether a context of n entries is large enough to send
+// only the relevant ones
+func (r *retrievalIndex) applies(n int) bool {
+	return r != nil && n >= r.minEntries && n > r.topK
+}
+
+// forget drops a feed's embeddings
+func (r *retrievalIndex) forget(feedID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.vectors, feedID)
+}
+
+// retrieve returns the newest entry and the topK-1 others most similar to
+// the question, skipping those below minSimilarity, newest first
+func (r *retrievalIndex) retrieve(ctx context.Context, feedID, question string, entries []map[string]interface{}) ([]map[string]interface{}, error) {
+	texts := make([]string, len(entries))
+	keys := make([]uint64, len(entries))
+	for i, entry := range entries {
+		texts[i] = entryText(entry)
+		h := fnv.New64a()
+		h.Write([]byte(texts[i]))
+		keys[i] = h.Sum64()
+	}
+
+	vectors := make([][]float32, len(entries))
+	var missing []int
+	r.mu.Lock()
+	known := r.vectors[feedID]
+	for i, key := range keys {
+		if v, ok := known[key]; ok {
+			vectors[i] = v
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	r.mu.Unlock()
+
+	// The question is embedded along with the entries not seen before
+	batch := make([]string, 0, len(missing)+1)
+	for _, i := range missing {
+		batch = append(batch, texts[i])
+	}
+	batch = append(batch, question)
+	embedded, err := r.embed(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		vectors[i] = embedded[j]
+	}
+	questionVector := embedded[len(embedded)-1]
+
+	// Only the entries still in context are kept
+	current := make(map[uint64][]float32, len(entries))
+	for i, key := range keys {
+		current[key] = vectors[i]
+	}
+	r.mu.Lock()
+	r.vectors[feedID] = current
+	r.mu.Unlock()
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var candidates []scored
+	for i := 1; i < len(entries); i++ {
+		if score := cosineSimilarity(questionVector, vectors[i]); score >= r.minSimilarity {
+			candidates = append(candidates, scored{i, score})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	if len(candidates) > r.topK-1 {
+		candidates = candidates[:r.topK-1]
+	}
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].index < candidates[b].index })
+
+	// The newest entry always goes, so questions about the current state work
+	selected := make([]map[string]interface{}, 0, len(candidates)+1)
+	selected = append(selected, entries[0])
+	for _, c := range candidates {
+		selected = append(selected, entries[c.index])
+	}
+	return selected, nil
+}
+
+// embed embeds texts in batches the embedding APIs accept
+func (r *retrievalIndex) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(texts))
+		batch, err := r.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// entryText renders a context entry for embedding, without its timestamp so
+// identical messages share an embedding
+func entryText(entry map[string]interface{}) string {
+	data := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		if k != "_timestamp" {
+			data[k] = v
+		}
+	}
+	text, _ := json.Marshal(data)
+	return string(text)
+}
+
+// contextEntries returns the entries a query sends: all of them, or for a
+// large context the newest one and those most relevant to the question. The
+// count is of entries picked by relevance, 0 when retrieval wasn't used.
+func (s *LLMService) contextEntries(ctx context.Context, req QueryRequest, entries []map[string]interface{}) ([]map[string]interface{}, int) {
+	if !s.retrieval.applies(len(entries)) {
+		return entries, 0
+	}
+	retrieved, err := s.retrieval.retrieve(ctx, req.FeedID, req.Question, entries)
+	if err != nil {
+		slog.WarnContext(ctx, "retrieval failed, sending the newest entries", "feed_id", req.FeedID, "error", err)
+		return entries[:s.retrieval.topK], 0
+	}
+	return retrieved, len(retrieved)
+}
+
+// contextHeader introduces the feed data in a query's prompt
+func contextHeader(retrieved int) string {
+	if retrieved > 0 {
+		return "Here are the latest entry and the entries of the streaming data most relevant to the question (newest first):"
+	}
+	return "Here is the recent streaming data (newest first):"
+}
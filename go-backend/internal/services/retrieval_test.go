@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// countingEmbedder embeds locally and counts the texts it was given
+type countingEmbedder struct {
+	localEmbedder
+	texts int
+	err   error
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.texts += len(texts)
+	return e.localEmbedder.Embed(ctx, texts)
+}
+
+// symbolEntries returns quotes newest first, alternating between BTC and ETH
+func symbolEntries(n int) []map[string]interface{} {
+	entries := make([]map[string]interface{}, n)
+	for i := range entries {
+		symbol := "BTC"
+		if i%2 == 1 {
+			symbol = "ETH"
+		}
+		entries[i] = map[string]interface{}{"symbol": symbol, "price": float64(1000 + i), "_timestamp": "2026-03-01T12:00:00Z"}
+	}
+	return entries
+}
+
+func TestLocalEmbedder(t *testing.T) {
+	vectors, err := localEmbedder{}.Embed(context.Background(), []string{
+		"What is the ETH price?",
+		`{"price":1001,"symbol":"ETH"}`,
+		`{"bid":99,"venue":"kraken"}`,
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Greater(t, cosineSimilarity(vectors[0], vectors[1]), cosineSimilarity(vectors[0], vectors[2]))
+	assert.InDelta(t, 1.0, cosineSimilarity(vectors[1], vectors[1]), 1e-6)
+	assert.Zero(t, cosineSimilarity(vectors[0], []float32{1, 2}))
+}
+
+func TestRetrievalIndex_Retrieve(t *testing.T) {
+	embedder := &countingEmbedder{}
+	r := &retrievalIndex{embedder: embedder, minEntries: 10, topK: 4, minSimilarity: 0.1, vectors: map[string]map[uint64][]float32{}}
+	entries := symbolEntries(12)
+
+	selected, err := r.retrieve(context.Background(), "feed-1", "ETH price", entries)
+	require.NoError(t, err)
+	require.Len(t, selected, 4)
+	// The newest entry always leads, then the ETH quotes newest first
+	assert.Equal(t, "BTC", selected[0]["symbol"])
+	for i, entry := range selected[1:] {
+		assert.Equal(t, "ETH", entry["symbol"], i)
+	}
+	assert.Less(t, selected[1]["price"], selected[2]["price"])
+	// Every entry and the question were embedded once
+	assert.Equal(t, 12+1, embedder.texts)
+
+	// Known entries aren't embedded again, only the question
+	_, err = r.retrieve(context.Background(), "feed-1", "BTC price", entries)
+	require.NoError(t, err)
+	assert.Equal(t, 12+2, embedder.texts)
+
+	r.forget("feed-1")
+	assert.Empty(t, r.vectors)
+}
+
+func TestRetrievalIndex_Applies(t *testing.T) {
+	var disabled *retrievalIndex
+	assert.False(t, disabled.applies(1000))
+
+	r := &retrievalIndex{minEntries: 100, topK: 30}
+	assert.False(t, r.applies(99))
+	assert.True(t, r.applies(100))
+
+	assert.Nil(t, newRetrievalIndex(config.Config{LLMRetrievalMinEntries: 0, LLMRetrievalTopK: 30}))
+	assert.Nil(t, newRetrievalIndex(config.Config{LLMRetrievalMinEntries: 100, LLMRetrievalTopK: 30, LLMRetrievalEmbedder: "openai"}))
+}
+
+func TestLLMService_QueryWithRetrieval(t *testing.T) {
+	provider := &cannedProvider{name: "anthropic", answer: "ETH is at 1001."}
+	svc := newStructuredLLMService(t, provider)
+	svc.retrieval = &retrievalIndex{embedder: localEmbedder{}, minEntries: 10, topK: 3, minSimilarity: 0.1, vectors: map[string]map[uint64][]float32{}}
+	svc.feedContexts["feed-1"].Entries = symbolEntries(20)
+
+	resp, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest ETH quotes?"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.Retrieved)
+	prompt := provider.messages[len(provider.messages)-1].Content
+	assert.Contains(t, prompt, "most relevant to the question")
+	assert.Contains(t, prompt, "# Count: 3")
+
+	// Without an embedding the newest entries are sent instead
+	svc.retrieval.embedder = &countingEmbedder{err: errors.New("embeddings unavailable")}
+	resp, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "Latest BTC quotes?"})
+	require.NoError(t, err)
+	assert.Zero(t, resp.Retrieved)
+	prompt = provider.messages[len(provider.messages)-1].Content
+	assert.Contains(t, prompt, "Here is the recent streaming data")
+	assert.Contains(t, prompt, "# Count: 3")
+	entries, retrieved := svc.contextEntries(context.Background(), QueryRequest{FeedID: "feed-1"}, svc.feedContexts["feed-1"].Entries)
+	assert.Zero(t, retrieved)
+	assert.Equal(t, svc.feedContexts["feed-1"].Entries[:3], entries)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// maxSchemaFields caps how many fields one inferred schema lists
+	maxSchemaFields = 100
+	// maxSchemaDepth limits how deep nested objects are walked
+	maxSchemaDepth = 4
+	// maxSchemaArrayItems is how many items of each array are inspected
+	maxSchemaArrayItems = 10
+	// maxSchemaExampleLen truncates long string examples
+	maxSchemaExampleLen = 80
+)
+
+// schemaFieldStats accumulates what was observed for one field path
+type schemaFieldStats struct {
+	types   map[string]bool
+	example interface{}
+	seen    int // messages the field appeared in
+}
+
+// SchemaBuilder infers a schema from decoded feed messages. Messages are
+// walked as they are added, so later changes to them do not affect the schema.
+type SchemaBuilder struct {
+	stats   map[string]*schemaFieldStats
+	samples int
+}
+
+// NewSchemaBuilder returns an empty schema builder
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{stats: make(map[string]*schemaFieldStats)}
+}
+
+// Add records the fields of one message. Messages that are not objects or
+// arrays of objects count as samples but contribute no fields.
+func (b *SchemaBuilder) Add(msg interface{}) {
+	present := make(map[string]bool)
+	walkSchemaValue(msg, "", 0, b.stats, present)
+	for path := range present {
+		b.stats[path].seen++
+	}
+	b.samples++
+}
+
+// Samples returns how many messages have been added
+func (b *SchemaBuilder) Samples() int {
+	return b.samples
+}
+
+// Schema returns the inferred schema with fields sorted by path. A field is
+// optional when some messages lack it.
+func (b *SchemaBuilder) Schema(now time.Time) models.FeedSchema {
+	paths := make([]string, 0, len(b.stats))
+	for path := range b.stats {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if len(paths) > maxSchemaFields {
+		paths = paths[:maxSchemaFields]
+	}
+
+	fields := make([]models.SchemaField, 0, len(paths))
+	for _, path := range paths {
+		st := b.stats[path]
+		types := make([]string, 0, len(st.types))
+		for t := range st.types {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		fields = append(fields, models.SchemaField{
+			Path:     path,
+			Types:    types,
+			Example:  st.example,
+			Optional: st.seen < b.samples,
+		})
+	}
+	return models.FeedSchema{Fields: fields, SampleCount: b.samples, InferredAt: now}
+}
+
+// walkSchemaValue records the fields of an object, or of the objects in an
+// array, under prefix
+func walkSchemaValue(value interface{}, prefix string, depth int, stats map[string]*schemaFieldStats, present map[string]bool) {
+	if depth >= maxSchemaDepth {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			recordSchemaField(path, child, stats, present)
+			walkSchemaValue(child, path, depth+1, stats, present)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if i == maxSchemaArrayItems {
+				break
+			}
+			walkSchemaValue(item, prefix+"[]", depth+1, stats, present)
+		}
+	}
+}
+
+// recordSchemaField adds one observation of value at path
+func recordSchemaField(path string, value interface{}, stats map[string]*schemaFieldStats, present map[string]bool) {
+	st, ok := stats[path]
+	if !ok {
+		st = &schemaFieldStats{types: make(map[string]bool)}
+		stats[path] = st
+	}
+	st.types[jsonType(value)] = true
+	if st.example == nil {
+		st.example = schemaExample(value)
+	}
+	present[path] = true
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int32, int64, json.Number:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// schemaExample returns value as an example when it is a scalar, truncating
+// long strings; objects, arrays and nulls have no example
+func schemaExample(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, map[string]interface{}, []interface{}:
+		return nil
+	case string:
+		if r := []rune(v); len(r) > maxSchemaExampleLen {
+			return string(r[:maxSchemaExampleLen]) + "…"
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// SchemaSignature identifies a schema's fields and types, ignoring examples,
+// so callers can tell when a feed's shape has changed
+func SchemaSignature(schema *models.FeedSchema) string {
+	if schema == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(schema.Fields))
+	for _, f := range schema.Fields {
+		parts = append(parts, f.Path+":"+strings.Join(f.Types, "|"))
+	}
+	return strings.Join(parts, ",")
+}
+
+// describeSchema renders a schema as a field list for LLM prompts
+func describeSchema(schema *models.FeedSchema) string {
+	if schema == nil || len(schema.Fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Fields in this feed's messages (path, type, example):\n")
+	for _, f := range schema.Fields {
+		fmt.Fprintf(&b, "- %s (%s", f.Path, strings.Join(f.Types, " or "))
+		if f.Optional {
+			b.WriteString(", optional")
+		}
+		b.WriteString(")")
+		if f.Example != nil {
+			example, err := json.Marshal(f.Example)
+			if err == nil {
+				fmt.Fprintf(&b, ", e.g. %s", example)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
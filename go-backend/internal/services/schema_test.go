@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestSchemaBuilder(t *testing.T) {
+	b := NewSchemaBuilder()
+	b.Add(map[string]interface{}{
+		"symbol": "BTC",
+		"price":  42.5,
+		"meta":   map[string]interface{}{"exchange": "x", "live": true},
+		"trades": []interface{}{
+			map[string]interface{}{"qty": 1.0},
+			map[string]interface{}{"qty": 2.0, "side": "buy"},
+		},
+	})
+	b.Add(map[string]interface{}{"symbol": "ETH", "price": nil})
+	b.Add("not an object")
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	schema := b.Schema(now)
+	assert.Equal(t, 3, schema.SampleCount)
+	assert.Equal(t, now, schema.InferredAt)
+
+	byPath := make(map[string]models.SchemaField)
+	var paths []string
+	for _, f := range schema.Fields {
+		byPath[f.Path] = f
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"meta", "meta.exchange", "meta.live", "price", "symbol", "trades", "trades[].qty", "trades[].side"}, paths)
+
+	assert.Equal(t, []string{"null", "number"}, byPath["price"].Types)
+	assert.Equal(t, 42.5, byPath["price"].Example)
+	assert.Equal(t, "BTC", byPath["symbol"].Example)
+	assert.Equal(t, []string{"object"}, byPath["meta"].Types)
+	assert.Nil(t, byPath["meta"].Example)
+	assert.Equal(t, []string{"boolean"}, byPath["meta.live"].Types)
+	assert.Equal(t, []string{"array"}, byPath["trades"].Types)
+	// Every sampled message counts, including ones without fields
+	assert.True(t, byPath["symbol"].Optional)
+	assert.True(t, byPath["trades[].side"].Optional)
+}
+
+func TestSchemaBuilder_ArrayMessages(t *testing.T) {
+	b := NewSchemaBuilder()
+	b.Add([]interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}})
+	b.Add([]interface{}{map[string]interface{}{"id": "c"}})
+
+	schema := b.Schema(time.Now())
+	require.Len(t, schema.Fields, 1)
+	assert.Equal(t, "[].id", schema.Fields[0].Path)
+	assert.Equal(t, []string{"string"}, schema.Fields[0].Types)
+	assert.False(t, schema.Fields[0].Optional)
+}
+
+func TestSchemaBuilder_Limits(t *testing.T) {
+	b := NewSchemaBuilder()
+	deep := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{"d": map[string]interface{}{"e": 1.0}}}}}
+	b.Add(deep)
+	b.Add(map[string]interface{}{"long": strings.Repeat("x", 200)})
+
+	schema := b.Schema(time.Now())
+	var paths []string
+	for _, f := range schema.Fields {
+		paths = append(paths, f.Path)
+		if f.Path == "long" {
+			assert.Len(t, []rune(f.Example.(string)), maxSchemaExampleLen+1)
+		}
+	}
+	assert.Equal(t, []string{"a", "a.b", "a.b.c", "a.b.c.d", "long"}, paths)
+}
+
+func TestSchemaSignature(t *testing.T) {
+	a := &models.FeedSchema{Fields: []models.SchemaField{{Path: "price", Types: []string{"number"}, Example: 1.0}}}
+	b := &models.FeedSchema{Fields: []models.SchemaField{{Path: "price", Types: []string{"number"}, Example: 2.0, Optional: true}}}
+	c := &models.FeedSchema{Fields: []models.SchemaField{{Path: "price", Types: []string{"string"}}}}
+
+	assert.Equal(t, SchemaSignature(a), SchemaSignature(b))
+	assert.NotEqual(t, SchemaSignature(a), SchemaSignature(c))
+	assert.Empty(t, SchemaSignature(nil))
+}
+
+func TestLLMService_WithFeedSchema(t *testing.T) {
+	svc, err := NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, "base", svc.withFeedSchema("feed-1", "base"))
+
+	svc.SetFeedSchema("feed-1", &models.FeedSchema{Fields: []models.SchemaField{
+		{Path: "price", Types: []string{"number"}, Example: 42.5},
+		{Path: "side", Types: []string{"string"}, Example: "buy", Optional: true},
+		{Path: "meta", Types: []string{"object"}},
+	}})
+	prompt := svc.withFeedSchema("feed-1", "base")
+	assert.True(t, strings.HasPrefix(prompt, "base\n\n"))
+	assert.Contains(t, prompt, "- price (number), e.g. 42.5")
+	assert.Contains(t, prompt, `- side (string, optional), e.g. "buy"`)
+	assert.True(t, strings.HasSuffix(prompt, "\n- meta (object)"))
+	assert.Equal(t, "base", svc.withFeedSchema("feed-2", "base"))
+
+	svc.SetFeedSchema("feed-1", nil)
+	assert.Equal(t, "base", svc.withFeedSchema("feed-1", "base"))
+}
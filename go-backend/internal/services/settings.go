@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,8 +23,14 @@ type Category struct {
 	Key   string `bson:"key" json:"key"`
 	Label string `bson:"label" json:"label"`
 	Scope string `bson:"scope" json:"scope"`
+	// Disabled categories are hidden from the picker and rejected for new feeds;
+	// existing feeds keep them
+	Disabled bool `bson:"disabled,omitempty" json:"disabled"`
 }
 
+// categoryKeyPattern is the shape of a category key: a lowercase slug
+var categoryKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
 func (s *SettingsService) categories() *mongo.Collection {
 	return s.db.Collection("settings_categories")
 }
@@ -44,8 +53,14 @@ func (s *SettingsService) EnsureDefaultCategories(ctx context.Context) error {
 	return nil
 }
 
-func (s *SettingsService) ListCategories(ctx context.Context) ([]Category, error) {
-	cur, err := s.categories().Find(ctx, bson.M{})
+// ListCategories returns the categories sorted by label, leaving out disabled
+// ones unless includeDisabled is set
+func (s *SettingsService) ListCategories(ctx context.Context, includeDisabled bool) ([]Category, error) {
+	filter := bson.M{}
+	if !includeDisabled {
+		filter["disabled"] = bson.M{"$ne": true}
+	}
+	cur, err := s.categories().Find(ctx, filter, options.Find().SetSort(bson.M{"label": 1}))
 	if err != nil {
 		return nil, err
 	}
@@ -67,3 +82,79 @@ func (s *SettingsService) GetCategory(ctx context.Context, key string) (*Categor
 	}
 	return &cat, nil
 }
+
+// CreateCategory adds a global category
+func (s *SettingsService) CreateCategory(ctx context.Context, key, label string) (*Category, error) {
+	key, label = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(label)
+	if key == "" || label == "" {
+		return nil, ErrCategoryKeyRequired
+	}
+	if !categoryKeyPattern.MatchString(key) {
+		return nil, ErrInvalidCategoryKey
+	}
+	cat := Category{Key: key, Label: label, Scope: "global"}
+	res, err := s.categories().UpdateOne(ctx, bson.M{"key": key}, bson.M{"$setOnInsert": cat}, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	if res.UpsertedCount == 0 {
+		return nil, ErrCategoryExists
+	}
+	return &cat, nil
+}
+
+// UpdateCategory renames and/or enables or disables a category. The key
+// never changes since feeds refer to it.
+func (s *SettingsService) UpdateCategory(ctx context.Context, key string, label *string, disabled *bool) (*Category, error) {
+	set := bson.M{}
+	if label != nil {
+		trimmed := strings.TrimSpace(*label)
+		if trimmed == "" {
+			return nil, ErrCategoryKeyRequired
+		}
+		set["label"] = trimmed
+	}
+	if disabled != nil {
+		set["disabled"] = *disabled
+	}
+	if len(set) == 0 {
+		return nil, ErrCategoryKeyRequired
+	}
+	var cat Category
+	err := s.categories().FindOneAndUpdate(ctx, bson.M{"key": key}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&cat)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cat, nil
+}
+
+// ResolveCategory returns the key of the enabled category matching value by
+// key or label, ignoring case. An empty value resolves to "custom".
+func (s *SettingsService) ResolveCategory(ctx context.Context, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = "custom"
+	}
+	cats, err := s.ListCategories(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	if key, ok := matchCategory(cats, value); ok {
+		return key, nil
+	}
+	return "", ErrUnknownCategory
+}
+
+// matchCategory finds value among cats by key or label, ignoring case
+func matchCategory(cats []Category, value string) (string, bool) {
+	for _, cat := range cats {
+		if strings.EqualFold(cat.Key, value) || strings.EqualFold(cat.Label, value) {
+			return cat.Key, true
+		}
+	}
+	return "", false
+}
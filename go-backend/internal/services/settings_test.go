@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchCategory(t *testing.T) {
+	cats := []Category{
+		{Key: "crypto", Label: "Crypto"},
+		{Key: "real-estate", Label: "Real Estate"},
+	}
+	tests := []struct {
+		value string
+		key   string
+		found bool
+	}{
+		{"crypto", "crypto", true},
+		{"CRYPTO", "crypto", true},
+		{"real estate", "real-estate", true},
+		{"Real-Estate", "real-estate", true},
+		{"stocks", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			key, found := matchCategory(cats, tt.value)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.key, key)
+		})
+	}
+}
+
+func TestCreateCategoryValidation(t *testing.T) {
+	s := &SettingsService{}
+	ctx := context.Background()
+
+	_, err := s.CreateCategory(ctx, "", "Label")
+	assert.ErrorIs(t, err, ErrCategoryKeyRequired)
+	_, err = s.CreateCategory(ctx, "key", " ")
+	assert.ErrorIs(t, err, ErrCategoryKeyRequired)
+	_, err = s.CreateCategory(ctx, "has space", "Label")
+	assert.ErrorIs(t, err, ErrInvalidCategoryKey)
+	_, err = s.CreateCategory(ctx, "-leading-dash", "Label")
+	assert.ErrorIs(t, err, ErrInvalidCategoryKey)
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Response formats a query can ask for
+const (
+	ResponseFormatText = "text"
+	ResponseFormatJSON = "json"
+)
+
+// ResponseFormat asks for an answer in JSON, optionally matching a JSON
+// schema. Schemas are checked with the common subset of JSON Schema: type,
+// properties, required, additionalProperties, items, enum, minimum and maximum.
+type ResponseFormat struct {
+	Type   string                 `json:"type"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
+}
+
+// isJSON reports whether the format asks for a JSON answer
+func (f *ResponseFormat) isJSON() bool {
+	return f != nil && f.Type == ResponseFormatJSON
+}
+
+// schemaTypes are the JSON Schema types structured answers can be checked against
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// validateResponseFormat checks a requested format; nil asks for plain text
+func validateResponseFormat(f *ResponseFormat) error {
+	if f == nil {
+		return nil
+	}
+	switch f.Type {
+	case "", ResponseFormatText:
+		if f.Schema != nil {
+			return fmt.Errorf("%w: a schema needs type %q", ErrInvalidResponseFormat, ResponseFormatJSON)
+		}
+		return nil
+	case ResponseFormatJSON:
+		if f.Schema == nil {
+			return nil
+		}
+		return validateSchema(f.Schema, "schema")
+	}
+	return fmt.Errorf("%w: type must be %q or %q", ErrInvalidResponseFormat, ResponseFormatText, ResponseFormatJSON)
+}
+
+// validateSchema checks that a schema only uses types the validator knows
+func validateSchema(schema map[string]interface{}, path string) error {
+	for _, t := range schemaTypeList(schema["type"]) {
+		if !schemaTypes[t] {
+			return fmt.Errorf("%w: %s.type %q is not a JSON Schema type", ErrInvalidResponseFormat, path, t)
+		}
+	}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, sub := range props {
+			subSchema, ok := sub.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%w: %s.properties.%s must be a schema object", ErrInvalidResponseFormat, path, name)
+			}
+			if err := validateSchema(subSchema, path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		return validateSchema(items, path+".items")
+	}
+	return nil
+}
+
+// schemaTypeList returns a schema's "type", which may be one name or a list
+func schemaTypeList(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// withResponseFormat tells the model to answer with JSON when the format asks
+// for it, which also covers providers without a native JSON mode
+func withResponseFormat(systemPrompt string, f *ResponseFormat) string {
+	if !f.isJSON() {
+		return systemPrompt
+	}
+	instruction := "\n\nRespond with a single valid JSON value and nothing else: no prose and no code fences."
+	if f.Schema != nil {
+		schema, _ := json.Marshal(f.Schema)
+		instruction += "\nThe JSON must match this JSON schema:\n" + string(schema)
+	}
+	return systemPrompt + instruction
+}
+
+// openAIResponseFormat returns the "response_format" of OpenAI-compatible
+// chat APIs, or nil for plain text. Providers without json_schema support get
+// plain JSON mode and rely on the schema in the prompt.
+func (o ChatOptions) openAIResponseFormat(schemas bool) map[string]interface{} {
+	if !o.Format.isJSON() {
+		return nil
+	}
+	if schemas && o.Format.Schema != nil {
+		return map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "answer",
+				"schema": o.Format.Schema,
+			},
+		}
+	}
+	return map[string]interface{}{"type": "json_object"}
+}
+
+// parseStructuredAnswer parses a JSON answer, tolerating a code fence around
+// it, and checks it against the format's schema
+func parseStructuredAnswer(answer string, f *ResponseFormat) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(stripCodeFence(answer)), &value); err != nil {
+		return nil, fmt.Errorf("%w: answer is not JSON: %v", ErrInvalidStructuredOutput, err)
+	}
+	if f.Schema != nil {
+		if err := matchSchema(value, f.Schema, "$"); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, err)
+		}
+	}
+	return value, nil
+}
+
+// stripCodeFence returns an answer without the markdown code fence models
+// often wrap JSON in
+func stripCodeFence(answer string) string {
+	text := strings.TrimSpace(answer)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	return text
+}
+
+// schemaType names the JSON Schema type of a decoded value, telling
+// whole numbers apart as integers
+func schemaType(v interface{}) string {
+	if n, ok := v.(float64); ok && n == math.Trunc(n) {
+		return "integer"
+	}
+	return jsonType(v)
+}
+
+// matchSchema checks a decoded value against a schema, naming the first
+// mismatch by its path
+func matchSchema(v interface{}, schema map[string]interface{}, path string) error {
+	if types := schemaTypeList(schema["type"]); len(types) > 0 {
+		actual := schemaType(v)
+		ok := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s is %s, want %s", path, actual, strings.Join(types, " or "))
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) && schemaType(e) == schemaType(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s is not one of the allowed values", path)
+		}
+	}
+	if n, ok := v.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s is below the minimum %g", path, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			return fmt.Errorf("%s is above the maximum %g", path, max)
+		}
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := val[name]; !present {
+						return fmt.Errorf("%s.%s is required", path, name)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := props[name].(map[string]interface{})
+			if !ok {
+				if extra, ok := schema["additionalProperties"].(bool); ok && !extra {
+					return fmt.Errorf("%s.%s is not allowed", path, name)
+				}
+				continue
+			}
+			if err := matchSchema(val[name], sub, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				if err := matchSchema(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// cannedProvider answers every call with answer and keeps the last messages
+type cannedProvider struct {
+	name     string
+	answer   string
+	messages []ChatMessage
+}
+
+func (p *cannedProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, TokenCount, error) {
+	p.messages = messages
+	return p.answer, TokenCount{Prompt: 10, Completion: 5}, nil
+}
+
+func (p *cannedProvider) StreamChat(ctx context.Context, messages []ChatMessage, opts ChatOptions, tokens chan<- string) (TokenCount, error) {
+	defer close(tokens)
+	p.messages = messages
+	tokens <- p.answer
+	return TokenCount{Prompt: 10, Completion: 5}, nil
+}
+
+func (p *cannedProvider) Enabled() bool { return true }
+func (p *cannedProvider) Name() string  { return p.name }
+
+func newStructuredLLMService(t *testing.T, provider *cannedProvider) *LLMService {
+	t.Helper()
+	svc, err := NewLLMService(config.Config{LLMContextLimit: 10, LLMMaxProviderAttempts: 1})
+	require.NoError(t, err)
+	svc.providers[provider.name] = provider
+	svc.defaultProv = provider.name
+	svc.AddFeedData("feed-1", "Feed", map[string]interface{}{"price": 1.0})
+	return svc
+}
+
+func decodeSchema(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &schema))
+	return schema
+}
+
+func TestValidateResponseFormat(t *testing.T) {
+	assert.NoError(t, validateResponseFormat(nil))
+	assert.NoError(t, validateResponseFormat(&ResponseFormat{Type: ResponseFormatText}))
+	assert.NoError(t, validateResponseFormat(&ResponseFormat{Type: ResponseFormatJSON}))
+	assert.NoError(t, validateResponseFormat(&ResponseFormat{Type: ResponseFormatJSON, Schema: decodeSchema(t, `{"type":"object","properties":{"trend":{"type":"string"}}}`)}))
+
+	assert.ErrorIs(t, validateResponseFormat(&ResponseFormat{Type: "xml"}), ErrInvalidResponseFormat)
+	assert.ErrorIs(t, validateResponseFormat(&ResponseFormat{Type: ResponseFormatText, Schema: map[string]interface{}{}}), ErrInvalidResponseFormat)
+	err := validateResponseFormat(&ResponseFormat{Type: ResponseFormatJSON, Schema: decodeSchema(t, `{"properties":{"trend":{"type":"text"}}}`)})
+	assert.ErrorIs(t, err, ErrInvalidResponseFormat)
+	assert.ErrorContains(t, err, "schema.properties.trend.type")
+
+	// Options reject a bad format for every provider
+	assert.ErrorIs(t, ChatOptions{Format: &ResponseFormat{Type: "xml"}}.Validate("openai"), ErrInvalidResponseFormat)
+}
+
+func TestParseStructuredAnswer(t *testing.T) {
+	schema := decodeSchema(t, `{
+		"type": "object",
+		"required": ["trend", "confidence"],
+		"additionalProperties": false,
+		"properties": {
+			"trend": {"type": "string", "enum": ["up", "down", "flat"]},
+			"confidence": {"type": "number", "minimum": 0, "maximum": 1},
+			"points": {"type": "array", "items": {"type": "integer"}}
+		}
+	}`)
+	format := &ResponseFormat{Type: ResponseFormatJSON, Schema: schema}
+
+	value, err := parseStructuredAnswer(`{"trend":"up","confidence":0.8,"points":[1,2]}`, format)
+	require.NoError(t, err)
+	assert.Equal(t, "up", value.(map[string]interface{})["trend"])
+
+	// Code fences around the JSON are tolerated
+	_, err = parseStructuredAnswer("```json\n{\"trend\":\"flat\",\"confidence\":1}\n```", format)
+	assert.NoError(t, err)
+
+	for answer, want := range map[string]string{
+		`the trend is up`:                                "not JSON",
+		`{"trend":"up"}`:                                 "$.confidence is required",
+		`{"trend":"sideways","confidence":0.5}`:          "$.trend is not one of the allowed values",
+		`{"trend":"up","confidence":2}`:                  "$.confidence is above the maximum 1",
+		`{"trend":"up","confidence":"high"}`:             "$.confidence is string, want number",
+		`{"trend":"up","confidence":0.5,"points":[1.5]}`: "$.points[0] is number, want integer",
+		`{"trend":"up","confidence":0.5,"note":"x"}`:     "$.note is not allowed",
+	} {
+		_, err := parseStructuredAnswer(answer, format)
+		assert.ErrorIs(t, err, ErrInvalidStructuredOutput, answer)
+		assert.ErrorContains(t, err, want, answer)
+	}
+
+	// Without a schema any JSON value is accepted
+	value, err = parseStructuredAnswer(`[1, "a"]`, &ResponseFormat{Type: ResponseFormatJSON})
+	require.NoError(t, err)
+	assert.Len(t, value, 2)
+}
+
+func TestOpenAIClient_Chat_ResponseFormat(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Write([]byte(`{"choices":[{"message":{"content":"{}"}}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient("test-key", "")
+	client.baseURL = srv.URL
+	schema := map[string]interface{}{"type": "object"}
+	_, _, err := client.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, ChatOptions{Format: &ResponseFormat{Type: ResponseFormatJSON, Schema: schema}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"type":        "json_schema",
+		"json_schema": map[string]interface{}{"name": "answer", "schema": schema},
+	}, body["response_format"])
+
+	_, _, err = client.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, ChatOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, body, "response_format")
+}
+
+func TestChatOptions_ProviderFormats(t *testing.T) {
+	schema := map[string]interface{}{"type": "object"}
+	withSchema := ChatOptions{Format: &ResponseFormat{Type: ResponseFormatJSON, Schema: schema}}
+
+	assert.Equal(t, map[string]interface{}{"type": "json_object"}, withSchema.openAIResponseFormat(false))
+	assert.Equal(t, "application/json", withSchema.geminiConfig()["responseMimeType"])
+	assert.Equal(t, schema, withSchema.ollamaFormat())
+	assert.Equal(t, "json", ChatOptions{Format: &ResponseFormat{Type: ResponseFormatJSON}}.ollamaFormat())
+
+	plain := ChatOptions{}
+	assert.Nil(t, plain.openAIResponseFormat(true))
+	assert.NotContains(t, plain.geminiConfig(), "responseMimeType")
+	assert.Nil(t, plain.ollamaFormat())
+}
+
+func TestLLMService_Query_StructuredOutput(t *testing.T) {
+	provider := &cannedProvider{name: "anthropic", answer: "```json\n{\"trend\":\"up\"}\n```"}
+	svc := newStructuredLLMService(t, provider)
+	format := &ResponseFormat{Type: ResponseFormatJSON, Schema: decodeSchema(t, `{"type":"object","required":["trend"]}`)}
+
+	resp, err := svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "trend?", ResponseFormat: format})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"trend": "up"}, resp.JSON)
+	assert.Contains(t, provider.messages[0].Content, `{"required":["trend"],"type":"object"}`)
+
+	// The same question without a format is not served the JSON answer
+	provider.answer = "It is going up."
+	resp, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "trend?"})
+	require.NoError(t, err)
+	assert.False(t, resp.Cached)
+	assert.Nil(t, resp.JSON)
+	assert.NotContains(t, provider.messages[0].Content, "JSON schema")
+
+	provider.answer = `{"direction":"up"}`
+	_, err = svc.Query(context.Background(), QueryRequest{FeedID: "feed-1", Question: "direction?", ResponseFormat: format})
+	assert.True(t, errors.Is(err, ErrInvalidStructuredOutput))
+}
+
+func TestLLMService_StreamQuery_StructuredOutput(t *testing.T) {
+	provider := &cannedProvider{name: "anthropic", answer: "not json"}
+	svc := newStructuredLLMService(t, provider)
+	format := &ResponseFormat{Type: ResponseFormatJSON}
+
+	tokenChan := make(chan string, 10)
+	resp, err := svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "trend?", ResponseFormat: format}, tokenChan)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"not json"}, collectTokens(tokenChan))
+	assert.Contains(t, resp.Error, "did not return the requested JSON")
+
+	// A failed answer is not cached
+	provider.answer = `{"trend":"up"}`
+	tokenChan = make(chan string, 10)
+	resp, err = svc.StreamQuery(context.Background(), QueryRequest{FeedID: "feed-1", Question: "trend?", ResponseFormat: format}, tokenChan)
+	require.NoError(t, err)
+	assert.False(t, resp.Cached)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, map[string]interface{}{"trend": "up"}, resp.JSON)
+}
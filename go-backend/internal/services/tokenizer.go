@@ -0,0 +1,111 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenPiece splits text the way the BPE pre-tokenizers of current chat
+// models do before merging: contractions, runs of letters with an optional
+// leading space, groups of up to three digits, runs of other symbols, and
+// whitespace
+var tokenPiece = regexp.MustCompile(`'(?:s|t|re|ve|m|ll|d)| ?\p{L}+| ?\p{N}{1,3}| ?[^\s\p{L}\p{N}]+|\s+`)
+
+// tokenizerProfile describes how a provider's vocabulary and chat format
+// turn text into tokens
+type tokenizerProfile struct {
+	// wordLetters is the longest run of Latin letters that is usually one token
+	wordLetters int
+	// lettersPerToken is how many further letters of a longer word make a token
+	lettersPerToken float64
+	// perMessage and perRequest are the tokens the chat format adds around
+	// each message and once per request
+	perMessage int
+	perRequest int
+}
+
+// tokenizerProfiles are keyed by provider name
+var tokenizerProfiles = map[string]tokenizerProfile{
+	"openai":       {wordLetters: 7, lettersPerToken: 4, perMessage: 3, perRequest: 3}, // o200k_base / cl100k_base
+	"azure-openai": {wordLetters: 7, lettersPerToken: 4, perMessage: 3, perRequest: 3},
+	"grok":         {wordLetters: 7, lettersPerToken: 4, perMessage: 3, perRequest: 3},
+	"anthropic":    {wordLetters: 6, lettersPerToken: 3.5, perMessage: 3, perRequest: 0},
+	"gemini":       {wordLetters: 7, lettersPerToken: 4, perMessage: 2, perRequest: 0},
+	"mistral":      {wordLetters: 6, lettersPerToken: 3.5, perMessage: 2, perRequest: 1}, // tekken
+	"ollama":       {wordLetters: 7, lettersPerToken: 4, perMessage: 4, perRequest: 1},   // llama 3
+}
+
+// defaultTokenizerProfile is used for providers without a profile; most
+// OpenAI-compatible servers use its vocabulary
+var defaultTokenizerProfile = tokenizerProfiles["openai"]
+
+// profileFor returns the tokenizer profile of a provider
+func profileFor(provider string) tokenizerProfile {
+	if p, ok := tokenizerProfiles[provider]; ok {
+		return p
+	}
+	return defaultTokenizerProfile
+}
+
+// countTokens counts the tokens text takes in the provider's vocabulary.
+// Providers report exact counts for the calls they serve; this fills in the
+// counts they leave out and prices prompts before they are sent.
+func countTokens(provider, text string) int {
+	profile := profileFor(provider)
+	total := 0
+	for _, piece := range tokenPiece.FindAllString(text, -1) {
+		total += profile.pieceTokens(piece)
+	}
+	return total
+}
+
+// pieceTokens counts the tokens of one pre-tokenized piece
+func (p tokenizerProfile) pieceTokens(piece string) int {
+	first, _ := utf8.DecodeRuneInString(piece)
+	if first == ' ' && len(piece) > 1 {
+		// A leading space merges into the word after it
+		piece = piece[1:]
+		first, _ = utf8.DecodeRuneInString(piece)
+	}
+	runes := utf8.RuneCountInString(piece)
+	switch {
+	case unicode.IsSpace(first), unicode.IsNumber(first):
+		return 1
+	case unicode.IsLetter(first):
+		if first > unicode.MaxASCII {
+			// Scripts outside Latin take about a token per character
+			return runes
+		}
+		if runes <= p.wordLetters {
+			return 1
+		}
+		return 1 + int(math.Ceil(float64(runes-p.wordLetters)/p.lettersPerToken))
+	default:
+		// Common pairs such as `":` and `},` are single tokens
+		return (runes + 1) / 2
+	}
+}
+
+// countMessageTokens counts the prompt tokens of a chat request
+func countMessageTokens(provider string, messages []ChatMessage) int {
+	profile := profileFor(provider)
+	total := profile.perRequest
+	for _, msg := range messages {
+		total += profile.perMessage + countTokens(provider, msg.Content)
+	}
+	return total
+}
+
+// completeTokenCount fills in the counts a provider did not report for a
+// request and its answer
+func completeTokenCount(provider string, messages []ChatMessage, answer string, reported TokenCount) TokenCount {
+	if reported.Prompt == 0 {
+		reported.Prompt = countMessageTokens(provider, messages)
+	}
+	if reported.Completion == 0 && answer != "" {
+		reported.Completion = countTokens(provider, answer)
+	}
+	return reported
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello world", 2},
+		{"What is the price?", 5},
+		{`{"price":70123.5}`, 8}, // {" price ": 701 23 . 5 }
+		{"internationalization", 5},
+		{"line one\n\nline two", 5},
+		{"价格", 2},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, countTokens("openai", tt.text), tt.text)
+	}
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: "system", Content: "Be concise."},
+		{Role: "user", Content: "What is the price?"},
+	}
+	// 3 per request, 3 per message, 3 + 5 content tokens
+	assert.Equal(t, 3+3+3+3+5, countMessageTokens("openai", messages))
+	// Unknown providers use the default profile
+	assert.Equal(t, countMessageTokens("openai", messages), countMessageTokens("custom", messages))
+}
+
+func TestCompleteTokenCount(t *testing.T) {
+	messages := []ChatMessage{{Role: "user", Content: "hello world"}}
+
+	// Reported counts are kept
+	assert.Equal(t, TokenCount{Prompt: 9, Completion: 4}, completeTokenCount("openai", messages, "hi there", TokenCount{Prompt: 9, Completion: 4}))
+
+	// Missing counts are filled in
+	assert.Equal(t, TokenCount{Prompt: 3 + 3 + 2, Completion: 2}, completeTokenCount("openai", messages, "hi there", TokenCount{}))
+
+	// An empty answer used no completion tokens
+	assert.Equal(t, 0, completeTokenCount("openai", messages, "", TokenCount{}).Completion)
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// COSE algorithms accepted for credential keys
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// Authenticator data flags
+const (
+	authDataUserPresent  = 0x01
+	authDataAttestedData = 0x40
+)
+
+// webAuthnTimeout is how long the browser prompt, and its challenge, stay valid
+const webAuthnTimeout = 5 * time.Minute
+
+// WebAuthnRelyingParty identifies this server to the authenticator
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUser is the account a credential is created for
+type WebAuthnUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// WebAuthnCredentialParam is a key algorithm the server accepts
+type WebAuthnCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// WebAuthnCredentialDescriptor names a registered credential
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// WebAuthnAuthenticatorSelection states what kind of authenticator is wanted
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are the publicKey options for
+// navigator.credentials.create. Binary values are base64url encoded and must
+// be decoded to ArrayBuffers by the client.
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUser                   `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+// WebAuthnRequestOptions are the publicKey options for navigator.credentials.get
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	Timeout          int64                          `json:"timeout"`
+	RPID             string                         `json:"rpId"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+// WebAuthnAttestationResponse is the response of a new credential
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"`
+	AttestationObject string   `json:"attestationObject"`
+	Transports        []string `json:"transports"`
+}
+
+// WebAuthnAttestation is the credential navigator.credentials.create returns,
+// with binary values base64url encoded
+type WebAuthnAttestation struct {
+	ID       string                      `json:"id"`
+	Type     string                      `json:"type"`
+	Response WebAuthnAttestationResponse `json:"response"`
+}
+
+// WebAuthnAssertionResponse is the signed response to a sign-in challenge
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// WebAuthnAssertion is the credential navigator.credentials.get returns,
+// with binary values base64url encoded
+type WebAuthnAssertion struct {
+	ID       string                    `json:"id"`
+	Type     string                    `json:"type"`
+	Response WebAuthnAssertionResponse `json:"response"`
+}
+
+// webAuthnRP checks responses against the relying party ID and the origins
+// the browser may report
+type webAuthnRP struct {
+	id      string
+	name    string
+	origins []string
+}
+
+// webAuthnClientData is the part of clientDataJSON that is checked
+type webAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// authenticatorData is the parsed authData an authenticator signs
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte // COSE key, present when a credential is created
+}
+
+// webAuthnRegistration is a verified new credential and the challenge it answered
+type webAuthnRegistration struct {
+	challenge  string
+	credential models.WebAuthnCredential
+}
+
+// webAuthnInvalid wraps ErrInvalidWebAuthnResponse with what was wrong
+func webAuthnInvalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidWebAuthnResponse, fmt.Sprintf(format, args...))
+}
+
+// decodeWebAuthnBase64 decodes base64url with or without padding
+func decodeWebAuthnBase64(field, value string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(b) == 0 {
+		return nil, webAuthnInvalid("%s is not base64url", field)
+	}
+	return b, nil
+}
+
+// clientData checks the type and origin of clientDataJSON and returns the
+// challenge it answers
+func (rp webAuthnRP) clientData(raw []byte, typ string) (string, error) {
+	var data webAuthnClientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", webAuthnInvalid("clientDataJSON is not JSON")
+	}
+	if data.Type != typ {
+		return "", webAuthnInvalid("clientDataJSON type is %q, not %q", data.Type, typ)
+	}
+	allowed := false
+	for _, origin := range rp.origins {
+		if data.Origin == origin {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", webAuthnInvalid("origin %q is not allowed", data.Origin)
+	}
+	if data.Challenge == "" {
+		return "", ErrWebAuthnChallenge
+	}
+	return data.Challenge, nil
+}
+
+// authenticatorData parses authData, checking it was made for this relying
+// party with the user present
+func (rp webAuthnRP) authenticatorData(raw []byte) (authenticatorData, error) {
+	var data authenticatorData
+	if len(raw) < 37 {
+		return data, webAuthnInvalid("authenticator data is too short")
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.id))
+	if !bytes.Equal(raw[:32], rpIDHash[:]) {
+		return data, webAuthnInvalid("credential belongs to another relying party")
+	}
+	data.flags = raw[32]
+	if data.flags&authDataUserPresent == 0 {
+		return data, webAuthnInvalid("user was not present")
+	}
+	data.signCount = binary.BigEndian.Uint32(raw[33:37])
+	if data.flags&authDataAttestedData == 0 {
+		return data, nil
+	}
+
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return data, webAuthnInvalid("attested credential data is too short")
+	}
+	data.aaguid = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || len(rest) < idLen {
+		return data, webAuthnInvalid("credential ID is truncated")
+	}
+	data.credentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	// The COSE key may be followed by extension data, so only one CBOR
+	// value is read
+	var key map[int64]interface{}
+	dec := codec.NewDecoderBytes(rest, &codec.CborHandle{})
+	if err := dec.Decode(&key); err != nil {
+		return data, webAuthnInvalid("credential public key is not COSE")
+	}
+	data.publicKey = rest[:dec.NumBytesRead()]
+	return data, nil
+}
+
+// coseInt reads a COSE integer, which decodes as signed or unsigned
+func coseInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// parseCOSEKey returns the public key in a COSE key and its algorithm.
+// ES256 (P-256), RS256 and EdDSA (Ed25519) keys are supported.
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+	var key map[int64]interface{}
+	if err := codec.NewDecoderBytes(raw, &codec.CborHandle{}).Decode(&key); err != nil {
+		return nil, 0, webAuthnInvalid("credential public key is not COSE")
+	}
+	kty, _ := coseInt(key[1])
+	alg, _ := coseInt(key[3])
+	crv, _ := coseInt(key[-1])
+	x, _ := key[-2].([]byte)
+	y, _ := key[-3].([]byte)
+
+	switch {
+	case alg == coseAlgES256 && kty == 2 && crv == 1:
+		// Loading the point into crypto/ecdh checks it is on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil || len(x) != 32 {
+			return nil, 0, webAuthnInvalid("invalid P-256 public key")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, alg, nil
+	case alg == coseAlgRS256 && kty == 3:
+		n, _ := key[-1].([]byte)
+		e, _ := key[-2].([]byte)
+		exp := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, 0, webAuthnInvalid("invalid RSA public key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, alg, nil
+	case alg == coseAlgEdDSA && kty == 1 && crv == 6:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, webAuthnInvalid("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), alg, nil
+	}
+	return nil, 0, ErrUnsupportedWebAuthnKey
+}
+
+// verifyWebAuthnSignature checks sig over signed with a key from parseCOSEKey
+func verifyWebAuthnSignature(pub crypto.PublicKey, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+	ok := false
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(key, digest[:], sig)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, signed, sig)
+	}
+	if !ok {
+		return webAuthnInvalid("signature does not match")
+	}
+	return nil
+}
+
+// parseAttestation verifies a new credential and returns it with the
+// challenge it answered. Attestation statements are not checked: "none" is
+// requested, so the key is trusted as registered by the signed-in user.
+func (rp webAuthnRP) parseAttestation(att WebAuthnAttestation) (webAuthnRegistration, error) {
+	var reg webAuthnRegistration
+	if att.Type != "public-key" {
+		return reg, webAuthnInvalid("credential type must be public-key")
+	}
+	clientJSON, err := decodeWebAuthnBase64("clientDataJSON", att.Response.ClientDataJSON)
+	if err != nil {
+		return reg, err
+	}
+	if reg.challenge, err = rp.clientData(clientJSON, "webauthn.create"); err != nil {
+		return reg, err
+	}
+	attObject, err := decodeWebAuthnBase64("attestationObject", att.Response.AttestationObject)
+	if err != nil {
+		return reg, err
+	}
+	var object map[string]interface{}
+	if err := codec.NewDecoderBytes(attObject, &codec.CborHandle{}).Decode(&object); err != nil {
+		return reg, webAuthnInvalid("attestationObject is not CBOR")
+	}
+	rawAuthData, _ := object["authData"].([]byte)
+	authData, err := rp.authenticatorData(rawAuthData)
+	if err != nil {
+		return reg, err
+	}
+	if authData.credentialID == nil {
+		return reg, webAuthnInvalid("attestation has no credential")
+	}
+	credentialID := base64.RawURLEncoding.EncodeToString(authData.credentialID)
+	if strings.TrimRight(att.ID, "=") != credentialID {
+		return reg, webAuthnInvalid("credential ID does not match the attested credential")
+	}
+	_, alg, err := parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return reg, err
+	}
+	reg.credential = models.WebAuthnCredential{
+		CredentialID: credentialID,
+		PublicKey:    authData.publicKey,
+		Algorithm:    alg,
+		SignCount:    authData.signCount,
+		AAGUID:       hex.EncodeToString(authData.aaguid),
+		Transports:   att.Response.Transports,
+	}
+	return reg, nil
+}
+
+// verifyAssertion checks an assertion was signed by cred and returns the
+// challenge it answered and the authenticator's new signature counter
+func (rp webAuthnRP) verifyAssertion(cred models.WebAuthnCredential, assertion WebAuthnAssertion) (string, uint32, error) {
+	if assertion.Type != "public-key" {
+		return "", 0, webAuthnInvalid("credential type must be public-key")
+	}
+	clientJSON, err := decodeWebAuthnBase64("clientDataJSON", assertion.Response.ClientDataJSON)
+	if err != nil {
+		return "", 0, err
+	}
+	challenge, err := rp.clientData(clientJSON, "webauthn.get")
+	if err != nil {
+		return "", 0, err
+	}
+	rawAuthData, err := decodeWebAuthnBase64("authenticatorData", assertion.Response.AuthenticatorData)
+	if err != nil {
+		return "", 0, err
+	}
+	authData, err := rp.authenticatorData(rawAuthData)
+	if err != nil {
+		return "", 0, err
+	}
+	sig, err := decodeWebAuthnBase64("signature", assertion.Response.Signature)
+	if err != nil {
+		return "", 0, err
+	}
+	pub, _, err := parseCOSEKey(cred.PublicKey)
+	if err != nil {
+		return "", 0, err
+	}
+	clientHash := sha256.Sum256(clientJSON)
+	if err := verifyWebAuthnSignature(pub, append(rawAuthData, clientHash[:]...), sig); err != nil {
+		return "", 0, err
+	}
+	// Authenticators that count signatures must count up; a counter that
+	// does not is a sign the key was cloned
+	if (authData.signCount != 0 || cred.SignCount != 0) && authData.signCount <= cred.SignCount {
+		return "", 0, ErrWebAuthnSignCount
+	}
+	return challenge, authData.signCount, nil
+}
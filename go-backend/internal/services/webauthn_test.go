@@ -0,0 +1,215 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+var testRP = webAuthnRP{id: "example.com", name: "Example", origins: []string{"https://example.com"}}
+
+// testAuthenticator plays a security key holding one credential
+type testAuthenticator struct {
+	t          *testing.T
+	rpID       string
+	origin     string
+	id         []byte
+	ecKey      *ecdsa.PrivateKey
+	edKey      ed25519.PrivateKey
+	signCount  uint32
+	extensions []byte // appended after the COSE key to check it is cut off
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testAuthenticator{t: t, rpID: "example.com", origin: "https://example.com", id: []byte("credential-1"), ecKey: key}
+}
+
+func cborBytes(t *testing.T, v interface{}) []byte {
+	var out []byte
+	require.NoError(t, codec.NewEncoderBytes(&out, &codec.CborHandle{}).Encode(v))
+	return out
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	if a.edKey != nil {
+		return cborBytes(a.t, map[int]interface{}{1: 1, 3: coseAlgEdDSA, -1: 6, -2: []byte(a.edKey.Public().(ed25519.PublicKey))})
+	}
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.ecKey.X.FillBytes(x)
+	a.ecKey.Y.FillBytes(y)
+	return cborBytes(a.t, map[int]interface{}{1: 2, 3: coseAlgES256, -1: 1, -2: x, -3: y})
+}
+
+func (a *testAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	flags := byte(authDataUserPresent)
+	if attested {
+		flags |= authDataAttestedData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, a.coseKey()...)
+		data = append(data, a.extensions...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) clientData(typ, challenge string) []byte {
+	out, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": a.origin})
+	require.NoError(a.t, err)
+	return out
+}
+
+// create answers a registration challenge
+func (a *testAuthenticator) create(challenge string) WebAuthnAttestation {
+	object := cborBytes(a.t, map[string]interface{}{"fmt": "none", "attStmt": map[string]interface{}{}, "authData": a.authData(true)})
+	return WebAuthnAttestation{
+		ID:   b64(a.id),
+		Type: "public-key",
+		Response: WebAuthnAttestationResponse{
+			ClientDataJSON:    b64(a.clientData("webauthn.create", challenge)),
+			AttestationObject: b64(object),
+			Transports:        []string{"usb"},
+		},
+	}
+}
+
+// get answers a sign-in challenge
+func (a *testAuthenticator) get(challenge string) WebAuthnAssertion {
+	a.signCount++
+	authData := a.authData(false)
+	clientJSON := a.clientData("webauthn.get", challenge)
+	clientHash := sha256.Sum256(clientJSON)
+	signed := append(append([]byte{}, authData...), clientHash[:]...)
+	var sig []byte
+	if a.edKey != nil {
+		sig = ed25519.Sign(a.edKey, signed)
+	} else {
+		digest := sha256.Sum256(signed)
+		var err error
+		sig, err = ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+		require.NoError(a.t, err)
+	}
+	return WebAuthnAssertion{
+		ID:   b64(a.id),
+		Type: "public-key",
+		Response: WebAuthnAssertionResponse{
+			ClientDataJSON:    b64(clientJSON),
+			AuthenticatorData: b64(authData),
+			Signature:         b64(sig),
+		},
+	}
+}
+
+func TestWebAuthnRP_RegisterAndAssert(t *testing.T) {
+	key := newTestAuthenticator(t)
+	key.extensions = cborBytes(t, map[string]interface{}{"credProtect": 1})
+
+	reg, err := testRP.parseAttestation(key.create("register-challenge"))
+	require.NoError(t, err)
+	assert.Equal(t, "register-challenge", reg.challenge)
+	assert.Equal(t, b64(key.id), reg.credential.CredentialID)
+	assert.Equal(t, int64(coseAlgES256), reg.credential.Algorithm)
+	// The extension data after the key is not part of it
+	pub, _, err := parseCOSEKey(reg.credential.PublicKey)
+	require.NoError(t, err)
+	assert.True(t, key.ecKey.PublicKey.Equal(pub))
+	assert.Len(t, reg.credential.PublicKey, len(key.coseKey()))
+	assert.Equal(t, []string{"usb"}, reg.credential.Transports)
+
+	challenge, signCount, err := testRP.verifyAssertion(reg.credential, key.get("login-challenge"))
+	require.NoError(t, err)
+	assert.Equal(t, "login-challenge", challenge)
+	assert.Equal(t, uint32(1), signCount)
+
+	// A counter that does not go up means the key may have been cloned
+	used := reg.credential
+	used.SignCount = 5
+	_, _, err = testRP.verifyAssertion(used, key.get("login-challenge"))
+	assert.ErrorIs(t, err, ErrWebAuthnSignCount)
+}
+
+func TestWebAuthnRP_EdDSA(t *testing.T) {
+	key := newTestAuthenticator(t)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key.edKey = priv
+
+	reg, err := testRP.parseAttestation(key.create("c1"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(coseAlgEdDSA), reg.credential.Algorithm)
+	_, _, err = testRP.verifyAssertion(reg.credential, key.get("c2"))
+	require.NoError(t, err)
+}
+
+func TestWebAuthnRP_Rejects(t *testing.T) {
+	key := newTestAuthenticator(t)
+	reg, err := testRP.parseAttestation(key.create("c1"))
+	require.NoError(t, err)
+
+	t.Run("wrong origin", func(t *testing.T) {
+		other := *key
+		other.origin = "https://evil.example"
+		_, err := testRP.parseAttestation(other.create("c1"))
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+	})
+	t.Run("wrong relying party", func(t *testing.T) {
+		other := *key
+		other.rpID = "evil.example"
+		_, _, err := testRP.verifyAssertion(reg.credential, other.get("c2"))
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+	})
+	t.Run("registration replayed as sign-in", func(t *testing.T) {
+		att := key.create("c1")
+		assertion := key.get("c2")
+		assertion.Response.ClientDataJSON = att.Response.ClientDataJSON
+		_, _, err := testRP.verifyAssertion(reg.credential, assertion)
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+	})
+	t.Run("tampered signature", func(t *testing.T) {
+		assertion := key.get("c2")
+		assertion.Response.ClientDataJSON = b64(key.clientData("webauthn.get", "c3"))
+		_, _, err := testRP.verifyAssertion(reg.credential, assertion)
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+	})
+	t.Run("mismatched credential ID", func(t *testing.T) {
+		att := key.create("c1")
+		att.ID = b64([]byte("another"))
+		_, err := testRP.parseAttestation(att)
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+	})
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, _, err := parseCOSEKey(cborBytes(t, map[int]interface{}{1: 2, 3: -35, -1: 2}))
+		assert.ErrorIs(t, err, ErrUnsupportedWebAuthnKey)
+	})
+}
+
+func TestUnusedBackupCodes(t *testing.T) {
+	user := models.User{BackupCodes: []models.BackupCode{{Code: "A"}, {Code: "B", Used: true}, {Code: "C"}}}
+	assert.Equal(t, 2, unusedBackupCodes(user))
+	assert.Equal(t, 0, unusedBackupCodes(models.User{}))
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Events a webhook can subscribe to
+const (
+	WebhookEventFeedData   = "feed-data"
+	WebhookEventFeedAlert  = "feed-alert"
+	WebhookEventFeedHealth = "feed-health"
+)
+
+// webhookEvents are the events webhooks receive, in the order used when a
+// webhook names none
+var webhookEvents = []string{WebhookEventFeedData, WebhookEventFeedAlert, WebhookEventFeedHealth}
+
+const (
+	// webhookSecretPrefix marks webhook signing secrets
+	webhookSecretPrefix = "whsec_"
+	// maxWebhooksPerFeed caps how many webhooks a user can register on one feed
+	maxWebhooksPerFeed = 10
+	// webhookQueueSize bounds deliveries waiting to be sent; newer ones are dropped when full
+	webhookQueueSize = 1024
+	// webhookWorkers is how many deliveries are sent at once
+	webhookWorkers = 4
+	// webhookMaxAttempts is how often a delivery is tried before it is logged as failed
+	webhookMaxAttempts = 4
+	// webhookRetryDelay is the wait before the first retry; it doubles for each later one
+	webhookRetryDelay = time.Second
+	// webhookTimeout bounds one delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookCacheTTL is how long a feed's webhooks are cached before they are reloaded
+	webhookCacheTTL = 30 * time.Second
+	// webhookDeliveryRetention is how long the delivery log is kept
+	webhookDeliveryRetention = 7 * 24 * time.Hour
+	// maxWebhookDeliveries caps one delivery log request
+	maxWebhookDeliveries = 200
+)
+
+// Headers sent with every delivery. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	WebhookEventHeader     = "X-TurboStream-Event"
+	WebhookDeliveryHeader  = "X-TurboStream-Delivery"
+	WebhookTimestampHeader = "X-TurboStream-Timestamp"
+	WebhookSignatureHeader = "X-TurboStream-Signature"
+)
+
+// webhookEnvelope is the JSON body POSTed to webhooks
+type webhookEnvelope struct {
+	Event     string      `json:"event"`
+	FeedID    string      `json:"feedId"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookJob is one event waiting to be sent to one webhook
+type webhookJob struct {
+	hook      models.Webhook
+	event     string
+	body      []byte
+	createdAt time.Time
+}
+
+// webhookCacheEntry holds a feed's enabled webhooks
+type webhookCacheEntry struct {
+	hooks    []models.Webhook
+	loadedAt time.Time
+	loading  bool
+}
+
+// WebhookService stores users' feed webhooks and sends them feed events.
+// Deliveries are queued so broadcasting never waits on an endpoint; each is
+// retried with backoff and its outcome is kept in the webhook_deliveries log.
+type WebhookService struct {
+	db         *mongo.Database
+	client     *http.Client
+	queue      chan webhookJob
+	retryDelay time.Duration
+
+	cacheMu sync.Mutex
+	cache   map[string]*webhookCacheEntry
+}
+
+// NewWebhookService creates the webhook service; Run sends its deliveries
+func NewWebhookService(db *mongo.Database) *WebhookService {
+	return &WebhookService{
+		db:         db,
+		client:     &http.Client{Timeout: webhookTimeout},
+		queue:      make(chan webhookJob, webhookQueueSize),
+		retryDelay: webhookRetryDelay,
+		cache:      make(map[string]*webhookCacheEntry),
+	}
+}
+
+// webhooks returns the MongoDB webhooks collection
+func (s *WebhookService) webhooks() *mongo.Collection {
+	return s.db.Collection("webhooks")
+}
+
+// deliveries returns the MongoDB webhook_deliveries collection
+func (s *WebhookService) deliveries() *mongo.Collection {
+	return s.db.Collection("webhook_deliveries")
+}
+
+// EnsureIndexes creates the webhook lookup indexes and the TTL index that
+// expires the delivery log
+func (s *WebhookService) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.webhooks().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "feedId", Value: 1}, {Key: "userId", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := s.deliveries().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	return err
+}
+
+// normalizeWebhook checks a webhook's URL and events, defaulting to every event
+func normalizeWebhook(hook *models.Webhook) error {
+	hook.URL = strings.TrimSpace(hook.URL)
+	u, err := url.Parse(hook.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return ErrWebhookURLNotHTTPS
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = append([]string(nil), webhookEvents...)
+		return nil
+	}
+	seen := make(map[string]bool, len(hook.Events))
+	events := make([]string, 0, len(hook.Events))
+	for _, event := range hook.Events {
+		if !webhookEventKnown(event) {
+			return ErrInvalidWebhookEvent
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	hook.Events = events
+	return nil
+}
+
+// webhookEventKnown reports whether webhooks can subscribe to event
+func webhookEventKnown(event string) bool {
+	for _, known := range webhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhook registers an endpoint for a feed's events and returns its
+// signing secret, which is not shown again
+func (s *WebhookService) CreateWebhook(ctx context.Context, hook models.Webhook) (string, models.Webhook, error) {
+	if err := normalizeWebhook(&hook); err != nil {
+		return "", models.Webhook{}, err
+	}
+	count, err := s.webhooks().CountDocuments(ctx, bson.M{"userId": hook.UserID, "feedId": hook.FeedID})
+	if err != nil {
+		return "", models.Webhook{}, err
+	}
+	if count >= maxWebhooksPerFeed {
+		return "", models.Webhook{}, ErrTooManyWebhooks
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", models.Webhook{}, err
+	}
+	now := time.Now()
+	hook.ID = primitive.NilObjectID
+	hook.Secret = secret
+	hook.Enabled = true
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+	res, err := s.webhooks().InsertOne(ctx, hook)
+	if err != nil {
+		return "", models.Webhook{}, err
+	}
+	hook.ID = res.InsertedID.(primitive.ObjectID)
+	s.invalidate(hook.FeedID)
+	return secret, hook, nil
+}
+
+// ListWebhooks returns the user's webhooks for a feed, oldest first
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID, feedID string) ([]models.Webhook, error) {
+	cur, err := s.webhooks().Find(ctx, bson.M{"userId": userID, "feedId": feedID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	hooks := []models.Webhook{}
+	if err := cur.All(ctx, &hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// GetWebhook returns one of the user's webhooks for a feed
+func (s *WebhookService) GetWebhook(ctx context.Context, userID, feedID string, id primitive.ObjectID) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := s.webhooks().FindOne(ctx, bson.M{"_id": id, "userId": userID, "feedId": feedID}).Decode(&hook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// UpdateWebhook saves an edited webhook's URL, events and enabled flag
+func (s *WebhookService) UpdateWebhook(ctx context.Context, hook models.Webhook) (*models.Webhook, error) {
+	if err := normalizeWebhook(&hook); err != nil {
+		return nil, err
+	}
+	var updated models.Webhook
+	err := s.webhooks().FindOneAndUpdate(ctx,
+		bson.M{"_id": hook.ID, "userId": hook.UserID, "feedId": hook.FeedID},
+		bson.M{"$set": bson.M{"url": hook.URL, "events": hook.Events, "enabled": hook.Enabled, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(hook.FeedID)
+	return &updated, nil
+}
+
+// DeleteWebhook removes one of the user's webhooks for a feed; its delivery
+// log expires on its own
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, feedID string, id primitive.ObjectID) error {
+	res, err := s.webhooks().DeleteOne(ctx, bson.M{"_id": id, "userId": userID, "feedId": feedID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	s.invalidate(feedID)
+	return nil
+}
+
+// ListDeliveries returns the newest deliveries to one of the user's webhooks
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, feedID string, id primitive.ObjectID, limit int64) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, userID, feedID, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxWebhookDeliveries {
+		limit = maxWebhookDeliveries
+	}
+	cur, err := s.deliveries().Find(ctx, bson.M{"webhookId": id},
+		options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []models.WebhookDelivery{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Dispatch queues an event for the feed's webhooks that subscribe to it. A
+// userID limits delivery to that user's webhooks, for events such as alerts
+// that belong to one subscriber.
+func (s *WebhookService) Dispatch(feedID, userID, event string, data interface{}) {
+	var body []byte
+	now := time.Now().UTC()
+	for _, hook := range s.feedWebhooks(feedID) {
+		if userID != "" && hook.UserID != userID {
+			continue
+		}
+		if !containsString(hook.Events, event) {
+			continue
+		}
+		if body == nil {
+			encoded, err := json.Marshal(webhookEnvelope{Event: event, FeedID: feedID, Timestamp: now, Data: data})
+			if err != nil {
+				slog.Warn("cannot encode webhook event", "event", event, "feed_id", feedID, "error", err)
+				return
+			}
+			body = encoded
+		}
+		select {
+		case s.queue <- webhookJob{hook: hook, event: event, body: body, createdAt: now}:
+		default:
+			metrics.WebhookDeliveries.WithLabelValues(event, "dropped").Inc()
+			slog.Warn("webhook queue full, dropping event", "event", event, "webhook_id", hook.ID.Hex())
+		}
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// feedWebhooks returns the feed's cached enabled webhooks, reloading them in
+// the background when missing or stale
+func (s *WebhookService) feedWebhooks(feedID string) []models.Webhook {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	entry, ok := s.cache[feedID]
+	if !ok {
+		entry = &webhookCacheEntry{}
+		s.cache[feedID] = entry
+	}
+	if !entry.loading && time.Since(entry.loadedAt) >= webhookCacheTTL {
+		entry.loading = true
+		go s.loadFeedWebhooks(feedID, entry)
+	}
+	return entry.hooks
+}
+
+// loadFeedWebhooks fills a cache entry with the feed's enabled webhooks
+func (s *WebhookService) loadFeedWebhooks(feedID string, entry *webhookCacheEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var hooks []models.Webhook
+	cur, err := s.webhooks().Find(ctx, bson.M{"feedId": feedID, "enabled": true})
+	if err == nil {
+		err = cur.All(ctx, &hooks)
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	entry.loading = false
+	if err != nil {
+		slog.Warn("failed to load webhooks", "feed_id", feedID, "error", err)
+		return
+	}
+	entry.hooks = hooks
+	entry.loadedAt = time.Now()
+}
+
+// invalidate drops a feed's cached webhooks so the next event reloads them
+func (s *WebhookService) invalidate(feedID string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	delete(s.cache, feedID)
+}
+
+// Run sends queued deliveries until ctx is cancelled. It blocks until the
+// workers have stopped.
+func (s *WebhookService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < webhookWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.queue:
+					s.record(ctx, s.deliver(ctx, job))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver sends a job, retrying network errors, 429s and 5xx responses with
+// backoff, and returns the delivery's log entry
+func (s *WebhookService) deliver(ctx context.Context, job webhookJob) models.WebhookDelivery {
+	delivery := models.WebhookDelivery{
+		ID:        primitive.NewObjectID(),
+		WebhookID: job.hook.ID,
+		FeedID:    job.hook.FeedID,
+		Event:     job.event,
+		CreatedAt: job.createdAt,
+	}
+	delay := s.retryDelay
+	for delivery.Attempts < webhookMaxAttempts {
+		delivery.Attempts++
+		status, err := s.post(ctx, job, delivery.ID)
+		delivery.StatusCode = status
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+		if status != 0 && status != http.StatusTooManyRequests && status < 500 {
+			break
+		}
+		if delivery.Attempts < webhookMaxAttempts {
+			select {
+			case <-ctx.Done():
+				delivery.CompletedAt = time.Now().UTC()
+				return delivery
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+	}
+	delivery.CompletedAt = time.Now().UTC()
+	result := "failed"
+	if delivery.Success {
+		result = "delivered"
+	}
+	metrics.WebhookDeliveries.WithLabelValues(job.event, result).Inc()
+	return delivery
+}
+
+// post makes one signed delivery attempt, returning the response status
+func (s *WebhookService) post(ctx context.Context, job webhookJob, deliveryID primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.hook.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, job.event)
+	req.Header.Set(WebhookDeliveryHeader, deliveryID.Hex())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(job.hook.Secret, timestamp, job.body))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record stores a delivery in the log
+func (s *WebhookService) record(ctx context.Context, delivery models.WebhookDelivery) {
+	delivery.ExpiresAt = delivery.CompletedAt.Add(webhookDeliveryRetention)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if _, err := s.deliveries().InsertOne(ctx, delivery); err != nil {
+		slog.WarnContext(ctx, "failed to log webhook delivery", "delivery_id", delivery.ID.Hex(), "error", err)
+	}
+}
+
+// SignWebhookPayload returns the signature header value for a delivery, so
+// receivers can check it came from TurboStream
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestNormalizeWebhook(t *testing.T) {
+	hook := models.Webhook{URL: " https://example.com/hook "}
+	require.NoError(t, normalizeWebhook(&hook))
+	assert.Equal(t, "https://example.com/hook", hook.URL)
+	assert.Equal(t, []string{WebhookEventFeedData, WebhookEventFeedAlert, WebhookEventFeedHealth}, hook.Events)
+
+	hook = models.Webhook{URL: "https://example.com/hook", Events: []string{"feed-alert", "feed-alert"}}
+	require.NoError(t, normalizeWebhook(&hook))
+	assert.Equal(t, []string{"feed-alert"}, hook.Events)
+
+	assert.ErrorIs(t, normalizeWebhook(&models.Webhook{URL: "http://example.com/hook"}), ErrWebhookURLNotHTTPS)
+	assert.ErrorIs(t, normalizeWebhook(&models.Webhook{URL: "https:///hook"}), ErrWebhookURLNotHTTPS)
+	assert.ErrorIs(t, normalizeWebhook(&models.Webhook{URL: "https://example.com", Events: []string{"feed-schema"}}), ErrInvalidWebhookEvent)
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"event":"feed-data"}`)
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), SignWebhookPayload("whsec_test", "1700000000", body))
+	assert.NotEqual(t, SignWebhookPayload("whsec_test", "1700000000", body), SignWebhookPayload("whsec_other", "1700000000", body))
+}
+
+func TestNewWebhookSecret(t *testing.T) {
+	a, err := newWebhookSecret()
+	require.NoError(t, err)
+	b, err := newWebhookSecret()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(a, webhookSecretPrefix))
+	assert.NotEqual(t, a, b)
+}
+
+func TestWebhookService_DispatchFiltersHooks(t *testing.T) {
+	s := NewWebhookService(nil)
+	all := models.Webhook{ID: primitive.NewObjectID(), UserID: "u1", FeedID: "f1", Events: []string{WebhookEventFeedData, WebhookEventFeedAlert}, Enabled: true}
+	healthOnly := models.Webhook{ID: primitive.NewObjectID(), UserID: "u2", FeedID: "f1", Events: []string{WebhookEventFeedHealth}, Enabled: true}
+	s.cache["f1"] = &webhookCacheEntry{hooks: []models.Webhook{all, healthOnly}, loadedAt: time.Now()}
+
+	s.Dispatch("f1", "", WebhookEventFeedData, map[string]interface{}{"price": 1})
+	require.Len(t, s.queue, 1)
+	job := <-s.queue
+	assert.Equal(t, all.ID, job.hook.ID)
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(job.body, &envelope))
+	assert.Equal(t, "feed-data", envelope["event"])
+	assert.Equal(t, "f1", envelope["feedId"])
+	assert.Equal(t, map[string]interface{}{"price": 1.0}, envelope["data"])
+
+	// Alerts only go to the owning user's webhooks
+	s.Dispatch("f1", "u2", WebhookEventFeedAlert, models.FeedAlert{})
+	assert.Len(t, s.queue, 0)
+	s.Dispatch("f1", "u1", WebhookEventFeedAlert, models.FeedAlert{})
+	assert.Len(t, s.queue, 1)
+}
+
+func TestWebhookService_DeliverSignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, SignWebhookPayload("whsec_test", r.Header.Get(WebhookTimestampHeader), body), r.Header.Get(WebhookSignatureHeader))
+		assert.Equal(t, "feed-data", r.Header.Get(WebhookEventHeader))
+		assert.NotEmpty(t, r.Header.Get(WebhookDeliveryHeader))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookService(nil)
+	s.retryDelay = time.Millisecond
+	hook := models.Webhook{ID: primitive.NewObjectID(), FeedID: "f1", URL: srv.URL, Secret: "whsec_test"}
+	delivery := s.deliver(context.Background(), webhookJob{hook: hook, event: "feed-data", body: []byte(`{"event":"feed-data"}`), createdAt: time.Now()})
+	assert.True(t, delivery.Success)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.StatusCode)
+	assert.Empty(t, delivery.Error)
+	assert.Equal(t, hook.ID, delivery.WebhookID)
+}
+
+func TestWebhookService_DeliverStopsOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookService(nil)
+	s.retryDelay = time.Millisecond
+	delivery := s.deliver(context.Background(), webhookJob{hook: models.Webhook{URL: srv.URL}, event: "feed-health", body: []byte(`{}`)})
+	assert.False(t, delivery.Success)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusGone, delivery.StatusCode)
+	assert.Contains(t, delivery.Error, "410")
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,26 @@
+package socket
+
+import (
+	"context"
+	"time"
+
+	coderws "nhooyr.io/websocket"
+)
+
+// accountDeletedFlushTimeout bounds waiting to deliver account-deleted before closing
+const accountDeletedFlushTimeout = time.Second
+
+// DisconnectUser tells a deleted user's clients on this instance their
+// account is gone and closes their connections
+func (m *Manager) DisconnectUser(userID string) {
+	for _, client := range m.rooms.Members(userRoom(userID)) {
+		m.rooms.LeaveAll(client)
+		client.send(makeMessage("account-deleted", map[string]string{"userId": userID}))
+		go func(c *Client) {
+			ctx, cancel := context.WithTimeout(context.Background(), accountDeletedFlushTimeout)
+			defer cancel()
+			c.flush(ctx)
+			c.closeWith(coderws.StatusPolicyViolation, "account deleted")
+		}(client)
+	}
+}
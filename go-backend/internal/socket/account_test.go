@@ -0,0 +1,29 @@
+package socket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_DisconnectUser(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	newClient := func(userID string) *Client {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 8)}
+		m.identifyClient(client, userID)
+		return client
+	}
+	alice, bob := newClient("alice"), newClient("bob")
+
+	m.DisconnectUser("alice")
+	assert.Empty(t, m.rooms.Rooms(alice))
+	assert.Equal(t, "account-deleted", (<-alice.out).Type)
+	assert.Eventually(t, func() bool { return alice.ctx.Err() != nil }, 2*accountDeletedFlushTimeout, 10*time.Millisecond)
+
+	assert.Equal(t, []string{userRoom("bob")}, m.rooms.Rooms(bob))
+	assert.NoError(t, bob.ctx.Err())
+}
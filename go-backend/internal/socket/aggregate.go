@@ -0,0 +1,272 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// aggregationWindows are the rollup windows a feed can request
+var aggregationWindows = map[string]time.Duration{
+	"1s":  time.Second,
+	"10s": 10 * time.Second,
+	"1m":  time.Minute,
+}
+
+// defaultAggregationWindows are computed when a feed names no windows
+var defaultAggregationWindows = []string{"1s", "10s", "1m"}
+
+const (
+	// maxAggregationFields caps how many fields one feed can aggregate
+	maxAggregationFields = 20
+	// aggregationFlushInterval is how often windows are closed when no new
+	// message arrives to close them
+	aggregationFlushInterval = 250 * time.Millisecond
+)
+
+// ValidateFeedAggregation checks a feed's aggregation options; nil is valid
+func ValidateFeedAggregation(a *models.FeedAggregation) error {
+	if a == nil {
+		return nil
+	}
+	if len(a.Fields) == 0 {
+		return errors.New("aggregation.fields must list at least one numeric field")
+	}
+	if len(a.Fields) > maxAggregationFields {
+		return fmt.Errorf("aggregation.fields may list at most %d fields", maxAggregationFields)
+	}
+	for _, field := range a.Fields {
+		if len(splitDataPath(field)) == 0 {
+			return fmt.Errorf("aggregation field %q is not a data path", field)
+		}
+	}
+	seen := make(map[string]bool, len(a.Windows))
+	for _, w := range a.Windows {
+		if _, ok := aggregationWindows[w]; !ok {
+			return fmt.Errorf("unsupported aggregation window %q (use 1s, 10s or 1m)", w)
+		}
+		if seen[w] {
+			return fmt.Errorf("aggregation window %q is listed twice", w)
+		}
+		seen[w] = true
+	}
+	return nil
+}
+
+// feedAggregator rolls one feed's messages up over its windows
+type feedAggregator struct {
+	feedID  string
+	opts    *models.FeedAggregation
+	mu      sync.Mutex
+	buckets map[string]*aggregateBucket // open bucket per window
+}
+
+// aggregateBucket accumulates the messages of one open window
+type aggregateBucket struct {
+	start  time.Time
+	count  int
+	fields map[string]*models.AggregateStats
+	sums   map[string]float64
+}
+
+func newFeedAggregator(feedID string, opts *models.FeedAggregation) *feedAggregator {
+	return &feedAggregator{feedID: feedID, opts: opts, buckets: make(map[string]*aggregateBucket)}
+}
+
+// windows returns the windows the feed aggregates over
+func (a *feedAggregator) windows() []string {
+	if len(a.opts.Windows) == 0 {
+		return defaultAggregationWindows
+	}
+	return a.opts.Windows
+}
+
+// add adds a message received at now to every window, returning the
+// rollups of windows it closed
+func (a *feedAggregator) add(data interface{}, now time.Time) []models.FeedAggregate {
+	if a.opts == nil || len(a.opts.Fields) == 0 {
+		return nil
+	}
+	values := make(map[string][]float64, len(a.opts.Fields))
+	for _, field := range a.opts.Fields {
+		if v, err := extractDataPath(data, field); err == nil {
+			values[field] = numericValues(v)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var closed []models.FeedAggregate
+	for _, w := range a.windows() {
+		start := now.Truncate(aggregationWindows[w])
+		b := a.buckets[w]
+		if b != nil && b.start.Before(start) {
+			closed = append(closed, a.rollup(w, b))
+			b = nil
+		}
+		if b == nil {
+			b = &aggregateBucket{start: start, fields: make(map[string]*models.AggregateStats), sums: make(map[string]float64)}
+			a.buckets[w] = b
+		}
+		b.count++
+		for field, vs := range values {
+			for _, v := range vs {
+				b.observe(field, v)
+			}
+		}
+	}
+	return closed
+}
+
+// flush closes the windows that ended by now, returning their rollups
+func (a *feedAggregator) flush(now time.Time) []models.FeedAggregate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var closed []models.FeedAggregate
+	for w, b := range a.buckets {
+		if !b.start.Add(aggregationWindows[w]).After(now) {
+			closed = append(closed, a.rollup(w, b))
+			delete(a.buckets, w)
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].End.Sub(closed[i].Start) < closed[j].End.Sub(closed[j].Start) })
+	return closed
+}
+
+// rollup summarises a bucket of window w
+func (a *feedAggregator) rollup(w string, b *aggregateBucket) models.FeedAggregate {
+	agg := models.FeedAggregate{
+		FeedID: a.feedID,
+		Window: w,
+		Start:  b.start,
+		End:    b.start.Add(aggregationWindows[w]),
+		Count:  b.count,
+		Fields: make(map[string]models.AggregateStats, len(b.fields)),
+	}
+	for field, stats := range b.fields {
+		s := *stats
+		s.Avg = b.sums[field] / float64(s.Count)
+		agg.Fields[field] = s
+	}
+	return agg
+}
+
+// observe adds one value of field to the bucket
+func (b *aggregateBucket) observe(field string, v float64) {
+	b.sums[field] += v
+	s, ok := b.fields[field]
+	if !ok {
+		b.fields[field] = &models.AggregateStats{Count: 1, Min: v, Max: v, Open: v, Close: v}
+		return
+	}
+	s.Count++
+	if v < s.Min {
+		s.Min = v
+	}
+	if v > s.Max {
+		s.Max = v
+	}
+	s.Close = v
+}
+
+// numericValues returns the numbers in an extracted value. Arrays count each
+// numeric item and numeric strings, common for exchange prices, are parsed.
+func numericValues(v interface{}) []float64 {
+	switch n := v.(type) {
+	case float64:
+		return []float64{n}
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil {
+			return []float64{f}
+		}
+	case []interface{}:
+		var out []float64
+		for _, item := range n {
+			out = append(out, numericValues(item)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// aggregate adds a transformed message to the feed's rollups and broadcasts
+// the windows it closed
+func (m *Manager) aggregate(feed models.WebSocketFeed, data interface{}, now time.Time) {
+	feedID := feed.ID.Hex()
+	m.aggregateMu.Lock()
+	if m.aggregators == nil {
+		m.aggregators = make(map[string]*feedAggregator)
+	}
+	a, ok := m.aggregators[feedID]
+	if !ok {
+		a = newFeedAggregator(feedID, feed.Aggregation)
+		m.aggregators[feedID] = a
+	}
+	m.aggregateMu.Unlock()
+
+	m.broadcastAggregates(a.add(data, now))
+}
+
+// broadcastAggregates sends closed rollups to the feed's data room and keeps
+// the LLM's summary of the feed current
+func (m *Manager) broadcastAggregates(aggs []models.FeedAggregate) {
+	for _, agg := range aggs {
+		if m.llm != nil {
+			m.llm.SetFeedAggregate(agg)
+		}
+		room := dataRoom(agg.FeedID)
+		msg := makeMessage("feed-aggregate", agg)
+		m.rooms.Broadcast(room, msg)
+		m.publishToCluster(room, msg)
+	}
+}
+
+// SetFeedAggregation replaces a connected feed's aggregation options after
+// the feed is updated. Open windows are dropped without being broadcast.
+func (m *Manager) SetFeedAggregation(feedID string, opts *models.FeedAggregation) {
+	m.aggregateMu.Lock()
+	defer m.aggregateMu.Unlock()
+	if m.aggregators == nil {
+		m.aggregators = make(map[string]*feedAggregator)
+	}
+	m.aggregators[feedID] = newFeedAggregator(feedID, opts)
+	if opts == nil && m.llm != nil {
+		m.llm.ClearFeedAggregates(feedID)
+	}
+}
+
+// RunAggregations closes rollup windows that ended without a later message
+// arriving to close them. It blocks until ctx is cancelled.
+func (m *Manager) RunAggregations(ctx context.Context) {
+	ticker := time.NewTicker(aggregationFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.flushAggregates(now.UTC())
+		}
+	}
+}
+
+// flushAggregates broadcasts every window that ended by now
+func (m *Manager) flushAggregates(now time.Time) {
+	m.aggregateMu.Lock()
+	aggregators := make([]*feedAggregator, 0, len(m.aggregators))
+	for _, a := range m.aggregators {
+		aggregators = append(aggregators, a)
+	}
+	m.aggregateMu.Unlock()
+
+	for _, a := range aggregators {
+		m.broadcastAggregates(a.flush(now))
+	}
+}
@@ -0,0 +1,114 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestValidateFeedAggregation(t *testing.T) {
+	assert.NoError(t, ValidateFeedAggregation(nil))
+	assert.NoError(t, ValidateFeedAggregation(&models.FeedAggregation{Fields: []string{"$.price"}}))
+	assert.NoError(t, ValidateFeedAggregation(&models.FeedAggregation{Fields: []string{"$.price", "qty"}, Windows: []string{"1s", "1m"}}))
+	assert.Error(t, ValidateFeedAggregation(&models.FeedAggregation{}))
+	assert.Error(t, ValidateFeedAggregation(&models.FeedAggregation{Fields: []string{"$"}}))
+	assert.Error(t, ValidateFeedAggregation(&models.FeedAggregation{Fields: []string{"price"}, Windows: []string{"5m"}}))
+	assert.Error(t, ValidateFeedAggregation(&models.FeedAggregation{Fields: []string{"price"}, Windows: []string{"1s", "1s"}}))
+	assert.Error(t, ValidateFeedAggregation(&models.FeedAggregation{Fields: make([]string, maxAggregationFields+1)}))
+}
+
+func TestFeedAggregator_Rollup(t *testing.T) {
+	a := newFeedAggregator("feed-1", &models.FeedAggregation{Fields: []string{"$.price", "$.sizes"}, Windows: []string{"1s"}})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, a.add(map[string]interface{}{"price": 10.0, "sizes": []interface{}{1.0, 2.0}}, start))
+	assert.Empty(t, a.add(map[string]interface{}{"price": "14"}, start.Add(200*time.Millisecond)))
+	assert.Empty(t, a.add(map[string]interface{}{"price": 8.0}, start.Add(400*time.Millisecond)))
+	assert.Empty(t, a.add(map[string]interface{}{"other": true}, start.Add(600*time.Millisecond)))
+	assert.Empty(t, a.add(map[string]interface{}{"price": 12.0}, start.Add(800*time.Millisecond)))
+
+	// The first message of the next window closes the previous one
+	closed := a.add(map[string]interface{}{"price": 20.0}, start.Add(time.Second))
+	require.Len(t, closed, 1)
+	agg := closed[0]
+	assert.Equal(t, "feed-1", agg.FeedID)
+	assert.Equal(t, "1s", agg.Window)
+	assert.Equal(t, start, agg.Start)
+	assert.Equal(t, start.Add(time.Second), agg.End)
+	assert.Equal(t, 5, agg.Count)
+	assert.Equal(t, models.AggregateStats{Count: 4, Min: 8, Max: 14, Avg: 11, Open: 10, Close: 12}, agg.Fields["$.price"])
+	assert.Equal(t, models.AggregateStats{Count: 2, Min: 1, Max: 2, Avg: 1.5, Open: 1, Close: 2}, agg.Fields["$.sizes"])
+}
+
+func TestFeedAggregator_Flush(t *testing.T) {
+	a := newFeedAggregator("feed-1", &models.FeedAggregation{Fields: []string{"v"}})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.add(map[string]interface{}{"v": 1.0}, start)
+
+	assert.Empty(t, a.flush(start.Add(500*time.Millisecond)))
+	closed := a.flush(start.Add(10 * time.Second))
+	require.Len(t, closed, 2)
+	assert.Equal(t, "1s", closed[0].Window)
+	assert.Equal(t, "10s", closed[1].Window)
+
+	closed = a.flush(start.Add(time.Minute))
+	require.Len(t, closed, 1)
+	assert.Equal(t, "1m", closed[0].Window)
+	assert.Equal(t, 1, closed[0].Count)
+	// Windows without messages are not broadcast
+	assert.Empty(t, a.flush(start.Add(2*time.Minute)))
+
+	assert.Empty(t, newFeedAggregator("feed-2", nil).add(map[string]interface{}{"v": 1.0}, start))
+}
+
+func TestManager_BroadcastAggregates(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+
+	// Throttled messages still count towards the rollups
+	feed := models.WebSocketFeed{
+		ID:          primitive.NewObjectID(),
+		Throttle:    &models.FeedThrottle{DedupeKey: "$"},
+		Aggregation: &models.FeedAggregation{Fields: []string{"price"}, Windows: []string{"1s"}},
+	}
+	m.rooms.Join(dataRoom(feed.ID.Hex()), client)
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 5.0}, "")
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 5.0}, "")
+	m.flushAggregates(time.Now().UTC().Add(time.Second))
+
+	var aggregates []models.FeedAggregate
+	for len(client.out) > 0 {
+		if msg := <-client.out; msg.Type == "feed-aggregate" {
+			var agg models.FeedAggregate
+			require.NoError(t, json.Unmarshal(msg.Payload, &agg))
+			aggregates = append(aggregates, agg)
+		}
+	}
+	// Both messages land in one window unless they straddle a second boundary
+	require.NotEmpty(t, aggregates)
+	count := 0
+	for _, agg := range aggregates {
+		assert.Equal(t, feed.ID.Hex(), agg.FeedID)
+		assert.Equal(t, 5.0, agg.Fields["price"].Avg)
+		count += agg.Count
+	}
+	assert.Equal(t, 2, count)
+
+	// Turning aggregation off drops the open windows
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 6.0}, "")
+	m.SetFeedAggregation(feed.ID.Hex(), nil)
+	m.flushAggregates(time.Now().UTC().Add(time.Second))
+	for len(client.out) > 0 {
+		assert.NotEqual(t, "feed-aggregate", (<-client.out).Type)
+	}
+}
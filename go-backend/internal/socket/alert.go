@@ -0,0 +1,361 @@
+package socket
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+const (
+	// defaultAlertCooldown is the minimum time between alerts of a rule
+	// without its own cooldown
+	defaultAlertCooldown = time.Minute
+	// alertCheckInterval is how often silence rules are checked
+	alertCheckInterval = time.Second
+	// alertRulesRefreshInterval is how often a feed's rules are reloaded, which
+	// picks up edits made through other instances
+	alertRulesRefreshInterval = 30 * time.Second
+	// minAlertSilence is the shortest quiet period a silence rule can watch for
+	minAlertSilence = 5 * time.Second
+	// alertIdleTimeout is how long rules of a feed without an upstream
+	// connection here, such as one published through the API, are kept after
+	// its last message
+	alertIdleTimeout = 10 * time.Minute
+)
+
+// alertSilencePattern matches silence conditions such as "no message for 60s"
+var alertSilencePattern = regexp.MustCompile(`^\s*no (?:messages?|data) for (\S+)\s*$`)
+
+// alertCondition is a parsed alert rule condition: a filter on each message,
+// or a quiet period after which the rule fires
+type alertCondition struct {
+	filter  *feedFilter
+	silence time.Duration
+}
+
+// parseAlertCondition parses a subscription-filter expression evaluated
+// against the transformed message, or "no message for <duration>"
+func parseAlertCondition(expr string) (alertCondition, error) {
+	if m := alertSilencePattern.FindStringSubmatch(expr); m != nil {
+		d, err := time.ParseDuration(m[1])
+		if err != nil || d < minAlertSilence {
+			return alertCondition{}, fmt.Errorf("invalid alert condition %q: quiet period must be a duration of at least %s", expr, minAlertSilence)
+		}
+		return alertCondition{silence: d}, nil
+	}
+	f, err := parseFilter(expr)
+	if err != nil {
+		return alertCondition{}, fmt.Errorf("invalid alert condition %q: expected <path> <op> <value> or \"no message for <duration>\"", expr)
+	}
+	return alertCondition{filter: &f}, nil
+}
+
+// ValidateAlertCondition reports whether an alert rule condition can be evaluated
+func ValidateAlertCondition(expr string) error {
+	_, err := parseAlertCondition(expr)
+	return err
+}
+
+// alertState tracks one rule between messages
+type alertState struct {
+	rule      models.AlertRule
+	cond      alertCondition
+	met       bool // the condition held at the last check; rules fire when it starts to hold
+	lastFired time.Time
+}
+
+// cooldown returns the minimum time between the rule's alerts
+func (s *alertState) cooldown() time.Duration {
+	if s.rule.CooldownSeconds > 0 {
+		return time.Duration(s.rule.CooldownSeconds) * time.Second
+	}
+	return defaultAlertCooldown
+}
+
+// trigger records whether the condition holds at now and reports whether the
+// rule fires: the condition must have just started to hold, outside the cooldown
+func (s *alertState) trigger(met bool, now time.Time) bool {
+	fire := met && !s.met && (s.lastFired.IsZero() || now.Sub(s.lastFired) >= s.cooldown())
+	s.met = met
+	if fire {
+		s.lastFired = now
+	}
+	return fire
+}
+
+// feedAlerts holds the alert rules of one feed connected on this instance
+type feedAlerts struct {
+	feedID      string
+	feedName    string
+	mu          sync.Mutex
+	rules       []*alertState
+	loadedAt    time.Time // zero until the rules are first loaded
+	loading     bool
+	lastMessage time.Time
+}
+
+// setRules replaces the feed's rules, keeping the state of rules that
+// survive so a reload does not re-fire them
+func (f *feedAlerts) setRules(rules []models.AlertRule, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous := make(map[string]*alertState, len(f.rules))
+	for _, s := range f.rules {
+		previous[s.rule.ID.Hex()] = s
+	}
+	f.rules = f.rules[:0]
+	for _, rule := range rules {
+		cond, err := parseAlertCondition(rule.Condition)
+		if err != nil {
+			slog.Warn("skipping alert rule", "rule_id", rule.ID.Hex(), "feed_id", f.feedID, "error", err)
+			continue
+		}
+		s := &alertState{rule: rule, cond: cond}
+		if old, ok := previous[rule.ID.Hex()]; ok && old.rule.Condition == rule.Condition {
+			s.met, s.lastFired = old.met, old.lastFired
+		}
+		f.rules = append(f.rules, s)
+	}
+	f.loadedAt = now
+	f.loading = false
+}
+
+// observe checks a message against the feed's filter rules and returns the alerts it triggers
+func (f *feedAlerts) observe(data interface{}, now time.Time) []models.FeedAlert {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastMessage = now
+	var fired []models.FeedAlert
+	for _, s := range f.rules {
+		if s.cond.filter == nil {
+			// A message ends the quiet period
+			s.met = false
+			continue
+		}
+		if s.trigger(s.cond.filter.matches(data), now) {
+			fired = append(fired, f.alert(s, data, now))
+		}
+	}
+	return fired
+}
+
+// checkSilence returns the alerts of silence rules whose quiet period passed by now
+func (f *feedAlerts) checkSilence(now time.Time) []models.FeedAlert {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	since := f.lastMessage
+	if since.Before(f.loadedAt) {
+		since = f.loadedAt
+	}
+	var fired []models.FeedAlert
+	for _, s := range f.rules {
+		if s.cond.filter != nil || since.IsZero() {
+			continue
+		}
+		if s.trigger(now.Sub(since) >= s.cond.silence, now) {
+			fired = append(fired, f.alert(s, nil, now))
+		}
+	}
+	return fired
+}
+
+// idle reports whether the feed has had no message, and no rule load, for alertIdleTimeout
+func (f *feedAlerts) idle(now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	last := f.lastMessage
+	if last.Before(f.loadedAt) {
+		last = f.loadedAt
+	}
+	return !last.IsZero() && now.Sub(last) >= alertIdleTimeout
+}
+
+// alert describes a rule firing at now
+func (f *feedAlerts) alert(s *alertState, data interface{}, now time.Time) models.FeedAlert {
+	return models.FeedAlert{
+		RuleID:      s.rule.ID.Hex(),
+		FeedID:      f.feedID,
+		FeedName:    f.feedName,
+		UserID:      s.rule.UserID,
+		Name:        s.rule.Name,
+		Condition:   s.rule.Condition,
+		Data:        data,
+		TriggeredAt: now,
+	}
+}
+
+// SetAlertService sets the service that stores alert rules and sends alert
+// emails and webhooks; without one no alerts are evaluated
+func (m *Manager) SetAlertService(alerts *services.AlertService) {
+	m.alerts = alerts
+}
+
+// evaluateAlerts checks a transformed message against the feed's alert rules.
+// A feed's rules are loaded in the background on its first message.
+func (m *Manager) evaluateAlerts(feed models.WebSocketFeed, data interface{}, now time.Time) {
+	if m.alerts == nil {
+		return
+	}
+	f := m.feedAlertsFor(feed.ID.Hex(), feed.Name)
+	m.deliverAlerts(f.observe(data, now))
+}
+
+// feedAlertsFor returns the feed's alert state, starting to load its rules
+// when the feed is new
+func (m *Manager) feedAlertsFor(feedID, feedName string) *feedAlerts {
+	m.alertMu.Lock()
+	if m.feedAlerts == nil {
+		m.feedAlerts = make(map[string]*feedAlerts)
+	}
+	f, ok := m.feedAlerts[feedID]
+	if !ok {
+		f = &feedAlerts{feedID: feedID, feedName: feedName}
+		m.feedAlerts[feedID] = f
+	}
+	m.alertMu.Unlock()
+	if !ok {
+		m.refreshAlertRules(f)
+	}
+	return f
+}
+
+// refreshAlertRules reloads a feed's rules in the background unless a load is running
+func (m *Manager) refreshAlertRules(f *feedAlerts) {
+	f.mu.Lock()
+	if f.loading {
+		f.mu.Unlock()
+		return
+	}
+	f.loading = true
+	f.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		rules, err := m.alerts.FeedAlertRules(ctx, f.feedID)
+		if err != nil {
+			slog.Warn("failed to load alert rules", "feed_id", f.feedID, "error", err)
+			f.mu.Lock()
+			f.loading = false
+			f.mu.Unlock()
+			return
+		}
+		f.setRules(rules, time.Now().UTC())
+	}()
+}
+
+// ReloadAlertRules picks up edited alert rules for a feed connected on this instance
+func (m *Manager) ReloadAlertRules(feedID string) {
+	if m.alerts == nil {
+		return
+	}
+	m.alertMu.Lock()
+	f, ok := m.feedAlerts[feedID]
+	m.alertMu.Unlock()
+	if ok {
+		m.refreshAlertRules(f)
+	}
+}
+
+// deliverAlerts sends triggered alerts to their owners' clients and feed
+// webhooks, then records them and sends their emails and rule webhooks in the
+// background
+func (m *Manager) deliverAlerts(alerts []models.FeedAlert) {
+	for _, alert := range alerts {
+		slog.Info("alert fired", "rule_id", alert.RuleID, "feed_id", alert.FeedID, "user_id", alert.UserID)
+		room := userRoom(alert.UserID)
+		msg := makeMessage("feed-alert", alert)
+		m.rooms.Broadcast(room, msg)
+		m.publishToCluster(room, msg)
+		m.dispatchWebhooks(alert.FeedID, alert.UserID, services.WebhookEventFeedAlert, alert)
+	}
+	if len(alerts) > 0 && m.alerts != nil {
+		go m.notifyAlerts(alerts)
+	}
+}
+
+// notifyAlerts records when rules fired and sends their emails and webhooks
+func (m *Manager) notifyAlerts(alerts []models.FeedAlert) {
+	for _, alert := range alerts {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		rule := m.alertRule(alert.FeedID, alert.RuleID)
+		if rule != nil {
+			if err := m.alerts.MarkAlertTriggered(ctx, rule.ID, alert.TriggeredAt); err != nil {
+				slog.Warn("failed to record alert", "rule_id", alert.RuleID, "error", err)
+			}
+			if err := m.alerts.Notify(ctx, *rule, alert); err != nil {
+				slog.Warn("failed to deliver alert", "rule_id", alert.RuleID, "error", err)
+			}
+		}
+		cancel()
+	}
+}
+
+// alertRule returns the loaded rule with the given ID
+func (m *Manager) alertRule(feedID, ruleID string) *models.AlertRule {
+	m.alertMu.Lock()
+	f, ok := m.feedAlerts[feedID]
+	m.alertMu.Unlock()
+	if !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.rules {
+		if s.rule.ID.Hex() == ruleID {
+			rule := s.rule
+			return &rule
+		}
+	}
+	return nil
+}
+
+// RunAlerts fires silence rules and periodically reloads alert rules. Feeds
+// that stopped receiving messages on this instance are forgotten. It blocks
+// until ctx is cancelled.
+func (m *Manager) RunAlerts(ctx context.Context) {
+	if m.alerts == nil {
+		return
+	}
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.checkAlerts(now.UTC())
+		}
+	}
+}
+
+// checkAlerts fires silence rules whose quiet period passed by now and
+// reloads rules that are due
+func (m *Manager) checkAlerts(now time.Time) {
+	m.alertMu.Lock()
+	feeds := make([]*feedAlerts, 0, len(m.feedAlerts))
+	for feedID, f := range m.feedAlerts {
+		if !m.IsFeedConnected(feedID) && f.idle(now) {
+			delete(m.feedAlerts, feedID)
+			continue
+		}
+		feeds = append(feeds, f)
+	}
+	m.alertMu.Unlock()
+
+	for _, f := range feeds {
+		f.mu.Lock()
+		due := !f.loadedAt.IsZero() && now.Sub(f.loadedAt) >= alertRulesRefreshInterval
+		f.mu.Unlock()
+		if due {
+			m.refreshAlertRules(f)
+		}
+		m.deliverAlerts(f.checkSilence(now))
+	}
+}
@@ -0,0 +1,103 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestParseAlertCondition(t *testing.T) {
+	cond, err := parseAlertCondition("price > 70000")
+	require.NoError(t, err)
+	require.NotNil(t, cond.filter)
+	assert.Equal(t, "price", cond.filter.path)
+
+	cond, err = parseAlertCondition("no message for 60s")
+	require.NoError(t, err)
+	assert.Nil(t, cond.filter)
+	assert.Equal(t, time.Minute, cond.silence)
+
+	cond, err = parseAlertCondition(" no data for 5m ")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cond.silence)
+
+	assert.Error(t, ValidateAlertCondition(""))
+	assert.Error(t, ValidateAlertCondition("price >"))
+	assert.Error(t, ValidateAlertCondition("no message for soon"))
+	assert.Error(t, ValidateAlertCondition("no message for 1s"))
+}
+
+func TestFeedAlerts_Threshold(t *testing.T) {
+	f := &feedAlerts{feedID: "feed-1", feedName: "BTC"}
+	ruleID := primitive.NewObjectID()
+	f.setRules([]models.AlertRule{{ID: ruleID, UserID: "user-1", Condition: "price > 100", CooldownSeconds: 10}}, time.Now())
+	start := time.Now()
+
+	assert.Empty(t, f.observe(map[string]interface{}{"price": 90.0}, start))
+	fired := f.observe(map[string]interface{}{"price": 120.0}, start.Add(time.Second))
+	require.Len(t, fired, 1)
+	assert.Equal(t, ruleID.Hex(), fired[0].RuleID)
+	assert.Equal(t, "user-1", fired[0].UserID)
+	assert.Equal(t, "BTC", fired[0].FeedName)
+	assert.Equal(t, map[string]interface{}{"price": 120.0}, fired[0].Data)
+
+	// The rule fires when the condition starts to hold, not on every match
+	assert.Empty(t, f.observe(map[string]interface{}{"price": 130.0}, start.Add(2*time.Second)))
+	// Crossing again within the cooldown stays quiet
+	assert.Empty(t, f.observe(map[string]interface{}{"price": 90.0}, start.Add(3*time.Second)))
+	assert.Empty(t, f.observe(map[string]interface{}{"price": 110.0}, start.Add(4*time.Second)))
+	assert.Empty(t, f.observe(map[string]interface{}{"price": 90.0}, start.Add(12*time.Second)))
+	assert.Len(t, f.observe(map[string]interface{}{"price": 110.0}, start.Add(13*time.Second)), 1)
+
+	// Reloading the same rule keeps its state
+	f.setRules([]models.AlertRule{{ID: ruleID, UserID: "user-1", Condition: "price > 100", CooldownSeconds: 10}}, time.Now())
+	assert.Empty(t, f.observe(map[string]interface{}{"price": 150.0}, start.Add(30*time.Second)))
+}
+
+func TestFeedAlerts_Silence(t *testing.T) {
+	f := &feedAlerts{feedID: "feed-1"}
+	start := time.Now()
+	f.setRules([]models.AlertRule{{ID: primitive.NewObjectID(), UserID: "user-1", Condition: "no message for 10s"}}, start)
+
+	assert.Empty(t, f.checkSilence(start.Add(5*time.Second)))
+	fired := f.checkSilence(start.Add(10 * time.Second))
+	require.Len(t, fired, 1)
+	assert.Nil(t, fired[0].Data)
+	// Still quiet: no repeat until a message arrives
+	assert.Empty(t, f.checkSilence(start.Add(20*time.Second)))
+
+	f.observe(map[string]interface{}{"v": 1.0}, start.Add(21*time.Second))
+	assert.Empty(t, f.checkSilence(start.Add(25*time.Second)))
+	assert.Len(t, f.checkSilence(start.Add(90*time.Second)), 1)
+}
+
+func TestManager_DeliverAlertsToUser(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	owner := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+	other := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+	m.identifyClient(owner, "user-1")
+	m.identifyClient(other, "user-2")
+
+	m.deliverAlerts([]models.FeedAlert{{RuleID: "r1", FeedID: "feed-1", UserID: "user-1", Condition: "price > 1"}})
+	require.Len(t, owner.out, 1)
+	msg := <-owner.out
+	assert.Equal(t, "feed-alert", msg.Type)
+	var alert models.FeedAlert
+	require.NoError(t, json.Unmarshal(msg.Payload, &alert))
+	assert.Equal(t, "r1", alert.RuleID)
+	assert.Empty(t, other.out)
+
+	// Switching users leaves the previous user's room
+	m.identifyClient(owner, "user-3")
+	m.deliverAlerts([]models.FeedAlert{{RuleID: "r2", FeedID: "feed-1", UserID: "user-1"}})
+	assert.Empty(t, owner.out)
+}
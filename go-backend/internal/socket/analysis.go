@@ -0,0 +1,127 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+const (
+	// analysisCheckInterval is how often due analysis schedules are claimed
+	analysisCheckInterval = 15 * time.Second
+	// analysisTimeout bounds one scheduled analysis
+	analysisTimeout = 60 * time.Second
+)
+
+// SetAnalysisService sets the service that stores scheduled analyses;
+// without one no schedules are run
+func (m *Manager) SetAnalysisService(analyses *services.AnalysisService) {
+	m.analyses = analyses
+}
+
+// RunAnalyses runs due analysis schedules of the feeds with data on this
+// instance, sending each result to its owner as an analysis-result event. It
+// blocks until ctx is cancelled.
+func (m *Manager) RunAnalyses(ctx context.Context) {
+	if m.analyses == nil || m.llm == nil {
+		return
+	}
+	ticker := time.NewTicker(analysisCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.checkAnalyses(ctx, now.UTC())
+		}
+	}
+}
+
+// checkAnalyses claims the schedules due at now and runs each in the background
+func (m *Manager) checkAnalyses(ctx context.Context, now time.Time) {
+	updates := m.llm.ContextUpdates()
+	feedIDs := make([]string, 0, len(updates))
+	for feedID := range updates {
+		feedIDs = append(feedIDs, feedID)
+	}
+	claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	due, err := m.analyses.ClaimDueSchedules(claimCtx, feedIDs, now)
+	if err != nil {
+		slog.Warn("failed to claim analysis schedules", "error", err)
+	}
+	for _, schedule := range due {
+		// Runs with no new data since the last one would repeat its answer
+		if schedule.LastRunAt != nil && !updates[schedule.FeedID].After(*schedule.LastRunAt) {
+			continue
+		}
+		if !m.startLLMQuery() {
+			return
+		}
+		go func(schedule models.AnalysisSchedule) {
+			defer m.llmWG.Done()
+			m.runAnalysis(schedule, now)
+		}(schedule)
+	}
+}
+
+// runAnalysis runs one schedule, charging the owner's token quota unless it
+// ran on their own key, then stores and sends the result
+func (m *Manager) runAnalysis(schedule models.AnalysisSchedule, now time.Time) {
+	// Each run gets a request ID so its provider calls can be traced
+	runCtx := logging.With(logging.WithRequestID(context.Background(), logging.NewID()), "schedule_id", schedule.ID.Hex())
+	ctx, cancel := context.WithTimeout(runCtx, analysisTimeout)
+	defer cancel()
+
+	req := services.AnalysisRequest(schedule)
+	analysis := models.Analysis{
+		ScheduleID: schedule.ID,
+		UserID:     schedule.UserID,
+		FeedID:     schedule.FeedID,
+		Name:       schedule.Name,
+		Prompt:     req.Question,
+		CreatedAt:  now,
+	}
+	if fc := m.llm.GetFeedContext(schedule.FeedID); fc != nil {
+		analysis.FeedName = fc.FeedName
+	}
+
+	// Access may have been revoked or the subscription lapsed since the schedule was made
+	if err := m.AuthorizeFeedRead(ctx, Subscriber{UserID: schedule.UserID}, schedule.FeedID); err != nil {
+		analysis.Error = err.Error()
+	} else if quota, ok := m.withinQuota(ctx, schedule.UserID, req); !ok {
+		analysis.Error = quotaExceededError(quota)
+	} else if resp, err := m.llm.Query(ctx, req); err != nil {
+		analysis.Error = err.Error()
+	} else {
+		analysis.Provider = resp.Provider
+		analysis.Answer = resp.Answer
+		analysis.PromptTokens = resp.PromptTokens
+		analysis.CompletionTokens = resp.CompletionTokens
+		analysis.DurationMs = resp.Duration
+		analysis.OwnKey = resp.OwnKey
+		if m.auth != nil && !resp.OwnKey {
+			if userID, err := primitive.ObjectIDFromHex(schedule.UserID); err == nil {
+				if err := m.auth.UpdateTokenUsage(ctx, userID, resp.TokensUsed); err != nil {
+					slog.WarnContext(ctx, "failed to update token usage", "user_id", schedule.UserID, "error", err)
+				}
+			}
+		}
+		m.recordLLMUsage(schedule.UserID, "scheduled", resp)
+	}
+
+	if err := m.analyses.RecordAnalysis(ctx, &analysis); err != nil {
+		slog.WarnContext(ctx, "failed to store analysis", "error", err)
+	}
+	room := userRoom(schedule.UserID)
+	msg := makeMessage("analysis-result", analysis)
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
+}
@@ -0,0 +1,307 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// Anomaly detection methods
+const (
+	anomalyZScore = "zscore"
+	anomalyEWMA   = "ewma"
+)
+
+const (
+	// maxAnomalyFields caps how many fields one feed can watch
+	maxAnomalyFields = 20
+	// defaultAnomalyThreshold is how many standard deviations from normal
+	// count as an anomaly when a feed sets no threshold
+	defaultAnomalyThreshold = 3.0
+	// defaultAnomalyWindow is the z-score window or EWMA span when a feed sets none
+	defaultAnomalyWindow = 100
+	// maxAnomalyWindow caps FeedAnomalyDetection.Window
+	maxAnomalyWindow = 10000
+	// minAnomalySamples is how many values of a field are seen before any
+	// can be anomalous
+	minAnomalySamples = 20
+	// anomalyCooldown is the least time between anomalies of one field, so a
+	// burst of unusual values is reported once
+	anomalyCooldown = 10 * time.Second
+	// anomalyExplainInterval is the least time between AI explanations of one
+	// feed's anomalies, as they are paid for by the server
+	anomalyExplainInterval = time.Minute
+	// anomalyExplainTimeout bounds one explanation
+	anomalyExplainTimeout = 60 * time.Second
+)
+
+// ValidateFeedAnomalyDetection checks a feed's anomaly detection options;
+// nil is valid
+func ValidateFeedAnomalyDetection(d *models.FeedAnomalyDetection) error {
+	if d == nil {
+		return nil
+	}
+	if len(d.Fields) == 0 {
+		return errors.New("anomalyDetection.fields must list at least one numeric field")
+	}
+	if len(d.Fields) > maxAnomalyFields {
+		return fmt.Errorf("anomalyDetection.fields may list at most %d fields", maxAnomalyFields)
+	}
+	for _, field := range d.Fields {
+		if len(splitDataPath(field)) == 0 {
+			return fmt.Errorf("anomaly detection field %q is not a data path", field)
+		}
+	}
+	if d.Method != "" && d.Method != anomalyZScore && d.Method != anomalyEWMA {
+		return fmt.Errorf("unsupported anomaly detection method %q (use zscore or ewma)", d.Method)
+	}
+	if d.Threshold < 0 || d.Threshold > 100 {
+		return errors.New("anomalyDetection.threshold must be between 0 and 100")
+	}
+	if d.Window < 0 || d.Window > maxAnomalyWindow {
+		return fmt.Errorf("anomalyDetection.window must be between 0 and %d", maxAnomalyWindow)
+	}
+	if d.Window > 0 && d.Window < minAnomalySamples {
+		return fmt.Errorf("anomalyDetection.window must be at least %d", minAnomalySamples)
+	}
+	return nil
+}
+
+// feedAnomalyDetector watches one feed's fields for unusual values
+type feedAnomalyDetector struct {
+	feedID        string
+	opts          *models.FeedAnomalyDetection
+	mu            sync.Mutex
+	fields        map[string]*anomalyState
+	lastExplained time.Time
+}
+
+// anomalyState is what is known of one field's normal values
+type anomalyState struct {
+	values         []float64 // last window values, as a ring, for zscore
+	next           int       // ring position of the next value
+	count          int       // values seen
+	mean, variance float64   // exponentially weighted, for ewma
+	lastAnomaly    time.Time
+}
+
+func newFeedAnomalyDetector(feedID string, opts *models.FeedAnomalyDetection) *feedAnomalyDetector {
+	return &feedAnomalyDetector{feedID: feedID, opts: opts, fields: make(map[string]*anomalyState)}
+}
+
+// method returns the feed's detection method
+func (d *feedAnomalyDetector) method() string {
+	if d.opts.Method == "" {
+		return anomalyZScore
+	}
+	return d.opts.Method
+}
+
+// threshold returns the standard deviations that count as an anomaly
+func (d *feedAnomalyDetector) threshold() float64 {
+	if d.opts.Threshold <= 0 {
+		return defaultAnomalyThreshold
+	}
+	return d.opts.Threshold
+}
+
+// window returns the z-score window or EWMA span
+func (d *feedAnomalyDetector) window() int {
+	if d.opts.Window <= 0 {
+		return defaultAnomalyWindow
+	}
+	return d.opts.Window
+}
+
+// observe checks a message's values received at now against each field's
+// normal values, then adds them to those, returning the anomalies found
+func (d *feedAnomalyDetector) observe(data interface{}, now time.Time) []models.FeedAnomaly {
+	if d.opts == nil || len(d.opts.Fields) == 0 {
+		return nil
+	}
+	method, threshold, window := d.method(), d.threshold(), d.window()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var anomalies []models.FeedAnomaly
+	for _, field := range d.opts.Fields {
+		v, err := extractDataPath(data, field)
+		if err != nil {
+			continue
+		}
+		s, ok := d.fields[field]
+		if !ok {
+			s = &anomalyState{}
+			d.fields[field] = s
+		}
+		for _, value := range numericValues(v) {
+			expected, stdDev, ok := s.baseline(method)
+			if ok && stdDev > 0 {
+				score := (value - expected) / stdDev
+				if math.Abs(score) >= threshold && now.Sub(s.lastAnomaly) >= anomalyCooldown {
+					s.lastAnomaly = now
+					anomalies = append(anomalies, models.FeedAnomaly{
+						ID:        primitive.NewObjectID().Hex(),
+						FeedID:    d.feedID,
+						Field:     field,
+						Value:     value,
+						Expected:  expected,
+						StdDev:    stdDev,
+						Score:     score,
+						Severity:  anomalySeverity(score, threshold),
+						Method:    method,
+						Timestamp: now,
+					})
+				}
+			}
+			s.add(value, method, window)
+		}
+	}
+	return anomalies
+}
+
+// explainDue reports whether an anomaly found at now should be explained,
+// counting it as explained if so
+func (d *feedAnomalyDetector) explainDue(now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.opts == nil || !d.opts.Explain || now.Sub(d.lastExplained) < anomalyExplainInterval {
+		return false
+	}
+	d.lastExplained = now
+	return true
+}
+
+// baseline returns the field's normal value and spread, once enough values
+// have been seen to judge
+func (s *anomalyState) baseline(method string) (expected, stdDev float64, ok bool) {
+	if s.count < minAnomalySamples {
+		return 0, 0, false
+	}
+	if method == anomalyEWMA {
+		return s.mean, math.Sqrt(s.variance), true
+	}
+	var sum float64
+	for _, v := range s.values {
+		sum += v
+	}
+	mean := sum / float64(len(s.values))
+	var squares float64
+	for _, v := range s.values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(s.values))), true
+}
+
+// add makes a value part of the field's normal values
+func (s *anomalyState) add(v float64, method string, window int) {
+	s.count++
+	if method == anomalyEWMA {
+		if s.count == 1 {
+			s.mean = v
+			return
+		}
+		alpha := 2 / (float64(window) + 1)
+		diff := v - s.mean
+		incr := alpha * diff
+		s.mean += incr
+		s.variance = (1 - alpha) * (s.variance + diff*incr)
+		return
+	}
+	if len(s.values) < window {
+		s.values = append(s.values, v)
+		return
+	}
+	s.values[s.next] = v
+	s.next = (s.next + 1) % window
+}
+
+// anomalySeverity grades an anomaly by how far past the threshold it is
+func anomalySeverity(score, threshold float64) string {
+	switch score = math.Abs(score); {
+	case score >= 2*threshold:
+		return "high"
+	case score >= 1.5*threshold:
+		return "medium"
+	}
+	return "low"
+}
+
+// detectAnomalies checks a transformed message for unusual values, sending
+// each anomaly found to the feed's data room
+func (m *Manager) detectAnomalies(feed models.WebSocketFeed, data interface{}, now time.Time) {
+	feedID := feed.ID.Hex()
+	m.anomalyMu.Lock()
+	d, ok := m.anomalyDetectors[feedID]
+	if !ok {
+		d = newFeedAnomalyDetector(feedID, feed.AnomalyDetection)
+		m.anomalyDetectors[feedID] = d
+	}
+	m.anomalyMu.Unlock()
+
+	for _, anomaly := range d.observe(data, now) {
+		anomaly.FeedName = feed.Name
+		m.broadcastAnomaly("feed-anomaly", anomaly)
+		if d.explainDue(now) {
+			m.explainAnomaly(anomaly)
+		}
+	}
+}
+
+// explainAnomaly asks the AI about an anomaly in the background and sends
+// its answer as a feed-anomaly-explained event
+func (m *Manager) explainAnomaly(anomaly models.FeedAnomaly) {
+	if m.llm == nil || !m.llm.Enabled() || !m.startLLMQuery() {
+		return
+	}
+	go func() {
+		defer m.llmWG.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), anomalyExplainTimeout)
+		defer cancel()
+		resp, err := m.llm.Query(ctx, services.QueryRequest{FeedID: anomaly.FeedID, Question: anomalyQuestion(anomaly)})
+		if err != nil {
+			slog.Warn("failed to explain anomaly", "feed_id", anomaly.FeedID, "error", err)
+			return
+		}
+		anomaly.Explanation = resp.Answer
+		m.broadcastAnomaly("feed-anomaly-explained", anomaly)
+	}()
+}
+
+// anomalyQuestion asks the AI what caused an anomaly
+func anomalyQuestion(a models.FeedAnomaly) string {
+	direction := "above"
+	if a.Score < 0 {
+		direction = "below"
+	}
+	return fmt.Sprintf("The field %s just read %s, %.1f standard deviations %s its usual level of %s (%s severity). "+
+		"Briefly explain what in the recent data may have caused this, or say if it looks like a data error.",
+		a.Field, strconv.FormatFloat(a.Value, 'f', -1, 64), math.Abs(a.Score), direction,
+		strconv.FormatFloat(a.Expected, 'g', 6, 64), a.Severity)
+}
+
+// broadcastAnomaly sends an anomaly event to the feed's data room
+func (m *Manager) broadcastAnomaly(event string, anomaly models.FeedAnomaly) {
+	room := dataRoom(anomaly.FeedID)
+	msg := makeMessage(event, anomaly)
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
+}
+
+// SetFeedAnomalyDetection replaces a connected feed's anomaly detection
+// options after the feed is updated; what was learnt of its fields is dropped
+func (m *Manager) SetFeedAnomalyDetection(feedID string, opts *models.FeedAnomalyDetection) {
+	m.anomalyMu.Lock()
+	defer m.anomalyMu.Unlock()
+	m.anomalyDetectors[feedID] = newFeedAnomalyDetector(feedID, opts)
+}
@@ -0,0 +1,118 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestValidateFeedAnomalyDetection(t *testing.T) {
+	assert.NoError(t, ValidateFeedAnomalyDetection(nil))
+	assert.NoError(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"$.price"}}))
+	assert.NoError(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"price"}, Method: "ewma", Threshold: 4, Window: 50, Explain: true}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"$"}}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"price"}, Method: "iforest"}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"price"}, Threshold: -1}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"price"}, Window: 5}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: []string{"price"}, Window: maxAnomalyWindow + 1}))
+	assert.Error(t, ValidateFeedAnomalyDetection(&models.FeedAnomalyDetection{Fields: make([]string, maxAnomalyFields+1)}))
+}
+
+func TestFeedAnomalyDetector(t *testing.T) {
+	for _, method := range []string{anomalyZScore, anomalyEWMA} {
+		t.Run(method, func(t *testing.T) {
+			d := newFeedAnomalyDetector("feed-1", &models.FeedAnomalyDetection{Fields: []string{"price"}, Method: method, Window: 20})
+			start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+			// Nothing is anomalous until enough values are seen, and an early
+			// outlier soon stops counting towards normal
+			assert.Empty(t, d.observe(map[string]interface{}{"price": 100.0}, start))
+			assert.Empty(t, d.observe(map[string]interface{}{"price": 500.0}, start))
+			for i := 0; i < 40; i++ {
+				v := 99.0
+				if i%2 == 0 {
+					v = 101.0
+				}
+				assert.Empty(t, d.observe(map[string]interface{}{"price": v}, start.Add(time.Duration(i)*time.Second)))
+			}
+
+			now := start.Add(time.Minute)
+			anomalies := d.observe(map[string]interface{}{"price": "-1000"}, now)
+			require.Len(t, anomalies, 1)
+			a := anomalies[0]
+			assert.Equal(t, "feed-1", a.FeedID)
+			assert.Equal(t, "price", a.Field)
+			assert.Equal(t, -1000.0, a.Value)
+			assert.Less(t, a.Score, -3.0)
+			assert.Equal(t, "high", a.Severity)
+			assert.Equal(t, method, a.Method)
+			assert.NotEmpty(t, a.ID)
+
+			// A second outlier within the cooldown isn't reported again
+			assert.Empty(t, d.observe(map[string]interface{}{"price": -1000.0}, now.Add(time.Second)))
+		})
+	}
+}
+
+func TestAnomalySeverity(t *testing.T) {
+	assert.Equal(t, "low", anomalySeverity(3.2, 3))
+	assert.Equal(t, "medium", anomalySeverity(-4.5, 3))
+	assert.Equal(t, "high", anomalySeverity(6, 3))
+}
+
+func TestFeedAnomalyDetector_ExplainDue(t *testing.T) {
+	now := time.Now()
+	assert.False(t, newFeedAnomalyDetector("feed-1", &models.FeedAnomalyDetection{Fields: []string{"v"}}).explainDue(now))
+
+	d := newFeedAnomalyDetector("feed-1", &models.FeedAnomalyDetection{Fields: []string{"v"}, Explain: true})
+	assert.True(t, d.explainDue(now))
+	assert.False(t, d.explainDue(now.Add(time.Second)))
+	assert.True(t, d.explainDue(now.Add(anomalyExplainInterval)))
+}
+
+func TestManager_DetectAnomalies(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 64)}
+
+	// Throttled messages still count towards the normal values
+	feed := models.WebSocketFeed{
+		ID:               primitive.NewObjectID(),
+		Name:             "Prices",
+		Throttle:         &models.FeedThrottle{SampleEvery: 100},
+		AnomalyDetection: &models.FeedAnomalyDetection{Fields: []string{"price"}},
+	}
+	m.rooms.Join(dataRoom(feed.ID.Hex()), client)
+	for i := 0; i < 30; i++ {
+		m.BroadcastFeedData(feed, map[string]interface{}{"price": 100.0 + float64(i%3)}, "")
+	}
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 1000.0}, "")
+
+	var anomalies []models.FeedAnomaly
+	for len(client.out) > 0 {
+		if msg := <-client.out; msg.Type == "feed-anomaly" {
+			var a models.FeedAnomaly
+			require.NoError(t, json.Unmarshal(msg.Payload, &a))
+			anomalies = append(anomalies, a)
+		}
+	}
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "Prices", anomalies[0].FeedName)
+	assert.Equal(t, 1000.0, anomalies[0].Value)
+
+	// Turning detection off forgets the field
+	m.SetFeedAnomalyDetection(feed.ID.Hex(), nil)
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": -1000.0}, "")
+	for len(client.out) > 0 {
+		assert.NotEqual(t, "feed-anomaly", (<-client.out).Type)
+	}
+}
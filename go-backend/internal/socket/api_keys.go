@@ -0,0 +1,34 @@
+package socket
+
+import (
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// apiKeyMessageTypes are the messages a client authenticated with an API key
+// may send; the key's grants limit which feeds it can subscribe to
+var apiKeyMessageTypes = map[string]bool{
+	"authenticate":     true,
+	"connection-stats": true,
+	"hello":            true,
+	"ping":             true,
+	"subscribe-feed":   true,
+	"subscribe-llm":    true,
+	"subscribe-all":    true,
+	"unsubscribe-feed": true,
+}
+
+// allowAPIKeyMessage reports whether the client may send msg, telling API
+// key clients when they may not
+func (m *Manager) allowAPIKeyMessage(client *Client, msg WSMessage) bool {
+	if client.apiKey == nil || apiKeyMessageTypes[msg.Type] {
+		return true
+	}
+	client.send(makeMessage("auth_error", map[string]string{"error": "API keys cannot send " + msg.Type}))
+	return false
+}
+
+// canRead reports whether the client may subscribe to the feed; only clients
+// authenticated with an API key are limited, to the feeds it grants read on
+func (c *Client) canRead(feedID string) bool {
+	return c.apiKey == nil || c.apiKey.Allows(feedID, models.APIKeyScopeRead)
+}
@@ -0,0 +1,45 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestManager_APIKeyClientLimits(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	readable := primitive.NewObjectID().Hex()
+	publishOnly := primitive.NewObjectID().Hex()
+	key := models.APIKey{Grants: []models.APIKeyGrant{
+		{FeedID: readable, Scopes: []string{models.APIKeyScopeRead}},
+		{FeedID: publishOnly, Scopes: []string{models.APIKeyScopePublish}},
+	}}
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 4), apiKey: &key}
+
+	assert.True(t, client.canRead(readable))
+	assert.False(t, client.canRead(publishOnly), "publish scope does not allow reading")
+	assert.True(t, (&Client{}).canRead(publishOnly), "user clients are not limited")
+
+	payload, err := json.Marshal(map[string]string{"feedId": publishOnly})
+	require.NoError(t, err)
+	m.handleMessage(client, WSMessage{Type: "subscribe-feed", Payload: payload})
+	reply := <-client.out
+	assert.Equal(t, "subscription-denied", reply.Type)
+	m.rooms.mu.RLock()
+	assert.NotContains(t, m.rooms.clientRooms, client)
+	m.rooms.mu.RUnlock()
+
+	assert.True(t, m.allowAPIKeyMessage(client, WSMessage{Type: "ping"}))
+	assert.False(t, m.allowAPIKeyMessage(client, WSMessage{Type: "llm-query"}))
+	reply = <-client.out
+	assert.Equal(t, "auth_error", reply.Type)
+}
@@ -0,0 +1,403 @@
+package socket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// avroRegistryTimeout bounds one schema registry lookup
+const avroRegistryTimeout = 10 * time.Second
+
+// avroRegistryClient fetches writer schemas from schema registries
+var avroRegistryClient = &http.Client{Timeout: avroRegistryTimeout}
+
+// avroSchema is a parsed Avro schema. Named types referenced by name share
+// the same *avroSchema, so recursive records work.
+type avroSchema struct {
+	Type     string // a primitive type, record, enum, array, map, fixed or union
+	Name     string
+	Fields   []avroField   // record
+	Symbols  []string      // enum
+	Items    *avroSchema   // array
+	Values   *avroSchema   // map
+	Size     int           // fixed
+	Branches []*avroSchema // union
+}
+
+// avroField is one field of an Avro record
+type avroField struct {
+	Name string
+	Type *avroSchema
+}
+
+// newAvroDecoder decodes Avro payloads. With a schema registry, payloads use
+// the Confluent wire format (a zero byte and a 4-byte schema ID) and writer
+// schemas are fetched by ID and cached; otherwise every payload is written
+// with the configured schema.
+func newAvroDecoder(feed models.WebSocketFeed) (payloadDecoder, error) {
+	cfg := feed.AvroConfig
+	if cfg == nil || (strings.TrimSpace(cfg.SchemaRegistryURL) == "" && strings.TrimSpace(cfg.Schema) == "") {
+		return nil, errors.New("avro feeds require avroConfig with a schemaRegistryUrl or schema")
+	}
+	if cfg.SchemaRegistryURL == "" {
+		schema, err := parseAvroSchema(cfg.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) (interface{}, error) { return decodeAvro(schema, msg) }, nil
+	}
+
+	registry := &avroRegistry{cfg: *cfg, schemas: make(map[uint32]*avroSchema)}
+	return func(msg []byte) (interface{}, error) {
+		if len(msg) < 5 || msg[0] != 0 {
+			return nil, errors.New("avro message is not in schema registry wire format")
+		}
+		schema, err := registry.schema(binary.BigEndian.Uint32(msg[1:5]))
+		if err != nil {
+			return nil, err
+		}
+		return decodeAvro(schema, msg[5:])
+	}, nil
+}
+
+// avroRegistry caches writer schemas fetched from a schema registry
+type avroRegistry struct {
+	cfg     models.AvroConfig
+	mu      sync.Mutex
+	schemas map[uint32]*avroSchema
+}
+
+// schema returns the writer schema registered under id
+func (r *avroRegistry) schema(id uint32) (*avroSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if schema, ok := r.schemas[id]; ok {
+		return schema, nil
+	}
+
+	url := fmt.Sprintf("%s/schemas/ids/%d", strings.TrimRight(r.cfg.SchemaRegistryURL, "/"), id)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.cfg.Username != "" {
+		req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	}
+	resp, err := avroRegistryClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch avro schema %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch avro schema %d: registry returned %s", id, resp.Status)
+	}
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("fetch avro schema %d: %w", id, err)
+	}
+	schema, err := parseAvroSchema(body.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("avro schema %d: %w", id, err)
+	}
+	r.schemas[id] = schema
+	return schema, nil
+}
+
+// parseAvroSchema parses an Avro schema in its JSON form
+func parseAvroSchema(text string) (*avroSchema, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, fmt.Errorf("avro schema is not JSON: %w", err)
+	}
+	return (&avroSchemaParser{named: make(map[string]*avroSchema)}).parse(raw, "")
+}
+
+// avroSchemaParser resolves named types while parsing a schema
+type avroSchemaParser struct {
+	named map[string]*avroSchema
+}
+
+// avroPrimitives are the Avro types without attributes
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// parse parses one schema node; namespace qualifies the names it defines
+func (p *avroSchemaParser) parse(raw interface{}, namespace string) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		if avroPrimitives[v] {
+			return &avroSchema{Type: v}, nil
+		}
+		if named, ok := p.named[v]; ok {
+			return named, nil
+		}
+		if named, ok := p.named[avroFullName(v, namespace)]; ok {
+			return named, nil
+		}
+		return nil, fmt.Errorf("avro schema references unknown type %q", v)
+	case []interface{}:
+		union := &avroSchema{Type: "union"}
+		for _, branch := range v {
+			s, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union.Branches = append(union.Branches, s)
+		}
+		return union, nil
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	}
+	return nil, fmt.Errorf("invalid avro schema node %v", raw)
+}
+
+// parseComplex parses a schema given as a JSON object
+func (p *avroSchemaParser) parseComplex(v map[string]interface{}, namespace string) (*avroSchema, error) {
+	typ, _ := v["type"].(string)
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := v["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("avro %s has no name", typ)
+		}
+		if ns, ok := v["namespace"].(string); ok && !strings.Contains(name, ".") {
+			namespace = ns
+		}
+		full := avroFullName(name, namespace)
+		if i := strings.LastIndex(full, "."); i >= 0 {
+			namespace = full[:i]
+		}
+		s := &avroSchema{Type: typ, Name: full}
+		if typ == "error" {
+			s.Type = "record"
+		}
+		// Register before parsing fields so records can refer to themselves
+		p.named[full] = s
+		switch typ {
+		case "enum":
+			symbols, _ := v["symbols"].([]interface{})
+			for _, sym := range symbols {
+				str, _ := sym.(string)
+				s.Symbols = append(s.Symbols, str)
+			}
+		case "fixed":
+			size, _ := v["size"].(float64)
+			s.Size = int(size)
+		default:
+			fields, _ := v["fields"].([]interface{})
+			for _, f := range fields {
+				field, _ := f.(map[string]interface{})
+				fieldName, _ := field["name"].(string)
+				if fieldName == "" {
+					return nil, fmt.Errorf("avro record %s has a field without a name", full)
+				}
+				fieldType, err := p.parse(field["type"], namespace)
+				if err != nil {
+					return nil, err
+				}
+				s.Fields = append(s.Fields, avroField{Name: fieldName, Type: fieldType})
+			}
+		}
+		return s, nil
+	case "array":
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{Type: typ, Items: items}, nil
+	case "map":
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{Type: typ, Values: values}, nil
+	}
+	// Primitives may be written as {"type": "long", "logicalType": ...}
+	return p.parse(v["type"], namespace)
+}
+
+// avroFullName qualifies name with namespace unless it is already qualified
+func avroFullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// decodeAvro decodes one Avro binary-encoded datum written with schema
+func decodeAvro(schema *avroSchema, msg []byte) (interface{}, error) {
+	r := &avroReader{buf: msg}
+	data, err := r.read(schema)
+	if err != nil {
+		return nil, fmt.Errorf("decode avro: %w", err)
+	}
+	return jsonValue(data)
+}
+
+// errAvroShort is returned when a message ends before its datum does
+var errAvroShort = errors.New("message is truncated")
+
+// avroReader reads Avro binary encoding from a buffer
+type avroReader struct {
+	buf []byte
+	pos int
+}
+
+// read decodes a value of the given schema
+func (r *avroReader) read(s *avroSchema) (interface{}, error) {
+	switch s.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := r.bytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case "int", "long":
+		return r.long()
+	case "float":
+		b, err := r.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+	case "double":
+		b, err := r.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes":
+		return r.lengthPrefixed()
+	case "string":
+		b, err := r.lengthPrefixed()
+		return string(b), err
+	case "fixed":
+		return r.bytes(s.Size)
+	case "enum":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(s.Symbols) {
+			return nil, fmt.Errorf("enum index %d out of range for %s", i, s.Name)
+		}
+		return s.Symbols[i], nil
+	case "union":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(s.Branches) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return r.read(s.Branches[i])
+	case "record":
+		out := make(map[string]interface{}, len(s.Fields))
+		for _, f := range s.Fields {
+			v, err := r.read(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			out[f.Name] = v
+		}
+		return out, nil
+	case "array":
+		out := []interface{}{}
+		err := r.blocks(func() error {
+			v, err := r.read(s.Items)
+			out = append(out, v)
+			return err
+		})
+		return out, err
+	case "map":
+		out := map[string]interface{}{}
+		err := r.blocks(func() error {
+			key, err := r.lengthPrefixed()
+			if err != nil {
+				return err
+			}
+			v, err := r.read(s.Values)
+			out[string(key)] = v
+			return err
+		})
+		return out, err
+	}
+	return nil, fmt.Errorf("unsupported avro type %q", s.Type)
+}
+
+// blocks reads the blocks of an array or map, calling item for each entry
+func (r *avroReader) blocks(item func() error) error {
+	for {
+		count, err := r.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// A negative count is followed by the block's size in bytes
+			count = -count
+			if _, err := r.long(); err != nil {
+				return err
+			}
+		}
+		// Every entry takes at least a byte unless it is null, so larger counts are corrupt
+		if count > int64(len(r.buf)) {
+			return fmt.Errorf("block of %d entries is larger than the message", count)
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// long reads a zigzag-encoded variable-length integer
+func (r *avroReader) long() (int64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errAvroShort
+	}
+	r.pos += n
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+// bytes reads n raw bytes
+func (r *avroReader) bytes(n int) ([]byte, error) {
+	if n < 0 || len(r.buf)-r.pos < n {
+		return nil, errAvroShort
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// lengthPrefixed reads a long length followed by that many bytes
+func (r *avroReader) lengthPrefixed() ([]byte, error) {
+	n, err := r.long()
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(len(r.buf)) {
+		return nil, errAvroShort
+	}
+	return r.bytes(int(n))
+}
@@ -0,0 +1,232 @@
+package socket
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
+)
+
+const (
+	// egressShards spreads the meter's users over separate locks, since it
+	// is updated for every message a broadcast delivers
+	egressShards = 32
+	// quotaWarningPercent is how much of a daily quota is used before quota-warning
+	quotaWarningPercent    = 80
+	quotaSubscriptionBytes = "subscription-bytes-per-day"
+)
+
+// BandwidthQuotas limits the feed data sent to each user; zero values turn
+// a limit off
+type BandwidthQuotas struct {
+	// SubscriptionBytesPerDay caps the feed-data payload bytes a user receives
+	// from one feed per UTC day, across all of the user's connections
+	SubscriptionBytesPerDay int64
+	// UserMessagesPerSecond caps the feed-data messages a user receives from
+	// all feeds together; messages over it are dropped
+	UserMessagesPerSecond int
+}
+
+// SubscriptionBandwidth is a user's feed-data egress from one feed today
+type SubscriptionBandwidth struct {
+	FeedID     string `json:"feedId"`
+	Bytes      int64  `json:"bytes"`
+	Messages   int64  `json:"messages"`
+	Dropped    int64  `json:"dropped"`
+	LimitBytes int64  `json:"limitBytes,omitempty"`
+	Percent    int    `json:"percent,omitempty"`
+}
+
+// BandwidthUsage is a user's metered feed-data egress for the current UTC day
+type BandwidthUsage struct {
+	Day                     string                  `json:"day"`
+	ResetsAt                time.Time               `json:"resetsAt"`
+	SubscriptionBytesPerDay int64                   `json:"subscriptionBytesPerDay"`
+	UserMessagesPerSecond   int                     `json:"userMessagesPerSecond"`
+	Throttled               int64                   `json:"throttled"`
+	Subscriptions           []SubscriptionBandwidth `json:"subscriptions"`
+}
+
+// SetBandwidthQuotas sets the egress quotas enforced on feed-data broadcasts
+func (m *Manager) SetBandwidthQuotas(quotas BandwidthQuotas) {
+	m.rooms.egress.setQuotas(quotas)
+}
+
+// BandwidthUsage returns the user's feed-data egress on this instance today
+func (m *Manager) BandwidthUsage(userID string) BandwidthUsage {
+	return m.rooms.egress.usage(userID, time.Now().UTC())
+}
+
+// egressMeter counts the feed data delivered to each user and enforces the
+// bandwidth quotas. Counts are kept for the current UTC day only.
+type egressMeter struct {
+	quotaMu sync.RWMutex
+	quotas  BandwidthQuotas
+	rate    *ratelimit.Limiter
+
+	shards [egressShards]egressShard
+}
+
+type egressShard struct {
+	mu    sync.Mutex
+	day   string
+	users map[string]*userEgress
+}
+
+type userEgress struct {
+	throttled int64
+	feeds     map[string]*feedEgress
+}
+
+type feedEgress struct {
+	bytes    int64
+	messages int64
+	dropped  int64
+	warned   bool
+	exceeded bool
+}
+
+func (e *egressMeter) setQuotas(quotas BandwidthQuotas) {
+	e.quotaMu.Lock()
+	defer e.quotaMu.Unlock()
+	e.quotas = quotas
+	e.rate = ratelimit.New(quotas.UserMessagesPerSecond*60, quotas.UserMessagesPerSecond)
+}
+
+func (e *egressMeter) limits() (BandwidthQuotas, *ratelimit.Limiter) {
+	e.quotaMu.RLock()
+	defer e.quotaMu.RUnlock()
+	return e.quotas, e.rate
+}
+
+// shard returns the shard holding a user's counts, reset if the day has changed
+func (e *egressMeter) shard(key, day string) *egressShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	s := &e.shards[h.Sum32()%egressShards]
+	s.mu.Lock()
+	if s.day != day || s.users == nil {
+		s.day = day
+		s.users = make(map[string]*userEgress)
+	}
+	return s
+}
+
+// allow meters a feed-data message for the client and reports whether it
+// may be sent, telling the client when it nears or reaches its daily quota
+func (e *egressMeter) allow(client *Client, room string, size int, now time.Time) bool {
+	feedID, ok := strings.CutPrefix(room, dataRoom(""))
+	if !ok {
+		return true
+	}
+	key := egressKey(client)
+	quotas, rate := e.limits()
+	day := now.Format(time.DateOnly)
+
+	s := e.shard(key, day)
+	user := s.users[key]
+	if user == nil {
+		user = &userEgress{feeds: make(map[string]*feedEgress)}
+		s.users[key] = user
+	}
+	feed := user.feeds[feedID]
+	if feed == nil {
+		feed = &feedEgress{}
+		user.feeds[feedID] = feed
+	}
+
+	if allowed, _ := rate.Allow(key); !allowed {
+		user.throttled++
+		s.mu.Unlock()
+		metrics.WSQuotaDropped.WithLabelValues("user-messages-per-second").Inc()
+		return false
+	}
+	limit := quotas.SubscriptionBytesPerDay
+	if limit > 0 && feed.bytes+int64(size) > limit {
+		feed.dropped++
+		notify := !feed.exceeded
+		feed.exceeded = true
+		used := feed.bytes
+		s.mu.Unlock()
+		metrics.WSQuotaDropped.WithLabelValues(quotaSubscriptionBytes).Inc()
+		if notify {
+			client.send(makeMessage("quota-exceeded", quotaEvent(feedID, used, limit, now)))
+		}
+		return false
+	}
+	feed.bytes += int64(size)
+	feed.messages++
+	warn := limit > 0 && !feed.warned && feed.bytes*100 >= limit*quotaWarningPercent
+	if warn {
+		feed.warned = true
+	}
+	used := feed.bytes
+	s.mu.Unlock()
+	if warn {
+		client.send(makeMessage("quota-warning", quotaEvent(feedID, used, limit, now)))
+	}
+	return true
+}
+
+// usage reports a user's counts for the day of now
+func (e *egressMeter) usage(userID string, now time.Time) BandwidthUsage {
+	quotas, _ := e.limits()
+	day := now.Format(time.DateOnly)
+	out := BandwidthUsage{
+		Day:                     day,
+		ResetsAt:                nextUTCDay(now),
+		SubscriptionBytesPerDay: quotas.SubscriptionBytesPerDay,
+		UserMessagesPerSecond:   quotas.UserMessagesPerSecond,
+		Subscriptions:           []SubscriptionBandwidth{},
+	}
+	s := e.shard(userID, day)
+	defer s.mu.Unlock()
+	user := s.users[userID]
+	if user == nil {
+		return out
+	}
+	out.Throttled = user.throttled
+	for feedID, feed := range user.feeds {
+		sub := SubscriptionBandwidth{FeedID: feedID, Bytes: feed.bytes, Messages: feed.messages, Dropped: feed.dropped}
+		if limit := quotas.SubscriptionBytesPerDay; limit > 0 {
+			sub.LimitBytes = limit
+			sub.Percent = int(min(feed.bytes*100/limit, 100))
+		}
+		out.Subscriptions = append(out.Subscriptions, sub)
+	}
+	sort.Slice(out.Subscriptions, func(i, j int) bool {
+		return out.Subscriptions[i].Bytes > out.Subscriptions[j].Bytes
+	})
+	return out
+}
+
+// egressKey is the user a client's egress is counted against; clients that
+// never identified themselves are counted per connection
+func egressKey(client *Client) string {
+	if client.userID != "" {
+		return client.userID
+	}
+	return "conn:" + client.id
+}
+
+// quotaEvent is the payload of quota-warning and quota-exceeded
+func quotaEvent(feedID string, used, limit int64, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"feedId":     feedID,
+		"quota":      quotaSubscriptionBytes,
+		"usedBytes":  used,
+		"limitBytes": limit,
+		"percent":    min(used*100/limit, 100),
+		"resetsAt":   nextUTCDay(now),
+	}
+}
+
+// nextUTCDay returns midnight UTC after now, when daily quotas reset
+func nextUTCDay(now time.Time) time.Time {
+	y, mo, d := now.UTC().Date()
+	return time.Date(y, mo, d+1, 0, 0, 0, 0, time.UTC)
+}
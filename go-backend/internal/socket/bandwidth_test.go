@@ -0,0 +1,76 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressMeter_SubscriptionQuota(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetBandwidthQuotas(BandwidthQuotas{SubscriptionBytesPerDay: 100})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, userID: "u1", out: make(chan WSMessage, 16)}
+	m.rooms.Join(dataRoom("f1"), client)
+
+	msg := WSMessage{Type: "feed-data", Payload: json.RawMessage(`{"feedId":"f1","price":123456}`)}
+	require.Len(t, msg.Payload, 30)
+	var types []string
+	for i := 0; i < 5; i++ {
+		m.rooms.Broadcast(dataRoom("f1"), msg)
+	}
+	for len(client.out) > 0 {
+		types = append(types, (<-client.out).Type)
+	}
+	assert.Equal(t, []string{"feed-data", "feed-data", "quota-warning", "feed-data", "quota-exceeded"}, types,
+		"the warning comes at 80% and the quota is exceeded once")
+
+	usage := m.BandwidthUsage("u1")
+	require.Len(t, usage.Subscriptions, 1)
+	assert.Equal(t, SubscriptionBandwidth{FeedID: "f1", Bytes: 90, Messages: 3, Dropped: 2, LimitBytes: 100, Percent: 90}, usage.Subscriptions[0])
+	assert.Equal(t, int64(100), usage.SubscriptionBytesPerDay)
+
+	// Other events are not metered
+	m.rooms.Broadcast(dataRoom("f1"), WSMessage{Type: "feed-status", Payload: json.RawMessage(`{}`)})
+	assert.Equal(t, "feed-status", (<-client.out).Type)
+}
+
+func TestEgressMeter_UserMessageRate(t *testing.T) {
+	meter := &egressMeter{}
+	meter.setQuotas(BandwidthQuotas{UserMessagesPerSecond: 2})
+	client1 := &Client{ctx: context.Background(), userID: "u1"}
+	client2 := &Client{ctx: context.Background(), userID: "u1"}
+	now := time.Now().UTC()
+
+	assert.True(t, meter.allow(client1, dataRoom("f1"), 10, now))
+	assert.True(t, meter.allow(client2, dataRoom("f2"), 10, now))
+	assert.False(t, meter.allow(client1, dataRoom("f1"), 10, now), "the rate covers all of a user's connections and feeds")
+	assert.True(t, meter.allow(&Client{ctx: context.Background(), userID: "u2"}, dataRoom("f1"), 10, now))
+	assert.True(t, meter.allow(client1, llmRoom("f1"), 10, now), "only data rooms are metered")
+
+	usage := meter.usage("u1", now)
+	assert.Equal(t, int64(1), usage.Throttled)
+	assert.Len(t, usage.Subscriptions, 2)
+}
+
+func TestEgressMeter_ResetsDaily(t *testing.T) {
+	meter := &egressMeter{}
+	meter.setQuotas(BandwidthQuotas{SubscriptionBytesPerDay: 10})
+	client := &Client{ctx: context.Background(), userID: "u1", out: make(chan WSMessage, 4)}
+	day := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+
+	assert.True(t, meter.allow(client, dataRoom("f1"), 10, day))
+	assert.False(t, meter.allow(client, dataRoom("f1"), 1, day))
+	usage := meter.usage("u1", day)
+	assert.Equal(t, "2026-03-01", usage.Day)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), usage.ResetsAt)
+
+	next := day.Add(2 * time.Minute)
+	assert.True(t, meter.allow(client, dataRoom("f1"), 5, next), "quotas reset at midnight UTC")
+	assert.Equal(t, int64(5), meter.usage("u1", next).Subscriptions[0].Bytes)
+}
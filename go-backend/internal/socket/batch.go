@@ -0,0 +1,186 @@
+package socket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limits on the batching a subscription may ask for
+const (
+	batchDefaultInterval    = 100 * time.Millisecond
+	batchMinInterval        = 10 * time.Millisecond
+	batchMaxInterval        = 5 * time.Second
+	batchDefaultMaxMessages = 100
+	batchMaxMessages        = 1000
+)
+
+// batchOptions is the batch field of a subscribe-feed or subscribe-all
+// payload. Either field may be left out for its default.
+type batchOptions struct {
+	IntervalMs  int `json:"intervalMs"`
+	MaxMessages int `json:"maxMessages"`
+}
+
+// batchSettings is the batching agreed for a subscription
+type batchSettings struct {
+	interval    time.Duration
+	maxMessages int
+}
+
+// parseBatchOptions checks requested batching against the limits; nil
+// options turn batching off
+func parseBatchOptions(opts *batchOptions) (*batchSettings, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	settings := &batchSettings{interval: batchDefaultInterval, maxMessages: batchDefaultMaxMessages}
+	if opts.IntervalMs != 0 {
+		settings.interval = time.Duration(opts.IntervalMs) * time.Millisecond
+	}
+	if opts.MaxMessages != 0 {
+		settings.maxMessages = opts.MaxMessages
+	}
+	if settings.interval < batchMinInterval || settings.interval > batchMaxInterval {
+		return nil, fmt.Errorf("batch intervalMs must be between %d and %d", batchMinInterval.Milliseconds(), batchMaxInterval.Milliseconds())
+	}
+	if settings.maxMessages < 1 || settings.maxMessages > batchMaxMessages {
+		return nil, fmt.Errorf("batch maxMessages must be between 1 and %d", batchMaxMessages)
+	}
+	return settings, nil
+}
+
+// feedBatcher collects a client's feed-data payloads for one room and sends
+// them as a single feed-data-batch when it holds maxMessages or interval
+// has passed since the first of them arrived. Batches are sent with mu held,
+// which client.send allows as it never blocks, so they stay in order.
+type feedBatcher struct {
+	client   *Client
+	feedID   string
+	settings batchSettings
+
+	mu      sync.Mutex
+	pending []json.RawMessage
+	timer   *time.Timer
+	stopped bool
+}
+
+// add queues a feed-data payload, flushing when the batch is full
+func (b *feedBatcher) add(payload json.RawMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+	b.pending = append(b.pending, payload)
+	if len(b.pending) >= b.settings.maxMessages {
+		b.send(b.take())
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(b.settings.interval, b.flush)
+	}
+}
+
+// flush sends whatever is pending
+func (b *feedBatcher) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.send(b.take())
+}
+
+// stop ignores later payloads, sending the pending ones first when flush is set
+func (b *feedBatcher) stop(flush bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	messages := b.take()
+	if flush {
+		b.send(messages)
+	}
+}
+
+// take empties the batch and stops its timer; b.mu must be held
+func (b *feedBatcher) take() []json.RawMessage {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	messages := b.pending
+	b.pending = nil
+	return messages
+}
+
+// send delivers a batch; b.mu must be held
+func (b *feedBatcher) send(messages []json.RawMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	b.client.send(makeMessage("feed-data-batch", map[string]interface{}{
+		"feedId":   b.feedID,
+		"count":    len(messages),
+		"messages": messages,
+	}))
+}
+
+// setBatching replaces the client's batching for a feed's data room; nil
+// turns it off. Payloads still held by the previous batcher are sent first.
+func (c *Client) setBatching(room, feedID string, settings *batchSettings) {
+	c.batchMu.Lock()
+	previous := c.batches[room]
+	if settings == nil {
+		delete(c.batches, room)
+	} else {
+		if c.batches == nil {
+			c.batches = make(map[string]*feedBatcher)
+		}
+		c.batches[room] = &feedBatcher{client: c, feedID: feedID, settings: *settings}
+	}
+	c.batchMu.Unlock()
+	if previous != nil {
+		previous.stop(true)
+	}
+}
+
+// clearBatching drops the client's batching for a room without sending what it holds
+func (c *Client) clearBatching(room string) {
+	c.batchMu.Lock()
+	previous := c.batches[room]
+	delete(c.batches, room)
+	c.batchMu.Unlock()
+	if previous != nil {
+		previous.stop(false)
+	}
+}
+
+// roomBatcher returns the client's batcher for a room, or nil when its feed-data is sent as it arrives
+func (c *Client) roomBatcher(room string) *feedBatcher {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+	return c.batches[room]
+}
+
+// applySubscriptionBatching sets the client's batching for a feed's data
+// room. It reports false, after telling the client, when the options are out
+// of range.
+func (m *Manager) applySubscriptionBatching(client *Client, room, feedID string, requested *batchOptions) (*batchSettings, bool) {
+	settings, err := parseBatchOptions(requested)
+	if err != nil {
+		client.send(makeMessage("subscription-error", map[string]string{"feedId": feedID, "error": err.Error()}))
+		return nil, false
+	}
+	client.setBatching(room, feedID, settings)
+	return settings, true
+}
+
+// subscriptionAck is the subscription-success payload, echoing the batching
+// agreed so clients know to expect feed-data-batch
+func subscriptionAck(feedID, kind string, batch *batchSettings) map[string]interface{} {
+	ack := map[string]interface{}{"feedId": feedID, "type": kind}
+	if batch != nil {
+		ack["batch"] = map[string]int{
+			"intervalMs":  int(batch.interval.Milliseconds()),
+			"maxMessages": batch.maxMessages,
+		}
+	}
+	return ack
+}
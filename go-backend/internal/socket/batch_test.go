@@ -0,0 +1,116 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestParseBatchOptions(t *testing.T) {
+	settings, err := parseBatchOptions(nil)
+	require.NoError(t, err)
+	assert.Nil(t, settings)
+
+	settings, err = parseBatchOptions(&batchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, batchSettings{interval: batchDefaultInterval, maxMessages: batchDefaultMaxMessages}, *settings)
+
+	settings, err = parseBatchOptions(&batchOptions{IntervalMs: 250, MaxMessages: 20})
+	require.NoError(t, err)
+	assert.Equal(t, batchSettings{interval: 250 * time.Millisecond, maxMessages: 20}, *settings)
+
+	for _, opts := range []batchOptions{{IntervalMs: 1}, {IntervalMs: 60000}, {MaxMessages: -1}, {MaxMessages: batchMaxMessages + 1}} {
+		_, err := parseBatchOptions(&opts)
+		assert.Error(t, err, "%+v", opts)
+	}
+}
+
+func TestRoomManager_Broadcast_BatchesFeedData(t *testing.T) {
+	rm := NewRoomManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+	rm.Join("feed:f1", client)
+	client.setBatching("feed:f1", "f1", &batchSettings{interval: 50 * time.Millisecond, maxMessages: 3})
+
+	for _, price := range []string{"1", "2", "3", "4"} {
+		rm.Broadcast("feed:f1", WSMessage{Type: "feed-data", Payload: []byte(`{"price":` + price + `}`)})
+	}
+	rm.Broadcast("feed:f1", WSMessage{Type: "feed-status", Payload: []byte(`{}`)})
+
+	batch := <-client.out
+	assert.Equal(t, "feed-data-batch", batch.Type, "a full batch is sent at once")
+	assert.JSONEq(t, `{"feedId":"f1","count":3,"messages":[{"price":1},{"price":2},{"price":3}]}`, string(batch.Payload))
+	assert.Equal(t, "feed-status", (<-client.out).Type, "other events are not batched")
+
+	select {
+	case batch = <-client.out:
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed")
+	}
+	assert.JSONEq(t, `{"feedId":"f1","count":1,"messages":[{"price":4}]}`, string(batch.Payload))
+
+	rm.Broadcast("feed:f1", WSMessage{Type: "feed-data", Payload: []byte(`{"price":5}`)})
+	rm.Leave("feed:f1", client)
+	assert.Nil(t, client.roomBatcher("feed:f1"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, client.out, "leaving a room drops its pending batch")
+}
+
+func TestManager_SubscribeWithBatch(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Ticker"}
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": map[string]interface{}{"feedId": feed.ID.Hex(), "batch": map[string]int{"intervalMs": 20000}},
+	}))
+	var reply WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &reply))
+	assert.Equal(t, "subscription-error", reply.Type)
+
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": map[string]interface{}{"feedId": feed.ID.Hex(), "batch": map[string]int{"intervalMs": 20, "maxMessages": 2}},
+	}))
+	require.NoError(t, wsjson.Read(ctx, conn, &reply))
+	require.Equal(t, "subscription-success", reply.Type)
+	assert.JSONEq(t, `{"feedId":"`+feed.ID.Hex()+`","type":"feed-data","batch":{"intervalMs":20,"maxMessages":2}}`, string(reply.Payload))
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"symbol": "ETHUSDT"}, "")
+	m.BroadcastFeedData(feed, map[string]interface{}{"symbol": "BTCUSDT"}, "")
+	var batch struct {
+		Type    string `json:"type"`
+		Payload struct {
+			Count    int `json:"count"`
+			Messages []struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"messages"`
+		} `json:"payload"`
+	}
+	for batch.Type != "feed-data-batch" {
+		require.NoError(t, wsjson.Read(ctx, conn, &batch))
+	}
+	require.Equal(t, 2, batch.Payload.Count)
+	assert.Equal(t, "ETHUSDT", batch.Payload.Messages[0].Data["symbol"])
+	assert.Equal(t, "BTCUSDT", batch.Payload.Messages[1].Data["symbol"])
+}
@@ -1,8 +1,10 @@
 package socket
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func BenchmarkWSMessageUnmarshal(b *testing.B) {
@@ -30,3 +32,34 @@ func BenchmarkWSMessageMarshal(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkRoomManager_Broadcast fans feed-data out to a 10k-client room
+// whose queues are drained as fast as the writers would
+func BenchmarkRoomManager_Broadcast(b *testing.B) {
+	const clients = 10000
+	rm := NewRoomManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < clients; i++ {
+		client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, clientQueueSize)}
+		rm.Join("room", client)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-client.out:
+					client.pending.Add(-1)
+				}
+			}
+		}()
+	}
+	msg := makeMessage("feed-data", map[string]interface{}{"feedId": "123", "data": map[string]interface{}{"price": 50000}})
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		rm.Broadcast("room", msg)
+	}
+	b.ReportMetric(float64(b.N*clients)/time.Since(start).Seconds(), "deliveries/s")
+}
@@ -0,0 +1,78 @@
+package socket
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+)
+
+func TestClient_SendDropsOldestWhenQueueFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 2)}
+
+	client.send(WSMessage{Type: "one"})
+	client.send(WSMessage{Type: "two"})
+	client.send(WSMessage{Type: "three"})
+
+	require.Len(t, client.out, 2)
+	assert.Equal(t, "two", (<-client.out).Type)
+	assert.Equal(t, "three", (<-client.out).Type)
+	assert.Equal(t, int64(1), client.dropped.Load())
+	assert.False(t, client.slow.Load(), "a brief overflow is tolerated")
+}
+
+func TestClient_SendDisconnectsSlowConsumer(t *testing.T) {
+	grace := slowConsumerGrace
+	slowConsumerGrace = 0
+	defer func() { slowConsumerGrace = grace }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 1)}
+
+	client.send(WSMessage{Type: "one"})
+	client.overflowSince = time.Now().Add(-time.Second)
+	client.send(WSMessage{Type: "two"})
+
+	assert.True(t, client.slow.Load())
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("slow consumer was not disconnected")
+	}
+
+	// Sends after the disconnect are ignored
+	client.send(WSMessage{Type: "three"})
+}
+
+func TestClient_OverflowKeepsStartTime(t *testing.T) {
+	client := &Client{}
+	assert.False(t, client.overflowing())
+	started := client.overflowSince
+	assert.False(t, started.IsZero())
+
+	time.Sleep(time.Millisecond)
+	client.overflowing()
+	assert.Equal(t, started, client.overflowSince, "overflow start is kept while the queue stays full")
+}
+
+func TestMessageContext_NewRequestIDPerMessage(t *testing.T) {
+	client := &Client{ctx: logging.With(context.Background(), "conn_id", "conn-1")}
+
+	first := messageContext(client, WSMessage{Type: "llm-query"})
+	second := messageContext(client, WSMessage{Type: "llm-query"})
+	assert.NotEmpty(t, logging.RequestID(first))
+	assert.NotEqual(t, logging.RequestID(first), logging.RequestID(second))
+
+	var out bytes.Buffer
+	logging.New(&out, "info", "text").InfoContext(first, "query")
+	assert.Contains(t, out.String(), "request_id="+logging.RequestID(first))
+	assert.Contains(t, out.String(), "conn_id=conn-1 event=llm-query")
+}
@@ -0,0 +1,244 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// feedLockTTL is how long a feed's ownership lock lives without renewal
+	feedLockTTL = 30 * time.Second
+	// feedLockRenewInterval is how often the owning instance renews a lock
+	feedLockRenewInterval = 10 * time.Second
+	// clusterTakeoverInterval is how often feeds with local subscribers but no
+	// owner are picked up, e.g. after the owning instance stopped
+	clusterTakeoverInterval = 15 * time.Second
+	// clusterQueueSize bounds messages waiting to be published to other instances
+	clusterQueueSize = 1024
+)
+
+// ClusterBus connects backend instances. Broadcasts are published to every
+// other instance, and a per-feed lock makes sure only one instance holds the
+// upstream connection for a feed.
+type ClusterBus interface {
+	// InstanceID identifies this instance in published messages and locks
+	InstanceID() string
+	// Publish sends a message to every instance, including this one
+	Publish(ctx context.Context, data []byte) error
+	// Subscribe delivers published messages until ctx is cancelled
+	Subscribe(ctx context.Context) (<-chan []byte, error)
+	// AcquireFeed takes or extends the feed's lock; false means another instance holds it
+	AcquireFeed(ctx context.Context, feedID string, ttl time.Duration) (bool, error)
+	// ReleaseFeed drops the feed's lock if this instance holds it
+	ReleaseFeed(ctx context.Context, feedID string) error
+}
+
+// clusterEnvelope is a broadcast relayed between instances
+type clusterEnvelope struct {
+	Origin  string          `json:"origin"`
+	Room    string          `json:"room"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// SetCluster enables multi-instance broadcast and feed ownership. RunCluster
+// must be started for messages to flow between instances.
+func (m *Manager) SetCluster(bus ClusterBus) {
+	m.cluster = bus
+	m.clusterOut = make(chan clusterEnvelope, clusterQueueSize)
+	m.heldFeeds = make(map[string]struct{})
+}
+
+// RunCluster relays broadcasts between instances and takes over feeds whose
+// owner went away until ctx is cancelled.
+func (m *Manager) RunCluster(ctx context.Context) error {
+	if m.cluster == nil {
+		return nil
+	}
+	incoming, err := m.cluster.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("cluster broadcast enabled", "instance", m.cluster.InstanceID())
+
+	takeover := time.NewTicker(clusterTakeoverInterval)
+	defer takeover.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case env := <-m.clusterOut:
+			data, err := json.Marshal(env)
+			if err != nil {
+				continue
+			}
+			pubCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := m.cluster.Publish(pubCtx, data); err != nil {
+				slog.Warn("failed to publish to cluster", "event", env.Type, "error", err)
+			}
+			cancel()
+		case data, ok := <-incoming:
+			if !ok {
+				return nil
+			}
+			m.handleClusterMessage(data)
+		case <-takeover.C:
+			m.takeOverOrphanedFeeds()
+		}
+	}
+}
+
+// publishToCluster queues a local broadcast for the other instances
+func (m *Manager) publishToCluster(room string, msg WSMessage) {
+	if m.cluster == nil {
+		return
+	}
+	env := clusterEnvelope{Origin: m.cluster.InstanceID(), Room: room, Type: msg.Type, Payload: msg.Payload}
+	select {
+	case m.clusterOut <- env:
+	default:
+		slog.Warn("cluster publish queue full, dropping message", "event", msg.Type, "room", room)
+	}
+}
+
+// handleClusterMessage delivers a broadcast from another instance to local clients
+func (m *Manager) handleClusterMessage(data []byte) {
+	var env clusterEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		slog.Warn("ignoring unreadable cluster message", "error", err)
+		return
+	}
+	if env.Origin == m.cluster.InstanceID() {
+		return
+	}
+
+	if env.Type == "feed-data" {
+		var payload struct {
+			FeedID   string      `json:"feedId"`
+			FeedName string      `json:"feedName"`
+			Data     interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil && payload.FeedID != "" {
+			// Keep replay and AI context in step with the owning instance
+			m.rememberFeedData(payload.FeedID, env.Payload)
+			if m.llm != nil {
+				m.llm.AddFeedData(payload.FeedID, payload.FeedName, payload.Data)
+			}
+		}
+	}
+	if env.Type == "feed-aggregate" && m.llm != nil {
+		var agg models.FeedAggregate
+		if err := json.Unmarshal(env.Payload, &agg); err == nil && agg.FeedID != "" {
+			m.llm.SetFeedAggregate(agg)
+		}
+	}
+	if env.Type == "feed-health" {
+		m.storeRemoteHealth(env.Payload)
+	}
+	if env.Type == "feed-paused" {
+		m.stopPausedFeed(env.Payload)
+	}
+	msg := WSMessage{Type: env.Type, Payload: env.Payload}
+	if env.Type == "feed-data" {
+		msg = withBinaryFrame(msg)
+	}
+	m.rooms.Broadcast(env.Room, msg)
+}
+
+// acquireFeed takes the feed's cluster lock before connecting upstream.
+// Without a cluster every feed is connected locally.
+func (m *Manager) acquireFeed(feedID string) bool {
+	if m.cluster == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ok, err := m.cluster.AcquireFeed(ctx, feedID, feedLockTTL)
+	if err != nil {
+		// Connecting anyway risks a duplicate upstream, which beats no data at all
+		slog.Warn("failed to acquire cluster lock", "feed_id", feedID, "error", err)
+		return true
+	}
+	if !ok {
+		slog.Info("feed is connected by another instance", "feed_id", feedID)
+	}
+	return ok
+}
+
+// releaseFeed drops the feed's cluster lock so another instance can take over
+func (m *Manager) releaseFeed(feedID string) {
+	if m.cluster == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.cluster.ReleaseFeed(ctx, feedID); err != nil {
+		slog.Warn("failed to release cluster lock", "feed_id", feedID, "error", err)
+	}
+}
+
+// holdFeed renews the feed's lock while it is connected here and releases
+// it once the connection closes. A lock taken by another instance stops the
+// local connection.
+func (m *Manager) holdFeed(feedID string) {
+	if m.cluster == nil {
+		return
+	}
+	m.clusterMu.Lock()
+	if _, held := m.heldFeeds[feedID]; held {
+		m.clusterMu.Unlock()
+		return
+	}
+	m.heldFeeds[feedID] = struct{}{}
+	m.clusterMu.Unlock()
+
+	go func() {
+		defer func() {
+			m.clusterMu.Lock()
+			delete(m.heldFeeds, feedID)
+			m.clusterMu.Unlock()
+		}()
+		ticker := time.NewTicker(feedLockRenewInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !m.IsFeedConnected(feedID) {
+				m.releaseFeed(feedID)
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ok, err := m.cluster.AcquireFeed(ctx, feedID, feedLockTTL)
+			cancel()
+			if err != nil {
+				slog.Warn("failed to renew cluster lock", "feed_id", feedID, "error", err)
+				continue
+			}
+			if !ok {
+				slog.Warn("lost cluster lock, disconnecting feed", "feed_id", feedID)
+				m.StopFeed(feedID)
+				return
+			}
+		}
+	}()
+}
+
+// takeOverOrphanedFeeds connects feeds that have local subscribers but no
+// local upstream connection; the lock keeps this a no-op while another
+// instance still owns the feed.
+func (m *Manager) takeOverOrphanedFeeds() {
+	m.subscriberMu.RLock()
+	feedIDs := make([]string, 0, len(m.subscribers))
+	for feedID := range m.subscribers {
+		feedIDs = append(feedIDs, feedID)
+	}
+	m.subscriberMu.RUnlock()
+
+	for _, feedID := range feedIDs {
+		if !m.IsFeedConnected(feedID) {
+			m.ensureFeedConnection(feedID)
+		}
+	}
+}
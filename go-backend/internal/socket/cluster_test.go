@@ -0,0 +1,134 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func newTestRedisCluster(t *testing.T, srv *miniredis.Miniredis) *RedisCluster {
+	t.Helper()
+	cluster, err := NewRedisCluster(context.Background(), "redis://"+srv.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { cluster.Close() })
+	return cluster
+}
+
+func TestRedisCluster_FeedLock(t *testing.T) {
+	srv := miniredis.RunT(t)
+	a := newTestRedisCluster(t, srv)
+	b := newTestRedisCluster(t, srv)
+	ctx := context.Background()
+
+	ok, err := a.AcquireFeed(ctx, "feed-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = b.AcquireFeed(ctx, "feed-1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "another instance holds the lock")
+
+	ok, err = a.AcquireFeed(ctx, "feed-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "the holder can renew")
+
+	require.NoError(t, b.ReleaseFeed(ctx, "feed-1"))
+	assert.True(t, srv.Exists(redisFeedLockPrefix+"feed-1"), "only the holder can release")
+
+	require.NoError(t, a.ReleaseFeed(ctx, "feed-1"))
+	ok, err = b.AcquireFeed(ctx, "feed-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	srv.FastForward(2 * time.Minute)
+	ok, err = a.AcquireFeed(ctx, "feed-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "expired locks can be taken over")
+}
+
+func TestManager_ClusterBroadcastReachesOtherInstance(t *testing.T) {
+	srv := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	owner := NewManager(nil, nil, nil, nil)
+	owner.SetCluster(newTestRedisCluster(t, srv))
+	go owner.RunCluster(ctx)
+
+	llm, err := services.NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	other := NewManager(nil, nil, nil, nil)
+	other.SetLLMService(llm)
+	other.SetCluster(newTestRedisCluster(t, srv))
+	go other.RunCluster(ctx)
+
+	// A client connected to the instance without the upstream connection
+	wsSrv := httptest.NewServer(http.HandlerFunc(other.Handle))
+	defer wsSrv.Close()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(wsSrv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Ticker"}
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": map[string]interface{}{"feedId": feed.ID.Hex()},
+	}))
+	var ack WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &ack))
+	require.Equal(t, "subscription-success", ack.Type)
+
+	// Both instances must be subscribed before publishing
+	require.Eventually(t, func() bool {
+		return len(srv.PubSubNumSub(redisBroadcastChannel)) == 1 && srv.PubSubNumSub(redisBroadcastChannel)[redisBroadcastChannel] == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	owner.BroadcastFeedData(feed, map[string]interface{}{"price": 1.5}, "")
+
+	readCtx, readCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer readCancel()
+	var msg struct {
+		Type    string `json:"type"`
+		Payload struct {
+			FeedID string                 `json:"feedId"`
+			Data   map[string]interface{} `json:"data"`
+		} `json:"payload"`
+	}
+	require.NoError(t, wsjson.Read(readCtx, conn, &msg))
+	assert.Equal(t, "feed-data", msg.Type)
+	assert.Equal(t, feed.ID.Hex(), msg.Payload.FeedID)
+	assert.Equal(t, 1.5, msg.Payload.Data["price"])
+
+	entry, ok := llm.LatestEntry(feed.ID.Hex())
+	require.True(t, ok, "relayed data reaches the local AI context")
+	assert.Equal(t, 1.5, entry["price"])
+}
+
+func TestManager_ConnectFeedSkipsFeedOwnedElsewhere(t *testing.T) {
+	srv := miniredis.RunT(t)
+	owner := newTestRedisCluster(t, srv)
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), URL: "ws://127.0.0.1:1/unreachable"}
+
+	ok, err := owner.AcquireFeed(context.Background(), feed.ID.Hex(), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	m := NewManager(nil, nil, nil, nil)
+	m.SetCluster(newTestRedisCluster(t, srv))
+	assert.NoError(t, m.ConnectFeed(feed), "the owning instance handles the upstream")
+	assert.False(t, m.IsFeedConnected(feed.ID.Hex()))
+}
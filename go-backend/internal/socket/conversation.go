@@ -0,0 +1,67 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// conversationTimeout bounds loading or saving a conversation
+const conversationTimeout = 5 * time.Second
+
+// SetConversationService sets the service that stores multi-turn AI chats;
+// without one llm-conversation messages are refused
+func (m *Manager) SetConversationService(conversations *services.ConversationService) {
+	m.conversations = conversations
+}
+
+// loadConversation returns the client's conversation with conversationID,
+// or a new one on feedID when conversationID is empty
+func (m *Manager) loadConversation(client *Client, conversationID, feedID string) (*models.Conversation, error) {
+	if m.conversations == nil {
+		return nil, errors.New("conversations are disabled")
+	}
+	if client.userID == "" {
+		return nil, errors.New("sign in to use conversations")
+	}
+	if conversationID == "" {
+		if feedID == "" {
+			return nil, errors.New("feedId is required")
+		}
+		return &models.Conversation{UserID: client.userID, FeedID: feedID}, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return nil, services.ErrConversationNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), conversationTimeout)
+	defer cancel()
+	conv, err := m.conversations.GetConversation(ctx, client.userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if feedID != "" && feedID != conv.FeedID {
+		return nil, services.ErrConversationFeed
+	}
+	return conv, nil
+}
+
+// saveConversationTurn stores a question and its answer in conv. Answers
+// given without feed data are not kept, so they don't crowd out real turns.
+func (m *Manager) saveConversationTurn(conv *models.Conversation, question string, resp *services.QueryResponse) {
+	if resp.Provider == "none" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), conversationTimeout)
+	defer cancel()
+	if err := m.conversations.AddTurn(ctx, conv, question, resp.Answer, resp.Provider); err != nil {
+		slog.Warn("failed to save conversation turn", "user_id", conv.UserID, "error", err)
+	}
+}
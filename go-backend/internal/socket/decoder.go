@@ -0,0 +1,107 @@
+package socket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Payload formats a feed can declare in DataFormat
+const (
+	dataFormatJSON     = "json"
+	dataFormatMsgpack  = "msgpack"
+	dataFormatAvro     = "avro"
+	dataFormatProtobuf = "protobuf"
+)
+
+// payloadDecoder turns a raw feed payload into the value broadcast to
+// subscribers and added to the LLM context
+type payloadDecoder func(msg []byte) (interface{}, error)
+
+// payloadDecoders builds the decoder for each data format from the feed's settings
+var payloadDecoders = map[string]func(feed models.WebSocketFeed) (payloadDecoder, error){
+	dataFormatJSON:     newJSONDecoder,
+	dataFormatMsgpack:  newMsgpackDecoder,
+	dataFormatAvro:     newAvroDecoder,
+	dataFormatProtobuf: newProtobufDecoder,
+}
+
+// feedDataFormat returns the format of a feed's payloads. "protobuf"
+// connections are websockets carrying protobuf; no format means JSON.
+func feedDataFormat(feed models.WebSocketFeed) string {
+	if feed.ConnectionType == connectionTypeProtobuf {
+		return dataFormatProtobuf
+	}
+	if format := strings.ToLower(strings.TrimSpace(feed.DataFormat)); format != "" {
+		return format
+	}
+	return dataFormatJSON
+}
+
+// carriesBinary reports whether the feed's transport delivers raw bytes, so
+// it can use a binary data format
+func carriesBinary(feed models.WebSocketFeed) bool {
+	switch feed.ConnectionType {
+	case connectionTypeMQTT, connectionTypeKafka:
+		return true
+	}
+	return isWebSocketFeed(feed)
+}
+
+// newPayloadDecoder returns the decoder for the feed's data format
+func newPayloadDecoder(feed models.WebSocketFeed) (payloadDecoder, error) {
+	format := feedDataFormat(feed)
+	build, ok := payloadDecoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dataFormat %q (use json, msgpack, avro or protobuf)", feed.DataFormat)
+	}
+	if format != dataFormatJSON && !carriesBinary(feed) {
+		return nil, fmt.Errorf("dataFormat %q requires a websocket, mqtt or kafka feed", format)
+	}
+	return build(feed)
+}
+
+// ValidateFeedDataFormat checks that the feed's data format is supported on
+// its connection type and that the format's settings are complete
+func ValidateFeedDataFormat(feed models.WebSocketFeed) error {
+	_, err := newPayloadDecoder(feed)
+	return err
+}
+
+// newJSONDecoder decodes JSON payloads, passing other text through as a string
+func newJSONDecoder(models.WebSocketFeed) (payloadDecoder, error) {
+	return func(msg []byte) (interface{}, error) { return decodeFeedMessage(msg), nil }, nil
+}
+
+// newMsgpackDecoder decodes MessagePack payloads
+func newMsgpackDecoder(models.WebSocketFeed) (payloadDecoder, error) {
+	handle := &codec.MsgpackHandle{}
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	handle.RawToString = true
+	return func(msg []byte) (interface{}, error) {
+		var data interface{}
+		if err := codec.NewDecoderBytes(msg, handle).Decode(&data); err != nil {
+			return nil, fmt.Errorf("decode msgpack: %w", err)
+		}
+		return jsonValue(data)
+	}, nil
+}
+
+// jsonValue converts a decoded binary payload to the types a JSON payload
+// decodes to, so transforms, filters and schemas treat every format alike
+func jsonValue(v interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
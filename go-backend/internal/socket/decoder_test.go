@@ -0,0 +1,192 @@
+package socket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestNewPayloadDecoder_Formats(t *testing.T) {
+	avro := &models.AvroConfig{Schema: `"string"`}
+	tests := []struct {
+		name    string
+		feed    models.WebSocketFeed
+		wantErr string
+	}{
+		{"default json", models.WebSocketFeed{}, ""},
+		{"json on sse", models.WebSocketFeed{ConnectionType: connectionTypeSSE, DataFormat: "json"}, ""},
+		{"msgpack on websocket", models.WebSocketFeed{DataFormat: "MsgPack"}, ""},
+		{"avro on kafka", models.WebSocketFeed{ConnectionType: connectionTypeKafka, DataFormat: "avro", AvroConfig: avro}, ""},
+		{"avro on mqtt", models.WebSocketFeed{ConnectionType: connectionTypeMQTT, DataFormat: "avro", AvroConfig: avro}, ""},
+		{"unknown format", models.WebSocketFeed{DataFormat: "xml"}, "unsupported dataFormat"},
+		{"binary on sse", models.WebSocketFeed{ConnectionType: connectionTypeSSE, DataFormat: "msgpack"}, "requires a websocket, mqtt or kafka feed"},
+		{"avro without config", models.WebSocketFeed{DataFormat: "avro"}, "avroConfig"},
+		{"avro with bad schema", models.WebSocketFeed{DataFormat: "avro", AvroConfig: &models.AvroConfig{Schema: `"Missing"`}}, "unknown type"},
+		{"protobuf format without descriptor", models.WebSocketFeed{ConnectionType: connectionTypeKafka, DataFormat: "protobuf"}, "protobufDescriptor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decode, err := newPayloadDecoder(tt.feed)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.NotNil(t, decode)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestMsgpackDecoder(t *testing.T) {
+	var raw []byte
+	require.NoError(t, codec.NewEncoderBytes(&raw, &codec.MsgpackHandle{}).Encode(map[string]interface{}{
+		"symbol": "BTC",
+		"qty":    3,
+		"price":  42.5,
+		"meta":   map[string]interface{}{"live": true},
+		"trades": []interface{}{1, 2},
+	}))
+
+	decode, err := newPayloadDecoder(models.WebSocketFeed{DataFormat: dataFormatMsgpack})
+	require.NoError(t, err)
+	data, err := decode(raw)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"symbol": "BTC",
+		"qty":    3.0,
+		"price":  42.5,
+		"meta":   map[string]interface{}{"live": true},
+		"trades": []interface{}{1.0, 2.0},
+	}, data)
+
+	_, err = decode([]byte{0xc1})
+	assert.Error(t, err)
+}
+
+// avroWriter hand-encodes Avro binary data for tests
+type avroWriter []byte
+
+func (w *avroWriter) long(v int64) *avroWriter {
+	*w = binary.AppendUvarint(*w, uint64((v<<1)^(v>>63)))
+	return w
+}
+
+func (w *avroWriter) str(s string) *avroWriter {
+	w.long(int64(len(s)))
+	*w = append(*w, s...)
+	return w
+}
+
+func (w *avroWriter) double(f float64) *avroWriter {
+	*w = binary.LittleEndian.AppendUint64(*w, math.Float64bits(f))
+	return w
+}
+
+func (w *avroWriter) raw(b ...byte) *avroWriter {
+	*w = append(*w, b...)
+	return w
+}
+
+const tickAvroSchema = `{
+	"type": "record", "name": "Tick", "namespace": "market",
+	"fields": [
+		{"name": "symbol", "type": "string"},
+		{"name": "seq", "type": "long"},
+		{"name": "price", "type": ["null", "double"]},
+		{"name": "live", "type": "boolean"},
+		{"name": "side", "type": {"type": "enum", "name": "Side", "symbols": ["BUY", "SELL"]}},
+		{"name": "sizes", "type": {"type": "array", "items": "int"}},
+		{"name": "tags", "type": {"type": "map", "values": "string"}},
+		{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "previous", "type": ["null", "market.Tick"]}
+	]
+}`
+
+// encodeAvroTick writes a market.Tick with no previous tick
+func encodeAvroTick(symbol string, price float64) []byte {
+	w := &avroWriter{}
+	w.str(symbol).long(7).long(1).double(price).raw(1).long(1)
+	w.long(-2).long(2).long(5).long(10).long(0) // one block of two sizes, with its byte size
+	w.long(1).str("venue").str("x").long(0)
+	w.long(1700000000000).long(0)
+	return *w
+}
+
+func TestAvroDecoder_Schema(t *testing.T) {
+	decode, err := newPayloadDecoder(models.WebSocketFeed{DataFormat: dataFormatAvro, AvroConfig: &models.AvroConfig{Schema: tickAvroSchema}})
+	require.NoError(t, err)
+
+	data, err := decode(encodeAvroTick("BTC", 42.5))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"symbol":   "BTC",
+		"seq":      7.0,
+		"price":    42.5,
+		"live":     true,
+		"side":     "SELL",
+		"sizes":    []interface{}{5.0, 10.0},
+		"tags":     map[string]interface{}{"venue": "x"},
+		"ts":       1700000000000.0,
+		"previous": nil,
+	}, data)
+
+	msg := encodeAvroTick("BTC", 42.5)
+	_, err = decode(msg[:len(msg)-3])
+	assert.Error(t, err)
+}
+
+func TestAvroDecoder_Registry(t *testing.T) {
+	var requests int32
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/schemas/ids/42" || user != "reader" || pass != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": tickAvroSchema})
+	}))
+	defer registry.Close()
+
+	decode, err := newPayloadDecoder(models.WebSocketFeed{
+		ConnectionType: connectionTypeKafka,
+		DataFormat:     dataFormatAvro,
+		AvroConfig:     &models.AvroConfig{SchemaRegistryURL: registry.URL + "/", Username: "reader", Password: "secret"},
+	})
+	require.NoError(t, err)
+
+	framed := func(id uint32, body []byte) []byte {
+		return append(binary.BigEndian.AppendUint32([]byte{0}, id), body...)
+	}
+	for _, symbol := range []string{"BTC", "ETH"} {
+		data, err := decode(framed(42, encodeAvroTick(symbol, 1)))
+		require.NoError(t, err)
+		assert.Equal(t, symbol, data.(map[string]interface{})["symbol"])
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "schemas are cached by ID")
+
+	_, err = decode(framed(7, encodeAvroTick("BTC", 1)))
+	assert.ErrorContains(t, err, "registry returned 404")
+	_, err = decode(encodeAvroTick("BTC", 1))
+	assert.ErrorContains(t, err, "wire format")
+}
+
+func TestAvroReader_RejectsOversizedBlocks(t *testing.T) {
+	schema, err := parseAvroSchema(`{"type": "array", "items": "null"}`)
+	require.NoError(t, err)
+	w := &avroWriter{}
+	w.long(1 << 40)
+	_, err = decodeAvro(schema, *w)
+	assert.ErrorContains(t, err, "larger than the message")
+}
@@ -0,0 +1,130 @@
+package socket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ugorji/go/codec"
+	coderws "nhooyr.io/websocket"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+)
+
+// binaryFrame caches a feed-data message's msgpack encoding, so a broadcast
+// is encoded once however many clients take it as binary
+type binaryFrame struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// withBinaryFrame returns msg with a cache for its binary encoding; copies
+// of the message share it
+func withBinaryFrame(msg WSMessage) WSMessage {
+	msg.frame = &binaryFrame{}
+	return msg
+}
+
+// encodeBinary returns the message as a msgpack {type, payload} map
+func (msg WSMessage) encodeBinary() ([]byte, error) {
+	frame := msg.frame
+	if frame == nil {
+		frame = &binaryFrame{}
+	}
+	frame.once.Do(func() {
+		frame.data, frame.err = encodeMsgpack(msg)
+	})
+	return frame.data, frame.err
+}
+
+func encodeMsgpack(msg WSMessage) ([]byte, error) {
+	envelope := map[string]interface{}{"type": msg.Type}
+	if len(msg.Payload) > 0 {
+		payload, err := decodeJSONNumbers(msg.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("decode payload: %w", err)
+		}
+		envelope["payload"] = payload
+	}
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, &codec.MsgpackHandle{WriteExt: true}).Encode(envelope); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// jsonFrameSize is the size of the text frame wsjson writes for msg
+func jsonFrameSize(msg WSMessage) int {
+	// {"type":"…","payload":…} and the encoder's trailing newline
+	return len(`{"type":"","payload":}`) + len(msg.Type) + len(msg.Payload) + 1
+}
+
+// decodeJSONNumbers decodes JSON keeping whole numbers as integers, which
+// msgpack encodes more compactly than floats
+func decodeJSONNumbers(raw json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertNumbers(v), nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if !strings.ContainsAny(val.String(), ".eE") {
+			if n, err := val.Int64(); err == nil {
+				return n
+			}
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = convertNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertNumbers(item)
+		}
+	}
+	return v
+}
+
+// writeBinary writes a feed-data message as a binary msgpack frame to a
+// client that agreed to binary frames. It reports whether it wrote the
+// frame; the caller falls back to JSON when the message can't be encoded.
+func (c *Client) writeBinary(ctx context.Context, msg WSMessage) (bool, error) {
+	data, err := msg.encodeBinary()
+	if err != nil {
+		slog.WarnContext(ctx, "binary encoding failed, sending as JSON", "event", msg.Type, "error", err)
+		return false, nil
+	}
+	if err := c.conn.Write(ctx, coderws.MessageBinary, data); err != nil {
+		return true, err
+	}
+	metrics.WSFeedDataBytes.WithLabelValues("msgpack").Add(float64(len(data)))
+	metrics.WSBinaryBytesSaved.Add(float64(jsonFrameSize(msg) - len(data)))
+	return true, nil
+}
+
+// compressionMode is the permessage-deflate mode offered on upgrade
+func compressionMode(enabled bool) coderws.CompressionMode {
+	if enabled {
+		return coderws.CompressionNoContextTakeover
+	}
+	return coderws.CompressionDisabled
+}
+
+// usesDeflate reports whether the upgrade response agreed to permessage-deflate
+func usesDeflate(header http.Header) bool {
+	return strings.Contains(header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+}
@@ -0,0 +1,118 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func decodeMsgpack(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	decode, err := newMsgpackDecoder(models.WebSocketFeed{})
+	require.NoError(t, err)
+	out, err := decode(data)
+	require.NoError(t, err)
+	return out.(map[string]interface{})
+}
+
+func TestWSMessage_EncodeBinary(t *testing.T) {
+	msg := withBinaryFrame(makeMessage("feed-data", map[string]interface{}{
+		"feedId": "feed-1",
+		"data":   map[string]interface{}{"seq": 7, "price": 50000.5, "tags": []string{"a", "b"}},
+	}))
+
+	data, err := msg.encodeBinary()
+	require.NoError(t, err)
+	assert.Less(t, len(data), jsonFrameSize(msg))
+
+	decoded := decodeMsgpack(t, data)
+	assert.Equal(t, "feed-data", decoded["type"])
+	payload := decoded["payload"].(map[string]interface{})
+	assert.Equal(t, "feed-1", payload["feedId"])
+	fields := payload["data"].(map[string]interface{})
+	assert.EqualValues(t, 7, fields["seq"], "whole numbers stay integers")
+	assert.Equal(t, 50000.5, fields["price"])
+
+	// Copies of the message share one encoding
+	copied := msg
+	again, err := copied.encodeBinary()
+	require.NoError(t, err)
+	assert.Same(t, &data[0], &again[0])
+}
+
+func TestManager_SendsBinaryFeedData(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetCompression(true)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), &coderws.DialOptions{
+		CompressionMode: coderws.CompressionNoContextTakeover,
+	})
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "hello",
+		"payload": map[string]interface{}{"version": ProtocolVersion, "features": []string{featureBinary, featureCompression}},
+	}))
+	var hello struct {
+		Type    string `json:"type"`
+		Payload struct {
+			Features []string `json:"features"`
+		} `json:"payload"`
+	}
+	require.NoError(t, wsjson.Read(ctx, conn, &hello))
+	require.Equal(t, "hello", hello.Type)
+	assert.Equal(t, []string{featureBinary, featureCompression}, hello.Payload.Features)
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Ticker"}
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": map[string]interface{}{"feedId": feed.ID.Hex()},
+	}))
+	var ack WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &ack))
+	require.Equal(t, "subscription-success", ack.Type)
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"seq": 1}, "tick")
+
+	typ, data, err := conn.Read(ctx)
+	require.NoError(t, err)
+	require.Equal(t, coderws.MessageBinary, typ)
+	decoded := decodeMsgpack(t, data)
+	assert.Equal(t, "feed-data", decoded["type"])
+	payload := decoded["payload"].(map[string]interface{})
+	assert.Equal(t, feed.ID.Hex(), payload["feedId"])
+	assert.Equal(t, "tick", payload["eventName"])
+}
+
+func TestManager_HelloWithoutDeflateRefusesCompression(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 4)}
+
+	m.handleMessage(client, WSMessage{Type: "hello", Payload: json.RawMessage(`{"version":2,"features":["compression","binary"]}`)})
+	msg := <-client.out
+	var payload struct {
+		Features []string `json:"features"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, []string{featureBinary}, payload.Features)
+	assert.False(t, client.hasFeature(featureCompression))
+}
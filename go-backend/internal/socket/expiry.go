@@ -0,0 +1,80 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunSubscriptionExpiry periodically deactivates expired subscriptions, notifies
+// the affected users' clients, and disconnects feeds left without subscribers.
+// Connected clients keep streaming until the first sweep after their
+// subscription's expiry, so interval is also the longest grace period; new
+// subscribes are refused as soon as the subscription expires.
+// It blocks until ctx is cancelled.
+func (m *Manager) RunSubscriptionExpiry(ctx context.Context, interval time.Duration) {
+	if m.marketplace == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.expireSubscriptions(ctx, now)
+		}
+	}
+}
+
+func (m *Manager) expireSubscriptions(ctx context.Context, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	expired, err := m.marketplace.ExpireSubscriptions(ctx, now)
+	if err != nil {
+		slog.Warn("failed to expire subscriptions", "error", err)
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	feeds := make(map[string]struct{})
+	for _, sub := range expired {
+		slog.Info("subscription expired", "user_id", sub.UserID, "feed_id", sub.FeedID)
+		m.dropUserFromFeed(sub.UserID, sub.FeedID)
+		feeds[sub.FeedID] = struct{}{}
+	}
+
+	for feedID := range feeds {
+		count, err := m.marketplace.CountActiveSubscriptions(ctx, feedID)
+		if err != nil {
+			slog.Warn("failed to count feed subscriptions", "feed_id", feedID, "error", err)
+			continue
+		}
+		if count == 0 {
+			m.stopIfIdle(feedID)
+		}
+	}
+}
+
+// dropUserFromFeed removes a user's clients from a feed's rooms and tells them
+// their subscription expired.
+func (m *Manager) dropUserFromFeed(userID, feedID string) {
+	m.subscriberMu.RLock()
+	var clients []*Client
+	for client := range m.subscribers[feedID] {
+		if client.userID == userID {
+			clients = append(clients, client)
+		}
+	}
+	m.subscriberMu.RUnlock()
+
+	for _, client := range clients {
+		m.rooms.Leave(dataRoom(feedID), client)
+		m.rooms.Leave(llmRoom(feedID), client)
+		m.untrackSubscriber(feedID, client)
+		client.send(makeMessage("subscription-expired", map[string]string{"feedId": feedID}))
+	}
+}
@@ -0,0 +1,71 @@
+package socket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestManager_ExpireSubscriptionsDropsLiveClients(t *testing.T) {
+	ctx := context.Background()
+	feeds := services.NewMemoryFeedRepo()
+	marketplace := services.NewMarketplaceServiceWithRepos(feeds, services.NewMemorySubscriptionRepo())
+	m := NewManager(nil, nil, marketplace, nil)
+
+	feed := models.WebSocketFeed{Name: "Expiring", IsActive: true}
+	require.NoError(t, feeds.Insert(ctx, &feed))
+	feedID := feed.ID.Hex()
+	_, err := marketplace.SubscribeWithTTL(ctx, "alice", feedID, "", time.Minute)
+	require.NoError(t, err)
+	_, err = marketplace.Subscribe(ctx, "bob", feedID, "")
+	require.NoError(t, err)
+
+	newClient := func(userID string) *Client {
+		clientCtx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		client := &Client{ctx: clientCtx, cancel: cancel, out: make(chan WSMessage, 8)}
+		m.identifyClient(client, userID)
+		m.rooms.Join(dataRoom(feedID), client)
+		m.rooms.Join(llmRoom(feedID), client)
+		m.trackSubscriber(feedID, client)
+		return client
+	}
+	alice, bob := newClient("alice"), newClient("bob")
+	stop := make(chan struct{})
+	m.feedConns[feedID] = &feedConnection{stop: stop}
+
+	// Before the TTL runs out the sweep leaves everyone streaming
+	m.expireSubscriptions(ctx, time.Now())
+	assert.Len(t, m.rooms.Members(dataRoom(feedID)), 2)
+
+	// Mid-stream expiry takes alice's client out of the feed's rooms and tells it why
+	m.expireSubscriptions(ctx, time.Now().Add(2*time.Minute))
+	assert.Equal(t, []*Client{bob}, m.rooms.Members(dataRoom(feedID)))
+	assert.Equal(t, []*Client{bob}, m.rooms.Members(llmRoom(feedID)))
+	assert.Equal(t, []string{userRoom("alice")}, m.rooms.Rooms(alice))
+	assert.Equal(t, 1, m.subscriberCount(feedID))
+	select {
+	case msg := <-alice.out:
+		assert.Equal(t, "subscription-expired", msg.Type)
+	default:
+		t.Fatal("expected alice to be told the subscription expired")
+	}
+	assert.Empty(t, bob.out)
+
+	// Broadcasts no longer reach the expired client
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 1.0}, "tick")
+	assert.Empty(t, alice.out)
+
+	// bob keeps the feed running
+	select {
+	case <-stop:
+		t.Fatal("expected the feed to keep running for its remaining subscriber")
+	default:
+	}
+}
@@ -0,0 +1,132 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// SetFeedAccessService sets the service that checks grants to private feeds;
+// without one only their owners may subscribe to them
+func (m *Manager) SetFeedAccessService(access *services.FeedAccessService) {
+	m.access = access
+}
+
+// SetOpenPublicFeeds lets clients subscribe to public feeds without
+// authenticating or holding a subscription; private feeds are unaffected
+func (m *Manager) SetOpenPublicFeeds(open bool) {
+	m.openPublicFeeds = open
+}
+
+// authorizeSubscription checks the client may receive the feed's data,
+// sending subscription-denied and returning false when it may not
+func (m *Manager) authorizeSubscription(client *Client, feedID string) bool {
+	reason := m.subscriptionDenial(client, feedID)
+	if reason == "" {
+		return true
+	}
+	metrics.WSSubscriptionsDenied.Inc()
+	client.send(makeMessage("subscription-denied", map[string]string{"feedId": feedID, "error": reason}))
+	return false
+}
+
+// AuthorizeFeedRead checks the subscriber may read the feed's data under the
+// rules subscribe-feed applies, for paths such as HTTP LLM queries and
+// scheduled analyses that read it without joining its room. It returns a
+// SubscriptionDeniedError when they may not.
+func (m *Manager) AuthorizeFeedRead(ctx context.Context, sub Subscriber, feedID string) error {
+	client := &Client{ctx: ctx, ip: sub.IP, apiKey: sub.APIKey, userID: sub.UserID, authenticated: sub.UserID != ""}
+	if reason := m.subscriptionDenial(client, feedID); reason != "" {
+		return &SubscriptionDeniedError{Reason: reason}
+	}
+	return nil
+}
+
+// authorizeLLMQuery checks the client may read the feed an LLM query draws
+// its context from, under the rules subscribe-feed applies, answering
+// llm-error and returning false when it may not
+func (m *Manager) authorizeLLMQuery(client *Client, feedID, requestID string) bool {
+	reason := m.subscriptionDenial(client, feedID)
+	if reason == "" {
+		return true
+	}
+	client.send(makeMessage("llm-error", map[string]interface{}{
+		"error":     reason,
+		"feedId":    feedID,
+		"requestId": requestID,
+	}))
+	return false
+}
+
+// subscriptionDenial returns why the client may not subscribe to the feed, or
+// "" when it may. Clients must be authenticated (a user ID claimed with
+// register-user is not enough) and either own the feed or hold an active
+// subscription to it; private feeds also need an access grant. Without a
+// marketplace only API key scopes are checked.
+func (m *Manager) subscriptionDenial(client *Client, feedID string) string {
+	if !client.canRead(feedID) {
+		return "API key cannot read this feed"
+	}
+	if m.marketplace == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(client.ctx, 5*time.Second)
+	defer cancel()
+	feed, err := m.marketplace.GetFeedByID(ctx, feedID)
+	if errors.Is(err, services.ErrFeedNotFound) {
+		return "feed not found"
+	}
+	if err != nil {
+		slog.WarnContext(client.ctx, "failed to load feed for subscription", "feed_id", feedID, "error", err)
+		return "failed to load feed"
+	}
+	if feed.IsPublic && m.openPublicFeeds {
+		return ""
+	}
+	if !client.authenticated {
+		return "authenticate before subscribing"
+	}
+	if feed.OwnerID == client.userID {
+		return ""
+	}
+	if !feed.IsPublic {
+		if m.access == nil {
+			return "not authorized to read this feed"
+		}
+		ok, err := m.access.CanAccess(ctx, feed, client.userID)
+		if err != nil {
+			slog.WarnContext(client.ctx, "failed to check feed access", "feed_id", feedID, "user_id", client.userID, "error", err)
+			return "failed to check feed access"
+		}
+		if !ok {
+			return "not authorized to read this feed"
+		}
+	}
+	sub, err := m.marketplace.GetSubscription(ctx, client.userID, feedID)
+	if errors.Is(err, services.ErrSubscriptionNotFound) {
+		return "not subscribed to this feed"
+	}
+	if err != nil {
+		slog.WarnContext(client.ctx, "failed to load subscription", "user_id", client.userID, "feed_id", feedID, "error", err)
+		return "failed to check subscription"
+	}
+	if sub.ExpiresAt != nil && !sub.ExpiresAt.After(time.Now()) {
+		return "subscription expired"
+	}
+	return ""
+}
+
+// RevokeFeedAccess removes a user's clients on this instance from a private
+// feed's rooms after the owner revoked their access, and tells them why
+func (m *Manager) RevokeFeedAccess(userID, feedID string) {
+	for _, client := range m.rooms.Members(userRoom(userID)) {
+		m.rooms.Leave(dataRoom(feedID), client)
+		m.rooms.Leave(llmRoom(feedID), client)
+		m.untrackSubscriber(feedID, client)
+		client.send(makeMessage("feed-access-revoked", map[string]string{"feedId": feedID}))
+	}
+}
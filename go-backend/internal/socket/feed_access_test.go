@@ -0,0 +1,117 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestManager_RevokeFeedAccess(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newClient := func(userID string) *Client {
+		client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 8)}
+		m.identifyClient(client, userID)
+		return client
+	}
+	alice, bob := newClient("alice"), newClient("bob")
+	for _, client := range []*Client{alice, bob} {
+		m.handleMessage(client, WSMessage{Type: "subscribe-all", Payload: json.RawMessage(`{"feedId":"feed-1"}`)})
+		require.Equal(t, "subscription-success", (<-client.out).Type)
+	}
+	for len(alice.out) > 0 {
+		<-alice.out
+	}
+
+	m.RevokeFeedAccess("alice", "feed-1")
+	assert.Equal(t, []string{userRoom("alice")}, m.rooms.Rooms(alice))
+	msg := <-alice.out
+	assert.Equal(t, "feed-access-revoked", msg.Type)
+	assert.JSONEq(t, `{"feedId":"feed-1"}`, string(msg.Payload))
+	assert.Equal(t, LiveSubscribers{FeedID: "feed-1", Connections: 1, Users: 1}, m.LiveSubscribers("feed-1"))
+	assert.Contains(t, m.rooms.Rooms(bob), dataRoom("feed-1"), "other users keep their subscription")
+}
+
+func TestManager_LLMQueryNeedsFeedAccess(t *testing.T) {
+	ctx := context.Background()
+	feeds := services.NewMemoryFeedRepo()
+	m := NewManager(nil, nil, services.NewMarketplaceServiceWithRepos(feeds, services.NewMemorySubscriptionRepo()), nil)
+	feed := models.WebSocketFeed{Name: "Owned", OwnerID: "owner", IsPublic: true, IsActive: true}
+	require.NoError(t, feeds.Insert(ctx, &feed))
+	feedID := feed.ID.Hex()
+
+	newClient := func(userID string) *Client {
+		clientCtx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		client := &Client{ctx: clientCtx, cancel: cancel, out: make(chan WSMessage, 8), authenticated: userID != ""}
+		if userID != "" {
+			m.identifyClient(client, userID)
+		}
+		return client
+	}
+	llmError := func(client *Client) map[string]interface{} {
+		t.Helper()
+		msg := <-client.out
+		require.Equal(t, "llm-error", msg.Type)
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		return payload
+	}
+
+	// Neither an unsubscribed user nor an anonymous client gets the feed's data through the AI
+	stranger := newClient("stranger")
+	m.handleLLMQuery(ctx, stranger, feedID, "what is the price?", "", "", "req-1", services.ChatOptions{}, true, nil)
+	assert.Equal(t, map[string]interface{}{"error": "not subscribed to this feed", "feedId": feedID, "requestId": "req-1"}, llmError(stranger))
+	m.handleLLMStreamQuery(ctx, stranger, feedID, "what is the price?", "", "", "req-2", services.ChatOptions{}, nil)
+	assert.Equal(t, "not subscribed to this feed", llmError(stranger)["error"])
+	anonymous := newClient("")
+	m.handleLLMQuery(ctx, anonymous, feedID, "what is the price?", "", "", "req-3", services.ChatOptions{}, false, nil)
+	assert.Equal(t, "authenticate before subscribing", llmError(anonymous)["error"])
+
+	// The owner passes the check and reaches the (unconfigured) LLM service
+	owner := newClient("owner")
+	m.handleLLMQuery(ctx, owner, feedID, "what is the price?", "", "", "req-4", services.ChatOptions{}, false, nil)
+	assert.Equal(t, "LLM service not configured", llmError(owner)["error"])
+}
+
+func TestManager_AuthorizeFeedRead(t *testing.T) {
+	ctx := context.Background()
+	feeds := services.NewMemoryFeedRepo()
+	marketplace := services.NewMarketplaceServiceWithRepos(feeds, services.NewMemorySubscriptionRepo())
+	m := NewManager(nil, nil, marketplace, nil)
+	public := models.WebSocketFeed{Name: "Public", OwnerID: "owner", IsPublic: true, IsActive: true}
+	private := models.WebSocketFeed{Name: "Private", OwnerID: "owner", IsActive: true}
+	require.NoError(t, feeds.Insert(ctx, &public))
+	require.NoError(t, feeds.Insert(ctx, &private))
+	_, err := marketplace.Subscribe(ctx, "subscriber", public.ID.Hex(), "")
+	require.NoError(t, err)
+	_, err = marketplace.Subscribe(ctx, "subscriber", private.ID.Hex(), "")
+	require.NoError(t, err)
+
+	denial := func(userID string, feed models.WebSocketFeed) string {
+		err := m.AuthorizeFeedRead(ctx, Subscriber{UserID: userID}, feed.ID.Hex())
+		if err == nil {
+			return ""
+		}
+		var denied *SubscriptionDeniedError
+		require.ErrorAs(t, err, &denied)
+		return denied.Reason
+	}
+	assert.Empty(t, denial("owner", private))
+	assert.Empty(t, denial("subscriber", public))
+	assert.Equal(t, "not authorized to read this feed", denial("subscriber", private), "private feeds also need a grant")
+	assert.Equal(t, "not subscribed to this feed", denial("stranger", public))
+	assert.Equal(t, "authenticate before subscribing", denial("", public))
+
+	err = m.AuthorizeFeedRead(ctx, Subscriber{UserID: "owner"}, "missing")
+	var denied *SubscriptionDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.True(t, denied.NotFound())
+}
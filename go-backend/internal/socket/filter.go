@@ -0,0 +1,169 @@
+package socket
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// feedFilter is one parsed subscription filter, e.g. `data.symbol == "BTCUSDT"`.
+// Paths are evaluated against the feed-data payload, so they usually start with "data.".
+type feedFilter struct {
+	path  string
+	op    string
+	value interface{}
+}
+
+var (
+	filterComparison = regexp.MustCompile(`^\s*(\S+?)\s*(==|!=|>=|<=|>|<)\s*(.+?)\s*$`)
+	filterContains   = regexp.MustCompile(`^\s*(\S+)\s+contains\s+(.+?)\s*$`)
+)
+
+// parseFilter parses "<path> <op> <value>" where op is ==, !=, >, >=, <, <= or
+// contains. Values are quoted strings, numbers, true, false or null; other bare
+// words are treated as strings.
+func parseFilter(expr string) (feedFilter, error) {
+	if m := filterContains.FindStringSubmatch(expr); m != nil {
+		return feedFilter{path: m[1], op: "contains", value: parseFilterValue(m[2])}, nil
+	}
+	m := filterComparison.FindStringSubmatch(expr)
+	if m == nil {
+		return feedFilter{}, fmt.Errorf("invalid filter %q: expected <path> <op> <value>", expr)
+	}
+	value := parseFilterValue(m[3])
+	if m[2] != "==" && m[2] != "!=" {
+		if _, ok := value.(float64); !ok {
+			return feedFilter{}, fmt.Errorf("invalid filter %q: %s needs a number", expr, m[2])
+		}
+	}
+	return feedFilter{path: m[1], op: m[2], value: value}, nil
+}
+
+// parseFilterValue turns a filter literal into a string, float64, bool or nil
+func parseFilterValue(raw string) interface{} {
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		return raw[1 : len(raw)-1]
+	}
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return n
+	}
+	return raw
+}
+
+// parseFilters parses every expression, failing on the first invalid one
+func parseFilters(exprs []string) ([]feedFilter, error) {
+	filters := make([]feedFilter, 0, len(exprs))
+	for _, expr := range exprs {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		f, err := parseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// ValidateFilters reports the first subscription filter that cannot be parsed
+func ValidateFilters(exprs []string) error {
+	_, err := parseFilters(exprs)
+	return err
+}
+
+// matches reports whether the payload satisfies the filter. A missing field only matches !=.
+func (f feedFilter) matches(payload interface{}) bool {
+	actual, err := extractDataPath(payload, f.path)
+	if err != nil {
+		return f.op == "!="
+	}
+
+	switch f.op {
+	case "==":
+		return filterEqual(actual, f.value)
+	case "!=":
+		return !filterEqual(actual, f.value)
+	case "contains":
+		return filterContainsValue(actual, f.value)
+	}
+
+	a, ok := filterNumber(actual)
+	if !ok {
+		return false
+	}
+	b := f.value.(float64)
+	switch f.op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+// matchFilters reports whether the payload satisfies every filter
+func matchFilters(filters []feedFilter, payload interface{}) bool {
+	for _, f := range filters {
+		if !f.matches(payload) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterNumber reads numbers and numeric strings (upstreams often quote prices)
+func filterNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// filterEqual compares numerically when both sides are numbers, otherwise by value
+func filterEqual(actual, expected interface{}) bool {
+	if want, ok := expected.(float64); ok {
+		got, ok := filterNumber(actual)
+		return ok && got == want
+	}
+	if expected == nil || actual == nil {
+		return expected == actual
+	}
+	if want, ok := expected.(bool); ok {
+		got, ok := actual.(bool)
+		return ok && got == want
+	}
+	return fmt.Sprint(actual) == fmt.Sprint(expected)
+}
+
+// filterContainsValue checks substrings of strings and elements of arrays
+func filterContainsValue(actual, expected interface{}) bool {
+	switch v := actual.(type) {
+	case string:
+		return strings.Contains(v, fmt.Sprint(expected))
+	case []interface{}:
+		for _, item := range v {
+			if filterEqual(item, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr        string
+		expected    feedFilter
+		expectError bool
+	}{
+		{expr: `data.symbol == "BTCUSDT"`, expected: feedFilter{path: "data.symbol", op: "==", value: "BTCUSDT"}},
+		{expr: `data.price>=100.5`, expected: feedFilter{path: "data.price", op: ">=", value: 100.5}},
+		{expr: `data.side != 'sell'`, expected: feedFilter{path: "data.side", op: "!=", value: "sell"}},
+		{expr: `data.active == true`, expected: feedFilter{path: "data.active", op: "==", value: true}},
+		{expr: `data.tags contains hot`, expected: feedFilter{path: "data.tags", op: "contains", value: "hot"}},
+		{expr: `data.price > cheap`, expectError: true},
+		{expr: `data.price`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseFilter(tt.expr)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestMatchFilters(t *testing.T) {
+	payload := map[string]interface{}{
+		"feedId": "feed-1",
+		"data": map[string]interface{}{
+			"symbol": "BTCUSDT",
+			"price":  "42000.5",
+			"volume": 12.0,
+			"tags":   []interface{}{"hot", "spot"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filters  []string
+		expected bool
+	}{
+		{"no filters", nil, true},
+		{"string equality", []string{`data.symbol == "BTCUSDT"`}, true},
+		{"string mismatch", []string{`data.symbol == "ETHUSDT"`}, false},
+		{"quoted number compared numerically", []string{`data.price > 40000`}, true},
+		{"threshold not reached", []string{`data.volume >= 20`}, false},
+		{"all filters must match", []string{`data.symbol == "BTCUSDT"`, `data.volume < 10`}, false},
+		{"array contains", []string{`data.tags contains "spot"`}, true},
+		{"missing field", []string{`data.bid > 1`}, false},
+		{"missing field is not equal", []string{`data.bid != 1`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := parseFilters(tt.filters)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matchFilters(filters, payload))
+		})
+	}
+}
+
+func TestRoomManager_Broadcast_FiltersPerClient(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Ticker"}
+
+	dial := func(filters []string) *coderws.Conn {
+		conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		require.NoError(t, err)
+		payload := map[string]interface{}{"feedId": feed.ID.Hex()}
+		if filters != nil {
+			payload["filters"] = filters
+		}
+		require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{"type": "subscribe-feed", "payload": payload}))
+		var ack WSMessage
+		require.NoError(t, wsjson.Read(ctx, conn, &ack))
+		require.Equal(t, "subscription-success", ack.Type)
+		return conn
+	}
+	btcOnly := dial([]string{`data.symbol == "BTCUSDT"`})
+	defer btcOnly.Close(coderws.StatusNormalClosure, "")
+	everything := dial(nil)
+	defer everything.Close(coderws.StatusNormalClosure, "")
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"symbol": "ETHUSDT"}, "")
+	m.BroadcastFeedData(feed, map[string]interface{}{"symbol": "BTCUSDT"}, "")
+
+	symbols := func(conn *coderws.Conn, n int) []string {
+		var out []string
+		for i := 0; i < n; i++ {
+			var msg struct {
+				Payload struct {
+					Data map[string]interface{} `json:"data"`
+				} `json:"payload"`
+			}
+			require.NoError(t, wsjson.Read(ctx, conn, &msg))
+			out = append(out, msg.Payload.Data["symbol"].(string))
+		}
+		return out
+	}
+	assert.Equal(t, []string{"ETHUSDT", "BTCUSDT"}, symbols(everything, 2))
+	assert.Equal(t, []string{"BTCUSDT"}, symbols(btcOnly, 1))
+}
+
+func TestManager_SubscribeRejectsInvalidFilters(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": map[string]interface{}{"feedId": "feed-1", "filters": []string{"data.price > cheap"}},
+	}))
+	var reply WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &reply))
+	assert.Equal(t, "subscription-error", reply.Type)
+}
@@ -0,0 +1,331 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	pb "github.com/turboline-ai/turbostream/go-backend/pkg/proto"
+)
+
+// GRPCServer serves the TurboStream gRPC API. Each call gets a client of its
+// own, so gRPC consumers join the same rooms and pass the same access checks,
+// filters and quotas as websocket clients.
+type GRPCServer struct {
+	pb.UnimplementedTurboStreamServer
+	m *Manager
+}
+
+// NewGRPCServer creates the gRPC API server for the manager's feeds
+func NewGRPCServer(m *Manager) *GRPCServer {
+	return &GRPCServer{m: m}
+}
+
+// SubscribeFeed streams the feed's data, after any replay, and its status
+// changes until the caller cancels or the server shuts down
+func (s *GRPCServer) SubscribeFeed(req *pb.SubscribeFeedRequest, stream grpc.ServerStreamingServer[pb.FeedEvent]) error {
+	feedID := req.GetFeedId()
+	if feedID == "" {
+		return status.Error(codes.InvalidArgument, "feed_id is required")
+	}
+	if err := ValidateFilters(req.GetFilters()); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	sub, err := s.subscriber(stream.Context())
+	if err != nil {
+		return err
+	}
+	feed, err := s.m.OpenFeedStream(stream.Context(), sub, feedID, req.GetFilters(), int(req.GetReplayCount()))
+	if err != nil {
+		return streamStatus(err)
+	}
+	defer feed.Close()
+
+	for {
+		event, err := feed.Next()
+		if err != nil {
+			return streamStatus(err)
+		}
+		if out := feedEvent(event); out != nil {
+			if err := stream.Send(out); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PublishData broadcasts a message on a feed the caller manages, as the
+// REST data endpoint does
+func (s *GRPCServer) PublishData(ctx context.Context, req *pb.PublishDataRequest) (*pb.PublishDataResponse, error) {
+	m := s.m
+	sub, err := s.subscriber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID == "" {
+		return nil, status.Error(codes.Unauthenticated, "authenticate before publishing")
+	}
+	feedID := req.GetFeedId()
+	if feedID == "" {
+		return nil, status.Error(codes.InvalidArgument, "feed_id is required")
+	}
+	if sub.APIKey != nil && !sub.APIKey.Allows(feedID, models.APIKeyScopePublish) {
+		return nil, status.Error(codes.PermissionDenied, "API key cannot publish to this feed")
+	}
+	if m.marketplace == nil {
+		return nil, status.Error(codes.Unavailable, "marketplace is not configured")
+	}
+	if err := allowGRPCCall(m.limits.User, "user", sub.UserID); err != nil {
+		return nil, err
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	feed, err := m.marketplace.GetFeedByID(lookupCtx, feedID)
+	if errors.Is(err, services.ErrFeedNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	manages, err := m.marketplace.CanManageFeed(lookupCtx, feed, sub.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !manages {
+		return nil, status.Error(codes.PermissionDenied, services.ErrNotAuthorized.Error())
+	}
+	if !feed.IsActive {
+		return nil, status.Error(codes.FailedPrecondition, services.ErrFeedPaused.Error())
+	}
+	m.BroadcastFeedData(*feed, req.GetData().AsInterface(), req.GetEventName())
+	return &pb.PublishDataResponse{
+		FeedId:    feed.ID.Hex(),
+		FeedName:  feed.Name,
+		Timestamp: timestamppb.Now(),
+	}, nil
+}
+
+// QueryLLM answers a question about the feed's recent data the way the
+// llm-query websocket event does, waiting for the answer
+func (s *GRPCServer) QueryLLM(ctx context.Context, req *pb.QueryLLMRequest) (*pb.QueryLLMResponse, error) {
+	m := s.m
+	sub, err := s.subscriber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sub.APIKey != nil {
+		return nil, status.Error(codes.PermissionDenied, "API keys cannot query the AI")
+	}
+	if req.GetFeedId() == "" || req.GetQuestion() == "" {
+		return nil, status.Error(codes.InvalidArgument, "feed_id and question are required")
+	}
+	key := sub.UserID
+	if key == "" {
+		key = sub.IP
+	}
+	if err := allowGRPCCall(m.limits.LLM, "llm", key); err != nil {
+		return nil, err
+	}
+	if !m.startLLMQuery() {
+		return nil, status.Error(codes.Unavailable, "server shutting down")
+	}
+	defer m.llmWG.Done()
+
+	// handleLLMQuery answers on a client's queue, so the call gets one
+	client := m.streamClient(ctx, sub)
+	defer func() {
+		m.rooms.LeaveAll(client)
+		client.cancel()
+	}()
+	if reason := m.subscriptionDenial(client, req.GetFeedId()); reason != "" {
+		return nil, streamStatus(&SubscriptionDeniedError{Reason: reason})
+	}
+	opts := services.ChatOptions{Temperature: req.Temperature, MaxTokens: int(req.GetMaxTokens())}
+	msgCtx := logging.With(logging.WithRequestID(client.ctx, logging.NewID()), "rpc", "QueryLLM")
+	m.handleLLMQuery(msgCtx, client, req.GetFeedId(), req.GetQuestion(), req.GetProvider(), req.GetSystemPrompt(), "", opts, false, nil)
+
+	// handleLLMQuery answers on the client's queue; token usage updates may come first
+	for {
+		select {
+		case msg := <-client.out:
+			client.pending.Add(-1)
+			if resp, done, err := llmQueryResult(msg); done {
+				return resp, err
+			}
+		default:
+			return nil, status.Error(codes.Internal, "no answer from the LLM service")
+		}
+	}
+}
+
+// subscriber identifies the caller from an "authorization: Bearer <token>" or
+// "x-api-key" metadata entry. Calls without credentials are anonymous.
+func (s *GRPCServer) subscriber(ctx context.Context) (Subscriber, error) {
+	var sub Subscriber
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		sub.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(sub.IP); err == nil {
+			sub.IP = host
+		}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	apiKey := firstMetadata(md, "x-api-key")
+	token, _ := strings.CutPrefix(firstMetadata(md, "authorization"), "Bearer ")
+	if apiKey == "" && token == "" {
+		return sub, nil
+	}
+	if s.m.auth == nil {
+		return sub, status.Error(codes.Unauthenticated, "authentication is not configured")
+	}
+	authCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if apiKey != "" {
+		key, err := s.m.auth.AuthenticateAPIKey(authCtx, apiKey)
+		if err != nil {
+			return sub, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		sub.UserID = key.UserID.Hex()
+		sub.APIKey = &key
+		return sub, nil
+	}
+	// Verify token and that its session has not been terminated
+	claims, err := s.m.auth.Authenticate(authCtx, token)
+	if err != nil {
+		return sub, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	userID, ok := claims["userId"].(string)
+	if !ok {
+		return sub, status.Error(codes.Unauthenticated, "invalid token claims")
+	}
+	sub.UserID = userID
+	return sub, nil
+}
+
+// streamStatus converts an error opening or reading a feed stream to a status
+func streamStatus(err error) error {
+	var denied *SubscriptionDeniedError
+	switch {
+	case errors.As(err, &denied) && denied.NotFound():
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &denied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrStreamSlow):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, ErrStreamClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// allowGRPCCall applies a rate limit to a call, returning ResourceExhausted
+// with the wait when it is over
+func allowGRPCCall(limiter *ratelimit.Limiter, scope, key string) error {
+	ok, wait := limiter.Allow(key)
+	if ok {
+		return nil
+	}
+	metrics.RateLimited.WithLabelValues("grpc_" + scope).Inc()
+	return status.Error(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded, retry in %s", wait.Round(time.Millisecond)))
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// feedEvent converts a stream event, or returns nil for feed data with no
+// protobuf Value form
+func feedEvent(event StreamEvent) *pb.FeedEvent {
+	if st := event.Status; st != nil {
+		return &pb.FeedEvent{Event: &pb.FeedEvent_Status{Status: &pb.FeedStatus{
+			FeedId:  st.FeedID,
+			Status:  st.Status,
+			Message: st.Message,
+		}}}
+	}
+	msg := event.Data
+	data, err := structpb.NewValue(msg.Data)
+	if err != nil {
+		slog.Warn("skipping feed data with no protobuf form", "feed_id", msg.FeedID, "error", err)
+		return nil
+	}
+	return &pb.FeedEvent{Event: &pb.FeedEvent_Data{Data: &pb.FeedData{
+		FeedId:    msg.FeedID,
+		FeedName:  msg.FeedName,
+		EventName: msg.EventName,
+		Data:      data,
+		Timestamp: timestamppb.New(msg.Timestamp),
+	}}}
+}
+
+// llmQueryResult converts handleLLMQuery's answer; done is false for other
+// messages on the client's queue
+func llmQueryResult(msg WSMessage) (*pb.QueryLLMResponse, bool, error) {
+	switch msg.Type {
+	case "llm-response":
+		var answer struct {
+			Answer           string `json:"answer"`
+			Provider         string `json:"provider"`
+			Model            string `json:"model"`
+			FeedID           string `json:"feedId"`
+			DurationMs       int64  `json:"durationMs"`
+			Cached           bool   `json:"cached"`
+			PromptTokens     int32  `json:"promptTokens"`
+			CompletionTokens int32  `json:"completionTokens"`
+			TokensUsed       int32  `json:"tokensUsed"`
+		}
+		if err := json.Unmarshal(msg.Payload, &answer); err != nil {
+			return nil, true, status.Error(codes.Internal, "unreadable LLM response")
+		}
+		return &pb.QueryLLMResponse{
+			Answer:           answer.Answer,
+			Provider:         answer.Provider,
+			Model:            answer.Model,
+			FeedId:           answer.FeedID,
+			DurationMs:       answer.DurationMs,
+			Cached:           answer.Cached,
+			PromptTokens:     answer.PromptTokens,
+			CompletionTokens: answer.CompletionTokens,
+			TokensUsed:       answer.TokensUsed,
+		}, true, nil
+	case "llm-error", "quota-exceeded":
+		var failure struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(msg.Payload, &failure)
+		code := codes.Internal
+		switch {
+		case msg.Type == "quota-exceeded":
+			code = codes.ResourceExhausted
+		case failure.Error == "LLM service not configured":
+			code = codes.Unavailable
+		case failure.Error == errLLMAuthRequired:
+			code = codes.Unauthenticated
+		}
+		return nil, true, status.Error(code, failure.Error)
+	}
+	return nil, false, nil
+}
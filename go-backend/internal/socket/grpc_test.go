@@ -0,0 +1,127 @@
+package socket
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	pb "github.com/turboline-ai/turbostream/go-backend/pkg/proto"
+)
+
+// dialGRPC serves the manager's gRPC API in memory and returns a client for it
+func dialGRPC(t *testing.T, m *Manager) pb.TurboStreamClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterTurboStreamServer(srv, NewGRPCServer(m))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTurboStreamClient(conn)
+}
+
+func TestGRPCServer_SubscribeFeed(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	client := dialGRPC(t, m)
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Prices", IsActive: true}
+	feedID := feed.ID.Hex()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.SubscribeFeed(ctx, &pb.SubscribeFeedRequest{FeedId: feedID})
+	require.NoError(t, err)
+	filtered, err := client.SubscribeFeed(ctx, &pb.SubscribeFeedRequest{FeedId: feedID, Filters: []string{`data.price > 10`}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(m.rooms.Members(dataRoom(feedID))) == 2 }, 2*time.Second, 10*time.Millisecond)
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 12.5, "symbol": "BTC"}, "tick")
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 5.0}, "tick")
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 20.0}, "tick")
+	m.rooms.Broadcast(dataRoom(feedID), makeMessage("feed-paused", feedStatus{FeedID: feedID, Status: "paused", Message: "feed is paused"}))
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	data := event.GetData()
+	require.NotNil(t, data)
+	assert.Equal(t, feedID, data.GetFeedId())
+	assert.Equal(t, "Prices", data.GetFeedName())
+	assert.Equal(t, "tick", data.GetEventName())
+	assert.Equal(t, map[string]interface{}{"price": 12.5, "symbol": "BTC"}, data.GetData().AsInterface())
+	assert.False(t, data.GetTimestamp().AsTime().IsZero())
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, event.GetData().GetData().GetStructValue().GetFields()["price"].GetNumberValue())
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, event.GetData().GetData().GetStructValue().GetFields()["price"].GetNumberValue())
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "paused", event.GetStatus().GetStatus())
+
+	event, err = filtered.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, event.GetData().GetData().GetStructValue().GetFields()["price"].GetNumberValue())
+	event, err = filtered.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, event.GetData().GetData().GetStructValue().GetFields()["price"].GetNumberValue(), "the filter drops the cheap message")
+
+	cancel()
+	require.Eventually(t, func() bool { return len(m.rooms.Members(dataRoom(feedID))) == 0 }, 2*time.Second, 10*time.Millisecond,
+		"the stream's client leaves the room when the call ends")
+}
+
+func TestGRPCServer_SubscribeFeedRejectsBadFilters(t *testing.T) {
+	client := dialGRPC(t, NewManager(nil, nil, nil, nil))
+	stream, err := client.SubscribeFeed(context.Background(), &pb.SubscribeFeedRequest{FeedId: "f1", Filters: []string{`data.price`}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCServer_Authentication(t *testing.T) {
+	client := dialGRPC(t, NewManager(nil, nil, nil, nil))
+	data, err := structpb.NewValue(map[string]interface{}{"price": 1.0})
+	require.NoError(t, err)
+
+	_, err = client.PublishData(context.Background(), &pb.PublishDataRequest{FeedId: "f1", Data: data})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "publishing needs credentials")
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+	_, err = client.QueryLLM(ctx, &pb.QueryLLMRequest{FeedId: "f1", Question: "trend?"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "credentials that cannot be checked are rejected")
+
+	_, err = client.QueryLLM(context.Background(), &pb.QueryLLMRequest{FeedId: "f1", Question: "trend?"})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "no LLM service is configured")
+}
+
+func TestGRPCServer_QueryLLMNeedsFeedAccess(t *testing.T) {
+	feeds := services.NewMemoryFeedRepo()
+	feed := models.WebSocketFeed{Name: "Private", OwnerID: "owner", IsActive: true}
+	require.NoError(t, feeds.Insert(context.Background(), &feed))
+	client := dialGRPC(t, NewManager(nil, nil, services.NewMarketplaceServiceWithRepos(feeds, services.NewMemorySubscriptionRepo()), nil))
+
+	_, err := client.QueryLLM(context.Background(), &pb.QueryLLMRequest{FeedId: feed.ID.Hex(), Question: "trend?"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the AI does not read feeds the caller cannot subscribe to")
+	_, err = client.QueryLLM(context.Background(), &pb.QueryLLMRequest{FeedId: primitive.NewObjectID().Hex(), Question: "trend?"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,172 @@
+package socket
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+const (
+	healthConnected    = "connected"
+	healthDisconnected = "disconnected"
+	healthErroring     = "erroring"
+)
+
+// FeedHealth is the upstream state of one feed as seen by the instance
+// holding its connection. It is pushed to the feed's data room as a
+// feed-health event whenever Status changes.
+type FeedHealth struct {
+	FeedID        string     `json:"feedId"`
+	Status        string     `json:"status"` // "connected", "disconnected" or "erroring"
+	ConnectedAt   *time.Time `json:"connectedAt,omitempty"`
+	LastMessageAt *time.Time `json:"lastMessageAt,omitempty"`
+	MessageCount  int64      `json:"messageCount"`
+	ErrorCount    int64      `json:"errorCount"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// FeedHealth returns the feed's current health; feeds never connected report disconnected
+func (m *Manager) FeedHealth(feedID string) FeedHealth {
+	if h, ok := m.knownFeedHealth(feedID); ok {
+		return h
+	}
+	return FeedHealth{FeedID: feedID, Status: healthDisconnected, UpdatedAt: time.Now().UTC()}
+}
+
+// FeedStatusCounts tallies the feeds with a known health by status
+func (m *Manager) FeedStatusCounts() map[string]int {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	counts := map[string]int{healthConnected: 0, healthDisconnected: 0, healthErroring: 0}
+	for _, h := range m.health {
+		counts[h.Status]++
+	}
+	return counts
+}
+
+// knownFeedHealth returns the feed's health if anything has been recorded for it
+func (m *Manager) knownFeedHealth(feedID string) (FeedHealth, bool) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	h, ok := m.health[feedID]
+	if !ok {
+		return FeedHealth{}, false
+	}
+	return *h, true
+}
+
+// updateHealth applies change to the feed's health and broadcasts a
+// feed-health event when its status changed
+func (m *Manager) updateHealth(feedID string, change func(h *FeedHealth)) {
+	now := time.Now().UTC()
+	m.healthMu.Lock()
+	if m.health == nil {
+		m.health = make(map[string]*FeedHealth)
+	}
+	h, ok := m.health[feedID]
+	if !ok {
+		h = &FeedHealth{FeedID: feedID, Status: healthDisconnected}
+		m.health[feedID] = h
+	}
+	previous := h.Status
+	change(h)
+	h.UpdatedAt = now
+	snapshot := *h
+	m.healthMu.Unlock()
+
+	if snapshot.Status != previous {
+		room := dataRoom(feedID)
+		msg := makeMessage("feed-health", snapshot)
+		m.rooms.Broadcast(room, msg)
+		m.publishToCluster(room, msg)
+		m.dispatchWebhooks(feedID, "", services.WebhookEventFeedHealth, snapshot)
+	}
+}
+
+// markFeedConnected records that the feed's upstream connection is open
+func (m *Manager) markFeedConnected(feedID string) {
+	m.updateHealth(feedID, func(h *FeedHealth) {
+		if h.Status != healthConnected || h.ConnectedAt == nil {
+			now := time.Now().UTC()
+			h.ConnectedAt = &now
+		}
+		h.Status = healthConnected
+	})
+}
+
+// recordFeedMessage records a message from the feed. A message after errors
+// means the upstream is working again.
+func (m *Manager) recordFeedMessage(feedID string) {
+	m.updateHealth(feedID, func(h *FeedHealth) {
+		now := time.Now().UTC()
+		h.LastMessageAt = &now
+		h.MessageCount++
+		if h.Status == healthErroring && m.IsFeedConnected(feedID) {
+			h.Status = healthConnected
+		}
+	})
+}
+
+// recordFeedError records an upstream error and marks the feed as erroring
+func (m *Manager) recordFeedError(feedID string, err error) {
+	m.updateHealth(feedID, func(h *FeedHealth) {
+		now := time.Now().UTC()
+		h.ErrorCount++
+		h.LastErrorAt = &now
+		if err != nil {
+			h.LastError = err.Error()
+		}
+		h.Status = healthErroring
+	})
+}
+
+// markFeedDisconnected records that the feed has no upstream connection and none is being retried
+func (m *Manager) markFeedDisconnected(feedID string) {
+	m.updateHealth(feedID, func(h *FeedHealth) {
+		h.Status = healthDisconnected
+		h.ConnectedAt = nil
+	})
+}
+
+// feedClosed removes the feed's connection once its loop has exited. A feed
+// that failed and will be reconnected stays erroring; otherwise it is
+// disconnected.
+func (m *Manager) feedClosed(feed models.WebSocketFeed) {
+	feedID := feed.ID.Hex()
+	m.feedMu.Lock()
+	delete(m.feedConns, feedID)
+	m.feedMu.Unlock()
+
+	if h, ok := m.knownFeedHealth(feedID); ok && h.Status == healthErroring && feed.ReconnectionEnabled && !m.isClosing() {
+		return
+	}
+	m.markFeedDisconnected(feedID)
+}
+
+// sendFeedHealth tells a new subscriber the feed's current health, if known
+func (m *Manager) sendFeedHealth(client *Client, feedID string) {
+	if h, ok := m.knownFeedHealth(feedID); ok {
+		client.send(makeMessage("feed-health", h))
+	}
+}
+
+// storeRemoteHealth keeps the health reported by the instance that owns the
+// feed so the health API answers the same on every instance
+func (m *Manager) storeRemoteHealth(payload []byte) {
+	var h FeedHealth
+	if err := json.Unmarshal(payload, &h); err != nil || h.FeedID == "" {
+		slog.Warn("ignoring unreadable feed-health message", "error", err)
+		return
+	}
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	if m.health == nil {
+		m.health = make(map[string]*FeedHealth)
+	}
+	m.health[h.FeedID] = &h
+}
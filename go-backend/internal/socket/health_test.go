@@ -0,0 +1,109 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// healthEvents drains the client's queue and returns the feed-health statuses sent
+func healthEvents(t *testing.T, client *Client) []FeedHealth {
+	t.Helper()
+	var events []FeedHealth
+	for len(client.out) > 0 {
+		msg := <-client.out
+		if msg.Type != "feed-health" {
+			continue
+		}
+		var h FeedHealth
+		require.NoError(t, json.Unmarshal(msg.Payload, &h))
+		events = append(events, h)
+	}
+	return events
+}
+
+func TestManager_FeedHealthTransitions(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), ReconnectionEnabled: true}
+	feedID := feed.ID.Hex()
+	m.rooms.Join(dataRoom(feedID), client)
+
+	assert.Equal(t, healthDisconnected, m.FeedHealth(feedID).Status, "unknown feeds are disconnected")
+
+	stop := make(chan struct{})
+	m.feedConns[feedID] = &feedConnection{stop: stop}
+	m.markFeedConnected(feedID)
+	m.recordFeedMessage(feedID)
+	m.recordFeedMessage(feedID)
+
+	events := healthEvents(t, client)
+	require.Len(t, events, 1, "only status changes are broadcast")
+	assert.Equal(t, healthConnected, events[0].Status)
+
+	h := m.FeedHealth(feedID)
+	assert.Equal(t, int64(2), h.MessageCount)
+	assert.NotNil(t, h.LastMessageAt)
+	assert.NotNil(t, h.ConnectedAt)
+
+	m.recordFeedError(feedID, errors.New("read timeout"))
+	m.recordFeedError(feedID, errors.New("read timeout"))
+	events = healthEvents(t, client)
+	require.Len(t, events, 1)
+	assert.Equal(t, healthErroring, events[0].Status)
+	assert.Equal(t, "read timeout", events[0].LastError)
+	assert.Equal(t, int64(2), m.FeedHealth(feedID).ErrorCount)
+
+	// A message while still connected means the upstream recovered
+	m.recordFeedMessage(feedID)
+	events = healthEvents(t, client)
+	require.Len(t, events, 1)
+	assert.Equal(t, healthConnected, events[0].Status)
+
+	// A failed feed that will reconnect stays erroring after its loop exits
+	m.recordFeedError(feedID, errors.New("connection reset"))
+	m.feedClosed(feed)
+	assert.Equal(t, healthErroring, m.FeedHealth(feedID).Status)
+	assert.False(t, m.IsFeedConnected(feedID))
+
+	m.markFeedDisconnected(feedID)
+	assert.Equal(t, healthDisconnected, m.FeedHealth(feedID).Status)
+	assert.Nil(t, m.FeedHealth(feedID).ConnectedAt)
+}
+
+func TestManager_FeedClosedWithoutReconnect(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID()}
+	feedID := feed.ID.Hex()
+
+	m.feedConns[feedID] = &feedConnection{stop: make(chan struct{})}
+	m.markFeedConnected(feedID)
+	m.recordFeedError(feedID, errors.New("eof"))
+	m.feedClosed(feed)
+
+	h := m.FeedHealth(feedID)
+	assert.Equal(t, healthDisconnected, h.Status)
+	assert.Equal(t, int64(1), h.ErrorCount, "error history is kept")
+	assert.Equal(t, map[string]int{healthConnected: 0, healthDisconnected: 1, healthErroring: 0}, m.FeedStatusCounts())
+}
+
+func TestManager_StoreRemoteHealth(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	payload, err := json.Marshal(FeedHealth{FeedID: "feed-1", Status: healthConnected, MessageCount: 7})
+	require.NoError(t, err)
+
+	m.storeRemoteHealth(payload)
+	h := m.FeedHealth("feed-1")
+	assert.Equal(t, healthConnected, h.Status)
+	assert.Equal(t, int64(7), h.MessageCount)
+}
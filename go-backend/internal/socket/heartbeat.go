@@ -0,0 +1,89 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+)
+
+// Heartbeat defaults: how often clients are pinged and how long a pong may take
+const (
+	defaultPingInterval = 30 * time.Second
+	defaultPingTimeout  = 10 * time.Second
+)
+
+// SetHeartbeat sets how often clients are pinged and how long they have to
+// answer before they are dropped as stale (an interval of 0 disables pings).
+// It applies to clients that connect afterwards.
+func (m *Manager) SetHeartbeat(interval, timeout time.Duration) {
+	m.pingInterval = interval
+	m.pingTimeout = timeout
+}
+
+// heartbeat pings the client until it disconnects. A client that misses a
+// pong leaves its rooms at once, so broadcasts stop queuing for it, and is
+// disconnected.
+func (m *Manager) heartbeat(client *Client, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := client.ping(timeout); err != nil {
+			if client.ctx.Err() != nil {
+				return
+			}
+			metrics.WSStaleClients.Inc()
+			slog.WarnContext(client.ctx, "disconnecting stale websocket client", "user_id", client.userID, "error", err)
+			m.rooms.LeaveAll(client)
+			// The peer isn't answering, so don't wait on a close handshake
+			_ = client.conn.CloseNow()
+			client.cancel()
+			return
+		}
+	}
+}
+
+// ping sends a websocket ping and waits for its pong, recording the round trip
+func (c *Client) ping(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	c.pingsSent.Add(1)
+	if err := c.conn.Ping(ctx); err != nil {
+		return err
+	}
+	now := time.Now()
+	c.lastPong.Store(now.UnixNano())
+	c.latency.Store(int64(now.Sub(start)))
+	return nil
+}
+
+// connectionStats is the payload of a connection-stats message; latencyMs is
+// -1 until the first pong
+func (m *Manager) connectionStats(client *Client, now time.Time) map[string]interface{} {
+	stats := map[string]interface{}{
+		"connectedAt":      client.connectedAt,
+		"uptimeMs":         now.Sub(client.connectedAt).Milliseconds(),
+		"latencyMs":        int64(-1),
+		"pingsSent":        client.pingsSent.Load(),
+		"messagesSent":     client.sent.Load(),
+		"messagesReceived": client.received.Load(),
+		"dropped":          client.dropped.Load(),
+		"queued":           len(client.out),
+		"rooms":            m.rooms.Rooms(client),
+		"protocolVersion":  client.protocolVersion(),
+		"compression":      client.deflate,
+		"pingIntervalMs":   m.pingInterval.Milliseconds(),
+	}
+	if lastPong := client.lastPong.Load(); lastPong != 0 {
+		stats["latencyMs"] = time.Duration(client.latency.Load()).Milliseconds()
+		stats["lastPongAt"] = time.Unix(0, lastPong).UTC()
+	}
+	return stats
+}
@@ -0,0 +1,86 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestManager_HeartbeatDropsStaleClients(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetHeartbeat(20*time.Millisecond, 50*time.Millisecond)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+
+	feedID := primitive.NewObjectID().Hex()
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": map[string]interface{}{"feedId": feedID},
+	}))
+	var ack WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &ack))
+	require.Equal(t, "subscription-success", ack.Type)
+
+	// The client stops reading, so it never answers pings
+	roomSize := func() int {
+		m.rooms.mu.RLock()
+		defer m.rooms.mu.RUnlock()
+		return len(m.rooms.rooms[dataRoom(feedID)])
+	}
+	assert.Equal(t, 1, roomSize())
+	assert.Eventually(t, func() bool { return roomSize() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		m.clientsMu.Lock()
+		defer m.clientsMu.Unlock()
+		return len(m.clients) == 0
+	}, 2*time.Second, 10*time.Millisecond, "stale client is disconnected")
+}
+
+func TestManager_ConnectionStats(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetHeartbeat(20*time.Millisecond, time.Second)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	var stats struct {
+		Type    string `json:"type"`
+		Payload struct {
+			LatencyMs        int64    `json:"latencyMs"`
+			PingsSent        int64    `json:"pingsSent"`
+			MessagesReceived int64    `json:"messagesReceived"`
+			Rooms            []string `json:"rooms"`
+			PingIntervalMs   int64    `json:"pingIntervalMs"`
+		} `json:"payload"`
+	}
+	// Reading answers the server's pings; ask until one has been answered
+	for stats.Payload.PingsSent == 0 || stats.Payload.LatencyMs < 0 {
+		time.Sleep(20 * time.Millisecond)
+		require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{"type": "connection-stats"}))
+		require.NoError(t, wsjson.Read(ctx, conn, &stats))
+		require.Equal(t, "connection-stats", stats.Type)
+	}
+	assert.GreaterOrEqual(t, stats.Payload.MessagesReceived, int64(1))
+	assert.Empty(t, stats.Payload.Rooms)
+	assert.Equal(t, int64(20), stats.Payload.PingIntervalMs)
+}
@@ -0,0 +1,202 @@
+package socket
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeKafka is the feed connection type for Kafka topics
+const connectionTypeKafka = "kafka"
+
+const (
+	// kafkaDialTimeout bounds the broker connectivity check when a feed connects
+	kafkaDialTimeout = 10 * time.Second
+	// kafkaDefaultRetry is the wait after a read error when the feed has no reconnection delay
+	kafkaDefaultRetry = 5 * time.Second
+)
+
+// ErrKafkaConfigInvalid is returned when a Kafka feed is missing its topic or brokers
+var ErrKafkaConfigInvalid = errors.New("kafka feed requires a topic and at least one broker")
+
+// kafkaBrokers returns the configured brokers, falling back to the feed URL
+// as a comma-separated list ("kafka://" prefixes are stripped).
+func kafkaBrokers(feed models.WebSocketFeed) []string {
+	if feed.KafkaConfig != nil && len(feed.KafkaConfig.Brokers) > 0 {
+		return feed.KafkaConfig.Brokers
+	}
+	var brokers []string
+	for _, b := range strings.Split(feed.URL, ",") {
+		b = strings.TrimPrefix(strings.TrimSpace(b), "kafka://")
+		if b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	return brokers
+}
+
+// kafkaMechanism builds the SASL mechanism for the feed's credentials, if any
+func kafkaMechanism(cfg *models.KafkaSASLConfig) (sasl.Mechanism, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	switch strings.ToLower(cfg.Mechanism) {
+	case "", "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unsupported kafka sasl mechanism %q", cfg.Mechanism)
+	}
+}
+
+// kafkaReaderConfig builds the consumer group reader config for a feed.
+// The group defaults to one per feed so offsets survive restarts.
+func kafkaReaderConfig(feed models.WebSocketFeed) (kafka.ReaderConfig, error) {
+	cfg := feed.KafkaConfig
+	brokers := kafkaBrokers(feed)
+	if cfg == nil || cfg.Topic == "" || len(brokers) == 0 {
+		return kafka.ReaderConfig{}, ErrKafkaConfigInvalid
+	}
+
+	mechanism, err := kafkaMechanism(cfg.SASL)
+	if err != nil {
+		return kafka.ReaderConfig{}, err
+	}
+	dialer := &kafka.Dialer{Timeout: kafkaDialTimeout, DualStack: true, SASLMechanism: mechanism}
+	if cfg.TLS {
+		dialer.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	groupID := cfg.GroupID
+	if groupID == "" {
+		groupID = "turbostream-" + feed.ID.Hex()
+	}
+	startOffset := kafka.LastOffset
+	if strings.EqualFold(cfg.StartOffset, "earliest") {
+		startOffset = kafka.FirstOffset
+	}
+
+	return kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       cfg.Topic,
+		GroupID:     groupID,
+		StartOffset: startOffset,
+		Dialer:      dialer,
+	}, nil
+}
+
+// connectKafkaFeed checks that a broker is reachable, then starts a consumer
+// group reader that broadcasts every message on the feed's topic.
+func (m *Manager) connectKafkaFeed(feed models.WebSocketFeed) error {
+	cfg, err := kafkaReaderConfig(feed)
+	if err != nil {
+		return err
+	}
+	decode, err := newPayloadDecoder(feed)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaDialTimeout)
+	conn, err := cfg.Dialer.DialContext(ctx, "tcp", cfg.Brokers[0])
+	cancel()
+	if err != nil {
+		slog.Warn("failed to reach kafka broker", "feed_id", feed.ID.Hex(), "error", err)
+		return err
+	}
+	conn.Close()
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "kafka", "topic", cfg.Topic, "group", cfg.GroupID)
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{stop: stop}
+	m.feedMu.Unlock()
+
+	go m.kafkaLoop(feed, kafka.NewReader(cfg), stop, decode)
+	return nil
+}
+
+// kafkaLoop reads messages until the feed is stopped. Offsets are committed
+// by the consumer group as messages are read.
+func (m *Manager) kafkaLoop(feed models.WebSocketFeed, reader *kafka.Reader, stop chan struct{}, decode payloadDecoder) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		if err := reader.Close(); err != nil {
+			slog.Warn("error closing feed kafka reader", "feed_id", feed.ID.Hex(), "error", err)
+		}
+		m.feedClosed(feed)
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
+	}()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	retry := kafkaDefaultRetry
+	if feed.ReconnectionDelay > 0 {
+		retry = time.Duration(feed.ReconnectionDelay) * time.Millisecond
+	}
+
+	failures := 0
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
+				return
+			}
+			slog.Warn("feed kafka read error", "feed_id", feed.ID.Hex(), "error", err)
+			m.recordFeedError(feed.ID.Hex(), err)
+			if !feed.ReconnectionEnabled {
+				return
+			}
+			failures++
+			if attemptsExhausted(feed, failures) {
+				m.openCircuit(feed, failures-1)
+				return
+			}
+			delay := backoffDelay(retry, failures)
+			m.reportReconnecting(feed, failures, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			countReconnect(feed)
+			continue
+		}
+		if failures > 0 {
+			failures = 0
+			m.reportConnected(feed)
+		}
+
+		eventName := feed.EventName
+		if eventName == "" {
+			eventName = msg.Topic
+		}
+		data, err := decode(msg.Value)
+		if err != nil {
+			slog.Warn("skipping undecodable feed message", "feed_id", feed.ID.Hex(), "error", err)
+			continue
+		}
+		m.BroadcastFeedData(feed, data, eventName)
+	}
+}
@@ -0,0 +1,85 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestKafkaBrokers(t *testing.T) {
+	tests := []struct {
+		name     string
+		feed     models.WebSocketFeed
+		expected []string
+	}{
+		{
+			name:     "configured brokers win",
+			feed:     models.WebSocketFeed{URL: "kafka://ignored:9092", KafkaConfig: &models.KafkaConfig{Brokers: []string{"a:9092"}}},
+			expected: []string{"a:9092"},
+		},
+		{
+			name:     "URL list fallback",
+			feed:     models.WebSocketFeed{URL: "kafka://a:9092, b:9092"},
+			expected: []string{"a:9092", "b:9092"},
+		},
+		{
+			name: "empty",
+			feed: models.WebSocketFeed{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, kafkaBrokers(tt.feed))
+		})
+	}
+}
+
+func TestKafkaReaderConfig(t *testing.T) {
+	feed := models.WebSocketFeed{
+		ID:  primitive.NewObjectID(),
+		URL: "kafka://broker:9092",
+		KafkaConfig: &models.KafkaConfig{
+			Topic:       "trades",
+			StartOffset: "earliest",
+			TLS:         true,
+			SASL:        &models.KafkaSASLConfig{Mechanism: "scram-sha-512", Username: "u", Password: "p"},
+		},
+	}
+
+	cfg, err := kafkaReaderConfig(feed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"broker:9092"}, cfg.Brokers)
+	assert.Equal(t, "trades", cfg.Topic)
+	assert.Equal(t, "turbostream-"+feed.ID.Hex(), cfg.GroupID)
+	assert.Equal(t, kafka.FirstOffset, cfg.StartOffset)
+	assert.NotNil(t, cfg.Dialer.TLS)
+	require.NotNil(t, cfg.Dialer.SASLMechanism)
+	assert.Equal(t, "SCRAM-SHA-512", cfg.Dialer.SASLMechanism.Name())
+}
+
+func TestKafkaReaderConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		feed models.WebSocketFeed
+	}{
+		{name: "missing config", feed: models.WebSocketFeed{URL: "broker:9092"}},
+		{name: "missing topic", feed: models.WebSocketFeed{URL: "broker:9092", KafkaConfig: &models.KafkaConfig{}}},
+		{name: "missing brokers", feed: models.WebSocketFeed{KafkaConfig: &models.KafkaConfig{Topic: "t"}}},
+		{name: "unknown sasl mechanism", feed: models.WebSocketFeed{URL: "broker:9092", KafkaConfig: &models.KafkaConfig{
+			Topic: "t", SASL: &models.KafkaSASLConfig{Mechanism: "gssapi"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := kafkaReaderConfig(tt.feed)
+			assert.Error(t, err)
+		})
+	}
+}
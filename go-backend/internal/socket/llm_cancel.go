@@ -0,0 +1,86 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// llmRequest is a client's streaming LLM request that can still be cancelled
+type llmRequest struct {
+	cancel context.CancelFunc
+}
+
+// trackLLMRequest lets the request with requestID be cancelled through
+// cancel, returning the func that stops tracking it
+func (c *Client) trackLLMRequest(requestID string, cancel context.CancelFunc) func() {
+	req := &llmRequest{cancel: cancel}
+	c.llmMu.Lock()
+	if c.llmRequests == nil {
+		c.llmRequests = make(map[string]*llmRequest)
+	}
+	c.llmRequests[requestID] = req
+	c.llmMu.Unlock()
+
+	return func() {
+		c.llmMu.Lock()
+		defer c.llmMu.Unlock()
+		// A later request reusing the ID is left tracked
+		if c.llmRequests[requestID] == req {
+			delete(c.llmRequests, requestID)
+		}
+	}
+}
+
+// cancelLLMRequest cancels the client's request with requestID, reporting
+// false when no such request is in progress
+func (c *Client) cancelLLMRequest(requestID string) bool {
+	c.llmMu.Lock()
+	req, ok := c.llmRequests[requestID]
+	delete(c.llmRequests, requestID)
+	c.llmMu.Unlock()
+	if ok {
+		req.cancel()
+	}
+	return ok
+}
+
+// finishCancelledStream ends a streaming request the client cancelled. Quota
+// isn't reserved up front, so only the tokens used before the cancel are
+// charged: the prompt and what was streamed so far, or nothing when the
+// provider hadn't started answering. resp is nil in that case.
+func (m *Manager) finishCancelledStream(client *Client, requestID string, resp *services.QueryResponse) {
+	cancelled := map[string]interface{}{
+		"requestId":  requestID,
+		"tokensUsed": 0,
+	}
+	if resp != nil {
+		if m.auth != nil && client.userID != "" && !resp.OwnKey {
+			if userID, err := primitive.ObjectIDFromHex(client.userID); err == nil {
+				// The request's own context is cancelled, so charge under a new one
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := m.auth.UpdateTokenUsage(ctx, userID, resp.TokensUsed); err != nil {
+					slog.WarnContext(client.ctx, "failed to update token usage", "user_id", client.userID, "error", err)
+				} else {
+					m.sendTokenUsageUpdate(client)
+				}
+				cancel()
+			}
+		}
+		m.recordLLMUsage(client.userID, "stream", resp)
+
+		cancelled["answer"] = resp.Answer
+		cancelled["provider"] = resp.Provider
+		cancelled["feedId"] = resp.FeedID
+		cancelled["durationMs"] = resp.Duration
+		cancelled["ownKey"] = resp.OwnKey
+		cancelled["promptTokens"] = resp.PromptTokens
+		cancelled["completionTokens"] = resp.CompletionTokens
+		cancelled["tokensUsed"] = resp.TokensUsed
+	}
+	client.send(makeMessage("llm-cancelled", cancelled))
+}
@@ -0,0 +1,64 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestClient_CancelLLMRequest(t *testing.T) {
+	client := &Client{}
+	assert.False(t, client.cancelLLMRequest("req-1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	untrack := client.trackLLMRequest("req-1", cancel)
+	assert.True(t, client.cancelLLMRequest("req-1"))
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	// A request is cancelled once
+	assert.False(t, client.cancelLLMRequest("req-1"))
+	untrack()
+
+	// Finishing an earlier request doesn't untrack a later one with its ID
+	_, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	untrackFirst := client.trackLLMRequest("req-2", cancelFirst)
+	second, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	untrackSecond := client.trackLLMRequest("req-2", cancelSecond)
+	untrackFirst()
+	assert.True(t, client.cancelLLMRequest("req-2"))
+	assert.Error(t, second.Err())
+	untrackSecond()
+	assert.Empty(t, client.llmRequests)
+}
+
+func TestManager_FinishCancelledStream(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 4)}
+
+	// Cancelled before the provider answered: nothing was used
+	m.finishCancelledStream(client, "req-1", nil)
+	msg := <-client.out
+	assert.Equal(t, "llm-cancelled", msg.Type)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, "req-1", payload["requestId"])
+	assert.Equal(t, 0.0, payload["tokensUsed"])
+	assert.NotContains(t, payload, "answer")
+
+	// Cancelled part way: the partial answer and the tokens it used
+	m.finishCancelledStream(client, "req-2", &services.QueryResponse{Answer: "BTC is", Provider: "openai", PromptTokens: 120, CompletionTokens: 3, TokensUsed: 123})
+	msg = <-client.out
+	payload = nil
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, "BTC is", payload["answer"])
+	assert.Equal(t, 123.0, payload["tokensUsed"])
+}
@@ -0,0 +1,65 @@
+package socket
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// llmProgressInterval is the least time between llm-progress frames of a
+// stream; the first token always sends one
+const llmProgressInterval = 500 * time.Millisecond
+
+// streamProgress tracks a streaming answer for its llm-progress frames and
+// the usage block of its llm-complete event
+type streamProgress struct {
+	start    time.Time
+	tokens   int
+	lastSent time.Time
+	// firstTokenMs is read when the answer completes, so it is atomic
+	firstTokenMs atomic.Int64
+}
+
+func newStreamProgress(start time.Time) *streamProgress {
+	p := &streamProgress{start: start}
+	p.firstTokenMs.Store(-1)
+	return p
+}
+
+// add counts a token streamed at now, reporting whether a progress frame is due
+func (p *streamProgress) add(now time.Time) bool {
+	p.tokens++
+	if p.tokens == 1 {
+		p.firstTokenMs.Store(now.Sub(p.start).Milliseconds())
+	}
+	if p.tokens > 1 && now.Sub(p.lastSent) < llmProgressInterval {
+		return false
+	}
+	p.lastSent = now
+	return true
+}
+
+// payload is the llm-progress frame at now
+func (p *streamProgress) payload(requestID string, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"requestId":    requestID,
+		"tokens":       p.tokens,
+		"elapsedMs":    now.Sub(p.start).Milliseconds(),
+		"firstTokenMs": p.firstTokenMs.Load(),
+	}
+}
+
+// usage is the usage block of the stream's llm-complete event; firstTokenMs
+// is -1 when no token was streamed
+func (p *streamProgress) usage(resp *services.QueryResponse) map[string]interface{} {
+	return map[string]interface{}{
+		"promptTokens":     resp.PromptTokens,
+		"completionTokens": resp.CompletionTokens,
+		"totalTokens":      resp.TokensUsed,
+		"provider":         resp.Provider,
+		"model":            resp.Model,
+		"firstTokenMs":     p.firstTokenMs.Load(),
+		"durationMs":       resp.Duration,
+	}
+}
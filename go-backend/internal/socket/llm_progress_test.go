@@ -0,0 +1,33 @@
+package socket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestStreamProgress(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := newStreamProgress(start)
+	assert.Equal(t, int64(-1), p.usage(&services.QueryResponse{})["firstTokenMs"])
+
+	// The first token sends a frame, later ones at most every interval
+	assert.True(t, p.add(start.Add(300*time.Millisecond)))
+	assert.False(t, p.add(start.Add(400*time.Millisecond)))
+	assert.False(t, p.add(start.Add(700*time.Millisecond)))
+	assert.True(t, p.add(start.Add(800*time.Millisecond)))
+
+	frame := p.payload("req-1", start.Add(800*time.Millisecond))
+	assert.Equal(t, "req-1", frame["requestId"])
+	assert.Equal(t, 4, frame["tokens"])
+	assert.Equal(t, int64(800), frame["elapsedMs"])
+	assert.Equal(t, int64(300), frame["firstTokenMs"])
+
+	usage := p.usage(&services.QueryResponse{Provider: "openai", Model: "gpt-4o", PromptTokens: 120, CompletionTokens: 4, TokensUsed: 124, Duration: 900})
+	assert.Equal(t, "gpt-4o", usage["model"])
+	assert.Equal(t, 124, usage["totalTokens"])
+	assert.Equal(t, int64(300), usage["firstTokenMs"])
+}
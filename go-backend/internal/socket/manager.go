@@ -5,47 +5,242 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	gws "github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	coderws "nhooyr.io/websocket"
 	"nhooyr.io/websocket/wsjson"
 
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
 	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+	"github.com/turboline-ai/turbostream/go-backend/internal/tracing"
 )
 
 // WSMessage represents the JSON structure shared between client and server.
 type WSMessage struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload,omitempty"`
+	// frame caches the binary encoding of feed-data broadcasts
+	frame *binaryFrame
 }
 
-// Client represents a connected WebSocket client with context and write synchronization
+var (
+	// clientQueueSize is how many outbound messages a client may have queued
+	clientQueueSize = 256
+	// slowConsumerGrace is how long a client's queue may stay full before it is disconnected
+	slowConsumerGrace = 10 * time.Second
+	// broadcastShardSize is the fewest clients worth giving a goroutine of
+	// their own when a broadcast is fanned out
+	broadcastShardSize = 1024
+)
+
+// Client represents a connected WebSocket client. Messages are queued and
+// written by the client's own writer goroutine so one slow client cannot
+// hold up broadcasts to the others.
 type Client struct {
+	// id names the connection in logs as conn_id
+	id      string
 	conn    *coderws.Conn
 	ctx     context.Context
 	cancel  context.CancelFunc
 	writeMu sync.Mutex
 	userID  string
+	ip      string
+	// apiKey is set when the client authenticated with an API key
+	apiKey *models.APIKey
+	// authenticated is set when userID was verified with a token or API key
+	// rather than claimed with register-user
+	authenticated bool
+
+	out           chan WSMessage
+	overflowMu    sync.Mutex
+	overflowSince time.Time
+	dropped       atomic.Int64
+	slow          atomic.Bool
+	// pending counts messages queued or being written, so shutdown can wait for them
+	pending atomic.Int64
+
+	// Connection stats for connection-stats; lastPong is in Unix nanoseconds
+	// and latency is the round trip of the last ping
+	connectedAt time.Time
+	sent        atomic.Int64
+	received    atomic.Int64
+	pingsSent   atomic.Int64
+	lastPong    atomic.Int64
+	latency     atomic.Int64
+
+	// filters holds per-room subscription filters; rooms without filters get every message
+	filterMu sync.RWMutex
+	filters  map[string][]feedFilter
+
+	// batches holds per-room feed-data batching; rooms without one get each message as it comes
+	batchMu sync.Mutex
+	batches map[string]*feedBatcher
+
+	// llmRequests holds the client's streaming LLM requests by request ID, so
+	// they can be cancelled
+	llmMu       sync.Mutex
+	llmRequests map[string]*llmRequest
+
+	// deflate is set when the upgrade agreed to permessage-deflate
+	deflate bool
+
+	// protocol is what the client agreed to in its hello; nil until then
+	protocolMu sync.RWMutex
+	protocol   *clientProtocol
 }
 
-// send writes a message to the client's WebSocket connection with thread safety
+// setFilters replaces the client's filters for a room; nil removes them
+func (c *Client) setFilters(room string, filters []feedFilter) {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	if len(filters) == 0 {
+		delete(c.filters, room)
+		return
+	}
+	if c.filters == nil {
+		c.filters = make(map[string][]feedFilter)
+	}
+	c.filters[room] = filters
+}
+
+// roomFilters returns the client's filters for a room
+func (c *Client) roomFilters(room string) []feedFilter {
+	c.filterMu.RLock()
+	defer c.filterMu.RUnlock()
+	return c.filters[room]
+}
+
+// send queues a message for the client's writer. When the queue is full the
+// oldest queued message is dropped to make room; a client whose queue stays
+// full for slowConsumerGrace is disconnected as a slow consumer. Clients
+// without a queue are written to directly.
 func (c *Client) send(msg WSMessage) {
+	if c.out == nil {
+		_ = c.write(msg)
+		return
+	}
+	c.pending.Add(1)
+	for {
+		select {
+		case c.out <- msg:
+			return
+		case <-c.ctx.Done():
+			c.pending.Add(-1)
+			return
+		default:
+		}
+
+		select {
+		case dropped := <-c.out:
+			c.pending.Add(-1)
+			c.dropped.Add(1)
+			metrics.WSDroppedMessages.WithLabelValues(dropped.Type).Inc()
+		default:
+		}
+		if c.overflowing() {
+			c.pending.Add(-1)
+			c.disconnectSlow()
+			return
+		}
+	}
+}
+
+// write sends one message on the connection with thread safety
+func (c *Client) write(msg WSMessage) error {
 	c.writeMu.Lock()
 	defer c.writeMu.Unlock()
 	ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
 	defer cancel()
+	if msg.Type == "feed-data" && c.hasFeature(featureBinary) {
+		if sent, err := c.writeBinary(ctx, msg); sent {
+			if err != nil {
+				slog.WarnContext(ctx, "websocket send error", "event", msg.Type, "error", err)
+				return err
+			}
+			c.sent.Add(1)
+			return nil
+		}
+	}
 	if err := wsjson.Write(ctx, c.conn, msg); err != nil {
-		log.Printf("❌ websocket send error (type: %s): %v", msg.Type, err)
-	} else {
-		log.Printf("✅ sent message type: %s", msg.Type)
+		slog.WarnContext(ctx, "websocket send error", "event", msg.Type, "error", err)
+		return err
+	}
+	if msg.Type == "feed-data" {
+		metrics.WSFeedDataBytes.WithLabelValues("json").Add(float64(jsonFrameSize(msg)))
+	}
+	c.sent.Add(1)
+	slog.DebugContext(ctx, "sent message", "event", msg.Type)
+	return nil
+}
+
+// writeLoop writes queued messages until the client disconnects
+func (c *Client) writeLoop() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg := <-c.out:
+			err := c.write(msg)
+			c.pending.Add(-1)
+			if err != nil {
+				c.cancel()
+				return
+			}
+			if len(c.out) == 0 {
+				c.overflowMu.Lock()
+				c.overflowSince = time.Time{}
+				c.overflowMu.Unlock()
+			}
+		}
+	}
+}
+
+// overflowing records that the queue is full and reports whether it has
+// stayed full (without draining) for longer than slowConsumerGrace
+func (c *Client) overflowing() bool {
+	c.overflowMu.Lock()
+	defer c.overflowMu.Unlock()
+	if c.overflowSince.IsZero() {
+		c.overflowSince = time.Now()
 	}
+	return time.Since(c.overflowSince) > slowConsumerGrace
+}
+
+// disconnectSlow tells the client it is being dropped for falling behind and closes the connection
+func (c *Client) disconnectSlow() {
+	if !c.slow.CompareAndSwap(false, true) {
+		return
+	}
+	metrics.WSSlowConsumers.Inc()
+	slog.WarnContext(c.ctx, "disconnecting slow websocket consumer", "user_id", c.userID, "dropped", c.dropped.Load())
+	go func() {
+		defer c.cancel()
+		if c.conn == nil {
+			return
+		}
+		// Bypass the queue, which is full; a stuck write just times out
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = wsjson.Write(ctx, c.conn, makeMessage("slow-consumer", map[string]interface{}{
+			"reason":  "client is not reading messages fast enough",
+			"dropped": c.dropped.Load(),
+		}))
+		_ = c.conn.Close(coderws.StatusPolicyViolation, "slow consumer")
+	}()
 }
 
 // RoomManager manages room memberships and client subscriptions with thread safety
@@ -53,12 +248,19 @@ type RoomManager struct {
 	mu          sync.RWMutex
 	rooms       map[string]map[*Client]struct{}
 	clientRooms map[*Client]map[string]struct{}
+	// members caches each room's clients as a slice for broadcasts. Entries
+	// are never modified, only dropped when the room's membership changes.
+	members map[string][]*Client
+	// egress meters feed-data deliveries and enforces bandwidth quotas
+	egress *egressMeter
 }
 
 func NewRoomManager() *RoomManager {
 	return &RoomManager{
 		rooms:       make(map[string]map[*Client]struct{}),
 		clientRooms: make(map[*Client]map[string]struct{}),
+		members:     make(map[string][]*Client),
+		egress:      &egressMeter{},
 	}
 }
 
@@ -69,15 +271,20 @@ func (rm *RoomManager) Join(room string, client *Client) {
 		rm.rooms[room] = make(map[*Client]struct{})
 	}
 	rm.rooms[room][client] = struct{}{}
+	delete(rm.members, room)
 	if _, ok := rm.clientRooms[client]; !ok {
 		rm.clientRooms[client] = make(map[string]struct{})
 	}
 	rm.clientRooms[client][room] = struct{}{}
+	metrics.Rooms.Set(float64(len(rm.rooms)))
 }
 
 func (rm *RoomManager) Leave(room string, client *Client) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
+	client.setFilters(room, nil)
+	client.clearBatching(room)
+	delete(rm.members, room)
 	if clients, ok := rm.rooms[room]; ok {
 		delete(clients, client)
 		if len(clients) == 0 {
@@ -90,6 +297,7 @@ func (rm *RoomManager) Leave(room string, client *Client) {
 			delete(rm.clientRooms, client)
 		}
 	}
+	metrics.Rooms.Set(float64(len(rm.rooms)))
 }
 
 func (rm *RoomManager) LeaveAll(client *Client) {
@@ -97,6 +305,7 @@ func (rm *RoomManager) LeaveAll(client *Client) {
 	defer rm.mu.Unlock()
 	if rooms, ok := rm.clientRooms[client]; ok {
 		for room := range rooms {
+			delete(rm.members, room)
 			if clients, exists := rm.rooms[room]; exists {
 				delete(clients, client)
 				if len(clients) == 0 {
@@ -106,22 +315,109 @@ func (rm *RoomManager) LeaveAll(client *Client) {
 		}
 		delete(rm.clientRooms, client)
 	}
+	metrics.Rooms.Set(float64(len(rm.rooms)))
 }
 
-func (rm *RoomManager) Broadcast(room string, msg WSMessage) {
+// Rooms returns the rooms a client is in, sorted
+func (rm *RoomManager) Rooms(client *Client) []string {
 	rm.mu.RLock()
-	clientsMap := rm.rooms[room]
-	var clients []*Client
-	for client := range clientsMap {
-		clients = append(clients, client)
+	defer rm.mu.RUnlock()
+	rooms := make([]string, 0, len(rm.clientRooms[client]))
+	for room := range rm.clientRooms[client] {
+		rooms = append(rooms, room)
 	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Members returns the clients in a room
+func (rm *RoomManager) Members(room string) []*Client {
+	return append([]*Client(nil), rm.snapshot(room)...)
+}
+
+// snapshot returns the cached member slice of a room, building it after a
+// membership change. Callers must not modify it.
+func (rm *RoomManager) snapshot(room string) []*Client {
+	rm.mu.RLock()
+	clients, ok := rm.members[room]
+	empty := len(rm.rooms[room]) == 0
 	rm.mu.RUnlock()
+	if ok || empty {
+		return clients
+	}
 
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if clients, ok := rm.members[room]; ok {
+		return clients
+	}
+	clients = make([]*Client, 0, len(rm.rooms[room]))
+	for client := range rm.rooms[room] {
+		clients = append(clients, client)
+	}
 	if len(clients) > 0 {
-		log.Printf("broadcasting to %d client(s) in room %s", len(clients), room)
+		rm.members[room] = clients
+	}
+	return clients
+}
+
+// Broadcast queues msg for every client in the room whose filters match it.
+// Enqueues never block: a client with a full queue loses its oldest message
+// instead, and its writer goroutine is the only thing a stuck connection
+// holds up. Large rooms are split across up to GOMAXPROCS goroutines of at
+// least broadcastShardSize clients, and Broadcast returns once every shard
+// is queued so consecutive broadcasts reach each client in order.
+func (rm *RoomManager) Broadcast(room string, msg WSMessage) {
+	clients := rm.snapshot(room)
+	if len(clients) == 0 {
+		return
 	}
+	slog.Debug("broadcasting to room", "room", room, "clients", len(clients))
 
+	// The payload is decoded once, and only if some client filters this room
+	payload := sync.OnceValue(func() interface{} {
+		var payload interface{}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			slog.Warn("cannot evaluate room filters", "room", room, "error", err)
+		}
+		return payload
+	})
+	now := time.Now().UTC()
+	shards := min(runtime.GOMAXPROCS(0), len(clients)/broadcastShardSize)
+	if shards <= 1 {
+		rm.deliver(room, clients, msg, payload, now)
+		return
+	}
+	size := (len(clients) + shards - 1) / shards
+	var wg sync.WaitGroup
+	for start := 0; start < len(clients); start += size {
+		shard := clients[start:min(start+size, len(clients))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rm.deliver(room, shard, msg, payload, now)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver queues msg for the clients whose filters for room match it,
+// adding feed-data to the batch of clients that asked for batching. Feed
+// data over a client's bandwidth quota is dropped.
+func (rm *RoomManager) deliver(room string, clients []*Client, msg WSMessage, payload func() interface{}, now time.Time) {
 	for _, client := range clients {
+		if filters := client.roomFilters(room); len(filters) > 0 && !matchFilters(filters, payload()) {
+			continue
+		}
+		if msg.Type == "feed-data" {
+			if !rm.egress.allow(client, room, len(msg.Payload), now) {
+				continue
+			}
+			if batcher := client.roomBatcher(room); batcher != nil {
+				batcher.add(msg.Payload)
+				continue
+			}
+		}
 		client.send(msg)
 	}
 }
@@ -133,27 +429,74 @@ type feedConnection struct {
 
 // Manager manages websocket connections and feed broadcasts.
 type Manager struct {
-	rooms          *RoomManager
-	auth           *services.AuthService
-	azure          *services.AzureOpenAI
-	llm            *services.LLMService
-	marketplace    *services.MarketplaceService
-	feedConns      map[string]*feedConnection
-	feedMu         sync.RWMutex
-	subscribers    map[string]map[*Client]struct{}
-	subscriberMu   sync.RWMutex
-	allowedOrigins []string
+	rooms            *RoomManager
+	auth             *services.AuthService
+	azure            *services.AzureOpenAI
+	llm              *services.LLMService
+	history          *services.FeedHistoryService
+	alerts           *services.AlertService
+	webhooks         *services.WebhookService
+	access           *services.FeedAccessService
+	usage            *services.LLMUsageService
+	analyses         *services.AnalysisService
+	conversations    *services.ConversationService
+	marketplace      *services.MarketplaceService
+	feedConns        map[string]*feedConnection
+	feedMu           sync.RWMutex
+	subscribers      map[string]map[*Client]struct{}
+	subscriberMu     sync.RWMutex
+	replays          map[string]*replayBuffer
+	replaySize       int
+	replayMu         sync.Mutex
+	cluster          ClusterBus
+	clusterOut       chan clusterEnvelope
+	heldFeeds        map[string]struct{}
+	clusterMu        sync.Mutex
+	health           map[string]*FeedHealth
+	healthMu         sync.Mutex
+	schemas          map[string]*schemaSampler
+	schemaMu         sync.Mutex
+	throttles        map[string]*feedThrottle
+	throttleMu       sync.Mutex
+	aggregators      map[string]*feedAggregator
+	aggregateMu      sync.Mutex
+	anomalyDetectors map[string]*feedAnomalyDetector
+	anomalyMu        sync.Mutex
+	feedAlerts       map[string]*feedAlerts
+	alertMu          sync.Mutex
+	limits           ratelimit.Limits
+	clients          map[*Client]struct{}
+	clientsMu        sync.Mutex
+	llmWG            sync.WaitGroup
+	closing          bool
+	shutdownMu       sync.Mutex
+	allowedOrigins   []string
+	compression      bool
+	openPublicFeeds  bool
+	pingInterval     time.Duration
+	pingTimeout      time.Duration
 }
 
 func NewManager(auth *services.AuthService, azure *services.AzureOpenAI, marketplace *services.MarketplaceService, allowedOrigins []string) *Manager {
 	return &Manager{
-		rooms:          NewRoomManager(),
-		auth:           auth,
-		azure:          azure,
-		marketplace:    marketplace,
-		feedConns:      make(map[string]*feedConnection),
-		subscribers:    make(map[string]map[*Client]struct{}),
-		allowedOrigins: allowedOrigins,
+		rooms:            NewRoomManager(),
+		auth:             auth,
+		azure:            azure,
+		marketplace:      marketplace,
+		feedConns:        make(map[string]*feedConnection),
+		subscribers:      make(map[string]map[*Client]struct{}),
+		replays:          make(map[string]*replayBuffer),
+		replaySize:       defaultReplayBufferSize,
+		health:           make(map[string]*FeedHealth),
+		schemas:          make(map[string]*schemaSampler),
+		throttles:        make(map[string]*feedThrottle),
+		aggregators:      make(map[string]*feedAggregator),
+		anomalyDetectors: make(map[string]*feedAnomalyDetector),
+		feedAlerts:       make(map[string]*feedAlerts),
+		clients:          make(map[*Client]struct{}),
+		allowedOrigins:   allowedOrigins,
+		pingInterval:     defaultPingInterval,
+		pingTimeout:      defaultPingTimeout,
 	}
 }
 
@@ -162,83 +505,171 @@ func (m *Manager) SetLLMService(llm *services.LLMService) {
 	m.llm = llm
 }
 
+// SetHistoryService sets the service that records broadcast feed messages
+func (m *Manager) SetHistoryService(history *services.FeedHistoryService) {
+	m.history = history
+}
+
+// SetCompression sets whether permessage-deflate is offered to new connections
+func (m *Manager) SetCompression(enabled bool) {
+	m.compression = enabled
+}
+
 // Handle upgrades the HTTP connection to a raw websocket connection.
 func (m *Manager) Handle(w http.ResponseWriter, r *http.Request) {
+	if m.isClosing() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
 	conn, err := coderws.Accept(w, r, &coderws.AcceptOptions{
 		InsecureSkipVerify: len(m.allowedOrigins) == 0,
 		OriginPatterns:     m.allowedOrigins,
+		CompressionMode:    compressionMode(m.compression),
 	})
 	if err != nil {
-		log.Printf("websocket accept failed: %v", err)
+		slog.WarnContext(r.Context(), "websocket accept failed", "error", err)
 		return
 	}
 
+	// The connection ID is the upgrade request's X-Request-ID when it has a
+	// usable one, so a client can match its connection to the server's logs
+	id := r.Header.Get(logging.RequestIDHeader)
+	if !logging.ValidRequestID(id) {
+		id = logging.NewID()
+	}
+
 	// Use a background context instead of request context
 	// because the request context is cancelled when the HTTP handler returns
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(logging.With(context.Background(), "conn_id", id))
 
 	client := &Client{
+		id:     id,
 		conn:   conn,
 		ctx:    ctx,
 		cancel: cancel,
+		ip:     remoteIP(r),
+		out:    make(chan WSMessage, clientQueueSize),
+		// Accept writes the agreed extensions into the response headers
+		deflate:     usesDeflate(w.Header()),
+		connectedAt: time.Now().UTC(),
 	}
+	go client.writeLoop()
 	go m.runClient(client)
 }
 
 func (m *Manager) runClient(client *Client) {
+	metrics.WSClients.Inc()
+	if client.deflate {
+		metrics.WSCompressedClients.Inc()
+	}
+	m.trackClient(client)
 	defer func() {
+		metrics.WSClients.Dec()
+		if client.deflate {
+			metrics.WSCompressedClients.Dec()
+		}
+		m.untrackClient(client)
 		m.rooms.LeaveAll(client)
+		m.untrackAllSubscriptions(client)
 		if err := client.conn.Close(coderws.StatusNormalClosure, "disconnect"); err != nil {
-			log.Printf("error closing client connection: %v", err)
+			slog.WarnContext(client.ctx, "error closing client connection", "error", err)
 		}
 		client.cancel()
-		log.Printf("client disconnected (userID: %s)", client.userID)
+		slog.InfoContext(client.ctx, "client disconnected", "user_id", client.userID)
 	}()
 
-	log.Printf("new client connected")
+	slog.InfoContext(client.ctx, "client connected", "ip", client.ip)
+	if m.pingInterval > 0 {
+		go m.heartbeat(client, m.pingInterval, m.pingTimeout)
+	}
 
 	for {
 		var msg WSMessage
 		if err := wsjson.Read(client.ctx, client.conn, &msg); err != nil {
 			// Don't log normal closure errors
 			if errors.Is(err, context.Canceled) || coderws.CloseStatus(err) == coderws.StatusNormalClosure {
-				log.Printf("client closed connection normally")
+				slog.DebugContext(client.ctx, "client closed connection normally")
 			} else {
-				log.Printf("websocket read error: %v", err)
+				slog.WarnContext(client.ctx, "websocket read error", "error", err)
 			}
 			return
 		}
-		log.Printf("📩 received message type: %s", msg.Type)
+		client.received.Add(1)
+		if !m.allowMessage(client, msg) || !m.allowAPIKeyMessage(client, msg) {
+			continue
+		}
 		m.handleMessage(client, msg)
 	}
 }
 
+// messageContext gives a client message its own request ID, carried into the
+// logs of everything it sets off, LLM provider calls included
+func messageContext(client *Client, msg WSMessage) context.Context {
+	return logging.With(logging.WithRequestID(client.ctx, logging.NewID()), "event", msg.Type)
+}
+
 func (m *Manager) handleMessage(client *Client, msg WSMessage) {
+	// The span covers handling the message; LLM answers run on in their own
+	// goroutines as its children
+	msgCtx, span := tracing.Tracer().Start(messageContext(client, msg), "ws "+msg.Type,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("ws.event", msg.Type),
+			attribute.String("ws.conn_id", client.id),
+			attribute.String("user.id", client.userID),
+		))
+	defer span.End()
+	slog.DebugContext(msgCtx, "received message")
 	switch msg.Type {
 	case "authenticate":
 		var payload struct {
-			Token string `json:"token"`
+			Token  string `json:"token"`
+			APIKey string `json:"apiKey"`
 		}
-		if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.Token == "" {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil || (payload.Token == "" && payload.APIKey == "") {
 			client.send(makeMessage("auth_error", map[string]string{"error": "invalid payload"}))
 			return
 		}
-		// Verify token using auth service
-		claims, err := m.auth.ParseToken(payload.Token)
+		ctx, cancel := context.WithTimeout(msgCtx, 5*time.Second)
+		defer cancel()
+		if payload.APIKey != "" {
+			key, err := m.auth.AuthenticateAPIKey(ctx, payload.APIKey)
+			if err != nil {
+				client.send(makeMessage("auth_error", map[string]string{"error": "invalid API key"}))
+				return
+			}
+			m.identifyClient(client, key.UserID.Hex())
+			client.apiKey = &key
+			client.authenticated = true
+			client.send(makeMessage("authenticated", map[string]string{"userId": client.userID, "apiKeyId": key.ID.Hex()}))
+			return
+		}
+		// Verify token and that its session has not been terminated
+		claims, err := m.auth.Authenticate(ctx, payload.Token)
 		if err != nil {
 			client.send(makeMessage("auth_error", map[string]string{"error": "invalid token"}))
 			return
 		}
 		if userID, ok := claims["userId"].(string); ok {
-			client.userID = userID
+			m.identifyClient(client, userID)
+			client.apiKey = nil
+			client.authenticated = true
 			client.send(makeMessage("authenticated", map[string]string{"userId": userID}))
 		} else {
 			client.send(makeMessage("auth_error", map[string]string{"error": "invalid token claims"}))
 		}
 
+	case "hello":
+		// Protocol version and feature negotiation; optional, and normally
+		// the first message a client sends
+		m.handleHello(client, msg.Payload)
+
 	case "ping":
 		client.send(makeMessage("pong", nil))
 
+	case "connection-stats":
+		client.send(makeMessage("connection-stats", m.connectionStats(client, time.Now().UTC())))
+
 	case "register-user":
 		var payload struct {
 			UserID    string `json:"userId"`
@@ -249,7 +680,8 @@ func (m *Manager) handleMessage(client *Client, msg WSMessage) {
 			client.send(makeMessage("registration-error", map[string]string{"error": "invalid payload"}))
 			return
 		}
-		client.userID = payload.UserID
+		m.identifyClient(client, payload.UserID)
+		client.authenticated = false
 		client.send(makeMessage("registration-success", map[string]interface{}{
 			"userId":  payload.UserID,
 			"message": "connected",
@@ -258,18 +690,33 @@ func (m *Manager) handleMessage(client *Client, msg WSMessage) {
 	case "subscribe-feed":
 		// Subscribe to raw feed data only
 		var payload struct {
-			UserID string `json:"userId"`
-			FeedID string `json:"feedId"`
+			UserID      string        `json:"userId"`
+			FeedID      string        `json:"feedId"`
+			Filters     *[]string     `json:"filters"`
+			ReplayCount int           `json:"replayCount"`
+			Batch       *batchOptions `json:"batch"`
 		}
 		if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.FeedID == "" {
 			client.send(makeMessage("subscription-error", map[string]string{"error": "invalid payload"}))
 			return
 		}
+		if !m.authorizeSubscription(client, payload.FeedID) {
+			return
+		}
 		room := dataRoom(payload.FeedID)
+		if !m.applySubscriptionFilters(client, room, payload.FeedID, payload.Filters) {
+			return
+		}
+		batch, ok := m.applySubscriptionBatching(client, room, payload.FeedID, payload.Batch)
+		if !ok {
+			return
+		}
 		m.rooms.Join(room, client)
 		m.trackSubscriber(payload.FeedID, client)
-		log.Printf("✓ client subscribed to feed data %s (room: %s)", payload.FeedID, room)
-		client.send(makeMessage("subscription-success", map[string]string{"feedId": payload.FeedID, "type": "feed-data"}))
+		slog.InfoContext(msgCtx, "client subscribed to feed data", "feed_id", payload.FeedID)
+		client.send(makeMessage("subscription-success", subscriptionAck(payload.FeedID, "feed-data", batch)))
+		m.sendReplay(client, payload.FeedID, payload.ReplayCount)
+		m.sendFeedHealth(client, payload.FeedID)
 		go m.ensureFeedConnection(payload.FeedID)
 
 	case "subscribe-llm":
@@ -282,27 +729,45 @@ func (m *Manager) handleMessage(client *Client, msg WSMessage) {
 			client.send(makeMessage("subscription-error", map[string]string{"error": "invalid payload"}))
 			return
 		}
+		if !m.authorizeSubscription(client, payload.FeedID) {
+			return
+		}
 		room := llmRoom(payload.FeedID)
 		m.rooms.Join(room, client)
-		log.Printf("✓ client subscribed to LLM output %s (room: %s)", payload.FeedID, room)
+		slog.InfoContext(msgCtx, "client subscribed to LLM output", "feed_id", payload.FeedID)
 		client.send(makeMessage("subscription-success", map[string]string{"feedId": payload.FeedID, "type": "llm-only"}))
 
 	case "subscribe-all":
 		// Subscribe to both feed data and LLM output
 		var payload struct {
-			UserID string `json:"userId"`
-			FeedID string `json:"feedId"`
+			UserID      string        `json:"userId"`
+			FeedID      string        `json:"feedId"`
+			Filters     *[]string     `json:"filters"`
+			ReplayCount int           `json:"replayCount"`
+			Batch       *batchOptions `json:"batch"`
 		}
 		if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.FeedID == "" {
 			client.send(makeMessage("subscription-error", map[string]string{"error": "invalid payload"}))
 			return
 		}
+		if !m.authorizeSubscription(client, payload.FeedID) {
+			return
+		}
+		if !m.applySubscriptionFilters(client, dataRoom(payload.FeedID), payload.FeedID, payload.Filters) {
+			return
+		}
+		batch, ok := m.applySubscriptionBatching(client, dataRoom(payload.FeedID), payload.FeedID, payload.Batch)
+		if !ok {
+			return
+		}
 		// Join both rooms
 		m.rooms.Join(dataRoom(payload.FeedID), client)
 		m.rooms.Join(llmRoom(payload.FeedID), client)
 		m.trackSubscriber(payload.FeedID, client)
-		log.Printf("✓ client subscribed to all %s (data + llm)", payload.FeedID)
-		client.send(makeMessage("subscription-success", map[string]string{"feedId": payload.FeedID, "type": "all"}))
+		slog.InfoContext(msgCtx, "client subscribed to feed data and LLM output", "feed_id", payload.FeedID)
+		client.send(makeMessage("subscription-success", subscriptionAck(payload.FeedID, "all", batch)))
+		m.sendReplay(client, payload.FeedID, payload.ReplayCount)
+		m.sendFeedHealth(client, payload.FeedID)
 		go m.ensureFeedConnection(payload.FeedID)
 
 	case "unsubscribe-feed":
@@ -320,6 +785,20 @@ func (m *Manager) handleMessage(client *Client, msg WSMessage) {
 		m.untrackSubscriber(payload.FeedID, client)
 		client.send(makeMessage("unsubscription-success", map[string]string{"feedId": payload.FeedID}))
 
+	case "subscribe-presence":
+		m.handleSubscribePresence(client, msg.Payload)
+
+	case "unsubscribe-presence":
+		var payload struct {
+			FeedID string `json:"feedId"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.FeedID == "" {
+			client.send(makeMessage("unsubscription-error", map[string]string{"error": "invalid payload"}))
+			return
+		}
+		m.rooms.Leave(ownerRoom(payload.FeedID), client)
+		client.send(makeMessage("unsubscription-success", map[string]string{"feedId": payload.FeedID, "type": "presence"}))
+
 	case "analyze-crypto":
 		var payload map[string]interface{}
 		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
@@ -353,56 +832,193 @@ func (m *Manager) handleMessage(client *Client, msg WSMessage) {
 	case "llm-query":
 		// LangChain-based LLM query using feed context
 		var payload struct {
-			FeedID       string `json:"feedId"`
-			Question     string `json:"question"`
-			Provider     string `json:"provider"`
-			SystemPrompt string `json:"systemPrompt"`
-			RequestID    string `json:"requestId"`
+			FeedID       string   `json:"feedId"`
+			Question     string   `json:"question"`
+			Provider     string   `json:"provider"`
+			SystemPrompt string   `json:"systemPrompt"`
+			RequestID    string   `json:"requestId"`
+			Temperature  *float64 `json:"temperature"`
+			MaxTokens    int      `json:"maxTokens"`
+			// ResponseFormat asks for a JSON answer, returned parsed as "json"
+			ResponseFormat *services.ResponseFormat `json:"responseFormat"`
+			// Tools lets the model compute figures from the feed first
+			Tools bool `json:"tools"`
 		}
 		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
 			client.send(makeMessage("llm-error", map[string]string{"error": "invalid payload"}))
 			return
 		}
-		go m.handleLLMQuery(client, payload.FeedID, payload.Question, payload.Provider, payload.SystemPrompt, payload.RequestID)
+		opts := services.ChatOptions{Temperature: payload.Temperature, MaxTokens: payload.MaxTokens, Format: payload.ResponseFormat}
+		if !m.startLLMQuery() {
+			client.send(makeMessage("llm-error", map[string]interface{}{
+				"error":     "server shutting down",
+				"requestId": payload.RequestID,
+			}))
+			return
+		}
+		go func() {
+			defer m.llmWG.Done()
+			m.handleLLMQuery(msgCtx, client, payload.FeedID, payload.Question, payload.Provider, payload.SystemPrompt, payload.RequestID, opts, payload.Tools, nil)
+		}()
 
 	case "llm-query-stream":
 		// Streaming LLM query
 		var payload struct {
-			FeedID       string `json:"feedId"`
-			Question     string `json:"question"`
-			Provider     string `json:"provider"`
-			SystemPrompt string `json:"systemPrompt"`
-			RequestID    string `json:"requestId"`
+			FeedID       string   `json:"feedId"`
+			Question     string   `json:"question"`
+			Provider     string   `json:"provider"`
+			SystemPrompt string   `json:"systemPrompt"`
+			RequestID    string   `json:"requestId"`
+			Temperature  *float64 `json:"temperature"`
+			MaxTokens    int      `json:"maxTokens"`
+			// ResponseFormat asks for a JSON answer, returned parsed as "json"
+			ResponseFormat *services.ResponseFormat `json:"responseFormat"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			client.send(makeMessage("llm-error", map[string]string{"error": "invalid payload"}))
+			return
+		}
+		opts := services.ChatOptions{Temperature: payload.Temperature, MaxTokens: payload.MaxTokens, Format: payload.ResponseFormat}
+		if !m.startLLMQuery() {
+			client.send(makeMessage("llm-error", map[string]interface{}{
+				"error":     "server shutting down",
+				"requestId": payload.RequestID,
+			}))
+			return
+		}
+		go func() {
+			defer m.llmWG.Done()
+			m.handleLLMStreamQuery(msgCtx, client, payload.FeedID, payload.Question, payload.Provider, payload.SystemPrompt, payload.RequestID, opts, nil)
+		}()
+
+	case "llm-conversation":
+		// A question in a stored conversation, answered in light of its
+		// earlier turns; without conversationId a new conversation starts
+		var payload struct {
+			ConversationID string   `json:"conversationId"`
+			FeedID         string   `json:"feedId"`
+			Question       string   `json:"question"`
+			Provider       string   `json:"provider"`
+			RequestID      string   `json:"requestId"`
+			Temperature    *float64 `json:"temperature"`
+			MaxTokens      int      `json:"maxTokens"`
+			Stream         bool     `json:"stream"`
 		}
 		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
 			client.send(makeMessage("llm-error", map[string]string{"error": "invalid payload"}))
 			return
 		}
-		go m.handleLLMStreamQuery(client, payload.FeedID, payload.Question, payload.Provider, payload.SystemPrompt, payload.RequestID)
+		opts := services.ChatOptions{Temperature: payload.Temperature, MaxTokens: payload.MaxTokens}
+		if !m.startLLMQuery() {
+			client.send(makeMessage("llm-error", map[string]interface{}{
+				"error":     "server shutting down",
+				"requestId": payload.RequestID,
+			}))
+			return
+		}
+		go func() {
+			defer m.llmWG.Done()
+			conv, err := m.loadConversation(client, payload.ConversationID, payload.FeedID)
+			if err != nil {
+				client.send(makeMessage("llm-error", map[string]interface{}{
+					"error":     err.Error(),
+					"requestId": payload.RequestID,
+				}))
+				return
+			}
+			if payload.Stream {
+				m.handleLLMStreamQuery(msgCtx, client, conv.FeedID, payload.Question, payload.Provider, "", payload.RequestID, opts, conv)
+			} else {
+				m.handleLLMQuery(msgCtx, client, conv.FeedID, payload.Question, payload.Provider, "", payload.RequestID, opts, false, conv)
+			}
+		}()
+
+	case "llm-cancel":
+		// Stop a streaming LLM request; its llm-cancelled event follows
+		var payload struct {
+			RequestID string `json:"requestId"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.RequestID == "" {
+			client.send(makeMessage("llm-error", map[string]string{"error": "requestId is required"}))
+			return
+		}
+		if !client.cancelLLMRequest(payload.RequestID) {
+			client.send(makeMessage("llm-error", map[string]interface{}{
+				"error":     "no LLM request in progress with this requestId",
+				"requestId": payload.RequestID,
+			}))
+		}
 
 	default:
 		client.send(makeMessage("error", map[string]string{"message": "unknown event"}))
 	}
 }
 
+// applySubscriptionFilters sets the client's filters for a feed's data room.
+// Filters sent with the subscribe message take precedence over the user's
+// stored subscription filters. It reports false after sending an error.
+func (m *Manager) applySubscriptionFilters(client *Client, room, feedID string, requested *[]string) bool {
+	var exprs []string
+	if requested != nil {
+		exprs = *requested
+	} else {
+		exprs = m.storedFilters(client.userID, feedID)
+	}
+	filters, err := parseFilters(exprs)
+	if err != nil {
+		client.send(makeMessage("subscription-error", map[string]string{"feedId": feedID, "error": err.Error()}))
+		return false
+	}
+	client.setFilters(room, filters)
+	return true
+}
+
+// storedFilters returns the filters saved on the user's subscription to a feed, if any
+func (m *Manager) storedFilters(userID, feedID string) []string {
+	if m.marketplace == nil || userID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := m.marketplace.GetSubscription(ctx, userID, feedID)
+	if err != nil {
+		if !errors.Is(err, services.ErrSubscriptionNotFound) {
+			slog.Warn("failed to load subscription filters", "feed_id", feedID, "user_id", userID, "error", err)
+		}
+		return nil
+	}
+	if sub.Settings == nil {
+		return nil
+	}
+	return sub.Settings.Filters
+}
+
 func (m *Manager) trackSubscriber(feedID string, client *Client) {
 	m.subscriberMu.Lock()
-	defer m.subscriberMu.Unlock()
 	if _, ok := m.subscribers[feedID]; !ok {
 		m.subscribers[feedID] = make(map[*Client]struct{})
 	}
+	_, known := m.subscribers[feedID][client]
 	m.subscribers[feedID][client] = struct{}{}
+	m.subscriberMu.Unlock()
+	if !known {
+		m.notifyPresence(feedID, 1)
+	}
 }
 
 func (m *Manager) untrackSubscriber(feedID string, client *Client) {
 	m.subscriberMu.Lock()
-	defer m.subscriberMu.Unlock()
+	_, known := m.subscribers[feedID][client]
 	if subs, ok := m.subscribers[feedID]; ok {
 		delete(subs, client)
 		if len(subs) == 0 {
 			delete(m.subscribers, feedID)
 		}
 	}
+	m.subscriberMu.Unlock()
+	if known {
+		m.notifyPresence(feedID, -1)
+	}
 }
 
 func feedRoom(feedID string) string {
@@ -417,6 +1033,20 @@ func dataRoom(feedID string) string {
 	return "data:" + feedID
 }
 
+// userRoom holds a user's clients, which receive their alerts
+func userRoom(userID string) string {
+	return "user:" + userID
+}
+
+// identifyClient records which user a client acts for and moves it to that user's room
+func (m *Manager) identifyClient(client *Client, userID string) {
+	if client.userID != "" && client.userID != userID {
+		m.rooms.Leave(userRoom(client.userID), client)
+	}
+	client.userID = userID
+	m.rooms.Join(userRoom(userID), client)
+}
+
 // BroadcastToRoom sends a message to all clients in a specific room
 func (m *Manager) BroadcastToRoom(room string, eventType string, payload interface{}) {
 	msg := makeMessage(eventType, payload)
@@ -429,22 +1059,59 @@ func makeMessage(eventType string, payload interface{}) WSMessage {
 	}
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("failed to marshal websocket payload: %v", err)
+		slog.Warn("failed to marshal websocket payload", "event", eventType, "error", err)
 		return WSMessage{Type: eventType}
 	}
 	return WSMessage{Type: eventType, Payload: data}
 }
 
-// BroadcastFeedData sends feed updates to clients subscribed to feed data.
+// BroadcastFeedData applies the feed's transform, if any, and sends the result
+// to clients subscribed to feed data unless the feed's throttle holds it back.
 func (m *Manager) BroadcastFeedData(feed models.WebSocketFeed, data interface{}, eventName string) {
+	_, span := tracing.Tracer().Start(context.Background(), "feed.message",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("feed.id", feed.ID.Hex()),
+			attribute.String("feed.event", eventName),
+			attribute.String("feed.connection_type", feed.ConnectionType),
+		))
+	defer span.End()
+	m.recordFeedMessage(feed.ID.Hex())
+	if feed.Transform != nil {
+		transformed, err := applyTransform(feed.Transform, data)
+		if err != nil {
+			slog.Warn("skipping message that does not match the feed transform", "feed_id", feed.ID.Hex(), "error", err)
+			return
+		}
+		data = transformed
+	}
+
+	now := time.Now().UTC()
+	// Rollups, anomalies and alerts cover every message, including ones the throttle holds back
+	m.aggregate(feed, data, now)
+	m.detectAnomalies(feed, data, now)
+	m.evaluateAlerts(feed, data, now)
+	if !m.allowBroadcast(feed, data, now) {
+		return
+	}
 	payload := map[string]interface{}{
 		"feedId":    feed.ID.Hex(),
 		"feedName":  feed.Name,
 		"eventName": eventName,
 		"data":      data,
-		"timestamp": time.Now().UTC(),
+		"timestamp": now,
 	}
 
+	metrics.FeedMessages.WithLabelValues(feed.ID.Hex()).Inc()
+
+	// Record history before the LLM context stamps the payload map
+	if m.history != nil {
+		m.history.Record(feed, eventName, data, now)
+	}
+
+	// Infer the schema before the LLM context stamps the payload map too
+	m.observeSchema(feed, data)
+
 	// Add to LLM context for AI queries
 	if m.llm != nil {
 		m.llm.AddFeedData(feed.ID.Hex(), feed.Name, data)
@@ -452,8 +1119,12 @@ func (m *Manager) BroadcastFeedData(feed models.WebSocketFeed, data interface{},
 
 	// Broadcast to data room only (not llm room)
 	room := dataRoom(feed.ID.Hex())
-	log.Printf("📡 broadcasting feed-data to room %s (feed: %s)", room, feed.Name)
-	m.rooms.Broadcast(room, makeMessage("feed-data", payload))
+	slog.Debug("broadcasting feed-data", "feed_id", feed.ID.Hex(), "room", room)
+	msg := withBinaryFrame(makeMessage("feed-data", payload))
+	m.rememberFeedData(feed.ID.Hex(), msg.Payload)
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
+	m.dispatchWebhooks(feed.ID.Hex(), "", services.WebhookEventFeedData, payload)
 }
 
 // BroadcastLLMOutput sends LLM analysis to clients subscribed to LLM output.
@@ -466,21 +1137,31 @@ func (m *Manager) BroadcastLLMOutput(feedID string, answer string, provider stri
 	}
 
 	room := llmRoom(feedID)
-	log.Printf("🤖 broadcasting llm-broadcast to room %s", room)
-	m.rooms.Broadcast(room, makeMessage("llm-broadcast", payload))
+	slog.Debug("broadcasting llm-broadcast", "feed_id", feedID, "room", room)
+	msg := makeMessage("llm-broadcast", payload)
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
 }
 
-// ConnectFeed opens a websocket connection to the external feed (basic websocket only) and broadcasts messages to subscribers.
+// ConnectFeed opens a connection to the external feed (websocket, protobuf over websocket, Socket.IO, SSE, HTTP polling, MQTT or Kafka) and broadcasts messages to subscribers.
 func (m *Manager) ConnectFeed(feed models.WebSocketFeed) error {
-	if feed.ConnectionType != "" && feed.ConnectionType != "websocket" && feed.ConnectionType != "socketio" {
-		log.Printf("skipping feed %s: unsupported connection type %s", feed.ID.Hex(), feed.ConnectionType)
+	// Push feeds have no upstream; their producers post to the ingestion endpoint
+	if feed.ConnectionType == connectionTypePush {
+		return nil
+	}
+	if !isSupportedFeed(feed) {
+		slog.Warn("skipping feed with unsupported connection type", "feed_id", feed.ID.Hex(), "connection_type", feed.ConnectionType)
+		return nil
+	}
+	// Reconnects racing a shutdown must not reopen upstreams
+	if m.isClosing() {
 		return nil
 	}
 
 	m.feedMu.Lock()
 	if fc, exists := m.feedConns[feed.ID.Hex()]; exists {
 		m.feedMu.Unlock()
-		log.Printf("feed %s already connected", feed.ID.Hex())
+		slog.Debug("feed already connected", "feed_id", feed.ID.Hex())
 		// Close existing connection if it's stale
 		select {
 		case <-fc.stop:
@@ -493,13 +1174,88 @@ func (m *Manager) ConnectFeed(feed models.WebSocketFeed) error {
 		m.feedMu.Unlock()
 	}
 
-	log.Printf("connecting to feed %s: %s", feed.ID.Hex(), feed.URL)
+	// With a cluster, only the instance holding the feed's lock connects upstream
+	if !m.acquireFeed(feed.ID.Hex()) {
+		return nil
+	}
+	feed, err := m.openFeedSecrets(feed)
+	if err != nil {
+		slog.Warn("failed to decrypt feed secrets", "feed_id", feed.ID.Hex(), "error", err)
+		m.releaseFeed(feed.ID.Hex())
+		return err
+	}
+	if err := m.connectUpstream(feed); err != nil {
+		m.releaseFeed(feed.ID.Hex())
+		return err
+	}
+	m.markFeedConnected(feed.ID.Hex())
+	m.holdFeed(feed.ID.Hex())
+	return nil
+}
+
+// openFeedSecrets returns the feed with its credentials decrypted. Feeds are
+// loaded with their secrets sealed; they are opened only to connect upstream.
+func (m *Manager) openFeedSecrets(feed models.WebSocketFeed) (models.WebSocketFeed, error) {
+	if m.marketplace == nil {
+		return feed, nil
+	}
+	return m.marketplace.OpenFeedSecrets(feed)
+}
+
+// connectUpstream dials the feed for its connection type and starts its read loop
+func (m *Manager) connectUpstream(feed models.WebSocketFeed) (err error) {
+	slog.Info("connecting to feed", "feed_id", feed.ID.Hex(), "name", feed.Name)
+	_, span := tracing.Tracer().Start(context.Background(), "feed.connect",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("feed.id", feed.ID.Hex()),
+			attribute.String("feed.connection_type", feed.ConnectionType),
+		))
+	defer func() { tracing.End(span, err) }()
+
+	switch feed.ConnectionType {
+	case connectionTypeSocketIO:
+		return m.connectSocketIOFeed(feed)
+	case connectionTypeSSE:
+		return m.connectSSEFeed(feed)
+	case connectionTypeHTTPPolling:
+		return m.connectPollingFeed(feed)
+	case connectionTypeMQTT:
+		return m.connectMQTTFeed(feed)
+	case connectionTypeKafka:
+		return m.connectKafkaFeed(feed)
+	case connectionTypeSimulated:
+		return m.connectSimulatedFeed(feed)
+	}
 
-	u, err := url.Parse(feed.URL)
+	decode, err := newPayloadDecoder(feed)
 	if err != nil {
-		log.Printf("failed to parse feed URL %s: %v", feed.URL, err)
 		return err
 	}
+	conn, err := dialFeed(feed)
+	if err != nil {
+		return err
+	}
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "websocket")
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{conn: conn, stop: stop}
+	m.feedMu.Unlock()
+
+	sendConnectionMessages(feed, conn)
+
+	go m.readLoop(feed, conn, stop, decode)
+	return nil
+}
+
+// feedTarget returns a feed's upstream URL with its query params applied, plus its configured headers.
+func feedTarget(feed models.WebSocketFeed) (string, http.Header, error) {
+	u, err := url.Parse(feed.URL)
+	if err != nil {
+		slog.Warn("failed to parse feed URL", "feed_id", feed.ID.Hex(), "error", err)
+		return "", nil, err
+	}
 
 	q := u.Query()
 	for _, kv := range feed.QueryParams {
@@ -515,51 +1271,59 @@ func (m *Manager) ConnectFeed(feed models.WebSocketFeed) error {
 			headers.Add(kv.Key, kv.Value)
 		}
 	}
+	return u.String(), headers, nil
+}
+
+// dialFeed opens a websocket connection to a feed's upstream URL with its query params and headers.
+func dialFeed(feed models.WebSocketFeed) (*gws.Conn, error) {
+	target, headers, err := feedTarget(feed)
+	if err != nil {
+		return nil, err
+	}
 
 	dialer := gws.Dialer{
 		HandshakeTimeout: 10 * time.Second,
 	}
-	conn, resp, err := dialer.Dial(u.String(), headers)
+	conn, resp, err := dialer.Dial(target, headers)
 	if err != nil {
 		if resp != nil {
-			log.Printf("failed to dial feed %s (status %d): %v", feed.ID.Hex(), resp.StatusCode, err)
+			slog.Warn("failed to dial feed", "feed_id", feed.ID.Hex(), "status", resp.StatusCode, "error", err)
 		} else {
-			log.Printf("failed to dial feed %s: %v", feed.ID.Hex(), err)
+			slog.Warn("failed to dial feed", "feed_id", feed.ID.Hex(), "error", err)
 		}
-		return err
+		return nil, err
 	}
-	log.Printf("✓ connected to feed %s", feed.ID.Hex())
-
-	stop := make(chan struct{})
-	m.feedMu.Lock()
-	m.feedConns[feed.ID.Hex()] = &feedConnection{conn: conn, stop: stop}
-	m.feedMu.Unlock()
+	return conn, nil
+}
 
+// sendConnectionMessages sends the feed's configured subscription messages after dialing.
+func sendConnectionMessages(feed models.WebSocketFeed, conn *gws.Conn) {
 	if feed.ConnectionMessage != "" {
-		log.Printf("sending connection message to feed %s", feed.ID.Hex())
+		slog.Debug("sending connection message to feed", "feed_id", feed.ID.Hex())
 		if err := conn.WriteMessage(gws.TextMessage, []byte(feed.ConnectionMessage)); err != nil {
-			log.Printf("failed to send connection message to feed %s: %v", feed.ID.Hex(), err)
+			slog.Warn("failed to send connection message to feed", "feed_id", feed.ID.Hex(), "error", err)
 		}
 	}
 	for _, msg := range feed.ConnectionMessages {
 		if msg == "" {
 			continue
 		}
-		log.Printf("sending connection message to feed %s: %s", feed.ID.Hex(), msg)
+		slog.Debug("sending connection message to feed", "feed_id", feed.ID.Hex(), "message", msg)
 		if err := conn.WriteMessage(gws.TextMessage, []byte(msg)); err != nil {
-			log.Printf("failed to send connection message to feed %s: %v", feed.ID.Hex(), err)
+			slog.Warn("failed to send connection message to feed", "feed_id", feed.ID.Hex(), "error", err)
 		}
 	}
-
-	go m.readLoop(feed, conn, stop)
-	return nil
 }
 
 // StopFeed stops the websocket connection for a given feed
 func (m *Manager) StopFeed(feedID string) {
 	m.feedMu.Lock()
 	defer m.feedMu.Unlock()
+	m.stopFeedLocked(feedID)
+}
 
+// stopFeedLocked is StopFeed for callers holding feedMu
+func (m *Manager) stopFeedLocked(feedID string) {
 	if fc, exists := m.feedConns[feedID]; exists {
 		// Check if channel is already closed to avoid panic
 		select {
@@ -570,7 +1334,7 @@ func (m *Manager) StopFeed(feedID string) {
 		}
 		// We don't delete here because readLoop's defer will handle it
 		// and we want to avoid race conditions or double deletes
-		log.Printf("stopped feed %s", feedID)
+		slog.Info("stopped feed", "feed_id", feedID)
 	}
 }
 
@@ -584,44 +1348,56 @@ func (m *Manager) ensureFeedConnection(feedID string) {
 	if err != nil || feed == nil {
 		return
 	}
+	if !feed.IsActive {
+		slog.Info("feed is deactivated, not connecting", "feed_id", feedID)
+		m.reportPaused(feedID)
+		return
+	}
+	if circuitBlocks(*feed, time.Now()) {
+		slog.Info("feed circuit is open, not connecting", "feed_id", feedID)
+		m.reportFeedStatus(feedStatus{FeedID: feedID, Status: "circuit-open", MaxAttempts: feed.ReconnectionAttempts, Message: "upstream unavailable, retrying later"})
+		return
+	}
 	if err := m.ConnectFeed(*feed); err != nil {
-		log.Printf("failed to connect feed %s: %v", feedID, err)
+		slog.Warn("failed to connect feed", "feed_id", feedID, "error", err)
+		// A failed probe after the cooldown keeps the circuit open for another cooldown
+		if feed.CircuitState == circuitOpen {
+			now := time.Now().UTC()
+			m.storeCircuit(feedID, circuitOpen, &now)
+		}
+		return
+	}
+	if feed.CircuitState == circuitOpen {
+		m.reportConnected(*feed)
 	}
 }
 
-// reconnectFeed attempts to reconnect to a feed after a delay
-func (m *Manager) reconnectFeed(feed models.WebSocketFeed) {
-	// Wait before reconnecting
-	time.Sleep(5 * time.Second)
-
-	log.Printf("attempting to reconnect feed %s", feed.ID.Hex())
-
-	if err := m.ConnectFeed(feed); err != nil {
-		log.Printf("failed to reconnect feed %s: %v", feed.ID.Hex(), err)
-	} else {
-		log.Printf("successfully reconnected feed %s", feed.ID.Hex())
+// countReconnect records a reconnect attempt to an upstream feed
+func countReconnect(feed models.WebSocketFeed) {
+	connectionType := feed.ConnectionType
+	if connectionType == "" {
+		connectionType = "websocket"
 	}
+	metrics.FeedReconnects.WithLabelValues(feed.ID.Hex(), connectionType).Inc()
 }
 
-func (m *Manager) readLoop(feed models.WebSocketFeed, conn *gws.Conn, stop chan struct{}) {
+func (m *Manager) readLoop(feed models.WebSocketFeed, conn *gws.Conn, stop chan struct{}, decode payloadDecoder) {
 	defer func() {
-		m.feedMu.Lock()
-		delete(m.feedConns, feed.ID.Hex())
-		m.feedMu.Unlock()
+		m.feedClosed(feed)
 		if err := conn.Close(); err != nil {
-			log.Printf("error closing feed %s connection: %v", feed.ID.Hex(), err)
+			slog.Warn("error closing feed connection", "feed_id", feed.ID.Hex(), "error", err)
 		}
-		log.Printf("feed %s connection closed", feed.ID.Hex())
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
 	}()
 
 	// Set up ping/pong to keep connection alive
 	if err := conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
-		log.Printf("error setting initial read deadline for feed %s: %v", feed.ID.Hex(), err)
+		slog.Warn("error setting initial feed read deadline", "feed_id", feed.ID.Hex(), "error", err)
 		return
 	}
 	conn.SetPongHandler(func(string) error {
 		if err := conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
-			log.Printf("error setting read deadline in pong handler for feed %s: %v", feed.ID.Hex(), err)
+			slog.Warn("error setting feed read deadline in pong handler", "feed_id", feed.ID.Hex(), "error", err)
 		}
 		return nil
 	})
@@ -630,6 +1406,12 @@ func (m *Manager) readLoop(feed models.WebSocketFeed, conn *gws.Conn, stop chan
 	pingTicker := time.NewTicker(30 * time.Second)
 	defer pingTicker.Stop()
 
+	// Optional reassembly of JSON documents split across frames
+	var reassembler *jsonReassembler
+	if feed.ReassembleFragments && feed.ConnectionType != connectionTypeProtobuf {
+		reassembler = newJSONReassembler(feed.FragmentMaxBytes, time.Duration(feed.FragmentTimeoutMs)*time.Millisecond)
+	}
+
 	// Channel for reading messages
 	msgChan := make(chan []byte, 10)
 	errChan := make(chan error, 1)
@@ -649,33 +1431,39 @@ func (m *Manager) readLoop(feed models.WebSocketFeed, conn *gws.Conn, stop chan
 	for {
 		select {
 		case <-stop:
-			log.Printf("feed %s stopping by request", feed.ID.Hex())
+			slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
 			return
 
 		case <-pingTicker.C:
 			if err := conn.WriteMessage(gws.PingMessage, []byte{}); err != nil {
-				log.Printf("feed %s ping failed: %v", feed.ID.Hex(), err)
+				slog.Warn("feed ping failed", "feed_id", feed.ID.Hex(), "error", err)
 				return
 			}
+			if reassembler != nil {
+				m.broadcastFragments(feed, reassembler.Expire(time.Now()))
+			}
 
 		case msg := <-msgChan:
 			// Reset read deadline on successful message
 			if err := conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
-				log.Printf("error resetting read deadline for feed %s: %v", feed.ID.Hex(), err)
+				slog.Warn("error resetting feed read deadline", "feed_id", feed.ID.Hex(), "error", err)
 				return
 			}
 
-			// Try to parse as JSON for better display
-			var jsonData interface{}
-			if err := json.Unmarshal(msg, &jsonData); err == nil {
-				m.BroadcastFeedData(feed, jsonData, feed.EventName)
-			} else {
-				// If not JSON, send as string
-				m.BroadcastFeedData(feed, string(msg), feed.EventName)
+			if reassembler != nil {
+				m.broadcastFragments(feed, reassembler.Push(msg, time.Now()))
+				continue
 			}
+			data, err := decode(msg)
+			if err != nil {
+				slog.Warn("skipping undecodable feed message", "feed_id", feed.ID.Hex(), "error", err)
+				continue
+			}
+			m.BroadcastFeedData(feed, data, feed.EventName)
 
 		case err := <-errChan:
-			log.Printf("feed %s read error: %v", feed.ID.Hex(), err)
+			slog.Warn("feed read error", "feed_id", feed.ID.Hex(), "error", err)
+			m.recordFeedError(feed.ID.Hex(), err)
 			// Check if we should attempt reconnection
 			if feed.ReconnectionEnabled {
 				go m.reconnectFeed(feed)
@@ -685,6 +1473,45 @@ func (m *Manager) readLoop(feed models.WebSocketFeed, conn *gws.Conn, stop chan
 	}
 }
 
+// broadcastFrame broadcasts a raw feed message, decoding it as JSON when possible.
+func (m *Manager) broadcastFrame(feed models.WebSocketFeed, msg []byte) {
+	m.BroadcastFeedData(feed, decodeFeedMessage(msg), feed.EventName)
+}
+
+// decodeFeedMessage parses a raw feed message as JSON for better display,
+// falling back to the message as a string.
+func decodeFeedMessage(msg []byte) interface{} {
+	var jsonData interface{}
+	if err := json.Unmarshal(msg, &jsonData); err != nil {
+		return string(msg)
+	}
+	return jsonData
+}
+
+// isWebSocketFeed reports whether the feed is read over a plain websocket connection
+func isWebSocketFeed(feed models.WebSocketFeed) bool {
+	return feed.ConnectionType == "" || feed.ConnectionType == "websocket" || feed.ConnectionType == connectionTypeProtobuf
+}
+
+// isSupportedFeed reports whether the manager has a connector for the feed's connection type
+func isSupportedFeed(feed models.WebSocketFeed) bool {
+	switch feed.ConnectionType {
+	case connectionTypeSocketIO, connectionTypeSSE, connectionTypeHTTPPolling, connectionTypeMQTT, connectionTypeKafka, connectionTypeSimulated:
+		return true
+	}
+	return isWebSocketFeed(feed)
+}
+
+// broadcastFragments broadcasts payloads emitted by the reassembler.
+func (m *Manager) broadcastFragments(feed models.WebSocketFeed, fragments []fragment) {
+	for _, f := range fragments {
+		if !f.Complete {
+			slog.Warn("flushing incomplete JSON fragment", "feed_id", feed.ID.Hex(), "bytes", len(f.Data))
+		}
+		m.broadcastFrame(feed, f.Data)
+	}
+}
+
 // simpleAnalyze either calls Azure OpenAI if configured or falls back to a canned response.
 func (m *Manager) simpleAnalyze(payload map[string]interface{}) (string, int) {
 	def := "Analysis is not yet connected to an AI provider in the Go backend. This is a placeholder response."
@@ -698,12 +1525,12 @@ func (m *Manager) simpleAnalyze(payload map[string]interface{}) (string, int) {
 		{Role: "system", Content: "You are an AI assistant providing concise analysis for realtime data feeds."},
 		{Role: "user", Content: fmt.Sprintf("Analyze this payload: %v", payload)},
 	}
-	resp, tokens, err := m.azure.Chat(ctx, messages)
+	resp, usage, err := m.azure.Chat(ctx, messages, services.ChatOptions{})
 	if err != nil {
-		log.Printf("azure openai chat failed: %v", err)
+		slog.Warn("azure openai chat failed", "error", err)
 		return def, 0
 	}
-	return resp, tokens
+	return resp, usage.Total()
 }
 
 func (m *Manager) sendTokenUsageUpdate(client *Client) {
@@ -726,9 +1553,81 @@ func (m *Manager) sendTokenUsageUpdate(client *Client) {
 	}
 }
 
-// handleLLMQuery handles non-streaming LLM queries via WebSocket
-func (m *Manager) handleLLMQuery(client *Client, feedID, question, provider, systemPrompt, requestID string) {
-	if m.llm == nil || !m.llm.Enabled() {
+// errLLMAuthRequired answers LLM queries from connections that have not
+// authenticated, which have no quota to charge
+const errLLMAuthRequired = "authenticate before querying the AI"
+
+// allowLLMQuery checks the client's monthly token quota against the query's
+// estimated prompt and answers quota-exceeded when the query would overrun
+// it. Clients must have authenticated so the query is charged to someone;
+// queries on the user's own provider key are not limited, and queries go
+// ahead when the quota cannot be read.
+func (m *Manager) allowLLMQuery(client *Client, req services.QueryRequest, requestID string) bool {
+	if m.auth != nil && !client.authenticated {
+		client.send(makeMessage("llm-error", map[string]interface{}{
+			"error":     errLLMAuthRequired,
+			"requestId": requestID,
+		}))
+		return false
+	}
+	quota, ok := m.withinQuota(client.ctx, client.userID, req)
+	if !ok {
+		client.send(makeMessage("quota-exceeded", quotaExceededPayload(quota, requestID)))
+	}
+	return ok
+}
+
+// withinQuota reports whether a user's query fits in their monthly token
+// quota, returning the quota when it does not. Without a valid user there is
+// no quota to charge, so the query does not fit.
+func (m *Manager) withinQuota(ctx context.Context, userID string, req services.QueryRequest) (services.TokenQuota, bool) {
+	if m.auth == nil {
+		return services.TokenQuota{}, true
+	}
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.TokenQuota{}, false
+	}
+	if m.llm.UsesOwnKey(ctx, req) {
+		return services.TokenQuota{}, true
+	}
+	quotaCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	quota, err := m.auth.CheckTokenQuota(quotaCtx, id, m.llm.EstimatePromptTokens(req))
+	if errors.Is(err, services.ErrTokenQuotaExceeded) {
+		return quota, false
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check token quota", "user_id", userID, "error", err)
+	}
+	return quota, true
+}
+
+// quotaExceededError says how much quota is left for a rejected query
+func quotaExceededError(quota services.TokenQuota) string {
+	return fmt.Sprintf("monthly token quota exceeded: %d of %d tokens left, query needs about %d", quota.Remaining, quota.Limit, quota.EstimatedTokens)
+}
+
+// quotaExceededPayload describes a rejected query and the quota left
+func quotaExceededPayload(quota services.TokenQuota, requestID string) map[string]interface{} {
+	return map[string]interface{}{
+		"error":           quotaExceededError(quota),
+		"requestId":       requestID,
+		"tokensUsed":      quota.TokensUsed,
+		"limit":           quota.Limit,
+		"remaining":       quota.Remaining,
+		"estimatedTokens": quota.EstimatedTokens,
+	}
+}
+
+// handleLLMQuery handles non-streaming LLM queries via WebSocket, letting
+// the model call tools when tools is set, as a turn of conv when it is set
+func (m *Manager) handleLLMQuery(msgCtx context.Context, client *Client, feedID, question, provider, systemPrompt, requestID string, opts services.ChatOptions, tools bool, conv *models.Conversation) {
+	// The answer is built from the feed's data, so the client must be allowed to read it
+	if !m.authorizeLLMQuery(client, feedID, requestID) {
+		return
+	}
+	if m.llm == nil || !m.llm.EnabledFor(client.ctx, client.userID) {
 		client.send(makeMessage("llm-error", map[string]interface{}{
 			"error":     "LLM service not configured",
 			"requestId": requestID,
@@ -736,15 +1635,29 @@ func (m *Manager) handleLLMQuery(client *Client, feedID, question, provider, sys
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	req := services.QueryRequest{
+		FeedID:         feedID,
+		Question:       question,
+		Provider:       provider,
+		SystemPrompt:   systemPrompt,
+		Temperature:    opts.Temperature,
+		MaxTokens:      opts.MaxTokens,
+		ResponseFormat: opts.Format,
+		Tools:          tools,
+		UserID:         client.userID,
+	}
+	if conv != nil {
+		req.History = services.ConversationHistory(conv)
+	}
+	if !m.allowLLMQuery(client, req, requestID) {
+		return
+	}
+
+	// Not cancelled when the client disconnects, but logged with its message
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(msgCtx), 60*time.Second)
 	defer cancel()
 
-	resp, err := m.llm.Query(ctx, services.QueryRequest{
-		FeedID:       feedID,
-		Question:     question,
-		Provider:     provider,
-		SystemPrompt: systemPrompt,
-	})
+	resp, err := m.llm.Query(ctx, req)
 
 	if err != nil {
 		client.send(makeMessage("llm-error", map[string]interface{}{
@@ -754,30 +1667,56 @@ func (m *Manager) handleLLMQuery(client *Client, feedID, question, provider, sys
 		return
 	}
 
-	// Update token usage
-	if m.auth != nil && client.userID != "" {
+	// Update token usage; the user pays their own provider for own-key queries
+	if m.auth != nil && client.userID != "" && !resp.OwnKey {
 		userID, err := primitive.ObjectIDFromHex(client.userID)
 		if err == nil {
 			if err := m.auth.UpdateTokenUsage(ctx, userID, resp.TokensUsed); err != nil {
-				log.Printf("failed to update token usage for user %s: %v", client.userID, err)
+				slog.WarnContext(ctx, "failed to update token usage", "user_id", client.userID, "error", err)
 			} else {
 				m.sendTokenUsageUpdate(client)
 			}
 		}
 	}
-
-	client.send(makeMessage("llm-response", map[string]interface{}{
-		"answer":     resp.Answer,
-		"provider":   resp.Provider,
-		"feedId":     resp.FeedID,
-		"durationMs": resp.Duration,
-		"requestId":  requestID,
-	}))
+	m.recordLLMUsage(client.userID, "query", resp)
+
+	response := map[string]interface{}{
+		"answer":                    resp.Answer,
+		"provider":                  resp.Provider,
+		"model":                     resp.Model,
+		"feedId":                    resp.FeedID,
+		"durationMs":                resp.Duration,
+		"cached":                    resp.Cached,
+		"ownKey":                    resp.OwnKey,
+		"failover":                  resp.Failover,
+		"json":                      resp.JSON,
+		"toolCalls":                 resp.ToolCalls,
+		"retrieved":                 resp.Retrieved,
+		"contextEntries":            resp.ContextEntries,
+		"contextWindow":             resp.ContextWindow,
+		"contextUtilizationPercent": resp.ContextUtilizationPercent,
+		"promptTokens":              resp.PromptTokens,
+		"completionTokens":          resp.CompletionTokens,
+		"tokensUsed":                resp.TokensUsed,
+		"requestId":                 requestID,
+	}
+	if conv != nil {
+		m.saveConversationTurn(conv, question, resp)
+		if !conv.ID.IsZero() {
+			response["conversationId"] = conv.ID.Hex()
+		}
+	}
+	client.send(makeMessage("llm-response", response))
 }
 
-// handleLLMStreamQuery handles streaming LLM queries via WebSocket
-func (m *Manager) handleLLMStreamQuery(client *Client, feedID, question, provider, systemPrompt, requestID string) {
-	if m.llm == nil || !m.llm.Enabled() {
+// handleLLMStreamQuery handles streaming LLM queries via WebSocket, as a
+// turn of conv when it is set
+func (m *Manager) handleLLMStreamQuery(msgCtx context.Context, client *Client, feedID, question, provider, systemPrompt, requestID string, opts services.ChatOptions, conv *models.Conversation) {
+	// The answer is built from the feed's data, so the client must be allowed to read it
+	if !m.authorizeLLMQuery(client, feedID, requestID) {
+		return
+	}
+	if m.llm == nil || !m.llm.EnabledFor(client.ctx, client.userID) {
 		client.send(makeMessage("llm-error", map[string]interface{}{
 			"error":     "LLM service not configured",
 			"requestId": requestID,
@@ -785,20 +1724,43 @@ func (m *Manager) handleLLMStreamQuery(client *Client, feedID, question, provide
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	req := services.QueryRequest{
+		FeedID:         feedID,
+		Question:       question,
+		Provider:       provider,
+		SystemPrompt:   systemPrompt,
+		Temperature:    opts.Temperature,
+		MaxTokens:      opts.MaxTokens,
+		ResponseFormat: opts.Format,
+		UserID:         client.userID,
+	}
+	if conv != nil {
+		req.History = services.ConversationHistory(conv)
+	}
+	if !m.allowLLMQuery(client, req, requestID) {
+		return
+	}
+
+	// Not cancelled when the client disconnects, but logged with its message
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(msgCtx), 60*time.Second)
 	defer cancel()
+	if requestID != "" {
+		defer client.trackLLMRequest(requestID, cancel)()
+	}
 
 	tokenChan := make(chan string, 100)
+	streamDone := make(chan struct{})
+	progress := newStreamProgress(time.Now())
 
 	// Start streaming
 	go func() {
-		resp, err := m.llm.StreamQuery(ctx, services.QueryRequest{
-			FeedID:       feedID,
-			Question:     question,
-			Provider:     provider,
-			SystemPrompt: systemPrompt,
-		}, tokenChan)
+		defer close(streamDone)
+		resp, err := m.llm.StreamQuery(ctx, req, tokenChan)
 
+		if errors.Is(ctx.Err(), context.Canceled) {
+			m.finishCancelledStream(client, requestID, resp)
+			return
+		}
 		if err != nil {
 			client.send(makeMessage("llm-error", map[string]interface{}{
 				"error":     err.Error(),
@@ -807,37 +1769,69 @@ func (m *Manager) handleLLMStreamQuery(client *Client, feedID, question, provide
 			return
 		}
 
-		// Update token usage
-		if m.auth != nil && client.userID != "" {
+		// Update token usage; the user pays their own provider for own-key queries
+		if m.auth != nil && client.userID != "" && !resp.OwnKey {
 			userID, err := primitive.ObjectIDFromHex(client.userID)
 			if err == nil {
 				if err := m.auth.UpdateTokenUsage(ctx, userID, resp.TokensUsed); err != nil {
-					log.Printf("failed to update token usage for user %s: %v", client.userID, err)
+					slog.WarnContext(ctx, "failed to update token usage", "user_id", client.userID, "error", err)
 				} else {
 					m.sendTokenUsageUpdate(client)
 				}
 			}
 		}
+		m.recordLLMUsage(client.userID, "stream", resp)
 
 		// Send completion message to the requester
-		completionMsg := makeMessage("llm-complete", map[string]interface{}{
-			"answer":     resp.Answer,
-			"provider":   resp.Provider,
-			"feedId":     resp.FeedID,
-			"durationMs": resp.Duration,
-			"requestId":  requestID,
-		})
-		client.send(completionMsg)
+		completion := map[string]interface{}{
+			"answer":                    resp.Answer,
+			"provider":                  resp.Provider,
+			"model":                     resp.Model,
+			"feedId":                    resp.FeedID,
+			"durationMs":                resp.Duration,
+			"cached":                    resp.Cached,
+			"ownKey":                    resp.OwnKey,
+			"failover":                  resp.Failover,
+			"json":                      resp.JSON,
+			"retrieved":                 resp.Retrieved,
+			"contextEntries":            resp.ContextEntries,
+			"contextWindow":             resp.ContextWindow,
+			"contextUtilizationPercent": resp.ContextUtilizationPercent,
+			"promptTokens":              resp.PromptTokens,
+			"completionTokens":          resp.CompletionTokens,
+			"tokensUsed":                resp.TokensUsed,
+			"requestId":                 requestID,
+			"usage":                     progress.usage(resp),
+		}
+		if resp.Error != "" {
+			// A JSON answer that failed validation after it was streamed
+			completion["error"] = resp.Error
+		}
+		if conv != nil {
+			m.saveConversationTurn(conv, question, resp)
+			if !conv.ID.IsZero() {
+				completion["conversationId"] = conv.ID.Hex()
+			}
+		}
+		client.send(makeMessage("llm-complete", completion))
 
 		// Broadcast to LLM subscribers
 		m.BroadcastLLMOutput(feedID, resp.Answer, resp.Provider)
 	}()
 
-	// Stream tokens to client
+	// Stream tokens to client; once cancelled the rest are drained unsent
 	for token := range tokenChan {
+		if ctx.Err() != nil {
+			continue
+		}
 		client.send(makeMessage("llm-token", map[string]interface{}{
 			"token":     token,
 			"requestId": requestID,
 		}))
+		if now := time.Now(); progress.add(now) {
+			client.send(makeMessage("llm-progress", progress.payload(requestID, now)))
+		}
 	}
+	// The completion is sent after the token channel closes; wait for it so shutdown can drain the query
+	<-streamDone
 }
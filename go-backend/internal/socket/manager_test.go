@@ -2,9 +2,17 @@ package socket
 
 import (
 	"context"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
 )
 
 func TestRoomManager_JoinAndLeave(t *testing.T) {
@@ -272,3 +280,165 @@ func TestManager_Initialization(t *testing.T) {
 	assert.Equal(t, 0, len(manager.feedConns))
 	assert.Equal(t, 0, len(manager.subscribers))
 }
+
+func TestManager_StopIfIdle(t *testing.T) {
+	manager := &Manager{
+		rooms:       NewRoomManager(),
+		feedConns:   make(map[string]*feedConnection),
+		subscribers: make(map[string]map[*Client]struct{}),
+	}
+
+	idleStop := make(chan struct{})
+	busyStop := make(chan struct{})
+	manager.feedConns["idle"] = &feedConnection{stop: idleStop}
+	manager.feedConns["busy"] = &feedConnection{stop: busyStop}
+	manager.trackSubscriber("busy", &Client{ctx: context.Background(), userID: "user1"})
+
+	assert.True(t, manager.stopIfIdle("idle"))
+	assert.False(t, manager.stopIfIdle("busy"))
+
+	select {
+	case <-idleStop:
+	default:
+		t.Fatal("expected idle warmed feed to be stopped")
+	}
+	select {
+	case <-busyStop:
+		t.Fatal("expected feed with subscribers to keep running")
+	default:
+	}
+}
+
+func TestManager_WarmUpFeedsSkipsDeactivated(t *testing.T) {
+	ctx := context.Background()
+	feeds := services.NewMemoryFeedRepo()
+	m := NewManager(nil, nil, services.NewMarketplaceServiceWithRepos(feeds, services.NewMemorySubscriptionRepo()), nil)
+
+	active := models.WebSocketFeed{Name: "Active", IsActive: true, ConnectionType: connectionTypeSimulated, SimulatedConfig: &models.SimulatedConfig{IntervalMs: 50}}
+	inactive := models.WebSocketFeed{Name: "Deactivated", ConnectionType: connectionTypeSimulated, SimulatedConfig: &models.SimulatedConfig{IntervalMs: 50}}
+	require.NoError(t, feeds.Insert(ctx, &active))
+	require.NoError(t, feeds.Insert(ctx, &inactive))
+
+	m.WarmUpFeeds(ctx, 0, []string{active.ID.Hex(), inactive.ID.Hex()}, 0)
+	defer m.StopFeed(active.ID.Hex())
+	assert.True(t, m.IsFeedConnected(active.ID.Hex()))
+	assert.False(t, m.IsFeedConnected(inactive.ID.Hex()), "WARM_FEED_IDS does not reconnect deactivated feeds")
+}
+
+func TestManager_StopIfIdleRacingSubscribe(t *testing.T) {
+	manager := &Manager{
+		rooms:       NewRoomManager(),
+		feedConns:   make(map[string]*feedConnection),
+		subscribers: make(map[string]map[*Client]struct{}),
+	}
+	stop := make(chan struct{})
+	manager.feedConns["feed"] = &feedConnection{stop: stop}
+
+	// The idle check waits for the feed lock, so a subscriber tracked before
+	// the lock is released is counted
+	manager.feedMu.Lock()
+	done := make(chan bool)
+	go func() { done <- manager.stopIfIdle("feed") }()
+	manager.trackSubscriber("feed", &Client{ctx: context.Background(), userID: "user1"})
+	manager.feedMu.Unlock()
+
+	assert.False(t, <-done)
+	select {
+	case <-stop:
+		t.Fatal("expected the feed to keep running for its new subscriber")
+	default:
+	}
+}
+
+func TestManager_AllowLLMQueryWithoutAccount(t *testing.T) {
+	manager := NewManager(nil, nil, nil, nil)
+	client := &Client{ctx: context.Background(), out: make(chan WSMessage, 1)}
+	assert.True(t, manager.allowLLMQuery(client, services.QueryRequest{FeedID: "feed-1"}, "req-1"))
+	assert.Empty(t, client.out)
+}
+
+func TestManager_AllowLLMQueryAnonymous(t *testing.T) {
+	auth := services.NewAuthService(config.Config{JWTSecret: "test-secret"}, nil, nil)
+	auth.SetStore(services.NewMemoryStore())
+	manager := NewManager(auth, nil, nil, nil)
+
+	// Neither a connection that never authenticated nor one that only claimed
+	// a user ID with register-user has a quota to charge
+	for _, client := range []*Client{
+		{ctx: context.Background(), out: make(chan WSMessage, 1)},
+		{ctx: context.Background(), out: make(chan WSMessage, 1), userID: primitive.NewObjectID().Hex()},
+	} {
+		assert.False(t, manager.allowLLMQuery(client, services.QueryRequest{FeedID: "feed-1"}, "req-1"))
+		msg := <-client.out
+		assert.Equal(t, "llm-error", msg.Type)
+		assert.JSONEq(t, `{"error":"authenticate before querying the AI","requestId":"req-1"}`, string(msg.Payload))
+	}
+
+	_, ok := manager.withinQuota(context.Background(), "", services.QueryRequest{FeedID: "feed-1"})
+	assert.False(t, ok, "queries without a user are never within quota")
+}
+
+func TestQuotaExceededPayload(t *testing.T) {
+	payload := quotaExceededPayload(services.TokenQuota{TokensUsed: 990, Limit: 1000, Remaining: 10, EstimatedTokens: 250}, "req-1")
+	assert.Equal(t, "req-1", payload["requestId"])
+	assert.Equal(t, int64(10), payload["remaining"])
+	assert.Equal(t, int64(1000), payload["limit"])
+	assert.Equal(t, 250, payload["estimatedTokens"])
+	assert.Equal(t, "monthly token quota exceeded: 10 of 1000 tokens left, query needs about 250", payload["error"])
+}
+
+func TestRoomManager_BroadcastShardsLargeRooms(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	rm := NewRoomManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A client that never reads keeps a full queue throughout
+	stuck := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 1)}
+	stuck.out <- WSMessage{Type: "old"}
+	rm.Join("room", stuck)
+
+	clients := make([]*Client, 2*broadcastShardSize+1)
+	for i := range clients {
+		clients[i] = &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 4)}
+		rm.Join("room", clients[i])
+	}
+
+	done := make(chan struct{})
+	go func() {
+		rm.Broadcast("room", WSMessage{Type: "one"})
+		rm.Broadcast("room", WSMessage{Type: "two"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("broadcast blocked on a stuck client")
+	}
+
+	for _, client := range clients {
+		require.Len(t, client.out, 2)
+		assert.Equal(t, "one", (<-client.out).Type)
+		assert.Equal(t, "two", (<-client.out).Type, "broadcasts reach each client in order")
+	}
+	assert.Equal(t, "two", (<-stuck.out).Type, "a full queue drops its oldest message")
+}
+
+func TestRoomManager_MembersFollowMembership(t *testing.T) {
+	rm := NewRoomManager()
+	client1 := &Client{ctx: context.Background()}
+	client2 := &Client{ctx: context.Background()}
+
+	rm.Join("room", client1)
+	assert.ElementsMatch(t, []*Client{client1}, rm.Members("room"))
+	rm.Join("room", client2)
+	assert.ElementsMatch(t, []*Client{client1, client2}, rm.Members("room"))
+	rm.Leave("room", client1)
+	assert.ElementsMatch(t, []*Client{client2}, rm.Members("room"))
+	rm.LeaveAll(client2)
+	assert.Empty(t, rm.Members("room"))
+
+	rm.mu.RLock()
+	assert.Empty(t, rm.members, "empty rooms are not cached")
+	rm.mu.RUnlock()
+}
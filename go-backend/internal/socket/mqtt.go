@@ -0,0 +1,179 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeMQTT is the feed connection type for MQTT broker topics
+const connectionTypeMQTT = "mqtt"
+
+const (
+	// mqttConnectTimeout bounds connecting to the broker and subscribing
+	mqttConnectTimeout = 10 * time.Second
+	// mqttDisconnectQuiesce is how long (ms) a disconnect waits for in-flight work
+	mqttDisconnectQuiesce = 250
+)
+
+// ErrMQTTTopicRequired is returned when an MQTT feed has no topic configured
+var ErrMQTTTopicRequired = errors.New("mqtt feed requires a topic")
+
+// mqttClientOptions builds the client options for a feed's broker. The client
+// ID gets a suffix so concurrent connections (e.g. a sample capture next to
+// the live feed) don't kick each other off the broker.
+func mqttClientOptions(feed models.WebSocketFeed, suffix string) (*mqtt.ClientOptions, error) {
+	cfg := feed.MQTTConfig
+	if cfg == nil || cfg.Topic == "" {
+		return nil, ErrMQTTTopicRequired
+	}
+	if cfg.QoS > 2 {
+		return nil, fmt.Errorf("invalid mqtt qos %d", cfg.QoS)
+	}
+
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = "turbostream-" + feed.ID.Hex()
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(feed.URL).
+		SetClientID(clientID + "-" + suffix).
+		SetCleanSession(true).
+		SetConnectTimeout(mqttConnectTimeout).
+		SetAutoReconnect(feed.ReconnectionEnabled)
+	if cfg.Username != "" {
+		opts.SetUsername(cfg.Username)
+		opts.SetPassword(cfg.Password)
+	}
+	if feed.ReconnectionDelay > 0 {
+		opts.SetMaxReconnectInterval(time.Duration(feed.ReconnectionDelay) * time.Millisecond)
+	}
+	return opts, nil
+}
+
+// waitToken waits for an MQTT operation and returns its error
+func waitToken(token mqtt.Token, timeout time.Duration) error {
+	if !token.WaitTimeout(timeout) {
+		return errors.New("mqtt operation timed out")
+	}
+	return token.Error()
+}
+
+// connectMQTTFeed connects to the feed's broker, subscribes to its topic and
+// broadcasts every message. The subscription is renewed after reconnects.
+func (m *Manager) connectMQTTFeed(feed models.WebSocketFeed) error {
+	opts, err := mqttClientOptions(feed, "live")
+	if err != nil {
+		return err
+	}
+	cfg := feed.MQTTConfig
+	decode, err := newPayloadDecoder(feed)
+	if err != nil {
+		return err
+	}
+
+	onMessage := func(_ mqtt.Client, msg mqtt.Message) {
+		eventName := feed.EventName
+		if eventName == "" {
+			eventName = msg.Topic()
+		}
+		data, err := decode(msg.Payload())
+		if err != nil {
+			slog.Warn("skipping undecodable feed message", "feed_id", feed.ID.Hex(), "error", err)
+			return
+		}
+		m.BroadcastFeedData(feed, data, eventName)
+	}
+
+	lost := make(chan struct{})
+	var lostOnce sync.Once
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		// Clean sessions drop subscriptions, so subscribe on every (re)connect
+		if err := waitToken(c.Subscribe(cfg.Topic, cfg.QoS, onMessage), mqttConnectTimeout); err != nil {
+			slog.Warn("failed to subscribe feed to MQTT topic", "feed_id", feed.ID.Hex(), "topic", cfg.Topic, "error", err)
+			m.recordFeedError(feed.ID.Hex(), err)
+			return
+		}
+		m.markFeedConnected(feed.ID.Hex())
+	})
+	opts.SetReconnectingHandler(func(mqtt.Client, *mqtt.ClientOptions) {
+		countReconnect(feed)
+	})
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		slog.Warn("feed broker connection lost", "feed_id", feed.ID.Hex(), "error", err)
+		m.recordFeedError(feed.ID.Hex(), err)
+		if !feed.ReconnectionEnabled {
+			lostOnce.Do(func() { close(lost) })
+		}
+	})
+
+	client := mqtt.NewClient(opts)
+	if err := waitToken(client.Connect(), mqttConnectTimeout); err != nil {
+		slog.Warn("failed to connect feed to broker", "feed_id", feed.ID.Hex(), "error", err)
+		return err
+	}
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "mqtt", "topic", cfg.Topic)
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{stop: stop}
+	m.feedMu.Unlock()
+
+	go func() {
+		select {
+		case <-stop:
+			slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
+		case <-lost:
+		}
+		client.Disconnect(mqttDisconnectQuiesce)
+		m.feedClosed(feed)
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
+	}()
+	return nil
+}
+
+// captureMQTTSample connects to the feed's broker and returns the first message on its topic.
+func captureMQTTSample(ctx context.Context, feed models.WebSocketFeed) (interface{}, error) {
+	opts, err := mqttClientOptions(feed, "sample")
+	if err != nil {
+		return nil, err
+	}
+	opts.SetAutoReconnect(false)
+	decode, err := newPayloadDecoder(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	client := mqtt.NewClient(opts)
+	if err := waitToken(client.Connect(), mqttConnectTimeout); err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(mqttDisconnectQuiesce)
+
+	first := make(chan []byte, 1)
+	handler := func(_ mqtt.Client, msg mqtt.Message) {
+		select {
+		case first <- msg.Payload():
+		default:
+		}
+	}
+	if err := waitToken(client.Subscribe(feed.MQTTConfig.Topic, feed.MQTTConfig.QoS, handler), mqttConnectTimeout); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sampleReadTimeout)
+	defer cancel()
+	select {
+	case payload := <-first:
+		return decode(payload)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,61 @@
+package socket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestMQTTClientOptions(t *testing.T) {
+	feed := models.WebSocketFeed{
+		ID:                  primitive.NewObjectID(),
+		URL:                 "tcp://broker.example.com:1883",
+		ConnectionType:      connectionTypeMQTT,
+		ReconnectionEnabled: true,
+		ReconnectionDelay:   2000,
+		MQTTConfig: &models.MQTTConfig{
+			Topic:    "prices/#",
+			QoS:      1,
+			Username: "user",
+			Password: "pass",
+		},
+	}
+
+	opts, err := mqttClientOptions(feed, "live")
+	require.NoError(t, err)
+	require.Len(t, opts.Servers, 1)
+	assert.Equal(t, "broker.example.com:1883", opts.Servers[0].Host)
+	assert.Equal(t, "turbostream-"+feed.ID.Hex()+"-live", opts.ClientID)
+	assert.Equal(t, "user", opts.Username)
+	assert.Equal(t, "pass", opts.Password)
+	assert.True(t, opts.AutoReconnect)
+	assert.Equal(t, 2*time.Second, opts.MaxReconnectInterval)
+
+	feed.MQTTConfig.ClientID = "custom"
+	opts, err = mqttClientOptions(feed, "sample")
+	require.NoError(t, err)
+	assert.Equal(t, "custom-sample", opts.ClientID)
+}
+
+func TestMQTTClientOptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *models.MQTTConfig
+	}{
+		{name: "missing config", cfg: nil},
+		{name: "missing topic", cfg: &models.MQTTConfig{}},
+		{name: "invalid qos", cfg: &models.MQTTConfig{Topic: "t", QoS: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mqttClientOptions(models.WebSocketFeed{URL: "tcp://localhost:1883", MQTTConfig: tt.cfg}, "live")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// PauseFeed stops a paused feed's upstream connection, on whichever instance
+// holds it, and tells its subscribers with feed-paused
+func (m *Manager) PauseFeed(feedID string) {
+	m.StopFeed(feedID)
+	m.reportPaused(feedID)
+}
+
+// ResumeFeed tells a resumed feed's subscribers with feed-resumed and
+// reconnects it when it has subscribers here. Other instances pick it up
+// for their subscribers when they next look for orphaned feeds.
+func (m *Manager) ResumeFeed(feed models.WebSocketFeed) error {
+	feedID := feed.ID.Hex()
+	room := dataRoom(feedID)
+	msg := makeMessage("feed-resumed", feedStatus{FeedID: feedID, Status: "resumed", Message: "feed resumed"})
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
+	if m.subscriberCount(feedID) == 0 {
+		return nil
+	}
+	return m.ConnectFeed(feed)
+}
+
+// reportPaused sends a feed-paused event to the feed's data subscribers on every instance
+func (m *Manager) reportPaused(feedID string) {
+	room := dataRoom(feedID)
+	msg := makeMessage("feed-paused", feedStatus{FeedID: feedID, Status: "paused", Message: "feed is paused"})
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
+}
+
+// stopPausedFeed stops the local connection of a feed another instance paused
+func (m *Manager) stopPausedFeed(payload json.RawMessage) {
+	var status feedStatus
+	if err := json.Unmarshal(payload, &status); err == nil && status.FeedID != "" {
+		m.StopFeed(status.FeedID)
+	}
+}
+
+// feedActive reports whether the feed may still be connected. Reconnects
+// check it so a feed paused while its connection was down stays down.
+func (m *Manager) feedActive(feedID string) bool {
+	if m.marketplace == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	feed, err := m.marketplace.GetFeedByID(ctx, feedID)
+	if errors.Is(err, services.ErrFeedNotFound) {
+		return false
+	}
+	// Keep retrying through a database hiccup
+	return err != nil || feed.IsActive
+}
@@ -0,0 +1,87 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// nextStatus returns the next event of type typ the client received
+func nextStatus(t *testing.T, client *Client, typ string) feedStatus {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-client.out:
+			if msg.Type != typ {
+				continue
+			}
+			var status feedStatus
+			require.NoError(t, json.Unmarshal(msg.Payload, &status))
+			return status
+		case <-deadline:
+			t.Fatalf("no %s event", typ)
+		}
+	}
+}
+
+func TestManager_PauseAndResumeFeed(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 256)}
+
+	feed := models.WebSocketFeed{
+		ID:              primitive.NewObjectID(),
+		IsActive:        true,
+		ConnectionType:  connectionTypeSimulated,
+		SimulatedConfig: &models.SimulatedConfig{IntervalMs: 20},
+	}
+	feedID := feed.ID.Hex()
+	m.rooms.Join(dataRoom(feedID), client)
+	m.trackSubscriber(feedID, client)
+	require.NoError(t, m.ConnectFeed(feed))
+	defer m.StopFeed(feedID)
+
+	m.PauseFeed(feedID)
+	status := nextStatus(t, client, "feed-paused")
+	assert.Equal(t, feedID, status.FeedID)
+	assert.Equal(t, "paused", status.Status)
+	// Wait for the generator to exit, not just be told to
+	require.Eventually(t, func() bool {
+		m.feedMu.Lock()
+		defer m.feedMu.Unlock()
+		_, ok := m.feedConns[feedID]
+		return !ok
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, m.ResumeFeed(feed))
+	assert.Equal(t, "resumed", nextStatus(t, client, "feed-resumed").Status)
+	assert.True(t, m.IsFeedConnected(feedID), "a resumed feed with subscribers reconnects")
+}
+
+func TestManager_HandleClusterPause(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetCluster(newTestRedisCluster(t, miniredis.RunT(t)))
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), ConnectionType: connectionTypeSimulated}
+	feedID := feed.ID.Hex()
+	require.NoError(t, m.ConnectFeed(feed))
+	defer m.StopFeed(feedID)
+
+	payload, err := json.Marshal(feedStatus{FeedID: feedID, Status: "paused"})
+	require.NoError(t, err)
+	data, err := json.Marshal(clusterEnvelope{Origin: "remote", Room: dataRoom(feedID), Type: "feed-paused", Payload: payload})
+	require.NoError(t, err)
+	m.handleClusterMessage(data)
+
+	require.Eventually(t, func() bool { return !m.IsFeedConnected(feedID) }, 2*time.Second, 10*time.Millisecond,
+		"the instance holding the upstream stops it when another instance pauses the feed")
+}
@@ -0,0 +1,250 @@
+package socket
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeHTTPPolling is the feed connection type for periodically polled HTTP endpoints
+const connectionTypeHTTPPolling = "http-polling"
+
+const (
+	// pollDefaultInterval is used when a feed has no polling interval configured
+	pollDefaultInterval = 5 * time.Second
+	// pollMinInterval keeps a misconfigured feed from hammering its upstream
+	pollMinInterval = 100 * time.Millisecond
+	// pollDefaultTimeout bounds a single poll when the feed has no timeout configured
+	pollDefaultTimeout = 10 * time.Second
+	// pollMaxResponseBytes caps how much of a response body is read
+	pollMaxResponseBytes = 10 << 20
+)
+
+var pollHTTPClient = &http.Client{}
+
+// pollSettings returns the feed's polling interval and request timeout.
+// Both are configured in milliseconds.
+func pollSettings(cfg *models.HTTPPollingConfig) (interval, timeout time.Duration) {
+	interval, timeout = pollDefaultInterval, pollDefaultTimeout
+	if cfg == nil {
+		return interval, timeout
+	}
+	if cfg.PollingInterval > 0 {
+		interval = time.Duration(cfg.PollingInterval) * time.Millisecond
+	}
+	if interval < pollMinInterval {
+		interval = pollMinInterval
+	}
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Millisecond
+	}
+	return interval, timeout
+}
+
+// pollFeed makes one request to the feed's endpoint and returns the payload
+// selected by its DataPath. JSON responses are decoded; responseFormat "text"
+// returns the body as a string.
+func pollFeed(ctx context.Context, feed models.WebSocketFeed) (interface{}, error) {
+	target, headers, err := feedTarget(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := feed.HTTPConfig
+	if cfg == nil {
+		cfg = &models.HTTPPollingConfig{}
+	}
+	_, timeout := pollSettings(cfg)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if cfg.RequestBody != "" {
+		body = strings.NewReader(cfg.RequestBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	for k, v := range cfg.RequestHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := pollHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, pollMaxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if strings.EqualFold(cfg.ResponseFormat, "text") {
+		return string(data), nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &decoded); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return extractDataPath(decoded, cfg.DataPath)
+}
+
+// extractDataPath selects part of a decoded JSON document with a small
+// JSONPath subset: "$.data.items[0].price", "data.items.0", or "items[*].id"
+// to collect a field from every array element (elements without it are
+// skipped). An empty path or "$" returns the whole document.
+func extractDataPath(data interface{}, path string) (interface{}, error) {
+	segments := splitDataPath(path)
+	for i, seg := range segments {
+		switch node := data.(type) {
+		case map[string]interface{}:
+			v, ok := node[seg]
+			if !ok {
+				return nil, fmt.Errorf("data path %q: key %q not found", path, seg)
+			}
+			data = v
+		case []interface{}:
+			if seg == "*" {
+				rest := strings.Join(segments[i+1:], ".")
+				out := make([]interface{}, 0, len(node))
+				for _, item := range node {
+					v, err := extractDataPath(item, rest)
+					if err != nil {
+						continue
+					}
+					out = append(out, v)
+				}
+				return out, nil
+			}
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("data path %q: index %q out of range", path, seg)
+			}
+			data = node[idx]
+		default:
+			return nil, fmt.Errorf("data path %q: cannot descend into %q", path, seg)
+		}
+	}
+	return data, nil
+}
+
+// splitDataPath turns "$.a.b[0]" into ["a", "b", "0"]
+func splitDataPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	var segments []string
+	for _, seg := range strings.Split(path, ".") {
+		if seg = strings.Trim(seg, `"'`); seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return segments
+}
+
+// payloadDigest fingerprints a payload so unchanged poll results can be skipped
+func payloadDigest(data interface{}) [sha256.Size]byte {
+	// json.Marshal sorts map keys, so equal payloads encode identically
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%v", data))
+	}
+	return sha256.Sum256(encoded)
+}
+
+// connectPollingFeed polls the feed once to validate it, then keeps polling
+// on its interval and broadcasts payloads that changed.
+func (m *Manager) connectPollingFeed(feed models.WebSocketFeed) error {
+	data, err := pollFeed(context.Background(), feed)
+	if err != nil {
+		slog.Warn("failed to poll feed", "feed_id", feed.ID.Hex(), "error", err)
+		return err
+	}
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "http polling")
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{stop: stop}
+	m.feedMu.Unlock()
+
+	// Digest before broadcasting: the LLM context stamps the payload map
+	digest := payloadDigest(data)
+	m.BroadcastFeedData(feed, data, feed.EventName)
+	go m.pollLoop(feed, digest, stop)
+	return nil
+}
+
+// pollLoop polls the feed until it is stopped, skipping payloads identical to the last one broadcast.
+func (m *Manager) pollLoop(feed models.WebSocketFeed, lastDigest [sha256.Size]byte, stop chan struct{}) {
+	defer func() {
+		m.feedClosed(feed)
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	interval, _ := pollSettings(feed.HTTPConfig)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
+			return
+		case <-ticker.C:
+			data, err := pollFeed(ctx, feed)
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				// Log the first failure of a run rather than every tick
+				if !failing {
+					slog.Warn("feed poll failed", "feed_id", feed.ID.Hex(), "error", err)
+				}
+				failing = true
+				m.recordFeedError(feed.ID.Hex(), err)
+				continue
+			}
+			if failing {
+				slog.Info("feed polling recovered", "feed_id", feed.ID.Hex())
+				failing = false
+			}
+
+			digest := payloadDigest(data)
+			if digest == lastDigest {
+				continue
+			}
+			lastDigest = digest
+			m.BroadcastFeedData(feed, data, feed.EventName)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package socket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestExtractDataPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"id": "a", "price": 1.0},
+				map[string]interface{}{"id": "b"},
+				map[string]interface{}{"price": 3.0},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected interface{}
+		wantErr  bool
+	}{
+		{name: "empty path returns document", path: "", expected: doc},
+		{name: "root", path: "$", expected: doc},
+		{name: "bracket index", path: "$.data.items[0].price", expected: 1.0},
+		{name: "dotted index", path: "data.items.1.id", expected: "b"},
+		{name: "quoted key", path: "$['data'].items[1].id", expected: "b"},
+		{name: "wildcard skips elements without the field", path: "data.items[*].price", expected: []interface{}{1.0, 3.0}},
+		{name: "missing key", path: "data.missing", wantErr: true},
+		{name: "index out of range", path: "data.items[5]", wantErr: true},
+		{name: "descend into scalar", path: "data.items[0].price.value", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractDataPath(doc, tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPollSettings(t *testing.T) {
+	interval, timeout := pollSettings(nil)
+	assert.Equal(t, pollDefaultInterval, interval)
+	assert.Equal(t, pollDefaultTimeout, timeout)
+
+	interval, timeout = pollSettings(&models.HTTPPollingConfig{PollingInterval: 2000, Timeout: 500})
+	assert.Equal(t, 2*time.Second, interval)
+	assert.Equal(t, 500*time.Millisecond, timeout)
+
+	interval, _ = pollSettings(&models.HTTPPollingConfig{PollingInterval: 1})
+	assert.Equal(t, pollMinInterval, interval)
+}
+
+func TestManager_ConnectFeed_HTTPPollingDedupes(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-Key"))
+		n := requests.Add(1)
+		price := 1
+		if n >= 3 {
+			price = 2
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"result":{"price":%d}}`, price)
+	}))
+	defer srv.Close()
+
+	llm, err := services.NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	m := NewManager(nil, nil, nil, nil)
+	m.SetLLMService(llm)
+
+	feed := models.WebSocketFeed{
+		ID:             primitive.NewObjectID(),
+		Name:           "Polled",
+		URL:            srv.URL,
+		ConnectionType: connectionTypeHTTPPolling,
+		HTTPConfig: &models.HTTPPollingConfig{
+			Method:          "post",
+			PollingInterval: 100,
+			RequestHeaders:  map[string]string{"X-Key": "secret"},
+			DataPath:        "$.result",
+		},
+	}
+	require.NoError(t, m.ConnectFeed(feed))
+	defer m.StopFeed(feed.ID.Hex())
+
+	require.Eventually(t, func() bool { return requests.Load() >= 4 }, 2*time.Second, 10*time.Millisecond)
+
+	// Two distinct payloads were served, so only two entries reach the context
+	entries := contextEntries(llm, feed.ID.Hex())
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2.0, entries[0]["price"])
+	assert.Equal(t, 1.0, entries[1]["price"])
+}
@@ -0,0 +1,106 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// ownerRoom holds the feed owner's clients watching its live subscribers
+func ownerRoom(feedID string) string {
+	return "owner:" + feedID
+}
+
+// LiveSubscribers counts the clients connected to this instance that are
+// subscribed to a feed's data, as opposed to the stored SubscriberCount
+type LiveSubscribers struct {
+	FeedID      string `json:"feedId"`
+	Connections int    `json:"connections"`
+	// Users counts distinct identified users; anonymous connections only
+	// count as connections
+	Users int `json:"users"`
+}
+
+// LiveSubscribers returns the feed's live subscriber counts
+func (m *Manager) LiveSubscribers(feedID string) LiveSubscribers {
+	m.subscriberMu.RLock()
+	defer m.subscriberMu.RUnlock()
+	live := LiveSubscribers{FeedID: feedID, Connections: len(m.subscribers[feedID])}
+	users := make(map[string]struct{})
+	for client := range m.subscribers[feedID] {
+		if client.userID != "" {
+			users[client.userID] = struct{}{}
+		}
+	}
+	live.Users = len(users)
+	return live
+}
+
+// presenceMessage is the subscriber-count-changed event for a feed; change
+// is +1 or -1 for a join or leave and 0 for the current counts
+func (m *Manager) presenceMessage(feedID string, change int) WSMessage {
+	live := m.LiveSubscribers(feedID)
+	return makeMessage("subscriber-count-changed", map[string]interface{}{
+		"feedId":      feedID,
+		"connections": live.Connections,
+		"users":       live.Users,
+		"change":      change,
+		"timestamp":   time.Now().UTC(),
+	})
+}
+
+// notifyPresence tells the feed's owner its live subscribers changed
+func (m *Manager) notifyPresence(feedID string, change int) {
+	m.rooms.Broadcast(ownerRoom(feedID), m.presenceMessage(feedID, change))
+}
+
+// untrackAllSubscriptions drops a disconnected client from every feed it subscribed to
+func (m *Manager) untrackAllSubscriptions(client *Client) {
+	m.subscriberMu.RLock()
+	var feedIDs []string
+	for feedID, clients := range m.subscribers {
+		if _, ok := clients[client]; ok {
+			feedIDs = append(feedIDs, feedID)
+		}
+	}
+	m.subscriberMu.RUnlock()
+
+	for _, feedID := range feedIDs {
+		m.untrackSubscriber(feedID, client)
+	}
+}
+
+// handleSubscribePresence lets a feed's owner watch its live subscribers
+func (m *Manager) handleSubscribePresence(client *Client, raw json.RawMessage) {
+	var payload struct {
+		FeedID string `json:"feedId"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.FeedID == "" {
+		client.send(makeMessage("subscription-error", map[string]string{"error": "invalid payload"}))
+		return
+	}
+	if client.userID == "" || m.marketplace == nil {
+		client.send(makeMessage("subscription-error", map[string]string{"feedId": payload.FeedID, "error": "only the feed owner can watch its subscribers"}))
+		return
+	}
+	ctx, cancel := context.WithTimeout(client.ctx, 5*time.Second)
+	defer cancel()
+	feed, err := m.marketplace.GetFeedByID(ctx, payload.FeedID)
+	if err != nil {
+		slog.WarnContext(client.ctx, "failed to load feed for presence", "feed_id", payload.FeedID, "error", err)
+		client.send(makeMessage("subscription-error", map[string]string{"feedId": payload.FeedID, "error": "feed not found"}))
+		return
+	}
+	manages, err := m.marketplace.CanManageFeed(ctx, feed, client.userID)
+	if err != nil {
+		slog.WarnContext(client.ctx, "failed to check feed ownership for presence", "feed_id", payload.FeedID, "error", err)
+	}
+	if !manages {
+		client.send(makeMessage("subscription-error", map[string]string{"feedId": payload.FeedID, "error": "only the feed owner can watch its subscribers"}))
+		return
+	}
+	m.rooms.Join(ownerRoom(payload.FeedID), client)
+	client.send(makeMessage("subscription-success", map[string]string{"feedId": payload.FeedID, "type": "presence"}))
+	client.send(m.presenceMessage(payload.FeedID, 0))
+}
@@ -0,0 +1,66 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_PresenceCounts(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newClient := func(userID string) *Client {
+		return &Client{ctx: ctx, cancel: cancel, userID: userID, out: make(chan WSMessage, 8)}
+	}
+	owner := newClient("owner")
+	m.rooms.Join(ownerRoom("feed-1"), owner)
+
+	type presence struct {
+		FeedID      string `json:"feedId"`
+		Connections int    `json:"connections"`
+		Users       int    `json:"users"`
+		Change      int    `json:"change"`
+	}
+	next := func() presence {
+		msg := <-owner.out
+		require.Equal(t, "subscriber-count-changed", msg.Type)
+		var p presence
+		require.NoError(t, json.Unmarshal(msg.Payload, &p))
+		return p
+	}
+
+	alice, alice2, anon := newClient("alice"), newClient("alice"), newClient("")
+	m.handleMessage(alice, WSMessage{Type: "subscribe-feed", Payload: json.RawMessage(`{"feedId":"feed-1"}`)})
+	assert.Equal(t, presence{FeedID: "feed-1", Connections: 1, Users: 1, Change: 1}, next())
+	m.trackSubscriber("feed-1", alice2)
+	m.trackSubscriber("feed-1", anon)
+	next()
+	assert.Equal(t, presence{FeedID: "feed-1", Connections: 3, Users: 1, Change: 1}, next())
+
+	// Re-subscribing doesn't count twice
+	m.trackSubscriber("feed-1", anon)
+	assert.Empty(t, owner.out)
+	assert.Equal(t, LiveSubscribers{FeedID: "feed-1", Connections: 3, Users: 1}, m.LiveSubscribers("feed-1"))
+
+	m.handleMessage(alice, WSMessage{Type: "unsubscribe-feed", Payload: json.RawMessage(`{"feedId":"feed-1"}`)})
+	assert.Equal(t, presence{FeedID: "feed-1", Connections: 2, Users: 1, Change: -1}, next())
+
+	// A disconnecting client leaves every feed it subscribed to
+	m.untrackAllSubscriptions(anon)
+	assert.Equal(t, presence{FeedID: "feed-1", Connections: 1, Users: 1, Change: -1}, next())
+}
+
+func TestManager_SubscribePresenceRequiresOwner(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 2)}
+
+	m.handleMessage(client, WSMessage{Type: "subscribe-presence", Payload: json.RawMessage(`{"feedId":"feed-1"}`)})
+	assert.Equal(t, "subscription-error", (<-client.out).Type)
+	assert.Empty(t, m.rooms.Rooms(client))
+}
@@ -0,0 +1,149 @@
+package socket
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Connection tests read a feed for DefaultProbeWindow unless asked otherwise,
+// never longer than MaxProbeWindow, and keep the first probeSampleLimit
+// messages
+const (
+	DefaultProbeWindow = 5 * time.Second
+	MaxProbeWindow     = 30 * time.Second
+	probeSampleLimit   = 5
+)
+
+// Formats detected in probed messages, besides the data formats
+const (
+	probeFormatText   = "text"
+	probeFormatBinary = "binary"
+	probeFormatMixed  = "mixed"
+)
+
+// FeedProbe is what a connection test saw of a feed before it is saved
+type FeedProbe struct {
+	Samples           []interface{}      `json:"samples"`                // decoded messages; binary ones are base64
+	DataFormat        string             `json:"dataFormat,omitempty"`   // json, msgpack, text or binary, or mixed when messages differ
+	MessageCount      int                `json:"messageCount"`           // messages read, including ones not kept as samples
+	MessagesPerSecond float64            `json:"messagesPerSecond"`      // over the time spent reading
+	DurationMs        int64              `json:"durationMs"`             // time spent reading
+	Subscription      *ProbeSubscription `json:"subscription,omitempty"` // set when the feed has connection messages
+	Error             string             `json:"error,omitempty"`        // why reading stopped early, e.g. the upstream closed
+}
+
+// ProbeSubscription is the round-trip of a feed's connection messages
+type ProbeSubscription struct {
+	Sent    int    `json:"sent"`    // messages written before the first failure
+	Replied bool   `json:"replied"` // a message arrived after they were sent
+	Error   string `json:"error,omitempty"`
+}
+
+// ProbeFeed dials a websocket feed, sends its connection messages and reads
+// it until window passes or probeSampleLimit messages arrive. Only failing
+// to connect is an error; the probe reports everything after that.
+func ProbeFeed(ctx context.Context, feed models.WebSocketFeed, window time.Duration) (*FeedProbe, error) {
+	if !isWebSocketFeed(feed) {
+		return nil, ErrFeedTypeUnsupported
+	}
+	if window <= 0 {
+		window = DefaultProbeWindow
+	}
+	if window > MaxProbeWindow {
+		window = MaxProbeWindow
+	}
+	conn, err := dialFeed(feed)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	probe := &FeedProbe{Samples: []interface{}{}}
+	messages := feed.ConnectionMessages
+	if feed.ConnectionMessage != "" {
+		messages = append([]string{feed.ConnectionMessage}, messages...)
+	}
+	for _, msg := range messages {
+		if msg == "" {
+			continue
+		}
+		if probe.Subscription == nil {
+			probe.Subscription = &ProbeSubscription{}
+		}
+		if err := conn.WriteMessage(gws.TextMessage, []byte(msg)); err != nil {
+			probe.Subscription.Error = err.Error()
+			break
+		}
+		probe.Subscription.Sent++
+	}
+
+	start := time.Now()
+	deadline := start.Add(window)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	for probe.MessageCount < probeSampleLimit {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				probe.Error = err.Error()
+			}
+			break
+		}
+		probe.MessageCount++
+		format, value := probeMessage(msgType, msg)
+		probe.Samples = append(probe.Samples, value)
+		switch probe.DataFormat {
+		case "", format:
+			probe.DataFormat = format
+		default:
+			probe.DataFormat = probeFormatMixed
+		}
+	}
+	elapsed := time.Since(start)
+	probe.DurationMs = elapsed.Milliseconds()
+	if elapsed > 0 {
+		probe.MessagesPerSecond = float64(probe.MessageCount) / elapsed.Seconds()
+	}
+	if probe.Subscription != nil {
+		probe.Subscription.Replied = probe.Subscription.Sent > 0 && probe.MessageCount > 0
+		if probe.Subscription.Error == "" && !probe.Subscription.Replied {
+			probe.Subscription.Error = "no messages arrived after the connection messages"
+		}
+	}
+	return probe, nil
+}
+
+// probeMessage detects a message's format and decodes it. Binary messages
+// are tried as JSON, then as a MessagePack map or array, and otherwise
+// returned as base64.
+func probeMessage(msgType int, msg []byte) (string, interface{}) {
+	var value interface{}
+	if err := json.Unmarshal(msg, &value); err == nil {
+		return dataFormatJSON, value
+	}
+	if msgType == gws.TextMessage {
+		return probeFormatText, string(msg)
+	}
+	if decode, err := newMsgpackDecoder(models.WebSocketFeed{}); err == nil {
+		if value, err := decode(msg); err == nil {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				return dataFormatMsgpack, value
+			}
+		}
+	}
+	return probeFormatBinary, base64.StdEncoding.EncodeToString(msg)
+}
@@ -0,0 +1,118 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// probeServer upgrades each connection and hands it to serve
+func probeServer(t *testing.T, serve func(conn *gws.Conn)) string {
+	upgrader := gws.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestProbeFeed_SamplesAfterSubscription(t *testing.T) {
+	url := probeServer(t, func(conn *gws.Conn) {
+		_, msg, err := conn.ReadMessage()
+		if err != nil || string(msg) != `{"op":"subscribe"}` {
+			return
+		}
+		for i := 0; i < 8; i++ {
+			_ = conn.WriteMessage(gws.TextMessage, []byte(`{"price":100}`))
+		}
+		time.Sleep(time.Second)
+	})
+
+	probe, err := ProbeFeed(context.Background(), models.WebSocketFeed{URL: url, ConnectionMessages: []string{`{"op":"subscribe"}`}}, time.Second)
+	require.NoError(t, err)
+	assert.Len(t, probe.Samples, probeSampleLimit)
+	assert.Equal(t, map[string]interface{}{"price": 100.0}, probe.Samples[0])
+	assert.Equal(t, dataFormatJSON, probe.DataFormat)
+	assert.Equal(t, probeSampleLimit, probe.MessageCount)
+	assert.Greater(t, probe.MessagesPerSecond, 0.0)
+	require.NotNil(t, probe.Subscription)
+	assert.Equal(t, 1, probe.Subscription.Sent)
+	assert.True(t, probe.Subscription.Replied)
+	assert.Empty(t, probe.Error)
+}
+
+func TestProbeFeed_NoReply(t *testing.T) {
+	url := probeServer(t, func(conn *gws.Conn) {
+		_, _, _ = conn.ReadMessage()
+		time.Sleep(time.Second)
+	})
+
+	probe, err := ProbeFeed(context.Background(), models.WebSocketFeed{URL: url, ConnectionMessage: "subscribe"}, 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, probe.Samples)
+	assert.Empty(t, probe.DataFormat)
+	assert.Empty(t, probe.Error, "the window passing is not an error")
+	require.NotNil(t, probe.Subscription)
+	assert.Equal(t, 1, probe.Subscription.Sent)
+	assert.False(t, probe.Subscription.Replied)
+	assert.NotEmpty(t, probe.Subscription.Error)
+}
+
+func TestProbeFeed_UpstreamCloses(t *testing.T) {
+	url := probeServer(t, func(conn *gws.Conn) {
+		_ = conn.WriteMessage(gws.TextMessage, []byte("hello"))
+	})
+
+	probe, err := ProbeFeed(context.Background(), models.WebSocketFeed{URL: url}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"hello"}, probe.Samples)
+	assert.Equal(t, probeFormatText, probe.DataFormat)
+	assert.Nil(t, probe.Subscription)
+	assert.NotEmpty(t, probe.Error)
+}
+
+func TestProbeFeed_Unsupported(t *testing.T) {
+	_, err := ProbeFeed(context.Background(), models.WebSocketFeed{ConnectionType: connectionTypeSSE, URL: "http://example.com"}, time.Second)
+	assert.ErrorIs(t, err, ErrFeedTypeUnsupported)
+}
+
+func TestProbeMessage(t *testing.T) {
+	var packed []byte
+	require.NoError(t, codec.NewEncoderBytes(&packed, &codec.MsgpackHandle{}).Encode(map[string]interface{}{"price": 1}))
+
+	tests := []struct {
+		name    string
+		msgType int
+		msg     []byte
+		format  string
+		value   interface{}
+	}{
+		{"json text", gws.TextMessage, []byte(`[1,2]`), dataFormatJSON, []interface{}{1.0, 2.0}},
+		{"plain text", gws.TextMessage, []byte("pong"), probeFormatText, "pong"},
+		{"json binary", gws.BinaryMessage, []byte(`{"a":true}`), dataFormatJSON, map[string]interface{}{"a": true}},
+		{"msgpack", gws.BinaryMessage, packed, dataFormatMsgpack, map[string]interface{}{"price": 1.0}},
+		{"other binary", gws.BinaryMessage, []byte{0x01}, probeFormatBinary, "AQ=="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, value := probeMessage(tt.msgType, tt.msg)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+}
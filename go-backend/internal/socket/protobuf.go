@@ -0,0 +1,87 @@
+package socket
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeProtobuf is a websocket feed whose frames are protobuf
+// messages; other feeds can set DataFormat to "protobuf" instead
+const connectionTypeProtobuf = "protobuf"
+
+// maxProtobufDescriptorLen caps the size of an uploaded descriptor set (base64)
+const maxProtobufDescriptorLen = 1 << 20
+
+// protobufJSON renders decoded messages with the field names from the .proto file
+var protobufJSON = protojson.MarshalOptions{UseProtoNames: true}
+
+// newProtobufDecoder decodes protobuf payloads as the feed's ProtobufType
+func newProtobufDecoder(feed models.WebSocketFeed) (payloadDecoder, error) {
+	desc, err := protobufMessageDescriptor(feed.ProtobufDescriptor, feed.ProtobufType)
+	if err != nil {
+		return nil, err
+	}
+	return func(msg []byte) (interface{}, error) { return decodeProtobuf(desc, msg) }, nil
+}
+
+// protobufMessageDescriptor finds messageType in a base64-encoded
+// FileDescriptorSet, as written by protoc --include_imports --descriptor_set_out
+func protobufMessageDescriptor(descriptor, messageType string) (protoreflect.MessageDescriptor, error) {
+	messageType = strings.TrimPrefix(strings.TrimSpace(messageType), ".")
+	if descriptor == "" || messageType == "" {
+		return nil, errors.New("protobuf feeds require protobufDescriptor and protobufType")
+	}
+	if len(descriptor) > maxProtobufDescriptorLen {
+		return nil, fmt.Errorf("protobufDescriptor is larger than %d bytes", maxProtobufDescriptorLen)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(descriptor))
+	if err != nil {
+		return nil, errors.New("protobufDescriptor must be a base64-encoded FileDescriptorSet")
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("protobufDescriptor is not a FileDescriptorSet: %w", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobufDescriptor (build it with --include_imports): %w", err)
+	}
+	found, err := files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("protobufType %q is not defined in protobufDescriptor", messageType)
+	}
+	desc, ok := found.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("protobufType %q is not a message", messageType)
+	}
+	return desc, nil
+}
+
+// decodeProtobuf decodes a binary protobuf message to the JSON value subscribers receive
+func decodeProtobuf(desc protoreflect.MessageDescriptor, msg []byte) (interface{}, error) {
+	m := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(msg, m); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", desc.FullName(), err)
+	}
+	encoded, err := protobufJSON.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,144 @@
+package socket
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// tickDescriptor returns a base64 FileDescriptorSet defining market.Tick
+func tickDescriptor(t *testing.T) string {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("market.proto"),
+		Package: proto.String("market"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Tick"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("symbol", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("last_price", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE),
+			},
+		}},
+	}}}
+	raw, err := proto.Marshal(set)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// encodeTick builds a binary market.Tick message
+func encodeTick(t *testing.T, desc protoreflect.MessageDescriptor, symbol string, price float64) []byte {
+	t.Helper()
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("symbol"), protoreflect.ValueOfString(symbol))
+	msg.Set(desc.Fields().ByName("last_price"), protoreflect.ValueOfFloat64(price))
+	raw, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestProtobufMessageDescriptor(t *testing.T) {
+	descriptor := tickDescriptor(t)
+
+	desc, err := protobufMessageDescriptor(descriptor, ".market.Tick")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("market.Tick"), desc.FullName())
+
+	tests := []struct {
+		name        string
+		descriptor  string
+		messageType string
+		wantErr     string
+	}{
+		{"missing type", descriptor, "", "require protobufDescriptor and protobufType"},
+		{"missing descriptor", "", "market.Tick", "require protobufDescriptor and protobufType"},
+		{"not base64", "%%%", "market.Tick", "base64"},
+		{"not a descriptor set", base64.StdEncoding.EncodeToString([]byte{0xff, 0xff}), "market.Tick", "not a FileDescriptorSet"},
+		{"unknown type", descriptor, "market.Quote", "not defined"},
+		{"field is not a message", descriptor, "market.Tick.symbol", "not a message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := protobufMessageDescriptor(tt.descriptor, tt.messageType)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateFeedDataFormat_Protobuf(t *testing.T) {
+	assert.NoError(t, ValidateFeedDataFormat(models.WebSocketFeed{ConnectionType: "websocket"}))
+	assert.Error(t, ValidateFeedDataFormat(models.WebSocketFeed{ConnectionType: connectionTypeProtobuf}))
+	assert.NoError(t, ValidateFeedDataFormat(models.WebSocketFeed{
+		ConnectionType:     connectionTypeProtobuf,
+		ProtobufDescriptor: tickDescriptor(t),
+		ProtobufType:       "market.Tick",
+	}))
+}
+
+func TestNewPayloadDecoder_Protobuf(t *testing.T) {
+	feed := models.WebSocketFeed{ConnectionType: connectionTypeProtobuf, ProtobufDescriptor: tickDescriptor(t), ProtobufType: "market.Tick"}
+	decode, err := newPayloadDecoder(feed)
+	require.NoError(t, err)
+
+	desc, err := protobufMessageDescriptor(feed.ProtobufDescriptor, feed.ProtobufType)
+	require.NoError(t, err)
+	data, err := decode(encodeTick(t, desc, "BTC", 42.5))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"symbol": "BTC", "last_price": 42.5}, data)
+
+	_, err = decode([]byte{0xff, 0xff, 0xff})
+	assert.Error(t, err)
+
+	jsonDecode, err := newPayloadDecoder(models.WebSocketFeed{})
+	require.NoError(t, err)
+	data, err = jsonDecode([]byte(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1.0}, data)
+}
+
+func TestCaptureFeedSample_Protobuf(t *testing.T) {
+	feed := models.WebSocketFeed{ConnectionType: connectionTypeProtobuf, ProtobufDescriptor: tickDescriptor(t), ProtobufType: "market.Tick"}
+	desc, err := protobufMessageDescriptor(feed.ProtobufDescriptor, feed.ProtobufType)
+	require.NoError(t, err)
+	frame := encodeTick(t, desc, "ETH", 3000)
+
+	upgrader := gws.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(gws.BinaryMessage, frame)
+	}))
+	defer server.Close()
+	feed.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+
+	m := NewManager(nil, nil, nil, nil)
+	data, err := m.CaptureFeedSample(context.Background(), feed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"symbol": "ETH", "last_price": 3000.0}, data)
+}
@@ -0,0 +1,133 @@
+package socket
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Protocol versions. Clients that never send hello speak version 1, the
+// protocol from before the handshake.
+const (
+	ProtocolVersion    = 2
+	minProtocolVersion = 1
+)
+
+// Features a client can ask for in its hello
+const (
+	featureCompression = "compression" // permessage-deflate on the connection
+	featureBinary      = "binary"      // binary-encoded feed-data frames
+	featureReplay      = "replay"      // recent feed-data sent on subscribe
+)
+
+// serverFeatures are the features this server can turn on for a client
+var serverFeatures = map[string]bool{
+	featureBinary: true,
+	featureReplay: true,
+}
+
+// legacyFeatures are the features of clients that never sent hello
+var legacyFeatures = map[string]bool{
+	featureReplay: true,
+}
+
+// clientProtocol is what a client agreed to in its hello
+type clientProtocol struct {
+	version  int
+	features map[string]bool
+}
+
+// negotiateProtocol agrees on the highest version both sides speak and the
+// requested features available to the client; ok is false when the client's
+// version is too old
+func negotiateProtocol(version int, requested []string, available map[string]bool) (clientProtocol, bool) {
+	if version < minProtocolVersion {
+		return clientProtocol{}, false
+	}
+	agreed := clientProtocol{version: min(version, ProtocolVersion), features: make(map[string]bool)}
+	for _, feature := range requested {
+		if available[feature] {
+			agreed.features[feature] = true
+		}
+	}
+	return agreed, true
+}
+
+// featureList returns the agreed features in a stable order
+func (p clientProtocol) featureList() []string {
+	list := make([]string, 0, len(p.features))
+	for feature := range p.features {
+		list = append(list, feature)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// availableFeatures are the features the client may ask for: the server's,
+// plus compression when its connection uses permessage-deflate
+func (c *Client) availableFeatures() map[string]bool {
+	if !c.deflate {
+		return serverFeatures
+	}
+	available := map[string]bool{featureCompression: true}
+	for feature := range serverFeatures {
+		available[feature] = true
+	}
+	return available
+}
+
+// setProtocol records what the client agreed to
+func (c *Client) setProtocol(p clientProtocol) {
+	c.protocolMu.Lock()
+	defer c.protocolMu.Unlock()
+	c.protocol = &p
+}
+
+// protocolVersion returns the client's agreed protocol version
+func (c *Client) protocolVersion() int {
+	c.protocolMu.RLock()
+	defer c.protocolMu.RUnlock()
+	if c.protocol == nil {
+		return minProtocolVersion
+	}
+	return c.protocol.version
+}
+
+// hasFeature reports whether the client agreed to a feature; clients that
+// never sent hello keep the features older clients relied on
+func (c *Client) hasFeature(feature string) bool {
+	c.protocolMu.RLock()
+	defer c.protocolMu.RUnlock()
+	if c.protocol == nil {
+		return legacyFeatures[feature]
+	}
+	return c.protocol.features[feature]
+}
+
+// handleHello answers a client's hello with the version and features agreed
+func (m *Manager) handleHello(client *Client, raw json.RawMessage) {
+	var payload struct {
+		Version  int      `json:"version"`
+		Features []string `json:"features"`
+		Client   string   `json:"client"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		client.send(makeMessage("hello-error", map[string]string{"error": "invalid payload"}))
+		return
+	}
+	agreed, ok := negotiateProtocol(payload.Version, payload.Features, client.availableFeatures())
+	if !ok {
+		client.send(makeMessage("hello-error", map[string]interface{}{
+			"error":      "unsupported protocol version",
+			"minVersion": minProtocolVersion,
+			"maxVersion": ProtocolVersion,
+		}))
+		return
+	}
+	client.setProtocol(agreed)
+	client.send(makeMessage("hello", map[string]interface{}{
+		"version":    agreed.version,
+		"minVersion": minProtocolVersion,
+		"maxVersion": ProtocolVersion,
+		"features":   agreed.featureList(),
+	}))
+}
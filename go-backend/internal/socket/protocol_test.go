@@ -0,0 +1,54 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateProtocol(t *testing.T) {
+	_, ok := negotiateProtocol(0, nil, serverFeatures)
+	assert.False(t, ok)
+
+	agreed, ok := negotiateProtocol(ProtocolVersion+3, []string{featureReplay, featureCompression, "teleport"}, serverFeatures)
+	require.True(t, ok)
+	assert.Equal(t, ProtocolVersion, agreed.version)
+	assert.Equal(t, []string{featureReplay}, agreed.featureList())
+
+	agreed, ok = negotiateProtocol(1, nil, serverFeatures)
+	require.True(t, ok)
+	assert.Equal(t, 1, agreed.version)
+	assert.Empty(t, agreed.featureList())
+}
+
+func TestManager_HandleHello(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 4)}
+
+	// Before hello a client keeps the features older clients relied on
+	assert.Equal(t, minProtocolVersion, client.protocolVersion())
+	assert.True(t, client.hasFeature(featureReplay))
+	assert.False(t, client.hasFeature(featureBinary))
+
+	m.handleMessage(client, WSMessage{Type: "hello", Payload: json.RawMessage(`{"version":2,"features":["compression"],"client":"test"}`)})
+	msg := <-client.out
+	assert.Equal(t, "hello", msg.Type)
+	var payload struct {
+		Version  int      `json:"version"`
+		Features []string `json:"features"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, 2, payload.Version)
+	assert.Empty(t, payload.Features)
+	assert.Equal(t, 2, client.protocolVersion())
+	// Replay wasn't asked for, so it is off
+	assert.False(t, client.hasFeature(featureReplay))
+
+	m.handleMessage(client, WSMessage{Type: "hello", Payload: json.RawMessage(`{"version":0}`)})
+	assert.Equal(t, "hello-error", (<-client.out).Type)
+}
@@ -0,0 +1,66 @@
+package socket
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
+)
+
+// llmMessageTypes are the websocket messages that call an AI provider
+var llmMessageTypes = map[string]bool{
+	"llm-query":              true,
+	"llm-query-stream":       true,
+	"llm-conversation":       true,
+	"analyze-crypto":         true,
+	"analyze-universal-feed": true,
+}
+
+// SetRateLimits limits incoming websocket messages per user (or per IP before
+// the client identifies itself), with a further limit on AI queries
+func (m *Manager) SetRateLimits(limits ratelimit.Limits) {
+	m.limits = limits
+}
+
+// allowMessage reports whether the client may send msg now, sending a
+// rate-limit-exceeded event when it may not
+func (m *Manager) allowMessage(client *Client, msg WSMessage) bool {
+	scope, limiter, key := "ip", m.limits.IP, client.ip
+	if client.userID != "" {
+		scope, limiter, key = "user", m.limits.User, client.userID
+	}
+	ok, wait := limiter.Allow(key)
+	if ok && llmMessageTypes[msg.Type] {
+		scope = "llm"
+		ok, wait = m.limits.LLM.Allow(key)
+	}
+	if ok {
+		return true
+	}
+
+	metrics.RateLimited.WithLabelValues("ws_" + scope).Inc()
+	// Echo the request ID so clients waiting on an AI answer can stop waiting
+	var ids struct {
+		RequestID string `json:"requestId"`
+	}
+	_ = json.Unmarshal(msg.Payload, &ids)
+	client.send(makeMessage("rate-limit-exceeded", map[string]interface{}{
+		"type":         msg.Type,
+		"scope":        scope,
+		"requestId":    ids.RequestID,
+		"retryAfterMs": wait.Round(time.Millisecond).Milliseconds(),
+	}))
+	return false
+}
+
+// remoteIP returns the request's client address without the port
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,55 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/ratelimit"
+)
+
+func TestManager_AllowMessage(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetRateLimits(ratelimit.Limits{
+		IP:   ratelimit.New(60, 1),
+		User: ratelimit.New(60, 3),
+		LLM:  ratelimit.New(60, 1),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, ip: "10.0.0.1", out: make(chan WSMessage, 8)}
+
+	// Before identifying, the client is limited by IP
+	assert.True(t, m.allowMessage(client, WSMessage{Type: "ping"}))
+	assert.False(t, m.allowMessage(client, WSMessage{Type: "ping"}))
+
+	msg := <-client.out
+	require.Equal(t, "rate-limit-exceeded", msg.Type)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, "ip", payload["scope"])
+	assert.Equal(t, "ping", payload["type"])
+	assert.Greater(t, payload["retryAfterMs"], float64(0))
+
+	// Once registered, the user's own bucket applies, plus the LLM limit for AI queries
+	client.userID = "user-1"
+	assert.True(t, m.allowMessage(client, WSMessage{Type: "llm-query", Payload: json.RawMessage(`{"requestId":"r1"}`)}))
+	assert.False(t, m.allowMessage(client, WSMessage{Type: "llm-query", Payload: json.RawMessage(`{"requestId":"r2"}`)}))
+	msg = <-client.out
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, "llm", payload["scope"])
+	assert.Equal(t, "r2", payload["requestId"])
+
+	assert.True(t, m.allowMessage(client, WSMessage{Type: "ping"}), "other messages still pass")
+}
+
+func TestManager_AllowMessageWithoutLimits(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	client := &Client{ctx: context.Background(), ip: "10.0.0.1"}
+	for i := 0; i < 100; i++ {
+		assert.True(t, m.allowMessage(client, WSMessage{Type: "llm-query"}))
+	}
+}
@@ -0,0 +1,97 @@
+package socket
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+const (
+	defaultFragmentMaxBytes = 1 << 20 // 1 MiB
+	defaultFragmentTimeout  = 5 * time.Second
+)
+
+// fragment is a payload emitted by the reassembler. Complete is false when the
+// buffer was flushed because a size/time cap was hit before valid JSON formed.
+type fragment struct {
+	Data     []byte
+	Complete bool
+}
+
+// jsonReassembler buffers frames from feeds that split a JSON document across
+// multiple websocket messages, emitting the document once it parses.
+type jsonReassembler struct {
+	buf      []byte
+	started  time.Time
+	maxBytes int
+	timeout  time.Duration
+}
+
+func newJSONReassembler(maxBytes int, timeout time.Duration) *jsonReassembler {
+	if maxBytes <= 0 {
+		maxBytes = defaultFragmentMaxBytes
+	}
+	if timeout <= 0 {
+		timeout = defaultFragmentTimeout
+	}
+	return &jsonReassembler{maxBytes: maxBytes, timeout: timeout}
+}
+
+// Push adds a frame and returns any payloads that are ready for broadcast.
+func (r *jsonReassembler) Push(frame []byte, now time.Time) []fragment {
+	var out []fragment
+
+	if len(r.buf) == 0 {
+		// Fast path: whole documents and non-JSON frames pass straight through
+		if json.Valid(frame) || !looksLikeJSONStart(frame) {
+			return []fragment{{Data: frame, Complete: true}}
+		}
+		r.buf = append(r.buf, frame...)
+		r.started = now
+		return nil
+	}
+
+	// A stale buffer is flushed before considering the new frame
+	if now.Sub(r.started) > r.timeout {
+		out = append(out, r.flush())
+		return append(out, r.Push(frame, now)...)
+	}
+
+	r.buf = append(r.buf, frame...)
+	if json.Valid(r.buf) {
+		data := r.buf
+		r.buf = nil
+		return append(out, fragment{Data: data, Complete: true})
+	}
+
+	// The new frame is itself a full document: the previous fragment was orphaned
+	if json.Valid(frame) {
+		r.buf = r.buf[:len(r.buf)-len(frame)]
+		out = append(out, r.flush())
+		return append(out, fragment{Data: frame, Complete: true})
+	}
+
+	if len(r.buf) > r.maxBytes {
+		out = append(out, r.flush())
+	}
+	return out
+}
+
+// Expire flushes the buffer if it has been waiting longer than the timeout.
+func (r *jsonReassembler) Expire(now time.Time) []fragment {
+	if len(r.buf) == 0 || now.Sub(r.started) <= r.timeout {
+		return nil
+	}
+	return []fragment{r.flush()}
+}
+
+func (r *jsonReassembler) flush() fragment {
+	data := r.buf
+	r.buf = nil
+	return fragment{Data: data, Complete: false}
+}
+
+func looksLikeJSONStart(frame []byte) bool {
+	trimmed := bytes.TrimSpace(frame)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
@@ -0,0 +1,91 @@
+package socket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONReassembler_Push(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		frames   []string
+		expected []fragment
+	}{
+		{
+			name:     "complete document passes through",
+			frames:   []string{`{"price":1}`},
+			expected: []fragment{{Data: []byte(`{"price":1}`), Complete: true}},
+		},
+		{
+			name:     "non-JSON frame passes through",
+			frames:   []string{"heartbeat"},
+			expected: []fragment{{Data: []byte("heartbeat"), Complete: true}},
+		},
+		{
+			name:     "fragmented object is reassembled",
+			frames:   []string{`{"items":[1,`, `2,3],`, `"ok":true}`},
+			expected: []fragment{{Data: []byte(`{"items":[1,2,3],"ok":true}`), Complete: true}},
+		},
+		{
+			name:   "orphaned fragment is flushed when a full document arrives",
+			frames: []string{`{"partial":`, `{"price":2}`},
+			expected: []fragment{
+				{Data: []byte(`{"partial":`), Complete: false},
+				{Data: []byte(`{"price":2}`), Complete: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newJSONReassembler(0, 0)
+			var got []fragment
+			for _, f := range tt.frames {
+				got = append(got, r.Push([]byte(f), now)...)
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestJSONReassembler_SizeCap(t *testing.T) {
+	r := newJSONReassembler(16, time.Minute)
+	now := time.Now()
+
+	assert.Empty(t, r.Push([]byte(`{"data":"`), now))
+	out := r.Push([]byte(`aaaaaaaaaaaaaaaa`), now)
+	require.Len(t, out, 1)
+	assert.False(t, out[0].Complete)
+	assert.Equal(t, `{"data":"aaaaaaaaaaaaaaaa`, string(out[0].Data))
+
+	// Buffer is reset after the flush
+	out = r.Push([]byte(`{"a":1}`), now)
+	require.Len(t, out, 1)
+	assert.True(t, out[0].Complete)
+}
+
+func TestJSONReassembler_Timeout(t *testing.T) {
+	r := newJSONReassembler(0, time.Second)
+	start := time.Now()
+
+	assert.Empty(t, r.Push([]byte(`{"a":`), start))
+	assert.Empty(t, r.Expire(start.Add(500*time.Millisecond)))
+
+	out := r.Expire(start.Add(2 * time.Second))
+	require.Len(t, out, 1)
+	assert.False(t, out[0].Complete)
+	assert.Empty(t, r.Expire(start.Add(3*time.Second)))
+
+	// A stale buffer is flushed before a late continuation is considered
+	assert.Empty(t, r.Push([]byte(`{"b":`), start))
+	out = r.Push([]byte(`2}`), start.Add(2*time.Second))
+	require.Len(t, out, 2)
+	assert.Equal(t, `{"b":`, string(out[0].Data))
+	assert.False(t, out[0].Complete)
+	assert.Equal(t, `2}`, string(out[1].Data))
+}
@@ -0,0 +1,163 @@
+package socket
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// defaultReconnectDelay is the first reconnect wait when the feed sets no reconnectionDelay
+	defaultReconnectDelay = 5 * time.Second
+	// maxReconnectDelay caps the exponential backoff between attempts
+	maxReconnectDelay = 5 * time.Minute
+	// circuitCooldown is how long an open circuit blocks new connections
+	// before a subscribe may try the upstream again
+	circuitCooldown = 5 * time.Minute
+	// circuitOpen marks a feed whose reconnect attempts are exhausted
+	circuitOpen = "open"
+)
+
+// feedStatus is the payload of the feed-status event sent to a feed's subscribers
+type feedStatus struct {
+	FeedID      string `json:"feedId"`
+	Status      string `json:"status"` // "reconnecting", "connected" or "circuit-open"; "paused" or "resumed" in feed-paused and feed-resumed
+	Attempt     int    `json:"attempt,omitempty"`
+	MaxAttempts int    `json:"maxAttempts,omitempty"` // 0 retries forever
+	RetryInMs   int64  `json:"retryInMs,omitempty"`
+	Message     string `json:"message"`
+}
+
+// backoffDelay doubles base for every attempt after the first, caps it at
+// maxReconnectDelay and randomises the upper half so instances that lost the
+// same upstream do not retry in lockstep.
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxReconnectDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxReconnectDelay {
+		delay = maxReconnectDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// reconnectDelay is the wait before the given (1-based) reconnect attempt
+func reconnectDelay(feed models.WebSocketFeed, attempt int) time.Duration {
+	base := defaultReconnectDelay
+	if feed.ReconnectionDelay > 0 {
+		base = time.Duration(feed.ReconnectionDelay) * time.Millisecond
+	}
+	return backoffDelay(base, attempt)
+}
+
+// attemptsExhausted reports whether attempt exceeds the feed's reconnectionAttempts (0 is unlimited)
+func attemptsExhausted(feed models.WebSocketFeed, attempt int) bool {
+	return feed.ReconnectionAttempts > 0 && attempt > feed.ReconnectionAttempts
+}
+
+// reconnectFeed retries the feed with exponential backoff until it connects,
+// the server shuts down, or reconnectionAttempts is used up, which opens the
+// feed's circuit.
+func (m *Manager) reconnectFeed(feed models.WebSocketFeed) {
+	feedID := feed.ID.Hex()
+	for attempt := 1; ; attempt++ {
+		if attemptsExhausted(feed, attempt) {
+			m.openCircuit(feed, attempt-1)
+			return
+		}
+		delay := reconnectDelay(feed, attempt)
+		m.reportReconnecting(feed, attempt, delay)
+		time.Sleep(delay)
+		if m.isClosing() {
+			return
+		}
+		if !m.feedActive(feedID) {
+			slog.Info("feed was paused or deleted, not reconnecting", "feed_id", feedID)
+			return
+		}
+
+		slog.Info("attempting to reconnect feed", "feed_id", feedID, "attempt", attempt)
+		countReconnect(feed)
+		if err := m.ConnectFeed(feed); err != nil {
+			slog.Warn("failed to reconnect feed", "feed_id", feedID, "error", err)
+			m.recordFeedError(feedID, err)
+			continue
+		}
+		slog.Info("reconnected feed", "feed_id", feedID)
+		m.reportConnected(feed)
+		return
+	}
+}
+
+// reportFeedStatus sends a feed-status event to the feed's data subscribers on every instance
+func (m *Manager) reportFeedStatus(status feedStatus) {
+	room := dataRoom(status.FeedID)
+	msg := makeMessage("feed-status", status)
+	m.rooms.Broadcast(room, msg)
+	m.publishToCluster(room, msg)
+}
+
+func (m *Manager) reportReconnecting(feed models.WebSocketFeed, attempt int, delay time.Duration) {
+	progress := fmt.Sprintf("attempt %d", attempt)
+	if feed.ReconnectionAttempts > 0 {
+		progress = fmt.Sprintf("attempt %d/%d", attempt, feed.ReconnectionAttempts)
+	}
+	m.reportFeedStatus(feedStatus{
+		FeedID:      feed.ID.Hex(),
+		Status:      "reconnecting",
+		Attempt:     attempt,
+		MaxAttempts: feed.ReconnectionAttempts,
+		RetryInMs:   delay.Milliseconds(),
+		Message:     fmt.Sprintf("reconnecting (%s)", progress),
+	})
+}
+
+// reportConnected tells subscribers the feed is back and closes its circuit if it was open
+func (m *Manager) reportConnected(feed models.WebSocketFeed) {
+	if feed.CircuitState != "" {
+		m.storeCircuit(feed.ID.Hex(), "", nil)
+	}
+	m.reportFeedStatus(feedStatus{FeedID: feed.ID.Hex(), Status: "connected", Message: "connected"})
+}
+
+// openCircuit stops retrying the feed and records that on the feed so new
+// subscribers do not hammer a dead upstream until circuitCooldown passes
+func (m *Manager) openCircuit(feed models.WebSocketFeed, attempts int) {
+	feedID := feed.ID.Hex()
+	slog.Warn("giving up reconnecting feed, circuit open", "feed_id", feedID, "attempts", attempts)
+	now := time.Now().UTC()
+	m.storeCircuit(feedID, circuitOpen, &now)
+	m.markFeedDisconnected(feedID)
+	m.reportFeedStatus(feedStatus{
+		FeedID:      feedID,
+		Status:      "circuit-open",
+		Attempt:     attempts,
+		MaxAttempts: feed.ReconnectionAttempts,
+		Message:     fmt.Sprintf("disconnected after %d reconnect attempts", attempts),
+	})
+}
+
+// circuitBlocks reports whether the feed's circuit is open and still cooling down
+func circuitBlocks(feed models.WebSocketFeed, now time.Time) bool {
+	if feed.CircuitState != circuitOpen {
+		return false
+	}
+	return feed.CircuitOpenedAt != nil && now.Sub(*feed.CircuitOpenedAt) < circuitCooldown
+}
+
+func (m *Manager) storeCircuit(feedID, state string, openedAt *time.Time) {
+	if m.marketplace == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.marketplace.SetFeedCircuit(ctx, feedID, state, openedAt); err != nil {
+		slog.Warn("failed to store circuit state", "feed_id", feedID, "error", err)
+	}
+}
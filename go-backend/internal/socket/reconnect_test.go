@@ -0,0 +1,100 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    time.Duration
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"first attempt", time.Second, 1, 500 * time.Millisecond, time.Second},
+		{"third attempt", time.Second, 3, 2 * time.Second, 4 * time.Second},
+		{"capped", time.Second, 30, maxReconnectDelay / 2, maxReconnectDelay},
+		{"large base capped", time.Hour, 1, maxReconnectDelay / 2, maxReconnectDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				delay := backoffDelay(tt.base, tt.attempt)
+				assert.GreaterOrEqual(t, delay, tt.min)
+				assert.LessOrEqual(t, delay, tt.max)
+			}
+		})
+	}
+}
+
+func TestReconnectDelay_UsesFeedDelay(t *testing.T) {
+	feed := models.WebSocketFeed{ReconnectionDelay: 200}
+	delay := reconnectDelay(feed, 1)
+	assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+	assert.LessOrEqual(t, delay, 200*time.Millisecond)
+
+	delay = reconnectDelay(models.WebSocketFeed{}, 1)
+	assert.GreaterOrEqual(t, delay, defaultReconnectDelay/2)
+}
+
+func TestAttemptsExhausted(t *testing.T) {
+	assert.False(t, attemptsExhausted(models.WebSocketFeed{}, 1000), "0 attempts retries forever")
+	assert.False(t, attemptsExhausted(models.WebSocketFeed{ReconnectionAttempts: 3}, 3))
+	assert.True(t, attemptsExhausted(models.WebSocketFeed{ReconnectionAttempts: 3}, 4))
+}
+
+func TestCircuitBlocks(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	old := now.Add(-2 * circuitCooldown)
+
+	assert.False(t, circuitBlocks(models.WebSocketFeed{}, now))
+	assert.True(t, circuitBlocks(models.WebSocketFeed{CircuitState: circuitOpen, CircuitOpenedAt: &recent}, now))
+	assert.False(t, circuitBlocks(models.WebSocketFeed{CircuitState: circuitOpen, CircuitOpenedAt: &old}, now), "cooldown over, a probe is allowed")
+}
+
+func TestManager_ReconnectFeedReportsStatusAndOpensCircuit(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+
+	feed := models.WebSocketFeed{
+		ID:                   primitive.NewObjectID(),
+		URL:                  "ws://127.0.0.1:1/unreachable",
+		ReconnectionEnabled:  true,
+		ReconnectionDelay:    1,
+		ReconnectionAttempts: 2,
+	}
+	m.rooms.Join(dataRoom(feed.ID.Hex()), client)
+
+	m.reconnectFeed(feed)
+
+	var statuses []feedStatus
+	for len(client.out) > 0 {
+		msg := <-client.out
+		if msg.Type != "feed-status" {
+			continue
+		}
+		var status feedStatus
+		require.NoError(t, json.Unmarshal(msg.Payload, &status))
+		statuses = append(statuses, status)
+	}
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "reconnecting", statuses[0].Status)
+	assert.Equal(t, "reconnecting (attempt 1/2)", statuses[0].Message)
+	assert.Equal(t, "reconnecting (attempt 2/2)", statuses[1].Message)
+	assert.Equal(t, "circuit-open", statuses[2].Status)
+	assert.Equal(t, 2, statuses[2].Attempt)
+	assert.False(t, m.IsFeedConnected(feed.ID.Hex()))
+}
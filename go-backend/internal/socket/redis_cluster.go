@@ -0,0 +1,125 @@
+package socket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisBroadcastChannel carries broadcasts between instances
+	redisBroadcastChannel = "turbostream:broadcast"
+	// redisFeedLockPrefix prefixes the per-feed ownership lock keys
+	redisFeedLockPrefix = "turbostream:feed-owner:"
+)
+
+// acquireScript extends the lock when this instance already holds it and
+// otherwise takes it only if nobody does.
+var acquireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+
+// releaseScript deletes the lock only when this instance holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisCluster is a ClusterBus backed by Redis pub/sub and SET NX locks
+type RedisCluster struct {
+	client     *redis.Client
+	instanceID string
+}
+
+// NewRedisCluster connects to Redis at url (redis:// or rediss://) and checks it is reachable
+func NewRedisCluster(ctx context.Context, url string) (*RedisCluster, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis url: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connect to redis: %w", err)
+	}
+	return &RedisCluster{client: client, instanceID: newInstanceID()}, nil
+}
+
+// newInstanceID combines the hostname with a random suffix so restarts on the same host differ
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "turbostream"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+func (r *RedisCluster) InstanceID() string {
+	return r.instanceID
+}
+
+func (r *RedisCluster) Publish(ctx context.Context, data []byte) error {
+	return r.client.Publish(ctx, redisBroadcastChannel, data).Err()
+}
+
+func (r *RedisCluster) Subscribe(ctx context.Context) (<-chan []byte, error) {
+	sub := r.client.Subscribe(ctx, redisBroadcastChannel)
+	// Wait for the subscription so messages published right after are not missed
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	out := make(chan []byte, clusterQueueSize)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (r *RedisCluster) AcquireFeed(ctx context.Context, feedID string, ttl time.Duration) (bool, error) {
+	n, err := acquireScript.Run(ctx, r.client, []string{redisFeedLockPrefix + feedID}, r.instanceID, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisCluster) ReleaseFeed(ctx context.Context, feedID string) error {
+	return releaseScript.Run(ctx, r.client, []string{redisFeedLockPrefix + feedID}, r.instanceID).Err()
+}
+
+// Close disconnects from Redis
+func (r *RedisCluster) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,113 @@
+package socket
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// defaultReplayBufferSize is how many recent feed-data messages are kept per feed
+const defaultReplayBufferSize = 50
+
+// replayBuffer is a fixed-size ring of encoded feed-data payloads
+type replayBuffer struct {
+	items []json.RawMessage
+	next  int
+	full  bool
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{items: make([]json.RawMessage, size)}
+}
+
+// add stores a payload, overwriting the oldest once the buffer is full
+func (b *replayBuffer) add(payload json.RawMessage) {
+	b.items[b.next] = payload
+	b.next = (b.next + 1) % len(b.items)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// last returns up to n of the newest payloads, oldest first
+func (b *replayBuffer) last(n int) []json.RawMessage {
+	count := b.next
+	if b.full {
+		count = len(b.items)
+	}
+	if n > count {
+		n = count
+	}
+	out := make([]json.RawMessage, 0, n)
+	for i := n; i > 0; i-- {
+		idx := (b.next - i + len(b.items)) % len(b.items)
+		out = append(out, b.items[idx])
+	}
+	return out
+}
+
+// SetReplayBufferSize sets how many recent messages are kept per feed for
+// replay on subscribe (0 disables replay). Existing buffers are discarded.
+func (m *Manager) SetReplayBufferSize(size int) {
+	m.replayMu.Lock()
+	defer m.replayMu.Unlock()
+	m.replaySize = size
+	m.replays = make(map[string]*replayBuffer)
+}
+
+// rememberFeedData adds an encoded feed-data payload to the feed's replay buffer
+func (m *Manager) rememberFeedData(feedID string, payload json.RawMessage) {
+	if len(payload) == 0 {
+		return
+	}
+	m.replayMu.Lock()
+	defer m.replayMu.Unlock()
+	if m.replaySize <= 0 {
+		return
+	}
+	buf, ok := m.replays[feedID]
+	if !ok {
+		buf = newReplayBuffer(m.replaySize)
+		m.replays[feedID] = buf
+	}
+	buf.add(payload)
+}
+
+// sendReplay sends up to count recent feed-data payloads as one feed-replay
+// event, honouring the client's filters for the feed's data room. The client
+// has already joined the room, so a live message may arrive twice but none
+// are missed. Clients that left replay out of their hello get none.
+func (m *Manager) sendReplay(client *Client, feedID string, count int) {
+	if count <= 0 || !client.hasFeature(featureReplay) {
+		return
+	}
+	m.replayMu.Lock()
+	var messages []json.RawMessage
+	if buf, ok := m.replays[feedID]; ok {
+		messages = buf.last(count)
+	}
+	m.replayMu.Unlock()
+
+	if filters := client.roomFilters(dataRoom(feedID)); len(filters) > 0 {
+		matching := messages[:0:0]
+		for _, raw := range messages {
+			var payload interface{}
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				slog.Warn("skipping unreadable replay message", "feed_id", feedID, "error", err)
+				continue
+			}
+			if matchFilters(filters, payload) {
+				matching = append(matching, raw)
+			}
+		}
+		messages = matching
+	}
+
+	if messages == nil {
+		messages = []json.RawMessage{}
+	}
+	client.send(makeMessage("feed-replay", map[string]interface{}{
+		"feedId":   feedID,
+		"messages": messages,
+		"count":    len(messages),
+	}))
+}
@@ -0,0 +1,84 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestReplayBuffer_Last(t *testing.T) {
+	raw := func(s string) json.RawMessage { return json.RawMessage(s) }
+
+	buf := newReplayBuffer(3)
+	assert.Empty(t, buf.last(5))
+
+	buf.add(raw("1"))
+	buf.add(raw("2"))
+	assert.Equal(t, []json.RawMessage{raw("1"), raw("2")}, buf.last(5))
+
+	buf.add(raw("3"))
+	buf.add(raw("4"))
+	assert.Equal(t, []json.RawMessage{raw("2"), raw("3"), raw("4")}, buf.last(5), "oldest entries are overwritten")
+	assert.Equal(t, []json.RawMessage{raw("3"), raw("4")}, buf.last(2))
+	assert.Empty(t, buf.last(0))
+}
+
+func TestManager_SubscribeReplaysRecentData(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	m.SetReplayBufferSize(3)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Ticker"}
+	for i := 1; i <= 4; i++ {
+		m.BroadcastFeedData(feed, map[string]interface{}{"seq": i}, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type": "subscribe-feed",
+		"payload": map[string]interface{}{
+			"feedId":      feed.ID.Hex(),
+			"replayCount": 10,
+			"filters":     []string{"data.seq != 3"},
+		},
+	}))
+
+	var ack WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &ack))
+	require.Equal(t, "subscription-success", ack.Type)
+
+	var replay struct {
+		Type    string `json:"type"`
+		Payload struct {
+			FeedID   string `json:"feedId"`
+			Count    int    `json:"count"`
+			Messages []struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"messages"`
+		} `json:"payload"`
+	}
+	require.NoError(t, wsjson.Read(ctx, conn, &replay))
+	assert.Equal(t, "feed-replay", replay.Type)
+	assert.Equal(t, feed.ID.Hex(), replay.Payload.FeedID)
+	require.Equal(t, 2, replay.Payload.Count, "buffer keeps 3 messages and the filter drops one")
+	assert.Equal(t, float64(2), replay.Payload.Messages[0].Data["seq"])
+	assert.Equal(t, float64(4), replay.Payload.Messages[1].Data["seq"])
+}
@@ -0,0 +1,107 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// sampleReadTimeout bounds how long a temporary connection waits for a message
+const sampleReadTimeout = 10 * time.Second
+
+// ErrFeedTypeUnsupported is returned when a sample cannot be captured for a feed's connection type
+var ErrFeedTypeUnsupported = errors.New("sampling not supported for this connection type")
+
+// IsFeedConnected reports whether the manager holds a live upstream connection for the feed
+func (m *Manager) IsFeedConnected(feedID string) bool {
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	fc, ok := m.feedConns[feedID]
+	if !ok {
+		return false
+	}
+	select {
+	case <-fc.stop:
+		return false
+	default:
+		return true
+	}
+}
+
+// LatestFeedSample returns the newest entry held in the feed's LLM context, if any
+func (m *Manager) LatestFeedSample(feedID string) (map[string]interface{}, bool) {
+	if m.llm == nil {
+		return nil, false
+	}
+	return m.llm.LatestEntry(feedID)
+}
+
+// CaptureFeedSample briefly connects to a feed, reads a single message and disconnects.
+// The message is returned to the caller only; it is not broadcast or added to context.
+func (m *Manager) CaptureFeedSample(ctx context.Context, feed models.WebSocketFeed) (interface{}, error) {
+	feed, err := m.openFeedSecrets(feed)
+	if err != nil {
+		return nil, err
+	}
+	switch feed.ConnectionType {
+	case connectionTypeSocketIO:
+		return captureSocketIOSample(ctx, feed)
+	case connectionTypeSSE:
+		return captureSSESample(ctx, feed)
+	case connectionTypeHTTPPolling:
+		return pollFeed(ctx, feed)
+	case connectionTypeMQTT:
+		return captureMQTTSample(ctx, feed)
+	case connectionTypeSimulated:
+		return captureSimulatedSample(feed)
+	}
+	if !isWebSocketFeed(feed) {
+		return nil, ErrFeedTypeUnsupported
+	}
+	decode, err := newPayloadDecoder(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialFeed(feed)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	sendConnectionMessages(feed, conn)
+
+	deadline := time.Now().Add(sampleReadTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return decode(msg)
+}
+
+// captureSSESample opens a feed's event stream and returns its first event.
+func captureSSESample(ctx context.Context, feed models.WebSocketFeed) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, sampleReadTimeout)
+	defer cancel()
+
+	resp, err := openSSEStream(ctx, feed, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	ev, err := newSSEStream(resp.Body, "").Next()
+	if err != nil {
+		return nil, err
+	}
+	return decodeFeedMessage([]byte(ev.Data)), nil
+}
@@ -0,0 +1,68 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// schemaWindowSize is how many messages each schema inference covers. The
+// first message is inferred on its own so new feeds get a schema right away.
+const schemaWindowSize = 20
+
+// schemaSampler infers a feed's schema from windows of broadcast messages
+type schemaSampler struct {
+	builder   *services.SchemaBuilder
+	signature string // signature of the last stored schema
+	published bool
+}
+
+// observeSchema adds a broadcast message to the feed's schema window. When a
+// window completes, the inferred schema goes to the LLM service, and to the
+// feed document when its fields or types changed.
+func (m *Manager) observeSchema(feed models.WebSocketFeed, data interface{}) {
+	feedID := feed.ID.Hex()
+	m.schemaMu.Lock()
+	if m.schemas == nil {
+		m.schemas = make(map[string]*schemaSampler)
+	}
+	s, ok := m.schemas[feedID]
+	if !ok {
+		s = &schemaSampler{builder: services.NewSchemaBuilder(), signature: services.SchemaSignature(feed.Schema)}
+		m.schemas[feedID] = s
+		if feed.Schema != nil && m.llm != nil {
+			m.llm.SetFeedSchema(feedID, feed.Schema)
+		}
+	}
+	s.builder.Add(data)
+	if s.published && s.builder.Samples() < schemaWindowSize {
+		m.schemaMu.Unlock()
+		return
+	}
+	schema := s.builder.Schema(time.Now().UTC())
+	s.builder = services.NewSchemaBuilder()
+	s.published = true
+	signature := services.SchemaSignature(&schema)
+	changed := signature != s.signature
+	s.signature = signature
+	m.schemaMu.Unlock()
+
+	if m.llm != nil {
+		m.llm.SetFeedSchema(feedID, &schema)
+	}
+	if changed && m.marketplace != nil {
+		go m.storeFeedSchema(feedID, schema)
+	}
+}
+
+// storeFeedSchema saves an inferred schema on the feed document
+func (m *Manager) storeFeedSchema(feedID string, schema models.FeedSchema) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.marketplace.SetFeedSchema(ctx, feedID, schema); err != nil {
+		slog.Warn("failed to store feed schema", "feed_id", feedID, "error", err)
+	}
+}
@@ -0,0 +1,46 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestManager_ObserveSchema(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID()}
+	feedID := feed.ID.Hex()
+
+	// The first message is inferred on its own
+	m.observeSchema(feed, map[string]interface{}{"price": 1.0})
+	s := m.schemas[feedID]
+	require.NotNil(t, s)
+	assert.True(t, s.published)
+	assert.Equal(t, "price:number", s.signature)
+	assert.Equal(t, 0, s.builder.Samples())
+
+	// Later messages wait for a full window
+	for i := 0; i < schemaWindowSize-1; i++ {
+		m.observeSchema(feed, map[string]interface{}{"price": "1"})
+	}
+	assert.Equal(t, "price:number", s.signature)
+	assert.Equal(t, schemaWindowSize-1, s.builder.Samples())
+
+	m.observeSchema(feed, map[string]interface{}{"price": "1"})
+	assert.Equal(t, "price:string", s.signature)
+	assert.Equal(t, 0, s.builder.Samples())
+}
+
+func TestManager_ObserveSchemaSeedsStoredSchema(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	stored := &models.FeedSchema{Fields: []models.SchemaField{{Path: "price", Types: []string{"number"}}}}
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Schema: stored}
+
+	m.observeSchema(feed, map[string]interface{}{"price": 2.0})
+	assert.Equal(t, services.SchemaSignature(stored), m.schemas[feed.ID.Hex()].signature)
+}
@@ -0,0 +1,154 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	coderws "nhooyr.io/websocket"
+)
+
+// shutdownPollInterval is how often Shutdown checks for drained queues and closed feeds
+const shutdownPollInterval = 50 * time.Millisecond
+
+// trackClient registers a connected client so Shutdown can close it
+func (m *Manager) trackClient(client *Client) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	m.clients[client] = struct{}{}
+}
+
+func (m *Manager) untrackClient(client *Client) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	delete(m.clients, client)
+}
+
+// startLLMQuery registers an LLM query goroutine so Shutdown can wait for it.
+// It reports false once shutdown has started.
+func (m *Manager) startLLMQuery() bool {
+	m.shutdownMu.Lock()
+	defer m.shutdownMu.Unlock()
+	if m.closing {
+		return false
+	}
+	m.llmWG.Add(1)
+	return true
+}
+
+// isClosing reports whether Shutdown has been called
+func (m *Manager) isClosing() bool {
+	m.shutdownMu.Lock()
+	defer m.shutdownMu.Unlock()
+	return m.closing
+}
+
+// Shutdown stops accepting websocket clients and LLM queries, closes every
+// upstream feed connection, waits for in-flight LLM queries to finish, then
+// flushes each client's queue and closes it with a going-away close frame.
+// It returns ctx's error if ctx ends first; remaining clients are still closed.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.shutdownMu.Lock()
+	m.closing = true
+	m.shutdownMu.Unlock()
+
+	m.stopAllFeeds(ctx)
+
+	llmDone := make(chan struct{})
+	go func() {
+		m.llmWG.Wait()
+		close(llmDone)
+	}()
+	var err error
+	select {
+	case <-llmDone:
+	case <-ctx.Done():
+		err = ctx.Err()
+		slog.Warn("shutdown timed out waiting for LLM queries")
+	}
+
+	m.clientsMu.Lock()
+	clients := make([]*Client, 0, len(m.clients))
+	for client := range m.clients {
+		clients = append(clients, client)
+	}
+	m.clientsMu.Unlock()
+
+	for _, client := range clients {
+		client.flush(ctx)
+	}
+	for _, client := range clients {
+		client.closeGoingAway()
+	}
+	slog.Info("closed websocket clients", "clients", len(clients))
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// stopAllFeeds stops every upstream connection, releases their cluster locks
+// so other instances can take over, and waits for the read loops to exit
+func (m *Manager) stopAllFeeds(ctx context.Context) {
+	m.feedMu.RLock()
+	feedIDs := make([]string, 0, len(m.feedConns))
+	for feedID := range m.feedConns {
+		feedIDs = append(feedIDs, feedID)
+	}
+	m.feedMu.RUnlock()
+
+	for _, feedID := range feedIDs {
+		m.StopFeed(feedID)
+		m.releaseFeed(feedID)
+	}
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		m.feedMu.RLock()
+		remaining := len(m.feedConns)
+		m.feedMu.RUnlock()
+		if remaining == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			slog.Warn("shutdown timed out with feed connections still closing", "feeds", remaining)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush waits until the client's writer has sent every queued message or ctx ends
+func (c *Client) flush(ctx context.Context) {
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for c.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeGoingAway closes the connection telling the client the server is going away
+func (c *Client) closeGoingAway() {
+	c.closeWith(coderws.StatusGoingAway, "server shutting down")
+}
+
+// closeWith waits for any in-flight write, then closes the connection with
+// status and reason
+func (c *Client) closeWith(status coderws.StatusCode, reason string) {
+	defer c.cancel()
+	if c.conn == nil {
+		return
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.Close(status, reason)
+}
@@ -0,0 +1,87 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	coderws "nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestManager_ShutdownFlushesAndClosesClients(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	srv := httptest.NewServer(http.HandlerFunc(m.Handle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := coderws.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(coderws.StatusNormalClosure, "")
+
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{
+		"type":    "subscribe-llm",
+		"payload": map[string]interface{}{"feedId": "feed-1"},
+	}))
+	var ack WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &ack))
+	require.Equal(t, "subscription-success", ack.Type)
+
+	// A feed connection whose loop exits once stopped, like the real connectors
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns["feed-1"] = &feedConnection{stop: stop}
+	m.feedMu.Unlock()
+	go func() {
+		<-stop
+		m.feedMu.Lock()
+		delete(m.feedConns, "feed-1")
+		m.feedMu.Unlock()
+	}()
+
+	// An in-flight LLM query delays the close until it finishes
+	require.True(t, m.startLLMQuery())
+	go func() {
+		defer m.llmWG.Done()
+		time.Sleep(100 * time.Millisecond)
+		m.BroadcastLLMOutput("feed-1", "answer", "test")
+	}()
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- m.Shutdown(ctx) }()
+
+	var msg WSMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, "llm-broadcast", msg.Type)
+
+	_, _, err = conn.Read(ctx)
+	assert.Equal(t, coderws.StatusGoingAway, coderws.CloseStatus(err))
+	require.NoError(t, <-shutdownDone)
+
+	m.feedMu.RLock()
+	assert.Empty(t, m.feedConns)
+	m.feedMu.RUnlock()
+	assert.False(t, m.startLLMQuery(), "no LLM queries start after shutdown")
+}
+
+func TestManager_RejectsWorkAfterShutdown(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	rec := httptest.NewRecorder()
+	m.Handle(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), URL: "ws://127.0.0.1:1/unreachable"}
+	assert.NoError(t, m.ConnectFeed(feed))
+	assert.False(t, m.IsFeedConnected(feed.ID.Hex()))
+}
@@ -0,0 +1,231 @@
+package socket
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeSimulated is the feed connection type for synthetic data generated in the backend
+const connectionTypeSimulated = "simulated"
+
+// Simulated feed generators
+const (
+	simulatedGeneratorPrices = "prices"
+	simulatedGeneratorNews   = "news"
+)
+
+const (
+	// simulatedDefaultInterval is the time between ticks when a feed sets none
+	simulatedDefaultInterval = time.Second
+	// simulatedMinInterval and simulatedMaxInterval bound a feed's tick interval
+	simulatedMinInterval = 10 * time.Millisecond
+	simulatedMaxInterval = time.Hour
+	// simulatedMaxSymbols bounds the messages a prices feed sends per tick
+	simulatedMaxSymbols = 50
+)
+
+// simulatedHeadlines are the news generator's headline templates; %s is a symbol
+var simulatedHeadlines = []struct {
+	text      string
+	sentiment string
+}{
+	{"%s rallies as buyers return after a quiet session", "positive"},
+	{"Analysts upgrade %s on stronger than expected demand", "positive"},
+	{"%s breaks above its 50-day moving average", "positive"},
+	{"%s slides as traders lock in profits", "negative"},
+	{"Regulators open a review of %s market makers", "negative"},
+	{"%s volume dries up ahead of the rate decision", "neutral"},
+	{"%s trades sideways as the market waits for new data", "neutral"},
+	{"Large transfer of %s spotted between exchanges", "neutral"},
+}
+
+var simulatedSources = []string{"Demo Wire", "Synthetic Times", "Example Markets Daily"}
+
+// ValidateSimulatedConfig checks a simulated feed's generator options; nil uses the defaults
+func ValidateSimulatedConfig(cfg *models.SimulatedConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.Generator {
+	case "", simulatedGeneratorPrices, simulatedGeneratorNews:
+	default:
+		return fmt.Errorf("simulatedConfig.generator must be %s or %s", simulatedGeneratorPrices, simulatedGeneratorNews)
+	}
+	if cfg.IntervalMs != 0 && (cfg.IntervalMs < int(simulatedMinInterval.Milliseconds()) || cfg.IntervalMs > int(simulatedMaxInterval.Milliseconds())) {
+		return fmt.Errorf("simulatedConfig.intervalMs must be between %d and %d", simulatedMinInterval.Milliseconds(), simulatedMaxInterval.Milliseconds())
+	}
+	if len(cfg.Symbols) > simulatedMaxSymbols {
+		return fmt.Errorf("simulatedConfig.symbols allows at most %d symbols", simulatedMaxSymbols)
+	}
+	if cfg.BasePrice < 0 || cfg.Amplitude < 0 || cfg.Amplitude > 1 || cfg.Noise < 0 || cfg.Noise > 1 || cfg.PeriodSec < 0 {
+		return errors.New("simulatedConfig.basePrice and periodSec must not be negative, and amplitude and noise must be between 0 and 1")
+	}
+	return nil
+}
+
+// simulator generates a simulated feed's messages. Prices follow a sine wave
+// per symbol, each a fraction of a period apart, plus noise; time is counted
+// in ticks so a seeded simulator always produces the same prices.
+type simulator struct {
+	generator string
+	interval  time.Duration
+	symbols   []string
+	basePrice float64
+	amplitude float64
+	period    time.Duration
+	noise     float64
+	rng       *rand.Rand
+	tick      int
+}
+
+func newSimulator(cfg *models.SimulatedConfig) (*simulator, error) {
+	if err := ValidateSimulatedConfig(cfg); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &models.SimulatedConfig{}
+	}
+	s := &simulator{
+		generator: cfg.Generator,
+		interval:  time.Duration(cfg.IntervalMs) * time.Millisecond,
+		basePrice: cfg.BasePrice,
+		amplitude: cfg.Amplitude,
+		period:    time.Duration(cfg.PeriodSec) * time.Second,
+		noise:     cfg.Noise,
+	}
+	for _, symbol := range cfg.Symbols {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			s.symbols = append(s.symbols, symbol)
+		}
+	}
+	if s.generator == "" {
+		s.generator = simulatedGeneratorPrices
+	}
+	if s.interval == 0 {
+		s.interval = simulatedDefaultInterval
+	}
+	if len(s.symbols) == 0 {
+		s.symbols = []string{"DEMO"}
+	}
+	if s.basePrice == 0 {
+		s.basePrice = 100
+	}
+	if s.amplitude == 0 {
+		s.amplitude = 0.05
+	}
+	if s.period == 0 {
+		s.period = time.Minute
+	}
+	if s.noise == 0 {
+		s.noise = 0.002
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s.rng = rand.New(rand.NewSource(seed))
+	return s, nil
+}
+
+// Next returns the messages for the next tick, stamped with now: a price
+// per symbol, or one news item
+func (s *simulator) Next(now time.Time) []interface{} {
+	tick := s.tick
+	s.tick++
+	timestamp := now.UTC().Format(time.RFC3339Nano)
+
+	if s.generator == simulatedGeneratorNews {
+		headline := simulatedHeadlines[s.rng.Intn(len(simulatedHeadlines))]
+		symbol := s.symbols[s.rng.Intn(len(s.symbols))]
+		return []interface{}{map[string]interface{}{
+			"id":        fmt.Sprintf("news-%d", tick+1),
+			"headline":  fmt.Sprintf(headline.text, symbol),
+			"source":    simulatedSources[s.rng.Intn(len(simulatedSources))],
+			"symbols":   []interface{}{symbol},
+			"sentiment": headline.sentiment,
+			"timestamp": timestamp,
+		}}
+	}
+
+	elapsed := float64(tick) * s.interval.Seconds()
+	messages := make([]interface{}, 0, len(s.symbols))
+	for i, symbol := range s.symbols {
+		phase := 2 * math.Pi * float64(i) / float64(len(s.symbols))
+		wave := math.Sin(2*math.Pi*elapsed/s.period.Seconds() + phase)
+		price := s.basePrice * (1 + s.amplitude*wave + s.noise*s.rng.NormFloat64())
+		if price < 0 {
+			price = 0
+		}
+		spread := price * 0.0005
+		messages = append(messages, map[string]interface{}{
+			"symbol":    symbol,
+			"price":     roundPrice(price),
+			"bid":       roundPrice(price - spread),
+			"ask":       roundPrice(price + spread),
+			"volume":    math.Round(s.rng.ExpFloat64() * 1000),
+			"timestamp": timestamp,
+		})
+	}
+	return messages
+}
+
+// roundPrice keeps four significant decimals, enough for sub-dollar prices
+func roundPrice(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
+
+// captureSimulatedSample returns the first message a simulated feed would send
+func captureSimulatedSample(feed models.WebSocketFeed) (interface{}, error) {
+	sim, err := newSimulator(feed.SimulatedConfig)
+	if err != nil {
+		return nil, err
+	}
+	return sim.Next(time.Now())[0], nil
+}
+
+// connectSimulatedFeed starts generating the feed's messages
+func (m *Manager) connectSimulatedFeed(feed models.WebSocketFeed) error {
+	sim, err := newSimulator(feed.SimulatedConfig)
+	if err != nil {
+		slog.Warn("invalid simulated feed", "feed_id", feed.ID.Hex(), "error", err)
+		return err
+	}
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "simulated")
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{stop: stop}
+	m.feedMu.Unlock()
+
+	go m.simulatedLoop(feed, sim, stop)
+	return nil
+}
+
+// simulatedLoop broadcasts a tick of generated messages every interval until the feed is stopped
+func (m *Manager) simulatedLoop(feed models.WebSocketFeed, sim *simulator, stop chan struct{}) {
+	defer func() {
+		m.feedClosed(feed)
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
+	}()
+
+	ticker := time.NewTicker(sim.interval)
+	defer ticker.Stop()
+	for {
+		for _, msg := range sim.Next(time.Now()) {
+			m.BroadcastFeedData(feed, msg, feed.EventName)
+		}
+		select {
+		case <-stop:
+			slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
+			return
+		case <-ticker.C:
+		}
+	}
+}
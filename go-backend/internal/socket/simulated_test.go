@@ -0,0 +1,96 @@
+package socket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestSimulator_Prices(t *testing.T) {
+	cfg := &models.SimulatedConfig{Symbols: []string{"AAA", "BBB"}, BasePrice: 50, Amplitude: 0.1, PeriodSec: 4, Seed: 7}
+	sim, err := newSimulator(cfg)
+	require.NoError(t, err)
+	again, err := newSimulator(cfg)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 8; i++ {
+		tick := sim.Next(now)
+		require.Len(t, tick, 2)
+		assert.Equal(t, tick, again.Next(now), "a seeded simulator repeats its prices")
+		for j, symbol := range []string{"AAA", "BBB"} {
+			msg := tick[j].(map[string]interface{})
+			assert.Equal(t, symbol, msg["symbol"])
+			price := msg["price"].(float64)
+			assert.InDelta(t, 50, price, 50*0.1+1, "prices stay near the wave")
+			assert.Less(t, msg["bid"].(float64), msg["ask"].(float64))
+			assert.Equal(t, "2026-01-02T03:04:05Z", msg["timestamp"])
+		}
+	}
+}
+
+func TestSimulator_News(t *testing.T) {
+	sim, err := newSimulator(&models.SimulatedConfig{Generator: simulatedGeneratorNews, Symbols: []string{"AAA"}, Seed: 1})
+	require.NoError(t, err)
+
+	tick := sim.Next(time.Now())
+	require.Len(t, tick, 1)
+	msg := tick[0].(map[string]interface{})
+	assert.Equal(t, "news-1", msg["id"])
+	assert.Contains(t, msg["headline"], "AAA")
+	assert.Contains(t, []string{"positive", "negative", "neutral"}, msg["sentiment"])
+	assert.Equal(t, "news-2", sim.Next(time.Now())[0].(map[string]interface{})["id"])
+}
+
+func TestValidateSimulatedConfig(t *testing.T) {
+	assert.NoError(t, ValidateSimulatedConfig(nil))
+	assert.NoError(t, ValidateSimulatedConfig(&models.SimulatedConfig{Generator: "news", IntervalMs: 500}))
+	assert.Error(t, ValidateSimulatedConfig(&models.SimulatedConfig{Generator: "weather"}))
+	assert.Error(t, ValidateSimulatedConfig(&models.SimulatedConfig{IntervalMs: 1}))
+	assert.Error(t, ValidateSimulatedConfig(&models.SimulatedConfig{Amplitude: 2}))
+	assert.Error(t, ValidateSimulatedConfig(&models.SimulatedConfig{Symbols: make([]string, simulatedMaxSymbols+1)}))
+}
+
+func TestManager_ConnectFeed_Simulated(t *testing.T) {
+	llm, err := services.NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	m := NewManager(nil, nil, nil, nil)
+	m.SetLLMService(llm)
+
+	feed := models.WebSocketFeed{
+		ID:              primitive.NewObjectID(),
+		Name:            "Demo",
+		ConnectionType:  connectionTypeSimulated,
+		SimulatedConfig: &models.SimulatedConfig{IntervalMs: 20, Symbols: []string{"AAA"}},
+	}
+	require.NoError(t, m.ConnectFeed(feed))
+	defer m.StopFeed(feed.ID.Hex())
+
+	require.Eventually(t, func() bool {
+		return len(contextEntries(llm, feed.ID.Hex())) >= 3
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "AAA", contextEntries(llm, feed.ID.Hex())[0]["symbol"])
+
+	sample, err := m.CaptureFeedSample(context.Background(), feed)
+	require.NoError(t, err)
+	assert.Contains(t, sample, "price")
+}
+
+// contextEntries copies a feed's context entries under the LLM service's
+// lock, since the feed keeps adding to them while the test reads
+func contextEntries(llm *services.LLMService, feedID string) []map[string]interface{} {
+	for _, fc := range llm.SnapshotContexts() {
+		if fc.FeedID == feedID {
+			return fc.Entries
+		}
+	}
+	return nil
+}
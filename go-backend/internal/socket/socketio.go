@@ -0,0 +1,446 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeSocketIO is the feed connection type for Socket.IO servers
+const connectionTypeSocketIO = "socketio"
+
+// socketIOHandshakeTimeout bounds the Engine.IO open and namespace connect exchange
+const socketIOHandshakeTimeout = 10 * time.Second
+
+// Engine.IO (v4) packet types, sent as the first byte of a text frame
+const (
+	engineOpen    = '0'
+	engineClose   = '1'
+	enginePing    = '2'
+	enginePong    = '3'
+	engineMessage = '4'
+)
+
+// Socket.IO packet types, sent as the first byte of an Engine.IO message
+const (
+	socketIOConnect      = '0'
+	socketIODisconnect   = '1'
+	socketIOEvent        = '2'
+	socketIOConnectError = '4'
+)
+
+// socketIOPacket is a decoded Socket.IO packet
+type socketIOPacket struct {
+	Type      byte
+	Namespace string
+	Data      json.RawMessage
+}
+
+// socketIOEndpoint maps a feed URL to the Engine.IO websocket URL and the
+// Socket.IO namespace. As with the JS client, the URL path names the
+// namespace unless it is the /socket.io/ endpoint itself.
+func socketIOEndpoint(feed models.WebSocketFeed) (string, string, error) {
+	target, _, err := feedTarget(feed)
+	if err != nil {
+		return "", "", err
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", "", err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+
+	namespace := "/"
+	path := strings.TrimSuffix(u.Path, "/")
+	if path != "" && !strings.HasSuffix(path, "/socket.io") {
+		namespace = path
+		path = ""
+	}
+	if path == "" {
+		path = "/socket.io"
+	}
+	u.Path = path + "/"
+
+	q := u.Query()
+	q.Set("EIO", "4")
+	q.Set("transport", "websocket")
+	u.RawQuery = q.Encode()
+	return u.String(), namespace, nil
+}
+
+// parseSocketIOPacket decodes the payload of an Engine.IO message packet:
+// <type>[<namespace>,][<ack id>][<json data>]
+func parseSocketIOPacket(payload string) (socketIOPacket, error) {
+	if payload == "" {
+		return socketIOPacket{}, errors.New("empty socket.io packet")
+	}
+	p := socketIOPacket{Type: payload[0], Namespace: "/"}
+	rest := payload[1:]
+
+	// Binary packets carry an attachment count first ("51-..."); they are not supported
+	if p.Type == '5' || p.Type == '6' {
+		return p, errors.New("binary socket.io packets are not supported")
+	}
+	if strings.HasPrefix(rest, "/") {
+		ns, after, found := strings.Cut(rest, ",")
+		p.Namespace = ns
+		if !found {
+			after = ""
+		}
+		rest = after
+	}
+	// Skip an ack id, if present
+	rest = strings.TrimLeft(rest, "0123456789")
+	if rest != "" {
+		p.Data = json.RawMessage(rest)
+	}
+	return p, nil
+}
+
+// socketIOEventArgs splits an EVENT packet's data into its name and payload.
+// A single argument is returned as-is; several are returned as an array.
+func socketIOEventArgs(data json.RawMessage) (string, interface{}, error) {
+	var args []interface{}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return "", nil, err
+	}
+	if len(args) == 0 {
+		return "", nil, errors.New("socket.io event without a name")
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return "", nil, errors.New("socket.io event name is not a string")
+	}
+	switch len(args) {
+	case 1:
+		return name, nil, nil
+	case 2:
+		return name, args[1], nil
+	default:
+		return name, args[1:], nil
+	}
+}
+
+// socketIOPacketPrefix returns the type and namespace prefix for outgoing packets
+func socketIOPacketPrefix(packetType byte, namespace string) string {
+	prefix := string([]byte{engineMessage, packetType})
+	if namespace != "/" {
+		prefix += namespace + ","
+	}
+	return prefix
+}
+
+// socketIOSession is an Engine.IO websocket joined to a Socket.IO namespace
+type socketIOSession struct {
+	conn         *gws.Conn
+	namespace    string
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+}
+
+// readTimeout is how long to wait for any frame before treating the server as gone
+func (s *socketIOSession) readTimeout() time.Duration {
+	if timeout := s.pingInterval + s.pingTimeout; timeout > 0 {
+		return timeout
+	}
+	return 60 * time.Second
+}
+
+// dialSocketIO performs the Engine.IO handshake and connects to the feed's namespace.
+func dialSocketIO(feed models.WebSocketFeed) (*socketIOSession, error) {
+	endpoint, namespace, err := socketIOEndpoint(feed)
+	if err != nil {
+		return nil, err
+	}
+	_, headers, err := feedTarget(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := gws.Dialer{HandshakeTimeout: socketIOHandshakeTimeout}
+	conn, resp, err := dialer.Dial(endpoint, headers)
+	if err != nil {
+		if resp != nil {
+			slog.Warn("failed to dial socket.io feed", "feed_id", feed.ID.Hex(), "status", resp.StatusCode, "error", err)
+		} else {
+			slog.Warn("failed to dial socket.io feed", "feed_id", feed.ID.Hex(), "error", err)
+		}
+		return nil, err
+	}
+
+	session := &socketIOSession{conn: conn, namespace: namespace}
+	if err := session.handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *socketIOSession) handshake() error {
+	if err := s.conn.SetReadDeadline(time.Now().Add(socketIOHandshakeTimeout)); err != nil {
+		return err
+	}
+	defer s.conn.SetReadDeadline(time.Time{})
+
+	_, msg, err := s.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if len(msg) == 0 || msg[0] != engineOpen {
+		return fmt.Errorf("unexpected engine.io open packet %q", truncateFrame(msg))
+	}
+	var open struct {
+		PingInterval int `json:"pingInterval"`
+		PingTimeout  int `json:"pingTimeout"`
+	}
+	if err := json.Unmarshal(msg[1:], &open); err != nil {
+		return fmt.Errorf("decode engine.io open packet: %w", err)
+	}
+	s.pingInterval = time.Duration(open.PingInterval) * time.Millisecond
+	s.pingTimeout = time.Duration(open.PingTimeout) * time.Millisecond
+
+	if err := s.conn.WriteMessage(gws.TextMessage, []byte(socketIOPacketPrefix(socketIOConnect, s.namespace))); err != nil {
+		return err
+	}
+	for {
+		_, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		frame := string(msg)
+		if frame == string(enginePing) {
+			if err := s.conn.WriteMessage(gws.TextMessage, []byte{enginePong}); err != nil {
+				return err
+			}
+			continue
+		}
+		if frame == "" || frame[0] != engineMessage {
+			continue
+		}
+		p, err := parseSocketIOPacket(frame[1:])
+		if err != nil || p.Namespace != s.namespace {
+			continue
+		}
+		switch p.Type {
+		case socketIOConnect:
+			return nil
+		case socketIOConnectError:
+			return fmt.Errorf("socket.io namespace %s rejected connection: %s", s.namespace, string(p.Data))
+		}
+	}
+}
+
+// Emit sends an event to the session's namespace
+func (s *socketIOSession) Emit(args []interface{}) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(gws.TextMessage, append([]byte(socketIOPacketPrefix(socketIOEvent, s.namespace)), data...))
+}
+
+// socketIOFrame is the outcome of handling one frame from the server
+type socketIOFrame struct {
+	Event  string
+	Data   interface{}
+	IsData bool // an event for the session's namespace was received
+	Closed bool // the server closed the session or namespace
+}
+
+// handleFrame answers pings and decodes events for the session's namespace.
+func (s *socketIOSession) handleFrame(msg []byte) (socketIOFrame, error) {
+	if len(msg) == 0 {
+		return socketIOFrame{}, nil
+	}
+	switch msg[0] {
+	case enginePing:
+		return socketIOFrame{}, s.conn.WriteMessage(gws.TextMessage, []byte{enginePong})
+	case engineClose:
+		return socketIOFrame{Closed: true}, nil
+	case engineMessage:
+	default:
+		return socketIOFrame{}, nil
+	}
+
+	p, err := parseSocketIOPacket(string(msg[1:]))
+	if err != nil || p.Namespace != s.namespace {
+		return socketIOFrame{}, nil
+	}
+	switch p.Type {
+	case socketIODisconnect:
+		return socketIOFrame{Closed: true}, nil
+	case socketIOEvent:
+		name, data, err := socketIOEventArgs(p.Data)
+		if err != nil {
+			return socketIOFrame{}, nil
+		}
+		return socketIOFrame{Event: name, Data: data, IsData: true}, nil
+	}
+	return socketIOFrame{}, nil
+}
+
+// socketIOEventMatches reports whether an event passes the feed's EventName
+// filter. An empty name or "*" accepts every event.
+func socketIOEventMatches(feed models.WebSocketFeed, event string) bool {
+	return feed.EventName == "" || feed.EventName == "*" || feed.EventName == event
+}
+
+// sendSocketIOConnectionMessages emits the feed's connection messages. A JSON
+// array is sent as [event, ...args]; anything else as a "message" event.
+func sendSocketIOConnectionMessages(feed models.WebSocketFeed, session *socketIOSession) {
+	messages := feed.ConnectionMessages
+	if feed.ConnectionMessage != "" {
+		messages = append([]string{feed.ConnectionMessage}, messages...)
+	}
+	for _, msg := range messages {
+		if msg == "" {
+			continue
+		}
+		var args []interface{}
+		if err := json.Unmarshal([]byte(msg), &args); err != nil || len(args) == 0 {
+			args = []interface{}{"message", decodeFeedMessage([]byte(msg))}
+		}
+		slog.Debug("emitting connection message to socket.io feed", "feed_id", feed.ID.Hex(), "message", msg)
+		if err := session.Emit(args); err != nil {
+			slog.Warn("failed to emit connection message", "feed_id", feed.ID.Hex(), "error", err)
+		}
+	}
+}
+
+// connectSocketIOFeed joins the feed's Socket.IO namespace and broadcasts matching events.
+func (m *Manager) connectSocketIOFeed(feed models.WebSocketFeed) error {
+	session, err := dialSocketIO(feed)
+	if err != nil {
+		return err
+	}
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "socket.io", "namespace", session.namespace)
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{conn: session.conn, stop: stop}
+	m.feedMu.Unlock()
+
+	sendSocketIOConnectionMessages(feed, session)
+
+	go m.socketIOLoop(feed, session, stop)
+	return nil
+}
+
+// socketIOLoop reads frames until the feed is stopped or the server goes away.
+func (m *Manager) socketIOLoop(feed models.WebSocketFeed, session *socketIOSession, stop chan struct{}) {
+	conn := session.conn
+	defer func() {
+		m.feedClosed(feed)
+		if err := conn.Close(); err != nil {
+			slog.Warn("error closing feed connection", "feed_id", feed.ID.Hex(), "error", err)
+		}
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
+	}()
+
+	msgChan := make(chan []byte, 10)
+	errChan := make(chan error, 1)
+	go func() {
+		for {
+			if err := conn.SetReadDeadline(time.Now().Add(session.readTimeout())); err != nil {
+				errChan <- err
+				return
+			}
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				errChan <- err
+				return
+			}
+			msgChan <- msg
+		}
+	}()
+
+	for {
+		select {
+		case <-stop:
+			slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
+			return
+
+		case msg := <-msgChan:
+			frame, err := session.handleFrame(msg)
+			if err == nil && frame.Closed {
+				err = errors.New("server closed the session")
+			}
+			if err != nil {
+				slog.Warn("feed socket.io error", "feed_id", feed.ID.Hex(), "error", err)
+				m.recordFeedError(feed.ID.Hex(), err)
+				if feed.ReconnectionEnabled {
+					go m.reconnectFeed(feed)
+				}
+				return
+			}
+			if frame.IsData && socketIOEventMatches(feed, frame.Event) {
+				m.BroadcastFeedData(feed, frame.Data, frame.Event)
+			}
+
+		case err := <-errChan:
+			slog.Warn("feed read error", "feed_id", feed.ID.Hex(), "error", err)
+			m.recordFeedError(feed.ID.Hex(), err)
+			if feed.ReconnectionEnabled {
+				go m.reconnectFeed(feed)
+			}
+			return
+		}
+	}
+}
+
+// captureSocketIOSample joins the feed's namespace and returns the first matching event.
+func captureSocketIOSample(ctx context.Context, feed models.WebSocketFeed) (interface{}, error) {
+	session, err := dialSocketIO(feed)
+	if err != nil {
+		return nil, err
+	}
+	defer session.conn.Close()
+
+	sendSocketIOConnectionMessages(feed, session)
+
+	deadline := time.Now().Add(sampleReadTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := session.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	for {
+		_, msg, err := session.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		frame, err := session.handleFrame(msg)
+		if err != nil {
+			return nil, err
+		}
+		if frame.Closed {
+			return nil, errors.New("server closed the session")
+		}
+		if frame.IsData && socketIOEventMatches(feed, frame.Event) {
+			return frame.Data, nil
+		}
+	}
+}
+
+// truncateFrame shortens a frame for error messages
+func truncateFrame(msg []byte) string {
+	if len(msg) > 64 {
+		return string(msg[:64]) + "..."
+	}
+	return string(msg)
+}
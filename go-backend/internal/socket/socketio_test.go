@@ -0,0 +1,150 @@
+package socket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestSocketIOEndpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		endpoint  string
+		namespace string
+	}{
+		{name: "root", url: "https://example.com", endpoint: "wss://example.com/socket.io/?EIO=4&transport=websocket", namespace: "/"},
+		{name: "namespace path", url: "http://example.com/chat", endpoint: "ws://example.com/socket.io/?EIO=4&transport=websocket", namespace: "/chat"},
+		{name: "explicit engine path", url: "wss://example.com/socket.io/?token=a", endpoint: "wss://example.com/socket.io/?EIO=4&token=a&transport=websocket", namespace: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, namespace, err := socketIOEndpoint(models.WebSocketFeed{URL: tt.url})
+			require.NoError(t, err)
+			assert.Equal(t, tt.endpoint, endpoint)
+			assert.Equal(t, tt.namespace, namespace)
+		})
+	}
+}
+
+func TestParseSocketIOPacket(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected socketIOPacket
+		wantErr  bool
+	}{
+		{name: "connect", payload: "0", expected: socketIOPacket{Type: '0', Namespace: "/"}},
+		{name: "connect with namespace and data", payload: `0/chat,{"sid":"a"}`, expected: socketIOPacket{Type: '0', Namespace: "/chat", Data: json.RawMessage(`{"sid":"a"}`)}},
+		{name: "event", payload: `2["price",1]`, expected: socketIOPacket{Type: '2', Namespace: "/", Data: json.RawMessage(`["price",1]`)}},
+		{name: "event with namespace and ack id", payload: `2/chat,12["price",1]`, expected: socketIOPacket{Type: '2', Namespace: "/chat", Data: json.RawMessage(`["price",1]`)}},
+		{name: "binary event", payload: `51-["file",{"_placeholder":true,"num":0}]`, wantErr: true},
+		{name: "empty", payload: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSocketIOPacket(tt.payload)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSocketIOEventArgs(t *testing.T) {
+	name, data, err := socketIOEventArgs(json.RawMessage(`["price",{"p":1}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "price", name)
+	assert.Equal(t, map[string]interface{}{"p": 1.0}, data)
+
+	_, data, err = socketIOEventArgs(json.RawMessage(`["pair",1,2]`))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1.0, 2.0}, data)
+
+	_, _, err = socketIOEventArgs(json.RawMessage(`[1]`))
+	assert.Error(t, err)
+}
+
+func TestManager_ConnectFeed_SocketIO(t *testing.T) {
+	upgrader := gws.Upgrader{}
+	received := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/socket.io/", r.URL.Path)
+		assert.Equal(t, "4", r.URL.Query().Get("EIO"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		write := func(s string) { _ = conn.WriteMessage(gws.TextMessage, []byte(s)) }
+		read := func() string {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return ""
+			}
+			received <- string(msg)
+			return string(msg)
+		}
+
+		write(`0{"sid":"abc","pingInterval":25000,"pingTimeout":20000}`)
+		if read() != "40/markets," {
+			return
+		}
+		write(`40/markets,{"sid":"def"}`)
+		read() // connection message
+		write("2")
+		read()                                // pong
+		write(`42["price",{"p":9}]`)          // other namespace: ignored
+		write(`42/markets,["trade",{"p":5}]`) // filtered by event name
+		write(`42/markets,["price",{"p":1}]`)
+		_, _, _ = conn.ReadMessage() // hold until the client disconnects
+	}))
+	defer srv.Close()
+
+	llm, err := services.NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	m := NewManager(nil, nil, nil, nil)
+	m.SetLLMService(llm)
+
+	feed := models.WebSocketFeed{
+		ID:                 primitive.NewObjectID(),
+		Name:               "Socket.IO",
+		URL:                strings.Replace(srv.URL, "http://", "ws://", 1) + "/markets",
+		ConnectionType:     connectionTypeSocketIO,
+		EventName:          "price",
+		ConnectionMessages: []string{`["subscribe","BTC"]`},
+	}
+	require.NoError(t, m.ConnectFeed(feed))
+	defer m.StopFeed(feed.ID.Hex())
+
+	require.Eventually(t, func() bool {
+		_, ok := llm.LatestEntry(feed.ID.Hex())
+		return ok
+	}, 2*time.Second, 10*time.Millisecond)
+
+	entries := contextEntries(llm, feed.ID.Hex())
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1.0, entries[0]["p"])
+
+	assert.Equal(t, "40/markets,", <-received)
+	assert.Equal(t, `42/markets,["subscribe","BTC"]`, <-received)
+	assert.Equal(t, "3", <-received)
+}
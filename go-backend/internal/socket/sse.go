@@ -0,0 +1,243 @@
+package socket
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// connectionTypeSSE is the feed connection type for Server-Sent Events sources
+const connectionTypeSSE = "sse"
+
+const (
+	// sseDefaultRetry is the reconnect delay used until the server sends a retry field
+	sseDefaultRetry = 3 * time.Second
+	// sseMaxLineBytes bounds a single line of the event stream
+	sseMaxLineBytes = 1 << 20
+)
+
+// sseHTTPClient opens event streams. It has no overall timeout because streams
+// are long-lived; only waiting for the response headers is bounded.
+var sseHTTPClient = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 10 * time.Second
+	return &http.Client{Transport: transport}
+}()
+
+// sseEvent is a single dispatched Server-Sent Event
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseStream parses a text/event-stream body. LastEventID and Retry track the
+// stream state a reconnect has to carry over.
+type sseStream struct {
+	scanner     *bufio.Scanner
+	LastEventID string
+	Retry       time.Duration
+}
+
+func newSSEStream(r io.Reader, lastEventID string) *sseStream {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), sseMaxLineBytes)
+	return &sseStream{scanner: scanner, LastEventID: lastEventID}
+}
+
+// Next returns the next event with data. It returns io.EOF when the stream
+// ends; a partially received event is discarded, as the SSE spec requires.
+func (s *sseStream) Next() (sseEvent, error) {
+	var (
+		data      strings.Builder
+		hasData   bool
+		eventType string
+	)
+	for s.scanner.Scan() {
+		line := strings.TrimSuffix(s.scanner.Text(), "\r")
+		if line == "" {
+			if !hasData {
+				eventType = ""
+				continue
+			}
+			return sseEvent{ID: s.LastEventID, Event: eventType, Data: data.String()}, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "event":
+			eventType = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.LastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return sseEvent{}, err
+	}
+	return sseEvent{}, io.EOF
+}
+
+// openSSEStream requests a feed's event stream, resuming after lastEventID when set.
+func openSSEStream(ctx context.Context, feed models.WebSocketFeed, lastEventID string) (*http.Response, error) {
+	target, headers, err := feedTarget(feed)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := sseHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	return resp, nil
+}
+
+// connectSSEFeed opens the feed's event stream and starts broadcasting its events.
+func (m *Manager) connectSSEFeed(feed models.WebSocketFeed) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := openSSEStream(ctx, feed, "")
+	if err != nil {
+		cancel()
+		slog.Warn("failed to open feed event stream", "feed_id", feed.ID.Hex(), "error", err)
+		return err
+	}
+	slog.Info("connected to feed", "feed_id", feed.ID.Hex(), "transport", "sse")
+
+	stop := make(chan struct{})
+	m.feedMu.Lock()
+	m.feedConns[feed.ID.Hex()] = &feedConnection{stop: stop}
+	m.feedMu.Unlock()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go m.sseLoop(ctx, cancel, feed, resp)
+	return nil
+}
+
+// sseLoop broadcasts events from the feed's stream and reconnects with
+// Last-Event-ID when the stream drops, until the feed is stopped.
+func (m *Manager) sseLoop(ctx context.Context, cancel context.CancelFunc, feed models.WebSocketFeed, resp *http.Response) {
+	defer func() {
+		cancel()
+		m.feedClosed(feed)
+		slog.Info("feed connection closed", "feed_id", feed.ID.Hex())
+	}()
+
+	retry := sseDefaultRetry
+	if feed.ReconnectionDelay > 0 {
+		retry = time.Duration(feed.ReconnectionDelay) * time.Millisecond
+	}
+	lastEventID := ""
+	failures := 0
+
+	for {
+		if resp != nil {
+			stream := newSSEStream(resp.Body, lastEventID)
+			err := m.readSSEStream(feed, stream)
+			resp.Body.Close()
+			lastEventID = stream.LastEventID
+			if stream.Retry > 0 {
+				retry = stream.Retry
+			}
+			if ctx.Err() != nil {
+				slog.Info("feed stopping by request", "feed_id", feed.ID.Hex())
+				return
+			}
+			slog.Warn("feed event stream ended", "feed_id", feed.ID.Hex(), "error", err)
+			m.recordFeedError(feed.ID.Hex(), err)
+		}
+
+		if !feed.ReconnectionEnabled {
+			return
+		}
+		if attemptsExhausted(feed, failures+1) {
+			m.openCircuit(feed, failures)
+			return
+		}
+		// The server's retry field (or the feed's delay) is the first wait, backing off from there
+		delay := backoffDelay(retry, failures+1)
+		m.reportReconnecting(feed, failures+1, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		slog.Info("attempting to reconnect feed", "feed_id", feed.ID.Hex(), "last_event_id", lastEventID)
+		countReconnect(feed)
+		var err error
+		resp, err = openSSEStream(ctx, feed, lastEventID)
+		if err != nil {
+			failures++
+			slog.Warn("failed to reconnect feed", "feed_id", feed.ID.Hex(), "error", err)
+			m.recordFeedError(feed.ID.Hex(), err)
+			continue
+		}
+		failures = 0
+		slog.Info("reconnected feed", "feed_id", feed.ID.Hex())
+		m.markFeedConnected(feed.ID.Hex())
+		m.reportConnected(feed)
+	}
+}
+
+// readSSEStream broadcasts every event in the stream until it ends
+func (m *Manager) readSSEStream(feed models.WebSocketFeed, stream *sseStream) error {
+	for {
+		ev, err := stream.Next()
+		if err != nil {
+			return err
+		}
+		eventName := feed.EventName
+		if ev.Event != "" {
+			eventName = ev.Event
+		}
+		m.BroadcastFeedData(feed, decodeFeedMessage([]byte(ev.Data)), eventName)
+	}
+}
@@ -0,0 +1,149 @@
+package socket
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestSSEStream_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []sseEvent
+	}{
+		{
+			name:     "single data line",
+			body:     "data: hello\n\n",
+			expected: []sseEvent{{Data: "hello"}},
+		},
+		{
+			name:     "multi-line data is joined with newlines",
+			body:     "data: {\"a\":\ndata: 1}\n\n",
+			expected: []sseEvent{{Data: "{\"a\":\n1}"}},
+		},
+		{
+			name:     "event type and id",
+			body:     "event: price\nid: 42\ndata: 1\n\n",
+			expected: []sseEvent{{ID: "42", Event: "price", Data: "1"}},
+		},
+		{
+			name:     "comments and CRLF line endings",
+			body:     ": keep-alive\r\ndata: x\r\n\r\n",
+			expected: []sseEvent{{Data: "x"}},
+		},
+		{
+			name:     "id carries over to later events",
+			body:     "id: 7\ndata: a\n\ndata: b\n\n",
+			expected: []sseEvent{{ID: "7", Data: "a"}, {ID: "7", Data: "b"}},
+		},
+		{
+			name:     "events without data are not dispatched",
+			body:     "event: ping\n\ndata: y\n\n",
+			expected: []sseEvent{{Data: "y"}},
+		},
+		{
+			name:     "trailing partial event is discarded",
+			body:     "data: done\n\ndata: partial",
+			expected: []sseEvent{{Data: "done"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := newSSEStream(strings.NewReader(tt.body), "")
+			var got []sseEvent
+			for {
+				ev, err := stream.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, ev)
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSSEStream_Retry(t *testing.T) {
+	stream := newSSEStream(strings.NewReader("retry: 1500\ndata: x\n\n"), "3")
+	ev, err := stream.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "3", ev.ID, "initial last event id is kept")
+	assert.Equal(t, 1500*time.Millisecond, stream.Retry)
+}
+
+func TestOpenSSEStream_RejectsNonEventStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	_, err := openSSEStream(t.Context(), models.WebSocketFeed{URL: srv.URL}, "")
+	assert.Error(t, err)
+}
+
+func TestManager_ConnectFeed_SSEReconnectsWithLastEventID(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		lastEventIDs []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		n := len(lastEventIDs)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "id: %d\ndata: {\"price\":%d}\n\n", n, n)
+		w.(http.Flusher).Flush()
+		if n > 1 {
+			// Hold the second stream open until the test stops the feed
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	llm, err := services.NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	m := NewManager(nil, nil, nil, nil)
+	m.SetLLMService(llm)
+
+	feed := models.WebSocketFeed{
+		ID:                  primitive.NewObjectID(),
+		Name:                "SSE",
+		URL:                 srv.URL,
+		ConnectionType:      connectionTypeSSE,
+		ReconnectionEnabled: true,
+		ReconnectionDelay:   10,
+	}
+	require.NoError(t, m.ConnectFeed(feed))
+
+	require.Eventually(t, func() bool {
+		entry, ok := llm.LatestEntry(feed.ID.Hex())
+		return ok && entry["price"] == float64(2)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []string{"", "1"}, lastEventIDs)
+	mu.Unlock()
+	assert.True(t, m.IsFeedConnected(feed.ID.Hex()))
+
+	m.StopFeed(feed.ID.Hex())
+	assert.Eventually(t, func() bool { return !m.IsFeedConnected(feed.ID.Hex()) }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,199 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/logging"
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Errors ending a feed stream other than its context
+var (
+	ErrStreamSlow   = errors.New("stream fell too far behind and was closed")
+	ErrStreamClosed = errors.New("stream closed by the server")
+)
+
+// SubscriptionDeniedError says why a feed stream was refused
+type SubscriptionDeniedError struct {
+	Reason string
+}
+
+func (e *SubscriptionDeniedError) Error() string {
+	return e.Reason
+}
+
+// NotFound reports whether the stream was refused because the feed does not exist
+func (e *SubscriptionDeniedError) NotFound() bool {
+	return e.Reason == "feed not found"
+}
+
+// Subscriber identifies who a feed stream is for. UserID must have been
+// verified by the caller; the zero Subscriber is anonymous.
+type Subscriber struct {
+	UserID string
+	// APIKey limits the stream to the feeds the key may read
+	APIKey *models.APIKey
+	IP     string
+}
+
+// FeedMessage is a feed-data message on a feed stream
+type FeedMessage struct {
+	FeedID    string      `json:"feedId"`
+	FeedName  string      `json:"feedName"`
+	EventName string      `json:"eventName"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// FeedStatusChange is a change in a feed's upstream connection on a feed
+// stream: reconnecting, connected, circuit-open, paused or resumed
+type FeedStatusChange struct {
+	FeedID  string `json:"feedId"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// StreamEvent is one event on a feed stream; exactly one field is set
+type StreamEvent struct {
+	Data   *FeedMessage
+	Status *FeedStatusChange
+}
+
+// FeedStream delivers a feed's data room to a subscriber outside the
+// websocket protocol, such as a gRPC or GraphQL call. It is not safe for
+// concurrent use.
+type FeedStream struct {
+	m        *Manager
+	ctx      context.Context
+	client   *Client
+	buffered []StreamEvent
+}
+
+// OpenFeedStream subscribes to a feed's data the way subscribe-feed does: the
+// subscriber must pass the same checks, filters default to those saved on the
+// user's subscription, and up to replayCount recent messages come first. The
+// stream ends when ctx does, when the server shuts down or the subscriber
+// falls too far behind; Close must be called once it is no longer read.
+func (m *Manager) OpenFeedStream(ctx context.Context, sub Subscriber, feedID string, filters []string, replayCount int) (*FeedStream, error) {
+	if m.isClosing() {
+		return nil, ErrStreamClosed
+	}
+	stream := &FeedStream{m: m, ctx: ctx, client: m.streamClient(ctx, sub)}
+	// Tracked like a websocket client so Shutdown drains the stream and ends it
+	m.trackClient(stream.client)
+	client := stream.client
+
+	if reason := m.subscriptionDenial(client, feedID); reason != "" {
+		metrics.WSSubscriptionsDenied.Inc()
+		stream.Close()
+		return nil, &SubscriptionDeniedError{Reason: reason}
+	}
+	if len(filters) == 0 {
+		filters = m.storedFilters(client.userID, feedID)
+	}
+	parsed, err := parseFilters(filters)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+	room := dataRoom(feedID)
+	client.setFilters(room, parsed)
+	m.rooms.Join(room, client)
+	m.trackSubscriber(feedID, client)
+	slog.InfoContext(client.ctx, "stream subscribed to feed data", "feed_id", feedID)
+	m.sendReplay(client, feedID, replayCount)
+	go m.ensureFeedConnection(feedID)
+	return stream, nil
+}
+
+// streamClient returns a client with no connection whose queue is read by a
+// FeedStream or an in-process call
+func (m *Manager) streamClient(ctx context.Context, sub Subscriber) *Client {
+	id := logging.NewID()
+	clientCtx, cancel := context.WithCancel(logging.With(ctx, "conn_id", id))
+	client := &Client{
+		id:            id,
+		ctx:           clientCtx,
+		cancel:        cancel,
+		ip:            sub.IP,
+		apiKey:        sub.APIKey,
+		authenticated: sub.UserID != "",
+		out:           make(chan WSMessage, clientQueueSize),
+		connectedAt:   time.Now().UTC(),
+	}
+	if sub.UserID != "" {
+		m.identifyClient(client, sub.UserID)
+	}
+	return client
+}
+
+// Next waits for the stream's next event
+func (s *FeedStream) Next() (StreamEvent, error) {
+	for len(s.buffered) == 0 {
+		select {
+		case msg := <-s.client.out:
+			s.client.pending.Add(-1)
+			s.buffered = streamEvents(msg)
+		case <-s.client.ctx.Done():
+			if s.client.slow.Load() {
+				return StreamEvent{}, ErrStreamSlow
+			}
+			if err := s.ctx.Err(); err != nil {
+				return StreamEvent{}, err
+			}
+			return StreamEvent{}, ErrStreamClosed
+		}
+	}
+	event := s.buffered[0]
+	s.buffered = s.buffered[1:]
+	s.client.sent.Add(1)
+	return event, nil
+}
+
+// Close unsubscribes the stream
+func (s *FeedStream) Close() {
+	s.m.untrackClient(s.client)
+	s.m.rooms.LeaveAll(s.client)
+	s.m.untrackAllSubscriptions(s.client)
+	s.client.cancel()
+}
+
+// streamEvents converts a message queued for a stream into its events;
+// messages streams do not carry convert to none
+func streamEvents(msg WSMessage) []StreamEvent {
+	switch msg.Type {
+	case "feed-data":
+		var data FeedMessage
+		if err := json.Unmarshal(msg.Payload, &data); err != nil {
+			slog.Warn("skipping unreadable feed data", "error", err)
+			return nil
+		}
+		return []StreamEvent{{Data: &data}}
+	case "feed-replay":
+		var replay struct {
+			Messages []FeedMessage `json:"messages"`
+		}
+		if err := json.Unmarshal(msg.Payload, &replay); err != nil {
+			slog.Warn("skipping unreadable feed replay", "error", err)
+			return nil
+		}
+		events := make([]StreamEvent, len(replay.Messages))
+		for i := range replay.Messages {
+			events[i] = StreamEvent{Data: &replay.Messages[i]}
+		}
+		return events
+	case "feed-status", "feed-paused", "feed-resumed":
+		var status FeedStatusChange
+		if err := json.Unmarshal(msg.Payload, &status); err != nil {
+			slog.Warn("skipping unreadable feed status", "event", msg.Type, "error", err)
+			return nil
+		}
+		return []StreamEvent{{Status: &status}}
+	}
+	return nil
+}
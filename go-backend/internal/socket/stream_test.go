@@ -0,0 +1,67 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestFeedStream_DeliversRoomEvents(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Name: "Prices", IsActive: true}
+	feedID := feed.ID.Hex()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := m.OpenFeedStream(ctx, Subscriber{UserID: "u1"}, feedID, []string{`data.price > 10`}, 0)
+	require.NoError(t, err)
+	assert.Len(t, m.rooms.Members(userRoom("u1")), 1, "the subscriber gets the user's events too")
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 5.0}, "tick")
+	m.BroadcastFeedData(feed, map[string]interface{}{"price": 12.0}, "tick")
+	event, err := stream.Next()
+	require.NoError(t, err)
+	require.NotNil(t, event.Data)
+	assert.Equal(t, "Prices", event.Data.FeedName)
+	assert.Equal(t, map[string]interface{}{"price": 12.0}, event.Data.Data)
+
+	cancel()
+	_, err = stream.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+	stream.Close()
+	assert.Empty(t, m.rooms.Members(dataRoom(feedID)))
+	assert.Empty(t, m.rooms.Members(userRoom("u1")))
+}
+
+func TestFeedStream_Denied(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	key := &models.APIKey{Grants: []models.APIKeyGrant{{FeedID: "other", Scopes: []string{models.APIKeyScopeRead}}}}
+
+	_, err := m.OpenFeedStream(context.Background(), Subscriber{UserID: "u1", APIKey: key}, "f1", nil, 0)
+	var denied *SubscriptionDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.False(t, denied.NotFound())
+	assert.Empty(t, m.rooms.Members(userRoom("u1")), "a refused stream leaves no client behind")
+}
+
+func TestStreamEvents(t *testing.T) {
+	first, _ := json.Marshal(FeedMessage{FeedID: "f1", EventName: "tick", Data: map[string]interface{}{"price": 1.0}})
+	second, _ := json.Marshal(FeedMessage{FeedID: "f1", EventName: "tick", Data: []interface{}{"a", true}})
+	replay := makeMessage("feed-replay", map[string]interface{}{"feedId": "f1", "messages": []json.RawMessage{first, second}, "count": 2})
+
+	events := streamEvents(replay)
+	require.Len(t, events, 2)
+	assert.Equal(t, map[string]interface{}{"price": 1.0}, events[0].Data.Data)
+	assert.Equal(t, []interface{}{"a", true}, events[1].Data.Data)
+
+	events = streamEvents(makeMessage("feed-resumed", feedStatus{FeedID: "f1", Status: "resumed", Message: "feed resumed"}))
+	require.Len(t, events, 1)
+	assert.Equal(t, &FeedStatusChange{FeedID: "f1", Status: "resumed", Message: "feed resumed"}, events[0].Status)
+	assert.Empty(t, streamEvents(makeMessage("connection-stats", nil)), "events streams do not carry are skipped")
+}
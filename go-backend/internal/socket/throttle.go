@@ -0,0 +1,134 @@
+package socket
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/metrics"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+const (
+	// dedupeWindow is how many recently broadcast dedupe keys each feed remembers
+	dedupeWindow = 1000
+	// maxThrottleIntervalMs caps FeedThrottle.MinIntervalMs at one hour
+	maxThrottleIntervalMs = 60 * 60 * 1000
+	// maxThrottleSampleEvery caps FeedThrottle.SampleEvery
+	maxThrottleSampleEvery = 10000
+)
+
+// Reasons a throttled message was not broadcast, used as metric labels
+const (
+	skipDuplicate = "duplicate"
+	skipInterval  = "interval"
+	skipSample    = "sample"
+)
+
+// ValidateFeedThrottle checks a feed's throttle options; nil is valid
+func ValidateFeedThrottle(t *models.FeedThrottle) error {
+	if t == nil {
+		return nil
+	}
+	if t.MinIntervalMs < 0 || t.MinIntervalMs > maxThrottleIntervalMs {
+		return errors.New("throttle.minIntervalMs must be between 0 and 3600000")
+	}
+	if t.SampleEvery < 0 || t.SampleEvery > maxThrottleSampleEvery {
+		return errors.New("throttle.sampleEvery must be between 0 and 10000")
+	}
+	return nil
+}
+
+// feedThrottle applies one feed's throttle options to its messages
+type feedThrottle struct {
+	opts     *models.FeedThrottle
+	mu       sync.Mutex
+	seen     map[[sha256.Size]byte]struct{}
+	order    [][sha256.Size]byte // remembered keys, oldest first
+	lastSent time.Time
+	passed   int // messages that got past dedupe and interval, for sampling
+}
+
+func newFeedThrottle(opts *models.FeedThrottle) *feedThrottle {
+	return &feedThrottle{opts: opts, seen: make(map[[sha256.Size]byte]struct{})}
+}
+
+// allow reports whether a message may be broadcast at now, or why not.
+// Messages without the dedupe key are never treated as duplicates.
+func (t *feedThrottle) allow(data interface{}, now time.Time) (bool, string) {
+	if t.opts == nil {
+		return true, ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var key [sha256.Size]byte
+	hasKey := false
+	if t.opts.DedupeKey != "" {
+		if value, err := extractDataPath(data, t.opts.DedupeKey); err == nil {
+			key, hasKey = payloadDigest(value), true
+			if _, dup := t.seen[key]; dup {
+				return false, skipDuplicate
+			}
+		}
+	}
+	if t.opts.MinIntervalMs > 0 && !t.lastSent.IsZero() &&
+		now.Sub(t.lastSent) < time.Duration(t.opts.MinIntervalMs)*time.Millisecond {
+		return false, skipInterval
+	}
+	if t.opts.SampleEvery > 1 {
+		t.passed++
+		if (t.passed-1)%t.opts.SampleEvery != 0 {
+			return false, skipSample
+		}
+	}
+
+	if hasKey {
+		t.remember(key)
+	}
+	t.lastSent = now
+	return true, ""
+}
+
+// remember records a broadcast dedupe key, forgetting the oldest past dedupeWindow
+func (t *feedThrottle) remember(key [sha256.Size]byte) {
+	t.seen[key] = struct{}{}
+	t.order = append(t.order, key)
+	if len(t.order) > dedupeWindow {
+		delete(t.seen, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// allowBroadcast applies the feed's throttle options to a transformed message
+func (m *Manager) allowBroadcast(feed models.WebSocketFeed, data interface{}, now time.Time) bool {
+	feedID := feed.ID.Hex()
+	m.throttleMu.Lock()
+	if m.throttles == nil {
+		m.throttles = make(map[string]*feedThrottle)
+	}
+	t, ok := m.throttles[feedID]
+	if !ok {
+		t = newFeedThrottle(feed.Throttle)
+		m.throttles[feedID] = t
+	}
+	m.throttleMu.Unlock()
+
+	allowed, reason := t.allow(data, now)
+	if !allowed {
+		metrics.FeedMessagesSkipped.WithLabelValues(feedID, reason).Inc()
+	}
+	return allowed
+}
+
+// SetFeedThrottle replaces a connected feed's throttle options after the feed
+// is updated. Remembered dedupe keys and counters start over.
+func (m *Manager) SetFeedThrottle(feedID string, opts *models.FeedThrottle) {
+	m.throttleMu.Lock()
+	defer m.throttleMu.Unlock()
+	if m.throttles == nil {
+		m.throttles = make(map[string]*feedThrottle)
+	}
+	m.throttles[feedID] = newFeedThrottle(opts)
+}
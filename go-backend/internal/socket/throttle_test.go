@@ -0,0 +1,113 @@
+package socket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestValidateFeedThrottle(t *testing.T) {
+	assert.NoError(t, ValidateFeedThrottle(nil))
+	assert.NoError(t, ValidateFeedThrottle(&models.FeedThrottle{DedupeKey: "$.id", MinIntervalMs: 500, SampleEvery: 10}))
+	assert.Error(t, ValidateFeedThrottle(&models.FeedThrottle{MinIntervalMs: -1}))
+	assert.Error(t, ValidateFeedThrottle(&models.FeedThrottle{MinIntervalMs: maxThrottleIntervalMs + 1}))
+	assert.Error(t, ValidateFeedThrottle(&models.FeedThrottle{SampleEvery: -2}))
+}
+
+func TestFeedThrottle_Dedupe(t *testing.T) {
+	th := newFeedThrottle(&models.FeedThrottle{DedupeKey: "$.id"})
+	now := time.Now()
+
+	allowed, _ := th.allow(map[string]interface{}{"id": 1.0, "v": "a"}, now)
+	assert.True(t, allowed)
+	allowed, reason := th.allow(map[string]interface{}{"id": 1.0, "v": "b"}, now)
+	assert.False(t, allowed)
+	assert.Equal(t, skipDuplicate, reason)
+	allowed, _ = th.allow(map[string]interface{}{"id": 2.0}, now)
+	assert.True(t, allowed)
+	// Messages without the key are not deduplicated
+	allowed, _ = th.allow(map[string]interface{}{"v": "c"}, now)
+	assert.True(t, allowed)
+	allowed, _ = th.allow(map[string]interface{}{"v": "c"}, now)
+	assert.True(t, allowed)
+
+	// Keys are forgotten once the window is full
+	for i := 0; i < dedupeWindow; i++ {
+		th.allow(map[string]interface{}{"id": float64(100 + i)}, now)
+	}
+	allowed, _ = th.allow(map[string]interface{}{"id": 1.0}, now)
+	assert.True(t, allowed)
+}
+
+func TestFeedThrottle_WholeMessageDedupe(t *testing.T) {
+	th := newFeedThrottle(&models.FeedThrottle{DedupeKey: "$"})
+	now := time.Now()
+	allowed, _ := th.allow(map[string]interface{}{"a": 1.0, "b": 2.0}, now)
+	assert.True(t, allowed)
+	allowed, _ = th.allow(map[string]interface{}{"b": 2.0, "a": 1.0}, now)
+	assert.False(t, allowed)
+}
+
+func TestFeedThrottle_MinInterval(t *testing.T) {
+	th := newFeedThrottle(&models.FeedThrottle{MinIntervalMs: 100, DedupeKey: "$.id"})
+	start := time.Now()
+
+	allowed, _ := th.allow(map[string]interface{}{"id": 1.0}, start)
+	assert.True(t, allowed)
+	allowed, reason := th.allow(map[string]interface{}{"id": 2.0}, start.Add(50*time.Millisecond))
+	assert.False(t, allowed)
+	assert.Equal(t, skipInterval, reason)
+	// A message held back by the interval is not remembered as broadcast
+	allowed, _ = th.allow(map[string]interface{}{"id": 2.0}, start.Add(100*time.Millisecond))
+	assert.True(t, allowed)
+}
+
+func TestFeedThrottle_Sample(t *testing.T) {
+	th := newFeedThrottle(&models.FeedThrottle{SampleEvery: 3})
+	var sent []int
+	for i := 0; i < 7; i++ {
+		if allowed, _ := th.allow(float64(i), time.Now()); allowed {
+			sent = append(sent, i)
+		}
+	}
+	assert.Equal(t, []int{0, 3, 6}, sent)
+
+	allowed, _ := newFeedThrottle(nil).allow("x", time.Now())
+	assert.True(t, allowed)
+}
+
+func TestManager_BroadcastThrottled(t *testing.T) {
+	m := NewManager(nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{ctx: ctx, cancel: cancel, out: make(chan WSMessage, 16)}
+
+	feed := models.WebSocketFeed{ID: primitive.NewObjectID(), Throttle: &models.FeedThrottle{DedupeKey: "$.id"}}
+	m.rooms.Join(dataRoom(feed.ID.Hex()), client)
+
+	countFeedData := func() int {
+		n := 0
+		for len(client.out) > 0 {
+			if msg := <-client.out; msg.Type == "feed-data" {
+				n++
+			}
+		}
+		return n
+	}
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"id": "a"}, "")
+	m.BroadcastFeedData(feed, map[string]interface{}{"id": "a"}, "")
+	m.BroadcastFeedData(feed, map[string]interface{}{"id": "b"}, "")
+	assert.Equal(t, 2, countFeedData())
+
+	// Updated options replace the connection's and start over
+	m.SetFeedThrottle(feed.ID.Hex(), nil)
+	m.BroadcastFeedData(feed, map[string]interface{}{"id": "a"}, "")
+	m.BroadcastFeedData(feed, map[string]interface{}{"id": "a"}, "")
+	assert.Equal(t, 2, countFeedData())
+}
@@ -0,0 +1,83 @@
+package socket
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// applyTransform reshapes a decoded feed message with the feed's transform.
+// A nil transform returns the message unchanged; an error means the message
+// did not match the transform's Path.
+func applyTransform(t *models.FeedTransform, data interface{}) (interface{}, error) {
+	if t == nil {
+		return data, nil
+	}
+	if t.Path != "" {
+		selected, err := extractDataPath(data, t.Path)
+		if err != nil {
+			return nil, err
+		}
+		data = selected
+	}
+	if len(t.Fields) == 0 && len(t.Rename) == 0 && len(t.Numeric) == 0 {
+		return data, nil
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return transformObject(t, v), nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				out[i] = transformObject(t, obj)
+			} else {
+				out[i] = item
+			}
+		}
+		return out, nil
+	default:
+		return data, nil
+	}
+}
+
+// transformObject applies the field selection, renames and numeric
+// conversions to one object. The input is not modified.
+func transformObject(t *models.FeedTransform, obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	if len(t.Fields) > 0 {
+		for _, field := range t.Fields {
+			v, err := extractDataPath(obj, field)
+			if err != nil {
+				continue
+			}
+			segments := splitDataPath(field)
+			if len(segments) == 0 {
+				continue
+			}
+			out[segments[len(segments)-1]] = v
+		}
+	} else {
+		for k, v := range obj {
+			out[k] = v
+		}
+	}
+
+	for from, to := range t.Rename {
+		if v, ok := out[from]; ok && to != "" {
+			delete(out, from)
+			out[to] = v
+		}
+	}
+
+	for _, key := range t.Numeric {
+		if s, ok := out[key].(string); ok {
+			if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				out[key] = n
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+func TestApplyTransform(t *testing.T) {
+	message := map[string]interface{}{
+		"type": "ticker",
+		"data": map[string]interface{}{
+			"s": "BTCUSDT",
+			"c": "42000.50",
+			"v": "12.5",
+			"meta": map[string]interface{}{
+				"exchange": "binance",
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		transform   *models.FeedTransform
+		input       interface{}
+		expected    interface{}
+		expectError bool
+	}{
+		{
+			name:     "nil transform passes through",
+			input:    message,
+			expected: message,
+		},
+		{
+			name:      "path selects a sub-document",
+			transform: &models.FeedTransform{Path: "$.data.meta"},
+			input:     message,
+			expected:  map[string]interface{}{"exchange": "binance"},
+		},
+		{
+			name: "select, rename and convert",
+			transform: &models.FeedTransform{
+				Path:    "$.data",
+				Fields:  []string{"s", "c", "meta.exchange"},
+				Rename:  map[string]string{"s": "symbol", "c": "price"},
+				Numeric: []string{"price"},
+			},
+			input: message,
+			expected: map[string]interface{}{
+				"symbol":   "BTCUSDT",
+				"price":    42000.5,
+				"exchange": "binance",
+			},
+		},
+		{
+			name:      "unparsable numbers are left as strings",
+			transform: &models.FeedTransform{Numeric: []string{"price"}},
+			input:     map[string]interface{}{"price": "n/a"},
+			expected:  map[string]interface{}{"price": "n/a"},
+		},
+		{
+			name:      "arrays are transformed element by element",
+			transform: &models.FeedTransform{Fields: []string{"id"}},
+			input: []interface{}{
+				map[string]interface{}{"id": 1.0, "noise": true},
+				"not an object",
+			},
+			expected: []interface{}{
+				map[string]interface{}{"id": 1.0},
+				"not an object",
+			},
+		},
+		{
+			name:        "message not matching the path",
+			transform:   &models.FeedTransform{Path: "$.result"},
+			input:       message,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyTransform(tt.transform, tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	assert.Equal(t, "42000.50", message["data"].(map[string]interface{})["c"], "input is not modified")
+}
+
+func TestManager_BroadcastFeedData_AppliesTransform(t *testing.T) {
+	llm, err := services.NewLLMService(config.Config{LLMContextLimit: 10})
+	require.NoError(t, err)
+	m := NewManager(nil, nil, nil, nil)
+	m.SetLLMService(llm)
+
+	feed := models.WebSocketFeed{
+		ID:        primitive.NewObjectID(),
+		Transform: &models.FeedTransform{Path: "$.data", Numeric: []string{"price"}},
+	}
+	m.BroadcastFeedData(feed, map[string]interface{}{"event": "heartbeat"}, "")
+	_, ok := llm.LatestEntry(feed.ID.Hex())
+	assert.False(t, ok, "messages not matching the transform are dropped")
+
+	m.BroadcastFeedData(feed, map[string]interface{}{"data": map[string]interface{}{"price": "1.5"}}, "")
+	entry, ok := llm.LatestEntry(feed.ID.Hex())
+	require.True(t, ok)
+	assert.Equal(t, 1.5, entry["price"])
+}
@@ -0,0 +1,42 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+	"github.com/turboline-ai/turbostream/go-backend/internal/services"
+)
+
+// SetLLMUsageService sets the ledger answered queries are recorded in;
+// without one no usage is recorded
+func (m *Manager) SetLLMUsageService(usage *services.LLMUsageService) {
+	m.usage = usage
+}
+
+// recordLLMUsage adds a user's answered query to the usage ledger
+func (m *Manager) recordLLMUsage(userID, mode string, resp *services.QueryResponse) {
+	if m.usage == nil || userID == "" {
+		return
+	}
+	entry := models.LLMUsage{
+		UserID:           userID,
+		FeedID:           resp.FeedID,
+		Provider:         resp.Provider,
+		Mode:             mode,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		LatencyMs:        resp.Duration,
+		Cached:           resp.Cached,
+		OwnKey:           resp.OwnKey,
+	}
+	if fc := m.llm.GetFeedContext(resp.FeedID); fc != nil {
+		entry.FeedName = fc.FeedName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.usage.Record(ctx, entry); err != nil {
+		slog.Warn("failed to record LLM usage", "user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,161 @@
+package socket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Bounds on HTTP polling options, in milliseconds
+const (
+	maxPollInterval = 24 * 60 * 60 * 1000
+	maxPollTimeout  = 5 * 60 * 1000
+)
+
+// connectionTypePush is the feed connection type for data its producers post
+// to the ingestion endpoint; nothing is dialled for it
+const connectionTypePush = "push"
+
+// feedURLSchemes are the URL schemes each connection type dials. Kafka feeds
+// take a broker list instead of a URL, and simulated and push feeds need none.
+var feedURLSchemes = map[string][]string{
+	"":                        {"ws", "wss"},
+	"websocket":               {"ws", "wss"},
+	connectionTypeProtobuf:    {"ws", "wss"},
+	connectionTypeSocketIO:    {"http", "https", "ws", "wss"},
+	connectionTypeSSE:         {"http", "https"},
+	connectionTypeHTTPPolling: {"http", "https"},
+	connectionTypeMQTT:        {"tcp", "ssl", "tls", "mqtt", "mqtts", "ws", "wss"},
+	connectionTypeKafka:       nil,
+	connectionTypeSimulated:   nil,
+	connectionTypePush:        nil,
+}
+
+// FieldError is a problem with one feed field, for forms to show next to it.
+// Field is the JSON name, with a dotted suffix for nested options, e.g.
+// "httpConfig.pollingInterval" or "connectionMessages.1".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateFeed checks what a feed needs to connect: a URL its connection type
+// can dial, an event name for Socket.IO, well-formed JSON connection
+// messages, sane HTTP polling and simulated feed options, and valid data
+// format, throttle, aggregation and anomaly detection options. It returns every problem found.
+func ValidateFeed(feed models.WebSocketFeed) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(feed.Name) == "" {
+		add("name", "name is required")
+	}
+	schemes, known := feedURLSchemes[feed.ConnectionType]
+	if !known {
+		add("connectionType", "unknown connectionType %q", feed.ConnectionType)
+	}
+	if field, msg := validateFeedURL(feed, schemes); msg != "" {
+		add(field, "%s", msg)
+	}
+	if feed.ConnectionType == connectionTypeSocketIO && strings.TrimSpace(feed.EventName) == "" {
+		add("eventName", "eventName is required for socketio feeds")
+	}
+
+	if !validConnectionMessage(feed.ConnectionMessage, feed.ConnectionMessageFormat) {
+		add("connectionMessage", "connectionMessage is not valid JSON")
+	}
+	for i, msg := range feed.ConnectionMessages {
+		if !validConnectionMessage(msg, feed.ConnectionMessageFormat) {
+			add(fmt.Sprintf("connectionMessages.%d", i), "connection message %d is not valid JSON", i+1)
+		}
+	}
+
+	if cfg := feed.HTTPConfig; cfg != nil && feed.ConnectionType == connectionTypeHTTPPolling {
+		if cfg.PollingInterval != 0 && (cfg.PollingInterval < int(pollMinInterval.Milliseconds()) || cfg.PollingInterval > maxPollInterval) {
+			add("httpConfig.pollingInterval", "pollingInterval must be between %d and %d milliseconds", pollMinInterval.Milliseconds(), maxPollInterval)
+		}
+		if cfg.Timeout < 0 || cfg.Timeout > maxPollTimeout {
+			add("httpConfig.timeout", "timeout must be between 0 and %d milliseconds", maxPollTimeout)
+		}
+		switch strings.ToUpper(cfg.Method) {
+		case "", "GET", "POST", "PUT", "PATCH":
+		default:
+			add("httpConfig.method", "method must be GET, POST, PUT or PATCH")
+		}
+		switch cfg.ResponseFormat {
+		case "", "json", "text":
+		default:
+			add("httpConfig.responseFormat", "responseFormat must be json or text")
+		}
+	}
+
+	if err := ValidateSimulatedConfig(feed.SimulatedConfig); err != nil {
+		add("simulatedConfig", "%s", err.Error())
+	}
+	if known {
+		if err := ValidateFeedDataFormat(feed); err != nil {
+			add("dataFormat", "%s", err.Error())
+		}
+	}
+	if err := ValidateFeedThrottle(feed.Throttle); err != nil {
+		add("throttle", "%s", err.Error())
+	}
+	if err := ValidateFeedAggregation(feed.Aggregation); err != nil {
+		add("aggregation", "%s", err.Error())
+	}
+	if err := ValidateFeedAnomalyDetection(feed.AnomalyDetection); err != nil {
+		add("anomalyDetection", "%s", err.Error())
+	}
+	return errs
+}
+
+// validateFeedURL checks the feed URL has a host and one of schemes,
+// returning the field at fault and why
+func validateFeedURL(feed models.WebSocketFeed, schemes []string) (string, string) {
+	raw := strings.TrimSpace(feed.URL)
+	if feed.ConnectionType == connectionTypeKafka {
+		if raw == "" && (feed.KafkaConfig == nil || len(feed.KafkaConfig.Brokers) == 0) {
+			return "url", "url or kafkaConfig.brokers is required"
+		}
+		return "", ""
+	}
+	if feed.ConnectionType == connectionTypeSimulated || feed.ConnectionType == connectionTypePush {
+		return "", ""
+	}
+	if raw == "" {
+		return "url", "url is required"
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "url", "url must be an absolute URL with a host"
+	}
+	if schemes == nil {
+		return "", ""
+	}
+	scheme := strings.ToLower(u.Scheme)
+	for _, s := range schemes {
+		if scheme == s {
+			return "", ""
+		}
+	}
+	return "url", fmt.Sprintf("url scheme must be %s for this connection type", strings.Join(schemes, ", "))
+}
+
+// validConnectionMessage reports whether a connection message is well formed.
+// Messages must be JSON when the format is "json", and ones that look like
+// JSON must parse; secret messages hidden from the client are not checked.
+func validConnectionMessage(msg, format string) bool {
+	msg = strings.TrimSpace(msg)
+	if msg == "" || msg == models.RedactedValue || models.IsSealedSecret(msg) {
+		return true
+	}
+	if format != "json" && !strings.HasPrefix(msg, "{") && !strings.HasPrefix(msg, "[") {
+		return true
+	}
+	return json.Valid([]byte(msg))
+}
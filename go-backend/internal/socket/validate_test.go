@@ -0,0 +1,65 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestValidateFeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		feed   models.WebSocketFeed
+		fields []string
+	}{
+		{"websocket", models.WebSocketFeed{Name: "ticks", URL: "wss://example.com/ws"}, nil},
+		{"missing name and url", models.WebSocketFeed{}, []string{"name", "url"}},
+		{"relative url", models.WebSocketFeed{Name: "ticks", URL: "/ws"}, []string{"url"}},
+		{"websocket over http", models.WebSocketFeed{Name: "ticks", URL: "https://example.com"}, []string{"url"}},
+		{"sse", models.WebSocketFeed{Name: "ticks", ConnectionType: "sse", URL: "https://example.com/events"}, nil},
+		{"sse over websocket", models.WebSocketFeed{Name: "ticks", ConnectionType: "sse", URL: "wss://example.com"}, []string{"url"}},
+		{"unknown connection type", models.WebSocketFeed{Name: "ticks", ConnectionType: "carrier-pigeon", URL: "wss://example.com"}, []string{"connectionType"}},
+		{"socketio without event", models.WebSocketFeed{Name: "ticks", ConnectionType: "socketio", URL: "https://example.com"}, []string{"eventName"}},
+		{"socketio", models.WebSocketFeed{Name: "ticks", ConnectionType: "socketio", URL: "https://example.com", EventName: "tick"}, nil},
+		{"kafka brokers", models.WebSocketFeed{Name: "ticks", ConnectionType: "kafka", KafkaConfig: &models.KafkaConfig{Brokers: []string{"broker:9092"}}}, nil},
+		{"kafka without brokers", models.WebSocketFeed{Name: "ticks", ConnectionType: "kafka"}, []string{"url"}},
+		{"mqtt", models.WebSocketFeed{Name: "ticks", ConnectionType: "mqtt", URL: "tcp://broker:1883"}, nil},
+		{"simulated", models.WebSocketFeed{Name: "ticks", ConnectionType: "simulated"}, nil},
+		{"simulated generator", models.WebSocketFeed{Name: "ticks", ConnectionType: "simulated", SimulatedConfig: &models.SimulatedConfig{Generator: "weather"}}, []string{"simulatedConfig"}},
+		{"push", models.WebSocketFeed{Name: "pushed", ConnectionType: "push"}, nil},
+		{
+			"malformed json messages",
+			models.WebSocketFeed{Name: "ticks", URL: "wss://example.com", ConnectionMessage: `{"op":`, ConnectionMessages: []string{`{"op":"sub"}`, `[1,`}},
+			[]string{"connectionMessage", "connectionMessages.1"},
+		},
+		{"plain text message", models.WebSocketFeed{Name: "ticks", URL: "wss://example.com", ConnectionMessage: "subscribe ticks"}, nil},
+		{"text message declared json", models.WebSocketFeed{Name: "ticks", URL: "wss://example.com", ConnectionMessage: "subscribe", ConnectionMessageFormat: "json"}, []string{"connectionMessage"}},
+		{"redacted message", models.WebSocketFeed{Name: "ticks", URL: "wss://example.com", ConnectionMessage: models.RedactedValue, ConnectionMessageFormat: "json"}, nil},
+		{
+			"polling options",
+			models.WebSocketFeed{Name: "ticks", ConnectionType: "http-polling", URL: "https://example.com",
+				HTTPConfig: &models.HTTPPollingConfig{Method: "DELETE", PollingInterval: 10, Timeout: -1, ResponseFormat: "xml"}},
+			[]string{"httpConfig.pollingInterval", "httpConfig.timeout", "httpConfig.method", "httpConfig.responseFormat"},
+		},
+		{
+			"polling",
+			models.WebSocketFeed{Name: "ticks", ConnectionType: "http-polling", URL: "https://example.com",
+				HTTPConfig: &models.HTTPPollingConfig{Method: "get", PollingInterval: 5000, Timeout: 2000, ResponseFormat: "json"}},
+			nil,
+		},
+		{"data format", models.WebSocketFeed{Name: "ticks", URL: "wss://example.com", DataFormat: "xml"}, []string{"dataFormat"}},
+		{"throttle", models.WebSocketFeed{Name: "ticks", URL: "wss://example.com", Throttle: &models.FeedThrottle{SampleEvery: -1}}, []string{"throttle"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, e := range ValidateFeed(tt.feed) {
+				assert.NotEmpty(t, e.Message)
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
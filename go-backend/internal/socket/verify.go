@@ -0,0 +1,87 @@
+package socket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// Names of the automatic checks run before a feed is verified
+const (
+	checkConnectivity = "connectivity"
+	checkSchema       = "schema"
+)
+
+// CheckFeed runs the automatic verification checks: the feed must deliver a
+// message over a fresh connection, and that message must be structured data
+// that survives the feed's transform.
+func (m *Manager) CheckFeed(ctx context.Context, feed models.WebSocketFeed) models.FeedVerification {
+	v := models.FeedVerification{CheckedAt: time.Now().UTC()}
+	sample, err := m.CaptureFeedSample(ctx, feed)
+	if err != nil {
+		v.Checks = []models.FeedCheck{
+			{Name: checkConnectivity, Message: err.Error()},
+			{Name: checkSchema, Message: "skipped, no message received"},
+		}
+		return v
+	}
+	v.Checks = []models.FeedCheck{
+		{Name: checkConnectivity, Passed: true, Message: "received a message"},
+		checkFeedSchema(feed, sample),
+	}
+	v.Passed = v.Checks[1].Passed
+	return v
+}
+
+// checkFeedSchema checks that a sample message is a JSON object or an array
+// of objects, after the feed's transform when it has one
+func checkFeedSchema(feed models.WebSocketFeed, sample interface{}) models.FeedCheck {
+	check := models.FeedCheck{Name: checkSchema}
+	data, err := applyTransform(feed.Transform, sample)
+	if err != nil {
+		check.Message = fmt.Sprintf("message does not match the feed transform: %v", err)
+		return check
+	}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			check.Message = "message is an empty object"
+			return check
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			check.Message = "message is an empty array"
+			return check
+		}
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); !ok {
+				check.Message = "message array contains non-object items"
+				return check
+			}
+		}
+	default:
+		check.Message = fmt.Sprintf("message is %s, not a JSON object or array of objects", describeValue(data))
+		return check
+	}
+	check.Passed = true
+	check.Message = "message is structured JSON"
+	return check
+}
+
+// describeValue names the JSON type of a decoded value for check messages
+func describeValue(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "text"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,78 @@
+package socket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+func TestCheckFeedSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform *models.FeedTransform
+		sample    interface{}
+		passed    bool
+	}{
+		{name: "object", sample: map[string]interface{}{"price": 1.0}, passed: true},
+		{name: "array of objects", sample: []interface{}{map[string]interface{}{"price": 1.0}}, passed: true},
+		{name: "plain text", sample: "hello", passed: false},
+		{name: "number", sample: 42.0, passed: false},
+		{name: "empty object", sample: map[string]interface{}{}, passed: false},
+		{name: "array of scalars", sample: []interface{}{1.0, 2.0}, passed: false},
+		{
+			name:      "transform path matches",
+			transform: &models.FeedTransform{Path: "$.data"},
+			sample:    map[string]interface{}{"data": map[string]interface{}{"price": 1.0}},
+			passed:    true,
+		},
+		{
+			name:      "transform path missing",
+			transform: &models.FeedTransform{Path: "$.data"},
+			sample:    map[string]interface{}{"price": 1.0},
+			passed:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checkFeedSchema(models.WebSocketFeed{Transform: tt.transform}, tt.sample)
+			assert.Equal(t, checkSchema, check.Name)
+			assert.Equal(t, tt.passed, check.Passed, check.Message)
+			assert.NotEmpty(t, check.Message)
+		})
+	}
+}
+
+func TestCheckFeed(t *testing.T) {
+	body := `{"symbol":"BTCUSDT","price":42000}`
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer upstream.Close()
+
+	m := NewManager(nil, nil, nil, nil)
+	feed := models.WebSocketFeed{URL: upstream.URL, ConnectionType: connectionTypeHTTPPolling}
+
+	v := m.CheckFeed(context.Background(), feed)
+	assert.True(t, v.Passed)
+	require.Len(t, v.Checks, 2)
+	assert.True(t, v.Checks[0].Passed)
+	assert.True(t, v.Checks[1].Passed)
+
+	body = `"ok"`
+	v = m.CheckFeed(context.Background(), feed)
+	assert.False(t, v.Passed, "a bare string fails the schema check")
+	assert.True(t, v.Checks[0].Passed)
+
+	upstream.Close()
+	v = m.CheckFeed(context.Background(), feed)
+	assert.False(t, v.Passed)
+	assert.False(t, v.Checks[0].Passed, "an unreachable upstream fails connectivity")
+	assert.False(t, v.Checks[1].Passed)
+}
@@ -0,0 +1,86 @@
+package socket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/models"
+)
+
+// WarmUpFeeds pre-connects the top popular public feeds (and any explicitly listed
+// feed IDs) so the first subscriber does not wait for the upstream dial. Warmed
+// feeds that still have no subscribers after the grace window are disconnected.
+func (m *Manager) WarmUpFeeds(ctx context.Context, popular int, feedIDs []string, grace time.Duration) {
+	if m.marketplace == nil || (popular <= 0 && len(feedIDs) == 0) {
+		return
+	}
+
+	var feeds []models.WebSocketFeed
+	seen := make(map[string]struct{})
+	add := func(feed models.WebSocketFeed) {
+		id := feed.ID.Hex()
+		if _, ok := seen[id]; ok || !feed.IsActive {
+			return
+		}
+		seen[id] = struct{}{}
+		feeds = append(feeds, feed)
+	}
+
+	if popular > 0 {
+		popularFeeds, err := m.marketplace.GetPopularFeeds(ctx, int64(popular))
+		if err != nil {
+			slog.Warn("failed to load popular feeds for warm-up", "error", err)
+		}
+		for _, feed := range popularFeeds {
+			add(feed)
+		}
+	}
+	for _, id := range feedIDs {
+		feed, err := m.marketplace.GetFeedByID(ctx, id)
+		if err != nil || feed == nil {
+			slog.Warn("warm-up feed not found", "feed_id", id, "error", err)
+			continue
+		}
+		// Deactivated feeds stay disconnected even when listed
+		if !feed.IsActive {
+			slog.Warn("warm-up feed is deactivated, skipping", "feed_id", id)
+			continue
+		}
+		add(*feed)
+	}
+
+	for _, feed := range feeds {
+		if err := m.ConnectFeed(feed); err != nil {
+			slog.Warn("failed to warm feed", "feed_id", feed.ID.Hex(), "error", err)
+			continue
+		}
+		slog.Info("warmed feed", "feed_id", feed.ID.Hex(), "name", feed.Name)
+		if grace > 0 {
+			feedID := feed.ID.Hex()
+			time.AfterFunc(grace, func() { m.stopIfIdle(feedID) })
+		}
+	}
+}
+
+// stopIfIdle disconnects a feed that has no subscribers. The count is taken
+// under the feed lock: a client that subscribes meanwhile is either counted,
+// or its connection attempt waits for the lock and finds the feed stopped,
+// so it reconnects.
+func (m *Manager) stopIfIdle(feedID string) bool {
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	if m.subscriberCount(feedID) > 0 {
+		return false
+	}
+	slog.Info("feed has no subscribers, disconnecting", "feed_id", feedID)
+	m.stopFeedLocked(feedID)
+	return true
+}
+
+// subscriberCount returns the number of clients tracked for a feed.
+func (m *Manager) subscriberCount(feedID string) int {
+	m.subscriberMu.RLock()
+	defer m.subscriberMu.RUnlock()
+	return len(m.subscribers[feedID])
+}
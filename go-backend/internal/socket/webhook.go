@@ -0,0 +1,18 @@
+package socket
+
+import "github.com/turboline-ai/turbostream/go-backend/internal/services"
+
+// SetWebhookService sets the service that sends feed events to users'
+// webhooks; without one no webhooks are called
+func (m *Manager) SetWebhookService(webhooks *services.WebhookService) {
+	m.webhooks = webhooks
+}
+
+// dispatchWebhooks queues an event for the feed's webhooks. Only the instance
+// that produced an event dispatches it, so events relayed from the cluster
+// are not sent twice.
+func (m *Manager) dispatchWebhooks(feedID, userID, event string, payload interface{}) {
+	if m.webhooks != nil {
+		m.webhooks.Dispatch(feedID, userID, event, payload)
+	}
+}
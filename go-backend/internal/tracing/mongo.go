@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MongoMonitor wraps next with a span per MongoDB command. Commands are only
+// traced inside a traced request, so background jobs don't start traces of
+// their own; the statement itself is left out as it may hold user data.
+func MongoMonitor(next *event.CommandMonitor) *event.CommandMonitor {
+	var spans sync.Map // request ID -> trace.Span
+	end := func(requestID int64, err error) {
+		if span, ok := spans.LoadAndDelete(requestID); ok {
+			End(span.(trace.Span), err)
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			if trace.SpanContextFromContext(ctx).IsValid() {
+				name := e.CommandName
+				attrs := []attribute.KeyValue{
+					attribute.String("db.system.name", "mongodb"),
+					attribute.String("db.namespace", e.DatabaseName),
+					attribute.String("db.operation.name", e.CommandName),
+				}
+				// The first element names the command and, for CRUD, the collection
+				if first, err := e.Command.IndexErr(0); err == nil {
+					if collection, ok := first.Value().StringValueOK(); ok {
+						name += " " + collection
+						attrs = append(attrs, attribute.String("db.collection.name", collection))
+					}
+				}
+				_, span := Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+				spans.Store(e.RequestID, span)
+			}
+			if next != nil && next.Started != nil {
+				next.Started(ctx, e)
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			end(e.RequestID, nil)
+			if next != nil && next.Succeeded != nil {
+				next.Succeeded(ctx, e)
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			end(e.RequestID, errors.New(e.Failure))
+			if next != nil && next.Failed != nil {
+				next.Failed(ctx, e)
+			}
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+)
+
+// recordSpans routes spans to a recorder for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestMongoMonitor(t *testing.T) {
+	recorder := recordSpans(t)
+	var succeeded, failed int
+	monitor := MongoMonitor(&event.CommandMonitor{
+		Succeeded: func(context.Context, *event.CommandSucceededEvent) { succeeded++ },
+		Failed:    func(context.Context, *event.CommandFailedEvent) { failed++ },
+	})
+
+	command, err := bson.Marshal(bson.D{{Key: "find", Value: "feeds"}, {Key: "filter", Value: bson.D{{Key: "secret", Value: "x"}}}})
+	require.NoError(t, err)
+	started := func(ctx context.Context, requestID int64) {
+		monitor.Started(ctx, &event.CommandStartedEvent{Command: command, DatabaseName: "turbostream", CommandName: "find", RequestID: requestID})
+	}
+
+	// Commands outside a traced request get no span, but still reach next
+	started(context.Background(), 1)
+	monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1}})
+	assert.Empty(t, recorder.Ended())
+	assert.Equal(t, 1, succeeded)
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	started(ctx, 2)
+	started(ctx, 3)
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2}})
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 3}, Failure: "boom"})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	ok, bad := spans[0], spans[1]
+	assert.Equal(t, "find feeds", ok.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), ok.Parent().SpanID())
+	assert.Contains(t, ok.Attributes(), attribute.String("db.collection.name", "feeds"))
+	assert.Contains(t, ok.Attributes(), attribute.String("db.namespace", "turbostream"))
+	for _, attr := range ok.Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "secret")
+	}
+	assert.Equal(t, codes.Unset, ok.Status().Code)
+	assert.Equal(t, codes.Error, bad.Status().Code)
+	assert.Equal(t, "boom", bad.Status().Description)
+	assert.Equal(t, 2, succeeded)
+	assert.Equal(t, 1, failed)
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.Config{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
@@ -0,0 +1,5 @@
+// Package proto holds the protobuf messages and gRPC service of the
+// TurboStream streaming API, generated from turbostream.proto.
+package proto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative turbostream.proto
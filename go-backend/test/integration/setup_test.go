@@ -14,6 +14,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/turboline-ai/turbostream/go-backend/internal/config"
+	appdb "github.com/turboline-ai/turbostream/go-backend/internal/db"
 	transport "github.com/turboline-ai/turbostream/go-backend/internal/http"
 	"github.com/turboline-ai/turbostream/go-backend/internal/http/handlers"
 	"github.com/turboline-ai/turbostream/go-backend/internal/services"
@@ -47,6 +48,9 @@ func SetupTestServer(t *testing.T) *TestServer {
 	// Create unique test database
 	dbName := "test_integration_" + primitive.NewObjectID().Hex()
 	db := client.Database(dbName)
+	if _, err := appdb.Migrate(ctx, db, appdb.Migrations); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
 
 	// Setup configuration
 	cfg := config.Config{
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+// feedDefaults are the fields a feed file may leave out; they match feeds
+// created in the TUI
+var feedDefaults = map[string]interface{}{
+	"connectionType":      "websocket",
+	"feedType":            "user",
+	"isPublic":            true,
+	"dataFormat":          "json",
+	"reconnectionEnabled": true,
+}
+
+// feedsList prints a page of the marketplace, or the user's own feeds
+func (c *cli) feedsList(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("feeds list", "feeds list [--mine] [--category CAT] [--sort recent|subscribers|name] [--limit N] [--format table|json]")
+	mine := fs.Bool("mine", false, "list the feeds you own instead of the marketplace")
+	category := fs.String("category", "", "only feeds in this category")
+	sort := fs.String("sort", "", "recent, subscribers or name (default recent)")
+	limit := fs.Int("limit", 50, "feeds per page")
+	offset := fs.Int("offset", 0, "feeds to skip")
+	format := fs.String("format", "table", "table or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format, "table", "json"); err != nil {
+		return err
+	}
+
+	var feeds []client.Feed
+	if *mine {
+		if err := c.authenticate(); err != nil {
+			return err
+		}
+		var err error
+		if feeds, err = c.client.MyFeeds(ctx); err != nil {
+			return err
+		}
+	} else {
+		page, err := c.client.ListFeeds(ctx, client.ListFeedsOptions{Category: *category, Sort: *sort, Limit: *limit, Offset: *offset})
+		if err != nil {
+			return err
+		}
+		feeds = page.Feeds
+	}
+
+	if *format == "json" {
+		if feeds == nil {
+			feeds = []client.Feed{}
+		}
+		return c.writeJSON(feeds)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tCATEGORY\tTYPE\tSUBSCRIBERS\tSTATUS")
+	for _, f := range feeds {
+		status := "active"
+		if !f.IsActive {
+			status = "paused"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", f.ID, f.Name, f.Category, f.ConnectionType, f.SubscriberCount, status)
+	}
+	return tw.Flush()
+}
+
+// feedCreate creates a feed from a YAML (or JSON) file of its fields as the
+// backend names them, printing the new feed's ID
+func (c *cli) feedCreate(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("feed create", "feed create -f feed.yaml [--format text|json]")
+	file := fs.String("f", "", "feed definition file, or - for stdin")
+	format := fs.String("format", "text", "text prints the feed ID, json the feed")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		fs.Usage()
+		return errUsage
+	}
+	if err := checkFormat(*format, "text", "json"); err != nil {
+		return err
+	}
+	fields, err := readFeedFile(*file)
+	if err != nil {
+		return err
+	}
+	if err := c.authenticate(); err != nil {
+		return err
+	}
+
+	feed, ingest, err := c.client.CreateFeedFrom(ctx, fields)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		return c.writeJSON(struct {
+			Feed   *client.Feed              `json:"feed"`
+			Ingest *client.IngestCredentials `json:"ingest,omitempty"`
+		}{feed, ingest})
+	}
+	fmt.Fprintln(c.stdout, feed.ID)
+	if ingest != nil {
+		fmt.Fprintf(c.stderr, "Push data to %s%s signed with secret %s\nThe secret is shown only once.\n",
+			strings.TrimRight(c.backendURL, "/"), ingest.Path, ingest.Secret)
+	}
+	return nil
+}
+
+// readFeedFile reads a feed definition, filling in feedDefaults
+func readFeedFile(path string) (map[string]interface{}, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New(path + " defines no feed fields")
+	}
+	for key, value := range feedDefaults {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// login signs in and saves the session; --print-token prints the token
+// instead, for exporting as TURBOSTREAM_TOKEN in CI
+func (c *cli) login(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("login", "login [--email EMAIL] [--password-stdin] [--totp CODE] [--print-token]")
+	email := fs.String("email", os.Getenv("TURBOSTREAM_EMAIL"), "account email (default $TURBOSTREAM_EMAIL, else prompted)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+	totp := fs.String("totp", "", "two-factor code, or a backup code for security key accounts")
+	printToken := fs.Bool("print-token", false, "print the token instead of saving the session")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	if *email == "" {
+		line, err := c.prompt(stdin, "Email: ")
+		if err != nil {
+			return err
+		}
+		*email = line
+	}
+	password, err := c.password(stdin, *passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	token, user, err := c.client.Login(ctx, *email, password, *totp)
+	if err != nil {
+		return err
+	}
+	if *printToken {
+		fmt.Fprintln(c.stdout, token)
+		return nil
+	}
+	path, err := saveSession(session{
+		BackendURL:   c.backendURL,
+		Email:        *email,
+		Token:        token,
+		RefreshToken: c.client.RefreshToken(),
+	})
+	if err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	name := *email
+	if user != nil && user.Name != "" {
+		name = fmt.Sprintf("%s <%s>", user.Name, user.Email)
+	}
+	fmt.Fprintf(c.stderr, "Logged in as %s; session saved to %s\n", name, path)
+	return nil
+}
+
+// password reads the password from stdin when asked to, else from
+// TURBOSTREAM_PASSWORD or a prompt that doesn't echo
+func (c *cli) password(stdin *bufio.Reader, fromStdin bool) (string, error) {
+	if fromStdin {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	if password := os.Getenv("TURBOSTREAM_PASSWORD"); password != "" {
+		return password, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", errors.New("no password: set TURBOSTREAM_PASSWORD or use --password-stdin")
+	}
+	fmt.Fprint(c.stderr, "Password: ")
+	password, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(c.stderr)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return string(password), nil
+}
+
+// prompt asks for a line on the terminal
+func (c *cli) prompt(stdin *bufio.Reader, label string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("%s required", strings.TrimSuffix(strings.ToLower(label), ": "))
+	}
+	fmt.Fprint(c.stderr, label)
+	line, err := stdin.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
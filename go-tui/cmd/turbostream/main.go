@@ -0,0 +1,242 @@
+// Command turbostream is the headless counterpart of the TUI: it logs in,
+// lists and creates feeds, streams feed data and asks the LLM about a feed
+// from scripts and CI.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+const usage = `Usage: turbostream <command> [flags]
+
+Commands:
+  login                        sign in and save the session for later commands
+  feeds list                   list marketplace feeds, or your own with --mine
+  feed create -f feed.yaml     create a feed from a YAML or JSON file
+  subscribe --feed ID          subscribe to feeds and print their data
+  ask --feed ID "question"     ask the LLM about a feed's recent data
+
+Environment:
+  TURBOSTREAM_BACKEND_URL      backend URL (default http://localhost:7210)
+  TURBOSTREAM_WEBSOCKET_URL    websocket URL (default ws://localhost:7210/ws)
+  TURBOSTREAM_TOKEN            token to use instead of the saved session
+  TURBOSTREAM_REFRESH_TOKEN    renews TURBOSTREAM_TOKEN once it expires
+  TURBOSTREAM_EMAIL            login email
+  TURBOSTREAM_PASSWORD         login password, instead of prompting
+
+Run "turbostream <command> -h" for a command's flags.
+`
+
+// errUsage reports a bad command line; its usage was already printed
+var errUsage = errors.New("usage")
+
+// cli is the state shared by commands
+type cli struct {
+	backendURL string
+	wsURL      string
+	client     *client.Client
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := &cli{
+		backendURL: getenvDefault("TURBOSTREAM_BACKEND_URL", "http://localhost:7210"),
+		wsURL:      getenvDefault("TURBOSTREAM_WEBSOCKET_URL", "ws://localhost:7210/ws"),
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+	}
+	c.client = client.NewClient(c.backendURL)
+
+	if err := c.run(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if !errors.Is(err, errUsage) && !errors.Is(err, context.Canceled) {
+			fmt.Fprintln(c.stderr, "turbostream:", client.FriendlyError(err))
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(c.stderr, usage)
+		return errUsage
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "login":
+		return c.login(ctx, args)
+	case "feeds", "feed":
+		if len(args) == 0 {
+			fmt.Fprint(c.stderr, usage)
+			return errUsage
+		}
+		switch sub := cmd + " " + args[0]; sub {
+		case "feeds list", "feed list":
+			return c.feedsList(ctx, args[1:])
+		case "feed create", "feeds create":
+			return c.feedCreate(ctx, args[1:])
+		default:
+			fmt.Fprintf(c.stderr, "unknown command %q\n\n%s", sub, usage)
+			return errUsage
+		}
+	case "subscribe":
+		return c.subscribe(ctx, args)
+	case "ask":
+		return c.ask(ctx, args)
+	case "help", "-h", "--help":
+		fmt.Fprint(c.stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(c.stderr, "unknown command %q\n\n%s", cmd, usage)
+		return errUsage
+	}
+}
+
+// newFlagSet returns a command's flag set; -h prints its usage
+func (c *cli) newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: turbostream %s\n\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses a command's flags; bad flags are reported as errUsage and
+// -h as flag.ErrHelp, after the flag set printed the usage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// authenticate gives the client a token: TURBOSTREAM_TOKEN when set, else
+// the session saved by login
+func (c *cli) authenticate() error {
+	if token := os.Getenv("TURBOSTREAM_TOKEN"); token != "" {
+		c.client.SetToken(token)
+		c.client.SetRefreshToken(os.Getenv("TURBOSTREAM_REFRESH_TOKEN"))
+		return nil
+	}
+	sess, err := loadSession()
+	if err != nil {
+		return err
+	}
+	if sess == nil || sess.BackendURL != c.backendURL {
+		return fmt.Errorf("not logged in to %s; run \"turbostream login\" or set TURBOSTREAM_TOKEN", c.backendURL)
+	}
+	c.client.SetToken(sess.Token)
+	c.client.SetRefreshToken(sess.RefreshToken)
+	return nil
+}
+
+// session is what login saves for later commands
+type session struct {
+	BackendURL   string `json:"backendUrl"`
+	Email        string `json:"email"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// sessionPath is where login saves the session, readable by the user only
+func sessionPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "turbostream", "session.json"), nil
+}
+
+// loadSession returns the saved session, or nil when there is none
+func loadSession() (*session, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sess session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return &sess, nil
+}
+
+func saveSession(sess session) (string, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0o600)
+}
+
+// writeJSON prints v as indented JSON
+func (c *cli) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// checkFormat rejects output formats a command doesn't support
+func checkFormat(format string, allowed ...string) error {
+	for _, f := range allowed {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q; use %s", format, strings.Join(allowed, " or "))
+}
+
+// exitCode is 2 for usage errors, 130 when interrupted and 1 otherwise
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.Is(err, context.Canceled):
+		return 130
+	default:
+		return 1
+	}
+}
+
+func getenvDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
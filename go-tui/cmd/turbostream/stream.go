@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+// userAgent identifies the CLI's websocket connections
+const userAgent = "turbostream-cli"
+
+// stringsFlag collects a flag given more than once
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// feedLine is one feed message in jsonl output
+type feedLine struct {
+	FeedID    string          `json:"feedId"`
+	FeedName  string          `json:"feedName"`
+	EventName string          `json:"eventName,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// subscribe subscribes to feeds and prints their messages until interrupted,
+// --count messages were printed, or every subscription ended
+func (c *cli) subscribe(ctx context.Context, args []string) error {
+	var feeds stringsFlag
+	fs := c.newFlagSet("subscribe", "subscribe --feed ID [--feed ID ...] [--format jsonl|text] [--count N] [--ttl DURATION]")
+	fs.Var(&feeds, "feed", "feed ID; repeat for several feeds")
+	format := fs.String("format", "jsonl", "jsonl prints a JSON object per message, text a line per message")
+	count := fs.Int("count", 0, "exit after this many messages (0 = run until interrupted)")
+	ttl := fs.Duration("ttl", 0, "expiry for new subscriptions, such as 2h (0 = never expire)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if len(feeds) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := checkFormat(*format, "jsonl", "text"); err != nil {
+		return err
+	}
+	if err := c.authenticate(); err != nil {
+		return err
+	}
+
+	user, err := c.client.Me(ctx)
+	if err != nil {
+		return err
+	}
+	// Existing subscriptions are left alone so their settings are kept
+	subs, err := c.client.Subscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	active := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		active[sub.FeedID] = sub.IsActive
+	}
+	for _, feedID := range feeds {
+		if active[feedID] {
+			continue
+		}
+		if err := c.client.Subscribe(ctx, feedID, *ttl); err != nil {
+			return fmt.Errorf("subscribe to %s: %w", feedID, err)
+		}
+	}
+
+	stream, err := client.DialStream(ctx, c.wsURL, client.StreamOptions{
+		UserID:    user.ID,
+		TokenFunc: c.client.Token,
+		UserAgent: userAgent,
+		Reconnect: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	for _, feedID := range feeds {
+		if err := stream.Subscribe(feedID); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(c.stdout)
+	printed := 0
+	for {
+		var ev client.Event
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-stream.Events():
+			if !ok {
+				return errors.New("connection closed")
+			}
+			ev = e
+		}
+		switch ev := ev.(type) {
+		case client.FeedData:
+			if *format == "jsonl" {
+				err = enc.Encode(feedLine{ev.FeedID, ev.FeedName, ev.EventName, ev.Time, ev.Data})
+			} else {
+				_, err = fmt.Fprintf(c.stdout, "%s %s: %s\n", ev.Time.Format(time.RFC3339), ev.FeedName, ev.Data)
+			}
+			if err != nil {
+				return err
+			}
+			if printed++; *count > 0 && printed >= *count {
+				return nil
+			}
+		case client.StatusEvent:
+			switch ev.Status {
+			case client.StatusReconnecting:
+				fmt.Fprintf(c.stderr, "connection lost (%v), reconnecting\n", ev.Err)
+			case client.StatusDisconnected:
+				return fmt.Errorf("disconnected: %w", ev.Err)
+			}
+		case client.FeedStatus:
+			fmt.Fprintf(c.stderr, "feed %s is %s %s\n", ev.FeedID, ev.Status, ev.Message)
+		case client.SubscriptionDenied:
+			return fmt.Errorf("subscription to %s denied: %s", ev.FeedID, ev.Reason)
+		case client.SubscriptionExpired:
+			fmt.Fprintf(c.stderr, "subscription to %s ended\n", ev.FeedID)
+			if len(stream.Subscriptions()) == 0 {
+				return errors.New("no subscriptions left")
+			}
+		case client.AccountDeleted:
+			return errors.New("account deleted")
+		}
+	}
+}
+
+// ask asks the LLM about a feed, printing the answer as it streams in
+func (c *cli) ask(ctx context.Context, args []string) error {
+	var temperature *float64
+	fs := c.newFlagSet("ask", `ask --feed ID [--system-prompt TEXT] [--format text|json] "question"`)
+	feedID := fs.String("feed", "", "feed ID")
+	systemPrompt := fs.String("system-prompt", "", "replaces the feed's system prompt")
+	fs.Func("temperature", "sampling temperature (default: the provider's)", func(v string) error {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		temperature = &t
+		return nil
+	})
+	maxTokens := fs.Int("max-tokens", 0, "answer length limit (0 = the provider's)")
+	timeout := fs.Duration("timeout", 2*time.Minute, "give up and cancel the query after this long")
+	format := fs.String("format", "text", "text streams the answer, json prints it with its usage once complete")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	question, err := readQuestion(fs)
+	if err != nil {
+		return err
+	}
+	if *feedID == "" || question == "" {
+		fs.Usage()
+		return errUsage
+	}
+	if err := checkFormat(*format, "text", "json"); err != nil {
+		return err
+	}
+	if err := c.authenticate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	stream, err := client.DialStream(ctx, c.wsURL, client.StreamOptions{
+		TokenFunc: c.client.Token,
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var onToken func(string)
+	streamed := false
+	if *format == "text" {
+		onToken = func(token string) {
+			streamed = true
+			fmt.Fprint(c.stdout, token)
+		}
+	}
+	resp, err := stream.Ask(ctx, client.LLMQuery{
+		FeedID:       *feedID,
+		Question:     question,
+		SystemPrompt: *systemPrompt,
+		Temperature:  temperature,
+		MaxTokens:    *maxTokens,
+	}, onToken)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("no answer within %s", *timeout)
+	}
+	if err != nil {
+		if *format == "text" {
+			fmt.Fprintln(c.stdout)
+		}
+		return err
+	}
+	if *format == "json" {
+		return c.writeJSON(struct {
+			Answer           string `json:"answer"`
+			Provider         string `json:"provider"`
+			Model            string `json:"model,omitempty"`
+			DurationMs       int64  `json:"durationMs"`
+			FirstTokenMs     int64  `json:"firstTokenMs,omitempty"`
+			PromptTokens     int    `json:"promptTokens"`
+			CompletionTokens int    `json:"completionTokens"`
+		}{resp.Answer, resp.Provider, resp.Model, resp.DurationMs, resp.FirstTokenMs, resp.PromptTokens, resp.CompletionTokens})
+	}
+	// Cached answers arrive whole, without tokens
+	if !streamed {
+		fmt.Fprint(c.stdout, resp.Answer)
+	}
+	fmt.Fprintln(c.stdout)
+	return nil
+}
+
+// readQuestion joins the remaining arguments; "-" reads the question from stdin
+func readQuestion(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() == 1 && fs.Arg(0) == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return strings.TrimSpace(strings.Join(fs.Args(), " ")), nil
+}
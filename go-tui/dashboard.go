@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
 )
 
 // Dashboard panel styles
@@ -306,6 +308,16 @@ func renderDashboardView(dm DashboardMetrics, termWidth, termHeight int) string
 		contentBuilder.WriteString(llmPanel)
 	}
 
+	// Bottom row: where the account's tokens went, from the backend ledger
+	if dm.Usage != nil {
+		usageWidth := panelWidth
+		if contentWidth >= 72 {
+			usageWidth = panelWidth*2 + 1
+		}
+		contentBuilder.WriteString("\n")
+		contentBuilder.WriteString(renderUsagePanel(dm.Usage, fm.FeedID, usageWidth))
+	}
+
 	// Join sidebar and content horizontally
 	mainView := lipgloss.JoinHorizontal(lipgloss.Top, sidebar, "  ", contentBuilder.String())
 
@@ -461,11 +473,23 @@ func renderStreamHealthPanel(fm FeedMetrics, width int) string {
 	}
 	lines = append(lines, renderColoredMetric("Status", connStatus, metricValueStyle))
 
+	// Upstream status as reported by the backend rather than inferred from traffic
+	upstream := metricValueStyle.Render("unknown")
+	switch fm.UpstreamStatus {
+	case "connected":
+		upstream = goodValueStyle.Render("connected")
+	case "erroring":
+		upstream = warnValueStyle.Render(fmt.Sprintf("erroring (%d errors)", fm.UpstreamErrorsTotal))
+	case "disconnected":
+		upstream = badValueStyle.Render("disconnected")
+	}
+	lines = append(lines, renderColoredMetric("Upstream", upstream, metricValueStyle))
+
 	// Message counts
 	lines = append(lines, renderMetric("Messages Received", fmt.Sprintf("%d", fm.MessagesReceivedTotal)))
 
-	// Message rate
-	lines = append(lines, renderMetric("Rate", fmt.Sprintf("%.1f msg/s", fm.MessagesPerSecond10s)))
+	// Message rate: instantaneous (1s) next to smoothed (10s) to tell bursts from steady flow
+	lines = append(lines, renderMetric("Rate", fmt.Sprintf("inst %.1f / avg %.1f msg/s", fm.MessagesPerSecond1s, fm.MessagesPerSecond10s)))
 
 	// Message rate sparkline (throughput: higher = better)
 	if len(fm.MsgRateHistory) > 0 {
@@ -661,6 +685,56 @@ func renderLLMPanel(fm FeedMetrics, width int) string {
 	return renderPanel("LLM / Tokens", strings.Join(lines, "\n"), width)
 }
 
+// maxUsageRows caps how many feeds the usage panel lists
+const maxUsageRows = 5
+
+// renderUsagePanel renders the account's LLM usage by feed over the ledger's
+// window, marking the selected feed
+func renderUsagePanel(usage *client.LLMUsageSummary, selectedFeedID string, width int) string {
+	var lines []string
+
+	t := usage.Totals
+	lines = append(lines, renderMetric("Requests", fmt.Sprintf("%d (%d cached)", t.Requests, t.CachedRequests))+"  "+
+		renderMetric("Tokens", fmt.Sprintf("%d in / %d out", t.PromptTokens, t.CompletionTokens))+"  "+
+		renderMetric("Est. Cost", fmt.Sprintf("$%.2f", t.CostUSD)))
+
+	if len(usage.Groups) == 0 {
+		lines = append(lines, "", helpStyle.Render("No AI queries in this period"))
+		return renderPanel("Token Usage by Feed (30d)", strings.Join(lines, "\n"), width)
+	}
+
+	nameWidth := width - 40
+	if nameWidth < 10 {
+		nameWidth = 10
+	}
+	lines = append(lines, "")
+	lines = append(lines, metricLabelStyle.Render(fmt.Sprintf("  %-*s %10s %8s %6s", nameWidth, "Feed", "Tokens", "Cost", "Share")))
+	for i, g := range usage.Groups {
+		if i == maxUsageRows {
+			lines = append(lines, helpStyle.Render(fmt.Sprintf("  … %d more feeds", len(usage.Groups)-maxUsageRows)))
+			break
+		}
+		name := g.FeedName
+		if name == "" {
+			name = g.Key
+		}
+		share := 0.0
+		if t.TotalTokens > 0 {
+			share = float64(g.TotalTokens) / float64(t.TotalTokens) * 100
+		}
+		marker := "  "
+		style := metricValueStyle
+		if g.Key == selectedFeedID {
+			marker = "▸ "
+			style = goodValueStyle
+		}
+		lines = append(lines, style.Render(fmt.Sprintf("%s%-*s %10d %8s %5.1f%%",
+			marker, nameWidth, truncate(name, nameWidth), g.TotalTokens, fmt.Sprintf("$%.2f", g.CostUSD), share)))
+	}
+
+	return renderPanel("Token Usage by Feed (30d)", strings.Join(lines, "\n"), width)
+}
+
 // renderContextBar renders a visual bar for context utilization
 func renderContextBar(percent float64, width int) string {
 	if width < 10 {
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+const (
+	// diagnosticsTimeout bounds each backend probe made while building the report
+	diagnosticsTimeout = 5 * time.Second
+	// errorLogSize is how many recent errors are kept for diagnostics
+	errorLogSize = 20
+	// connStatsInterval is how often connection stats are requested from the backend
+	connStatsInterval = 30 * time.Second
+)
+
+// diagnosticsMsg is sent when a diagnostics report finished writing
+type diagnosticsMsg struct {
+	Path string
+	Err  error
+}
+
+// loggedError is a user-visible error with the time it was shown
+type loggedError struct {
+	Time    time.Time
+	Message string
+}
+
+// errorLog keeps the most recent errors for diagnostics. It is shared across
+// model copies.
+type errorLog struct {
+	mu      sync.Mutex
+	entries []loggedError
+}
+
+func newErrorLog() *errorLog {
+	return &errorLog{}
+}
+
+// Add records an error, dropping the oldest once the log is full
+func (l *errorLog) Add(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, loggedError{Time: time.Now(), Message: message})
+	if len(l.entries) > errorLogSize {
+		l.entries = l.entries[len(l.entries)-errorLogSize:]
+	}
+}
+
+// Recent returns a copy of the logged errors, oldest first
+func (l *errorLog) Recent() []loggedError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]loggedError(nil), l.entries...)
+}
+
+// connStatsTickCmd schedules the next connection stats request
+func connStatsTickCmd() tea.Cmd {
+	return tea.Tick(connStatsInterval, func(t time.Time) tea.Msg { return connStatsTickMsg{} })
+}
+
+// requestConnStats asks the backend for connection-stats; the answer arrives
+// as a connStatsMsg
+func (m *model) requestConnStats() {
+	if m.wsClient == nil {
+		return
+	}
+	if err := m.wsClient.SendConnectionStats(); err != nil {
+		m.errorLog.Add(fmt.Sprintf("connection stats request failed: %v", err))
+	}
+}
+
+// diagnosticsSnapshot is the UI state a diagnostics report needs, captured on
+// the UI goroutine before the command runs.
+type diagnosticsSnapshot struct {
+	BackendURL    string
+	WSURL         string
+	WSStatus      string
+	ConnStats     *connStatsMsg
+	HasToken      bool
+	User          *client.User
+	Feeds         int
+	Subscriptions int
+	Metrics       []FeedMetrics
+	Errors        []loggedError
+}
+
+// diagnosticsSnapshot collects the state included in a diagnostics report
+func (m model) diagnosticsSnapshot() diagnosticsSnapshot {
+	snap := diagnosticsSnapshot{
+		BackendURL:    m.backendURL,
+		WSURL:         m.wsURL,
+		WSStatus:      m.wsStatus,
+		ConnStats:     m.connStats,
+		HasToken:      m.token != "",
+		User:          m.user,
+		Feeds:         len(m.feeds),
+		Subscriptions: len(activeSubscriptions(m.subs)),
+		Metrics:       m.metricsCollector.GetMetrics().Feeds,
+		Errors:        m.errorLog.Recent(),
+	}
+	sort.Slice(snap.Metrics, func(i, j int) bool { return snap.Metrics[i].Name < snap.Metrics[j].Name })
+	return snap
+}
+
+// generateDiagnosticsCmd probes the backend and writes a redacted diagnostics
+// report suitable for attaching to bug reports.
+func generateDiagnosticsCmd(c *client.Client, snap diagnosticsSnapshot, dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+		start := time.Now()
+		healthErr := c.Health(ctx)
+		latency := time.Since(start)
+		cancel()
+
+		ctx, cancel = context.WithTimeout(context.Background(), diagnosticsTimeout)
+		providers, providersErr := c.Providers(ctx)
+		cancel()
+
+		var b strings.Builder
+		b.WriteString("# TurboStream Diagnostics\n\n")
+		b.WriteString(fmt.Sprintf("Generated %s\n\n", time.Now().Format("2006-01-02 15:04:05 MST")))
+
+		b.WriteString("## Connection\n\n")
+		b.WriteString(fmt.Sprintf("- Backend URL: %s\n", redactURL(snap.BackendURL)))
+		b.WriteString(fmt.Sprintf("- WebSocket URL: %s\n", redactURL(snap.WSURL)))
+		if healthErr != nil {
+			b.WriteString(fmt.Sprintf("- Backend health: unreachable (%s)\n", redactSecrets(client.FriendlyError(healthErr))))
+		} else {
+			b.WriteString(fmt.Sprintf("- Backend health: ok, latency %dms\n", latency.Milliseconds()))
+		}
+		wsStatus := snap.WSStatus
+		if wsStatus == "" {
+			wsStatus = "not connected"
+		}
+		b.WriteString(fmt.Sprintf("- WebSocket status: %s\n", wsStatus))
+		if cs := snap.ConnStats; cs != nil {
+			latency := "no ping answered yet"
+			if cs.LatencyMs >= 0 {
+				latency = fmt.Sprintf("%dms", cs.LatencyMs)
+			}
+			b.WriteString(fmt.Sprintf("- WebSocket latency: %s (%d pings, uptime %s)\n", latency, cs.PingsSent, (time.Duration(cs.UptimeMs) * time.Millisecond).Round(time.Second)))
+			b.WriteString(fmt.Sprintf("- WebSocket messages: %d sent, %d received, %d dropped by the backend, compression %t\n", cs.MessagesReceived, cs.MessagesSent, cs.Dropped, cs.Compression))
+		}
+		if snap.HasToken {
+			b.WriteString("- Auth token: present (redacted)\n\n")
+		} else {
+			b.WriteString("- Auth token: none\n\n")
+		}
+
+		b.WriteString("## Session\n\n")
+		if snap.User != nil {
+			b.WriteString(fmt.Sprintf("- User: %s <%s> (id %s)\n", snap.User.Name, snap.User.Email, snap.User.ID))
+		} else {
+			b.WriteString("- User: not logged in\n")
+		}
+		b.WriteString(fmt.Sprintf("- Feeds loaded: %d\n", snap.Feeds))
+		b.WriteString(fmt.Sprintf("- Active subscriptions: %d\n\n", snap.Subscriptions))
+
+		b.WriteString("## LLM Providers\n\n")
+		switch {
+		case providersErr != nil:
+			b.WriteString(fmt.Sprintf("_Could not list providers: %s_\n\n", redactSecrets(client.FriendlyError(providersErr))))
+		case len(providers.Providers) == 0:
+			b.WriteString("_No providers configured._\n\n")
+		default:
+			names := append([]string(nil), providers.Providers...)
+			sort.Strings(names)
+			b.WriteString(fmt.Sprintf("- %s\n\n", strings.Join(names, ", ")))
+		}
+
+		b.WriteString("## Feed Metrics\n\n")
+		if len(snap.Metrics) == 0 {
+			b.WriteString("_No metrics collected yet._\n\n")
+		} else {
+			b.WriteString("| Feed | WS | Msg/s | Messages | Last msg | Reconnects | Drop % | LLM req | LLM err | Avg gen |\n")
+			b.WriteString("|------|----|-------|----------|----------|------------|--------|---------|---------|---------|\n")
+			for _, fm := range snap.Metrics {
+				ws := "down"
+				if fm.WSConnected {
+					ws = "up"
+				}
+				b.WriteString(fmt.Sprintf("| %s | %s | %.1f | %d | %.0fs | %d | %.1f | %d | %d | %.0fms |\n",
+					escapeMarkdownCell(fm.Name), ws, fm.MessagesPerSecond10s, fm.MessagesReceivedTotal,
+					fm.LastMessageAgeSeconds, fm.ReconnectsTotal, fm.DropRatePercent,
+					fm.LLMRequestsTotal, fm.LLMErrorsTotal, fm.GenerationTimeAvgMs))
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString("## Recent Errors\n\n")
+		if len(snap.Errors) == 0 {
+			b.WriteString("_None recorded this session._\n")
+		}
+		for _, e := range snap.Errors {
+			b.WriteString(fmt.Sprintf("- %s %s\n", e.Time.Format("15:04:05"), redactSecrets(e.Message)))
+		}
+
+		if dir == "" {
+			dir = "."
+		}
+		path := filepath.Join(dir, fmt.Sprintf("turbostream-diagnostics-%s.md", time.Now().Format("20060102-150405")))
+		if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+			return diagnosticsMsg{Err: err}
+		}
+		return diagnosticsMsg{Path: path}
+	}
+}
+
+// secretQueryParams are query parameter names whose values are redacted
+var secretQueryParams = []string{"token", "key", "secret", "password", "auth", "signature"}
+
+// redactURL strips credentials and secret-looking query values from a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactSecrets(raw)
+	}
+	if u.User != nil {
+		u.User = url.User("REDACTED")
+	}
+	q := u.Query()
+	changed := false
+	for name := range q {
+		lower := strings.ToLower(name)
+		for _, secret := range secretQueryParams {
+			if strings.Contains(lower, secret) {
+				q.Set(name, "REDACTED")
+				changed = true
+				break
+			}
+		}
+	}
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	apiKeyPattern = regexp.MustCompile(`\b(sk|xai|key)-[A-Za-z0-9_-]{16,}`)
+	secretPattern = regexp.MustCompile(`(?i)((?:token|api[_-]?key|secret|password)["']?\s*[:=]\s*["']?)[^\s"'&,}]+`)
+)
+
+// redactSecrets masks bearer tokens, JWTs, API keys and key=value secrets in free text
+func redactSecrets(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}REDACTED")
+	s = jwtPattern.ReplaceAllString(s, "REDACTED")
+	s = apiKeyPattern.ReplaceAllString(s, "REDACTED")
+	return secretPattern.ReplaceAllString(s, "${1}REDACTED")
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+// kvPair is a row of a key/value editor, such as a feed header
+type kvPair struct {
+	key   textinput.Model
+	value textinput.Model
+}
+
+func newKVPair(key, value string) kvPair {
+	k := textinput.New()
+	k.Placeholder = "key"
+	k.CharLimit = 200
+	k.Width = 20
+	k.SetValue(key)
+	v := textinput.New()
+	v.Placeholder = "value"
+	v.CharLimit = 2000
+	v.Width = 32
+	v.SetValue(value)
+	return kvPair{key: k, value: v}
+}
+
+// feedEditForm holds the edit screen's fields beyond the ones it shares with
+// the register form, and the feed as it was when editing started
+type feedEditForm struct {
+	feed              client.Feed
+	defaultPrompt     textinput.Model
+	reconnect         bool
+	reconnectDelay    textinput.Model
+	reconnectAttempts textinput.Model
+	headers           []kvPair
+	queryParams       []kvPair
+}
+
+// editStop is a place the focus can be on the edit screen. Key/value rows
+// have a stop per cell; an empty list has one stop (row -1) to add rows from.
+type editStop struct {
+	input  *textinput.Model // nil for the reconnection toggle and empty lists
+	field  string           // backend field, for its validation errors
+	toggle bool
+	list   *[]kvPair
+	row    int
+}
+
+// Focus positions of the edit screen before the key/value lists
+const (
+	editStopDefaultPrompt = 7 + iota // after the inputs shared with the register form
+	editStopReconnect
+	editStopReconnectDelay
+	editStopReconnectAttempts
+)
+
+// startEditFeed fills the edit screen from a feed
+func (m *model) startEditFeed(feed client.Feed) tea.Cmd {
+	m.screen = screenEditFeed
+	m.feedFieldErrs = nil
+	m.errorMessage = ""
+	m.feedName.SetValue(feed.Name)
+	m.feedDescription.SetValue(feed.Description)
+	m.feedURL.SetValue(feed.URL)
+	m.feedCategory.SetValue(feed.Category)
+	m.feedEventName.SetValue(feed.EventName)
+	m.feedSubMsg.SetValue(subscriptionMessage(feed))
+	m.feedSystemPrompt.SetValue(feed.SystemPrompt)
+
+	form := feedEditForm{feed: feed, reconnect: feed.ReconnectionEnabled}
+	form.defaultPrompt = textinput.New()
+	form.defaultPrompt.CharLimit = 2000
+	form.defaultPrompt.SetValue(feed.DefaultAIPrompt)
+	form.reconnectDelay = textinput.New()
+	form.reconnectDelay.Placeholder = "server default"
+	form.reconnectDelay.CharLimit = 7
+	form.reconnectDelay.Width = 16
+	if feed.ReconnectionDelay > 0 {
+		form.reconnectDelay.SetValue(strconv.Itoa(feed.ReconnectionDelay))
+	}
+	form.reconnectAttempts = textinput.New()
+	form.reconnectAttempts.Placeholder = "unlimited"
+	form.reconnectAttempts.CharLimit = 4
+	form.reconnectAttempts.Width = 16
+	if feed.ReconnectionAttempts > 0 {
+		form.reconnectAttempts.SetValue(strconv.Itoa(feed.ReconnectionAttempts))
+	}
+	for _, h := range feed.Headers {
+		form.headers = append(form.headers, newKVPair(h.Key, h.Value))
+	}
+	for _, q := range feed.QueryParams {
+		form.queryParams = append(form.queryParams, newKVPair(q.Key, q.Value))
+	}
+	m.editFeed = form
+
+	m.feedFormFocus = 0
+	return m.feedName.Focus()
+}
+
+// subscriptionMessage is the first message a feed sends after connecting
+func subscriptionMessage(feed client.Feed) string {
+	if len(feed.ConnectionMessages) > 0 {
+		return feed.ConnectionMessages[0]
+	}
+	return feed.ConnectionMessage
+}
+
+// editStops lists the edit screen's focus positions in order
+func (m *model) editStops() []editStop {
+	form := &m.editFeed
+	inputs := []*textinput.Model{&m.feedName, &m.feedDescription, &m.feedURL, &m.feedCategory, &m.feedEventName, &m.feedSubMsg, &m.feedSystemPrompt}
+	stops := make([]editStop, 0, len(inputs)+4+2*(len(form.headers)+len(form.queryParams)))
+	for i, input := range inputs {
+		stops = append(stops, editStop{input: input, field: feedFormFields[i]})
+	}
+	stops = append(stops,
+		editStop{input: &form.defaultPrompt, field: "defaultAIPrompt"},
+		editStop{toggle: true, field: "reconnectionEnabled"},
+		editStop{input: &form.reconnectDelay, field: "reconnectionDelay"},
+		editStop{input: &form.reconnectAttempts, field: "reconnectionAttempts"},
+	)
+	for _, list := range []struct {
+		rows  *[]kvPair
+		field string
+	}{{&form.headers, "headers"}, {&form.queryParams, "queryParams"}} {
+		if len(*list.rows) == 0 {
+			stops = append(stops, editStop{list: list.rows, field: list.field, row: -1})
+			continue
+		}
+		for i := range *list.rows {
+			row := &(*list.rows)[i]
+			stops = append(stops,
+				editStop{input: &row.key, field: list.field, list: list.rows, row: i},
+				editStop{input: &row.value, field: list.field, list: list.rows, row: i})
+		}
+	}
+	return stops
+}
+
+// focusEditStop moves the focus to stop n, wrapping around
+func (m *model) focusEditStop(n int) tea.Cmd {
+	stops := m.editStops()
+	if m.feedFormFocus < len(stops) && stops[m.feedFormFocus].input != nil {
+		stops[m.feedFormFocus].input.Blur()
+	}
+	m.feedFormFocus = (n + len(stops)) % len(stops)
+	if input := stops[m.feedFormFocus].input; input != nil {
+		return input.Focus()
+	}
+	return nil
+}
+
+// focusEditRow focuses the key cell of a list's row, or the list itself when it is empty
+func (m *model) focusEditRow(list *[]kvPair, row int) tea.Cmd {
+	for i, stop := range m.editStops() {
+		if stop.list == list && (stop.row == row || len(*list) == 0) {
+			return m.focusEditStop(i)
+		}
+	}
+	return nil
+}
+
+func (m model) updateEditFeed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	stops := m.editStops()
+	if m.feedFormFocus >= len(stops) {
+		m.feedFormFocus = 0
+	}
+	stop := stops[m.feedFormFocus]
+
+	switch msg.String() {
+	case "esc":
+		m.screen = screenFeeds
+		m.errorMessage = ""
+		return m, nil
+	case "enter":
+		return m.saveEditFeed()
+	case "up", "shift+tab":
+		return m, m.focusEditStop(m.feedFormFocus - 1)
+	case "down", "tab":
+		return m, m.focusEditStop(m.feedFormFocus + 1)
+	case " ":
+		if stop.toggle {
+			m.editFeed.reconnect = !m.editFeed.reconnect
+			return m, nil
+		}
+	case "ctrl+n":
+		// Add a row below the focused one
+		if stop.list != nil {
+			at := stop.row + 1
+			rows := append([]kvPair{}, (*stop.list)[:at]...)
+			rows = append(rows, newKVPair("", ""))
+			*stop.list = append(rows, (*stop.list)[at:]...)
+			return m, m.focusEditRow(stop.list, at)
+		}
+	case "ctrl+x":
+		// Remove the focused row
+		if stop.list != nil && stop.row >= 0 {
+			*stop.list = append((*stop.list)[:stop.row:stop.row], (*stop.list)[stop.row+1:]...)
+			return m, m.focusEditRow(stop.list, max(stop.row-1, 0))
+		}
+	}
+
+	if stop.input == nil {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	*stop.input, cmd = stop.input.Update(msg)
+	return m, cmd
+}
+
+// saveEditFeed sends the fields that changed to the backend. Redacted
+// secrets are sent back as they came, which keeps them.
+func (m model) saveEditFeed() (tea.Model, tea.Cmd) {
+	if m.feedName.Value() == "" || m.feedURL.Value() == "" {
+		m.errorMessage = "Name and URL are required"
+		return m, nil
+	}
+	form := m.editFeed
+	delay, err := optionalInt(form.reconnectDelay.Value())
+	if err != nil {
+		m.errorMessage = "Reconnect delay must be a number of milliseconds"
+		return m, nil
+	}
+	attempts, err := optionalInt(form.reconnectAttempts.Value())
+	if err != nil {
+		m.errorMessage = "Reconnect attempts must be a number"
+		return m, nil
+	}
+
+	updates := map[string]interface{}{
+		"name":                 m.feedName.Value(),
+		"description":          m.feedDescription.Value(),
+		"url":                  m.feedURL.Value(),
+		"category":             m.feedCategory.Value(),
+		"eventName":            m.feedEventName.Value(),
+		"systemPrompt":         m.feedSystemPrompt.Value(),
+		"defaultAIPrompt":      form.defaultPrompt.Value(),
+		"reconnectionEnabled":  form.reconnect,
+		"reconnectionDelay":    delay,
+		"reconnectionAttempts": attempts,
+		"headers":              kvValues(form.headers),
+		"queryParams":          kvValues(form.queryParams),
+	}
+	// Only the first subscription message is on the form; any others are kept
+	if msg := m.feedSubMsg.Value(); msg != subscriptionMessage(form.feed) {
+		switch messages := append([]string{}, form.feed.ConnectionMessages...); {
+		case len(messages) > 0 && msg == "":
+			updates["connectionMessages"] = messages[1:]
+		case len(messages) > 0:
+			messages[0] = msg
+			updates["connectionMessages"] = messages
+		case form.feed.ConnectionMessage != "":
+			updates["connectionMessage"] = msg
+		default:
+			updates["connectionMessages"] = []string{msg}
+		}
+	}
+
+	m.loading = true
+	m.errorMessage = ""
+	return m, updateFeedCmd(m.client, form.feed.ID, updates)
+}
+
+// optionalInt parses a non-negative number, where empty means 0
+func optionalInt(val string) (int, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("negative value %d", n)
+	}
+	return n, err
+}
+
+// kvValues turns editor rows into the backend's key/value list, skipping rows without a key
+func kvValues(rows []kvPair) []client.KeyValue {
+	out := []client.KeyValue{}
+	for _, row := range rows {
+		key := strings.TrimSpace(row.key.Value())
+		if key == "" {
+			continue
+		}
+		out = append(out, client.KeyValue{Key: key, Value: row.value.Value()})
+	}
+	return out
+}
+
+func (m model) viewEditFeed() string {
+	builder := strings.Builder{}
+	builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(cyanColor).Render("✏️ Edit Feed"))
+	builder.WriteString("\n\n")
+
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	focused := lipgloss.NewStyle().Foreground(cyanColor).Bold(true)
+	label := func(stop int, text string) string {
+		if stop == m.feedFormFocus {
+			return focused.Render(text + ": ")
+		}
+		return dim.Render(text + ": ")
+	}
+
+	labels := []string{
+		"Feed Name *",
+		"Description",
+		"WebSocket URL *",
+		"Category",
+		"Event Name",
+		"Subscription Message (JSON)",
+		"AI System Prompt",
+	}
+	inputs := []*textinput.Model{
+		&m.feedName,
+		&m.feedDescription,
+		&m.feedURL,
+		&m.feedCategory,
+		&m.feedEventName,
+		&m.feedSubMsg,
+		&m.feedSystemPrompt,
+	}
+	for i, text := range labels {
+		builder.WriteString(label(i, text))
+		builder.WriteString(inputs[i].View())
+		builder.WriteString("\n")
+		builder.WriteString(m.viewFeedFieldError(feedFormFields[i]))
+		if i == 3 && m.feedFormFocus == 3 {
+			builder.WriteString(m.viewCategoryHint())
+		}
+	}
+
+	form := m.editFeed
+	builder.WriteString(label(editStopDefaultPrompt, "Default AI Prompt"))
+	builder.WriteString(form.defaultPrompt.View())
+	builder.WriteString("\n")
+	builder.WriteString(m.viewFeedFieldError("defaultAIPrompt"))
+
+	builder.WriteString("\n")
+	reconnect := "[ ] off"
+	if form.reconnect {
+		reconnect = "[x] on"
+	}
+	builder.WriteString(label(editStopReconnect, "Reconnect"))
+	builder.WriteString(reconnect)
+	if m.feedFormFocus == editStopReconnect {
+		builder.WriteString(dim.Render("  (space: toggle)"))
+	}
+	builder.WriteString("\n")
+	builder.WriteString(label(editStopReconnectDelay, "Reconnect Delay (ms)"))
+	builder.WriteString(form.reconnectDelay.View())
+	builder.WriteString("\n")
+	builder.WriteString(m.viewFeedFieldError("reconnectionDelay"))
+	builder.WriteString(label(editStopReconnectAttempts, "Max Reconnect Attempts"))
+	builder.WriteString(form.reconnectAttempts.View())
+	builder.WriteString("\n")
+	builder.WriteString(m.viewFeedFieldError("reconnectionAttempts"))
+
+	// Key/value lists; stops continue after the reconnection settings
+	stop := editStopReconnectAttempts + 1
+	for _, list := range []struct {
+		title string
+		field string
+		rows  []kvPair
+	}{{"Headers", "headers", form.headers}, {"Query Params", "queryParams", form.queryParams}} {
+		builder.WriteString("\n")
+		builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(dimCyanColor).Render(list.title))
+		builder.WriteString("\n")
+		if len(list.rows) == 0 {
+			text := "  (none)"
+			if stop == m.feedFormFocus {
+				builder.WriteString(focused.Render(text) + dim.Render("  Ctrl+N: add"))
+			} else {
+				builder.WriteString(dim.Render(text))
+			}
+			builder.WriteString("\n")
+			stop++
+		}
+		for _, row := range list.rows {
+			marker := "  "
+			if m.feedFormFocus == stop || m.feedFormFocus == stop+1 {
+				marker = focused.Render("> ")
+			}
+			builder.WriteString(marker + row.key.View() + dim.Render(" = ") + row.value.View())
+			if row.value.Value() == client.RedactedValue {
+				builder.WriteString(dim.Render("  (kept)"))
+			}
+			builder.WriteString("\n")
+			stop += 2
+		}
+		for field, msg := range m.feedFieldErrs {
+			if strings.HasPrefix(field, list.field) {
+				builder.WriteString(lipgloss.NewStyle().Foreground(redColor).Render("  ↳ "+msg) + "\n")
+			}
+		}
+	}
+
+	builder.WriteString("\n")
+	builder.WriteString(dim.Render("↑↓/Tab navigate | Ctrl+N add row | Ctrl+X remove row | Enter save | Esc cancel | * required"))
+
+	if m.loading {
+		builder.WriteString("\n")
+		builder.WriteString(fmt.Sprintf("%s Updating feed...", m.spinner.View()))
+	}
+	if m.errorMessage != "" {
+		builder.WriteString("\n")
+		builder.WriteString(lipgloss.NewStyle().Foreground(redColor).Render(m.errorMessage))
+	}
+
+	return contentStyle.Render(builder.String())
+}
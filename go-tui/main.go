@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -14,7 +17,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
-	"github.com/turboline-ai/turbostream/go-tui/pkg/api"
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
 )
 
 // Color palette - Cyan theme with Magenta tabs
@@ -207,6 +210,7 @@ const (
 // Tab indices for main navigation
 const (
 	tabDashboard = iota
+	tabMarketplace
 	tabRegisterFeed
 	tabMyFeeds
 	tabAPI
@@ -228,6 +232,7 @@ type aiOutputEntry struct {
 	Response  string
 	Timestamp time.Time
 	Provider  string
+	Model     string
 	Duration  int64
 }
 
@@ -235,30 +240,67 @@ type aiOutputEntry struct {
 type (
 	authResultMsg struct {
 		Token string
-		User  *api.User
+		User  *client.User
 		Err   error
 	}
 	meResultMsg struct {
-		User *api.User
+		User *client.User
 		Err  error
 	}
+	llmUsageMsg struct {
+		Usage *client.LLMUsageSummary
+		Err   error
+	}
 	feedsMsg struct {
-		Feeds []api.Feed
+		Feeds []client.Feed
 		Err   error
 	}
+	categoriesMsg struct {
+		Categories []client.Category
+		Err        error
+	}
 	subsMsg struct {
-		Subs []api.Subscription
+		Subs []client.Subscription
 		Err  error
 	}
 	feedDetailMsg struct {
-		Feed *api.Feed
+		Feed *client.Feed
 		Err  error
 	}
+	feedSampleMsg struct {
+		FeedID string
+		Sample *client.FeedSample
+		Err    error
+	}
+	feedTestMsg struct {
+		URL    string
+		Result *client.FeedTest
+		Err    error
+	}
 	subscribeResultMsg struct {
 		FeedID string
 		Action string
 		Err    error
 	}
+	subscriptionExpiredMsg struct {
+		FeedID string
+		// Revoked is set when the feed's owner revoked access rather than the subscription lapsing
+		Revoked bool
+	}
+	subscriptionDeniedMsg struct {
+		FeedID string
+		Reason string
+	}
+	renewResultMsg struct {
+		FeedID    string
+		ExpiresAt *time.Time
+		Err       error
+	}
+	// accountDeletedMsg arrives when the signed-in account was deleted; the
+	// server closes the connection after it
+	accountDeletedMsg struct {
+		UserID string
+	}
 	wsConnectedMsg struct {
 		Client *wsClient
 		Err    error
@@ -278,43 +320,92 @@ type (
 		FeedID string
 		Reason string
 	}
+	feedStatusMsg struct {
+		FeedID  string
+		Status  string
+		Message string
+	}
+	rateLimitedMsg struct {
+		Type string
+		Err  error
+	}
+	feedHealthMsg struct {
+		FeedID     string
+		Status     string
+		ErrorCount int64
+		LastError  string
+	}
 	tokenUsageUpdateMsg struct {
-		Usage *api.TokenUsage
+		Usage *client.TokenUsage
 	}
 	feedCreateMsg struct {
-		Feed *api.Feed
+		Feed *client.Feed
 		Err  error
 	}
 	feedUpdateMsg struct {
-		Feed *api.Feed
+		Feed *client.Feed
 		Err  error
 	}
 	feedDeleteMsg struct {
 		FeedID string
 		Err    error
 	}
+	feedPauseMsg struct {
+		Feed *client.Feed
+		Err  error
+	}
 	// AI-related messages
 	aiResponseMsg struct {
 		RequestID string
 		Answer    string
 		Provider  string
 		Duration  int64
+		// Token counts reported by the backend; zero for cached answers
+		PromptTokens     int
+		CompletionTokens int
+		// Feed entries the backend sent and the percent of the model's context window they filled
+		ContextEntries     int
+		ContextUtilization float64
+		// Model and FirstTokenMs come from a stream's usage block; FirstTokenMs is 0 when unknown
+		Model        string
+		FirstTokenMs int64
+		// Cancelled marks a stream stopped by the user; Answer is the part streamed
+		Cancelled bool
 		Err       error
 	}
 	aiTokenMsg struct {
 		RequestID string
 		Token     string
 	}
+	// aiProgressMsg is the backend's periodic progress report on a streaming query
+	aiProgressMsg struct {
+		RequestID    string
+		Tokens       int
+		ElapsedMs    int64
+		FirstTokenMs int64
+	}
+	// connStatsMsg is the backend's view of this websocket connection;
+	// LatencyMs is -1 until the server's first ping is answered
+	connStatsMsg struct {
+		LatencyMs        int64
+		UptimeMs         int64
+		PingsSent        int64
+		MessagesSent     int64
+		MessagesReceived int64
+		Dropped          int64
+		Compression      bool
+	}
 	aiTickMsg        struct{} // For auto-query interval
 	userTickMsg      struct{} // For periodic user data refresh
 	dashboardTickMsg struct{} // For dashboard metrics refresh
+	connStatsTickMsg struct{} // For connection stats refresh
 )
 
 // Model keeps the application state (Elm-style).
 type model struct {
 	backendURL string
 	wsURL      string
-	client     *api.Client
+	client     *client.Client
 
 	screen    screen
 	activeTab int // Current tab index (0=Dashboard, 1=Marketplace, 2=Register Feed, 3=Feeds)
@@ -326,21 +417,40 @@ type model struct {
 	name     textinput.Model
 	totp     textinput.Model
 	token    string
-	user     *api.User
+	user     *client.User
 
 	// Data
-	feeds         []api.Feed
-	subs          []api.Subscription
+	feeds         []client.Feed
+	subs          []client.Subscription
+	categories    []client.Category // picker options for the feed form
 	selectedIdx   int
-	selectedFeed  *api.Feed
+	selectedFeed  *client.Feed
+	feedSample    *client.FeedSample
+	feedSampleErr error
+	feedTest      *client.FeedTest // connection test of the register form's URL
+	feedTestErr   error
+	feedTesting   bool
+	feedFieldErrs map[string]string // backend validation errors by field, shown under the form inputs
 	activeFeedID  string
+	detailBack    screen // where Esc on Feed Detail returns to
 	feedEntries   map[string][]feedEntry
+	streamBuffer  int        // entries kept per feed for scrollback
+	stream        streamView // live stream scroll, pause and search state
 	statusMessage string
 	errorMessage  string
 
+	// Marketplace tab
+	marketplace marketplaceState
+
 	// Realtime
 	wsClient *wsClient
 	wsStatus string
+	// Last connection-stats from the backend; nil until the first arrives
+	connStats *connStatsMsg
+	// Upstream status per feed while it is not connected, e.g. "reconnecting (attempt 3/10)"
+	feedUpstream map[string]string
+	// Upstream health per feed as reported by the backend's feed-health events
+	feedHealth map[string]feedHealthMsg
 
 	// UI helpers
 	spinner spinner.Model
@@ -355,12 +465,16 @@ type model struct {
 	feedSubMsg       textinput.Model
 	feedSystemPrompt textinput.Model
 	feedFormFocus    int
+	editFeed         feedEditForm // edit screen fields beyond the register form's
 
 	// AI Analysis panel (per-feed state)
 	aiPrompts         map[string]textarea.Model  // feedID -> prompt input (per-feed prompts)
+	promptLib         promptLibrary              // saved prompt list open in the AI panel
 	aiAutoMode        bool                       // true = auto query at interval, false = manual
 	aiInterval        int                        // seconds between auto queries (5, 10, 30, 60)
 	aiIntervalIdx     int                        // index into interval options
+	aiTemperature     *float64                   // generation temperature sent with queries (nil = provider default)
+	aiMaxTokens       int                        // max output tokens sent with queries (0 = provider default)
 	aiResponses       map[string]string          // feedID -> current AI response (for streaming)
 	aiOutputHistories map[string][]aiOutputEntry // feedID -> history of AI outputs (last 10)
 	aiLoading         map[string]bool            // feedID -> whether AI query is in progress
@@ -372,13 +486,37 @@ type model struct {
 	aiActiveRequests  map[string]string          // requestID -> feedID (tracks ALL active concurrent requests)
 	aiStartTimes      map[string]time.Time       // feedID -> when request started (for concurrent tracking)
 	aiFirstTokens     map[string]time.Time       // feedID -> when first token was received (for TTFT per feed)
+	aiProgress        map[string]aiProgressMsg   // feedID -> latest progress of its streaming query
 	aiViewport        viewport.Model             // scrollable viewport for AI output
 	aiViewportReady   bool                       // whether viewport is initialized
+	aiSuggestions     map[string][]string        // feedID -> example questions generated from inferred schema
+	aiErrors          map[string]string          // feedID -> last AI error, shown on a dismissible status line
+	aiFailures        map[string]int             // feedID -> consecutive AI failures since the last good answer
+	aiSchemaSigs      map[string]string          // feedID -> signature of last inferred schema
 
 	// Observability dashboard
 	metricsCollector      *MetricsCollector
 	dashboardMetrics      DashboardMetrics
-	dashboardSelectedFeed int // Selected feed index in dashboard
+	dashboardSelectedFeed int                     // Selected feed index in dashboard
+	llmUsage              *client.LLMUsageSummary // Server-side token ledger by feed, last 30 days
+
+	// Combined report export
+	digestCache     *digestCache // per-feed LLM digests reused across reports
+	reportRunning   bool
+	reportOutputDir string
+
+	// Stream recording (w) and buffer export (e / Shift+E)
+	recordConfig recordConfig
+	recordings   map[string]*recorder // feedID -> open recording, shared across model copies
+
+	// Diagnostics report (ctrl+d)
+	errorLog           *errorLog // recent user-visible errors, shared across model copies
+	diagnosticsRunning bool
+
+	// subscriptionTTL is applied to new subscriptions and renewals (0 = never expire)
+	subscriptionTTL time.Duration
+	// wsBatchInterval asks the server to batch feed-data per subscription (0 = no batching)
+	wsBatchInterval time.Duration
 
 	// Help section
 	helpPage      int // Current help page index
@@ -395,20 +533,23 @@ func main() {
 	token := os.Getenv("TURBOSTREAM_TOKEN")
 	email := os.Getenv("TURBOSTREAM_EMAIL")
 
-	client := api.NewClient(backendURL)
+	client := client.NewClient(backendURL)
 	if token != "" {
 		client.SetToken(token)
+		client.SetRefreshToken(os.Getenv("TURBOSTREAM_REFRESH_TOKEN"))
 	}
 
 	m := newModel(client, backendURL, wsURL, token, email)
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	m.metricsCollector.Close()
+	if err != nil {
 		fmt.Println("failed to start TUI:", err)
 		os.Exit(1)
 	}
 }
 
-func newModel(client *api.Client, backendURL, wsURL, token, presetEmail string) model {
+func newModel(c *client.Client, backendURL, wsURL, token, presetEmail string) model {
 	email := textinput.New()
 	email.Placeholder = ""
 	email.SetValue(presetEmail)
@@ -445,6 +586,10 @@ func newModel(client *api.Client, backendURL, wsURL, token, presetEmail string)
 	feedCategory := textinput.New()
 	feedCategory.Placeholder = ""
 	feedCategory.CharLimit = 50
+	// Suggestions come from the backend's category list; tab switches
+	// screens, so → accepts the suggestion instead
+	feedCategory.ShowSuggestions = true
+	feedCategory.KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("right"))
 
 	feedEventName := textinput.New()
 	feedEventName.Placeholder = ""
@@ -458,10 +603,14 @@ func newModel(client *api.Client, backendURL, wsURL, token, presetEmail string)
 	feedSystemPrompt.Placeholder = ""
 	feedSystemPrompt.CharLimit = 2000
 
+	// Started here and stopped by shutdown (or after the program exits)
+	metrics := NewMetricsCollector()
+	metrics.Start(context.Background())
+
 	return model{
 		backendURL:       backendURL,
 		wsURL:            wsURL,
-		client:           client,
+		client:           c,
 		screen:           screenLogin,
 		authMode:         "login",
 		email:            email,
@@ -470,6 +619,11 @@ func newModel(client *api.Client, backendURL, wsURL, token, presetEmail string)
 		totp:             totp,
 		token:            token,
 		feedEntries:      map[string][]feedEntry{},
+		streamBuffer:     parseStreamBuffer(os.Getenv("TURBOSTREAM_STREAM_BUFFER")),
+		stream:           newStreamView(),
+		marketplace:      newMarketplaceState(),
+		feedUpstream:     map[string]string{},
+		feedHealth:       map[string]feedHealthMsg{},
 		spinner:          sp,
 		loading:          token != "",
 		statusMessage:    "TurboStream TUI (Bubble Tea)",
@@ -486,6 +640,8 @@ func newModel(client *api.Client, backendURL, wsURL, token, presetEmail string)
 		aiAutoMode:        false,
 		aiInterval:        10,
 		aiIntervalIdx:     1, // 10 seconds default
+		aiTemperature:     parseAITemperature(os.Getenv("TURBOSTREAM_AI_TEMPERATURE")),
+		aiMaxTokens:       parseAIMaxTokens(os.Getenv("TURBOSTREAM_AI_MAX_TOKENS")),
 		aiResponses:       make(map[string]string),
 		aiOutputHistories: make(map[string][]aiOutputEntry),
 		aiLoading:         make(map[string]bool),
@@ -494,9 +650,21 @@ func newModel(client *api.Client, backendURL, wsURL, token, presetEmail string)
 		aiActiveRequests:  make(map[string]string),    // requestID -> feedID for concurrent tracking
 		aiStartTimes:      make(map[string]time.Time), // feedID -> start time
 		aiFirstTokens:     make(map[string]time.Time), // feedID -> first token time
+		aiProgress:        make(map[string]aiProgressMsg),
+		aiSuggestions:     make(map[string][]string), // feedID -> suggested questions
+		aiSchemaSigs:      make(map[string]string),   // feedID -> schema signature
+		aiErrors:          make(map[string]string),   // feedID -> last AI error
+		aiFailures:        make(map[string]int),      // feedID -> consecutive failures
 		// Dashboard
-		metricsCollector:      NewMetricsCollector(),
+		metricsCollector:      metrics,
 		dashboardSelectedFeed: 0,
+		digestCache:           newDigestCache(),
+		errorLog:              newErrorLog(),
+		reportOutputDir:       os.Getenv("TURBOSTREAM_REPORT_DIR"),
+		recordConfig:          loadRecordConfig(os.Getenv("TURBOSTREAM_REPORT_DIR")),
+		recordings:            make(map[string]*recorder),
+		subscriptionTTL:       parseSubscriptionTTL(os.Getenv("TURBOSTREAM_SUBSCRIPTION_TTL")),
+		wsBatchInterval:       parseBatchInterval(os.Getenv("TURBOSTREAM_BATCH_MS")),
 		termWidth:             120,
 		termHeight:            40,
 	}
@@ -511,10 +679,24 @@ func (m model) Init() tea.Cmd {
 	cmds = append(cmds, tea.Tick(5*time.Minute, func(t time.Time) tea.Msg { return userTickMsg{} }))
 	// Dashboard metrics refresh every 500ms
 	cmds = append(cmds, tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg { return dashboardTickMsg{} }))
+	// Connection stats refresh every 30s
+	cmds = append(cmds, connStatsTickCmd())
+	// Background refresh of inferred schemas and AI question suggestions
+	cmds = append(cmds, suggestionTickCmd())
 	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	prevErr := m.errorMessage
+	next, cmd := m.update(msg)
+	// Keep a history of shown errors for the diagnostics report
+	if nm, ok := next.(model); ok && nm.errorMessage != "" && nm.errorMessage != prevErr {
+		nm.errorLog.Add(nm.errorMessage)
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKey(msg)
@@ -525,7 +707,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case authResultMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
 		m.token = msg.Token
@@ -533,12 +715,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.client.SetToken(msg.Token)
 		m.screen = screenDashboard
 		m.statusMessage = "Logged in"
-		return m, tea.Batch(loadInitialDataCmd(m.client), connectWS(m.wsURL, m.user.ID, m.userAgent()))
+		return m, tea.Batch(loadInitialDataCmd(m.client), connectWS(m.wsURL, m.user.ID, m.client.Token, m.userAgent(), m.wsBatchInterval))
 
 	case meResultMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			m.screen = screenLogin
 			return m, nil
 		}
@@ -548,12 +730,31 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.screen = screenDashboard
 		m.statusMessage = "Session restored"
-		return m, tea.Batch(loadInitialDataCmd(m.client), connectWS(m.wsURL, m.user.ID, m.userAgent()))
+		return m, tea.Batch(loadInitialDataCmd(m.client), connectWS(m.wsURL, m.user.ID, m.client.Token, m.userAgent(), m.wsBatchInterval))
+
+	case categoriesMsg:
+		// The picker is a convenience; without it the category is typed freely
+		if msg.Err != nil {
+			return m, nil
+		}
+		m.categories = msg.Categories
+		keys := make([]string, len(msg.Categories))
+		for i, cat := range msg.Categories {
+			keys[i] = cat.Key
+		}
+		m.feedCategory.SetSuggestions(keys)
+		return m, nil
+
+	case marketplaceFeedsMsg, marketplaceSearchMsg:
+		return m.handleMarketplaceMsg(msg)
+
+	case promptLibraryMsg:
+		return m.handlePromptLibraryMsg(msg)
 
 	case feedsMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
 		m.feeds = msg.Feeds
@@ -567,10 +768,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case subsMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
-		m.subs = msg.Subs
+		m.subs = activeSubscriptions(msg.Subs)
 		// If WebSocket is already connected, subscribe to all feeds
 		if m.wsClient != nil {
 			for _, sub := range m.subs {
@@ -582,18 +783,40 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case feedDetailMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
 		m.selectedFeed = msg.Feed
 		m.activeFeedID = msg.Feed.ID
+		if m.screen != screenFeedDetail {
+			m.detailBack = m.screen
+		}
 		m.screen = screenFeedDetail
 		m.errorMessage = ""
+		m.feedSample = nil
+		m.feedSampleErr = nil
+		return m, fetchFeedSampleCmd(m.client, msg.Feed.ID)
+
+	case feedSampleMsg:
+		if m.selectedFeed == nil || m.selectedFeed.ID != msg.FeedID {
+			return m, nil
+		}
+		m.feedSample = msg.Sample
+		m.feedSampleErr = msg.Err
+		return m, nil
+
+	case feedTestMsg:
+		m.feedTesting = false
+		if msg.URL != m.feedURL.Value() {
+			return m, nil
+		}
+		m.feedTest = msg.Result
+		m.feedTestErr = msg.Err
 		return m, nil
 
 	case subscribeResultMsg:
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
 		m.errorMessage = ""
@@ -612,10 +835,59 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 
+	case feedStatusMsg:
+		if msg.Status == "connected" || msg.Status == "resumed" {
+			delete(m.feedUpstream, msg.FeedID)
+		} else {
+			m.feedUpstream[msg.FeedID] = msg.Message
+		}
+		if msg.Status == "paused" || msg.Status == "resumed" {
+			m.setFeedActive(msg.FeedID, msg.Status == "resumed")
+		}
+		return m, m.nextWSListen()
+
+	case rateLimitedMsg:
+		m.errorMessage = fmt.Sprintf("%s: %v", msg.Type, msg.Err)
+		return m, m.nextWSListen()
+
+	case feedHealthMsg:
+		m.feedHealth[msg.FeedID] = msg
+		m.metricsCollector.RecordUpstreamHealth(msg.FeedID, msg.Status, msg.ErrorCount)
+		return m, m.nextWSListen()
+
+	case subscriptionExpiredMsg:
+		m.statusMessage = fmt.Sprintf("Subscription to %s expired", m.feedDisplayName(msg.FeedID))
+		if msg.Revoked {
+			m.statusMessage = fmt.Sprintf("Access to %s was revoked", m.feedDisplayName(msg.FeedID))
+		}
+		delete(m.feedEntries, msg.FeedID)
+		return m, tea.Batch(loadSubscriptionsCmd(m.client), m.nextWSListen())
+
+	case subscriptionDeniedMsg:
+		m.errorMessage = fmt.Sprintf("Live data for %s denied: %s", m.feedDisplayName(msg.FeedID), msg.Reason)
+		return m, m.nextWSListen()
+
+	case accountDeletedMsg:
+		m.errorMessage = "This account has been deleted"
+		return m, m.nextWSListen()
+
+	case renewResultMsg:
+		if msg.Err != nil {
+			m.errorMessage = "Renew failed: " + client.FriendlyError(msg.Err)
+			return m, nil
+		}
+		m.errorMessage = ""
+		if msg.ExpiresAt != nil {
+			m.statusMessage = fmt.Sprintf("Subscription renewed until %s", msg.ExpiresAt.Local().Format("Jan 2 15:04"))
+		} else {
+			m.statusMessage = "Subscription renewed (no expiry)"
+		}
+		return m, loadSubscriptionsCmd(m.client)
+
 	case wsConnectedMsg:
 		if msg.Err != nil {
 			m.wsStatus = "disconnected"
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
 		m.wsClient = msg.Client
@@ -631,38 +903,62 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case wsStatusMsg:
 		m.wsStatus = msg.Status
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 		}
-		if msg.Status == "disconnected" {
-			m.wsClient = nil
+		switch msg.Status {
+		case client.StatusDisconnected, client.StatusReconnecting:
+			// A reconnecting client keeps its subscriptions and resumes on its own
+			if msg.Status == client.StatusDisconnected {
+				m.wsClient = nil
+			}
+			m.connStats = nil
 			// Update metrics for all feeds
 			for _, feed := range m.feeds {
 				m.metricsCollector.RecordWSStatus(feed.ID, false)
 			}
-		} else if msg.Status == "connected" {
+		case client.StatusConnected:
 			// Update metrics for all feeds
 			for _, feed := range m.feeds {
 				m.metricsCollector.RecordWSStatus(feed.ID, true)
 			}
+			m.requestConnStats()
 		}
 		return m, m.nextWSListen()
 
+	case connStatsMsg:
+		m.connStats = &msg
+		return m, m.nextWSListen()
+
+	case connStatsTickMsg:
+		m.requestConnStats()
+		return m, connStatsTickCmd()
+
 	case feedDataMsg:
 		// Record metrics for the feed
 		m.metricsCollector.InitFeed(msg.FeedID, msg.FeedName)
 		m.metricsCollector.RecordMessage(msg.FeedID, len(msg.Data))
 		m.metricsCollector.RecordWSStatus(msg.FeedID, true)
 
+		entry := feedEntry{FeedID: msg.FeedID, FeedName: msg.FeedName, Event: msg.EventName, Data: msg.Data, Time: msg.Time}
+		if rec := m.recordings[msg.FeedID]; rec != nil {
+			if err := rec.Write(entry); err != nil {
+				m.stopRecording(msg.FeedID)
+				m.errorMessage = "Recording stopped: " + err.Error()
+			}
+		}
 		entries := m.feedEntries[msg.FeedID]
-		entries = append([]feedEntry{{FeedID: msg.FeedID, FeedName: msg.FeedName, Event: msg.EventName, Data: msg.Data, Time: msg.Time}}, entries...)
+		entries = append([]feedEntry{entry}, entries...)
 
 		// Track evictions when context buffer overflows
-		if len(entries) > 50 {
-			evictedCount := len(entries) - 50
+		if len(entries) > m.streamBuffer {
+			evictedCount := len(entries) - m.streamBuffer
 			m.metricsCollector.RecordContextEviction(msg.FeedID, evictedCount)
-			entries = entries[:50]
+			entries = entries[:m.streamBuffer]
 		}
 		m.feedEntries[msg.FeedID] = entries
+		if m.stream.paused && m.stream.feedID == msg.FeedID {
+			m.stream.missed++
+		}
 
 		// Update cache metrics based on feed entries
 		cacheBytes := uint64(0)
@@ -682,13 +978,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Refresh dashboard metrics
 		m.dashboardMetrics = m.metricsCollector.GetMetrics()
 		m.dashboardMetrics.SelectedIdx = m.dashboardSelectedFeed
+		m.dashboardMetrics.Usage = m.llmUsage
 		// Continue the tick
 		return m, tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg { return dashboardTickMsg{} })
 
 	case feedCreateMsg:
 		m.loading = false
+		m.feedFieldErrs = client.FieldErrors(msg.Err)
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
+			if len(m.feedFieldErrs) > 0 {
+				m.errorMessage = "Fix the highlighted fields and try again"
+			}
 			return m, nil
 		}
 		m.statusMessage = fmt.Sprintf("Feed '%s' created! Auto-subscribing...", msg.Feed.Name)
@@ -702,6 +1003,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.feedSubMsg.SetValue("")
 		m.feedSystemPrompt.SetValue("")
 		m.feedFormFocus = 0
+		m.feedTest = nil
+		m.feedTestErr = nil
 		// Set selected feed and go to My Feeds tab to show it
 		m.selectedFeed = msg.Feed
 		m.activeFeedID = msg.Feed.ID
@@ -712,14 +1015,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmds []tea.Cmd
 		cmds = append(cmds, loadFeedsCmd(m.client))
 		if m.user != nil {
-			cmds = append(cmds, subscribeCmd(m.client, msg.Feed.ID, m.user.ID))
+			cmds = append(cmds, subscribeCmd(m.client, msg.Feed.ID, m.user.ID, m.subscriptionTTL))
 		}
 		return m, tea.Batch(cmds...)
 
 	case feedUpdateMsg:
 		m.loading = false
+		m.feedFieldErrs = client.FieldErrors(msg.Err)
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
+			if len(m.feedFieldErrs) > 0 {
+				m.errorMessage = "Fix the highlighted fields and try again"
+			}
 			return m, nil
 		}
 		m.statusMessage = fmt.Sprintf("Feed '%s' updated successfully!", msg.Feed.Name)
@@ -740,10 +1047,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Reload feeds to show updated data
 		return m, loadFeedsCmd(m.client)
 
+	case feedPauseMsg:
+		m.loading = false
+		if msg.Err != nil {
+			m.errorMessage = client.FriendlyError(msg.Err)
+			return m, nil
+		}
+		m.errorMessage = ""
+		if msg.Feed.IsActive {
+			m.statusMessage = fmt.Sprintf("Feed '%s' resumed", msg.Feed.Name)
+		} else {
+			m.statusMessage = fmt.Sprintf("Feed '%s' paused", msg.Feed.Name)
+		}
+		m.setFeedActive(msg.Feed.ID, msg.Feed.IsActive)
+		return m, nil
+
 	case feedDeleteMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.errorMessage = client.FriendlyError(msg.Err)
 			return m, nil
 		}
 		m.statusMessage = "Feed deleted successfully!"
@@ -770,23 +1092,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Clean up the active request tracking
 		delete(m.aiActiveRequests, msg.RequestID)
+		delete(m.aiProgress, feedID)
 
 		m.aiLoading[feedID] = false
 		if msg.Err != nil {
-			m.aiResponses[feedID] = "Error: " + msg.Err.Error()
-			// Add error to history for this feed
-			history := m.aiOutputHistories[feedID]
-			history = append(history, aiOutputEntry{
-				Response:  "Error: " + msg.Err.Error(),
-				Timestamp: time.Now(),
-				Provider:  "error",
-				Duration:  0,
-			})
-			// Keep only last 10 outputs
-			if len(history) > 10 {
-				history = history[len(history)-10:]
+			// Keep the last good answer visible; errors go to a separate status line
+			m.aiResponses[feedID] = ""
+			if history := m.aiOutputHistories[feedID]; len(history) > 0 {
+				m.aiResponses[feedID] = history[len(history)-1].Response
 			}
-			m.aiOutputHistories[feedID] = history
+			m.aiErrors[feedID] = client.FriendlyError(msg.Err)
+			m.aiFailures[feedID]++
+			m.errorLog.Add(fmt.Sprintf("AI (%s): %s", m.feedDisplayName(feedID), m.aiErrors[feedID]))
 			// Record LLM error in metrics
 			if feedID != "" {
 				m.metricsCollector.RecordLLMRequest(feedID, 0, 0, 0, 0, 0, true)
@@ -794,8 +1111,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.nextWSListen()
 		}
 
+		if msg.Cancelled {
+			// Keep the partial answer on screen but out of the history and metrics
+			m.aiResponses[feedID] = msg.Answer
+			m.statusMessage = fmt.Sprintf("AI request cancelled (%d tokens used)", msg.PromptTokens+msg.CompletionTokens)
+			delete(m.aiStartTimes, feedID)
+			delete(m.aiFirstTokens, feedID)
+			return m, m.nextWSListen()
+		}
+
 		// Process successful response
 		m.aiResponses[feedID] = msg.Answer
+		delete(m.aiErrors, feedID)
+		delete(m.aiFailures, feedID)
 		m.statusMessage = fmt.Sprintf("AI response received for feed (%s, %dms)", msg.Provider, msg.Duration)
 
 		// Add to output history for this feed
@@ -804,6 +1132,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			Response:  msg.Answer,
 			Timestamp: time.Now(),
 			Provider:  msg.Provider,
+			Model:     msg.Model,
 			Duration:  msg.Duration,
 		})
 		// Keep only last 10 outputs
@@ -812,20 +1141,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.aiOutputHistories[feedID] = history
 
-		// Record LLM metrics (estimate tokens: 1 token ≈ 4 chars)
+		// Record LLM metrics with the token counts the backend reported
 		if feedID != "" {
-			// Get per-feed prompt for token estimation
-			promptValue := ""
-			if feedPrompt, ok := m.aiPrompts[feedID]; ok {
-				promptValue = feedPrompt.Value()
+			promptTokens := msg.PromptTokens
+			responseTokens := msg.CompletionTokens
+			eventsInPrompt := msg.ContextEntries
+			if eventsInPrompt == 0 {
+				// Cached answers and older backends don't say
+				eventsInPrompt = len(m.feedEntries[feedID])
 			}
-			promptTokens := len(promptValue) / 4
-			responseTokens := len(msg.Answer) / 4
-			eventsInPrompt := len(m.feedEntries[feedID])
 
 			// Calculate TTFT and generation time using per-feed tracking
 			var ttftMs, genTimeMs float64
-			if firstToken, ok := m.aiFirstTokens[feedID]; ok && !firstToken.IsZero() {
+			if msg.FirstTokenMs > 0 {
+				// Measured by the backend, without the websocket round trip
+				ttftMs = float64(msg.FirstTokenMs)
+			} else if firstToken, ok := m.aiFirstTokens[feedID]; ok && !firstToken.IsZero() {
 				if startTime, ok := m.aiStartTimes[feedID]; ok && !startTime.IsZero() {
 					ttftMs = float64(firstToken.Sub(startTime).Milliseconds())
 				}
@@ -835,6 +1166,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.metricsCollector.RecordLLMRequest(feedID, promptTokens, responseTokens, ttftMs, genTimeMs, eventsInPrompt, false)
+			if msg.ContextUtilization > 0 {
+				m.metricsCollector.RecordContextUtilization(feedID, msg.ContextUtilization)
+			}
 
 			// Clean up per-feed timing
 			delete(m.aiStartTimes, feedID)
@@ -863,6 +1197,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.aiLoading[feedID] = true // Keep showing loading while streaming
 		return m, m.nextWSListen()
 
+	case aiProgressMsg:
+		if feedID, exists := m.aiActiveRequests[msg.RequestID]; exists {
+			m.aiProgress[feedID] = msg
+		}
+		return m, m.nextWSListen()
+
 	case aiTickMsg:
 		// Auto-query tick - iterate over ALL subscribed feeds
 		if m.aiAutoMode {
@@ -914,9 +1254,48 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Schedule next tick
 		return m, tea.Tick(time.Second, func(t time.Time) tea.Msg { return aiTickMsg{} })
 
+	case reportMsg:
+		m.reportRunning = false
+		if msg.Err != nil {
+			m.errorMessage = "Report failed: " + client.FriendlyError(msg.Err)
+			return m, nil
+		}
+		m.errorMessage = ""
+		m.statusMessage = fmt.Sprintf("Report for %d feed(s) written to %s", msg.Feeds, msg.Path)
+		return m, nil
+
+	case exportMsg:
+		if msg.Err != nil {
+			m.errorMessage = "Export failed: " + msg.Err.Error()
+			return m, nil
+		}
+		m.errorMessage = ""
+		m.statusMessage = fmt.Sprintf("Exported %d entries to %s", msg.Entries, msg.Path)
+		return m, nil
+
+	case diagnosticsMsg:
+		m.diagnosticsRunning = false
+		if msg.Err != nil {
+			m.errorMessage = "Diagnostics failed: " + msg.Err.Error()
+			return m, nil
+		}
+		m.errorMessage = ""
+		m.statusMessage = "Diagnostics written to " + msg.Path + " (tokens redacted)"
+		return m, nil
+
+	case suggestionTickMsg:
+		m.refreshSuggestions()
+		return m, suggestionTickCmd()
+
 	case userTickMsg:
 		if m.token != "" {
-			return m, fetchMeCmd(m.client)
+			return m, tea.Batch(fetchMeCmd(m.client), fetchLLMUsageCmd(m.client))
+		}
+
+	case llmUsageMsg:
+		// The breakdown is informational; keep the last one when a refresh fails
+		if msg.Err == nil {
+			m.llmUsage = msg.Usage
 		}
 
 	case spinner.TickMsg:
@@ -928,26 +1307,46 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// shutdown releases background resources before quitting
+func (m model) shutdown() {
+	if m.wsClient != nil {
+		m.wsClient.Close()
+	}
+	for feedID := range m.recordings {
+		m.stopRecording(feedID)
+	}
+	m.metricsCollector.Close()
+}
+
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Global quit on Ctrl+C
 	if msg.String() == "ctrl+c" {
-		if m.wsClient != nil {
-			m.wsClient.Close()
-		}
+		m.shutdown()
 		return m, tea.Quit
 	}
 
+	// Global diagnostics report on Ctrl+D, for attaching to bug reports
+	if msg.String() == "ctrl+d" {
+		if m.diagnosticsRunning {
+			m.statusMessage = "Diagnostics already in progress..."
+			return m, nil
+		}
+		m.diagnosticsRunning = true
+		m.statusMessage = "Collecting diagnostics..."
+		return m, generateDiagnosticsCmd(m.client, m.diagnosticsSnapshot(), m.reportOutputDir)
+	}
+
 	// Quit on 'q' only if not in an input mode
 	if msg.String() == "q" {
 		isInputMode := m.screen == screenLogin ||
 			m.screen == screenRegisterFeed ||
 			m.screen == screenEditFeed ||
-			m.aiFocused
+			m.aiFocused ||
+			m.stream.searching ||
+			m.marketplace.searching
 
 		if !isInputMode {
-			if m.wsClient != nil {
-				m.wsClient.Close()
-			}
+			m.shutdown()
 			return m, tea.Quit
 		}
 	}
@@ -956,6 +1355,15 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.updateAuth(msg)
 	}
 
+	// Live stream and marketplace search inputs take all keys while focused
+	if m.stream.searching && (m.screen == screenFeeds || m.screen == screenFeedDetail) {
+		return m.updateStreamSearch(msg)
+	}
+	if m.marketplace.searching && m.screen == screenMarketplace {
+		next, cmd, _ := m.updateMarketplace(msg)
+		return next, cmd
+	}
+
 	// Handle tab switching globally (except on login screen)
 	switch msg.String() {
 	case "tab":
@@ -970,8 +1378,14 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch m.activeTab {
 		case tabDashboard:
 			m.screen = screenDashboard
+		case tabMarketplace:
+			m.screen = screenMarketplace
+			if !m.marketplace.loaded && !m.marketplace.loading {
+				return m, m.reloadMarketplace()
+			}
 		case tabRegisterFeed:
 			m.screen = screenRegisterFeed
+			m.feedFieldErrs = nil
 			m.feedName.Focus()
 			m.feedFormFocus = 0
 		case tabMyFeeds:
@@ -997,8 +1411,14 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch m.activeTab {
 		case tabDashboard:
 			m.screen = screenDashboard
+		case tabMarketplace:
+			m.screen = screenMarketplace
+			if !m.marketplace.loaded && !m.marketplace.loading {
+				return m, m.reloadMarketplace()
+			}
 		case tabRegisterFeed:
 			m.screen = screenRegisterFeed
+			m.feedFieldErrs = nil
 			m.feedName.Focus()
 			m.feedFormFocus = 0
 		case tabMyFeeds:
@@ -1070,6 +1490,12 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 			}
 			return m, nil
+		case "ctrl+s":
+			// Save the prompt to the feed's library
+			if currentFeedID != "" {
+				return m.savePrompt(currentFeedID)
+			}
+			return m, nil
 		default:
 			// Update the per-feed prompt
 			if currentFeedID != "" {
@@ -1083,6 +1509,25 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.screen == screenFeeds {
+		if next, cmd, ok := m.updatePromptLibrary(msg); ok {
+			return next, cmd
+		}
+	}
+
+	if m.screen == screenMarketplace {
+		if next, cmd, ok := m.updateMarketplace(msg); ok {
+			return next, cmd
+		}
+	}
+
+	// Live stream scrolling, pause and search on My Feeds and Feed Detail
+	if m.screen == screenFeeds || m.screen == screenFeedDetail {
+		if next, cmd, ok := m.updateStream(msg); ok {
+			return next, cmd
+		}
+	}
+
 	// Dashboard-specific key handling (up/down for vertical feed sidebar)
 	if m.screen == screenDashboard {
 		switch msg.String() {
@@ -1145,6 +1590,14 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg.String() {
+	case "1", "2", "3", "4", "5":
+		// Use a suggested AI question for the selected feed
+		if m.screen == screenFeeds && len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+			feedID := m.feeds[m.selectedIdx].ID
+			if m.applySuggestion(feedID, int(msg.String()[0]-'1')) {
+				m.statusMessage = "Suggestion loaded into prompt. Press Enter to send."
+			}
+		}
 	case "up":
 		// Only for feed list navigation, not dashboard
 		if m.screen != screenDashboard && m.selectedIdx > 0 {
@@ -1176,25 +1629,19 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if m.isSubscribed(feedID) {
 				return m, unsubscribeCmd(m.client, feedID)
 			}
-			return m, subscribeCmd(m.client, feedID, userID)
+			return m, subscribeCmd(m.client, feedID, userID, m.subscriptionTTL)
 		}
 	case "e":
+		// Export the stream buffer on Feed Detail
+		if m.screen == screenFeedDetail && m.selectedFeed != nil {
+			return m, m.exportFeedEntries(m.selectedFeed.ID, m.selectedFeed.Name)
+		}
 		// Edit feed (only on My Feeds screen)
 		if m.screen == screenFeeds && len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
 			feed := m.feeds[m.selectedIdx]
 			// Only allow editing own feeds
 			if m.user != nil && feed.OwnerID == m.user.ID {
-				m.screen = screenEditFeed
-				m.feedName.SetValue(feed.Name)
-				m.feedDescription.SetValue(feed.Description)
-				m.feedURL.SetValue(feed.URL)
-				m.feedCategory.SetValue(feed.Category)
-				m.feedEventName.SetValue(feed.EventName)
-				m.feedSubMsg.SetValue("") // Default or fetch if available
-				m.feedSystemPrompt.SetValue(feed.SystemPrompt)
-				m.feedFormFocus = 0
-				m.errorMessage = ""
-				return m, m.feedName.Focus()
+				return m, m.startEditFeed(feed)
 			} else {
 				m.errorMessage = "You can only edit your own feeds"
 			}
@@ -1211,6 +1658,17 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.errorMessage = "You can only delete your own feeds"
 			}
 		}
+	case "z":
+		// Pause or resume the selected feed's upstream (only on My Feeds screen)
+		if m.screen == screenFeeds && !m.aiFocused && len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+			feed := m.feeds[m.selectedIdx]
+			if m.user == nil || feed.OwnerID != m.user.ID {
+				m.errorMessage = "You can only pause your own feeds"
+				return m, nil
+			}
+			m.loading = true
+			return m, setFeedPausedCmd(m.client, feed.ID, feed.IsActive)
+		}
 	case "m":
 		// Toggle AI mode (auto/manual)
 		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused {
@@ -1226,6 +1684,98 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.statusMessage = "AI Manual mode enabled"
 			}
 		}
+	case "R":
+		// Export combined markdown report for all subscribed feeds (Shift+R)
+		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused {
+			if m.reportRunning {
+				m.statusMessage = "Report already in progress..."
+				return m, nil
+			}
+			feeds := m.reportSnapshot()
+			if len(feeds) == 0 {
+				m.errorMessage = "No subscribed feeds to report on"
+				return m, nil
+			}
+			m.reportRunning = true
+			m.statusMessage = fmt.Sprintf("Generating report for %d feed(s)...", len(feeds))
+			return m, generateReportCmd(m.client, m.digestCache, feeds, m.reportOutputDir)
+		}
+	case "E":
+		// Export the selected feed's stream buffer (Shift+E)
+		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused && len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+			feed := m.feeds[m.selectedIdx]
+			return m, m.exportFeedEntries(feed.ID, feed.Name)
+		}
+	case "w":
+		// Start or stop recording the selected feed's live entries to disk
+		if (m.screen == screenFeeds || m.screen == screenDashboard || m.screen == screenFeedDetail) && !m.aiFocused {
+			var feed *client.Feed
+			if m.screen == screenFeedDetail && m.selectedFeed != nil {
+				feed = m.selectedFeed
+			} else if len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+				feed = &m.feeds[m.selectedIdx]
+			}
+			if feed == nil {
+				return m, nil
+			}
+			if rec := m.recordings[feed.ID]; rec != nil {
+				m.stopRecording(feed.ID)
+				m.statusMessage = fmt.Sprintf("Recording stopped: %d entries written to %s", rec.Entries, rec.Path())
+				return m, nil
+			}
+			rec, err := startRecorder(m.recordConfig, feed.Name)
+			if err != nil {
+				m.errorMessage = "Recording failed: " + err.Error()
+				return m, nil
+			}
+			m.recordings[feed.ID] = rec
+			m.errorMessage = ""
+			m.statusMessage = "Recording to " + rec.Path() + " (w: stop)"
+		}
+	case "n":
+		// Renew the selected feed's subscription by the configured TTL (or remove its expiry)
+		if (m.screen == screenFeeds || m.screen == screenDashboard || m.screen == screenFeedDetail) && !m.aiFocused {
+			var feedID string
+			if m.screen == screenFeedDetail && m.selectedFeed != nil {
+				feedID = m.selectedFeed.ID
+			} else if len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+				feedID = m.feeds[m.selectedIdx].ID
+			}
+			if feedID == "" || !m.isSubscribed(feedID) {
+				m.errorMessage = "Not subscribed to this feed"
+				return m, nil
+			}
+			return m, renewSubscriptionCmd(m.client, feedID, m.subscriptionTTL)
+		}
+	case "b":
+		// Open the selected feed's saved prompt library
+		if m.screen == screenFeeds && !m.aiFocused {
+			m.togglePromptLibrary()
+		}
+	case "x":
+		// Cancel the current feed's AI request, or dismiss its error line when none is running
+		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused {
+			if len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+				feedID := m.feeds[m.selectedIdx].ID
+				if requestID := m.activeAIRequest(feedID); requestID != "" && m.wsClient != nil {
+					m.statusMessage = "Cancelling AI request..."
+					return m, m.cancelAIQuery(requestID)
+				}
+				delete(m.aiErrors, feedID)
+			}
+		}
+	case "t":
+		// Cycle AI temperature presets
+		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused {
+			m.aiTemperature = nextAITemperature(m.aiTemperature)
+			m.statusMessage = "AI generation settings: " + m.aiGenerationLabel()
+		}
+	case "T":
+		// Cycle AI max output tokens presets (Shift+T)
+		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused {
+			m.aiMaxTokens = nextAIMaxTokens(m.aiMaxTokens)
+			m.statusMessage = "AI generation settings: " + m.aiGenerationLabel()
+		}
 	case "i":
 		// Cycle AI interval (works on My Feeds and Dashboard)
 		if (m.screen == screenFeeds || m.screen == screenDashboard) && !m.aiFocused {
@@ -1277,9 +1827,12 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
-		// Go back from Feed Detail view to My Feeds
+		// Go back from Feed Detail view to My Feeds, or the Marketplace it was opened from
 		if m.screen == screenFeedDetail {
 			m.screen = screenFeeds
+			if m.detailBack == screenMarketplace {
+				m.screen = screenMarketplace
+			}
 			m.selectedFeed = nil
 			return m, nil
 		}
@@ -1292,7 +1845,7 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.wsClient = nil
 			}
 			m.wsStatus = "reconnecting"
-			return m, connectWS(m.wsURL, m.user.ID, m.userAgent())
+			return m, connectWS(m.wsURL, m.user.ID, m.client.Token, m.userAgent(), m.wsBatchInterval)
 		}
 	case "l":
 		if m.wsClient != nil {
@@ -1301,10 +1854,17 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.token = ""
 		m.user = nil
 		m.client.SetToken("")
+		m.client.SetRefreshToken("")
 		m.feeds = nil
 		m.subs = nil
 		m.selectedFeed = nil
+		for feedID := range m.recordings {
+			m.stopRecording(feedID)
+		}
 		m.feedEntries = map[string][]feedEntry{}
+		m.stream = newStreamView()
+		m.marketplace = newMarketplaceState()
+		m.promptLib = promptLibrary{}
 		m.wsClient = nil
 		m.wsStatus = ""
 		m.screen = screenLogin
@@ -1430,6 +1990,17 @@ func (m model) updateRegisterFeed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.feedURL.Value(), m.feedCategory.Value(),
 				m.feedEventName.Value(), m.feedSubMsg.Value(), m.feedSystemPrompt.Value())
 		}
+	case tea.KeyCtrlT:
+		// Preview the feed's messages before saving it
+		if m.feedURL.Value() == "" {
+			m.errorMessage = "Enter a WebSocket URL to test"
+			return m, nil
+		}
+		m.feedTesting = true
+		m.feedTest = nil
+		m.feedTestErr = nil
+		m.errorMessage = ""
+		return m, testFeedCmd(m.client, m.feedURL.Value(), m.feedSubMsg.Value())
 	case tea.KeyDown:
 		return m, m.nextFeedFormFocus()
 	case tea.KeyUp:
@@ -1459,62 +2030,6 @@ func (m model) updateRegisterFeed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-func (m model) updateEditFeed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
-
-	switch msg.Type {
-	case tea.KeyEsc:
-		m.screen = screenFeeds
-		m.errorMessage = ""
-		return m, nil
-	case tea.KeyEnter:
-		// Submit update
-		if m.feedName.Value() == "" || m.feedURL.Value() == "" {
-			m.errorMessage = "Name and URL are required"
-			return m, nil
-		}
-		m.loading = true
-		m.errorMessage = ""
-
-		updates := map[string]interface{}{
-			"name":         m.feedName.Value(),
-			"description":  m.feedDescription.Value(),
-			"url":          m.feedURL.Value(),
-			"category":     m.feedCategory.Value(),
-			"eventName":    m.feedEventName.Value(),
-			"systemPrompt": m.feedSystemPrompt.Value(),
-		}
-
-		return m, updateFeedCmd(m.client, m.feeds[m.selectedIdx].ID, updates)
-	case tea.KeyUp, tea.KeyShiftTab:
-		return m, m.prevFeedFormFocus()
-	case tea.KeyDown, tea.KeyTab:
-		return m, m.nextFeedFormFocus()
-	}
-
-	// Handle text input updates
-	var cmd tea.Cmd
-	switch m.feedFormFocus {
-	case 0:
-		m.feedName, cmd = m.feedName.Update(msg)
-	case 1:
-		m.feedDescription, cmd = m.feedDescription.Update(msg)
-	case 2:
-		m.feedURL, cmd = m.feedURL.Update(msg)
-	case 3:
-		m.feedCategory, cmd = m.feedCategory.Update(msg)
-	case 4:
-		m.feedEventName, cmd = m.feedEventName.Update(msg)
-	case 5:
-		m.feedSubMsg, cmd = m.feedSubMsg.Update(msg)
-	case 6:
-		m.feedSystemPrompt, cmd = m.feedSystemPrompt.Update(msg)
-	}
-	cmds = append(cmds, cmd)
-
-	return m, tea.Batch(cmds...)
-}
-
 func (m *model) nextFeedFormFocus() tea.Cmd {
 	inputs := []struct {
 		input *textinput.Model
@@ -1591,7 +2106,7 @@ func (m model) viewAuth() string {
 	builder.WriteString(m.password.View())
 	builder.WriteString("\n")
 	if m.authMode == "login" {
-		builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("TOTP (optional): "))
+		builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("TOTP / backup code (optional): "))
 		builder.WriteString(m.totp.View())
 		builder.WriteString("\n")
 	}
@@ -1620,7 +2135,7 @@ func (m model) viewApp() string {
 }
 
 func (m model) viewTabBar() string {
-	tabs := []string{"Dashboard", "Register Feed", "My Feeds", "API", "Help"}
+	tabs := []string{"Dashboard", "Marketplace", "Register Feed", "My Feeds", "API", "Help"}
 	var renderedTabs []string
 
 	for i, tab := range tabs {
@@ -1654,6 +2169,8 @@ func (m model) viewContent() string {
 	switch m.screen {
 	case screenDashboard:
 		return m.viewDashboard()
+	case screenMarketplace:
+		return m.viewMarketplace()
 	case screenFeedDetail:
 		return m.viewFeedDetail()
 	case screenRegisterFeed:
@@ -1696,7 +2213,7 @@ func (m model) viewMyFeeds() string {
 
 	// Height calculations: Feed list is 12, we want Instructions + Feed list bottom to align with Live Stream bottom
 	feedListHeight := 12
-	streamHeight := 25
+	streamHeight := myFeedsStreamHeight
 	infoBoxHeight := 10 // approximate height of info box
 
 	// Total right column height = infoBox + streamBox
@@ -1756,6 +2273,9 @@ func (m model) viewMyFeeds() string {
 		if m.isSubscribed(f.ID) {
 			subscribed = " [ok]"
 		}
+		if !f.IsActive {
+			subscribed += " [paused]"
+		}
 		// Calculate max name length: leftColWidth - 4 (borders) - 2 (cursor) - category - subscribed - brackets
 		maxNameLen := leftColWidth - 18
 		if maxNameLen < 10 {
@@ -1788,6 +2308,7 @@ func (m model) viewMyFeeds() string {
 	instructBuilder.WriteString("  s        Sub/Unsub\n")
 	instructBuilder.WriteString("  e        Edit feed\n")
 	instructBuilder.WriteString("  r        Reconnect to WS\n")
+	instructBuilder.WriteString("  z        Pause/Resume my feed\n")
 	instructBuilder.WriteString("  Shift+D  Delete my feed\n")
 	instructBuilder.WriteString("  l        Logout\n")
 	instructBuilder.WriteString("  q        Quit\n")
@@ -1798,6 +2319,9 @@ func (m model) viewMyFeeds() string {
 	instructBuilder.WriteString("  Enter    Send prompt\n")
 	instructBuilder.WriteString("  Esc      Exit prompt\n")
 	instructBuilder.WriteString("  m        Auto/Manual\n")
+	instructBuilder.WriteString("  1-5      Use suggestion\n")
+	instructBuilder.WriteString("  b        Saved prompts\n")
+	instructBuilder.WriteString("  x        Dismiss AI error\n")
 	instructBuilder.WriteString("  [ ]      Scroll output\n")
 
 	instructBox := renderBoxWithTitle("Instructions", instructBuilder.String(), leftColWidth, instructHeight, darkMagentaColor, magentaColor)
@@ -1830,6 +2354,12 @@ func (m model) viewMyFeeds() string {
 		subStatus := "[-] Not Subscribed"
 		if m.isSubscribed(feed.ID) {
 			subStatus = "[+] Subscribed"
+			if expiry := m.subscriptionExpiryLabel(feed.ID); expiry != "" {
+				subStatus += " (" + expiry + ", n: renew)"
+			}
+		}
+		if !feed.IsActive {
+			subStatus += " | Paused"
 		}
 		infoBuilder.WriteString(fmt.Sprintf("Status: %s\n", subStatus))
 		infoBuilder.WriteString(fmt.Sprintf("WS: %s", m.wsStatus))
@@ -1857,19 +2387,21 @@ func (m model) viewMyFeeds() string {
 				streamBuilder.WriteString("Waiting for data...")
 			}
 		} else {
-			// Show latest entries (up to fit in box)
-			showCount := streamHeight - 3 // account for borders
-			if len(entries) < showCount {
-				showCount = len(entries)
-			}
-			for i := 0; i < showCount; i++ {
-				e := entries[i]
-				timestamp := e.Time.Format("15:04:05")
-				streamBuilder.WriteString(fmt.Sprintf("%s %s\n", timestamp, truncate(e.Data, maxDataWidth)))
+			sv := m.streamViewFor(feed.ID)
+			shown := sv.entries(entries)
+			rows := m.streamRows()
+			streamBuilder.WriteString(renderStreamStatus(sv, len(shown), len(entries), rows) + "\n")
+			if len(shown) == 0 {
+				streamBuilder.WriteString("No entries match the search (Esc: clear)\n")
 			}
+			streamBuilder.WriteString(renderStreamEntries(sv, shown, rows, maxDataWidth, false))
 		}
 
-		streamBox := renderBoxWithTitle("Live Stream", streamBuilder.String(), middleColWidth, streamHeight, darkCyanColor, cyanColor)
+		streamTitle := "Live Stream"
+		if rec := m.recordings[feed.ID]; rec != nil {
+			streamTitle += fmt.Sprintf(" [REC %d]", rec.Entries)
+		}
+		streamBox := renderBoxWithTitle(streamTitle, streamBuilder.String(), middleColWidth, streamHeight, darkCyanColor, cyanColor)
 
 		// AI Analysis Box (right column) - with scrollable output
 		aiBuilder := strings.Builder{}
@@ -1890,8 +2422,18 @@ func (m model) viewMyFeeds() string {
 			aiBuilder.WriteString("  ")
 			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(greenColor).Render("▶ Active"))
 		}
+		aiBuilder.WriteString("  ")
+		aiBuilder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render(m.aiGenerationLabel()))
 		aiBuilder.WriteString("\n")
 
+		// Last AI error, kept apart from the output so good answers stay visible
+		feedAIError := m.aiErrors[feed.ID]
+		if feedAIError != "" {
+			errLine := fmt.Sprintf("⚠ %s (failures: %d, x: dismiss)", feedAIError, m.aiFailures[feed.ID])
+			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(redColor).Render(truncate(errLine, aiColWidth-6)))
+			aiBuilder.WriteString("\n")
+		}
+
 		// Dynamic separator based on AI panel width
 		separatorWidth := aiColWidth - 8 // account for padding and border
 		if separatorWidth < 20 {
@@ -1907,7 +2449,20 @@ func (m model) viewMyFeeds() string {
 
 		// Calculate available height for output area
 		// Total height - header(3) - mode(2) - separator(2) - prompt(3) - controls(2) - borders/padding(4)
+		feedSuggestions := m.aiSuggestions[feed.ID]
+		libraryOpen := m.promptLib.open && m.promptLib.feedID == feed.ID
 		outputAreaHeight := aiHeight - 16
+		if libraryOpen {
+			outputAreaHeight -= m.promptLibraryLines(feed.ID) + 1 // saved prompts + separator
+		} else if len(feedSuggestions) > 0 {
+			outputAreaHeight -= len(feedSuggestions) + 2 // suggestions + heading + separator
+		}
+		if m.aiFocused {
+			outputAreaHeight -= aiPromptEditHeight - 3 // the prompt editor grows while focused
+		}
+		if feedAIError != "" {
+			outputAreaHeight--
+		}
 		if outputAreaHeight < 6 {
 			outputAreaHeight = 6
 		}
@@ -1924,7 +2479,11 @@ func (m model) viewMyFeeds() string {
 		feedAILoading := m.aiLoading[feed.ID]
 
 		if feedAILoading && len(feedAIHistory) == 0 {
-			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(magentaColor).Render("[...] Querying LLM..."))
+			status := "[...] Querying LLM..."
+			if progress, ok := m.aiProgress[feed.ID]; ok {
+				status = fmt.Sprintf("[...] Streaming: %d tokens, %.1fs", progress.Tokens, float64(progress.ElapsedMs)/1000)
+			}
+			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(magentaColor).Render(status))
 			aiBuilder.WriteString("\n")
 		}
 
@@ -1944,7 +2503,11 @@ func (m model) viewMyFeeds() string {
 				entry := feedAIHistory[i]
 				// Header line with timestamp and provider
 				timestamp := entry.Timestamp.Format("15:04:05")
-				header := fmt.Sprintf("[%s | %s | %dms]", timestamp, entry.Provider, entry.Duration)
+				provider := entry.Provider
+				if entry.Model != "" {
+					provider += "/" + entry.Model
+				}
+				header := fmt.Sprintf("[%s | %s | %dms]", timestamp, provider, entry.Duration)
 				outputContent.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render(header))
 				outputContent.WriteString("\n")
 
@@ -1992,6 +2555,25 @@ func (m model) viewMyFeeds() string {
 		aiBuilder.WriteString(lipgloss.NewStyle().Foreground(darkMagentaColor).Render(separator))
 		aiBuilder.WriteString("\n")
 
+		// Saved prompts while the library is open, otherwise suggested
+		// questions inferred from the feed's schema
+		if libraryOpen {
+			aiBuilder.WriteString(m.renderPromptLibrary(feed.ID, aiTextWidth))
+			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(darkMagentaColor).Render(separator))
+			aiBuilder.WriteString("\n")
+		} else if len(feedSuggestions) > 0 {
+			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("Suggestions (1-5 to use):"))
+			aiBuilder.WriteString("\n")
+			for i, q := range feedSuggestions {
+				num := lipgloss.NewStyle().Foreground(magentaColor).Render(fmt.Sprintf("%d ", i+1))
+				aiBuilder.WriteString(num)
+				aiBuilder.WriteString(lipgloss.NewStyle().Foreground(whiteColor).Render(truncate(q, aiTextWidth-2)))
+				aiBuilder.WriteString("\n")
+			}
+			aiBuilder.WriteString(lipgloss.NewStyle().Foreground(darkMagentaColor).Render(separator))
+			aiBuilder.WriteString("\n")
+		}
+
 		// Prompt input area - with green > prefix and per-feed prompt
 		promptPrefix := lipgloss.NewStyle().Foreground(greenColor).Render("> ")
 		aiBuilder.WriteString(promptPrefix)
@@ -2007,6 +2589,7 @@ func (m model) viewMyFeeds() string {
 		feedPrompt.SetWidth(promptWidth)
 
 		if m.aiFocused {
+			feedPrompt.SetHeight(aiPromptEditHeight)
 			feedPrompt.Focus()
 		} else {
 			feedPrompt.Blur()
@@ -2016,7 +2599,10 @@ func (m model) viewMyFeeds() string {
 		aiBuilder.WriteString("\n\n")
 
 		// AI Controls hint - updated with pause info
-		controlHint := "Enter: send | m: mode | p: edit | Shift+P: pause"
+		controlHint := "Enter: send | m: mode | p: edit | b: saved | x: cancel | Shift+P: pause"
+		if m.aiFocused {
+			controlHint = "Enter: send | Alt+Enter: new line | Ctrl+S: save prompt | Esc: done"
+		}
 		aiBuilder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render(controlHint))
 
 		aiBox := renderBoxWithTitle("AI Analysis", aiBuilder.String(), aiColWidth, aiHeight, darkMagentaColor, magentaColor)
@@ -2062,6 +2648,22 @@ func (m model) viewDashboard() string {
 	return contentStyle.Render(builder.String())
 }
 
+// renderFeedHealth formats a feed's upstream health for the feed detail view
+func renderFeedHealth(h feedHealthMsg) string {
+	color := greenColor
+	if h.Status != "connected" {
+		color = redColor
+	}
+	line := "Upstream: " + h.Status
+	if h.ErrorCount > 0 {
+		line += fmt.Sprintf(" | %d errors", h.ErrorCount)
+		if h.LastError != "" {
+			line += " (last: " + truncate(h.LastError, 40) + ")"
+		}
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(line)
+}
+
 func (m model) viewFeedDetail() string {
 	if m.selectedFeed == nil {
 		return contentStyle.Render("Select a feed to view details.")
@@ -2078,11 +2680,22 @@ func (m model) viewFeedDetail() string {
 	subStatus := lipgloss.NewStyle().Foreground(redColor).Render("not subscribed")
 	if m.isSubscribed(feed.ID) {
 		subStatus = lipgloss.NewStyle().Foreground(greenColor).Render("subscribed [ok]")
+		if expiry := m.subscriptionExpiryLabel(feed.ID); expiry != "" {
+			subStatus += lipgloss.NewStyle().Foreground(dimCyanColor).Render(" (" + expiry + ", n: renew)")
+		}
 	}
 	builder.WriteString(fmt.Sprintf("Status: %s | WS: %s\n", subStatus, m.wsStatus))
+	if upstream := m.feedUpstream[feed.ID]; upstream != "" {
+		builder.WriteString(lipgloss.NewStyle().Foreground(redColor).Render("Upstream: "+upstream) + "\n")
+	} else if health, ok := m.feedHealth[feed.ID]; ok {
+		builder.WriteString(renderFeedHealth(health) + "\n")
+	}
 
 	builder.WriteString("\n")
 	builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(dimCyanColor).Render("Live data (latest first):"))
+	if rec := m.recordings[feed.ID]; rec != nil {
+		builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(redColor).Render(fmt.Sprintf("  ● REC %d → %s", rec.Entries, rec.Path())))
+	}
 	builder.WriteString("\n")
 
 	// Calculate available height for entries
@@ -2095,23 +2708,25 @@ func (m model) viewFeedDetail() string {
 	entries := m.feedEntries[feed.ID]
 	if len(entries) == 0 {
 		builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("No data yet. Subscribe (s) or wait for updates."))
+		builder.WriteString("\n\n")
+		builder.WriteString(m.renderFeedSample())
 	} else {
-		// Limit entries to available height
-		showCount := availableHeight
-		if len(entries) < showCount {
-			showCount = len(entries)
+		sv := m.streamViewFor(feed.ID)
+		shown := sv.entries(entries)
+		rows := m.streamRows()
+		builder.WriteString(renderStreamStatus(sv, len(shown), len(entries), rows) + "\n")
+		if len(shown) == 0 {
+			builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("No entries match the search (Esc: clear)") + "\n")
 		}
-		for i := 0; i < showCount; i++ {
-			e := entries[i]
-			builder.WriteString(fmt.Sprintf("[%s] %s\n", e.Time.Format("15:04:05"), truncate(e.Data, 100)))
-		}
-		if len(entries) > showCount {
-			builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render(fmt.Sprintf("  ... and %d more entries", len(entries)-showCount)))
+		builder.WriteString(renderStreamEntries(sv, shown, rows, 100, true))
+		if sv.expanded && sv.selected < len(shown) {
+			builder.WriteString("\n")
+			builder.WriteString(renderExpandedEntry(shown[sv.selected], 110, availableHeight-rows-3))
 		}
 	}
 
 	builder.WriteString("\n")
-	builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("s: subscribe/unsubscribe | Esc: go back to My Feeds"))
+	builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("↑/↓ Enter: expand | space: pause | /: search | s: sub/unsub | e: export | w: record | Esc: back"))
 
 	// Calculate box dimensions
 	boxWidth := m.termWidth - 4
@@ -2126,58 +2741,97 @@ func (m model) viewFeedDetail() string {
 	return renderBoxWithTitle(feed.Name, builder.String(), boxWidth, boxHeight, darkCyanColor, cyanColor)
 }
 
-func (m model) viewRegisterFeed() string {
-	builder := strings.Builder{}
-	builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(cyanColor).Render("📝 Register New WebSocket Feed"))
-	builder.WriteString("\n\n")
+// renderFeedSample shows a preview message fetched from the backend so a feed's
+// shape is visible before subscribing.
+func (m model) renderFeedSample() string {
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	switch {
+	case m.feedSampleErr != nil:
+		return dim.Render("Sample unavailable: " + client.FriendlyError(m.feedSampleErr))
+	case m.feedSample == nil:
+		return dim.Render("Fetching sample message...")
+	}
+	data, err := json.Marshal(m.feedSample.Sample)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", m.feedSample.Sample))
+	}
+	label := fmt.Sprintf("Sample (%s, %s):", m.feedSample.Source, m.feedSample.Timestamp)
+	return lipgloss.NewStyle().Bold(true).Foreground(dimCyanColor).Render(label) + "\n" + truncate(string(data), 300)
+}
 
-	labels := []string{
-		"Feed Name *",
-		"Description",
-		"WebSocket URL *",
-		"Category",
-		"Event Name",
-		"Subscription Message (JSON)",
-		"AI System Prompt",
+// renderFeedTest shows the register form's connection test: the messages
+// read from the URL, their format and rate, and whether the subscription
+// message got a reply.
+func (m model) renderFeedTest() string {
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	switch {
+	case m.feedTesting:
+		return fmt.Sprintf("%s Testing connection...", m.spinner.View())
+	case m.feedTestErr != nil:
+		return lipgloss.NewStyle().Foreground(redColor).Render("Connection failed: " + client.FriendlyError(m.feedTestErr))
+	case m.feedTest == nil:
+		return ""
 	}
-	inputs := []*textinput.Model{
-		&m.feedName,
-		&m.feedDescription,
-		&m.feedURL,
-		&m.feedCategory,
-		&m.feedEventName,
-		&m.feedSubMsg,
-		&m.feedSystemPrompt,
+	t := m.feedTest
+	builder := strings.Builder{}
+	format := t.DataFormat
+	if format == "" {
+		format = "none"
 	}
-
-	for i, label := range labels {
-		labelStyle := lipgloss.NewStyle().Foreground(dimCyanColor)
-		if i == m.feedFormFocus {
-			labelStyle = lipgloss.NewStyle().Foreground(cyanColor).Bold(true)
-		}
-		builder.WriteString(labelStyle.Render(label + ": "))
-		builder.WriteString(inputs[i].View())
+	builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(dimCyanColor).Render(
+		fmt.Sprintf("Connected: %d messages in %.1fs (%.1f/s), format %s", t.MessageCount, float64(t.DurationMs)/1000, t.MessagesPerSecond, format)))
+	if sub := t.Subscription; sub != nil {
 		builder.WriteString("\n")
+		if sub.Replied {
+			builder.WriteString(lipgloss.NewStyle().Foreground(greenColor).Render("Subscription message answered"))
+		} else {
+			builder.WriteString(lipgloss.NewStyle().Foreground(redColor).Render("Subscription message: " + sub.Error))
+		}
 	}
-
-	builder.WriteString("\n")
-	builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("↑↓ navigate | Enter submit | Esc cancel | * required"))
-
-	if m.loading {
+	if t.Error != "" {
 		builder.WriteString("\n")
-		builder.WriteString(fmt.Sprintf("%s Creating feed...", m.spinner.View()))
+		builder.WriteString(dim.Render("Stopped early: " + t.Error))
 	}
-	if m.errorMessage != "" {
+	for _, sample := range t.Samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			data = []byte(fmt.Sprintf("%v", sample))
+		}
 		builder.WriteString("\n")
-		builder.WriteString(lipgloss.NewStyle().Foreground(redColor).Render(m.errorMessage))
+		builder.WriteString(dim.Render("  " + truncate(string(data), 120)))
 	}
+	return builder.String()
+}
 
-	return contentStyle.Render(builder.String())
+// viewCategoryHint lists the backend's categories under the category field
+func (m model) viewCategoryHint() string {
+	if len(m.categories) == 0 {
+		return ""
+	}
+	names := make([]string, len(m.categories))
+	for i, cat := range m.categories {
+		names[i] = cat.Key
+	}
+	hint := "  " + truncate(strings.Join(names, ", "), 70) + "  (→ completes, ctrl+n/ctrl+p cycles)"
+	return lipgloss.NewStyle().Foreground(dimCyanColor).Render(hint) + "\n"
 }
 
-func (m model) viewEditFeed() string {
+// feedFormFields are the feed fields behind the register and edit form
+// inputs, in order, for matching the backend's validation errors
+var feedFormFields = []string{"name", "description", "url", "category", "eventName", "connectionMessages.0", "systemPrompt"}
+
+// viewFeedFieldError renders the backend's complaint about a form field, if any
+func (m model) viewFeedFieldError(field string) string {
+	msg, ok := m.feedFieldErrs[field]
+	if !ok {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(redColor).Render("  ↳ "+msg) + "\n"
+}
+
+func (m model) viewRegisterFeed() string {
 	builder := strings.Builder{}
-	builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(cyanColor).Render("✏️ Edit Feed"))
+	builder.WriteString(lipgloss.NewStyle().Bold(true).Foreground(cyanColor).Render("📝 Register New WebSocket Feed"))
 	builder.WriteString("\n\n")
 
 	labels := []string{
@@ -2207,14 +2861,22 @@ func (m model) viewEditFeed() string {
 		builder.WriteString(labelStyle.Render(label + ": "))
 		builder.WriteString(inputs[i].View())
 		builder.WriteString("\n")
+		builder.WriteString(m.viewFeedFieldError(feedFormFields[i]))
+		if i == 3 && m.feedFormFocus == 3 {
+			builder.WriteString(m.viewCategoryHint())
+		}
 	}
 
 	builder.WriteString("\n")
-	builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("↑↓ navigate | Enter save | Esc cancel | * required"))
+	builder.WriteString(lipgloss.NewStyle().Foreground(dimCyanColor).Render("↑↓ navigate | Enter submit | Ctrl+T test connection | Esc cancel | * required"))
 
 	if m.loading {
 		builder.WriteString("\n")
-		builder.WriteString(fmt.Sprintf("%s Updating feed...", m.spinner.View()))
+		builder.WriteString(fmt.Sprintf("%s Creating feed...", m.spinner.View()))
+	}
+	if preview := m.renderFeedTest(); preview != "" {
+		builder.WriteString("\n\n")
+		builder.WriteString(preview)
 	}
 	if m.errorMessage != "" {
 		builder.WriteString("\n")
@@ -2250,6 +2912,8 @@ func (m model) viewAPI() string {
 	builder.WriteString("\n")
 	builder.WriteString("Connect to: " + m.backendURL + "/ws")
 	builder.WriteString("\n")
+	builder.WriteString("First: 'authenticate' Payload: { \"token\": \"<ACCESS_TOKEN>\" }, then subscribe over REST")
+	builder.WriteString("\n")
 	builder.WriteString("Event: 'subscribe-feed' Payload: { \"feedId\": \"<FEED_ID>\" }")
 	builder.WriteString("\n")
 	builder.WriteString("Listen for: 'llm-broadcast' event for AI updates.")
@@ -2281,6 +2945,7 @@ NAVIGATION
 TABS OVERVIEW
 -------------
   Dashboard       View your subscribed feeds in real-time
+  Marketplace     Browse, search and subscribe to public feeds
   Register Feed   Create and register new WebSocket feeds  
   My Feeds        Manage your registered feeds
   Help            You are here! Documentation and guides
@@ -2305,6 +2970,34 @@ KEYBOARD SHORTCUTS
   Up/Down         Select different feed in sidebar
 
 The Dashboard displays real-time streaming data from your subscribed feeds.`,
+		},
+		{
+			title: "Marketplace",
+			content: `BROWSING THE MARKETPLACE
+========================
+
+The Marketplace lists the public feeds anyone can subscribe to, with a
+preview of the selected feed next to the list.
+
+LAYOUT
+------
+  Top Line        Category and sort in effect, and the search box
+  Left Panel      Feeds with their category and subscriber count
+  Right Panel     Preview of the selected feed
+
+KEYBOARD SHORTCUTS
+------------------
+  Up/Down         Select a feed (more load at the end of the list)
+  /               Search: typing narrows the list as you go
+  Enter           In the search box: also search the whole marketplace
+                  Otherwise: open the selected feed
+  Esc             Clear the search
+  c               Next category
+  o               Sort by popularity or most recent
+  s               Subscribe/Unsubscribe to the selected feed
+
+Search matches loosely: "btc tkr" finds "BTC Ticker". Feeds found by the
+marketplace search but not loaded yet are listed after the loose matches.`,
 		},
 		{
 			title: "Register Feed",
@@ -2335,7 +3028,7 @@ EXAMPLE: CRYPTO FEED
 
 TIPS
 ----
-  - Test your WebSocket URL before registering
+  - Press Ctrl+T to test your WebSocket URL and preview its messages
   - Use descriptive names for easy identification
   - Set a good AI prompt for better analysis`,
 		},
@@ -2358,10 +3051,16 @@ KEYBOARD SHORTCUTS
   Up/Down     Navigate feed list
   Enter       View feed details
   s           Subscribe/Unsubscribe to feed
+  n           Renew an expiring subscription
+  z           Pause/Resume selected feed (owners)
+  e           Edit selected feed (owners)
   D           Delete selected feed (Shift+D)
   r           Reconnect WebSocket
   p           Open custom AI prompt input (per-feed)
   Shift+P     Pause/Resume AI Analysis
+  1-5         Use a suggested AI question
+  b           Open the saved prompt library
+  x           Cancel the running AI query, or dismiss the AI error line
   Esc         Return from feed details
 
 AI ANALYSIS
@@ -2369,6 +3068,18 @@ AI ANALYSIS
 The AI panel provides intelligent insights about your data streams.
 Press 'p' to enter a custom prompt for analysis.
 Press 'Shift+P' to pause/resume AI queries for current feed.
+Suggested questions are generated from the fields seen in the feed
+and refresh as its schema evolves. Press 1-5 to load one into the prompt.
+
+The prompt editor takes several lines: Alt+Enter (or Ctrl+J) starts a
+new line and Enter sends the prompt. Ctrl+S saves the prompt to the
+feed's library, which is kept with your subscription on the server.
+Press 'b' to open the library: 1-9 or Enter load a saved prompt into
+the editor, d deletes the selected one and Esc closes it.
+If a query fails, the last good answer stays visible and the error is
+shown on its own line with a failure count. Press 'x' to dismiss it.
+Press 'x' while an answer is streaming to stop it; the part already
+streamed stays visible and only the tokens used so far are charged.
 
 Each feed has its own prompt - prompts are preserved when switching feeds.
 
@@ -2390,6 +3101,15 @@ FEED DETAILS VIEW
 
   Press Esc to return to feed list
 
+EDITING A FEED
+--------------
+  Press 'e' on one of your feeds to edit it. Besides the fields of
+  the register form you can set the default AI prompt, reconnection
+  behaviour, and the headers and query params sent when connecting.
+  Tab/Up/Down move between fields; in the headers and query params
+  Ctrl+N adds a row and Ctrl+X removes one. Secret values show as
+  [redacted] and are kept unless you change them. Enter saves.
+
 SUBSCRIPTIONS
 -------------
   - Subscribe to feeds you want on your Dashboard
@@ -2410,8 +3130,29 @@ STEP 1: CONNECT
   
   In Postman: Enter URL and click "Connect"
 
+  Optionally say hello first to agree on a protocol version and
+  features ("replay", "binary", "compression"); clients that skip
+  it get version 1:
+  {
+    "type": "hello",
+    "payload": { "version": 2, "features": ["replay"] }
+  }
+  The server answers with the agreed "version" and "features".
+  Without "replay" in the features, replayCount is ignored.
+  With "binary", feed-data arrives as MessagePack binary frames.
+
+  Then authenticate with your access token (or "apiKey"):
+  {
+    "type": "authenticate",
+    "payload": { "token": "<ACCESS_TOKEN>" }
+  }
+
 STEP 2: SUBSCRIBE
 -----------------
+  Subscribe to the feed over REST first (POST
+  /api/marketplace/subscribe/:feedId); feed owners may skip this.
+  Otherwise the server answers "subscription-denied" with the reason.
+
   Choose what data you want to receive:
 
   LLM Output Only (recommended for most use cases):
@@ -2453,6 +3194,33 @@ STEP 3: RECEIVE DATA
     "payload": { "feedId": "...", "data": {...} }
   }
 
+  Recent Data on Subscribe (feed-replay):
+  Add "replayCount": 10 to the subscribe payload to receive
+  the last messages (oldest first) right after subscribing.
+  {
+    "type": "feed-replay",
+    "payload": { "feedId": "...", "messages": [...], "count": 10 }
+  }
+
+  Upstream Health (feed-health):
+  Sent on subscribe and whenever the feed's source changes state;
+  also available from GET /api/marketplace/feeds/:id/health.
+  {
+    "type": "feed-health",
+    "payload": { "feedId": "...", "status": "connected",
+                 "lastMessageAt": "...", "errorCount": 0 }
+  }
+
+  Upstream Status (feed-status):
+  Sent when the feed's source drops; status is "reconnecting",
+  "connected" or "circuit-open" (retries exhausted).
+  {
+    "type": "feed-status",
+    "payload": { "feedId": "...", "status": "reconnecting",
+                 "attempt": 3, "maxAttempts": 10,
+                 "message": "reconnecting (attempt 3/10)" }
+  }
+
 UNSUBSCRIBE
 -----------
   {
@@ -2499,16 +3267,41 @@ KEYBOARD REFERENCE
   Dashboard & My Feeds:
     Up/Down         Navigate feed list
     i               Change AI interval
+    t / Shift+T     Cycle AI temperature / max tokens
+    Ctrl+D          Write a redacted diagnostics report
     m               Toggle AI auto/manual
     p               Custom AI prompt (per-feed)
     Shift+P         Pause/Resume AI
+    x               Cancel running AI query
+    Shift+R         Export report (all subscribed feeds)
+    Shift+E         Export the feed's stream buffer to a file
+    w               Start/stop recording the feed to disk
     r               Reconnect WebSocket
     
+  Marketplace:
+    /               Search feeds (Enter searches the whole marketplace)
+    c / o           Next category / popular or recent
+    s               Subscribe/Unsubscribe
+    Enter           Open the feed (Esc returns to the Marketplace)
+
   My Feeds Only:
     s               Subscribe/Unsubscribe
+    1-5             Use suggested AI question
+    b               Saved prompts (1-9 load, d delete)
+    Ctrl+S          Save the prompt being edited
+    z               Pause/Resume feed
     D               Delete feed (Shift+D)
     Enter           View feed details
+    e               Export stream buffer (on feed details)
     Esc             Back to list
+
+  Live Stream (My Feeds & feed details):
+    Space           Pause/Resume the stream
+    /               Search entries (Enter keeps, Esc clears)
+    PgUp/PgDn       Scroll back through the buffer
+    Home/End        Newest/oldest entry
+    Up/Down         Select an entry (feed details)
+    Enter           Expand the entry as JSON (feed details)
     
   Help:
     Left/Right      Navigate pages
@@ -2628,6 +3421,65 @@ func (m model) viewFooter() string {
 	return ""
 }
 
+// activeSubscriptions drops inactive (unsubscribed or expired) subscriptions
+func activeSubscriptions(subs []client.Subscription) []client.Subscription {
+	active := make([]client.Subscription, 0, len(subs))
+	for _, s := range subs {
+		if s.IsActive {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// subscription returns the user's subscription to a feed, if any
+func (m model) subscription(feedID string) *client.Subscription {
+	for i := range m.subs {
+		if m.subs[i].FeedID == feedID {
+			return &m.subs[i]
+		}
+	}
+	return nil
+}
+
+// subscriptionExpiryLabel describes the time left on an expiring subscription, or "" if it does not expire
+func (m model) subscriptionExpiryLabel(feedID string) string {
+	sub := m.subscription(feedID)
+	if sub == nil || sub.ExpiresAt == nil {
+		return ""
+	}
+	left := time.Until(*sub.ExpiresAt)
+	if left <= 0 {
+		return "expiring"
+	}
+	if left < time.Minute {
+		return fmt.Sprintf("expires in %ds", int(left.Seconds()))
+	}
+	return "expires in " + strings.TrimSuffix(left.Truncate(time.Minute).String(), "0s")
+}
+
+// feedDisplayName returns a feed's name for status messages, falling back to its ID
+func (m model) feedDisplayName(feedID string) string {
+	for _, f := range m.feeds {
+		if f.ID == feedID {
+			return f.Name
+		}
+	}
+	return feedID
+}
+
+// setFeedActive records a feed being paused or resumed in the loaded listings
+func (m *model) setFeedActive(feedID string, active bool) {
+	for i := range m.feeds {
+		if m.feeds[i].ID == feedID {
+			m.feeds[i].IsActive = active
+		}
+	}
+	if m.selectedFeed != nil && m.selectedFeed.ID == feedID {
+		m.selectedFeed.IsActive = active
+	}
+}
+
 func (m model) isSubscribed(feedID string) bool {
 	for _, s := range m.subs {
 		if s.FeedID == feedID {
@@ -2681,115 +3533,175 @@ func (m model) nextWSListen() tea.Cmd {
 
 // ---- Commands ----
 
-func loginCmd(client *api.Client, email, password, totp string) tea.Cmd {
+func loginCmd(c *client.Client, email, password, totp string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		token, user, err := client.Login(ctx, email, password, totp)
+		token, user, err := c.Login(ctx, email, password, totp)
 		return authResultMsg{Token: token, User: user, Err: err}
 	}
 }
 
-func registerCmd(client *api.Client, email, password, name string) tea.Cmd {
+func registerCmd(c *client.Client, email, password, name string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		token, user, err := client.Register(ctx, email, password, name)
+		token, user, err := c.Register(ctx, email, password, name)
 		return authResultMsg{Token: token, User: user, Err: err}
 	}
 }
 
-func fetchMeCmd(client *api.Client) tea.Cmd {
+func fetchMeCmd(c *client.Client) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
 		defer cancel()
-		user, err := client.Me(ctx)
+		user, err := c.Me(ctx)
 		return meResultMsg{User: user, Err: err}
 	}
 }
 
-func loadInitialDataCmd(client *api.Client) tea.Cmd {
-	return tea.Batch(loadFeedsCmd(client), loadSubscriptionsCmd(client))
+func loadInitialDataCmd(c *client.Client) tea.Cmd {
+	return tea.Batch(loadFeedsCmd(c), loadSubscriptionsCmd(c), loadCategoriesCmd(c), fetchLLMUsageCmd(c))
+}
+
+func fetchLLMUsageCmd(c *client.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		usage, err := c.LLMUsage(ctx, "feed")
+		return llmUsageMsg{Usage: usage, Err: err}
+	}
 }
 
-func loadFeedsCmd(client *api.Client) tea.Cmd {
+func loadCategoriesCmd(c *client.Client) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		feeds, err := client.MyFeeds(ctx)
+		cats, err := c.Categories(ctx)
+		return categoriesMsg{Categories: cats, Err: err}
+	}
+}
+
+func loadFeedsCmd(c *client.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		feeds, err := c.MyFeeds(ctx)
 		return feedsMsg{Feeds: feeds, Err: err}
 	}
 }
 
-func loadSubscriptionsCmd(client *api.Client) tea.Cmd {
+func loadSubscriptionsCmd(c *client.Client) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		subs, err := client.Subscriptions(ctx)
+		subs, err := c.Subscriptions(ctx)
 		return subsMsg{Subs: subs, Err: err}
 	}
 }
 
-func fetchFeedCmd(client *api.Client, id string) tea.Cmd {
+func fetchFeedCmd(c *client.Client, id string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		feed, err := client.Feed(ctx, id)
+		feed, err := c.Feed(ctx, id)
 		return feedDetailMsg{Feed: feed, Err: err}
 	}
 }
 
-func subscribeCmd(client *api.Client, feedID, userID string) tea.Cmd {
+func fetchFeedSampleCmd(c *client.Client, id string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		sample, err := c.FeedSample(ctx, id)
+		return feedSampleMsg{FeedID: id, Sample: sample, Err: err}
+	}
+}
+
+func subscribeCmd(c *client.Client, feedID, userID string, ttl time.Duration) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
 		defer cancel()
-		err := client.Subscribe(ctx, feedID)
-		if err == nil && client.Token() != "" {
+		err := c.Subscribe(ctx, feedID, ttl)
+		if err == nil && c.Token() != "" {
 			// Best-effort websocket subscribe.
 		}
 		return subscribeResultMsg{FeedID: feedID, Action: "subscribe", Err: err}
 	}
 }
 
-func unsubscribeCmd(client *api.Client, feedID string) tea.Cmd {
+func renewSubscriptionCmd(c *client.Client, feedID string, ttl time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+		defer cancel()
+		expiresAt, err := c.RenewSubscription(ctx, feedID, ttl)
+		return renewResultMsg{FeedID: feedID, ExpiresAt: expiresAt, Err: err}
+	}
+}
+
+func unsubscribeCmd(c *client.Client, feedID string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
 		defer cancel()
-		err := client.Unsubscribe(ctx, feedID)
+		err := c.Unsubscribe(ctx, feedID)
 		return subscribeResultMsg{FeedID: feedID, Action: "unsubscribe", Err: err}
 	}
 }
 
-func connectWS(url, userID, userAgent string) tea.Cmd {
+func connectWS(url, userID string, token func() string, userAgent string, batchInterval time.Duration) tea.Cmd {
 	return func() tea.Msg {
-		client, err := dialWS(url, userID, userAgent)
-		return wsConnectedMsg{Client: client, Err: err}
+		ws, err := dialWS(url, userID, token, userAgent, batchInterval)
+		return wsConnectedMsg{Client: ws, Err: err}
 	}
 }
 
-func createFeedCmd(client *api.Client, name, description, url, category, eventName, subMsg, systemPrompt string) tea.Cmd {
+func createFeedCmd(c *client.Client, name, description, url, category, eventName, subMsg, systemPrompt string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
-		feed, err := client.CreateFeed(ctx, name, description, url, category, eventName, subMsg, systemPrompt)
+		feed, err := c.CreateFeed(ctx, name, description, url, category, eventName, subMsg, systemPrompt)
 		return feedCreateMsg{Feed: feed, Err: err}
 	}
 }
 
-func updateFeedCmd(client *api.Client, feedID string, updates map[string]interface{}) tea.Cmd {
+func testFeedCmd(c *client.Client, url, subMsg string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		result, err := c.TestFeed(ctx, url, subMsg, 5)
+		return feedTestMsg{URL: url, Result: result, Err: err}
+	}
+}
+
+func updateFeedCmd(c *client.Client, feedID string, updates map[string]interface{}) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
-		feed, err := client.UpdateFeed(ctx, feedID, updates)
+		feed, err := c.UpdateFeed(ctx, feedID, updates)
 		return feedUpdateMsg{Feed: feed, Err: err}
 	}
 }
 
-func deleteFeedCmd(client *api.Client, feedID string) tea.Cmd {
+func setFeedPausedCmd(c *client.Client, feedID string, pause bool) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		err := client.DeleteFeed(ctx, feedID)
+		var feed *client.Feed
+		var err error
+		if pause {
+			feed, err = c.PauseFeed(ctx, feedID)
+		} else {
+			feed, err = c.ResumeFeed(ctx, feedID)
+		}
+		return feedPauseMsg{Feed: feed, Err: err}
+	}
+}
+
+func deleteFeedCmd(c *client.Client, feedID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := c.DeleteFeed(ctx, feedID)
 		return feedDeleteMsg{FeedID: feedID, Err: err}
 	}
 }
@@ -2797,6 +3709,69 @@ func deleteFeedCmd(client *api.Client, feedID string) tea.Cmd {
 // AI interval options in seconds
 var aiIntervalOptions = []int{5, 10, 30, 60}
 
+// AI generation presets cycled with t/T; -1 and 0 mean "provider default"
+var (
+	aiTemperatureOptions = []float64{-1, 0, 0.3, 0.7, 1.0}
+	aiMaxTokensOptions   = []int{0, 256, 512, 1024, 2048}
+)
+
+// nextAITemperature returns the preset after the current temperature
+func nextAITemperature(current *float64) *float64 {
+	idx := 0
+	for i, v := range aiTemperatureOptions {
+		if (current == nil && v < 0) || (current != nil && v == *current) {
+			idx = i
+			break
+		}
+	}
+	next := aiTemperatureOptions[(idx+1)%len(aiTemperatureOptions)]
+	if next < 0 {
+		return nil
+	}
+	return &next
+}
+
+// nextAIMaxTokens returns the preset after the current max tokens
+func nextAIMaxTokens(current int) int {
+	for i, v := range aiMaxTokensOptions {
+		if v == current {
+			return aiMaxTokensOptions[(i+1)%len(aiMaxTokensOptions)]
+		}
+	}
+	return aiMaxTokensOptions[0]
+}
+
+// aiGenerationLabel describes the generation settings for the AI panel
+func (m model) aiGenerationLabel() string {
+	temp, maxTokens := "default", "default"
+	if m.aiTemperature != nil {
+		temp = strconv.FormatFloat(*m.aiTemperature, 'f', -1, 64)
+	}
+	if m.aiMaxTokens > 0 {
+		maxTokens = strconv.Itoa(m.aiMaxTokens)
+	}
+	return fmt.Sprintf("Temp: %s  Max: %s", temp, maxTokens)
+}
+
+func parseAITemperature(val string) *float64 {
+	if strings.TrimSpace(val) == "" {
+		return nil
+	}
+	t, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || t < 0 {
+		return nil
+	}
+	return &t
+}
+
+func parseAIMaxTokens(val string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
 // getOrCreatePrompt gets the prompt for a feed, creating a new one if it doesn't exist
 // NOTE: Uses pointer receiver to allow modification
 func (m *model) getOrCreatePrompt(feedID string) textarea.Model {
@@ -2810,6 +3785,9 @@ func (m *model) getOrCreatePrompt(feedID string) textarea.Model {
 	newPrompt.SetHeight(3)
 	newPrompt.ShowLineNumbers = false
 	newPrompt.Prompt = "" // Remove default > prefix since we add our own
+	newPrompt.CharLimit = maxPromptLength
+	// Enter sends the prompt, so new lines take Alt+Enter or Ctrl+J
+	newPrompt.KeyMap.InsertNewline.SetKeys("alt+enter", "ctrl+j")
 	m.aiPrompts[feedID] = newPrompt
 	return newPrompt
 }
@@ -2874,9 +3852,10 @@ func (m model) sendAIQueryForFeed(feedID, requestID string) tea.Cmd {
 	}
 
 	wsClient := m.wsClient
+	temperature, maxTokens := m.aiTemperature, m.aiMaxTokens
 
 	return func() tea.Msg {
-		err := wsClient.SendLLMQuery(feedID, prompt, systemPrompt, requestID)
+		err := wsClient.SendLLMQuery(feedID, prompt, systemPrompt, requestID, temperature, maxTokens)
 		if err != nil {
 			return aiResponseMsg{RequestID: requestID, Err: err}
 		}
@@ -2884,6 +3863,30 @@ func (m model) sendAIQueryForFeed(feedID, requestID string) tea.Cmd {
 	}
 }
 
+// activeAIRequest returns the ID of the feed's in-flight AI request, if any
+func (m model) activeAIRequest(feedID string) string {
+	if !m.aiLoading[feedID] {
+		return ""
+	}
+	for requestID, id := range m.aiActiveRequests {
+		if id == feedID {
+			return requestID
+		}
+	}
+	return ""
+}
+
+// cancelAIQuery asks the backend to stop a streaming AI request
+func (m model) cancelAIQuery(requestID string) tea.Cmd {
+	wsClient := m.wsClient
+	return func() tea.Msg {
+		if err := wsClient.SendLLMCancel(requestID); err != nil {
+			return aiResponseMsg{RequestID: requestID, Err: err}
+		}
+		return nil
+	}
+}
+
 // startAIAutoQuery starts the auto-query ticker
 func (m model) startAIAutoQuery() tea.Cmd {
 	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return aiTickMsg{} })
@@ -2891,6 +3894,24 @@ func (m model) startAIAutoQuery() tea.Cmd {
 
 // ---- Helpers ----
 
+// parseSubscriptionTTL parses a Go duration such as "2h"; empty or invalid values disable expiry
+func parseSubscriptionTTL(val string) time.Duration {
+	ttl, err := time.ParseDuration(strings.TrimSpace(val))
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// parseBatchInterval reads a batching interval in milliseconds; anything else turns batching off
+func parseBatchInterval(val string) time.Duration {
+	ms, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 func getenvDefault(key, fallback string) string {
 	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
 		return v
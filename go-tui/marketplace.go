@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+const (
+	// marketplacePageSize is how many feeds each listing request loads
+	marketplacePageSize    = 200
+	marketplaceSortPopular = "subscribers"
+	marketplaceSortRecent  = "recent"
+)
+
+// marketplaceState is the Marketplace tab: a listing of public feeds for a
+// category and sort, narrowed by a fuzzy search of the loaded feeds plus the
+// backend's search results for the same query
+type marketplaceState struct {
+	feeds    []client.Feed
+	hasMore  bool
+	total    int
+	category int    // index into marketplaceCategories; 0 is every category
+	sort     string // marketplaceSortPopular or marketplaceSortRecent
+	// results are the backend's matches for searched, run when Enter is pressed
+	results  []client.Feed
+	searched string
+	search   textinput.Model
+	// searching means the search box has focus
+	searching bool
+	selected  int
+	loading   bool
+	loaded    bool
+}
+
+type (
+	marketplaceFeedsMsg struct {
+		Page   *client.FeedPage
+		Append bool
+		Err    error
+	}
+	marketplaceSearchMsg struct {
+		Query string
+		Feeds []client.Feed
+		Err   error
+	}
+)
+
+func newMarketplaceState() marketplaceState {
+	search := textinput.New()
+	search.Prompt = "Search: "
+	search.Placeholder = "name, tag or description"
+	search.CharLimit = 100
+	return marketplaceState{sort: marketplaceSortPopular, search: search}
+}
+
+// marketplaceCategories are the category filter options, all categories first
+func (m model) marketplaceCategories() []string {
+	cats := []string{""}
+	for _, cat := range m.categories {
+		cats = append(cats, cat.Key)
+	}
+	return cats
+}
+
+// marketplaceCategory is the category the listing is filtered to, "" for all
+func (m model) marketplaceCategory() string {
+	cats := m.marketplaceCategories()
+	if m.marketplace.category >= len(cats) {
+		return ""
+	}
+	return cats[m.marketplace.category]
+}
+
+func loadMarketplaceCmd(c *client.Client, category, sortBy string, offset int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		page, err := c.ListFeeds(ctx, client.ListFeedsOptions{Category: category, Sort: sortBy, Limit: marketplacePageSize, Offset: offset})
+		return marketplaceFeedsMsg{Page: page, Append: offset > 0, Err: err}
+	}
+}
+
+func searchMarketplaceCmd(c *client.Client, query, category string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		feeds, err := c.SearchFeeds(ctx, query, category)
+		return marketplaceSearchMsg{Query: query, Feeds: feeds, Err: err}
+	}
+}
+
+// reloadMarketplace fetches the first page of the listing for the current
+// category and sort
+func (m *model) reloadMarketplace() tea.Cmd {
+	m.marketplace.loading = true
+	m.marketplace.selected = 0
+	m.marketplace.results, m.marketplace.searched = nil, ""
+	return loadMarketplaceCmd(m.client, m.marketplaceCategory(), m.marketplace.sort, 0)
+}
+
+// handleMarketplaceMsg applies listing and search responses
+func (m model) handleMarketplaceMsg(msg tea.Msg) (model, tea.Cmd) {
+	mp := &m.marketplace
+	switch msg := msg.(type) {
+	case marketplaceFeedsMsg:
+		mp.loading = false
+		if msg.Err != nil {
+			m.errorMessage = client.FriendlyError(msg.Err)
+			return m, nil
+		}
+		mp.loaded = true
+		if msg.Append {
+			mp.feeds = append(mp.feeds, msg.Page.Feeds...)
+		} else {
+			mp.feeds = msg.Page.Feeds
+		}
+		mp.hasMore, mp.total = msg.Page.HasMore, msg.Page.Total
+	case marketplaceSearchMsg:
+		mp.loading = false
+		if msg.Err != nil {
+			m.errorMessage = "Search failed: " + client.FriendlyError(msg.Err)
+			return m, nil
+		}
+		if msg.Query == strings.TrimSpace(mp.search.Value()) {
+			mp.results, mp.searched = msg.Feeds, msg.Query
+		}
+	}
+	if n := len(m.marketplaceFeeds()); mp.selected >= n {
+		mp.selected = max(n-1, 0)
+	}
+	return m, nil
+}
+
+// marketplaceFeeds is what the tab lists: the loaded feeds matching the
+// search best first, then the backend's further matches for it
+func (m model) marketplaceFeeds() []client.Feed {
+	mp := m.marketplace
+	query := strings.TrimSpace(mp.search.Value())
+	if query == "" {
+		return mp.feeds
+	}
+	shown := fuzzyFilterFeeds(mp.feeds, query)
+	if mp.searched != query {
+		return shown
+	}
+	seen := make(map[string]bool, len(shown))
+	for _, f := range shown {
+		seen[f.ID] = true
+	}
+	for _, f := range mp.results {
+		if !seen[f.ID] {
+			shown = append(shown, f)
+		}
+	}
+	return shown
+}
+
+// selectedMarketplaceFeed is the feed under the cursor, if any
+func (m model) selectedMarketplaceFeed() *client.Feed {
+	feeds := m.marketplaceFeeds()
+	if m.marketplace.selected < len(feeds) {
+		return &feeds[m.marketplace.selected]
+	}
+	return nil
+}
+
+// updateMarketplace handles keys on the Marketplace tab: / searches (Enter
+// also asks the backend), c cycles the category, o switches between
+// popular and recent, s subscribes and Enter opens the feed. Other keys are
+// left to the global handlers.
+func (m model) updateMarketplace(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	mp := &m.marketplace
+	if mp.searching {
+		switch msg.String() {
+		case "esc":
+			mp.searching = false
+			mp.search.Blur()
+			mp.search.SetValue("")
+			mp.results, mp.searched = nil, ""
+			mp.selected = 0
+			return m, nil, true
+		case "enter", "down":
+			mp.searching = false
+			mp.search.Blur()
+			query := strings.TrimSpace(mp.search.Value())
+			if query == "" || query == mp.searched {
+				return m, nil, true
+			}
+			mp.loading = true
+			return m, searchMarketplaceCmd(m.client, query, m.marketplaceCategory()), true
+		}
+		var cmd tea.Cmd
+		mp.search, cmd = mp.search.Update(msg)
+		mp.selected = 0
+		return m, cmd, true
+	}
+
+	feeds := m.marketplaceFeeds()
+	switch msg.String() {
+	case "/":
+		mp.searching = true
+		return m, mp.search.Focus(), true
+	case "esc":
+		if mp.search.Value() == "" {
+			return m, nil, false
+		}
+		mp.search.SetValue("")
+		mp.results, mp.searched = nil, ""
+		mp.selected = 0
+		return m, nil, true
+	case "up", "k":
+		if mp.selected > 0 {
+			mp.selected--
+		}
+		return m, nil, true
+	case "down", "j":
+		if mp.selected < len(feeds)-1 {
+			mp.selected++
+			return m, nil, true
+		}
+		// Reaching the end of an unfiltered listing loads the next page
+		if mp.hasMore && !mp.loading && mp.search.Value() == "" {
+			mp.loading = true
+			return m, loadMarketplaceCmd(m.client, m.marketplaceCategory(), mp.sort, len(mp.feeds)), true
+		}
+		return m, nil, true
+	case "c":
+		mp.category = (mp.category + 1) % len(m.marketplaceCategories())
+		return m, m.reloadMarketplace(), true
+	case "o":
+		if mp.sort == marketplaceSortPopular {
+			mp.sort = marketplaceSortRecent
+		} else {
+			mp.sort = marketplaceSortPopular
+		}
+		return m, m.reloadMarketplace(), true
+	case "s":
+		feed := m.selectedMarketplaceFeed()
+		if feed == nil || m.user == nil {
+			return m, nil, true
+		}
+		if m.isSubscribed(feed.ID) {
+			return m, unsubscribeCmd(m.client, feed.ID), true
+		}
+		return m, subscribeCmd(m.client, feed.ID, m.user.ID, m.subscriptionTTL), true
+	case "enter":
+		if feed := m.selectedMarketplaceFeed(); feed != nil {
+			return m, fetchFeedCmd(m.client, feed.ID), true
+		}
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// fuzzyScore matches query against text as a case-insensitive subsequence,
+// scoring runs of consecutive characters and word starts higher. ok is false
+// when the text doesn't contain the query's characters in order.
+func fuzzyScore(query, text string) (score int, ok bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	qi, prev := 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 2
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 3
+		}
+		prev = ti
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// fuzzyFilterFeeds keeps the feeds matching every word of the query in their
+// name, tags, category or description, best matches first; names count double
+func fuzzyFilterFeeds(feeds []client.Feed, query string) []client.Feed {
+	terms := strings.Fields(query)
+	type scored struct {
+		feed  client.Feed
+		score int
+	}
+	var matches []scored
+	for _, f := range feeds {
+		total := 0
+		for _, term := range terms {
+			best := 0
+			if s, ok := fuzzyScore(term, f.Name); ok {
+				best = 2 * s
+			}
+			fields := append([]string{f.Category, f.Description}, f.Tags...)
+			for _, field := range fields {
+				if s, ok := fuzzyScore(term, field); ok && s > best {
+					best = s
+				}
+			}
+			if best == 0 {
+				total = 0
+				break
+			}
+			total += best
+		}
+		if total > 0 {
+			matches = append(matches, scored{f, total})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	out := make([]client.Feed, len(matches))
+	for i, s := range matches {
+		out[i] = s.feed
+	}
+	return out
+}
+
+func (m model) viewMarketplace() string {
+	mp := m.marketplace
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	bright := lipgloss.NewStyle().Foreground(brightCyanColor)
+
+	listWidth := 60
+	previewWidth := m.termWidth - listWidth - 8
+	if previewWidth < 40 {
+		previewWidth = 40
+	}
+	boxHeight := m.termHeight - 8
+	if boxHeight < 15 {
+		boxHeight = 15
+	}
+
+	// Filters and search
+	category := m.marketplaceCategory()
+	if category == "" {
+		category = "all"
+	}
+	sortLabel := "popular"
+	if mp.sort == marketplaceSortRecent {
+		sortLabel = "recent"
+	}
+	filters := dim.Render("Category: ") + bright.Render(category) + dim.Render(" (c)  Sort: ") + bright.Render(sortLabel) + dim.Render(" (o)  ")
+	if mp.searching || mp.search.Value() != "" {
+		filters += mp.search.View()
+	} else {
+		filters += dim.Render("/: search")
+	}
+
+	// Feed list
+	feeds := m.marketplaceFeeds()
+	list := strings.Builder{}
+	switch {
+	case mp.loading && len(feeds) == 0:
+		list.WriteString(fmt.Sprintf("%s Loading feeds...", m.spinner.View()))
+	case len(feeds) == 0 && mp.search.Value() != "" && mp.searched == "":
+		list.WriteString(dim.Render("No loaded feed matches. Press Enter in the search box to search the marketplace."))
+	case len(feeds) == 0:
+		list.WriteString(dim.Render("No feeds found"))
+	default:
+		rows := boxHeight - 3
+		start := 0
+		if mp.selected >= rows {
+			start = mp.selected - rows + 1
+		}
+		for i := start; i < len(feeds) && i < start+rows; i++ {
+			f := feeds[i]
+			mark := "   "
+			if m.isSubscribed(f.ID) {
+				mark = "[+]"
+			}
+			line := fmt.Sprintf("%s %-32s %-10s %5d", mark, truncate(f.Name, 32), truncate(f.Category, 10), f.SubscriberCount)
+			if i == mp.selected {
+				list.WriteString(lipgloss.NewStyle().Bold(true).Foreground(brightCyanColor).Render("> " + line))
+			} else {
+				list.WriteString("  " + line)
+			}
+			list.WriteString("\n")
+		}
+	}
+	listTitle := fmt.Sprintf("Marketplace (%d", len(feeds))
+	if mp.search.Value() == "" && mp.total > len(feeds) {
+		listTitle += fmt.Sprintf(" of %d", mp.total)
+	}
+	listTitle += ")"
+	listBox := renderBoxWithTitle(listTitle, list.String(), listWidth, boxHeight, darkCyanColor, cyanColor)
+
+	// Preview of the selected feed
+	preview := strings.Builder{}
+	if f := m.selectedMarketplaceFeed(); f != nil {
+		preview.WriteString(lipgloss.NewStyle().Bold(true).Foreground(cyanColor).Render(truncate(f.Name, previewWidth-6)))
+		if f.IsVerified {
+			preview.WriteString(lipgloss.NewStyle().Foreground(greenColor).Render(" ✓ verified"))
+		}
+		preview.WriteString("\n\n")
+		owner := f.OwnerName
+		if owner == "" {
+			owner = "-"
+		}
+		preview.WriteString(fmt.Sprintf("Owner: %s\n", owner))
+		preview.WriteString(fmt.Sprintf("Category: %s | Type: %s\n", f.Category, f.ConnectionType))
+		preview.WriteString(fmt.Sprintf("Subscribers: %d\n", f.SubscriberCount))
+		if !f.CreatedAt.IsZero() {
+			preview.WriteString(fmt.Sprintf("Created: %s\n", f.CreatedAt.Format("2006-01-02")))
+		}
+		if len(f.Tags) > 0 {
+			preview.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(f.Tags, ", ")))
+		}
+		status := "[-] Not Subscribed"
+		if m.isSubscribed(f.ID) {
+			status = "[+] Subscribed"
+		}
+		if !f.IsActive {
+			status += " | Paused"
+		}
+		preview.WriteString(fmt.Sprintf("Status: %s\n", status))
+		if f.Description != "" {
+			preview.WriteString("\n")
+			preview.WriteString(wrapText(f.Description, previewWidth-6))
+			preview.WriteString("\n")
+		}
+		preview.WriteString("\n")
+		preview.WriteString(dim.Render("s: subscribe/unsubscribe | Enter: open feed"))
+	}
+	previewBox := renderBoxWithTitle("Preview", preview.String(), previewWidth, boxHeight, darkCyanColor, cyanColor)
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top, listBox, "  ", previewBox)
+	return lipgloss.JoinVertical(lipgloss.Left, " "+filters, body)
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
 )
 
 // FeedMetrics contains observability metrics for a single feed
@@ -15,6 +18,7 @@ type FeedMetrics struct {
 
 	// 1) Stream / WebSocket health
 	MessagesReceivedTotal uint64
+	MessagesPerSecond1s   float64 // instantaneous rate (last 1s)
 	MessagesPerSecond10s  float64
 	BytesReceivedTotal    uint64
 	BytesPerSecond10s     float64
@@ -22,6 +26,8 @@ type FeedMetrics struct {
 	WSConnected           bool
 	ReconnectsTotal       uint64
 	CurrentUptimeSeconds  float64
+	UpstreamStatus        string // backend's feed-health status: connected, erroring or disconnected ("" until reported)
+	UpstreamErrorsTotal   int64
 
 	// 2) In-memory cache health (context for LLM)
 	CacheItemsCurrent    int
@@ -44,7 +50,7 @@ type FeedMetrics struct {
 	OutputTokensTotal         uint64  // Total output/response tokens used
 	InputTokensLast           int     // Input tokens in last request
 	OutputTokensLast          int     // Output tokens in last request
-	ContextUtilizationPercent float64 // prompt_tokens / model_context_limit * 100
+	ContextUtilizationPercent float64 // prompt_tokens / model_context_window * 100, from the backend
 	LLMErrorsTotal            uint64
 	EventsInContextCurrent    int     // Number of feed events currently in LLM context
 	TTFTMs                    float64 // Time to First Token (ms) - last request
@@ -62,7 +68,8 @@ type FeedMetrics struct {
 // DashboardMetrics holds metrics for all feeds
 type DashboardMetrics struct {
 	Feeds       []FeedMetrics
-	SelectedIdx int // index of the currently selected feed
+	SelectedIdx int                     // index of the currently selected feed
+	Usage       *client.LLMUsageSummary // token ledger by feed from the backend, nil until loaded
 }
 
 // MetricsCollector collects and computes metrics from feed data
@@ -82,8 +89,16 @@ type MetricsCollector struct {
 	cacheBytesHistory map[string]*historySampler
 	genTimeHistory    map[string]*historySampler
 	payloadHistory    map[string]*historySampler
+
+	// Background maintenance lifecycle (see Start/Close)
+	lifecycleMu sync.Mutex
+	cancel      context.CancelFunc
+	done        chan struct{}
 }
 
+// metricsMaintenanceInterval is how often the collector's background loop runs
+const metricsMaintenanceInterval = time.Second
+
 // slidingWindow tracks values over time for rate calculations
 type slidingWindow struct {
 	mu       sync.Mutex
@@ -125,6 +140,13 @@ func (w *slidingWindow) prune(now time.Time) {
 	}
 }
 
+// Expire drops samples that have aged out of the window
+func (w *slidingWindow) Expire(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.prune(now)
+}
+
 func (w *slidingWindow) Rate(windowDuration time.Duration) float64 {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -433,6 +455,68 @@ func NewMetricsCollector() *MetricsCollector {
 	}
 }
 
+// Start launches the collector's background maintenance loop. It runs until ctx
+// is cancelled or Close is called; calling Start on a running collector is a no-op.
+func (mc *MetricsCollector) Start(ctx context.Context) {
+	mc.lifecycleMu.Lock()
+	defer mc.lifecycleMu.Unlock()
+	if mc.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	mc.cancel = cancel
+	mc.done = done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(metricsMaintenanceInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				mc.maintain(now)
+			}
+		}
+	}()
+}
+
+// Close stops the background loop and waits for it to exit. It is safe to call
+// more than once and on a collector that was never started.
+func (mc *MetricsCollector) Close() {
+	mc.lifecycleMu.Lock()
+	cancel, done := mc.cancel, mc.done
+	mc.cancel, mc.done = nil, nil
+	mc.lifecycleMu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// maintain expires old window samples (so idle feeds release memory) and keeps
+// last-message ages current between dashboard refreshes.
+func (mc *MetricsCollector) maintain(now time.Time) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for _, windows := range []map[string]*slidingWindow{mc.messageWindows, mc.byteWindows, mc.llmLatencies} {
+		for _, w := range windows {
+			w.Expire(now)
+		}
+	}
+	for feedID, fm := range mc.feedMetrics {
+		if lastMsg, ok := mc.lastMsgTimes[feedID]; ok {
+			fm.LastMessageAgeSeconds = now.Sub(lastMsg).Seconds()
+		}
+	}
+}
+
 // InitFeed initializes metrics for a feed
 func (mc *MetricsCollector) InitFeed(feedID, name string) {
 	mc.mu.Lock()
@@ -512,6 +596,19 @@ func (mc *MetricsCollector) RecordWSStatus(feedID string, connected bool) {
 	}
 }
 
+// RecordUpstreamHealth records the upstream state reported by the backend
+func (mc *MetricsCollector) RecordUpstreamHealth(feedID string, status string, errorCount int64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	fm, exists := mc.feedMetrics[feedID]
+	if !exists {
+		return
+	}
+	fm.UpstreamStatus = status
+	fm.UpstreamErrorsTotal = errorCount
+}
+
 // RecordCacheStats records cache statistics
 func (mc *MetricsCollector) RecordCacheStats(feedID string, itemCount int, approxBytes uint64, oldestAge float64) {
 	mc.mu.Lock()
@@ -581,6 +678,16 @@ func (mc *MetricsCollector) RecordLLMRequest(feedID string, inputTokens, outputT
 	sampler.Add(inputTokens, outputTokens, ttftMs, genTimeMs, eventsInContext)
 }
 
+// RecordContextUtilization records how much of the model's context window
+// the feed's last prompt filled, as reported by the backend
+func (mc *MetricsCollector) RecordContextUtilization(feedID string, percent float64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if fm, exists := mc.feedMetrics[feedID]; exists {
+		fm.ContextUtilizationPercent = percent
+	}
+}
+
 // GetMetrics returns computed metrics for all feeds
 func (mc *MetricsCollector) GetMetrics() DashboardMetrics {
 	mc.mu.RLock()
@@ -593,8 +700,9 @@ func (mc *MetricsCollector) GetMetrics() DashboardMetrics {
 		// Copy the metrics
 		metrics := *fm
 
-		// Compute rates (10s window)
+		// Compute rates (1s instantaneous and 10s smoothed windows)
 		if msgWindow, ok := mc.messageWindows[feedID]; ok {
+			metrics.MessagesPerSecond1s = msgWindow.Rate(1 * time.Second)
 			metrics.MessagesPerSecond10s = msgWindow.Rate(10 * time.Second)
 		}
 
@@ -619,12 +727,6 @@ func (mc *MetricsCollector) GetMetrics() DashboardMetrics {
 			metrics.TTFTAvgMs = ttftAvg
 			metrics.GenerationTimeMs = genTimeLast
 			metrics.GenerationTimeAvgMs = genTimeAvg
-
-			// Context utilization (assume 128K context window for GPT-4o)
-			const modelContextLimit = 128000
-			if inputLast > 0 {
-				metrics.ContextUtilizationPercent = (float64(inputLast) / modelContextLimit) * 100
-			}
 			_ = eventsMax // Not used in simplified metrics
 		}
 
@@ -675,6 +777,7 @@ func (mc *MetricsCollector) GetFeedMetrics(feedID string) *FeedMetrics {
 		// Compute real-time rates
 		now := time.Now()
 		if msgWindow, ok := mc.messageWindows[feedID]; ok {
+			metrics.MessagesPerSecond1s = msgWindow.Rate(1 * time.Second)
 			metrics.MessagesPerSecond10s = msgWindow.Rate(10 * time.Second)
 		}
 
@@ -0,0 +1,885 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned when a request exceeds its deadline.
+var ErrTimeout = errors.New("request timed out — backend slow or unreachable")
+
+// IsTimeout reports whether err is a deadline or network timeout.
+func IsTimeout(err error) bool {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// FriendlyError returns a user-facing message for err. Timeouts get a short
+// explanation and retry hint instead of the raw Go error.
+func FriendlyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if IsTimeout(err) {
+		return ErrTimeout.Error() + ". Check the backend and try again."
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return "Rate limit exceeded. Wait a moment and try again."
+	}
+	return err.Error()
+}
+
+// FieldErrors returns the per-field problems the backend reported when it
+// rejected a feed, keyed by field name such as "url" or
+// "connectionMessages.0". It is empty for any other error.
+func FieldErrors(err error) map[string]string {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		return nil
+	}
+	var resp struct {
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal([]byte(httpErr.Body), &resp) != nil || len(resp.Errors) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(resp.Errors))
+	for _, e := range resp.Errors {
+		if _, seen := fields[e.Field]; !seen {
+			fields[e.Field] = e.Message
+		}
+	}
+	return fields
+}
+
+// HTTPError wraps the status code and body of an error response.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Client is a thin wrapper around the Go backend REST API. Access tokens are
+// short-lived; when a request is rejected with 401 the client exchanges its
+// refresh token for a new pair and retries once.
+type Client struct {
+	baseURL      string
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	// refreshMu makes concurrent 401s share one refresh, since each refresh
+	// rotates the refresh token and replaying the old one revokes the session
+	refreshMu  sync.Mutex
+	httpClient *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests through hc instead of a client with a 20
+// second timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTokens starts the client signed in with an access token and the
+// refresh token that renews it, as returned by an earlier Login.
+func WithTokens(token, refreshToken string) Option {
+	return func(c *Client) {
+		c.token = token
+		c.refreshToken = refreshToken
+	}
+}
+
+// NewClient returns a client for the backend at baseURL, e.g.
+// "http://localhost:7210".
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetToken sets the access token sent with requests.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// Token returns the current access token, which changes when it is renewed.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// SetRefreshToken sets the token used to renew the access token; Login and
+// Register set it from the backend's response.
+func (c *Client) SetRefreshToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshToken = token
+}
+
+// RefreshToken returns the current refresh token.
+func (c *Client) RefreshToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshToken
+}
+
+// RedactedValue stands in for feed secrets in responses.
+const RedactedValue = "[redacted]"
+
+// Domain models, covering the fields clients commonly need.
+type (
+	User struct {
+		ID         string      `json:"_id"`
+		Email      string      `json:"email"`
+		Name       string      `json:"name"`
+		TokenUsage *TokenUsage `json:"tokenUsage"`
+	}
+
+	TokenUsage struct {
+		CurrentMonth string `json:"currentMonth"`
+		TokensUsed   int64  `json:"tokensUsed"`
+		Limit        int64  `json:"limit"`
+	}
+
+	Feed struct {
+		ID                string     `json:"_id"`
+		Name              string     `json:"name"`
+		Description       string     `json:"description"`
+		SystemPrompt      string     `json:"systemPrompt"`
+		URL               string     `json:"url"`
+		Category          string     `json:"category"`
+		Icon              string     `json:"icon"`
+		OwnerName         string     `json:"ownerName"`
+		OwnerID           string     `json:"ownerId"`
+		IsActive          bool       `json:"isActive"`
+		PausedAt          *time.Time `json:"pausedAt,omitempty"`
+		IsVerified        bool       `json:"isVerified"`
+		IsPublic          bool       `json:"isPublic"`
+		FeedType          string     `json:"feedType"`
+		SubscriberCount   int        `json:"subscriberCount"`
+		ConnectionType    string     `json:"connectionType"`
+		EventName         string     `json:"eventName"`
+		DefaultAIPrompt   string     `json:"defaultAIPrompt"`
+		AIAnalysisEnabled bool       `json:"aiAnalysisEnabled"`
+		Tags              []string   `json:"tags"`
+		CreatedAt         time.Time  `json:"createdAt"`
+		UpdatedAt         time.Time  `json:"updatedAt"`
+		// Connection settings. Values of sensitive headers and query params
+		// and secret messages come back as RedactedValue; sending them back
+		// unchanged in an update keeps the stored secret.
+		Headers              []KeyValue `json:"headers,omitempty"`
+		QueryParams          []KeyValue `json:"queryParams,omitempty"`
+		ConnectionMessage    string     `json:"connectionMessage,omitempty"`
+		ConnectionMessages   []string   `json:"connectionMessages,omitempty"`
+		ReconnectionEnabled  bool       `json:"reconnectionEnabled"`
+		ReconnectionDelay    int        `json:"reconnectionDelay,omitempty"`    // milliseconds
+		ReconnectionAttempts int        `json:"reconnectionAttempts,omitempty"` // 0 is unlimited
+	}
+
+	// KeyValue is a feed header or query parameter
+	KeyValue struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+
+	Subscription struct {
+		ID         string                `json:"_id"`
+		UserID     string                `json:"userId"`
+		FeedID     string                `json:"feedId"`
+		Subscribed string                `json:"subscribedAt"`
+		IsActive   bool                  `json:"isActive"`
+		ExpiresAt  *time.Time            `json:"expiresAt,omitempty"`
+		Settings   *SubscriptionSettings `json:"settings,omitempty"`
+	}
+
+	// SubscriptionSettings are a subscriber's preferences for a feed
+	SubscriptionSettings struct {
+		Filters []string `json:"filters,omitempty"`
+		Prompts []string `json:"prompts,omitempty"` // saved AI prompt library
+	}
+
+	LLMAnswer struct {
+		Answer     string `json:"answer"`
+		Provider   string `json:"provider"`
+		FeedID     string `json:"feedId"`
+		TokensUsed int    `json:"tokensUsed"`
+		DurationMs int64  `json:"durationMs"`
+	}
+
+	// IngestCredentials are where and how a push feed's producers send data:
+	// POST to Path, signed with Secret
+	IngestCredentials struct {
+		Path   string `json:"path"`
+		Token  string `json:"token"`
+		Secret string `json:"secret"`
+	}
+
+	ProviderInfo struct {
+		Enabled   bool     `json:"enabled"`
+		Providers []string `json:"providers"`
+	}
+
+	// LLMUsageGroup totals the user's queries for one feed, day or provider
+	LLMUsageGroup struct {
+		Key              string  `json:"key"`
+		FeedName         string  `json:"feedName"`
+		Requests         int     `json:"requests"`
+		CachedRequests   int     `json:"cachedRequests"`
+		PromptTokens     int     `json:"promptTokens"`
+		CompletionTokens int     `json:"completionTokens"`
+		TotalTokens      int     `json:"totalTokens"`
+		CostUSD          float64 `json:"costUsd"`
+		AvgLatencyMs     float64 `json:"avgLatencyMs"`
+	}
+
+	LLMUsageSummary struct {
+		GroupBy string          `json:"groupBy"`
+		From    time.Time       `json:"from"`
+		To      time.Time       `json:"to"`
+		Totals  LLMUsageGroup   `json:"totals"`
+		Groups  []LLMUsageGroup `json:"groups"`
+	}
+
+	Category struct {
+		Key   string `json:"key"`
+		Label string `json:"label"`
+	}
+
+	FeedSample struct {
+		FeedID    string      `json:"feedId"`
+		Source    string      `json:"source"`
+		Sample    interface{} `json:"sample"`
+		Timestamp string      `json:"timestamp"`
+	}
+
+	// FeedTest is what a connection test read from a feed before it is saved
+	FeedTest struct {
+		Samples           []interface{} `json:"samples"`
+		DataFormat        string        `json:"dataFormat"`
+		MessageCount      int           `json:"messageCount"`
+		MessagesPerSecond float64       `json:"messagesPerSecond"`
+		DurationMs        int64         `json:"durationMs"`
+		Subscription      *struct {
+			Sent    int    `json:"sent"`
+			Replied bool   `json:"replied"`
+			Error   string `json:"error"`
+		} `json:"subscription"`
+		Error string `json:"error"`
+	}
+)
+
+// Login authenticates and returns token plus user.
+func (c *Client) Login(ctx context.Context, email, password, totp string) (string, *User, error) {
+	payload := map[string]string{"email": email, "password": password}
+	if totp != "" {
+		payload["totpToken"] = totp
+	}
+	var resp struct {
+		Success           bool   `json:"success"`
+		Message           string `json:"message"`
+		Token             string `json:"token"`
+		RefreshToken      string `json:"refreshToken"`
+		User              *User  `json:"user"`
+		RequiresTwoFactor bool   `json:"requiresTwoFactor"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/auth/login", payload, &resp); err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+			var errResp struct {
+				RequiresTwoFactor bool     `json:"requiresTwoFactor"`
+				TwoFactorMethods  []string `json:"twoFactorMethods"`
+				Message           string   `json:"message"`
+			}
+			if jsonErr := json.Unmarshal([]byte(httpErr.Body), &errResp); jsonErr == nil {
+				if errResp.RequiresTwoFactor {
+					return "", nil, twoFactorPrompt(errResp.TwoFactorMethods)
+				}
+				return "", nil, errors.New(errResp.Message)
+			}
+		}
+		return "", nil, err
+	}
+	if !resp.Success {
+		return "", nil, errors.New(resp.Message)
+	}
+	c.SetRefreshToken(resp.RefreshToken)
+	return resp.Token, resp.User, nil
+}
+
+// twoFactorPrompt asks for the second factor the terminal can take. Security
+// keys need a browser, so accounts that only have one fall back to a backup code.
+func twoFactorPrompt(methods []string) error {
+	totp, backup := len(methods) == 0, false
+	for _, method := range methods {
+		switch method {
+		case "totp":
+			totp = true
+		case "backup_code":
+			backup = true
+		}
+	}
+	switch {
+	case totp:
+		return errors.New("2FA code required. Please enter your TOTP code.")
+	case backup:
+		return errors.New("This account uses a security key, which the terminal can't use. Enter a backup code in the TOTP field.")
+	}
+	return errors.New("This account uses a security key, which the terminal can't use. Sign in from a browser.")
+}
+
+// Register creates an account and returns its token and user.
+func (c *Client) Register(ctx context.Context, email, password, name string) (string, *User, error) {
+	payload := map[string]string{"email": email, "password": password, "name": name}
+	var resp struct {
+		Success      bool   `json:"success"`
+		Message      string `json:"message"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+		User         *User  `json:"user"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/auth/register", payload, &resp); err != nil {
+		return "", nil, err
+	}
+	if !resp.Success {
+		return "", nil, errors.New(resp.Message)
+	}
+	c.SetRefreshToken(resp.RefreshToken)
+	return resp.Token, resp.User, nil
+}
+
+// refresh exchanges the refresh token for a new token pair. stale is the
+// access token the failed request used; if another request already replaced
+// it, there is nothing to do.
+func (c *Client) refresh(ctx context.Context, stale string) bool {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if c.Token() != stale {
+		return true
+	}
+	refreshToken := c.RefreshToken()
+	if refreshToken == "" {
+		return false
+	}
+	var resp struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/api/auth/refresh", "", map[string]string{"refreshToken": refreshToken}, &resp); err != nil || resp.Token == "" {
+		return false
+	}
+	c.mu.Lock()
+	c.token = resp.Token
+	c.refreshToken = resp.RefreshToken
+	c.mu.Unlock()
+	return true
+}
+
+// Me returns the signed-in user, with their token usage.
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		User    *User  `json:"user"`
+		Message string `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/auth/me", nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.User, nil
+}
+
+// ListFeedsOptions filters, sorts and pages ListFeeds. Zero values use the
+// backend defaults: newest first, 50 feeds per page.
+type ListFeedsOptions struct {
+	Category string
+	Verified *bool
+	Sort     string // "recent", "subscribers" or "name"
+	Limit    int
+	Offset   int
+}
+
+// FeedPage is one page of the marketplace listing.
+type FeedPage struct {
+	Feeds   []Feed
+	Total   int
+	Limit   int
+	Offset  int
+	HasMore bool
+}
+
+// ListFeeds fetches a page of public marketplace feeds.
+func (c *Client) ListFeeds(ctx context.Context, opts ListFeedsOptions) (*FeedPage, error) {
+	query := url.Values{}
+	if opts.Category != "" {
+		query.Set("category", opts.Category)
+	}
+	if opts.Verified != nil {
+		query.Set("verified", strconv.FormatBool(*opts.Verified))
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	path := "/api/marketplace/feeds"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    []Feed `json:"data"`
+		Count   int    `json:"count"`
+		Total   int    `json:"total"`
+		Limit   int    `json:"limit"`
+		Offset  int    `json:"offset"`
+		HasMore bool   `json:"hasMore"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return &FeedPage{Feeds: resp.Data, Total: resp.Total, Limit: resp.Limit, Offset: resp.Offset, HasMore: resp.HasMore}, nil
+}
+
+// SearchFeeds searches public feeds by name, description and tags, best
+// matches first, optionally within a category.
+func (c *Client) SearchFeeds(ctx context.Context, q, category string) ([]Feed, error) {
+	query := url.Values{"q": {q}}
+	if category != "" {
+		query.Set("category", category)
+	}
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    []Feed `json:"data"`
+		Count   int    `json:"count"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/marketplace/feeds/search?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// MyFeeds lists the feeds the user manages.
+func (c *Client) MyFeeds(ctx context.Context) ([]Feed, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    []Feed `json:"data"`
+		Count   int    `json:"count"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/marketplace/my-feeds", nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// Feed fetches a feed by ID.
+func (c *Client) Feed(ctx context.Context, id string) (*Feed, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    *Feed  `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/marketplace/feeds/"+id, nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// FeedSample fetches the most recent message for a feed, captured live if the feed is idle.
+func (c *Client) FeedSample(ctx context.Context, id string) (*FeedSample, error) {
+	var resp struct {
+		Success bool        `json:"success"`
+		Message string      `json:"message"`
+		Data    *FeedSample `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/marketplace/feeds/"+id+"/sample", nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// TestFeed connects to a websocket feed without saving it, sends the
+// subscription message and reads for up to seconds, returning sample messages.
+func (c *Client) TestFeed(ctx context.Context, url, subMsg string, seconds int) (*FeedTest, error) {
+	payload := map[string]interface{}{
+		"url":            url,
+		"connectionType": "websocket",
+		"seconds":        seconds,
+	}
+	if subMsg != "" {
+		payload["connectionMessages"] = []string{subMsg}
+	}
+
+	var resp struct {
+		Success bool      `json:"success"`
+		Message string    `json:"message"`
+		Data    *FeedTest `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/marketplace/test-feed", payload, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// Subscriptions lists the user's feed subscriptions.
+func (c *Client) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	var resp struct {
+		Success bool           `json:"success"`
+		Message string         `json:"message"`
+		Data    []Subscription `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/marketplace/subscriptions", nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// Subscribe subscribes to a feed; a positive ttl makes the subscription expire after that duration.
+func (c *Client) Subscribe(ctx context.Context, feedID string, ttl time.Duration) error {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	var payload interface{}
+	if ttl > 0 {
+		payload = map[string]int64{"ttlSeconds": int64(ttl / time.Second)}
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/marketplace/subscribe/"+feedID, payload, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+// RenewSubscription extends a subscription's expiry by ttl from now; a zero ttl removes the expiry.
+func (c *Client) RenewSubscription(ctx context.Context, feedID string, ttl time.Duration) (*time.Time, error) {
+	var resp struct {
+		Success   bool       `json:"success"`
+		Message   string     `json:"message"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	payload := map[string]int64{"ttlSeconds": int64(ttl / time.Second)}
+	if err := c.do(ctx, http.MethodPost, "/api/marketplace/subscriptions/"+feedID+"/renew", payload, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.ExpiresAt, nil
+}
+
+// SaveSubscriptionPrompts replaces the saved AI prompt library of the subscription to a feed.
+func (c *Client) SaveSubscriptionPrompts(ctx context.Context, feedID string, prompts []string) error {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if prompts == nil {
+		prompts = []string{}
+	}
+	payload := map[string]interface{}{"settings.prompts": prompts}
+	if err := c.do(ctx, http.MethodPut, "/api/marketplace/subscriptions/"+feedID+"/settings", payload, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+// Unsubscribe ends the subscription to a feed.
+func (c *Client) Unsubscribe(ctx context.Context, feedID string) error {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/marketplace/unsubscribe/"+feedID, nil, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+// CreateFeed registers a public websocket feed; subMsg, when set, is sent
+// after connecting to subscribe upstream.
+func (c *Client) CreateFeed(ctx context.Context, name, description, url, category, eventName, subMsg, systemPrompt string) (*Feed, error) {
+	payload := map[string]interface{}{
+		"name":                name,
+		"description":         description,
+		"url":                 url,
+		"category":            category,
+		"isPublic":            true,
+		"feedType":            "user",
+		"connectionType":      "websocket",
+		"eventName":           eventName,
+		"dataFormat":          "json",
+		"reconnectionEnabled": true,
+	}
+
+	if subMsg != "" {
+		payload["connectionMessages"] = []string{subMsg}
+	}
+	if systemPrompt != "" {
+		payload["systemPrompt"] = systemPrompt
+	}
+	feed, _, err := c.CreateFeedFrom(ctx, payload)
+	return feed, err
+}
+
+// CreateFeedFrom registers a feed from its fields as the backend names them,
+// such as "name", "url", "connectionType" and "headers". Push feeds also get
+// their ingestion credentials, which cannot be retrieved again.
+func (c *Client) CreateFeedFrom(ctx context.Context, fields map[string]interface{}) (*Feed, *IngestCredentials, error) {
+	var resp struct {
+		Success bool               `json:"success"`
+		Message string             `json:"message"`
+		Data    *Feed              `json:"data"`
+		Ingest  *IngestCredentials `json:"ingest"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/marketplace/feeds", fields, &resp); err != nil {
+		return nil, nil, err
+	}
+	if !resp.Success {
+		return nil, nil, errors.New(resp.Message)
+	}
+	return resp.Data, resp.Ingest, nil
+}
+
+// UpdateFeed changes the given fields of a feed the user manages.
+func (c *Client) UpdateFeed(ctx context.Context, feedID string, updates map[string]interface{}) (*Feed, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    *Feed  `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/api/marketplace/feeds/"+feedID, updates, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// PauseFeed stops an owned feed's upstream connection until it is resumed.
+func (c *Client) PauseFeed(ctx context.Context, feedID string) (*Feed, error) {
+	return c.setFeedPaused(ctx, feedID, "pause")
+}
+
+// ResumeFeed reconnects an owned feed paused with PauseFeed.
+func (c *Client) ResumeFeed(ctx context.Context, feedID string) (*Feed, error) {
+	return c.setFeedPaused(ctx, feedID, "resume")
+}
+
+func (c *Client) setFeedPaused(ctx context.Context, feedID, action string) (*Feed, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    *Feed  `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/marketplace/feeds/"+feedID+"/"+action, nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Data, nil
+}
+
+// DeleteFeed deletes a feed the user manages.
+func (c *Client) DeleteFeed(ctx context.Context, feedID string) error {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodDelete, "/api/marketplace/feeds/"+feedID, nil, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+// Query asks the LLM a one-shot (non-streaming) question about a feed's context.
+func (c *Client) Query(ctx context.Context, feedID, question, systemPrompt string) (*LLMAnswer, error) {
+	payload := map[string]string{"feedId": feedID, "question": question}
+	if systemPrompt != "" {
+		payload["systemPrompt"] = systemPrompt
+	}
+	var resp LLMAnswer
+	if err := c.do(ctx, http.MethodPost, "/api/llm/query", payload, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health pings the backend health endpoint.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+// Categories lists the enabled feed categories.
+func (c *Client) Categories(ctx context.Context) ([]Category, error) {
+	var resp struct {
+		Success    bool       `json:"success"`
+		Message    string     `json:"message"`
+		Categories []Category `json:"categories"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/settings/categories", nil, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Categories, nil
+}
+
+// Providers lists the LLM providers configured on the backend.
+func (c *Client) Providers(ctx context.Context) (*ProviderInfo, error) {
+	var resp ProviderInfo
+	if err := c.do(ctx, http.MethodGet, "/api/llm/providers", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// LLMUsage breaks the user's LLM usage over the last 30 days down by
+// "feed", "day" or "provider".
+func (c *Client) LLMUsage(ctx context.Context, groupBy string) (*LLMUsageSummary, error) {
+	var resp LLMUsageSummary
+	if err := c.do(ctx, http.MethodGet, "/api/llm/usage?groupBy="+url.QueryEscape(groupBy), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do performs an HTTP request and unmarshals the response, renewing the
+// access token once if it was rejected.
+func (c *Client) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	token := c.Token()
+	err := c.send(ctx, method, path, token, payload, out)
+	var httpErr *HTTPError
+	if token != "" && errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized && c.refresh(ctx, token) {
+		return c.send(ctx, method, path, c.Token(), payload, out)
+	}
+	return err
+}
+
+func (c *Client) send(ctx context.Context, method, path, token string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		buf := &bytes.Buffer{}
+		if err := json.NewEncoder(buf).Encode(payload); err != nil {
+			return err
+		}
+		body = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if IsTimeout(err) {
+			return fmt.Errorf("%w: %s %s", ErrTimeout, method, path)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if IsTimeout(err) {
+			return fmt.Errorf("%w: %s %s", ErrTimeout, method, path)
+		}
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Package client is the Go SDK for the TurboStream backend. Client covers the
+// REST API: authentication, the feed marketplace, subscriptions and LLM
+// queries. Stream is the websocket connection that delivers feed data and
+// streamed LLM answers as typed Events, optionally reconnecting and
+// resubscribing on its own.
+//
+//	c := client.NewClient("http://localhost:7210")
+//	_, user, err := c.Login(ctx, email, password, "")
+//	if err != nil {
+//		return err
+//	}
+//	stream, err := client.DialStream(ctx, "ws://localhost:7210/ws", client.StreamOptions{
+//		UserID:    user.ID,
+//		TokenFunc: c.Token,
+//		Reconnect: true,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	_ = stream.Subscribe(feedID)
+//	for ev := range stream.Events() {
+//		if data, ok := ev.(client.FeedData); ok {
+//			fmt.Println(data.FeedName, string(data.Data))
+//		}
+//	}
+//
+// Ask sends a question about a feed and waits for the answer:
+//
+//	resp, err := stream.Ask(ctx, client.LLMQuery{FeedID: feedID, Question: "Any anomalies?"}, nil)
+package client
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is something the backend sent on a Stream. It is one of the types
+// below; switch on it to handle the ones you need.
+type Event interface {
+	event()
+}
+
+// Connection states reported by StatusEvent
+const (
+	StatusConnected    = "connected"
+	StatusReconnecting = "reconnecting"
+	StatusDisconnected = "disconnected"
+)
+
+type (
+	// StatusEvent reports the connection state. Connected follows each
+	// successful (re)connect; Reconnecting carries the error that dropped the
+	// connection; Disconnected is the last event before Events is closed.
+	StatusEvent struct {
+		Status string
+		Err    error
+	}
+
+	// FeedData is one message from a subscribed feed. Batches the server sent
+	// are unpacked into single messages.
+	FeedData struct {
+		FeedID    string
+		FeedName  string
+		EventName string
+		Data      json.RawMessage
+		Time      time.Time
+	}
+
+	// PacketDropped reports a feed message that could not be decoded
+	PacketDropped struct {
+		FeedID string
+		Reason string
+	}
+
+	// FeedHealth is a feed's upstream connection health
+	FeedHealth struct {
+		FeedID     string
+		Status     string
+		ErrorCount int64
+		LastError  string
+	}
+
+	// FeedStatus is a change in a feed's upstream connection, such as
+	// reconnecting, circuit-open, paused or resumed
+	FeedStatus struct {
+		FeedID  string
+		Status  string
+		Message string
+	}
+
+	// TokenUsageUpdate carries the user's token usage after an LLM query
+	TokenUsageUpdate struct {
+		Usage TokenUsage
+	}
+
+	// SubscriptionExpired reports a subscription that ended on the server;
+	// Revoked is set when the feed's owner withdrew access
+	SubscriptionExpired struct {
+		FeedID  string
+		Revoked bool
+	}
+
+	// SubscriptionDenied reports a subscribe request the server refused
+	SubscriptionDenied struct {
+		FeedID string
+		Reason string
+	}
+
+	// AccountDeleted arrives when the signed-in account was deleted; the
+	// server closes the connection after it
+	AccountDeleted struct {
+		UserID string
+	}
+
+	// LLMToken is one streamed piece of an answer
+	LLMToken struct {
+		RequestID string
+		Token     string
+	}
+
+	// LLMProgress is the backend's periodic progress report on a streaming query
+	LLMProgress struct {
+		RequestID    string
+		Tokens       int
+		ElapsedMs    int64
+		FirstTokenMs int64
+	}
+
+	// LLMResponse ends a query: the full answer, or Err when it failed or was
+	// refused by a rate limit or token quota
+	LLMResponse struct {
+		RequestID  string
+		Answer     string
+		Provider   string
+		DurationMs int64
+		// Token counts reported by the backend; zero for cached answers
+		PromptTokens     int
+		CompletionTokens int
+		// Feed entries the backend sent and the percent of the model's context window they filled
+		ContextEntries     int
+		ContextUtilization float64
+		// Model and FirstTokenMs come from a stream's usage block; FirstTokenMs is 0 when unknown
+		Model        string
+		FirstTokenMs int64
+		// Cancelled marks a stream stopped with CancelLLMQuery; Answer is the part streamed
+		Cancelled bool
+		Err       error
+	}
+
+	// ConnectionStats is the backend's view of the connection, sent in reply
+	// to RequestConnectionStats; LatencyMs is -1 until the server's first
+	// ping is answered
+	ConnectionStats struct {
+		LatencyMs        int64
+		UptimeMs         int64
+		PingsSent        int64
+		MessagesSent     int64
+		MessagesReceived int64
+		Dropped          int64
+		Compression      bool
+	}
+
+	// RateLimited reports a message the server dropped for exceeding a rate
+	// limit; rate limited LLM queries end with an LLMResponse instead
+	RateLimited struct {
+		Type       string
+		RetryAfter time.Duration
+	}
+)
+
+func (StatusEvent) event()         {}
+func (FeedData) event()            {}
+func (PacketDropped) event()       {}
+func (FeedHealth) event()          {}
+func (FeedStatus) event()          {}
+func (TokenUsageUpdate) event()    {}
+func (SubscriptionExpired) event() {}
+func (SubscriptionDenied) event()  {}
+func (AccountDeleted) event()      {}
+func (LLMToken) event()            {}
+func (LLMProgress) event()         {}
+func (LLMResponse) event()         {}
+func (ConnectionStats) event()     {}
+func (RateLimited) event()         {}
@@ -0,0 +1,10 @@
+module github.com/turboline-ai/turbostream/go-tui/pkg/client
+
+go 1.24.0
+
+require (
+	github.com/ugorji/go/codec v1.3.0
+	nhooyr.io/websocket v1.8.7
+)
+
+require github.com/klauspost/compress v1.10.3 // indirect
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LLMQuery is a question about a feed's recent data
+type LLMQuery struct {
+	FeedID   string
+	Question string
+	// SystemPrompt replaces the feed's default prompt when set
+	SystemPrompt string
+	// RequestID ties the query's events together; Ask generates one when empty
+	RequestID string
+	// Temperature and MaxTokens override the provider's defaults when set
+	Temperature *float64
+	MaxTokens   int
+}
+
+// llmWaiter receives the events of a query an Ask call is waiting on
+type llmWaiter struct {
+	events chan Event
+	done   chan struct{}
+}
+
+// SendLLMQuery starts a streaming query. Its LLMToken and LLMProgress events
+// and the closing LLMResponse arrive on Events under q.RequestID.
+func (s *Stream) SendLLMQuery(q LLMQuery) error {
+	payload := map[string]interface{}{
+		"feedId":       q.FeedID,
+		"question":     q.Question,
+		"systemPrompt": q.SystemPrompt,
+		"requestId":    q.RequestID,
+	}
+	if q.Temperature != nil {
+		payload["temperature"] = *q.Temperature
+	}
+	if q.MaxTokens > 0 {
+		payload["maxTokens"] = q.MaxTokens
+	}
+	return s.send(map[string]interface{}{
+		"type":    "llm-query-stream",
+		"payload": payload,
+	})
+}
+
+// CancelLLMQuery stops a streaming query; it ends with a Cancelled LLMResponse
+func (s *Stream) CancelLLMQuery(requestID string) error {
+	return s.send(map[string]interface{}{
+		"type": "llm-cancel",
+		"payload": map[string]string{
+			"requestId": requestID,
+		},
+	})
+}
+
+// Ask sends a query and waits for its answer, passing each streamed token to
+// onToken when it is not nil. The query's events go to Ask instead of Events.
+// Cancelling ctx cancels the query on the backend; as an answer is lost when
+// the connection drops, ctx should carry a deadline. Failed and refused
+// queries are returned as errors along with their LLMResponse.
+func (s *Stream) Ask(ctx context.Context, q LLMQuery, onToken func(string)) (*LLMResponse, error) {
+	if q.RequestID == "" {
+		q.RequestID = fmt.Sprintf("req-%d", time.Now().UnixNano())
+	}
+	w := &llmWaiter{events: make(chan Event, 16), done: make(chan struct{})}
+	s.waitersMu.Lock()
+	s.waiters[q.RequestID] = w
+	s.waitersMu.Unlock()
+	defer func() {
+		s.waitersMu.Lock()
+		delete(s.waiters, q.RequestID)
+		s.waitersMu.Unlock()
+		close(w.done)
+	}()
+
+	if err := s.SendLLMQuery(q); err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case ev := <-w.events:
+			switch ev := ev.(type) {
+			case LLMToken:
+				if onToken != nil {
+					onToken(ev.Token)
+				}
+			case LLMResponse:
+				return &ev, ev.Err
+			}
+		case <-ctx.Done():
+			_ = s.CancelLLMQuery(q.RequestID)
+			return nil, ctx.Err()
+		case <-s.ctx.Done():
+			return nil, ErrStreamClosed
+		}
+	}
+}
+
+// emitLLM hands a query's event to the Ask call waiting on it, or to Events
+func (s *Stream) emitLLM(requestID string, ev Event) {
+	s.waitersMu.Lock()
+	w := s.waiters[requestID]
+	s.waitersMu.Unlock()
+	if w == nil {
+		s.emit(ev)
+		return
+	}
+	select {
+	case w.events <- ev:
+	case <-w.done:
+	case <-s.ctx.Done():
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// queryOf decodes an llm-query-stream or llm-cancel payload
+func queryOf(t *testing.T, env wsEnvelope) (requestID, question string) {
+	t.Helper()
+	var payload struct {
+		RequestID string `json:"requestId"`
+		Question  string `json:"question"`
+	}
+	if err := json.Unmarshal(env.Payload, &payload); err != nil {
+		t.Fatalf("decode %s payload: %v", env.Type, err)
+	}
+	return payload.RequestID, payload.Question
+}
+
+// askResult is what an Ask call running in the background returned
+type askResult struct {
+	resp *LLMResponse
+	err  error
+}
+
+// ask runs Ask in the background so the test can play the server meanwhile
+func ask(s *Stream, ctx context.Context, q LLMQuery, onToken func(string)) <-chan askResult {
+	done := make(chan askResult, 1)
+	go func() {
+		resp, err := s.Ask(ctx, q, onToken)
+		done <- askResult{resp, err}
+	}()
+	return done
+}
+
+func TestStream_AskStreamsTokensAndAnswer(t *testing.T) {
+	srv := newWSServer(t)
+	s := dialTestStream(t, srv, StreamOptions{})
+	sc := srv.accept(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	var tokens []string
+	done := ask(s, ctx, LLMQuery{FeedID: "feed-1", Question: "what moved?"}, func(tok string) {
+		tokens = append(tokens, tok)
+	})
+
+	requestID, question := queryOf(t, sc.expect(t, "llm-query-stream"))
+	if question != "what moved?" {
+		t.Errorf("question = %q", question)
+	}
+	sc.send(t, "llm-token", map[string]string{"requestId": requestID, "token": "Prices "})
+	sc.send(t, "llm-token", map[string]string{"requestId": requestID, "token": "rose."})
+	sc.send(t, "llm-complete", map[string]interface{}{
+		"requestId":        requestID,
+		"answer":           "Prices rose.",
+		"provider":         "openai",
+		"completionTokens": 2,
+	})
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Ask: %v", res.err)
+	}
+	if res.resp.Answer != "Prices rose." || res.resp.Provider != "openai" || res.resp.CompletionTokens != 2 {
+		t.Errorf("response = %#v", res.resp)
+	}
+	if !reflect.DeepEqual(tokens, []string{"Prices ", "rose."}) {
+		t.Errorf("tokens = %q", tokens)
+	}
+}
+
+func TestStream_AskReturnsServerError(t *testing.T) {
+	srv := newWSServer(t)
+	s := dialTestStream(t, srv, StreamOptions{})
+	sc := srv.accept(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	done := ask(s, ctx, LLMQuery{FeedID: "feed-1", Question: "what moved?", RequestID: "req-1"}, nil)
+
+	requestID, _ := queryOf(t, sc.expect(t, "llm-query-stream"))
+	sc.send(t, "llm-error", map[string]string{"requestId": requestID, "error": "subscription required"})
+
+	res := <-done
+	if res.err == nil || res.err.Error() != "subscription required" {
+		t.Fatalf("Ask error = %v, want subscription required", res.err)
+	}
+	if res.resp == nil || res.resp.RequestID != "req-1" {
+		t.Errorf("response = %#v, want the failed request's", res.resp)
+	}
+}
+
+func TestStream_AskCancelsOnTimeout(t *testing.T) {
+	srv := newWSServer(t)
+	s := dialTestStream(t, srv, StreamOptions{})
+	sc := srv.accept(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	resp, err := s.Ask(ctx, LLMQuery{FeedID: "feed-1", Question: "what moved?", RequestID: "req-1"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Ask error = %v, want deadline exceeded", err)
+	}
+	if resp != nil {
+		t.Errorf("response = %#v, want none", resp)
+	}
+
+	sc.expect(t, "llm-query-stream")
+	if requestID, _ := queryOf(t, sc.expect(t, "llm-cancel")); requestID != "req-1" {
+		t.Errorf("cancelled %q, want req-1", requestID)
+	}
+
+	// A late answer goes to Events once nobody waits for it
+	sc.send(t, "llm-cancelled", map[string]string{"requestId": "req-1"})
+	if got, ok := nextEvent(t, s).(LLMResponse); !ok || got.RequestID != "req-1" || !got.Cancelled {
+		t.Errorf("late answer = %#v, want the cancelled req-1", got)
+	}
+}
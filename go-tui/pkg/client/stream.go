@@ -0,0 +1,619 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ugorji/go/codec"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// wsProtocolVersion is the newest websocket protocol this package speaks
+const wsProtocolVersion = 2
+
+// wsFeatures are the optional protocol features asked for in the hello
+var wsFeatures = []string{"binary", "compression"}
+
+const (
+	// minReconnectDelay is the first wait before redialling; it doubles up
+	// to StreamOptions.MaxReconnectDelay
+	minReconnectDelay        = time.Second
+	defaultMaxReconnectDelay = 30 * time.Second
+	// connectTimeout bounds each reconnect attempt
+	connectTimeout    = 15 * time.Second
+	writeTimeout      = 5 * time.Second
+	defaultEventQueue = 64
+)
+
+var (
+	// ErrNotConnected is returned when a message cannot be sent because the
+	// stream is between connections
+	ErrNotConnected = errors.New("stream is not connected")
+	// ErrStreamClosed is returned once Close was called
+	ErrStreamClosed = errors.New("stream closed")
+)
+
+// msgpackHandle decodes binary frames; maps decode like JSON objects
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.RawToString = true
+	return h
+}()
+
+type wsEnvelope struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// StreamOptions configures DialStream.
+type StreamOptions struct {
+	// UserID registers the connection for the user's own events, such as
+	// token usage updates. Without it the connection is anonymous.
+	UserID string
+	// Token authenticates the connection, which subscribing to private feeds
+	// requires. TokenFunc, when set, is asked instead on every connect, so
+	// reconnects use a renewed token; Client.Token fits.
+	Token     string
+	TokenFunc func() string
+	// UserAgent identifies the client to the server
+	UserAgent string
+	// BatchInterval asks the server to batch each subscription's messages;
+	// 0 sends them as they come. Batches still arrive as single FeedData events.
+	BatchInterval time.Duration
+	// Reconnect redials with exponential backoff, up to MaxReconnectDelay
+	// (30 seconds by default) between attempts, after the connection drops,
+	// and subscribes again to the feeds the stream was subscribed to
+	Reconnect         bool
+	MaxReconnectDelay time.Duration
+	// EventQueue is the capacity of the Events channel, 64 by default. The
+	// stream stops reading while the channel is full.
+	EventQueue int
+}
+
+// Stream is a websocket connection to the backend delivering feed data,
+// status changes and LLM answers as typed Events. It keeps track of the feeds
+// it is subscribed to so they survive reconnects. Its methods are safe for
+// concurrent use.
+type Stream struct {
+	url    string
+	opts   StreamOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	events chan Event
+
+	connMu sync.Mutex
+	conn   *websocket.Conn
+
+	subsMu sync.Mutex
+	subs   map[string]bool
+
+	// Agreed in the hello handshake; servers that don't know hello leave version 1
+	protocolMu sync.RWMutex
+	version    int
+	features   map[string]bool
+
+	waitersMu sync.Mutex
+	waiters   map[string]*llmWaiter
+}
+
+// DialStream connects to the backend's websocket endpoint, e.g.
+// "ws://localhost:7210/ws". ctx bounds the first connection only; the
+// stream runs until Close.
+func DialStream(ctx context.Context, url string, opts StreamOptions) (*Stream, error) {
+	if opts.MaxReconnectDelay <= 0 {
+		opts.MaxReconnectDelay = defaultMaxReconnectDelay
+	}
+	if opts.EventQueue <= 0 {
+		opts.EventQueue = defaultEventQueue
+	}
+	s := &Stream{
+		url:     url,
+		opts:    opts,
+		events:  make(chan Event, opts.EventQueue),
+		subs:    make(map[string]bool),
+		waiters: make(map[string]*llmWaiter),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	conn, err := s.connect(ctx)
+	if err != nil {
+		s.cancel()
+		return nil, err
+	}
+	s.setConn(conn)
+	go s.run(conn)
+	return s, nil
+}
+
+// Events delivers what the backend sends. It is closed when the connection
+// ends for good: after Close, or once it drops without Reconnect.
+func (s *Stream) Events() <-chan Event {
+	return s.events
+}
+
+// connect dials and introduces the connection: the protocol hello, the user
+// registration and, with a token, authentication
+func (s *Stream) connect(ctx context.Context) (*websocket.Conn, error) {
+	conn, _, err := websocket.Dial(ctx, s.url, &websocket.DialOptions{
+		CompressionMode: websocket.CompressionNoContextTakeover,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.setProtocol(1, nil)
+
+	// Negotiate the protocol first; older servers answer with an unknown-event
+	// error, which is ignored, and keep speaking version 1
+	intro := []struct {
+		typ     string
+		payload interface{}
+	}{{"hello", map[string]interface{}{
+		"version":  wsProtocolVersion,
+		"features": wsFeatures,
+		"client":   s.opts.UserAgent,
+	}}}
+	if s.opts.UserID != "" {
+		intro = append(intro, struct {
+			typ     string
+			payload interface{}
+		}{"register-user", map[string]interface{}{
+			"userId":    s.opts.UserID,
+			"userAgent": s.opts.UserAgent,
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		}})
+	}
+	if token := s.token(); token != "" {
+		intro = append(intro, struct {
+			typ     string
+			payload interface{}
+		}{"authenticate", map[string]interface{}{"token": token}})
+	}
+	for _, msg := range intro {
+		if err := wsjson.Write(ctx, conn, map[string]interface{}{"type": msg.typ, "payload": msg.payload}); err != nil {
+			_ = conn.Close(websocket.StatusInternalError, msg.typ+" failed")
+			return nil, fmt.Errorf("%s failed: %w", msg.typ, err)
+		}
+	}
+	// Registered connections are reported connected once the server confirms
+	if s.opts.UserID == "" {
+		s.emit(StatusEvent{Status: StatusConnected})
+	}
+	return conn, nil
+}
+
+func (s *Stream) token() string {
+	if s.opts.TokenFunc != nil {
+		return s.opts.TokenFunc()
+	}
+	return s.opts.Token
+}
+
+// run reads until the stream is closed, reconnecting when asked to
+func (s *Stream) run(conn *websocket.Conn) {
+	defer close(s.events)
+	for {
+		err := s.read(conn)
+		s.setConn(nil)
+		if s.ctx.Err() != nil {
+			return
+		}
+		if !s.opts.Reconnect {
+			s.emit(StatusEvent{Status: StatusDisconnected, Err: err})
+			return
+		}
+		s.emit(StatusEvent{Status: StatusReconnecting, Err: err})
+		if conn = s.reconnect(); conn == nil {
+			return
+		}
+		s.setConn(conn)
+		s.resubscribe()
+	}
+}
+
+// reconnect redials with backoff until it succeeds or the stream is closed,
+// in which case it returns nil
+func (s *Stream) reconnect() *websocket.Conn {
+	delay := minReconnectDelay
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, connectTimeout)
+		conn, err := s.connect(ctx)
+		cancel()
+		if err == nil {
+			return conn
+		}
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		s.emit(StatusEvent{Status: StatusReconnecting, Err: err})
+		delay *= 2
+		if delay > s.opts.MaxReconnectDelay {
+			delay = s.opts.MaxReconnectDelay
+		}
+	}
+}
+
+// read dispatches messages until the connection fails
+func (s *Stream) read(conn *websocket.Conn) error {
+	for {
+		env, err := s.readEnvelope(conn)
+		if err != nil {
+			return err
+		}
+		s.dispatch(env)
+	}
+}
+
+// readEnvelope reads the next message; feed-data may arrive as a msgpack
+// binary frame once the server agreed to binary frames
+func (s *Stream) readEnvelope(conn *websocket.Conn) (wsEnvelope, error) {
+	var env wsEnvelope
+	typ, data, err := conn.Read(s.ctx)
+	if err != nil {
+		return env, err
+	}
+	if typ == websocket.MessageText {
+		return env, json.Unmarshal(data, &env)
+	}
+	if !s.hasFeature("binary") {
+		return env, errors.New("unexpected binary frame")
+	}
+	var frame struct {
+		Type    string      `codec:"type"`
+		Payload interface{} `codec:"payload"`
+	}
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&frame); err != nil {
+		return env, fmt.Errorf("decode binary frame: %w", err)
+	}
+	// dispatch reads JSON payloads
+	payload, err := json.Marshal(frame.Payload)
+	if err != nil {
+		return env, err
+	}
+	return wsEnvelope{Type: frame.Type, Payload: payload}, nil
+}
+
+// dispatch turns one server message into events; unknown types are ignored
+func (s *Stream) dispatch(env wsEnvelope) {
+	switch env.Type {
+	case "hello":
+		var payload struct {
+			Version  int      `json:"version"`
+			Features []string `json:"features"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.setProtocol(payload.Version, payload.Features)
+		}
+	case "hello-error":
+		// The server doesn't speak our version; carry on with version 1
+	case "registration-success":
+		s.emit(StatusEvent{Status: StatusConnected})
+	case "feed-data":
+		s.emitFeedData(env.Payload)
+	case "feed-data-batch":
+		var payload struct {
+			FeedID   string            `json:"feedId"`
+			Messages []json.RawMessage `json:"messages"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err != nil {
+			s.emit(PacketDropped{FeedID: payload.FeedID, Reason: "json_parse_error"})
+			break
+		}
+		for _, msg := range payload.Messages {
+			s.emitFeedData(msg)
+		}
+	case "feed-health":
+		var payload struct {
+			FeedID     string `json:"feedId"`
+			Status     string `json:"status"`
+			ErrorCount int64  `json:"errorCount"`
+			LastError  string `json:"lastError"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emit(FeedHealth(payload))
+		}
+	case "feed-status", "feed-paused", "feed-resumed":
+		var payload struct {
+			FeedID  string `json:"feedId"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emit(FeedStatus(payload))
+		}
+	case "token-usage-update":
+		var usage TokenUsage
+		if err := json.Unmarshal(env.Payload, &usage); err == nil {
+			s.emit(TokenUsageUpdate{Usage: usage})
+		}
+	case "subscription-success", "unsubscription-success":
+		// The subscription calls already succeeded
+	case "subscription-expired", "feed-access-revoked":
+		var payload struct {
+			FeedID string `json:"feedId"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.forget(payload.FeedID)
+			s.emit(SubscriptionExpired{FeedID: payload.FeedID, Revoked: env.Type == "feed-access-revoked"})
+		}
+	case "subscription-denied":
+		var payload struct {
+			FeedID string `json:"feedId"`
+			Error  string `json:"error"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.forget(payload.FeedID)
+			s.emit(SubscriptionDenied{FeedID: payload.FeedID, Reason: payload.Error})
+		}
+	case "account-deleted":
+		var payload struct {
+			UserID string `json:"userId"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emit(AccountDeleted(payload))
+		}
+	case "llm-response", "llm-complete", "llm-cancelled":
+		var payload struct {
+			RequestID        string `json:"requestId"`
+			Answer           string `json:"answer"`
+			Provider         string `json:"provider"`
+			DurationMs       int64  `json:"durationMs"`
+			PromptTokens     int    `json:"promptTokens"`
+			CompletionTokens int    `json:"completionTokens"`
+			// Entries sent and the share of the model's context window the prompt filled
+			ContextEntries            int     `json:"contextEntries"`
+			ContextUtilizationPercent float64 `json:"contextUtilizationPercent"`
+			// Usage is the backend's own account of a stream, including its time to first token
+			Usage *struct {
+				Model        string `json:"model"`
+				FirstTokenMs int64  `json:"firstTokenMs"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			resp := LLMResponse{
+				RequestID:          payload.RequestID,
+				Answer:             payload.Answer,
+				Provider:           payload.Provider,
+				DurationMs:         payload.DurationMs,
+				PromptTokens:       payload.PromptTokens,
+				CompletionTokens:   payload.CompletionTokens,
+				ContextEntries:     payload.ContextEntries,
+				ContextUtilization: payload.ContextUtilizationPercent,
+				Cancelled:          env.Type == "llm-cancelled",
+			}
+			if payload.Usage != nil {
+				resp.Model = payload.Usage.Model
+				resp.FirstTokenMs = payload.Usage.FirstTokenMs
+			}
+			s.emitLLM(resp.RequestID, resp)
+		}
+	case "llm-token":
+		var payload struct {
+			RequestID string `json:"requestId"`
+			Token     string `json:"token"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emitLLM(payload.RequestID, LLMToken(payload))
+		}
+	case "llm-progress":
+		var payload struct {
+			RequestID    string `json:"requestId"`
+			Tokens       int    `json:"tokens"`
+			ElapsedMs    int64  `json:"elapsedMs"`
+			FirstTokenMs int64  `json:"firstTokenMs"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emitLLM(payload.RequestID, LLMProgress(payload))
+		}
+	case "llm-error", "quota-exceeded":
+		// A quota-exceeded query was rejected before reaching the LLM; the
+		// error says how much quota is left
+		var payload struct {
+			RequestID string `json:"requestId"`
+			Error     string `json:"error"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emitLLM(payload.RequestID, LLMResponse{RequestID: payload.RequestID, Err: errors.New(payload.Error)})
+		}
+	case "connection-stats":
+		var payload struct {
+			LatencyMs        int64 `json:"latencyMs"`
+			UptimeMs         int64 `json:"uptimeMs"`
+			PingsSent        int64 `json:"pingsSent"`
+			MessagesSent     int64 `json:"messagesSent"`
+			MessagesReceived int64 `json:"messagesReceived"`
+			Dropped          int64 `json:"dropped"`
+			Compression      bool  `json:"compression"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			s.emit(ConnectionStats(payload))
+		}
+	case "rate-limit-exceeded":
+		var payload struct {
+			Type         string `json:"type"`
+			RequestID    string `json:"requestId"`
+			RetryAfterMs int64  `json:"retryAfterMs"`
+		}
+		if err := json.Unmarshal(env.Payload, &payload); err == nil {
+			retryAfter := time.Duration(payload.RetryAfterMs) * time.Millisecond
+			if payload.RequestID != "" {
+				err := fmt.Errorf("rate limit exceeded, retry in %.1fs", retryAfter.Seconds())
+				s.emitLLM(payload.RequestID, LLMResponse{RequestID: payload.RequestID, Err: err})
+			} else {
+				s.emit(RateLimited{Type: payload.Type, RetryAfter: retryAfter})
+			}
+		}
+	}
+}
+
+// emitFeedData passes one feed-data payload on as FeedData
+func (s *Stream) emitFeedData(raw json.RawMessage) {
+	var payload struct {
+		FeedID    string          `json:"feedId"`
+		FeedName  string          `json:"feedName"`
+		EventName string          `json:"eventName"`
+		Data      json.RawMessage `json:"data"`
+		Timestamp string          `json:"timestamp"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		s.emit(PacketDropped{FeedID: payload.FeedID, Reason: "json_parse_error"})
+		return
+	}
+	ts, _ := time.Parse(time.RFC3339, payload.Timestamp)
+	s.emit(FeedData{
+		FeedID:    payload.FeedID,
+		FeedName:  payload.FeedName,
+		EventName: payload.EventName,
+		Data:      payload.Data,
+		Time:      ts,
+	})
+}
+
+// emit queues an event, waiting while the queue is full unless the stream is closed
+func (s *Stream) emit(ev Event) {
+	select {
+	case s.events <- ev:
+	case <-s.ctx.Done():
+	}
+}
+
+// Subscribe starts delivering a feed's messages and keeps the subscription
+// across reconnects. While a reconnecting stream is between connections the
+// request is sent once it is back.
+func (s *Stream) Subscribe(feedID string) error {
+	s.subsMu.Lock()
+	s.subs[feedID] = true
+	s.subsMu.Unlock()
+	err := s.sendSubscribe(feedID)
+	if errors.Is(err, ErrNotConnected) && s.opts.Reconnect {
+		return nil
+	}
+	return err
+}
+
+// Unsubscribe stops delivering a feed's messages
+func (s *Stream) Unsubscribe(feedID string) error {
+	s.forget(feedID)
+	return s.send(map[string]interface{}{
+		"type": "unsubscribe-feed",
+		"payload": map[string]string{
+			"feedId": feedID,
+			"userId": s.opts.UserID,
+		},
+	})
+}
+
+// Subscriptions returns the IDs of the feeds the stream is subscribed to
+func (s *Stream) Subscriptions() []string {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	ids := make([]string, 0, len(s.subs))
+	for id := range s.subs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// forget drops a subscription the server ended
+func (s *Stream) forget(feedID string) {
+	s.subsMu.Lock()
+	delete(s.subs, feedID)
+	s.subsMu.Unlock()
+}
+
+func (s *Stream) sendSubscribe(feedID string) error {
+	payload := map[string]interface{}{
+		"feedId": feedID,
+		"userId": s.opts.UserID,
+	}
+	if s.opts.BatchInterval > 0 {
+		payload["batch"] = map[string]int64{"intervalMs": s.opts.BatchInterval.Milliseconds()}
+	}
+	return s.send(map[string]interface{}{
+		"type":    "subscribe-feed",
+		"payload": payload,
+	})
+}
+
+// resubscribe renews every subscription on a new connection
+func (s *Stream) resubscribe() {
+	for _, feedID := range s.Subscriptions() {
+		_ = s.sendSubscribe(feedID)
+	}
+}
+
+// RequestConnectionStats asks the backend for its view of the connection,
+// which arrives as a ConnectionStats event
+func (s *Stream) RequestConnectionStats() error {
+	return s.send(map[string]interface{}{"type": "connection-stats"})
+}
+
+// ProtocolVersion returns the websocket protocol version agreed with the server
+func (s *Stream) ProtocolVersion() int {
+	s.protocolMu.RLock()
+	defer s.protocolMu.RUnlock()
+	return s.version
+}
+
+// setProtocol records the version and features agreed with the server
+func (s *Stream) setProtocol(version int, features []string) {
+	s.protocolMu.Lock()
+	defer s.protocolMu.Unlock()
+	s.version = version
+	s.features = make(map[string]bool, len(features))
+	for _, f := range features {
+		s.features[f] = true
+	}
+}
+
+// hasFeature reports whether the server agreed to an optional protocol feature
+func (s *Stream) hasFeature(feature string) bool {
+	s.protocolMu.RLock()
+	defer s.protocolMu.RUnlock()
+	return s.features[feature]
+}
+
+func (s *Stream) setConn(conn *websocket.Conn) {
+	s.connMu.Lock()
+	s.conn = conn
+	s.connMu.Unlock()
+}
+
+func (s *Stream) send(msg interface{}) error {
+	if s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+	s.connMu.Lock()
+	conn := s.conn
+	s.connMu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, writeTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, msg)
+}
+
+// Close ends the stream; Events is closed once its reader stops
+func (s *Stream) Close() {
+	s.connMu.Lock()
+	conn := s.conn
+	s.conn = nil
+	s.connMu.Unlock()
+	s.cancel()
+	if conn != nil {
+		_ = conn.Close(websocket.StatusNormalClosure, "bye")
+	}
+}
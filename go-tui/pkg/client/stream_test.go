@@ -0,0 +1,284 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// testTimeout bounds every wait; reconnecting takes minReconnectDelay
+const testTimeout = 5 * time.Second
+
+// wsServer is a websocket endpoint that hands each accepted connection to the
+// test
+type wsServer struct {
+	*httptest.Server
+	conns chan *wsServerConn
+}
+
+// wsServerConn is the server side of one connection; msgs carries what the
+// client sent
+type wsServerConn struct {
+	conn *websocket.Conn
+	msgs chan wsEnvelope
+}
+
+func newWSServer(t *testing.T) *wsServer {
+	t.Helper()
+	srv := &wsServer{conns: make(chan *wsServerConn, 4)}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		sc := &wsServerConn{conn: conn, msgs: make(chan wsEnvelope, 16)}
+		srv.conns <- sc
+		defer close(sc.msgs)
+		for {
+			var env wsEnvelope
+			if err := wsjson.Read(context.Background(), conn, &env); err != nil {
+				return
+			}
+			sc.msgs <- env
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *wsServer) url() string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// accept waits for the next connection
+func (srv *wsServer) accept(t *testing.T) *wsServerConn {
+	t.Helper()
+	select {
+	case sc := <-srv.conns:
+		return sc
+	case <-time.After(testTimeout):
+		t.Fatal("no connection")
+		return nil
+	}
+}
+
+// expect waits for the next message of a type, skipping the others
+func (sc *wsServerConn) expect(t *testing.T, typ string) wsEnvelope {
+	t.Helper()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case env, ok := <-sc.msgs:
+			if !ok {
+				t.Fatalf("connection closed before %s", typ)
+			}
+			if env.Type == typ {
+				return env
+			}
+		case <-timeout:
+			t.Fatalf("no %s message", typ)
+		}
+	}
+}
+
+func (sc *wsServerConn) send(t *testing.T, typ string, payload interface{}) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := wsjson.Write(ctx, sc.conn, map[string]interface{}{"type": typ, "payload": payload}); err != nil {
+		t.Fatalf("send %s: %v", typ, err)
+	}
+}
+
+func dialTestStream(t *testing.T, srv *wsServer, opts StreamOptions) *Stream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	s, err := DialStream(ctx, srv.url(), opts)
+	if err != nil {
+		t.Fatalf("DialStream: %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// nextEvent waits for the next event that is not a StatusEvent
+func nextEvent(t *testing.T, s *Stream) Event {
+	t.Helper()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case ev, ok := <-s.Events():
+			if !ok {
+				t.Fatal("events closed")
+			}
+			if _, status := ev.(StatusEvent); !status {
+				return ev
+			}
+		case <-timeout:
+			t.Fatal("no event")
+			return nil
+		}
+	}
+}
+
+// waitStatus waits for a StatusEvent with the given status
+func waitStatus(t *testing.T, s *Stream, status string) StatusEvent {
+	t.Helper()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case ev, ok := <-s.Events():
+			if !ok {
+				t.Fatalf("events closed before %s", status)
+			}
+			if ev, isStatus := ev.(StatusEvent); isStatus && ev.Status == status {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("no %s status", status)
+			return StatusEvent{}
+		}
+	}
+}
+
+func feedIDOf(t *testing.T, env wsEnvelope) string {
+	t.Helper()
+	var payload struct {
+		FeedID string `json:"feedId"`
+	}
+	if err := json.Unmarshal(env.Payload, &payload); err != nil {
+		t.Fatalf("decode %s payload: %v", env.Type, err)
+	}
+	return payload.FeedID
+}
+
+func TestStream_ResubscribesAfterReconnect(t *testing.T) {
+	srv := newWSServer(t)
+	s := dialTestStream(t, srv, StreamOptions{Reconnect: true, MaxReconnectDelay: time.Second})
+
+	first := srv.accept(t)
+	first.expect(t, "hello")
+	waitStatus(t, s, StatusConnected)
+	if err := s.Subscribe("feed-1"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if got := feedIDOf(t, first.expect(t, "subscribe-feed")); got != "feed-1" {
+		t.Fatalf("subscribed to %q, want feed-1", got)
+	}
+
+	// Drop the connection as a restarting server would
+	_ = first.conn.Close(websocket.StatusGoingAway, "restart")
+	if ev := waitStatus(t, s, StatusReconnecting); ev.Err == nil {
+		t.Error("reconnecting status carries no error")
+	}
+
+	second := srv.accept(t)
+	second.expect(t, "hello")
+	if got := feedIDOf(t, second.expect(t, "subscribe-feed")); got != "feed-1" {
+		t.Fatalf("resubscribed to %q, want feed-1", got)
+	}
+	waitStatus(t, s, StatusConnected)
+	if got := s.Subscriptions(); !reflect.DeepEqual(got, []string{"feed-1"}) {
+		t.Errorf("Subscriptions() = %v, want [feed-1]", got)
+	}
+}
+
+func TestStream_DisconnectsWithoutReconnect(t *testing.T) {
+	srv := newWSServer(t)
+	s := dialTestStream(t, srv, StreamOptions{})
+
+	sc := srv.accept(t)
+	sc.expect(t, "hello")
+	_ = sc.conn.Close(websocket.StatusGoingAway, "restart")
+	waitStatus(t, s, StatusDisconnected)
+	select {
+	case _, ok := <-s.Events():
+		if ok {
+			t.Fatal("event after disconnected")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("events not closed")
+	}
+}
+
+func TestStream_DecodesEvents(t *testing.T) {
+	srv := newWSServer(t)
+	s := dialTestStream(t, srv, StreamOptions{})
+	sc := srv.accept(t)
+	sc.expect(t, "hello")
+	if err := s.Subscribe("feed-1"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := s.Subscribe("feed-2"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	sc.expect(t, "subscribe-feed")
+	sc.expect(t, "subscribe-feed")
+
+	sc.send(t, "feed-data", map[string]interface{}{
+		"feedId":    "feed-1",
+		"feedName":  "Prices",
+		"eventName": "tick",
+		"data":      map[string]float64{"price": 1.5},
+		"timestamp": "2026-01-02T03:04:05Z",
+	})
+	want := FeedData{
+		FeedID:    "feed-1",
+		FeedName:  "Prices",
+		EventName: "tick",
+		Data:      json.RawMessage(`{"price":1.5}`),
+		Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if got := nextEvent(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("feed-data = %#v, want %#v", got, want)
+	}
+
+	sc.send(t, "feed-data-batch", map[string]interface{}{
+		"feedId": "feed-1",
+		"messages": []map[string]interface{}{
+			{"feedId": "feed-1", "data": 1},
+			{"feedId": "feed-1", "data": 2},
+		},
+	})
+	for _, data := range []string{"1", "2"} {
+		got, ok := nextEvent(t, s).(FeedData)
+		if !ok || string(got.Data) != data {
+			t.Errorf("batch message = %#v, want data %s", got, data)
+		}
+	}
+
+	sc.send(t, "feed-paused", map[string]string{"feedId": "feed-1", "status": "paused", "message": "maintenance"})
+	if got, want := nextEvent(t, s), (FeedStatus{FeedID: "feed-1", Status: "paused", Message: "maintenance"}); got != want {
+		t.Errorf("feed-paused = %#v, want %#v", got, want)
+	}
+
+	sc.send(t, "subscription-denied", map[string]string{"feedId": "feed-2", "error": "subscription required"})
+	if got, want := nextEvent(t, s), (SubscriptionDenied{FeedID: "feed-2", Reason: "subscription required"}); got != want {
+		t.Errorf("subscription-denied = %#v, want %#v", got, want)
+	}
+	if got := s.Subscriptions(); !reflect.DeepEqual(got, []string{"feed-1"}) {
+		t.Errorf("Subscriptions() after denial = %v, want [feed-1]", got)
+	}
+
+	sc.send(t, "feed-access-revoked", map[string]string{"feedId": "feed-1"})
+	if got, want := nextEvent(t, s), (SubscriptionExpired{FeedID: "feed-1", Revoked: true}); got != want {
+		t.Errorf("feed-access-revoked = %#v, want %#v", got, want)
+	}
+	if got := s.Subscriptions(); len(got) != 0 {
+		t.Errorf("Subscriptions() after revocation = %v, want none", got)
+	}
+
+	sc.send(t, "rate-limit-exceeded", map[string]interface{}{"type": "subscribe", "retryAfterMs": 1500})
+	if got, want := nextEvent(t, s), (RateLimited{Type: "subscribe", RetryAfter: 1500 * time.Millisecond}); got != want {
+		t.Errorf("rate-limit-exceeded = %#v, want %#v", got, want)
+	}
+}
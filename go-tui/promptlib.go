@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+const (
+	// maxSavedPrompts and maxPromptLength match the backend's limits on a
+	// subscription's prompt library
+	maxSavedPrompts = 50
+	maxPromptLength = 4000
+	// aiPromptEditHeight is how many rows the prompt editor grows to while focused
+	aiPromptEditHeight = 8
+	// promptLibraryRows is how many saved prompts the AI panel lists at once
+	promptLibraryRows = 9
+)
+
+// promptLibraryMsg reports the outcome of saving a feed's prompt library
+type promptLibraryMsg struct {
+	FeedID  string
+	Prompts []string
+	Status  string // shown once saved
+	Err     error
+}
+
+// promptLibrary is the saved prompt list open in the AI panel, if any
+type promptLibrary struct {
+	feedID   string
+	open     bool
+	selected int
+}
+
+func savePromptsCmd(c *client.Client, feedID string, prompts []string, status string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+		defer cancel()
+		err := c.SaveSubscriptionPrompts(ctx, feedID, prompts)
+		return promptLibraryMsg{FeedID: feedID, Prompts: prompts, Status: status, Err: err}
+	}
+}
+
+// savedPrompts is the prompt library kept with the user's subscription to a feed
+func (m model) savedPrompts(feedID string) []string {
+	sub := m.subscription(feedID)
+	if sub == nil || sub.Settings == nil {
+		return nil
+	}
+	return sub.Settings.Prompts
+}
+
+func (m model) handlePromptLibraryMsg(msg promptLibraryMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.errorMessage = "Saving prompts failed: " + client.FriendlyError(msg.Err)
+		return m, nil
+	}
+	for i := range m.subs {
+		if m.subs[i].FeedID != msg.FeedID {
+			continue
+		}
+		settings := client.SubscriptionSettings{}
+		if m.subs[i].Settings != nil {
+			settings = *m.subs[i].Settings
+		}
+		settings.Prompts = msg.Prompts
+		m.subs[i].Settings = &settings
+	}
+	if m.promptLib.feedID == msg.FeedID && m.promptLib.selected >= len(msg.Prompts) {
+		m.promptLib.selected = max(len(msg.Prompts)-1, 0)
+	}
+	m.errorMessage = ""
+	m.statusMessage = msg.Status
+	return m, nil
+}
+
+// savePrompt adds the feed's current prompt to its library
+func (m model) savePrompt(feedID string) (tea.Model, tea.Cmd) {
+	if m.subscription(feedID) == nil {
+		m.errorMessage = "Subscribe to the feed to save prompts"
+		return m, nil
+	}
+	text := strings.TrimSpace(m.getPrompt(feedID).Value())
+	if text == "" {
+		m.statusMessage = "Nothing to save: the prompt is empty"
+		return m, nil
+	}
+	saved := m.savedPrompts(feedID)
+	for i, p := range saved {
+		if p == text {
+			m.statusMessage = fmt.Sprintf("Already saved as prompt %d", i+1)
+			return m, nil
+		}
+	}
+	if len(saved) >= maxSavedPrompts {
+		m.errorMessage = fmt.Sprintf("The library holds %d prompts; delete one first (b)", maxSavedPrompts)
+		return m, nil
+	}
+	prompts := append(append([]string{}, saved...), text)
+	return m, savePromptsCmd(m.client, feedID, prompts, fmt.Sprintf("Saved as prompt %d", len(prompts)))
+}
+
+// loadSavedPrompt puts saved prompt n (0-based) into the feed's prompt editor
+func (m *model) loadSavedPrompt(feedID string, n int) bool {
+	saved := m.savedPrompts(feedID)
+	if n < 0 || n >= len(saved) {
+		return false
+	}
+	prompt := m.getOrCreatePrompt(feedID)
+	prompt.SetValue(saved[n])
+	prompt.Focus()
+	m.aiPrompts[feedID] = prompt
+	m.aiFocused = true
+	m.promptLib.open = false
+	m.statusMessage = fmt.Sprintf("Prompt %d loaded. Press Enter to send.", n+1)
+	return true
+}
+
+// updatePromptLibrary handles keys while the library is open on My Feeds:
+// 1-9 or Enter load a prompt, ↑/↓ select, d deletes, Esc or b closes
+func (m model) updatePromptLibrary(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	feedID := m.promptLib.feedID
+	if !m.promptLib.open || len(m.feeds) == 0 || m.selectedIdx >= len(m.feeds) || m.feeds[m.selectedIdx].ID != feedID {
+		return m, nil, false
+	}
+	saved := m.savedPrompts(feedID)
+	switch key := msg.String(); key {
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		m.loadSavedPrompt(feedID, int(key[0]-'1'))
+	case "enter":
+		m.loadSavedPrompt(feedID, m.promptLib.selected)
+	case "up", "k":
+		if m.promptLib.selected > 0 {
+			m.promptLib.selected--
+		}
+	case "down", "j":
+		if m.promptLib.selected < len(saved)-1 {
+			m.promptLib.selected++
+		}
+	case "d", "delete":
+		if n := m.promptLib.selected; n < len(saved) {
+			prompts := append(append([]string{}, saved[:n]...), saved[n+1:]...)
+			return m, savePromptsCmd(m.client, feedID, prompts, fmt.Sprintf("Deleted prompt %d", n+1)), true
+		}
+	case "esc", "b":
+		m.promptLib.open = false
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// togglePromptLibrary opens or closes the library of the selected feed
+func (m *model) togglePromptLibrary() {
+	if len(m.feeds) == 0 || m.selectedIdx >= len(m.feeds) {
+		return
+	}
+	feedID := m.feeds[m.selectedIdx].ID
+	if m.subscription(feedID) == nil {
+		m.errorMessage = "Subscribe to the feed to use saved prompts"
+		return
+	}
+	if m.promptLib.open && m.promptLib.feedID == feedID {
+		m.promptLib.open = false
+		return
+	}
+	m.promptLib = promptLibrary{feedID: feedID, open: true}
+	if len(m.savedPrompts(feedID)) == 0 {
+		m.statusMessage = "No saved prompts yet. Press Ctrl+S while editing a prompt to save it."
+	}
+}
+
+// renderPromptLibrary lists a feed's saved prompts for the AI panel, one line
+// each, keeping the selected one in view
+func (m model) renderPromptLibrary(feedID string, width int) string {
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	b := strings.Builder{}
+	b.WriteString(dim.Render("Saved prompts (1-9/Enter: load, d: delete, Esc: close):"))
+	b.WriteString("\n")
+	saved := m.savedPrompts(feedID)
+	if len(saved) == 0 {
+		b.WriteString(dim.Render("  none yet (Ctrl+S in the prompt editor saves)"))
+		b.WriteString("\n")
+		return b.String()
+	}
+	start := 0
+	if m.promptLib.selected >= promptLibraryRows {
+		start = m.promptLib.selected - promptLibraryRows + 1
+	}
+	end := min(start+promptLibraryRows, len(saved))
+	for i := start; i < end; i++ {
+		num := "  "
+		if i < 9 {
+			num = fmt.Sprintf("%d ", i+1)
+		}
+		// Multi-line prompts show as one line
+		text := truncate(strings.Join(strings.Fields(saved[i]), " "), width-4)
+		line := lipgloss.NewStyle().Foreground(magentaColor).Render(num) + lipgloss.NewStyle().Foreground(whiteColor).Render(text)
+		if i == m.promptLib.selected {
+			line = lipgloss.NewStyle().Bold(true).Foreground(brightCyanColor).Render("›") + line
+		} else {
+			line = " " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// promptLibraryLines is how many lines renderPromptLibrary takes
+func (m model) promptLibraryLines(feedID string) int {
+	return 1 + max(min(len(m.savedPrompts(feedID)), promptLibraryRows), 1)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	recordFormatJSONL = "jsonl"
+	recordFormatCSV   = "csv"
+	// defaultRecordRotateMB starts a new recording file once the current one reaches this size
+	defaultRecordRotateMB = 50
+)
+
+// recordCSVHeader is the first row of every CSV recording and export
+var recordCSVHeader = []string{"time", "feed_id", "feed_name", "event", "data"}
+
+// recordConfig is where and how recordings and exports are written
+type recordConfig struct {
+	Dir    string
+	Format string
+	// RotateBytes starts a new file once a recording reaches it; 0 never rotates
+	RotateBytes int64
+}
+
+// loadRecordConfig reads TURBOSTREAM_RECORD_DIR (falling back to the report
+// directory), TURBOSTREAM_RECORD_FORMAT and TURBOSTREAM_RECORD_ROTATE_MB
+func loadRecordConfig(reportDir string) recordConfig {
+	dir := getenvDefault("TURBOSTREAM_RECORD_DIR", reportDir)
+	if dir == "" {
+		dir = "."
+	}
+	return recordConfig{
+		Dir:         dir,
+		Format:      parseRecordFormat(os.Getenv("TURBOSTREAM_RECORD_FORMAT")),
+		RotateBytes: parseRecordRotate(os.Getenv("TURBOSTREAM_RECORD_ROTATE_MB")),
+	}
+}
+
+// parseRecordFormat accepts jsonl or csv; anything else records JSONL
+func parseRecordFormat(val string) string {
+	if strings.EqualFold(strings.TrimSpace(val), recordFormatCSV) {
+		return recordFormatCSV
+	}
+	return recordFormatJSONL
+}
+
+// parseRecordRotate reads the rotation size in megabytes; 0 turns rotation
+// off and anything unparsable keeps the default
+func parseRecordRotate(val string) int64 {
+	mb, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || mb < 0 {
+		mb = defaultRecordRotateMB
+	}
+	return int64(mb) << 20
+}
+
+// encodeEntry renders an entry as a JSONL line or a CSV row. JSON payloads
+// are embedded as JSON, anything else as a string.
+func encodeEntry(format string, e feedEntry) ([]byte, error) {
+	ts := e.Time.UTC().Format(time.RFC3339Nano)
+	if format == recordFormatCSV {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write([]string{ts, e.FeedID, e.FeedName, e.Event, e.Data}); err != nil {
+			return nil, err
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	}
+	var data interface{} = e.Data
+	if json.Valid([]byte(e.Data)) {
+		data = json.RawMessage(e.Data)
+	}
+	line, err := json.Marshal(struct {
+		Time     string      `json:"time"`
+		FeedID   string      `json:"feedId"`
+		FeedName string      `json:"feedName"`
+		Event    string      `json:"event,omitempty"`
+		Data     interface{} `json:"data"`
+	}{ts, e.FeedID, e.FeedName, e.Event, data})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// fileHeader is what starts a new recording or export file
+func fileHeader(format string) []byte {
+	if format != recordFormatCSV {
+		return nil
+	}
+	return []byte(strings.Join(recordCSVHeader, ",") + "\n")
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// recordPath names a feed's recording or export file, e.g.
+// turbostream-btc-trades-20250101-120000.jsonl; part 2 and later get a suffix
+func recordPath(cfg recordConfig, feedName, kind string, started time.Time, part int) string {
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(feedName), "-"), "-")
+	if slug == "" {
+		slug = "feed"
+	}
+	name := fmt.Sprintf("turbostream-%s-%s%s", slug, kind, started.Format("20060102-150405"))
+	if part > 1 {
+		name += fmt.Sprintf("-%d", part)
+	}
+	return filepath.Join(cfg.Dir, name+"."+cfg.Format)
+}
+
+// recorder appends a feed's live entries to disk, rotating files by size.
+// It is shared across model copies and only used from Update.
+type recorder struct {
+	cfg      recordConfig
+	feedName string
+	started  time.Time
+	file     *os.File
+	path     string
+	size     int64
+	part     int
+	// Entries counts what was written across all parts
+	Entries int
+}
+
+// startRecorder opens the first file of a feed's recording
+func startRecorder(cfg recordConfig, feedName string) (*recorder, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	r := &recorder{cfg: cfg, feedName: feedName, started: time.Now()}
+	if err := r.rotate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Path is the file being written
+func (r *recorder) Path() string {
+	return r.path
+}
+
+// rotate closes the current file, if any, and opens the next part. A
+// recording restarted within the same second appends to the earlier file.
+func (r *recorder) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+	}
+	r.part++
+	r.path = recordPath(r.cfg, r.feedName, "", r.started, r.part)
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	if r.size > 0 {
+		return nil
+	}
+	n, err := f.Write(fileHeader(r.cfg.Format))
+	r.size += int64(n)
+	return err
+}
+
+// Write appends an entry, first starting a new file if it would overflow the current one
+func (r *recorder) Write(e feedEntry) error {
+	line, err := encodeEntry(r.cfg.Format, e)
+	if err != nil {
+		return err
+	}
+	// Never rotate away from a file that holds no entries yet
+	if r.cfg.RotateBytes > 0 && r.size > int64(len(fileHeader(r.cfg.Format))) && r.size+int64(len(line)) > r.cfg.RotateBytes {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		return err
+	}
+	r.Entries++
+	return nil
+}
+
+// Close finishes the recording
+func (r *recorder) Close() error {
+	return r.file.Close()
+}
+
+// exportMsg is sent when a stream buffer export was written
+type exportMsg struct {
+	Path    string
+	Entries int
+	Err     error
+}
+
+// exportEntriesCmd writes a feed's stream buffer, which is newest first, to a
+// new file oldest first
+func exportEntriesCmd(cfg recordConfig, feedName string, entries []feedEntry) tea.Cmd {
+	return func() tea.Msg {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return exportMsg{Err: err}
+		}
+		buf := bytes.NewBuffer(fileHeader(cfg.Format))
+		for i := len(entries) - 1; i >= 0; i-- {
+			line, err := encodeEntry(cfg.Format, entries[i])
+			if err != nil {
+				return exportMsg{Err: err}
+			}
+			buf.Write(line)
+		}
+		path := recordPath(cfg, feedName, "export-", time.Now(), 1)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return exportMsg{Err: err}
+		}
+		return exportMsg{Path: path, Entries: len(entries)}
+	}
+}
+
+// stopRecording closes a feed's recording, if one is running
+func (m model) stopRecording(feedID string) {
+	rec := m.recordings[feedID]
+	if rec == nil {
+		return
+	}
+	delete(m.recordings, feedID)
+	_ = rec.Close()
+}
+
+// exportFeedEntries exports a feed's stream buffer as it is now
+func (m model) exportFeedEntries(feedID, feedName string) tea.Cmd {
+	entries := m.feedEntries[feedID]
+	if len(entries) == 0 {
+		return func() tea.Msg { return exportMsg{Err: errors.New("no entries to export yet")} }
+	}
+	return exportEntriesCmd(m.recordConfig, feedName, entries)
+}
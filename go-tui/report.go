@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
+)
+
+const (
+	// digestQuestion is the prompt used for each feed's report digest
+	digestQuestion = "Give a concise digest (3-5 bullet points) of the recent data: key values, trends, and anything unusual."
+	// digestCacheTTL is how long a digest is reused if the feed's data has not changed
+	digestCacheTTL = 5 * time.Minute
+	// digestSpacing spaces LLM calls out so a report does not burst the provider rate limit
+	digestSpacing = 1 * time.Second
+	// digestTimeout bounds a single digest request
+	digestTimeout = 30 * time.Second
+)
+
+// reportMsg is sent when a combined report finished generating
+type reportMsg struct {
+	Path  string
+	Feeds int
+	Err   error
+}
+
+// reportFeed is a snapshot of everything the report needs for one feed,
+// captured on the UI goroutine before the command runs.
+type reportFeed struct {
+	Feed       client.Feed
+	Metrics    FeedMetrics
+	HasMetrics bool
+	Entries    int
+	DataSig    string
+}
+
+// digestEntry is a cached LLM digest for a feed
+type digestEntry struct {
+	Answer    string
+	Provider  string
+	DataSig   string
+	CreatedAt time.Time
+}
+
+// digestCache stores per-feed digests so repeated reports don't re-query the LLM
+// for feeds whose data has not changed. It is shared across model copies.
+type digestCache struct {
+	mu      sync.Mutex
+	entries map[string]digestEntry
+}
+
+func newDigestCache() *digestCache {
+	return &digestCache{entries: make(map[string]digestEntry)}
+}
+
+// Get returns a fresh digest for the feed if the data signature still matches
+func (c *digestCache) Get(feedID, dataSig string) (digestEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[feedID]
+	if !ok || e.DataSig != dataSig || time.Since(e.CreatedAt) > digestCacheTTL {
+		return digestEntry{}, false
+	}
+	return e, true
+}
+
+func (c *digestCache) Put(feedID string, e digestEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[feedID] = e
+}
+
+// reportSnapshot collects the subscribed feeds and their current metrics
+func (m model) reportSnapshot() []reportFeed {
+	var out []reportFeed
+	for _, f := range m.feeds {
+		if !m.isSubscribed(f.ID) {
+			continue
+		}
+		rf := reportFeed{Feed: f}
+		if fm := m.metricsCollector.GetFeedMetrics(f.ID); fm != nil {
+			rf.Metrics = *fm
+			rf.HasMetrics = true
+		}
+		entries := m.feedEntries[f.ID]
+		rf.Entries = len(entries)
+		if len(entries) > 0 {
+			rf.DataSig = fmt.Sprintf("%d@%d", len(entries), entries[0].Time.UnixNano())
+		}
+		out = append(out, rf)
+	}
+	return out
+}
+
+// generateReportCmd runs one LLM digest per subscribed feed (reusing cached
+// digests where possible) and writes a combined markdown report to disk.
+func generateReportCmd(c *client.Client, cache *digestCache, feeds []reportFeed, dir string) tea.Cmd {
+	return func() tea.Msg {
+		digests := make(map[string]digestEntry, len(feeds))
+		digestErrs := make(map[string]error)
+		queried := 0
+		for _, rf := range feeds {
+			if rf.Entries == 0 {
+				continue
+			}
+			if cached, ok := cache.Get(rf.Feed.ID, rf.DataSig); ok {
+				digests[rf.Feed.ID] = cached
+				continue
+			}
+			if queried > 0 {
+				time.Sleep(digestSpacing)
+			}
+			queried++
+			ctx, cancel := context.WithTimeout(context.Background(), digestTimeout)
+			ans, err := c.Query(ctx, rf.Feed.ID, digestQuestion, rf.Feed.SystemPrompt)
+			cancel()
+			if err != nil {
+				digestErrs[rf.Feed.ID] = err
+				continue
+			}
+			entry := digestEntry{Answer: ans.Answer, Provider: ans.Provider, DataSig: rf.DataSig, CreatedAt: time.Now()}
+			cache.Put(rf.Feed.ID, entry)
+			digests[rf.Feed.ID] = entry
+		}
+
+		report := renderReport(feeds, digests, digestErrs, time.Now())
+
+		if dir == "" {
+			dir = "."
+		}
+		path := filepath.Join(dir, fmt.Sprintf("turbostream-report-%s.md", time.Now().Format("20060102-150405")))
+		if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+			return reportMsg{Err: err}
+		}
+		return reportMsg{Path: path, Feeds: len(feeds)}
+	}
+}
+
+// renderReport builds the markdown report
+func renderReport(feeds []reportFeed, digests map[string]digestEntry, digestErrs map[string]error, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("# TurboStream Feed Report\n\n")
+	b.WriteString(fmt.Sprintf("Generated %s — %d subscribed feed(s)\n\n", now.Format("2006-01-02 15:04:05 MST"), len(feeds)))
+
+	if len(feeds) == 0 {
+		b.WriteString("_No subscribed feeds._\n")
+		return b.String()
+	}
+
+	// Overview table
+	b.WriteString("## Overview\n\n")
+	b.WriteString("| Feed | Status | Msg/s | Messages | Dropped | Context items | LLM requests |\n")
+	b.WriteString("|------|--------|-------|----------|---------|---------------|--------------|\n")
+	for _, rf := range feeds {
+		fm := rf.Metrics
+		status := "disconnected"
+		if fm.WSConnected {
+			status = "connected"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %.1f | %d | %d | %d | %d |\n",
+			escapeMarkdownCell(rf.Feed.Name), status, fm.MessagesPerSecond10s, fm.MessagesReceivedTotal,
+			fm.MessagesDroppedTotal, fm.CacheItemsCurrent, fm.LLMRequestsTotal))
+	}
+	b.WriteString("\n")
+
+	for _, rf := range feeds {
+		fm := rf.Metrics
+		b.WriteString(fmt.Sprintf("## %s\n\n", rf.Feed.Name))
+		if rf.Feed.Category != "" {
+			b.WriteString(fmt.Sprintf("Category: %s\n\n", rf.Feed.Category))
+		}
+
+		b.WriteString("### Health\n\n")
+		if !rf.HasMetrics {
+			b.WriteString("_No metrics collected yet._\n\n")
+		} else {
+			b.WriteString(fmt.Sprintf("- Rate: %.1f msg/s (inst %.1f), %.1f KB/s\n", fm.MessagesPerSecond10s, fm.MessagesPerSecond1s, fm.BytesPerSecond10s/1024))
+			b.WriteString(fmt.Sprintf("- Last message: %.0fs ago\n", fm.LastMessageAgeSeconds))
+			b.WriteString(fmt.Sprintf("- Reconnects: %d, drop rate: %.1f%%\n", fm.ReconnectsTotal, fm.DropRatePercent))
+			b.WriteString(fmt.Sprintf("- Payload: avg %.0f B, max %d B\n", fm.PayloadSizeAvgBytes, fm.PayloadSizeMaxBytes))
+			b.WriteString(fmt.Sprintf("- LLM: %d requests, %d errors, avg gen %.0fms\n\n", fm.LLMRequestsTotal, fm.LLMErrorsTotal, fm.GenerationTimeAvgMs))
+		}
+
+		b.WriteString("### AI Digest\n\n")
+		switch {
+		case rf.Entries == 0:
+			b.WriteString("_No data received yet, digest skipped._\n\n")
+		case digestErrs[rf.Feed.ID] != nil:
+			b.WriteString(fmt.Sprintf("_Digest failed: %s_\n\n", client.FriendlyError(digestErrs[rf.Feed.ID])))
+		default:
+			d := digests[rf.Feed.ID]
+			b.WriteString(strings.TrimSpace(d.Answer))
+			b.WriteString(fmt.Sprintf("\n\n_— %s, %s_\n\n", d.Provider, d.CreatedAt.Format("15:04:05")))
+		}
+	}
+	return b.String()
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
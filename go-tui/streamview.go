@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// defaultStreamBuffer is how many entries each feed keeps for scrollback
+	defaultStreamBuffer = 500
+	minStreamBuffer     = 10
+	maxStreamBuffer     = 10000
+	// myFeedsStreamHeight is the height of the Live Stream box on My Feeds
+	myFeedsStreamHeight = 25
+)
+
+// parseStreamBuffer reads the per-feed scrollback size, clamped to 10..10000;
+// anything unparsable keeps the default
+func parseStreamBuffer(val string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n <= 0 {
+		return defaultStreamBuffer
+	}
+	if n < minStreamBuffer {
+		return minStreamBuffer
+	}
+	if n > maxStreamBuffer {
+		return maxStreamBuffer
+	}
+	return n
+}
+
+// streamView is the scroll, pause and search state of the live stream of the
+// feed it was last used on. Entries are indexed newest first, like the buffer.
+type streamView struct {
+	feedID string
+	// paused freezes the view on a snapshot while the buffer keeps filling
+	paused bool
+	frozen []feedEntry
+	missed int // entries that arrived while paused
+	// selected is the entry under the cursor, offset the first one shown
+	selected int
+	offset   int
+	expanded bool // selected entry shown as pretty-printed JSON (Feed Detail)
+	search   textinput.Model
+	// searching means the search input has focus; its value filters the entries
+	searching bool
+}
+
+func newStreamView() streamView {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+	search.CharLimit = 200
+	return streamView{search: search}
+}
+
+// query is the search filter in effect
+func (sv streamView) query() string {
+	return strings.TrimSpace(sv.search.Value())
+}
+
+// entries is what the view shows of a feed's buffer: the snapshot while
+// paused, filtered by the search query
+func (sv streamView) entries(live []feedEntry) []feedEntry {
+	src := live
+	if sv.paused {
+		src = sv.frozen
+	}
+	q := strings.ToLower(sv.query())
+	if q == "" {
+		return src
+	}
+	var out []feedEntry
+	for _, e := range src {
+		if strings.Contains(strings.ToLower(e.Data), q) || strings.Contains(strings.ToLower(e.Event), q) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// pause freezes the view on the buffer as it is now
+func (sv *streamView) pause(live []feedEntry) {
+	if sv.paused {
+		return
+	}
+	sv.paused = true
+	sv.frozen = append([]feedEntry(nil), live...)
+	sv.missed = 0
+}
+
+// resume goes back to following the newest entries
+func (sv *streamView) resume() {
+	sv.paused = false
+	sv.frozen = nil
+	sv.missed = 0
+	sv.selected, sv.offset = 0, 0
+	sv.expanded = false
+}
+
+// move puts the cursor on entry n of total, scrolling so it stays within rows
+func (sv *streamView) move(n, total, rows int) {
+	if n > total-1 {
+		n = total - 1
+	}
+	if n < 0 {
+		n = 0
+	}
+	sv.selected = n
+	if sv.selected < sv.offset {
+		sv.offset = sv.selected
+	}
+	if sv.selected >= sv.offset+rows {
+		sv.offset = sv.selected - rows + 1
+	}
+}
+
+// streamViewFor returns the view state for a feed; a feed it wasn't used on
+// yet starts out live
+func (m model) streamViewFor(feedID string) streamView {
+	if m.stream.feedID == feedID {
+		return m.stream
+	}
+	sv := newStreamView()
+	sv.feedID = feedID
+	return sv
+}
+
+// streamFeed is the feed whose live stream is on screen
+func (m model) streamFeed() string {
+	if m.screen == screenFeedDetail && m.selectedFeed != nil {
+		return m.selectedFeed.ID
+	}
+	if m.screen == screenFeeds && len(m.feeds) > 0 && m.selectedIdx < len(m.feeds) {
+		return m.feeds[m.selectedIdx].ID
+	}
+	return ""
+}
+
+// streamRows is how many entries fit in the live stream on the current screen
+func (m model) streamRows() int {
+	if m.screen == screenFeedDetail {
+		rows := m.termHeight - 21
+		if m.stream.expanded {
+			rows /= 3
+		}
+		if rows < 3 {
+			rows = 3
+		}
+		return rows
+	}
+	// My Feeds' stream box minus its borders and the status line
+	return myFeedsStreamHeight - 4
+}
+
+// updateStreamSearch edits the search query; Enter keeps it, Esc clears it
+func (m model) updateStreamSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.stream.searching = false
+		m.stream.search.Blur()
+		return m, nil
+	case "esc":
+		m.stream.searching = false
+		m.stream.search.Blur()
+		m.stream.search.SetValue("")
+		m.stream.selected, m.stream.offset = 0, 0
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.stream.search, cmd = m.stream.search.Update(msg)
+	m.stream.selected, m.stream.offset = 0, 0
+	m.stream.expanded = false
+	return m, cmd
+}
+
+// updateStream handles the live stream keys on My Feeds and Feed Detail:
+// space pauses, / searches, PgUp/PgDn/Home/End scroll, and on Feed Detail
+// ↑/↓ select an entry and Enter expands it. Moving through the entries
+// pauses the stream so they stay put.
+func (m model) updateStream(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	feedID := m.streamFeed()
+	if feedID == "" {
+		return m, nil, false
+	}
+	detail := m.screen == screenFeedDetail
+	key := msg.String()
+	switch key {
+	case " ", "/", "pgup", "pgdown", "home", "end":
+	case "up", "k", "down", "j", "enter":
+		if !detail {
+			return m, nil, false
+		}
+	case "esc":
+		// Collapse or clear the search before Esc leaves the screen
+		if m.stream.feedID != feedID || (!m.stream.expanded && m.stream.query() == "") {
+			return m, nil, false
+		}
+	default:
+		return m, nil, false
+	}
+
+	m.stream = m.streamViewFor(feedID)
+	sv := &m.stream
+	live := m.feedEntries[feedID]
+	rows := m.streamRows()
+	switch key {
+	case " ":
+		if sv.paused {
+			sv.resume()
+			m.statusMessage = "Live stream resumed"
+		} else {
+			sv.pause(live)
+			m.statusMessage = "Live stream paused (space: resume)"
+		}
+		return m, nil, true
+	case "/":
+		sv.searching = true
+		return m, sv.search.Focus(), true
+	case "esc":
+		if sv.expanded {
+			sv.expanded = false
+		} else {
+			sv.search.SetValue("")
+			sv.selected, sv.offset = 0, 0
+		}
+		return m, nil, true
+	case "enter":
+		sv.pause(live)
+		sv.expanded = !sv.expanded
+		// The list shrinks while an entry is expanded
+		sv.move(sv.selected, len(sv.entries(live)), m.streamRows())
+		return m, nil, true
+	}
+
+	sv.pause(live)
+	total := len(sv.entries(live))
+	switch key {
+	case "up", "k":
+		sv.move(sv.selected-1, total, rows)
+	case "down", "j":
+		sv.move(sv.selected+1, total, rows)
+	case "pgup":
+		sv.offset -= rows
+		sv.move(sv.selected-rows, total, rows)
+	case "pgdown":
+		sv.offset += rows
+		sv.move(sv.selected+rows, total, rows)
+	case "home":
+		sv.move(0, total, rows)
+	case "end":
+		sv.move(total-1, total, rows)
+	}
+	if sv.offset > total-rows {
+		sv.offset = total - rows
+	}
+	if sv.offset < 0 {
+		sv.offset = 0
+	}
+	return m, nil, true
+}
+
+// renderStreamEntries renders the rows of entries a stream view shows, a
+// line each; cursor marks the selected entry
+func renderStreamEntries(sv streamView, entries []feedEntry, rows, dataWidth int, cursor bool) string {
+	b := strings.Builder{}
+	end := sv.offset + rows
+	if end > len(entries) {
+		end = len(entries)
+	}
+	for i := sv.offset; i < end; i++ {
+		e := entries[i]
+		line := fmt.Sprintf("%s %s", e.Time.Format("15:04:05"), truncate(e.Data, dataWidth))
+		if cursor {
+			if i == sv.selected {
+				line = lipgloss.NewStyle().Bold(true).Foreground(brightCyanColor).Render("› " + line)
+			} else {
+				line = "  " + line
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// renderStreamStatus is the stream's status line: live or paused, the shown
+// range and the search, or the search input while it has focus
+func renderStreamStatus(sv streamView, shown, buffered, rows int) string {
+	if sv.searching {
+		return sv.search.View()
+	}
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	var state string
+	if sv.paused {
+		state = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF6B6B")).Render("⏸ PAUSED")
+		if sv.missed > 0 {
+			state += dim.Render(fmt.Sprintf(" +%d new", sv.missed))
+		}
+	} else {
+		state = lipgloss.NewStyle().Foreground(greenColor).Render("▶ live")
+	}
+	info := fmt.Sprintf(" %d buffered", buffered)
+	if shown > 0 {
+		end := sv.offset + rows
+		if end > shown {
+			end = shown
+		}
+		info = fmt.Sprintf(" %d-%d of %d", sv.offset+1, end, shown)
+	}
+	if q := sv.query(); q != "" {
+		info += fmt.Sprintf(" matching %q", q)
+	}
+	if sv.paused {
+		info += " · space: resume"
+	} else {
+		info += " · space: pause, /: search"
+	}
+	return state + dim.Render(info)
+}
+
+// prettyEntry indents an entry's JSON data; anything else is returned as is
+func prettyEntry(e feedEntry) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(e.Data), "", "  "); err != nil {
+		return e.Data
+	}
+	return buf.String()
+}
+
+// renderExpandedEntry shows the selected entry pretty-printed, cut to fit
+// within height lines of width columns
+func renderExpandedEntry(e feedEntry, width, height int) string {
+	dim := lipgloss.NewStyle().Foreground(dimCyanColor)
+	header := e.Time.Format("15:04:05.000")
+	if e.Event != "" {
+		header += " " + e.Event
+	}
+	b := strings.Builder{}
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(dimCyanColor).Render(header + " (Enter: collapse)"))
+	b.WriteString("\n")
+	lines := strings.Split(prettyEntry(e), "\n")
+	if height < 2 {
+		height = 2
+	}
+	shown := lines
+	if len(lines) > height {
+		shown = lines[:height-1]
+	}
+	for _, line := range shown {
+		b.WriteString(truncate(line, width) + "\n")
+	}
+	if len(shown) < len(lines) {
+		b.WriteString(dim.Render(fmt.Sprintf("... %d more lines", len(lines)-len(shown))) + "\n")
+	}
+	return b.String()
+}
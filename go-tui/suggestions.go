@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// suggestionRefreshInterval controls how often inferred schemas are re-checked
+	suggestionRefreshInterval = 5 * time.Second
+	// maxSuggestions caps the number of example questions shown in the AI panel
+	maxSuggestions = 5
+	// minSuggestions is the number of questions we always try to offer
+	minSuggestions = 3
+	// schemaSampleSize is how many recent entries are inspected per feed
+	schemaSampleSize = 20
+	// schemaMaxDepth limits how deep nested objects are flattened
+	schemaMaxDepth = 2
+)
+
+// suggestionTickMsg triggers a background refresh of schemas and AI suggestions
+type suggestionTickMsg struct{}
+
+// schemaField describes a single field observed in a feed's JSON payloads
+type schemaField struct {
+	Path string // dotted path, e.g. "data.price"
+	Kind string // number, string, bool, array, object
+}
+
+// feedSchema is the inferred shape of a feed's recent messages
+type feedSchema struct {
+	Fields []schemaField
+}
+
+// Signature returns a stable string used to detect schema changes
+func (s feedSchema) Signature() string {
+	parts := make([]string, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		parts = append(parts, f.Path+":"+f.Kind)
+	}
+	return strings.Join(parts, ",")
+}
+
+// fieldsOfKind returns the fields matching the given kind, in schema order
+func (s feedSchema) fieldsOfKind(kind string) []schemaField {
+	var out []schemaField
+	for _, f := range s.Fields {
+		if f.Kind == kind {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// inferSchema builds a lightweight schema from the most recent feed entries.
+// Entries that are not JSON objects are ignored. When a field is seen with
+// several types, the most frequent one wins.
+func inferSchema(entries []feedEntry) feedSchema {
+	counts := map[string]map[string]int{}
+	limit := len(entries)
+	if limit > schemaSampleSize {
+		limit = schemaSampleSize
+	}
+	for i := 0; i < limit; i++ {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(entries[i].Data), &obj); err != nil {
+			continue
+		}
+		collectFields("", obj, 0, counts)
+	}
+
+	schema := feedSchema{}
+	for path, kinds := range counts {
+		best, bestCount := "", 0
+		for kind, n := range kinds {
+			if n > bestCount || (n == bestCount && kind < best) {
+				best, bestCount = kind, n
+			}
+		}
+		schema.Fields = append(schema.Fields, schemaField{Path: path, Kind: best})
+	}
+	sort.Slice(schema.Fields, func(i, j int) bool { return schema.Fields[i].Path < schema.Fields[j].Path })
+	return schema
+}
+
+func collectFields(prefix string, obj map[string]interface{}, depth int, counts map[string]map[string]int) {
+	for key, val := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		kind := jsonKind(val)
+		if kind == "" {
+			continue
+		}
+		if kind == "object" && depth < schemaMaxDepth {
+			collectFields(path, val.(map[string]interface{}), depth+1, counts)
+			continue
+		}
+		if counts[path] == nil {
+			counts[path] = map[string]int{}
+		}
+		counts[path][kind]++
+	}
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// isTimeLikeField reports whether a field is most likely a timestamp or identifier,
+// which rarely makes for an interesting question.
+func isTimeLikeField(path string) bool {
+	name := strings.ToLower(path)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	switch name {
+	case "id", "_id", "ts", "t", "time", "timestamp", "date", "datetime", "created", "updated", "createdat", "updatedat", "_timestamp":
+		return true
+	}
+	return strings.HasSuffix(name, "_id") || strings.HasSuffix(name, "_at") || strings.HasSuffix(name, "time")
+}
+
+// generateSuggestions proposes example questions for a feed from its inferred
+// schema using fixed templates. No LLM call is involved.
+func generateSuggestions(schema feedSchema) []string {
+	var numbers, strs, bools []string
+	for _, f := range schema.fieldsOfKind("number") {
+		if !isTimeLikeField(f.Path) {
+			numbers = append(numbers, f.Path)
+		}
+	}
+	for _, f := range schema.fieldsOfKind("string") {
+		if !isTimeLikeField(f.Path) {
+			strs = append(strs, f.Path)
+		}
+	}
+	for _, f := range schema.fieldsOfKind("bool") {
+		bools = append(bools, f.Path)
+	}
+
+	var out []string
+	add := func(q string) {
+		if len(out) < maxSuggestions {
+			out = append(out, q)
+		}
+	}
+
+	if len(numbers) > 0 {
+		add(fmt.Sprintf("What's the highest %s in the recent data?", numbers[0]))
+		add(fmt.Sprintf("Is %s trending up or down?", numbers[0]))
+	}
+	if len(strs) > 0 {
+		add(fmt.Sprintf("Which %s appears most often?", strs[0]))
+	}
+	if len(numbers) > 1 {
+		add(fmt.Sprintf("Is there a relationship between %s and %s?", numbers[0], numbers[1]))
+		add(fmt.Sprintf("What's the average %s?", numbers[1]))
+	}
+	if len(bools) > 0 {
+		add(fmt.Sprintf("How often is %s true?", bools[0]))
+	}
+	if len(strs) > 0 && len(numbers) > 0 {
+		add(fmt.Sprintf("Which %s has the largest %s?", strs[0], numbers[0]))
+	}
+
+	// Generic fallbacks for feeds with few usable fields
+	for _, q := range []string{
+		"Summarize the latest events.",
+		"Are there any anomalies in the recent data?",
+		"What changed in the last few messages?",
+	} {
+		if len(out) >= minSuggestions {
+			break
+		}
+		add(q)
+	}
+	return out
+}
+
+// suggestionTickCmd schedules the next background suggestion refresh
+func suggestionTickCmd() tea.Cmd {
+	return tea.Tick(suggestionRefreshInterval, func(t time.Time) tea.Msg { return suggestionTickMsg{} })
+}
+
+// refreshSuggestions re-infers schemas for feeds with data and regenerates
+// suggestions for those whose schema changed since the last refresh.
+func (m *model) refreshSuggestions() {
+	for feedID, entries := range m.feedEntries {
+		if len(entries) == 0 {
+			continue
+		}
+		schema := inferSchema(entries)
+		sig := schema.Signature()
+		if existing, ok := m.aiSchemaSigs[feedID]; ok && existing == sig {
+			continue
+		}
+		m.aiSchemaSigs[feedID] = sig
+		m.aiSuggestions[feedID] = generateSuggestions(schema)
+	}
+}
+
+// applySuggestion fills the feed's AI prompt with the n-th suggestion (0-based)
+// and focuses it so the user can tweak or send it.
+func (m *model) applySuggestion(feedID string, n int) bool {
+	suggestions := m.aiSuggestions[feedID]
+	if n < 0 || n >= len(suggestions) {
+		return false
+	}
+	prompt := m.getOrCreatePrompt(feedID)
+	prompt.SetValue(suggestions[n])
+	prompt.Focus()
+	m.aiPrompts[feedID] = prompt
+	m.aiFocused = true
+	return true
+}
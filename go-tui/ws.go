@@ -2,237 +2,137 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/turboline-ai/turbostream/go-tui/pkg/api"
-	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
+	"github.com/turboline-ai/turbostream/go-tui/pkg/client"
 )
 
-type wsEnvelope struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload,omitempty"`
-}
+// wsDialTimeout bounds the first connection; reconnects are the stream's own
+const wsDialTimeout = 15 * time.Second
 
-// wsClient wraps the websocket connection and streams messages into the Bubble Tea loop.
+// wsClient feeds a client.Stream's events into the Bubble Tea loop.
 type wsClient struct {
-	conn     *websocket.Conn
-	ctx      context.Context
-	cancel   context.CancelFunc
-	incoming chan tea.Msg
-	userID   string
+	stream *client.Stream
 }
 
-func dialWS(url, userID, userAgent string) (*wsClient, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{
-		Subprotocols: []string{},
+// dialWS connects and registers the user; the token, asked for on every
+// (re)connect, also authenticates the connection, which subscribing to
+// private feeds requires. Dropped connections are redialled and resubscribed.
+func dialWS(url, userID string, token func() string, userAgent string, batchInterval time.Duration) (*wsClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), wsDialTimeout)
+	defer cancel()
+	stream, err := client.DialStream(ctx, url, client.StreamOptions{
+		UserID:        userID,
+		TokenFunc:     token,
+		UserAgent:     userAgent,
+		BatchInterval: batchInterval,
+		Reconnect:     true,
 	})
 	if err != nil {
-		cancel()
 		return nil, err
 	}
-
-	client := &wsClient{
-		conn:     conn,
-		ctx:      ctx,
-		cancel:   cancel,
-		incoming: make(chan tea.Msg, 32),
-		userID:   userID,
-	}
-
-	// Register the user.
-	regPayload := map[string]interface{}{
-		"userId":    userID,
-		"userAgent": userAgent,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	}
-	if err := wsjson.Write(ctx, conn, map[string]interface{}{
-		"type":    "register-user",
-		"payload": regPayload,
-	}); err != nil {
-		if closeErr := conn.Close(websocket.StatusInternalError, "register failed"); closeErr != nil {
-			log.Printf("error closing connection after registration failure: %v", closeErr)
-		}
-		cancel()
-		return nil, fmt.Errorf("register-user failed: %w", err)
-	}
-
-	go client.readLoop()
-	return client, nil
-}
-
-func (c *wsClient) readLoop() {
-	defer func() {
-		close(c.incoming)
-	}()
-
-	for {
-		var env wsEnvelope
-		if err := wsjson.Read(c.ctx, c.conn, &env); err != nil {
-			c.incoming <- wsStatusMsg{Status: "disconnected", Err: err}
-			return
-		}
-
-		switch env.Type {
-		case "registration-success":
-			c.incoming <- wsStatusMsg{Status: "connected", Err: nil}
-		case "feed-data":
-			var payload struct {
-				FeedID    string          `json:"feedId"`
-				FeedName  string          `json:"feedName"`
-				EventName string          `json:"eventName"`
-				Data      json.RawMessage `json:"data"`
-				Timestamp string          `json:"timestamp"`
-			}
-			if err := json.Unmarshal(env.Payload, &payload); err == nil {
-				ts, _ := time.Parse(time.RFC3339, payload.Timestamp)
-				c.incoming <- feedDataMsg{
-					FeedID:    payload.FeedID,
-					FeedName:  payload.FeedName,
-					EventName: payload.EventName,
-					Data:      string(payload.Data),
-					Time:      ts,
-				}
-			} else {
-				// Report packet dropped due to parse error
-				c.incoming <- packetDroppedMsg{
-					FeedID: payload.FeedID,
-					Reason: "json_parse_error",
-				}
-			}
-		case "token-usage-update":
-			var usage api.TokenUsage
-			if err := json.Unmarshal(env.Payload, &usage); err == nil {
-				c.incoming <- tokenUsageUpdateMsg{Usage: &usage}
-			}
-		case "subscription-success", "unsubscription-success":
-			// No-op; REST already returns status.
-		case "llm-response":
-			var payload struct {
-				RequestID  string `json:"requestId"`
-				Answer     string `json:"answer"`
-				Provider   string `json:"provider"`
-				DurationMs int64  `json:"durationMs"`
-			}
-			if err := json.Unmarshal(env.Payload, &payload); err == nil {
-				c.incoming <- aiResponseMsg{
-					RequestID: payload.RequestID,
-					Answer:    payload.Answer,
-					Provider:  payload.Provider,
-					Duration:  payload.DurationMs,
-				}
-			}
-		case "llm-token":
-			var payload struct {
-				RequestID string `json:"requestId"`
-				Token     string `json:"token"`
-			}
-			if err := json.Unmarshal(env.Payload, &payload); err == nil {
-				c.incoming <- aiTokenMsg{
-					RequestID: payload.RequestID,
-					Token:     payload.Token,
-				}
-			}
-		case "llm-complete":
-			var payload struct {
-				RequestID  string `json:"requestId"`
-				Answer     string `json:"answer"`
-				Provider   string `json:"provider"`
-				DurationMs int64  `json:"durationMs"`
-			}
-			if err := json.Unmarshal(env.Payload, &payload); err == nil {
-				c.incoming <- aiResponseMsg{
-					RequestID: payload.RequestID,
-					Answer:    payload.Answer,
-					Provider:  payload.Provider,
-					Duration:  payload.DurationMs,
-				}
-			}
-		case "llm-error":
-			var payload struct {
-				RequestID string `json:"requestId"`
-				Error     string `json:"error"`
-			}
-			if err := json.Unmarshal(env.Payload, &payload); err == nil {
-				c.incoming <- aiResponseMsg{
-					RequestID: payload.RequestID,
-					Err:       errors.New(payload.Error),
-				}
-			}
-		default:
-			// unknown types are ignored but logged in status.
-		}
-	}
+	return &wsClient{stream: stream}, nil
 }
 
+// ListenCmd waits for the stream's next event and hands it to the app as a message
 func (c *wsClient) ListenCmd() tea.Cmd {
 	return func() tea.Msg {
-		msg, ok := <-c.incoming
+		ev, ok := <-c.stream.Events()
 		if !ok {
 			return wsStatusMsg{Status: "disconnected", Err: errors.New("ws closed")}
 		}
-		return msg
+		return wsMsg(ev)
+	}
+}
+
+// wsMsg converts a stream event into the app's message for it
+func wsMsg(ev client.Event) tea.Msg {
+	switch ev := ev.(type) {
+	case client.StatusEvent:
+		return wsStatusMsg{Status: ev.Status, Err: ev.Err}
+	case client.FeedData:
+		return feedDataMsg{
+			FeedID:    ev.FeedID,
+			FeedName:  ev.FeedName,
+			EventName: ev.EventName,
+			Data:      string(ev.Data),
+			Time:      ev.Time,
+		}
+	case client.PacketDropped:
+		return packetDroppedMsg(ev)
+	case client.FeedHealth:
+		return feedHealthMsg(ev)
+	case client.FeedStatus:
+		return feedStatusMsg(ev)
+	case client.TokenUsageUpdate:
+		return tokenUsageUpdateMsg{Usage: &ev.Usage}
+	case client.SubscriptionExpired:
+		return subscriptionExpiredMsg{FeedID: ev.FeedID, Revoked: ev.Revoked}
+	case client.SubscriptionDenied:
+		return subscriptionDeniedMsg{FeedID: ev.FeedID, Reason: ev.Reason}
+	case client.AccountDeleted:
+		return accountDeletedMsg(ev)
+	case client.LLMToken:
+		return aiTokenMsg(ev)
+	case client.LLMProgress:
+		return aiProgressMsg(ev)
+	case client.LLMResponse:
+		return aiResponseMsg{
+			RequestID:          ev.RequestID,
+			Answer:             ev.Answer,
+			Provider:           ev.Provider,
+			Duration:           ev.DurationMs,
+			PromptTokens:       ev.PromptTokens,
+			CompletionTokens:   ev.CompletionTokens,
+			ContextEntries:     ev.ContextEntries,
+			ContextUtilization: ev.ContextUtilization,
+			Model:              ev.Model,
+			FirstTokenMs:       ev.FirstTokenMs,
+			Cancelled:          ev.Cancelled,
+			Err:                ev.Err,
+		}
+	case client.ConnectionStats:
+		return connStatsMsg(ev)
+	case client.RateLimited:
+		return rateLimitedMsg{Type: ev.Type, Err: fmt.Errorf("rate limit exceeded, retry in %.1fs", ev.RetryAfter.Seconds())}
 	}
+	return nil
 }
 
 func (c *wsClient) Subscribe(feedID string) error {
-	return c.send(map[string]interface{}{
-		"type": "subscribe-feed",
-		"payload": map[string]string{
-			"feedId": feedID,
-			"userId": c.userID,
-		},
-	})
+	return c.stream.Subscribe(feedID)
 }
 
 func (c *wsClient) Unsubscribe(feedID string) error {
-	return c.send(map[string]interface{}{
-		"type": "unsubscribe-feed",
-		"payload": map[string]string{
-			"feedId": feedID,
-			"userId": c.userID,
-		},
-	})
+	return c.stream.Unsubscribe(feedID)
 }
 
-// SendLLMQuery sends a query to the LLM service via WebSocket
-func (c *wsClient) SendLLMQuery(feedID, question, systemPrompt, requestID string) error {
-	return c.send(map[string]interface{}{
-		"type": "llm-query-stream",
-		"payload": map[string]string{
-			"feedId":       feedID,
-			"question":     question,
-			"systemPrompt": systemPrompt,
-			"requestId":    requestID,
-		},
+// SendLLMQuery sends a query to the LLM service via WebSocket.
+// A nil temperature or zero maxTokens leaves the provider default in place.
+func (c *wsClient) SendLLMQuery(feedID, question, systemPrompt, requestID string, temperature *float64, maxTokens int) error {
+	return c.stream.SendLLMQuery(client.LLMQuery{
+		FeedID:       feedID,
+		Question:     question,
+		SystemPrompt: systemPrompt,
+		RequestID:    requestID,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
 	})
 }
 
-// SendLLMStreamQuery sends a streaming query to the LLM service
-func (c *wsClient) SendLLMStreamQuery(feedID, question, requestID string) error {
-	return c.send(map[string]interface{}{
-		"type": "llm-query-stream",
-		"payload": map[string]string{
-			"feedId":    feedID,
-			"question":  question,
-			"requestId": requestID,
-		},
-	})
+// SendLLMCancel stops a streaming query; the backend answers with llm-cancelled
+func (c *wsClient) SendLLMCancel(requestID string) error {
+	return c.stream.CancelLLMQuery(requestID)
 }
 
-func (c *wsClient) send(msg interface{}) error {
-	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
-	defer cancel()
-	return wsjson.Write(ctx, c.conn, msg)
+// SendConnectionStats asks the backend for its view of this connection
+func (c *wsClient) SendConnectionStats() error {
+	return c.stream.RequestConnectionStats()
 }
 
 func (c *wsClient) Close() {
-	c.cancel()
-	_ = c.conn.Close(websocket.StatusNormalClosure, "bye")
+	c.stream.Close()
 }